@@ -268,22 +268,25 @@ func (s *HTTPServer) ACLPolicyCreate(resp http.ResponseWriter, req *http.Request
 	return s.ACLPolicyWrite(resp, req, "")
 }
 
-// fixCreateTimeAndHash is used to help in decoding the CreateTime and Hash
-// attributes from the ACL Token/Policy create/update requests. It is needed
-// to help mapstructure decode things properly when decodeBody is used.
-func fixCreateTimeAndHash(raw interface{}) error {
+// fixTimeAndHashFields is used to help in decoding the ExpirationTime,
+// CreateTime, and Hash attributes from the ACL Token/Policy/Role create/update
+// requests. It is needed to help mapstructure decode things properly when
+// decodeBody is used.
+func fixTimeAndHashFields(raw interface{}) error {
 	rawMap, ok := raw.(map[string]interface{})
 	if !ok {
 		return nil
 	}
 
-	if val, ok := rawMap["CreateTime"]; ok {
-		if sval, ok := val.(string); ok {
-			t, err := time.Parse(time.RFC3339, sval)
-			if err != nil {
-				return err
+	for _, field := range []string{"ExpirationTime", "CreateTime"} {
+		if val, ok := rawMap[field]; ok {
+			if sval, ok := val.(string); ok {
+				t, err := time.Parse(time.RFC3339, sval)
+				if err != nil {
+					return err
+				}
+				rawMap[field] = t
 			}
-			rawMap["CreateTime"] = t
 		}
 	}
 
@@ -301,7 +304,7 @@ func (s *HTTPServer) ACLPolicyWrite(resp http.ResponseWriter, req *http.Request,
 	}
 	s.parseToken(req, &args.Token)
 
-	if err := decodeBody(req, &args.Policy, fixCreateTimeAndHash); err != nil {
+	if err := decodeBody(req, &args.Policy, fixTimeAndHashFields); err != nil {
 		return nil, BadRequestError{Reason: fmt.Sprintf("Policy decoding failed: %v", err)}
 	}
 
@@ -336,6 +339,156 @@ func (s *HTTPServer) ACLPolicyDelete(resp http.ResponseWriter, req *http.Request
 	return true, nil
 }
 
+func (s *HTTPServer) ACLRoleList(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	var args structs.ACLRoleListRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	if args.Datacenter == "" {
+		args.Datacenter = s.agent.config.Datacenter
+	}
+
+	args.Policy = req.URL.Query().Get("policy")
+
+	var out structs.ACLRoleListResponse
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("ACL.RoleList", &args, &out); err != nil {
+		return nil, err
+	}
+
+	// make sure we return an array and not nil
+	if out.Roles == nil {
+		out.Roles = make(structs.ACLRoles, 0)
+	}
+
+	return out.Roles, nil
+}
+
+func (s *HTTPServer) ACLRoleCRUD(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	var fn func(resp http.ResponseWriter, req *http.Request, roleID string) (interface{}, error)
+
+	switch req.Method {
+	case "GET":
+		fn = s.ACLRoleReadByID
+
+	case "PUT":
+		fn = s.ACLRoleWrite
+
+	case "DELETE":
+		fn = s.ACLRoleDelete
+
+	default:
+		return nil, MethodNotAllowedError{req.Method, []string{"GET", "PUT", "DELETE"}}
+	}
+
+	roleID := strings.TrimPrefix(req.URL.Path, "/v1/acl/role/")
+	if roleID == "" && req.Method != "PUT" {
+		return nil, BadRequestError{Reason: "Missing role ID"}
+	}
+
+	return fn(resp, req, roleID)
+}
+
+func (s *HTTPServer) ACLRoleReadByName(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	roleName := strings.TrimPrefix(req.URL.Path, "/v1/acl/role/name/")
+	if roleName == "" {
+		return nil, BadRequestError{Reason: "Missing role Name"}
+	}
+
+	return s.ACLRoleRead(resp, req, roleName, structs.ACLRoleName)
+}
+
+func (s *HTTPServer) ACLRoleReadByID(resp http.ResponseWriter, req *http.Request, roleID string) (interface{}, error) {
+	return s.ACLRoleRead(resp, req, roleID, structs.ACLRoleID)
+}
+
+func (s *HTTPServer) ACLRoleRead(resp http.ResponseWriter, req *http.Request, roleID string, idType structs.ACLRoleIDType) (interface{}, error) {
+	args := structs.ACLRoleGetRequest{
+		Datacenter: s.agent.config.Datacenter,
+		RoleID:     roleID,
+		RoleIDType: idType,
+	}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	if args.Datacenter == "" {
+		args.Datacenter = s.agent.config.Datacenter
+	}
+
+	var out structs.ACLRoleResponse
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("ACL.RoleRead", &args, &out); err != nil {
+		return nil, err
+	}
+
+	if out.Role == nil {
+		return nil, acl.ErrNotFound
+	}
+
+	return out.Role, nil
+}
+
+func (s *HTTPServer) ACLRoleCreate(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	return s.ACLRoleWrite(resp, req, "")
+}
+
+func (s *HTTPServer) ACLRoleWrite(resp http.ResponseWriter, req *http.Request, roleID string) (interface{}, error) {
+	args := structs.ACLRoleSetRequest{
+		Datacenter: s.agent.config.Datacenter,
+	}
+	s.parseToken(req, &args.Token)
+
+	if err := decodeBody(req, &args.Role, fixTimeAndHashFields); err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("Role decoding failed: %v", err)}
+	}
+
+	if args.Role.ID != "" && args.Role.ID != roleID {
+		return nil, BadRequestError{Reason: "Role ID in URL and payload do not match"}
+	} else if args.Role.ID == "" {
+		args.Role.ID = roleID
+	}
+
+	var out structs.ACLRole
+	if err := s.agent.RPC("ACL.RoleSet", args, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (s *HTTPServer) ACLRoleDelete(resp http.ResponseWriter, req *http.Request, roleID string) (interface{}, error) {
+	args := structs.ACLRoleDeleteRequest{
+		Datacenter: s.agent.config.Datacenter,
+		RoleID:     roleID,
+	}
+	s.parseToken(req, &args.Token)
+
+	var ignored string
+	if err := s.agent.RPC("ACL.RoleDelete", args, &ignored); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
 func (s *HTTPServer) ACLTokenList(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if s.checkACLDisabled(resp, req) {
 		return nil, nil
@@ -354,6 +507,8 @@ func (s *HTTPServer) ACLTokenList(resp http.ResponseWriter, req *http.Request) (
 	}
 
 	args.Policy = req.URL.Query().Get("policy")
+	args.Role = req.URL.Query().Get("role")
+	args.AuthMethod = req.URL.Query().Get("authmethod")
 
 	var out structs.ACLTokenListResponse
 	defer setMeta(resp, &out.QueryMeta)
@@ -472,7 +627,7 @@ func (s *HTTPServer) ACLTokenSet(resp http.ResponseWriter, req *http.Request, to
 	}
 	s.parseToken(req, &args.Token)
 
-	if err := decodeBody(req, &args.ACLToken, fixCreateTimeAndHash); err != nil {
+	if err := decodeBody(req, &args.ACLToken, fixTimeAndHashFields); err != nil {
 		return nil, BadRequestError{Reason: fmt.Sprintf("Token decoding failed: %v", err)}
 	}
 
@@ -513,7 +668,7 @@ func (s *HTTPServer) ACLTokenClone(resp http.ResponseWriter, req *http.Request,
 		Datacenter: s.agent.config.Datacenter,
 	}
 
-	if err := decodeBody(req, &args.ACLToken, fixCreateTimeAndHash); err != nil && err.Error() != "EOF" {
+	if err := decodeBody(req, &args.ACLToken, fixTimeAndHashFields); err != nil && err.Error() != "EOF" {
 		return nil, BadRequestError{Reason: fmt.Sprintf("Token decoding failed: %v", err)}
 	}
 	s.parseToken(req, &args.Token)
@@ -528,3 +683,323 @@ func (s *HTTPServer) ACLTokenClone(resp http.ResponseWriter, req *http.Request,
 
 	return &out, nil
 }
+
+func (s *HTTPServer) ACLAuthMethodList(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	var args structs.ACLAuthMethodListRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	if args.Datacenter == "" {
+		args.Datacenter = s.agent.config.Datacenter
+	}
+
+	var out structs.ACLAuthMethodListResponse
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("ACL.AuthMethodList", &args, &out); err != nil {
+		return nil, err
+	}
+
+	// make sure we return an array and not nil
+	if out.AuthMethods == nil {
+		out.AuthMethods = make(structs.ACLAuthMethods, 0)
+	}
+
+	return out.AuthMethods, nil
+}
+
+func (s *HTTPServer) ACLAuthMethodCRUD(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	var fn func(resp http.ResponseWriter, req *http.Request, methodName string) (interface{}, error)
+
+	switch req.Method {
+	case "GET":
+		fn = s.ACLAuthMethodRead
+
+	case "PUT":
+		fn = s.ACLAuthMethodWrite
+
+	case "DELETE":
+		fn = s.ACLAuthMethodDelete
+
+	default:
+		return nil, MethodNotAllowedError{req.Method, []string{"GET", "PUT", "DELETE"}}
+	}
+
+	methodName := strings.TrimPrefix(req.URL.Path, "/v1/acl/auth-method/")
+	if methodName == "" && req.Method != "PUT" {
+		return nil, BadRequestError{Reason: "Missing auth method name"}
+	}
+
+	return fn(resp, req, methodName)
+}
+
+func (s *HTTPServer) ACLAuthMethodRead(resp http.ResponseWriter, req *http.Request, methodName string) (interface{}, error) {
+	args := structs.ACLAuthMethodGetRequest{
+		Datacenter:     s.agent.config.Datacenter,
+		AuthMethodName: methodName,
+	}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	if args.Datacenter == "" {
+		args.Datacenter = s.agent.config.Datacenter
+	}
+
+	var out structs.ACLAuthMethodResponse
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("ACL.AuthMethodRead", &args, &out); err != nil {
+		return nil, err
+	}
+
+	if out.AuthMethod == nil {
+		return nil, acl.ErrNotFound
+	}
+
+	return out.AuthMethod, nil
+}
+
+func (s *HTTPServer) ACLAuthMethodCreate(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	return s.ACLAuthMethodWrite(resp, req, "")
+}
+
+func (s *HTTPServer) ACLAuthMethodWrite(resp http.ResponseWriter, req *http.Request, methodName string) (interface{}, error) {
+	args := structs.ACLAuthMethodSetRequest{
+		Datacenter: s.agent.config.Datacenter,
+	}
+	s.parseToken(req, &args.Token)
+
+	if err := decodeBody(req, &args.AuthMethod, nil); err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("AuthMethod decoding failed: %v", err)}
+	}
+
+	if methodName != "" {
+		if args.AuthMethod.Name != "" && args.AuthMethod.Name != methodName {
+			return nil, BadRequestError{Reason: "AuthMethod Name in URL and payload do not match"}
+		} else if args.AuthMethod.Name == "" {
+			args.AuthMethod.Name = methodName
+		}
+	}
+
+	var out structs.ACLAuthMethod
+	if err := s.agent.RPC("ACL.AuthMethodSet", args, &out); err != nil {
+		return nil, err
+	}
+
+	// The reply isn't wrapped in a response type that fixes up the Config
+	// when it is decoded so do it here before rendering it as JSON.
+	if err := out.FixupConfig(); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (s *HTTPServer) ACLAuthMethodDelete(resp http.ResponseWriter, req *http.Request, methodName string) (interface{}, error) {
+	args := structs.ACLAuthMethodDeleteRequest{
+		Datacenter:     s.agent.config.Datacenter,
+		AuthMethodName: methodName,
+	}
+	s.parseToken(req, &args.Token)
+
+	var ignored bool
+	if err := s.agent.RPC("ACL.AuthMethodDelete", args, &ignored); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+func (s *HTTPServer) ACLBindingRuleList(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	var args structs.ACLBindingRuleListRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	if args.Datacenter == "" {
+		args.Datacenter = s.agent.config.Datacenter
+	}
+
+	args.AuthMethod = req.URL.Query().Get("authmethod")
+
+	var out structs.ACLBindingRuleListResponse
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("ACL.BindingRuleList", &args, &out); err != nil {
+		return nil, err
+	}
+
+	// make sure we return an array and not nil
+	if out.BindingRules == nil {
+		out.BindingRules = make(structs.ACLBindingRules, 0)
+	}
+
+	return out.BindingRules, nil
+}
+
+func (s *HTTPServer) ACLBindingRuleCRUD(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	var fn func(resp http.ResponseWriter, req *http.Request, bindingRuleID string) (interface{}, error)
+
+	switch req.Method {
+	case "GET":
+		fn = s.ACLBindingRuleRead
+
+	case "PUT":
+		fn = s.ACLBindingRuleWrite
+
+	case "DELETE":
+		fn = s.ACLBindingRuleDelete
+
+	default:
+		return nil, MethodNotAllowedError{req.Method, []string{"GET", "PUT", "DELETE"}}
+	}
+
+	bindingRuleID := strings.TrimPrefix(req.URL.Path, "/v1/acl/binding-rule/")
+	if bindingRuleID == "" && req.Method != "PUT" {
+		return nil, BadRequestError{Reason: "Missing binding rule ID"}
+	}
+
+	return fn(resp, req, bindingRuleID)
+}
+
+func (s *HTTPServer) ACLBindingRuleRead(resp http.ResponseWriter, req *http.Request, bindingRuleID string) (interface{}, error) {
+	args := structs.ACLBindingRuleGetRequest{
+		Datacenter:    s.agent.config.Datacenter,
+		BindingRuleID: bindingRuleID,
+	}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	if args.Datacenter == "" {
+		args.Datacenter = s.agent.config.Datacenter
+	}
+
+	var out structs.ACLBindingRuleResponse
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("ACL.BindingRuleRead", &args, &out); err != nil {
+		return nil, err
+	}
+
+	if out.BindingRule == nil {
+		return nil, acl.ErrNotFound
+	}
+
+	return out.BindingRule, nil
+}
+
+func (s *HTTPServer) ACLBindingRuleCreate(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	return s.ACLBindingRuleWrite(resp, req, "")
+}
+
+func (s *HTTPServer) ACLBindingRuleWrite(resp http.ResponseWriter, req *http.Request, bindingRuleID string) (interface{}, error) {
+	args := structs.ACLBindingRuleSetRequest{
+		Datacenter: s.agent.config.Datacenter,
+	}
+	s.parseToken(req, &args.Token)
+
+	if err := decodeBody(req, &args.BindingRule, nil); err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("BindingRule decoding failed: %v", err)}
+	}
+
+	if args.BindingRule.ID != "" && args.BindingRule.ID != bindingRuleID {
+		return nil, BadRequestError{Reason: "BindingRule ID in URL and payload do not match"}
+	} else if args.BindingRule.ID == "" {
+		args.BindingRule.ID = bindingRuleID
+	}
+
+	var out structs.ACLBindingRule
+	if err := s.agent.RPC("ACL.BindingRuleSet", args, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func (s *HTTPServer) ACLBindingRuleDelete(resp http.ResponseWriter, req *http.Request, bindingRuleID string) (interface{}, error) {
+	args := structs.ACLBindingRuleDeleteRequest{
+		Datacenter:    s.agent.config.Datacenter,
+		BindingRuleID: bindingRuleID,
+	}
+	s.parseToken(req, &args.Token)
+
+	var ignored bool
+	if err := s.agent.RPC("ACL.BindingRuleDelete", args, &ignored); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+// ACLLogin exchanges a bearer token accepted by an auth method for a new
+// local ACL token. Any ACL token sent along with the request is ignored.
+func (s *HTTPServer) ACLLogin(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	args := &structs.ACLLoginRequest{
+		Datacenter: s.agent.config.Datacenter,
+		Auth:       &structs.ACLLoginParams{},
+	}
+	s.parseDC(req, &args.Datacenter)
+
+	if err := decodeBody(req, args.Auth, nil); err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("Failed to decode request body: %v", err)}
+	}
+
+	var out structs.ACLToken
+	if err := s.agent.RPC("ACL.Login", args, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// ACLLogout deletes the token making the request, which must have been
+// created by ACLLogin.
+func (s *HTTPServer) ACLLogout(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.checkACLDisabled(resp, req) {
+		return nil, nil
+	}
+
+	args := structs.ACLLogoutRequest{
+		Datacenter: s.agent.config.Datacenter,
+	}
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+
+	if args.Token == "" {
+		return nil, acl.ErrNotFound
+	}
+
+	var ignored bool
+	if err := s.agent.RPC("ACL.Logout", &args, &ignored); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
@@ -7,7 +7,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/authmethod/testauth"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/stretchr/testify/require"
@@ -36,10 +39,22 @@ func TestACL_Disabled_Response(t *testing.T) {
 		{"ACLPolicyList", a.srv.ACLPolicyList},
 		{"ACLPolicyCRUD", a.srv.ACLPolicyCRUD},
 		{"ACLPolicyCreate", a.srv.ACLPolicyCreate},
+		{"ACLRoleList", a.srv.ACLRoleList},
+		{"ACLRoleCRUD", a.srv.ACLRoleCRUD},
+		{"ACLRoleCreate", a.srv.ACLRoleCreate},
+		{"ACLRoleReadByName", a.srv.ACLRoleReadByName},
 		{"ACLTokenList", a.srv.ACLTokenList},
 		{"ACLTokenCreate", a.srv.ACLTokenCreate},
 		{"ACLTokenSelf", a.srv.ACLTokenSelf},
 		{"ACLTokenCRUD", a.srv.ACLTokenCRUD},
+		{"ACLAuthMethodList", a.srv.ACLAuthMethodList},
+		{"ACLAuthMethodCreate", a.srv.ACLAuthMethodCreate},
+		{"ACLAuthMethodCRUD", a.srv.ACLAuthMethodCRUD},
+		{"ACLBindingRuleList", a.srv.ACLBindingRuleList},
+		{"ACLBindingRuleCreate", a.srv.ACLBindingRuleCreate},
+		{"ACLBindingRuleCRUD", a.srv.ACLBindingRuleCRUD},
+		{"ACLLogin", a.srv.ACLLogin},
+		{"ACLLogout", a.srv.ACLLogout},
 	}
 	testrpc.WaitForLeader(t, a.RPC, "dc1")
 	for _, tt := range tests {
@@ -119,6 +134,7 @@ func TestACL_HTTP(t *testing.T) {
 
 	idMap := make(map[string]string)
 	policyMap := make(map[string]*structs.ACLPolicy)
+	roleMap := make(map[string]*structs.ACLRole)
 	tokenMap := make(map[string]*structs.ACLToken)
 
 	// This is all done as a subtest for a couple reasons
@@ -355,6 +371,226 @@ func TestACL_HTTP(t *testing.T) {
 		})
 	})
 
+	t.Run("Role", func(t *testing.T) {
+		t.Run("Create", func(t *testing.T) {
+			roleInput := &structs.ACLRole{
+				Name:        "test",
+				Description: "test",
+				Policies: []structs.ACLRolePolicyLink{
+					structs.ACLRolePolicyLink{
+						ID:   idMap["policy-test"],
+						Name: policyMap[idMap["policy-test"]].Name,
+					},
+					structs.ACLRolePolicyLink{
+						ID:   idMap["policy-read-all-nodes"],
+						Name: policyMap[idMap["policy-read-all-nodes"]].Name,
+					},
+				},
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/role?token=root", jsonBody(roleInput))
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLRoleCreate(resp, req)
+			require.NoError(t, err)
+
+			role, ok := obj.(*structs.ACLRole)
+			require.True(t, ok)
+
+			// 36 = length of the string form of uuids
+			require.Len(t, role.ID, 36)
+			require.Equal(t, roleInput.Name, role.Name)
+			require.Equal(t, roleInput.Description, role.Description)
+			require.Equal(t, roleInput.Policies, role.Policies)
+			require.True(t, role.CreateIndex > 0)
+			require.Equal(t, role.CreateIndex, role.ModifyIndex)
+			require.NotNil(t, role.Hash)
+			require.NotEqual(t, role.Hash, []byte{})
+
+			idMap["role-test"] = role.ID
+			roleMap[role.ID] = role
+		})
+
+		t.Run("Name Chars", func(t *testing.T) {
+			roleInput := &structs.ACLRole{
+				Name: "service-id-web",
+				Policies: []structs.ACLRolePolicyLink{
+					structs.ACLRolePolicyLink{
+						ID: idMap["policy-read-all-nodes"],
+					},
+				},
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/role?token=root", jsonBody(roleInput))
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLRoleCreate(resp, req)
+			require.NoError(t, err)
+
+			role, ok := obj.(*structs.ACLRole)
+			require.True(t, ok)
+
+			// 36 = length of the string form of uuids
+			require.Len(t, role.ID, 36)
+			require.Equal(t, roleInput.Name, role.Name)
+			require.Len(t, role.Policies, 1)
+			require.Equal(t, idMap["policy-read-all-nodes"], role.Policies[0].ID)
+			require.Equal(t, "read-all-nodes", role.Policies[0].Name)
+
+			idMap["role-service-id-web"] = role.ID
+			roleMap[role.ID] = role
+		})
+
+		t.Run("Invalid Name", func(t *testing.T) {
+			roleInput := &structs.ACLRole{
+				Name: "invalid name",
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/role?token=root", jsonBody(roleInput))
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLRoleCreate(resp, req)
+			require.Error(t, err)
+		})
+
+		t.Run("Update Name ID Mistmatch", func(t *testing.T) {
+			roleInput := &structs.ACLRole{
+				ID:          "ac7560be-7f11-4d6d-bfcf-15633c2090fd",
+				Name:        "test",
+				Description: "test",
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/role/"+idMap["role-test"]+"?token=root", jsonBody(roleInput))
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLRoleCRUD(resp, req)
+			require.Error(t, err)
+			_, ok := err.(BadRequestError)
+			require.True(t, ok)
+		})
+
+		t.Run("Role CRUD Missing ID in URL", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/role/?token=root", nil)
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLRoleCRUD(resp, req)
+			require.Error(t, err)
+			_, ok := err.(BadRequestError)
+			require.True(t, ok)
+		})
+
+		t.Run("Update", func(t *testing.T) {
+			roleInput := &structs.ACLRole{
+				Name:        "test",
+				Description: "an updated description",
+				Policies: []structs.ACLRolePolicyLink{
+					structs.ACLRolePolicyLink{
+						ID:   idMap["policy-test"],
+						Name: policyMap[idMap["policy-test"]].Name,
+					},
+				},
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/role/"+idMap["role-test"]+"?token=root", jsonBody(roleInput))
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLRoleCRUD(resp, req)
+			require.NoError(t, err)
+
+			role, ok := obj.(*structs.ACLRole)
+			require.True(t, ok)
+
+			require.Equal(t, idMap["role-test"], role.ID)
+			require.Equal(t, roleInput.Name, role.Name)
+			require.Equal(t, roleInput.Description, role.Description)
+			require.Equal(t, roleInput.Policies, role.Policies)
+			require.True(t, role.CreateIndex > 0)
+			require.True(t, role.CreateIndex < role.ModifyIndex)
+			require.NotNil(t, role.Hash)
+			require.NotEqual(t, role.Hash, []byte{})
+
+			roleMap[role.ID] = role
+		})
+
+		t.Run("ID Supplied", func(t *testing.T) {
+			roleInput := &structs.ACLRole{
+				ID:          "12123d01-37f1-47e6-b55b-32328652bd38",
+				Name:        "with-id",
+				Description: "test",
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/role?token=root", jsonBody(roleInput))
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLRoleCreate(resp, req)
+			require.Error(t, err)
+			_, ok := err.(BadRequestError)
+			require.True(t, ok)
+		})
+
+		t.Run("Invalid payload", func(t *testing.T) {
+			body := bytes.NewBuffer(nil)
+			body.Write([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/role?token=root", body)
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLRoleCreate(resp, req)
+			require.Error(t, err)
+			_, ok := err.(BadRequestError)
+			require.True(t, ok)
+		})
+
+		t.Run("Delete", func(t *testing.T) {
+			req, _ := http.NewRequest("DELETE", "/v1/acl/role/"+idMap["role-service-id-web"]+"?token=root", nil)
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLRoleCRUD(resp, req)
+			require.NoError(t, err)
+			delete(roleMap, idMap["role-service-id-web"])
+			delete(idMap, "role-service-id-web")
+		})
+
+		t.Run("List", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/roles?token=root", nil)
+			resp := httptest.NewRecorder()
+			raw, err := a.srv.ACLRoleList(resp, req)
+			require.NoError(t, err)
+			roles, ok := raw.(structs.ACLRoles)
+			require.True(t, ok)
+			require.Len(t, roles, 1)
+			require.Equal(t, roleMap[idMap["role-test"]], roles[0])
+		})
+
+		t.Run("List by Policy", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/roles?token=root&policy="+idMap["policy-read-all-nodes"], nil)
+			resp := httptest.NewRecorder()
+			raw, err := a.srv.ACLRoleList(resp, req)
+			require.NoError(t, err)
+			roles, ok := raw.(structs.ACLRoles)
+			require.True(t, ok)
+			require.Len(t, roles, 0)
+		})
+
+		t.Run("Read", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/role/"+idMap["role-test"]+"?token=root", nil)
+			resp := httptest.NewRecorder()
+			raw, err := a.srv.ACLRoleCRUD(resp, req)
+			require.NoError(t, err)
+			role, ok := raw.(*structs.ACLRole)
+			require.True(t, ok)
+			require.Equal(t, roleMap[idMap["role-test"]], role)
+		})
+
+		t.Run("Read By Name", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/role/name/test?token=root", nil)
+			resp := httptest.NewRecorder()
+			raw, err := a.srv.ACLRoleReadByName(resp, req)
+			require.NoError(t, err)
+			role, ok := raw.(*structs.ACLRole)
+			require.True(t, ok)
+			require.Equal(t, roleMap[idMap["role-test"]], role)
+		})
+
+		t.Run("Read Missing", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/role/name/nope?token=root", nil)
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLRoleReadByName(resp, req)
+			require.Error(t, err)
+		})
+	})
+
 	t.Run("Token", func(t *testing.T) {
 		t.Run("Create", func(t *testing.T) {
 			tokenInput := &structs.ACLToken{
@@ -592,5 +828,293 @@ func TestACL_HTTP(t *testing.T) {
 			require.Len(t, token.Policies, 1)
 			require.Equal(t, structs.ACLPolicyGlobalManagementID, token.Policies[0].ID)
 		})
+		t.Run("Create With Role", func(t *testing.T) {
+			tokenInput := &structs.ACLToken{
+				Description: "role token",
+				Roles: []structs.ACLTokenRoleLink{
+					structs.ACLTokenRoleLink{
+						Name: roleMap[idMap["role-test"]].Name,
+					},
+				},
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/token?token=root", jsonBody(tokenInput))
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLTokenCreate(resp, req)
+			require.NoError(t, err)
+
+			token, ok := obj.(*structs.ACLToken)
+			require.True(t, ok)
+			require.Len(t, token.Roles, 1)
+			require.Equal(t, idMap["role-test"], token.Roles[0].ID)
+			require.Equal(t, "test", token.Roles[0].Name)
+
+			idMap["token-role"] = token.AccessorID
+		})
+		t.Run("List by Role", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/tokens?token=root&role="+idMap["role-test"], nil)
+			resp := httptest.NewRecorder()
+			raw, err := a.srv.ACLTokenList(resp, req)
+			require.NoError(t, err)
+			tokens, ok := raw.(structs.ACLTokenListStubs)
+			require.True(t, ok)
+			require.Len(t, tokens, 1)
+			require.Equal(t, idMap["token-role"], tokens[0].AccessorID)
+			require.Equal(t, "role token", tokens[0].Description)
+		})
+		t.Run("Create With Expiration TTL", func(t *testing.T) {
+			tokenInput := map[string]interface{}{
+				"Description":   "expiring token",
+				"ExpirationTTL": "1h",
+				"Policies": []map[string]string{
+					{"ID": idMap["policy-test"]},
+				},
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/token?token=root", jsonBody(tokenInput))
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLTokenCreate(resp, req)
+			require.NoError(t, err)
+
+			token, ok := obj.(*structs.ACLToken)
+			require.True(t, ok)
+			require.Equal(t, time.Duration(0), token.ExpirationTTL)
+			require.NotNil(t, token.ExpirationTime)
+			require.Equal(t, token.CreateTime.Add(time.Hour), *token.ExpirationTime)
+
+			idMap["token-expiring"] = token.AccessorID
+			tokenMap[token.AccessorID] = token
+		})
+		t.Run("Update With Expiration Time", func(t *testing.T) {
+			original := tokenMap[idMap["token-expiring"]]
+
+			tokenInput := map[string]interface{}{
+				"Description":    "still expiring",
+				"ExpirationTime": original.ExpirationTime.Format(time.RFC3339Nano),
+				"Policies": []map[string]string{
+					{"ID": idMap["policy-test"]},
+				},
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/token/"+original.AccessorID+"?token=root", jsonBody(tokenInput))
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLTokenCRUD(resp, req)
+			require.NoError(t, err)
+
+			token, ok := obj.(*structs.ACLToken)
+			require.True(t, ok)
+			require.Equal(t, "still expiring", token.Description)
+			require.True(t, original.ExpirationTime.Equal(*token.ExpirationTime))
+
+			tokenInput["ExpirationTime"] = original.ExpirationTime.Add(time.Minute).Format(time.RFC3339Nano)
+			req, _ = http.NewRequest("PUT", "/v1/acl/token/"+original.AccessorID+"?token=root", jsonBody(tokenInput))
+			resp = httptest.NewRecorder()
+			_, err = a.srv.ACLTokenCRUD(resp, req)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "Cannot change expiration time")
+		})
+	})
+}
+
+func TestACL_LoginProcedure_HTTP(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), TestACLConfig())
+	defer a.Shutdown()
+
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	testSessionID := testauth.StartSession()
+	defer testauth.ResetSession(testSessionID)
+	testauth.InstallSessionToken(testSessionID, "fake-web", map[string]string{
+		"service": "web",
+	})
+
+	idMap := make(map[string]string)
+
+	t.Run("AuthMethod", func(t *testing.T) {
+		t.Run("Create", func(t *testing.T) {
+			methodInput := &structs.ACLAuthMethod{
+				Name:        "test",
+				Type:        testauth.Type,
+				Description: "test",
+				Config: map[string]interface{}{
+					"SessionID": testSessionID,
+					"Fields":    []string{"service"},
+				},
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/auth-method?token=root", jsonBody(methodInput))
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLAuthMethodCreate(resp, req)
+			require.NoError(t, err)
+
+			method, ok := obj.(*structs.ACLAuthMethod)
+			require.True(t, ok)
+			require.Equal(t, methodInput.Name, method.Name)
+			require.Equal(t, methodInput.Type, method.Type)
+			require.Equal(t, testSessionID, method.Config["SessionID"])
+			require.True(t, method.CreateIndex > 0)
+		})
+
+		t.Run("Name Mismatch", func(t *testing.T) {
+			methodInput := &structs.ACLAuthMethod{
+				Name: "other",
+				Type: testauth.Type,
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/auth-method/test?token=root", jsonBody(methodInput))
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLAuthMethodCRUD(resp, req)
+			require.Error(t, err)
+			_, ok := err.(BadRequestError)
+			require.True(t, ok)
+		})
+
+		t.Run("Read", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/auth-method/test?token=root", nil)
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLAuthMethodCRUD(resp, req)
+			require.NoError(t, err)
+			method, ok := obj.(*structs.ACLAuthMethod)
+			require.True(t, ok)
+			require.Equal(t, "test", method.Description)
+		})
+
+		t.Run("Read Missing", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/auth-method/not-found?token=root", nil)
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLAuthMethodCRUD(resp, req)
+			require.True(t, acl.IsErrNotFound(err))
+		})
+
+		t.Run("List", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/auth-methods?token=root", nil)
+			resp := httptest.NewRecorder()
+			raw, err := a.srv.ACLAuthMethodList(resp, req)
+			require.NoError(t, err)
+			methods, ok := raw.(structs.ACLAuthMethods)
+			require.True(t, ok)
+			require.Len(t, methods, 1)
+			require.Equal(t, "test", methods[0].Name)
+		})
+	})
+
+	t.Run("BindingRule", func(t *testing.T) {
+		t.Run("Create", func(t *testing.T) {
+			ruleInput := &structs.ACLBindingRule{
+				Description: "test",
+				AuthMethod:  "test",
+				Selector:    "value.service == web",
+				BindType:    structs.BindingRuleBindTypeService,
+				BindName:    "${value.service}",
+			}
+
+			req, _ := http.NewRequest("PUT", "/v1/acl/binding-rule?token=root", jsonBody(ruleInput))
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLBindingRuleCreate(resp, req)
+			require.NoError(t, err)
+
+			rule, ok := obj.(*structs.ACLBindingRule)
+			require.True(t, ok)
+			require.Len(t, rule.ID, 36)
+			require.Equal(t, ruleInput.Selector, rule.Selector)
+			require.Equal(t, ruleInput.BindName, rule.BindName)
+
+			idMap["rule-test"] = rule.ID
+		})
+
+		t.Run("Read", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/binding-rule/"+idMap["rule-test"]+"?token=root", nil)
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLBindingRuleCRUD(resp, req)
+			require.NoError(t, err)
+			rule, ok := obj.(*structs.ACLBindingRule)
+			require.True(t, ok)
+			require.Equal(t, "test", rule.AuthMethod)
+		})
+
+		t.Run("List by AuthMethod", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/binding-rules?token=root&authmethod=test", nil)
+			resp := httptest.NewRecorder()
+			raw, err := a.srv.ACLBindingRuleList(resp, req)
+			require.NoError(t, err)
+			rules, ok := raw.(structs.ACLBindingRules)
+			require.True(t, ok)
+			require.Len(t, rules, 1)
+			require.Equal(t, idMap["rule-test"], rules[0].ID)
+		})
+	})
+
+	t.Run("Login", func(t *testing.T) {
+		t.Run("Bad Bearer Token", func(t *testing.T) {
+			loginInput := &structs.ACLLoginParams{
+				AuthMethod:  "test",
+				BearerToken: "fake-unknown",
+			}
+
+			req, _ := http.NewRequest("POST", "/v1/acl/login", jsonBody(loginInput))
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLLogin(resp, req)
+			require.True(t, acl.IsErrPermissionDenied(err))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			loginInput := &structs.ACLLoginParams{
+				AuthMethod:  "test",
+				BearerToken: "fake-web",
+			}
+
+			req, _ := http.NewRequest("POST", "/v1/acl/login", jsonBody(loginInput))
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLLogin(resp, req)
+			require.NoError(t, err)
+
+			token, ok := obj.(*structs.ACLToken)
+			require.True(t, ok)
+			require.True(t, token.Local)
+			require.Equal(t, "test", token.AuthMethod)
+			require.Len(t, token.ServiceIdentities, 1)
+			require.Equal(t, "web", token.ServiceIdentities[0].ServiceName)
+
+			idMap["token-login"] = token.AccessorID
+			idMap["secret-login"] = token.SecretID
+		})
+
+		t.Run("List Tokens by AuthMethod", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/v1/acl/tokens?token=root&authmethod=test", nil)
+			resp := httptest.NewRecorder()
+			raw, err := a.srv.ACLTokenList(resp, req)
+			require.NoError(t, err)
+			tokens, ok := raw.(structs.ACLTokenListStubs)
+			require.True(t, ok)
+			require.Len(t, tokens, 1)
+			require.Equal(t, idMap["token-login"], tokens[0].AccessorID)
+			require.Equal(t, "test", tokens[0].AuthMethod)
+		})
+
+		t.Run("Logout", func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/v1/acl/logout", nil)
+			req.Header.Add("X-Consul-Token", idMap["secret-login"])
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ACLLogout(resp, req)
+			require.NoError(t, err)
+
+			req, _ = http.NewRequest("GET", "/v1/acl/token/"+idMap["token-login"]+"?token=root", nil)
+			resp = httptest.NewRecorder()
+			_, err = a.srv.ACLTokenCRUD(resp, req)
+			require.True(t, acl.IsErrNotFound(err))
+		})
+	})
+
+	t.Run("Delete AuthMethod", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/v1/acl/auth-method/test?token=root", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.ACLAuthMethodCRUD(resp, req)
+		require.NoError(t, err)
+
+		req, _ = http.NewRequest("GET", "/v1/acl/binding-rule/"+idMap["rule-test"]+"?token=root", nil)
+		resp = httptest.NewRecorder()
+		_, err = a.srv.ACLBindingRuleCRUD(resp, req)
+		require.True(t, acl.IsErrNotFound(err))
 	})
 }
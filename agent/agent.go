@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha512"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -66,6 +67,9 @@ const (
 	// Name of the file tokens will be persisted within
 	tokensPath = "acl-tokens.json"
 
+	// Path to save the agent cache snapshot
+	cacheSnapshotPath = "cache/snapshot"
+
 	// Default reasons for node/service maintenance mode
 	defaultNodeMaintReason = "Maintenance mode is enabled for this node, " +
 		"but no reason was provided. This is a default message."
@@ -163,9 +167,15 @@ type Agent struct {
 	// checkHTTPs maps the check ID to an associated HTTP check
 	checkHTTPs map[types.CheckID]*checks.CheckHTTP
 
+	// checkH2Cs maps the check ID to an associated H2C check
+	checkH2Cs map[types.CheckID]*checks.CheckH2C
+
 	// checkTCPs maps the check ID to an associated TCP check
 	checkTCPs map[types.CheckID]*checks.CheckTCP
 
+	// checkUDPs maps the check ID to an associated UDP check
+	checkUDPs map[types.CheckID]*checks.CheckUDP
+
 	// checkGRPCs maps the check ID to an associated GRPC check
 	checkGRPCs map[types.CheckID]*checks.CheckGRPC
 
@@ -175,6 +185,9 @@ type Agent struct {
 	// checkDockers maps the check ID to an associated Docker Exec based check
 	checkDockers map[types.CheckID]*checks.CheckDocker
 
+	// checkOSServices maps the check ID to an associated OS service check
+	checkOSServices map[types.CheckID]*checks.CheckOSService
+
 	// checkAliases maps the check ID to an associated Alias checks
 	checkAliases map[types.CheckID]*checks.CheckAlias
 
@@ -184,6 +197,10 @@ type Agent struct {
 	// dockerClient is the client for performing docker health checks.
 	dockerClient *checks.DockerClient
 
+	// osServiceClient is the client for performing OS service health
+	// checks.
+	osServiceClient checks.OSServiceClient
+
 	// eventCh is used to receive user events
 	eventCh chan serf.UserEvent
 
@@ -274,9 +291,12 @@ func New(c *config.RuntimeConfig) (*Agent, error) {
 		checkMonitors:   make(map[types.CheckID]*checks.CheckMonitor),
 		checkTTLs:       make(map[types.CheckID]*checks.CheckTTL),
 		checkHTTPs:      make(map[types.CheckID]*checks.CheckHTTP),
+		checkH2Cs:       make(map[types.CheckID]*checks.CheckH2C),
 		checkTCPs:       make(map[types.CheckID]*checks.CheckTCP),
+		checkUDPs:       make(map[types.CheckID]*checks.CheckUDP),
 		checkGRPCs:      make(map[types.CheckID]*checks.CheckGRPC),
 		checkDockers:    make(map[types.CheckID]*checks.CheckDocker),
+		checkOSServices: make(map[types.CheckID]*checks.CheckOSService),
 		checkAliases:    make(map[types.CheckID]*checks.CheckAlias),
 		eventCh:         make(chan serf.UserEvent, 1024),
 		eventBuf:        make([]*UserEvent, 256),
@@ -380,7 +400,16 @@ func (a *Agent) Start() error {
 	a.sync = ae.NewStateSyncer(a.State, c.AEInterval, a.shutdownCh, a.logger)
 
 	// create the cache
-	a.cache = cache.New(nil)
+	cacheOpts := &cache.Options{
+		Logger:     a.logger,
+		MaxEntries: c.CacheMaxEntries,
+		MaxBytes:   int64(c.CacheMaxBytes),
+	}
+	if c.CachePersist {
+		cacheOpts.SnapshotPath = filepath.Join(c.DataDir, cacheSnapshotPath)
+		cacheOpts.SnapshotInterval = c.CachePersistInterval
+	}
+	a.cache = cache.New(cacheOpts)
 
 	// create the config for the rpc server/client
 	consulCfg, err := a.consulConfig()
@@ -428,6 +457,14 @@ func (a *Agent) Start() error {
 	// populated from above.
 	a.registerCache()
 
+	// Restore the cache entries from the last run so they can be served
+	// until the servers can be reached.
+	if n, err := a.cache.LoadSnapshot(); err != nil {
+		a.logger.Printf("[WARN] agent: failed to load cache snapshot: %v", err)
+	} else if n > 0 {
+		a.logger.Printf("[INFO] agent: restored %d cache entries from snapshot", n)
+	}
+
 	// Load checks/services/metadata.
 	if err := a.loadServices(c); err != nil {
 		return err
@@ -571,9 +608,23 @@ func (a *Agent) listenAndServeGRPC() error {
 }
 
 func (a *Agent) listenAndServeDNS() error {
-	notif := make(chan net.Addr, len(a.config.DNSAddrs))
-	errCh := make(chan error, len(a.config.DNSAddrs))
+	// DNS-over-TLS servers listen on TCP addresses but need to be started
+	// with their own network name.
+	type dnsListener struct {
+		network string
+		addr    net.Addr
+	}
+	var listeners []dnsListener
 	for _, addr := range a.config.DNSAddrs {
+		listeners = append(listeners, dnsListener{addr.Network(), addr})
+	}
+	for _, addr := range a.config.DNSTLSAddrs {
+		listeners = append(listeners, dnsListener{"tcp-tls", addr})
+	}
+
+	notif := make(chan dnsListener, len(listeners))
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
 		// create server
 		s, err := NewDNSServer(a)
 		if err != nil {
@@ -583,22 +634,22 @@ func (a *Agent) listenAndServeDNS() error {
 
 		// start server
 		a.wgServers.Add(1)
-		go func(addr net.Addr) {
+		go func(l dnsListener) {
 			defer a.wgServers.Done()
-			err := s.ListenAndServe(addr.Network(), addr.String(), func() { notif <- addr })
+			err := s.ListenAndServe(l.network, l.addr.String(), func() { notif <- l })
 			if err != nil && !strings.Contains(err.Error(), "accept") {
 				errCh <- err
 			}
-		}(addr)
+		}(l)
 	}
 
 	// wait for servers to be up
 	timeout := time.After(time.Second)
 	var merr *multierror.Error
-	for range a.config.DNSAddrs {
+	for range listeners {
 		select {
-		case addr := <-notif:
-			a.logger.Printf("[INFO] agent: Started DNS server %s (%s)", addr.String(), addr.Network())
+		case l := <-notif:
+			a.logger.Printf("[INFO] agent: Started DNS server %s (%s)", l.addr.String(), l.network)
 
 		case err := <-errCh:
 			merr = multierror.Append(merr, err)
@@ -679,6 +730,13 @@ func (a *Agent) listenHTTP() ([]*HTTPServer, error) {
 				blacklist: NewBlacklist(a.config.HTTPBlockEndpoints),
 				proto:     proto,
 			}
+			if proto == "https" && a.config.DNSEnableDoH {
+				dnsServer, err := NewDNSServer(a)
+				if err != nil {
+					return err
+				}
+				srv.dnsHandler = dnsServer.handler()
+			}
 			srv.Server.Handler = srv.handler(a.config.EnableDebug)
 
 			// This will enable upgrading connections to HTTP/2 as
@@ -996,6 +1054,9 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	if a.config.ACLPolicyTTL != 0 {
 		base.ACLPolicyTTL = a.config.ACLPolicyTTL
 	}
+	if a.config.ACLRoleTTL != 0 {
+		base.ACLRoleTTL = a.config.ACLRoleTTL
+	}
 	if a.config.ACLDefaultPolicy != "" {
 		base.ACLDefaultPolicy = a.config.ACLDefaultPolicy
 	}
@@ -1011,6 +1072,7 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	if a.config.SessionTTLMin != 0 {
 		base.SessionTTLMin = a.config.SessionTTLMin
 	}
+	base.KVSHistory = a.config.KVHistory
 	if a.config.NonVotingServer {
 		base.NonVoter = a.config.NonVotingServer
 	}
@@ -1037,6 +1099,21 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	if a.config.RPCMaxBurst > 0 {
 		base.RPCMaxBurst = a.config.RPCMaxBurst
 	}
+	if a.config.RPCServerRateLimit > 0 {
+		base.RPCServerRate = a.config.RPCServerRateLimit
+	}
+	if a.config.RPCServerMaxBurst > 0 {
+		base.RPCServerMaxBurst = a.config.RPCServerMaxBurst
+	}
+	if a.config.RPCServerSourceRateLimit > 0 {
+		base.RPCServerSourceRate = a.config.RPCServerSourceRateLimit
+	}
+	if a.config.RPCServerSourceMaxBurst > 0 {
+		base.RPCServerSourceMaxBurst = a.config.RPCServerSourceMaxBurst
+	}
+	if a.config.RPCMaxBlockingQueries > 0 {
+		base.RPCMaxBlockingQueries = a.config.RPCMaxBlockingQueries
+	}
 
 	// RPC-related performance configs.
 	if a.config.RPCHoldTimeout > 0 {
@@ -1450,15 +1527,27 @@ func (a *Agent) ShutdownAgent() error {
 	for _, chk := range a.checkHTTPs {
 		chk.Stop()
 	}
+	for _, chk := range a.checkH2Cs {
+		chk.Stop()
+	}
 	for _, chk := range a.checkTCPs {
 		chk.Stop()
 	}
+	for _, chk := range a.checkUDPs {
+		chk.Stop()
+	}
 	for _, chk := range a.checkGRPCs {
 		chk.Stop()
 	}
 	for _, chk := range a.checkDockers {
 		chk.Stop()
 	}
+	for _, chk := range a.checkOSServices {
+		chk.Stop()
+	}
+	if c, ok := a.osServiceClient.(*checks.SystemdClient); ok {
+		c.Close()
+	}
 	for _, chk := range a.checkAliases {
 		chk.Stop()
 	}
@@ -1493,7 +1582,9 @@ func (a *Agent) ShutdownAgent() error {
 
 	// Stop the cache background work
 	if a.cache != nil {
-		a.cache.Close()
+		if err := a.cache.Close(); err != nil {
+			a.logger.Printf("[WARN] agent: failed to save cache snapshot: %v", err)
+		}
 	}
 
 	var err error
@@ -2180,11 +2271,24 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 		return fmt.Errorf("CheckID missing")
 	}
 
+	var tlsClientConfig *tls.Config
 	if chkType != nil {
 		if err := chkType.Validate(); err != nil {
 			return fmt.Errorf("Check is not valid: %v", err)
 		}
 
+		if err := a.vetCheckTLSFiles(chkType, source); err != nil {
+			return err
+		}
+
+		if chkType.IsHTTP() || (chkType.IsGRPC() && chkType.GRPCUseTLS) {
+			var err error
+			tlsClientConfig, err = a.checkTLSConfig(chkType, service)
+			if err != nil {
+				return fmt.Errorf("Check is not valid: %v", err)
+			}
+		}
+
 		if chkType.IsScript() {
 			if source == ConfigSourceLocal && !a.config.EnableLocalScriptChecks {
 				return fmt.Errorf("Scripts are disabled on this agent; to enable, configure 'enable_script_checks' or 'enable_local_script_checks' to true")
@@ -2203,6 +2307,11 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 
 	// Check if already registered
 	if chkType != nil {
+		// Interval based checks report their results through a handler
+		// that applies the check's success and failure thresholds.
+		statusHandler := checks.NewStatusHandler(a.State, a.logger,
+			chkType.SuccessBeforePassing, chkType.FailuresBeforeCritical, check.Status)
+
 		switch {
 
 		case chkType.IsTTL():
@@ -2238,10 +2347,8 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 				chkType.Interval = checks.MinInterval
 			}
 
-			tlsClientConfig := a.tlsConfigurator.OutgoingTLSConfigForCheck(chkType.TLSSkipVerify)
-
 			http := &checks.CheckHTTP{
-				Notify:          a.State,
+				Notify:          statusHandler,
 				CheckID:         check.CheckID,
 				HTTP:            chkType.HTTP,
 				Header:          chkType.Header,
@@ -2254,6 +2361,32 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			http.Start()
 			a.checkHTTPs[check.CheckID] = http
 
+		case chkType.IsH2C():
+			if existing, ok := a.checkH2Cs[check.CheckID]; ok {
+				existing.Stop()
+				delete(a.checkH2Cs, check.CheckID)
+			}
+			if chkType.Interval < checks.MinInterval {
+				a.logger.Println(fmt.Sprintf("[WARN] agent: check '%s' has interval below minimum of %v",
+					check.CheckID, checks.MinInterval))
+				chkType.Interval = checks.MinInterval
+			}
+
+			h2c := &checks.CheckH2C{
+				CheckHTTP: checks.CheckHTTP{
+					Notify:   statusHandler,
+					CheckID:  check.CheckID,
+					HTTP:     chkType.H2C,
+					Header:   chkType.Header,
+					Method:   chkType.Method,
+					Interval: chkType.Interval,
+					Timeout:  chkType.Timeout,
+					Logger:   a.logger,
+				},
+			}
+			h2c.Start()
+			a.checkH2Cs[check.CheckID] = h2c
+
 		case chkType.IsTCP():
 			if existing, ok := a.checkTCPs[check.CheckID]; ok {
 				existing.Stop()
@@ -2266,7 +2399,7 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			}
 
 			tcp := &checks.CheckTCP{
-				Notify:   a.State,
+				Notify:   statusHandler,
 				CheckID:  check.CheckID,
 				TCP:      chkType.TCP,
 				Interval: chkType.Interval,
@@ -2276,6 +2409,29 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			tcp.Start()
 			a.checkTCPs[check.CheckID] = tcp
 
+		case chkType.IsUDP():
+			if existing, ok := a.checkUDPs[check.CheckID]; ok {
+				existing.Stop()
+				delete(a.checkUDPs, check.CheckID)
+			}
+			if chkType.Interval < checks.MinInterval {
+				a.logger.Println(fmt.Sprintf("[WARN] agent: check '%s' has interval below minimum of %v",
+					check.CheckID, checks.MinInterval))
+				chkType.Interval = checks.MinInterval
+			}
+
+			udp := &checks.CheckUDP{
+				Notify:   statusHandler,
+				CheckID:  check.CheckID,
+				UDP:      chkType.UDP,
+				Payload:  chkType.UDPPayload,
+				Interval: chkType.Interval,
+				Timeout:  chkType.Timeout,
+				Logger:   a.logger,
+			}
+			udp.Start()
+			a.checkUDPs[check.CheckID] = udp
+
 		case chkType.IsGRPC():
 			if existing, ok := a.checkGRPCs[check.CheckID]; ok {
 				existing.Stop()
@@ -2287,13 +2443,8 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 				chkType.Interval = checks.MinInterval
 			}
 
-			var tlsClientConfig *tls.Config
-			if chkType.GRPCUseTLS {
-				tlsClientConfig = a.tlsConfigurator.OutgoingTLSConfigForCheck(chkType.TLSSkipVerify)
-			}
-
 			grpc := &checks.CheckGRPC{
-				Notify:          a.State,
+				Notify:          statusHandler,
 				CheckID:         check.CheckID,
 				GRPC:            chkType.GRPC,
 				Interval:        chkType.Interval,
@@ -2326,7 +2477,7 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			}
 
 			dockerCheck := &checks.CheckDocker{
-				Notify:            a.State,
+				Notify:            statusHandler,
 				CheckID:           check.CheckID,
 				DockerContainerID: chkType.DockerContainerID,
 				Shell:             chkType.Shell,
@@ -2341,6 +2492,33 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			dockerCheck.Start()
 			a.checkDockers[check.CheckID] = dockerCheck
 
+		case chkType.IsOSService():
+			if existing, ok := a.checkOSServices[check.CheckID]; ok {
+				existing.Stop()
+				delete(a.checkOSServices, check.CheckID)
+			}
+			if chkType.Interval < checks.MinInterval {
+				a.logger.Println(fmt.Sprintf("[WARN] agent: check '%s' has interval below minimum of %v",
+					check.CheckID, checks.MinInterval))
+				chkType.Interval = checks.MinInterval
+			}
+
+			if a.osServiceClient == nil {
+				a.osServiceClient = checks.NewSystemdClient()
+			}
+
+			osService := &checks.CheckOSService{
+				Notify:    statusHandler,
+				CheckID:   check.CheckID,
+				OSService: chkType.OSService,
+				Interval:  chkType.Interval,
+				Timeout:   chkType.Timeout,
+				Logger:    a.logger,
+				Client:    a.osServiceClient,
+			}
+			osService.Start()
+			a.checkOSServices[check.CheckID] = osService
+
 		case chkType.IsMonitor():
 			if existing, ok := a.checkMonitors[check.CheckID]; ok {
 				existing.Stop()
@@ -2353,7 +2531,7 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			}
 
 			monitor := &checks.CheckMonitor{
-				Notify:     a.State,
+				Notify:     statusHandler,
 				CheckID:    check.CheckID,
 				ScriptArgs: chkType.ScriptArgs,
 				Interval:   chkType.Interval,
@@ -2412,6 +2590,82 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 	return nil
 }
 
+// vetCheckTLSFiles makes sure that a check only reads TLS files from the
+// agent's disk when it comes from the local configuration, or when that has
+// been allowed for the HTTP API with enable_remote_check_tls_files.
+func (a *Agent) vetCheckTLSFiles(chkType *structs.CheckType, source configSource) error {
+	if source == ConfigSourceRemote && chkType.UsesTLSFiles() && !a.config.EnableRemoteCheckTLSFiles {
+		return fmt.Errorf("CACert, ClientCert and ClientKey are disabled for checks from remote calls; to enable, configure 'enable_remote_check_tls_files' to true")
+	}
+	return nil
+}
+
+// checkTLSConfig returns the TLS configuration that an HTTP or gRPC check
+// uses to connect, which is the agent's configuration for checks with the
+// check's own settings applied on top.
+func (a *Agent) checkTLSConfig(chkType *structs.CheckType, service *structs.NodeService) (*tls.Config, error) {
+	tlsConfig := a.tlsConfigurator.OutgoingTLSConfigForCheck(chkType.TLSSkipVerify)
+
+	if chkType.TLSServerName != "" {
+		tlsConfig.ServerName = chkType.TLSServerName
+	}
+
+	if chkType.CACert != "" {
+		pem, err := ioutil.ReadFile(chkType.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CACert: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to parse CACert %q: no certificates found", chkType.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	switch {
+	case chkType.ClientCert != "":
+		cert, err := tls.LoadX509KeyPair(chkType.ClientCert, chkType.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ClientCert and ClientKey: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		tlsConfig.GetClientCertificate = nil
+
+	case chkType.UseConnectCert:
+		if service == nil {
+			return nil, fmt.Errorf("UseConnectCert requires the check to be associated with a service")
+		}
+
+		// The leaf certificate is fetched from the cache for every
+		// handshake so that the check always uses the current one.
+		serviceID, serviceName := service.ID, service.Service
+		tlsConfig.Certificates = nil
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			args := cachetype.ConnectCALeafRequest{
+				Token:      a.State.ServiceToken(serviceID),
+				Datacenter: a.config.Datacenter,
+				Service:    serviceName,
+			}
+			raw, _, err := a.cache.Get(cachetype.ConnectCALeafName, &args)
+			if err != nil {
+				return nil, err
+			}
+			leaf, ok := raw.(*structs.IssuedCert)
+			if !ok {
+				// This should never happen, but we want to protect against panics
+				return nil, fmt.Errorf("internal error: response type not correct")
+			}
+			cert, err := tls.X509KeyPair([]byte(leaf.CertPEM), []byte(leaf.PrivateKeyPEM))
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		}
+	}
+
+	return tlsConfig, nil
+}
+
 // RemoveCheck is used to remove a health check.
 // The agent will make a best effort to ensure it is deregistered
 func (a *Agent) RemoveCheck(checkID types.CheckID, persist bool) error {
@@ -2800,10 +3054,18 @@ func (a *Agent) cancelCheckMonitors(checkID types.CheckID) {
 		check.Stop()
 		delete(a.checkHTTPs, checkID)
 	}
+	if check, ok := a.checkH2Cs[checkID]; ok {
+		check.Stop()
+		delete(a.checkH2Cs, checkID)
+	}
 	if check, ok := a.checkTCPs[checkID]; ok {
 		check.Stop()
 		delete(a.checkTCPs, checkID)
 	}
+	if check, ok := a.checkUDPs[checkID]; ok {
+		check.Stop()
+		delete(a.checkUDPs, checkID)
+	}
 	if check, ok := a.checkGRPCs[checkID]; ok {
 		check.Stop()
 		delete(a.checkGRPCs, checkID)
@@ -2816,6 +3078,10 @@ func (a *Agent) cancelCheckMonitors(checkID types.CheckID) {
 		check.Stop()
 		delete(a.checkDockers, checkID)
 	}
+	if check, ok := a.checkOSServices[checkID]; ok {
+		check.Stop()
+		delete(a.checkOSServices, checkID)
+	}
 }
 
 // updateTTLCheck is used to update the status of a TTL check via the Agent API.
@@ -3523,6 +3789,11 @@ func (a *Agent) DisableNodeMaintenance() {
 func (a *Agent) loadLimits(conf *config.RuntimeConfig) {
 	a.config.RPCRateLimit = conf.RPCRateLimit
 	a.config.RPCMaxBurst = conf.RPCMaxBurst
+	a.config.RPCServerRateLimit = conf.RPCServerRateLimit
+	a.config.RPCServerMaxBurst = conf.RPCServerMaxBurst
+	a.config.RPCServerSourceRateLimit = conf.RPCServerSourceRateLimit
+	a.config.RPCServerSourceMaxBurst = conf.RPCServerSourceMaxBurst
+	a.config.RPCMaxBlockingQueries = conf.RPCMaxBlockingQueries
 }
 
 func (a *Agent) ReloadConfig(newCfg *config.RuntimeConfig) error {
@@ -3614,6 +3885,11 @@ func (a *Agent) registerCache() {
 		Refresh:        true,
 		RefreshTimer:   0 * time.Second,
 		RefreshTimeout: 10 * time.Minute,
+
+		// Keep serving the last known value across restarts
+		Persist:    true,
+		NewRequest: func() cache.Request { return &structs.DCSpecificRequest{} },
+		NewValue:   func() interface{} { return &structs.IndexedCARoots{} },
 	})
 
 	a.cache.RegisterType(cachetype.ConnectCALeafName, &cachetype.ConnectCALeaf{
@@ -3626,6 +3902,11 @@ func (a *Agent) registerCache() {
 		Refresh:        true,
 		RefreshTimer:   0 * time.Second,
 		RefreshTimeout: 10 * time.Minute,
+
+		// Keep serving the last known value across restarts
+		Persist:    true,
+		NewRequest: func() cache.Request { return &cachetype.ConnectCALeafRequest{} },
+		NewValue:   func() interface{} { return &structs.IssuedCert{} },
 	})
 
 	a.cache.RegisterType(cachetype.IntentionMatchName, &cachetype.IntentionMatch{
@@ -3635,6 +3916,11 @@ func (a *Agent) registerCache() {
 		Refresh:        true,
 		RefreshTimer:   0 * time.Second,
 		RefreshTimeout: 10 * time.Minute,
+
+		// Keep serving the last known value across restarts
+		Persist:    true,
+		NewRequest: func() cache.Request { return &structs.IntentionQueryRequest{} },
+		NewValue:   func() interface{} { return &structs.IndexedIntentionMatches{} },
 	})
 
 	a.cache.RegisterType(cachetype.CatalogServicesName, &cachetype.CatalogServices{
@@ -3653,6 +3939,11 @@ func (a *Agent) registerCache() {
 		Refresh:        true,
 		RefreshTimer:   0 * time.Second,
 		RefreshTimeout: 10 * time.Minute,
+
+		// Keep serving the last known value across restarts
+		Persist:    true,
+		NewRequest: func() cache.Request { return &structs.ServiceSpecificRequest{} },
+		NewValue:   func() interface{} { return &structs.IndexedCheckServiceNodes{} },
 	})
 
 	a.cache.RegisterType(cachetype.PreparedQueryName, &cachetype.PreparedQuery{
@@ -3670,6 +3961,49 @@ func (a *Agent) registerCache() {
 		RefreshTimer:   0 * time.Second,
 		RefreshTimeout: 10 * time.Minute,
 	})
+
+	a.cache.RegisterType(cachetype.ConfigEntriesName, &cachetype.ConfigEntries{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query, retry dropped connections quickly
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
+	a.cache.RegisterType(cachetype.ConfigEntryName, &cachetype.ConfigEntry{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query, retry dropped connections quickly
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
+	a.cache.RegisterType(cachetype.CatalogListServicesName, &cachetype.CatalogListServices{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query, retry dropped connections quickly
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
+	a.cache.RegisterType(cachetype.CatalogDatacentersName, &cachetype.CatalogDatacenters{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// The datacenter list doesn't support blocking
+		Refresh: false,
+	})
+
+	a.cache.RegisterType(cachetype.InternalServiceDumpName, &cachetype.InternalServiceDump{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query, retry dropped connections quickly
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
 }
 
 // defaultProxyCommand returns the default Connect managed proxy command.
@@ -195,6 +195,11 @@ func buildAgentService(s *structs.NodeService, proxies map[string]*local.Managed
 		as.ProxyDestination = as.Proxy.DestinationServiceName
 	}
 
+	// Mesh gateways only carry the opaque proxy config
+	if s.Kind == structs.ServiceKindMeshGateway {
+		as.Proxy = s.Proxy.ToAPI()
+	}
+
 	// Attach Connect configs if they exist. We use the actual proxy state since
 	// that may have had defaults filled in compared to the config that was
 	// provided with the service as stored in the NodeService here.
@@ -368,7 +373,8 @@ func (s *HTTPServer) AgentService(resp http.ResponseWriter, req *http.Request) (
 				}
 			}
 
-			if svc.Kind == structs.ServiceKindConnectProxy {
+			if svc.Kind == structs.ServiceKindConnectProxy ||
+				svc.Kind == structs.ServiceKindMeshGateway {
 				proxy = svc.Proxy.ToAPI()
 			}
 
@@ -588,6 +594,9 @@ func (s *HTTPServer) AgentRegisterCheck(resp http.ResponseWriter, req *http.Requ
 		fmt.Fprint(resp, fmt.Errorf("Invalid check: %v", err))
 		return nil, nil
 	}
+	if err := s.agent.vetCheckTLSFiles(chkType, ConfigSourceRemote); err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("Invalid check: %v", err)}
+	}
 
 	// Get the provided token, if any, and vet against any ACL policies.
 	var token string
@@ -869,6 +878,7 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 			"destination_namespace": "DestinationNamespace",
 			"local_bind_port":       "LocalBindPort",
 			"local_bind_address":    "LocalBindAddress",
+			"mesh_gateway":          "MeshGateway",
 			// Proxy Config
 			"destination_service_name": "DestinationServiceName",
 			"destination_service_id":   "DestinationServiceID",
@@ -973,6 +983,9 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 			fmt.Fprint(resp, "Status for checks must 'passing', 'warning', 'critical'")
 			return nil, nil
 		}
+		if err := s.agent.vetCheckTLSFiles(check, ConfigSourceRemote); err != nil {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Invalid check: %v", err)}
+		}
 	}
 
 	// Verify the sidecar check types
@@ -989,6 +1002,11 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 					Reason: "Status for checks must 'passing', 'warning', 'critical'",
 				}
 			}
+			if err := s.agent.vetCheckTLSFiles(check, ConfigSourceRemote); err != nil {
+				return nil, BadRequestError{
+					Reason: fmt.Sprintf("Invalid check in sidecar_service: %v", err),
+				}
+			}
 		}
 	}
 
@@ -1672,3 +1690,26 @@ func (s *HTTPServer) AgentHost(resp http.ResponseWriter, req *http.Request) (int
 
 	return debug.CollectHostInfo(), nil
 }
+
+// AgentCache
+//
+// GET /v1/agent/cache
+//
+// Retrieves the entries in the agent cache and the hit, miss and eviction
+// counters of each cache type for debugging. The entries are those of all
+// tokens, so this requires an operator:read ACL token.
+func (s *HTTPServer) AgentCache(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Fetch the ACL token, if any, and enforce operator policy.
+	var token string
+	s.parseToken(req, &token)
+	rule, err := s.agent.resolveToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if rule != nil && !rule.OperatorRead() {
+		return nil, acl.ErrPermissionDenied
+	}
+
+	return s.agent.cache.Stats(), nil
+}
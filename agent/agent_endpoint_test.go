@@ -18,6 +18,8 @@ import (
 	"time"
 
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/cache"
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/checks"
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/connect"
@@ -318,7 +320,7 @@ func TestAgent_Service(t *testing.T) {
 		Service:     "web-sidecar-proxy",
 		Port:        8000,
 		Proxy:       expectProxy.ToAPI(),
-		ContentHash: "3442362e971c43d1",
+		ContentHash: "8c5cc41b108d02fe",
 		Weights: api.AgentWeights{
 			Passing: 1,
 			Warning: 1,
@@ -328,7 +330,7 @@ func TestAgent_Service(t *testing.T) {
 	// Copy and modify
 	updatedResponse := *expectedResponse
 	updatedResponse.Port = 9999
-	updatedResponse.ContentHash = "90b5c19bf0f5073"
+	updatedResponse.ContentHash = "b115ae2c389e115c"
 
 	// Simple response for non-proxy service registered in TestAgent config
 	expectWebResponse := &api.AgentService{
@@ -625,7 +627,7 @@ func TestAgent_Service_DeprecatedManagedProxy(t *testing.T) {
 		Service:     "web-proxy",
 		Port:        9999,
 		Address:     "10.10.10.10",
-		ContentHash: "e24f099e42e88317",
+		ContentHash: "245d12541a0e7e84",
 		Proxy: &api.AgentServiceConnectProxyConfig{
 			DestinationServiceID:   "web",
 			DestinationServiceName: "web",
@@ -1898,6 +1900,58 @@ func TestAgent_RegisterCheckScriptsExecRemoteDisable(t *testing.T) {
 	}
 }
 
+func TestAgent_RegisterCheck_TLSFiles(t *testing.T) {
+	t.Parallel()
+
+	check := map[string]interface{}{
+		"Name":     "test",
+		"HTTP":     "https://localhost:8443/health",
+		"Interval": "10s",
+		"CACert":   "ca.pem",
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		a := NewTestAgent(t, t.Name(), "")
+		defer a.Shutdown()
+		testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+		req, _ := http.NewRequest("PUT", "/v1/agent/check/register", jsonReader(check))
+		_, err := a.srv.AgentRegisterCheck(httptest.NewRecorder(), req)
+		_, ok := err.(BadRequestError)
+		require.True(t, ok, "expected a bad request error, got: %v", err)
+		require.Contains(t, err.Error(), "enable_remote_check_tls_files")
+
+		service := map[string]interface{}{
+			"Name":   "web",
+			"Port":   8443,
+			"Checks": []map[string]interface{}{check},
+		}
+		req, _ = http.NewRequest("PUT", "/v1/agent/service/register", jsonReader(service))
+		_, err = a.srv.AgentRegisterService(httptest.NewRecorder(), req)
+		_, ok = err.(BadRequestError)
+		require.True(t, ok, "expected a bad request error, got: %v", err)
+
+		require.Empty(t, a.State.Checks())
+		require.Nil(t, a.State.Service("web"))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		t.Parallel()
+		a := NewTestAgent(t, t.Name(), `
+			enable_remote_check_tls_files = true
+		`)
+		defer a.Shutdown()
+		testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+		// The file is read, rather than the check being rejected up front.
+		req, _ := http.NewRequest("PUT", "/v1/agent/check/register", jsonReader(check))
+		_, err := a.srv.AgentRegisterCheck(httptest.NewRecorder(), req)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to read CACert")
+	})
+}
+
 func TestAgent_RegisterCheck_Passing(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -5036,7 +5090,7 @@ func TestAgentConnectProxyConfig_Blocking(t *testing.T) {
 		ProxyServiceID:    "test-proxy",
 		TargetServiceID:   "test",
 		TargetServiceName: "test",
-		ContentHash:       "a7c93585b6d70445",
+		ContentHash:       "cd9fae3f744900f3",
 		ExecMode:          "daemon",
 		Command:           []string{"tubes.sh"},
 		Config: map[string]interface{}{
@@ -5057,7 +5111,7 @@ func TestAgentConnectProxyConfig_Blocking(t *testing.T) {
 	ur, err := copystructure.Copy(expectedResponse)
 	require.NoError(t, err)
 	updatedResponse := ur.(*api.ConnectProxyConfig)
-	updatedResponse.ContentHash = "aedc0ca0f3f7794e"
+	updatedResponse.ContentHash = "59b052e51c1dada3"
 	updatedResponse.Upstreams = append(updatedResponse.Upstreams, api.Upstream{
 		DestinationType: "service",
 		DestinationName: "cache",
@@ -6274,3 +6328,82 @@ func TestAgent_HostBadACL(t *testing.T) {
 	assert.Equal(http.StatusOK, resp.Code)
 	assert.Nil(respRaw)
 }
+
+func TestAgent_Cache(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	a := NewTestAgent(t, t.Name(), `
+		cache {
+			max_entries = 100
+		}
+	`)
+	defer a.Shutdown()
+
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	// Populate the cache
+	req, _ := http.NewRequest("GET", "/v1/agent/connect/ca/roots", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.AgentConnectCARoots(resp, req)
+	require.NoError(err)
+	req, _ = http.NewRequest("GET", "/v1/agent/connect/ca/roots", nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.AgentConnectCARoots(resp, req)
+	require.NoError(err)
+	require.Equal("HIT", resp.Header().Get("X-Cache"))
+
+	req, _ = http.NewRequest("GET", "/v1/agent/cache", nil)
+	resp = httptest.NewRecorder()
+	respRaw, err := a.srv.AgentCache(resp, req)
+	require.NoError(err)
+	require.Equal(http.StatusOK, resp.Code)
+
+	stats := respRaw.(*cache.Stats)
+	require.Equal(100, stats.MaxEntries)
+	require.True(stats.Count > 0)
+
+	var roots *cache.EntryStats
+	for i, entry := range stats.Entries {
+		if entry.Type == cachetype.ConnectCARootName {
+			roots = &stats.Entries[i]
+		}
+	}
+	require.NotNil(roots)
+	require.Equal("dc1", roots.Datacenter)
+	require.True(roots.Valid)
+	require.True(roots.Index > 0)
+	require.True(roots.Size > 0)
+
+	var rootStats *cache.TypeStats
+	for i, ts := range stats.Types {
+		if ts.Name == cachetype.ConnectCARootName {
+			rootStats = &stats.Types[i]
+		}
+	}
+	require.NotNil(rootStats)
+	require.Equal(1, rootStats.Count)
+	require.Equal(uint64(1), rootStats.Hits)
+	require.Equal(uint64(1), rootStats.Misses)
+}
+
+func TestAgent_Cache_ACLDeny(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), TestACLConfig())
+	defer a.Shutdown()
+
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+	t.Run("no token", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/cache", nil)
+		if _, err := a.srv.AgentCache(nil, req); !acl.IsErrPermissionDenied(err) {
+			t.Fatalf("err: %v", err)
+		}
+	})
+
+	t.Run("root token", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/cache?token=root", nil)
+		if _, err := a.srv.AgentCache(nil, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
@@ -3,7 +3,9 @@ package agent
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -18,6 +20,7 @@ import (
 
 	"github.com/hashicorp/consul/testrpc"
 
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/checks"
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/connect"
@@ -1125,6 +1128,235 @@ func TestAgent_AddCheck_GRPC(t *testing.T) {
 	}
 }
 
+func TestAgent_AddCheck_UDP(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	health := &structs.HealthCheck{
+		Node:    "foo",
+		CheckID: "udp",
+		Name:    "udp check",
+		Status:  api.HealthCritical,
+	}
+	chk := &structs.CheckType{
+		UDP:        "localhost:53",
+		UDPPayload: "ping",
+		Interval:   15 * time.Second,
+	}
+	require.NoError(t, a.AddCheck(health, chk, false, "", ConfigSourceLocal))
+
+	require.NotNil(t, a.State.Check("udp"))
+	udp, ok := a.checkUDPs["udp"]
+	require.True(t, ok, "missing udp check")
+	require.Equal(t, "ping", udp.Payload)
+}
+
+func TestAgent_AddCheck_H2C(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	health := &structs.HealthCheck{
+		Node:    "foo",
+		CheckID: "h2c",
+		Name:    "h2c check",
+		Status:  api.HealthCritical,
+	}
+	chk := &structs.CheckType{
+		H2C:      "http://localhost:8080/health",
+		Interval: 15 * time.Second,
+	}
+	require.NoError(t, a.AddCheck(health, chk, false, "", ConfigSourceLocal))
+
+	require.NotNil(t, a.State.Check("h2c"))
+	_, ok := a.checkH2Cs["h2c"]
+	require.True(t, ok, "missing h2c check")
+	_, ok = a.checkHTTPs["h2c"]
+	require.False(t, ok, "h2c check registered as an http check")
+
+	// Only cleartext URLs are allowed.
+	chk.H2C = "https://localhost:8080/health"
+	err := a.AddCheck(health, chk, false, "", ConfigSourceLocal)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "http://")
+}
+
+func TestAgent_AddCheck_OSService(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	health := &structs.HealthCheck{
+		Node:    "foo",
+		CheckID: "nginx",
+		Name:    "nginx unit",
+		Status:  api.HealthCritical,
+	}
+	chk := &structs.CheckType{
+		OSService: "nginx",
+		Interval:  15 * time.Second,
+	}
+	require.NoError(t, a.AddCheck(health, chk, false, "", ConfigSourceLocal))
+
+	require.NotNil(t, a.State.Check("nginx"))
+	_, ok := a.checkOSServices["nginx"]
+	require.True(t, ok, "missing os service check")
+	require.NotNil(t, a.osServiceClient)
+
+	require.NoError(t, a.RemoveCheck("nginx", false))
+	_, ok = a.checkOSServices["nginx"]
+	require.False(t, ok, "os service check not removed")
+}
+
+func TestAgent_AddCheck_TLSClientCert(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	// Serve a health endpoint that requires a client certificate signed by
+	// the test CA.
+	ca := connect.TestCA(t, nil)
+	clientCAs := x509.NewCertPool()
+	require.True(t, clientCAs.AppendCertsFromPEM([]byte(ca.RootCert)))
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	dir := testutil.TempDir(t, "check-tls")
+	defer os.RemoveAll(dir)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: ts.Certificate().Raw,
+	}), 0600))
+	certPEM, keyPEM := connect.TestLeaf(t, "web", ca)
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, []byte(certPEM), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(keyPEM), 0600))
+
+	health := &structs.HealthCheck{
+		Node:    "foo",
+		CheckID: "tlscheck",
+		Name:    "tls check",
+		Status:  api.HealthCritical,
+	}
+	chk := &structs.CheckType{
+		HTTP:     ts.URL,
+		Interval: time.Second,
+		// The httptest certificate is only valid for example.com and
+		// the loopback addresses.
+		TLSServerName: "example.com",
+		CACert:        caFile,
+		ClientCert:    certFile,
+		ClientKey:     keyFile,
+	}
+	require.NoError(t, a.AddCheck(health, chk, false, "", ConfigSourceLocal))
+
+	retry.Run(t, func(r *retry.R) {
+		status := a.State.Check("tlscheck")
+		if status == nil {
+			r.Fatalf("missing tlscheck check")
+		}
+		if status.Status != api.HealthPassing {
+			r.Fatalf("bad: %v: %s", status.Status, status.Output)
+		}
+	})
+}
+
+func TestAgent_AddCheck_TLSInvalid(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	cases := []struct {
+		name string
+		chk  *structs.CheckType
+		err  string
+	}{
+		{
+			"tls settings on tcp check",
+			&structs.CheckType{
+				TCP:           "localhost:8080",
+				Interval:      time.Second,
+				TLSServerName: "example.com",
+			},
+			"only supported for HTTP and gRPC",
+		},
+		{
+			"grpc without tls",
+			&structs.CheckType{
+				GRPC:     "localhost:12345/package.Service",
+				Interval: time.Second,
+				CACert:   "ca.pem",
+			},
+			"GRPCUseTLS",
+		},
+		{
+			"client cert without key",
+			&structs.CheckType{
+				HTTP:       "https://localhost:8080",
+				Interval:   time.Second,
+				ClientCert: "client.pem",
+			},
+			"must be set together",
+		},
+		{
+			"client cert and connect cert",
+			&structs.CheckType{
+				HTTP:           "https://localhost:8080",
+				Interval:       time.Second,
+				ClientCert:     "client.pem",
+				ClientKey:      "client-key.pem",
+				UseConnectCert: true,
+			},
+			"UseConnectCert",
+		},
+		{
+			"connect cert without service",
+			&structs.CheckType{
+				HTTP:           "https://localhost:8080",
+				Interval:       time.Second,
+				UseConnectCert: true,
+			},
+			"associated with a service",
+		},
+		{
+			"missing ca file",
+			&structs.CheckType{
+				HTTP:     "https://localhost:8080",
+				Interval: time.Second,
+				CACert:   filepath.Join("does", "not", "exist.pem"),
+			},
+			"failed to read CACert",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			health := &structs.HealthCheck{
+				Node:    "foo",
+				CheckID: "tlscheck",
+				Name:    "tls check",
+				Status:  api.HealthCritical,
+			}
+			err := a.AddCheck(health, tc.chk, false, "", ConfigSourceLocal)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+			require.Nil(t, a.State.Check("tlscheck"))
+		})
+	}
+}
+
 func TestAgent_AddCheck_Alias(t *testing.T) {
 	t.Parallel()
 
@@ -1660,6 +1892,55 @@ func TestAgent_PurgeServiceOnDuplicate(t *testing.T) {
 	}
 }
 
+func TestAgent_CacheSnapshot(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	cacheCfg := `
+		cache {
+			persist = true
+			persist_interval = "1h"
+		}
+	`
+	dataDir := testutil.TempDir(t, "agent") // we manage the data dir
+	defer os.RemoveAll(dataDir)
+	a := &TestAgent{Name: t.Name(), HCL: cacheCfg + `
+		data_dir = "` + dataDir + `"
+	`, DataDir: dataDir}
+	a.Start(t)
+	defer a.Shutdown()
+
+	req := &structs.DCSpecificRequest{Datacenter: "dc1"}
+	raw, _, err := a.cache.Get(cachetype.ConnectCARootName, req)
+	require.NoError(err)
+	roots := raw.(*structs.IndexedCARoots)
+	require.NotEmpty(roots.Roots)
+
+	// Shutting down writes the snapshot.
+	a.Shutdown()
+	snap, err := ioutil.ReadFile(filepath.Join(dataDir, cacheSnapshotPath))
+	require.NoError(err)
+
+	// A client that can't reach any servers serves the roots from the
+	// snapshot.
+	dataDir2 := testutil.TempDir(t, "agent") // we manage the data dir
+	defer os.RemoveAll(dataDir2)
+	require.NoError(os.MkdirAll(filepath.Join(dataDir2, "cache"), 0700))
+	require.NoError(ioutil.WriteFile(filepath.Join(dataDir2, cacheSnapshotPath), snap, 0600))
+	a2 := &TestAgent{Name: t.Name() + "-a2", HCL: cacheCfg + `
+		data_dir = "` + dataDir2 + `"
+		server = false
+		bootstrap = false
+	`, DataDir: dataDir2}
+	a2.Start(t)
+	defer a2.Shutdown()
+
+	req = &structs.DCSpecificRequest{Datacenter: "dc1"}
+	raw, meta, err := a2.cache.Get(cachetype.ConnectCARootName, req)
+	require.NoError(err)
+	require.True(meta.Hit)
+	require.Equal(roots.ActiveRootID, raw.(*structs.IndexedCARoots).ActiveRootID)
+}
+
 func TestAgent_PersistProxy(t *testing.T) {
 	t.Parallel()
 	dataDir := testutil.TempDir(t, "agent") // we manage the data dir
@@ -0,0 +1,46 @@
+package cachetype
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Recommended name for registration.
+const CatalogDatacentersName = "catalog-datacenters"
+
+// CatalogDatacenters supports fetching the list of known datacenters.
+type CatalogDatacenters struct {
+	RPC RPC
+}
+
+func (c *CatalogDatacenters) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a DatacentersRequest.
+	reqReal, ok := req.(*structs.DatacentersRequest)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Fetch
+	var reply []string
+	if err := c.RPC.RPC("Catalog.ListDatacenters", reqReal, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+
+	// The datacenter list has no index. Report 1 so the cache doesn't treat
+	// the result as a failed fetch and back off.
+	result.Index = 1
+
+	return result, nil
+}
+
+func (c *CatalogDatacenters) SupportsBlocking() bool {
+	// The datacenter list can't be watched with a blocking query.
+	return false
+}
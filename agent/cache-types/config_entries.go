@@ -0,0 +1,92 @@
+package cachetype
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Recommended name for registration.
+const ConfigEntriesName = "config-entries"
+
+// ConfigEntries supports fetching all the centralized config entries of a
+// single kind.
+type ConfigEntries struct {
+	RPC RPC
+}
+
+func (c *ConfigEntries) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a ConfigEntryQuery.
+	reqReal, ok := req.(*structs.ConfigEntryQuery)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Set the minimum query index to our current index so we block
+	reqReal.QueryOptions.MinQueryIndex = opts.MinIndex
+	reqReal.QueryOptions.MaxQueryTime = opts.Timeout
+
+	// Always allow stale - there's no point in hitting leader if the request is
+	// going to be served from cache and end up arbitrarily stale anyway.
+	reqReal.AllowStale = true
+
+	// Fetch
+	var reply structs.IndexedConfigEntries
+	if err := c.RPC.RPC("ConfigEntry.List", reqReal, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+	result.Index = reply.QueryMeta.Index
+	return result, nil
+}
+
+func (c *ConfigEntries) SupportsBlocking() bool {
+	return true
+}
+
+// Recommended name for registration.
+const ConfigEntryName = "config-entry"
+
+// ConfigEntry supports fetching a single centralized config entry by kind and
+// name.
+type ConfigEntry struct {
+	RPC RPC
+}
+
+func (c *ConfigEntry) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a ConfigEntryQuery.
+	reqReal, ok := req.(*structs.ConfigEntryQuery)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Set the minimum query index to our current index so we block
+	reqReal.QueryOptions.MinQueryIndex = opts.MinIndex
+	reqReal.QueryOptions.MaxQueryTime = opts.Timeout
+
+	// Always allow stale - there's no point in hitting leader if the request is
+	// going to be served from cache and end up arbitrarily stale anyway.
+	reqReal.AllowStale = true
+
+	// Fetch
+	var reply structs.IndexedConfigEntries
+	if err := c.RPC.RPC("ConfigEntry.Get", reqReal, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+	result.Index = reply.QueryMeta.Index
+	return result, nil
+}
+
+func (c *ConfigEntry) SupportsBlocking() bool {
+	return true
+}
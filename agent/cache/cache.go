@@ -16,7 +16,10 @@ package cache
 
 import (
 	"container/heap"
+	"container/list"
 	"fmt"
+	"log"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -58,12 +61,19 @@ type Cache struct {
 	typesLock sync.RWMutex
 	types     map[string]typeEntry
 
-	// entries contains the actual cache data. Access to entries and
-	// entriesExpiryHeap must be protected by entriesLock.
+	// entries contains the actual cache data. Access to entries,
+	// entriesExpiryHeap, entriesLRU and entriesBytes must be protected by
+	// entriesLock.
 	//
 	// entriesExpiryHeap is a heap of *cacheEntry values ordered by
 	// expiry, with the soonest to expire being first in the list (index 0).
 	//
+	// entriesLRU is a list of the *cacheEntryExpiry values of the entries
+	// ordered by their last use, with the most recently used first. It is
+	// used to evict entries when the cache is over its limits.
+	//
+	// entriesBytes is the sum of the estimated sizes of the entries.
+	//
 	// NOTE(mitchellh): The entry map key is currently a string in the format
 	// of "<DC>/<ACL token>/<Request key>" in order to properly partition
 	// requests to different datacenters and ACL tokens. This format has some
@@ -73,6 +83,11 @@ type Cache struct {
 	entriesLock       sync.RWMutex
 	entries           map[string]cacheEntry
 	entriesExpiryHeap *expiryHeap
+	entriesLRU        *list.List
+	entriesBytes      int64
+
+	options *Options
+	logger  *log.Logger
 
 	// stopped is used as an atomic flag to signal that the Cache has been
 	// discarded so background fetches and expiry processing should stop.
@@ -83,8 +98,9 @@ type Cache struct {
 
 // typeEntry is a single type that is registered with a Cache.
 type typeEntry struct {
-	Type Type
-	Opts *RegisterOptions
+	Type  Type
+	Opts  *RegisterOptions
+	Stats *typeStats
 }
 
 // ResultMeta is returned from Get calls along with the value and can be used
@@ -118,12 +134,36 @@ type ResultMeta struct {
 
 // Options are options for the Cache.
 type Options struct {
-	// Nothing currently, reserved.
+	// SnapshotPath is the file that entries of types registered with
+	// Persist are saved to every SnapshotInterval and when the cache is
+	// closed. If it is empty then nothing is saved.
+	SnapshotPath string
+
+	// SnapshotInterval is the time between writes of the snapshot. If it
+	// is zero, the snapshot is only written when the cache is closed.
+	SnapshotInterval time.Duration
+
+	// Logger is used to report background snapshot failures.
+	Logger *log.Logger
+
+	// MaxEntries and MaxBytes limit the number of entries and their total
+	// estimated size in bytes. The least recently used entries are evicted
+	// when either is exceeded. Zero means no limit.
+	MaxEntries int
+	MaxBytes   int64
 }
 
 // New creates a new cache with the given RPC client and reasonable defaults.
 // Further settings can be tweaked on the returned value.
-func New(*Options) *Cache {
+func New(options *Options) *Cache {
+	if options == nil {
+		options = &Options{}
+	}
+	logger := options.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+
 	// Initialize the heap. The buffer of 1 is really important because
 	// its possible for the expiry loop to trigger the heap to update
 	// itself and it'd block forever otherwise.
@@ -134,12 +174,20 @@ func New(*Options) *Cache {
 		types:             make(map[string]typeEntry),
 		entries:           make(map[string]cacheEntry),
 		entriesExpiryHeap: h,
+		entriesLRU:        list.New(),
+		options:           options,
+		logger:            logger,
 		stopCh:            make(chan struct{}),
 	}
 
 	// Start the expiry watcher
 	go c.runExpiryLoop()
 
+	// Start writing the snapshot if asked to
+	if options.SnapshotPath != "" && options.SnapshotInterval > 0 {
+		go c.runSnapshotLoop()
+	}
+
 	return c
 }
 
@@ -177,6 +225,18 @@ type RegisterOptions struct {
 	//
 	RefreshTimer   time.Duration
 	RefreshTimeout time.Duration
+
+	// Persist configures whether entries of this type are saved to the
+	// cache snapshot and restored from it by LoadSnapshot. Restored entries
+	// are served as stale until their background refresh succeeds, so this
+	// is only supported along with Refresh.
+	//
+	// NewRequest and NewValue must return pointers to new values of the
+	// request and result types of this type, which saved entries are
+	// decoded into. They are required if Persist is set.
+	Persist    bool
+	NewRequest func() Request
+	NewValue   func() interface{}
 }
 
 // RegisterType registers a cacheable type.
@@ -193,7 +253,7 @@ func (c *Cache) RegisterType(n string, typ Type, opts *RegisterOptions) {
 
 	c.typesLock.Lock()
 	defer c.typesLock.Unlock()
-	c.types[n] = typeEntry{Type: typ, Opts: opts}
+	c.types[n] = typeEntry{Type: typ, Opts: opts, Stats: &typeStats{}}
 }
 
 // Get loads the data for the given type and request. If data satisfying the
@@ -283,6 +343,7 @@ RETRY_GET:
 		meta := ResultMeta{Index: entry.Index}
 		if first {
 			metrics.IncrCounter([]string{"consul", "cache", t, "hit"}, 1)
+			atomic.AddUint64(&tEntry.Stats.Hits, 1)
 			meta.Hit = true
 		}
 
@@ -305,6 +366,9 @@ RETRY_GET:
 		c.entriesLock.Lock()
 		entry.Expiry.Reset()
 		c.entriesExpiryHeap.Fix(entry.Expiry)
+		if entry.Expiry.LRUElement != nil {
+			c.entriesLRU.MoveToFront(entry.Expiry.LRUElement)
+		}
 		c.entriesLock.Unlock()
 
 		// We purposely do not return an error here since the cache only works with
@@ -334,6 +398,8 @@ RETRY_GET:
 	}
 
 	if first {
+		atomic.AddUint64(&tEntry.Stats.Misses, 1)
+
 		// We increment two different counters for cache misses depending on
 		// whether we're missing because we didn't have the data at all,
 		// or if we're missing because we're blocking on a set index.
@@ -416,7 +482,24 @@ func (c *Cache) fetch(t, key string, r Request, allowNew bool, attempt uint) (<-
 	// If we don't have an entry, then create it. The entry must be marked
 	// as invalid so that it isn't returned as a valid value for a zero index.
 	if !ok {
-		entry = cacheEntry{Valid: false, Waiter: make(chan struct{})}
+		info := r.CacheInfo()
+		entry = cacheEntry{
+			Type:       t,
+			Datacenter: info.Datacenter,
+			Key:        info.Key,
+			Valid:      false,
+			Waiter:     make(chan struct{}),
+		}
+
+		// Encode the request now since the type may modify it while
+		// refreshing.
+		if tEntry.Opts.Persist {
+			req, err := encodeMsgpack(r)
+			if err != nil {
+				c.logger.Printf("[WARN] agent: failed to encode %s request for the cache snapshot: %v", t, err)
+			}
+			entry.Request = req
+		}
 	}
 
 	// Set that we're fetching to true, which makes it so that future
@@ -486,6 +569,7 @@ func (c *Cache) fetch(t, key string, r Request, allowNew bool, attempt uint) (<-
 			newEntry.Value = result.Value
 			newEntry.State = result.State
 			newEntry.Index = result.Index
+			newEntry.Size = estimateSize(result.Value)
 			newEntry.FetchedAt = time.Now()
 			if newEntry.Index < 1 {
 				// Less than one is invalid unless there was an error and in this case
@@ -574,9 +658,17 @@ func (c *Cache) fetch(t, key string, r Request, allowNew bool, attempt uint) (<-
 			}
 			newEntry.Expiry.Reset()
 			heap.Push(c.entriesExpiryHeap, newEntry.Expiry)
+			newEntry.Expiry.LRUElement = c.entriesLRU.PushFront(newEntry.Expiry)
 		}
 
+		// The entry may have been evicted while fetching, in which case
+		// its size was already subtracted.
+		if old, ok := c.entries[key]; ok {
+			c.entriesBytes -= old.Size
+		}
+		c.entriesBytes += newEntry.Size
 		c.entries[key] = newEntry
+		c.evictLRU(key)
 		c.entriesLock.Unlock()
 
 		// Trigger the old waiter
@@ -690,13 +782,9 @@ func (c *Cache) runExpiryLoop() {
 			c.entriesLock.Lock()
 
 			// Entry expired! Remove it.
-			delete(c.entries, entry.Key)
-			heap.Remove(c.entriesExpiryHeap, entry.HeapIndex)
-
-			// This is subtle but important: if we race and simultaneously
-			// evict and fetch a new value, then we set this to -1 to
-			// have it treated as a new value so that the TTL is extended.
-			entry.HeapIndex = -1
+			if t := c.removeEntry(entry); t != nil {
+				atomic.AddUint64(&t.Stats.Expirations, 1)
+			}
 
 			// Set some metrics
 			metrics.IncrCounter([]string{"consul", "cache", "evict_expired"}, 1)
@@ -707,6 +795,63 @@ func (c *Cache) runExpiryLoop() {
 	}
 }
 
+// removeEntry removes the entry with the given expiry from the cache and
+// returns its type, if it is still registered. This must be called with
+// entriesLock held.
+func (c *Cache) removeEntry(expiry *cacheEntryExpiry) *typeEntry {
+	entry, ok := c.entries[expiry.Key]
+	if ok {
+		c.entriesBytes -= entry.Size
+	}
+	delete(c.entries, expiry.Key)
+	heap.Remove(c.entriesExpiryHeap, expiry.HeapIndex)
+	if expiry.LRUElement != nil {
+		c.entriesLRU.Remove(expiry.LRUElement)
+		expiry.LRUElement = nil
+	}
+
+	// This is subtle but important: if we race and simultaneously
+	// evict and fetch a new value, then we set this to -1 to
+	// have it treated as a new value so that the TTL is extended.
+	expiry.HeapIndex = -1
+
+	metrics.SetGauge([]string{"consul", "cache", "bytes"}, float32(c.entriesBytes))
+	if !ok {
+		return nil
+	}
+	c.typesLock.RLock()
+	defer c.typesLock.RUnlock()
+	if tEntry, ok := c.types[entry.Type]; ok {
+		return &tEntry
+	}
+	return nil
+}
+
+// evictLRU removes the least recently used entries until the cache is
+// within its MaxEntries and MaxBytes limits. The entry with the given key
+// is never evicted so that a value larger than MaxBytes can still be
+// returned. This must be called with entriesLock held.
+func (c *Cache) evictLRU(keep string) {
+	elem := c.entriesLRU.Back()
+	for elem != nil &&
+		((c.options.MaxEntries > 0 && len(c.entries) > c.options.MaxEntries) ||
+			(c.options.MaxBytes > 0 && c.entriesBytes > c.options.MaxBytes)) {
+		expiry := elem.Value.(*cacheEntryExpiry)
+		elem = elem.Prev()
+		if expiry.Key == keep {
+			continue
+		}
+
+		t := c.removeEntry(expiry)
+		metrics.IncrCounter([]string{"consul", "cache", "evict_lru"}, 1)
+		if t != nil {
+			atomic.AddUint64(&t.Stats.Evictions, 1)
+		}
+	}
+	metrics.SetGauge([]string{"consul", "cache", "entries_count"}, float32(len(c.entries)))
+	metrics.SetGauge([]string{"consul", "cache", "bytes"}, float32(c.entriesBytes))
+}
+
 // Close stops any background work and frees all resources for the cache.
 // Current Fetch requests are allowed to continue to completion and callers may
 // still access the current cache values so coordination isn't needed with
@@ -716,8 +861,9 @@ func (c *Cache) runExpiryLoop() {
 func (c *Cache) Close() error {
 	wasStopped := atomic.SwapUint32(&c.stopped, 1)
 	if wasStopped == 0 {
-		// First time only, close stop chan
+		// First time only, close stop chan and save the final snapshot
 		close(c.stopCh)
+		return c.SaveSnapshot()
 	}
 	return nil
 }
@@ -1187,3 +1187,138 @@ func TestCacheGet_nonBlockingType(t *testing.T) {
 	time.Sleep(20 * time.Millisecond)
 	typ.AssertExpectations(t)
 }
+
+// Test that the least recently used entries are evicted when the cache has
+// too many entries.
+func TestCacheGet_evictLRU(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+
+	typ := TestTypeNonBlocking(t)
+	defer typ.AssertExpectations(t)
+	c := New(&Options{MaxEntries: 2})
+	c.RegisterType("t", typ, nil)
+
+	// Each fetch is a miss, verified via the mock assertions
+	typ.Static(FetchResult{Value: 42}, nil).Times(5)
+
+	get := func(key string, hit bool) {
+		t.Helper()
+		result, meta, err := c.Get("t", TestRequest(t, RequestInfo{Key: key}))
+		require.NoError(err)
+		require.Equal(42, result)
+		require.Equal(hit, meta.Hit, key)
+	}
+
+	get("a", false)
+	get("b", false)
+
+	// Using a makes b the least recently used entry, so it is evicted
+	get("a", true)
+	get("c", false)
+	get("a", true)
+	get("b", false)
+
+	// Fetching b again evicted c, the least recently used entry
+	get("c", false)
+
+	stats := c.Stats()
+	require.Equal(2, stats.Count)
+	require.Len(stats.Types, 1)
+	require.Equal(uint64(2), stats.Types[0].Hits)
+	require.Equal(uint64(5), stats.Types[0].Misses)
+	require.Equal(uint64(3), stats.Types[0].Evictions)
+}
+
+// Test that entries are evicted when the cache is over its size limit, but
+// that a single entry larger than the limit is still cached.
+func TestCacheGet_evictLRUBytes(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+
+	typ := TestTypeNonBlocking(t)
+	defer typ.AssertExpectations(t)
+	c := New(&Options{MaxBytes: 250})
+	c.RegisterType("t", typ, nil)
+
+	typ.On("Fetch", mock.Anything, mock.Anything).
+		Return(func(o FetchOptions, r Request) FetchResult {
+			size := 100
+			if r.CacheInfo().Key == "large" {
+				size = 1000
+			}
+			return FetchResult{Value: make([]byte, size), Index: 1}
+		}, nil)
+
+	for _, key := range []string{"a", "b"} {
+		_, _, err := c.Get("t", TestRequest(t, RequestInfo{Key: key}))
+		require.NoError(err)
+	}
+	stats := c.Stats()
+	require.Equal(2, stats.Count)
+	require.True(stats.Bytes > 200 && stats.Bytes <= 250, "bytes: %d", stats.Bytes)
+
+	// A third entry doesn't fit
+	_, _, err := c.Get("t", TestRequest(t, RequestInfo{Key: "c"}))
+	require.NoError(err)
+	stats = c.Stats()
+	require.Equal(2, stats.Count)
+	require.Equal("b", stats.Entries[0].Key)
+	require.Equal("c", stats.Entries[1].Key)
+
+	// An entry larger than the limit evicts everything else but is kept
+	_, meta, err := c.Get("t", TestRequest(t, RequestInfo{Key: "large"}))
+	require.NoError(err)
+	require.False(meta.Hit)
+	_, meta, err = c.Get("t", TestRequest(t, RequestInfo{Key: "large"}))
+	require.NoError(err)
+	require.True(meta.Hit)
+	stats = c.Stats()
+	require.Equal(1, stats.Count)
+	require.Equal(uint64(3), stats.Types[0].Evictions)
+}
+
+// Test the entries listed by Stats.
+func TestCache_Stats(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+
+	typ := TestTypeNonBlocking(t)
+	defer typ.AssertExpectations(t)
+	c := TestCache(t)
+	c.RegisterType("t", typ, nil)
+	c.RegisterType("unused", typ, nil)
+
+	typ.Static(FetchResult{Value: "hello", Index: 4}, nil).Once()
+	typ.Static(FetchResult{}, fmt.Errorf("no servers")).Once()
+
+	_, _, err := c.Get("t", TestRequest(t, RequestInfo{Datacenter: "dc1", Token: "secret", Key: "ok"}))
+	require.NoError(err)
+	_, _, err = c.Get("t", TestRequest(t, RequestInfo{Datacenter: "dc1", Token: "secret", Key: "err"}))
+	require.Error(err)
+
+	stats := c.Stats()
+	require.Equal(2, stats.Count)
+	require.Len(stats.Types, 2)
+	require.Equal("t", stats.Types[0].Name)
+	require.Equal(2, stats.Types[0].Count)
+	require.Equal(uint64(2), stats.Types[0].Misses)
+	require.Equal("unused", stats.Types[1].Name)
+	require.Equal(0, stats.Types[1].Count)
+
+	require.Len(stats.Entries, 2)
+	errEntry, okEntry := stats.Entries[0], stats.Entries[1]
+	require.Equal("err", errEntry.Key)
+	require.False(errEntry.Valid)
+	require.Equal("no servers", errEntry.Error)
+	require.Equal("ok", okEntry.Key)
+	require.Equal("dc1", okEntry.Datacenter)
+	require.True(okEntry.Valid)
+	require.Equal(uint64(4), okEntry.Index)
+	require.True(okEntry.Size > 0)
+	require.Empty(okEntry.Error)
+	require.False(okEntry.FetchedAt.IsZero())
+}
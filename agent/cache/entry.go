@@ -2,6 +2,7 @@ package cache
 
 import (
 	"container/heap"
+	"container/list"
 	"time"
 )
 
@@ -10,6 +11,17 @@ import (
 // Note that this isn't a very optimized structure currently. There are
 // a lot of improvements that can be made here in the long term.
 type cacheEntry struct {
+	// Type is the cache type of the entry and Request is the encoded
+	// request it was first fetched for, which is only set for types
+	// registered with Persist. They are saved in the cache snapshot.
+	Type    string
+	Request []byte
+
+	// Datacenter and Key are from the RequestInfo of the request, which
+	// are used to describe the entry in Stats without exposing the token.
+	Datacenter string
+	Key        string
+
 	// Fields pertaining to the actual value
 	Value interface{}
 	// State can be used to store info needed by the cache type but that should
@@ -22,6 +34,9 @@ type cacheEntry struct {
 	Error error
 	Index uint64
 
+	// Size is the estimated size of Value in bytes.
+	Size int64
+
 	// Metadata that is used for internal accounting
 	Valid    bool          // True if the Value is set
 	Fetching bool          // True if a fetch is already active
@@ -47,10 +62,11 @@ type cacheEntry struct {
 // entry. Any modifications to this struct should be done only while
 // the Cache entriesLock is held.
 type cacheEntryExpiry struct {
-	Key       string        // Key in the cache map
-	Expires   time.Time     // Time when entry expires (monotonic clock)
-	TTL       time.Duration // TTL for this entry to extend when resetting
-	HeapIndex int           // Index in the heap
+	Key        string        // Key in the cache map
+	Expires    time.Time     // Time when entry expires (monotonic clock)
+	TTL        time.Duration // TTL for this entry to extend when resetting
+	HeapIndex  int           // Index in the heap
+	LRUElement *list.Element // Element in the LRU list
 }
 
 // Reset resets the expiration to be the ttl duration from now.
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"reflect"
+)
+
+// estimateSize returns an estimate of the memory used by v in bytes. It
+// follows pointers, slices, maps and interfaces and counts each pointer
+// target only once. It doesn't account for allocator overhead, so it is
+// only useful for comparing values and enforcing a rough limit.
+func estimateSize(v interface{}) int64 {
+	if v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	return int64(rv.Type().Size()) + sizeOfReferenced(rv, make(map[uintptr]struct{}))
+}
+
+// sizeOfReferenced returns the size of the memory referenced by v, not
+// including the size of v itself.
+func sizeOfReferenced(v reflect.Value, seen map[uintptr]struct{}) int64 {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return 0
+		}
+		if _, ok := seen[v.Pointer()]; ok {
+			return 0
+		}
+		seen[v.Pointer()] = struct{}{}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + sizeOfReferenced(elem, seen)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + sizeOfReferenced(elem, seen)
+
+	case reflect.String:
+		return int64(v.Len())
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return 0
+		}
+		if _, ok := seen[v.Pointer()]; ok {
+			return 0
+		}
+		seen[v.Pointer()] = struct{}{}
+		size := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOfReferenced(v.Index(i), seen)
+		}
+		return size
+
+	case reflect.Array:
+		var size int64
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOfReferenced(v.Index(i), seen)
+		}
+		return size
+
+	case reflect.Map:
+		if v.IsNil() {
+			return 0
+		}
+		if _, ok := seen[v.Pointer()]; ok {
+			return 0
+		}
+		seen[v.Pointer()] = struct{}{}
+		entrySize := int64(v.Type().Key().Size() + v.Type().Elem().Size())
+		size := int64(v.Len()) * entrySize
+		iter := v.MapRange()
+		for iter.Next() {
+			size += sizeOfReferenced(iter.Key(), seen)
+			size += sizeOfReferenced(iter.Value(), seen)
+		}
+		return size
+
+	case reflect.Struct:
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += sizeOfReferenced(v.Field(i), seen)
+		}
+		return size
+
+	default:
+		return 0
+	}
+}
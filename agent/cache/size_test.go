@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateSize(t *testing.T) {
+	t.Parallel()
+
+	type node struct {
+		Name string
+		Meta map[string]string
+		Next *node
+	}
+
+	shared := &node{Name: "shared"}
+	loop := &node{Name: "loop"}
+	loop.Next = loop
+
+	cases := []struct {
+		name string
+		v    interface{}
+		min  int64
+		max  int64
+	}{
+		{"nil", nil, 0, 0},
+		{"int", 42, 8, 8},
+		{"string", "hello", 16 + 5, 16 + 5},
+		{"bytes", make([]byte, 100), 24 + 100, 24 + 100},
+		{"struct with map", &node{Name: "a", Meta: map[string]string{"key": "value"}}, 60, 120},
+		{"shared pointer counted once", []*node{shared, shared}, 40, 100},
+		{"cycle", loop, 40, 100},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			size := estimateSize(tc.v)
+			require.True(t, size >= tc.min && size <= tc.max, "size: %d", size)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/lib/file"
+	"github.com/hashicorp/go-msgpack/codec"
+)
+
+// snapshotVersion is the version of the snapshot format. Snapshots with a
+// different version fail to load.
+const snapshotVersion = 1
+
+// msgpackHandle is used to encode snapshots and the entries in them.
+var msgpackHandle = &codec.MsgpackHandle{}
+
+// snapshot is the on-disk format of the entries of persisted types.
+type snapshot struct {
+	Version int
+	Entries []snapshotEntry
+}
+
+// snapshotEntry is a single cache entry in a snapshot. The request and value
+// are encoded separately since their types depend on the cache type.
+type snapshotEntry struct {
+	Type      string
+	Key       string
+	Request   []byte
+	Value     []byte
+	Index     uint64
+	FetchedAt time.Time
+
+	// StaleSince is the time the entry was last known to be up to date,
+	// which is either when the snapshot was taken or when the background
+	// refresh lost contact with the servers.
+	StaleSince time.Time
+}
+
+// SaveSnapshot writes the entries of types registered with Persist to the
+// snapshot file. It does nothing if the cache has no snapshot file.
+func (c *Cache) SaveSnapshot() error {
+	if c.options.SnapshotPath == "" {
+		return nil
+	}
+
+	c.typesLock.RLock()
+	persisted := make(map[string]bool)
+	for name, tEntry := range c.types {
+		if tEntry.Opts.Persist && tEntry.Opts.Refresh {
+			persisted[name] = true
+		}
+	}
+	c.typesLock.RUnlock()
+
+	now := time.Now()
+	snap := snapshot{Version: snapshotVersion}
+	c.entriesLock.RLock()
+	for key, entry := range c.entries {
+		if !entry.Valid || entry.Request == nil || !persisted[entry.Type] {
+			continue
+		}
+		value, err := encodeMsgpack(entry.Value)
+		if err != nil {
+			c.entriesLock.RUnlock()
+			return fmt.Errorf("failed to encode %s value: %v", entry.Type, err)
+		}
+		staleSince := now
+		if !entry.RefreshLostContact.IsZero() {
+			staleSince = entry.RefreshLostContact
+		}
+		snap.Entries = append(snap.Entries, snapshotEntry{
+			Type:       entry.Type,
+			Key:        key,
+			Request:    entry.Request,
+			Value:      value,
+			Index:      entry.Index,
+			FetchedAt:  entry.FetchedAt,
+			StaleSince: staleSince,
+		})
+	}
+	c.entriesLock.RUnlock()
+
+	buf, err := encodeMsgpack(&snap)
+	if err != nil {
+		return err
+	}
+	if err := file.WriteAtomicWithPerms(c.options.SnapshotPath, buf, 0700); err != nil {
+		return err
+	}
+	metrics.SetGauge([]string{"consul", "cache", "snapshot", "entries"}, float32(len(snap.Entries)))
+	return nil
+}
+
+// LoadSnapshot restores the entries in the snapshot file, which must be
+// called after the types are registered. Restored entries are returned by
+// Get as stale until their background refresh succeeds, so they can be
+// served even if the servers can't be reached. Entries of types that are no
+// longer registered with Persist are skipped, as are entries that fail to
+// decode, such as after an upgrade changed their type, so that one bad entry
+// doesn't lose the rest. It returns the number of entries restored.
+func (c *Cache) LoadSnapshot() (int, error) {
+	if c.options.SnapshotPath == "" {
+		return 0, nil
+	}
+
+	buf, err := ioutil.ReadFile(c.options.SnapshotPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var snap snapshot
+	if err := decodeMsgpack(buf, &snap); err != nil {
+		return 0, fmt.Errorf("failed to decode cache snapshot: %v", err)
+	}
+	if snap.Version != snapshotVersion {
+		return 0, fmt.Errorf("unsupported cache snapshot version %d", snap.Version)
+	}
+
+	restored := 0
+	for _, se := range snap.Entries {
+		c.typesLock.RLock()
+		tEntry, ok := c.types[se.Type]
+		c.typesLock.RUnlock()
+		if !ok || !tEntry.Opts.Persist || !tEntry.Opts.Refresh {
+			continue
+		}
+
+		req := tEntry.Opts.NewRequest()
+		if err := decodeMsgpack(se.Request, req); err != nil {
+			c.logger.Printf("[WARN] agent: skipping cache snapshot entry, failed to decode %s request: %v", se.Type, err)
+			continue
+		}
+		value := tEntry.Opts.NewValue()
+		if err := decodeMsgpack(se.Value, value); err != nil {
+			c.logger.Printf("[WARN] agent: skipping cache snapshot entry, failed to decode %s value: %v", se.Type, err)
+			continue
+		}
+
+		info := req.CacheInfo()
+		key := c.entryKey(se.Type, &info)
+		if c.restoreEntry(tEntry, key, &info, value, se) {
+			restored++
+
+			// Refresh the entry in the background. The existing value
+			// keeps being served if this fails.
+			if _, err := c.fetch(se.Type, key, req, false, 0); err != nil {
+				c.logger.Printf("[WARN] agent: failed to refresh restored %s cache entry: %v", se.Type, err)
+			}
+		}
+	}
+	metrics.IncrCounter([]string{"consul", "cache", "snapshot", "restored"}, float32(restored))
+	return restored, nil
+}
+
+// restoreEntry adds an entry from a snapshot to the cache unless there is
+// already an entry for the key.
+func (c *Cache) restoreEntry(tEntry typeEntry, key string, info *RequestInfo, value interface{}, se snapshotEntry) bool {
+	c.entriesLock.Lock()
+	defer c.entriesLock.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		return false
+	}
+
+	entry := cacheEntry{
+		Type:               se.Type,
+		Request:            se.Request,
+		Datacenter:         info.Datacenter,
+		Key:                info.Key,
+		Value:              value,
+		Size:               estimateSize(value),
+		Index:              se.Index,
+		Valid:              true,
+		Waiter:             make(chan struct{}),
+		FetchedAt:          se.FetchedAt,
+		RefreshLostContact: se.StaleSince,
+		Expiry: &cacheEntryExpiry{
+			Key: key,
+			TTL: tEntry.Opts.LastGetTTL,
+		},
+	}
+	entry.Expiry.Reset()
+	heap.Push(c.entriesExpiryHeap, entry.Expiry)
+	entry.Expiry.LRUElement = c.entriesLRU.PushFront(entry.Expiry)
+	c.entries[key] = entry
+	c.entriesBytes += entry.Size
+	c.evictLRU(key)
+	return true
+}
+
+// runSnapshotLoop writes the snapshot periodically until the cache is
+// closed.
+func (c *Cache) runSnapshotLoop() {
+	ticker := time.NewTicker(c.options.SnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			if atomic.LoadUint32(&c.stopped) == 1 {
+				return
+			}
+			if err := c.SaveSnapshot(); err != nil {
+				c.logger.Printf("[WARN] agent: failed to save cache snapshot: %v", err)
+			}
+		}
+	}
+}
+
+func encodeMsgpack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := codec.NewEncoder(&buf, msgpackHandle).Encode(v)
+	return buf.Bytes(), err
+}
+
+func decodeMsgpack(buf []byte, out interface{}) error {
+	return codec.NewDecoder(bytes.NewReader(buf), msgpackHandle).Decode(out)
+}
@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testSnapshotRequest is a request that can be encoded in a snapshot, unlike
+// MockRequest.
+type testSnapshotRequest struct {
+	Datacenter string
+	Token      string
+	Key        string
+}
+
+func (r *testSnapshotRequest) CacheInfo() RequestInfo {
+	return RequestInfo{
+		Datacenter: r.Datacenter,
+		Token:      r.Token,
+		Key:        r.Key,
+	}
+}
+
+type testSnapshotValue struct {
+	Name string
+}
+
+func testSnapshotOptions() *RegisterOptions {
+	return &RegisterOptions{
+		Refresh:        true,
+		RefreshTimeout: 5 * time.Minute,
+		Persist:        true,
+		NewRequest:     func() Request { return &testSnapshotRequest{} },
+		NewValue:       func() interface{} { return &testSnapshotValue{} },
+	}
+}
+
+func TestCacheSnapshot(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "cache-snapshot")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache", "snapshot")
+
+	// Fill a cache with one persisted and one in-memory entry.
+	typ := TestType(t)
+	typ.On("Fetch", mock.Anything, mock.Anything).
+		Return(func(o FetchOptions, r Request) FetchResult {
+			// Block refreshes for a while like a blocking query would.
+			if o.MinIndex > 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			return FetchResult{Value: &testSnapshotValue{Name: "web"}, Index: 4}
+		}, nil)
+	c := New(&Options{SnapshotPath: path})
+	c.RegisterType("t", typ, testSnapshotOptions())
+	c.RegisterType("memory", typ, &RegisterOptions{Refresh: true})
+
+	req := &testSnapshotRequest{Datacenter: "dc1", Token: "token", Key: "web"}
+	_, _, err = c.Get("t", req)
+	require.NoError(err)
+	_, _, err = c.Get("memory", req)
+	require.NoError(err)
+
+	// Closing the cache writes the snapshot.
+	require.NoError(c.Close())
+	_, err = os.Stat(path)
+	require.NoError(err)
+
+	// Restore into a new cache whose servers can't be reached.
+	fetchCh := make(chan uint64, 10)
+	typ2 := TestType(t)
+	typ2.On("Fetch", mock.Anything, mock.Anything).
+		Return(func(o FetchOptions, r Request) FetchResult {
+			fetchCh <- o.MinIndex
+			time.Sleep(10 * time.Millisecond)
+			return FetchResult{}
+		}, func(o FetchOptions, r Request) error {
+			return errors.New("no servers")
+		})
+	c2 := New(&Options{SnapshotPath: path})
+	defer c2.Close()
+	c2.RegisterType("t", typ2, testSnapshotOptions())
+	c2.RegisterType("memory", typ2, &RegisterOptions{Refresh: true})
+
+	n, err := c2.LoadSnapshot()
+	require.NoError(err)
+	require.Equal(1, n)
+
+	c2.entriesLock.RLock()
+	require.Len(c2.entries, 1)
+	c2.entriesLock.RUnlock()
+
+	// The restored entry is refreshed in the background.
+	select {
+	case minIndex := <-fetchCh:
+		require.Equal(uint64(4), minIndex)
+	case <-time.After(time.Second):
+		t.Fatal("restored entry was not refreshed")
+	}
+
+	// The restored value is served as stale while the refresh fails.
+	result, meta, err := c2.Get("t", &testSnapshotRequest{Datacenter: "dc1", Token: "token", Key: "web"})
+	require.NoError(err)
+	require.Equal(&testSnapshotValue{Name: "web"}, result)
+	require.True(meta.Hit)
+	require.Equal(uint64(4), meta.Index)
+	require.True(meta.Age > 0)
+
+	// Entries are keyed by token, so other tokens don't see them.
+	c2.entriesLock.RLock()
+	_, ok := c2.entries[c2.entryKey("t", &RequestInfo{Datacenter: "dc1", Token: "other", Key: "web"})]
+	c2.entriesLock.RUnlock()
+	require.False(ok)
+}
+
+func TestCacheSnapshot_periodic(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "cache-snapshot")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot")
+
+	typ := TestType(t)
+	typ.On("Fetch", mock.Anything, mock.Anything).
+		Return(func(o FetchOptions, r Request) FetchResult {
+			if o.MinIndex > 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			return FetchResult{Value: &testSnapshotValue{Name: "web"}, Index: 4}
+		}, nil)
+	c := New(&Options{SnapshotPath: path, SnapshotInterval: 10 * time.Millisecond})
+	defer c.Close()
+	c.RegisterType("t", typ, testSnapshotOptions())
+
+	_, _, err = c.Get("t", &testSnapshotRequest{Key: "web"})
+	require.NoError(err)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("snapshot was not written")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCacheSnapshot_missing(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "cache-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := New(&Options{SnapshotPath: filepath.Join(dir, "snapshot")})
+	defer c.Close()
+	c.RegisterType("t", TestType(t), testSnapshotOptions())
+
+	n, err := c.LoadSnapshot()
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
+
+func TestCacheSnapshot_badEntry(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "cache-snapshot")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot")
+
+	// Write a snapshot where the first entries no longer decode, like after
+	// an upgrade changed their types.
+	goodReq, err := encodeMsgpack(&testSnapshotRequest{Key: "web"})
+	require.NoError(err)
+	goodValue, err := encodeMsgpack(&testSnapshotValue{Name: "web"})
+	require.NoError(err)
+	bad := []byte{0xc1}
+	snap := snapshot{
+		Version: snapshotVersion,
+		Entries: []snapshotEntry{
+			{Type: "t", Key: "bad-request", Request: bad, Value: goodValue, Index: 1},
+			{Type: "t", Key: "bad-value", Request: goodReq, Value: bad, Index: 2},
+			{Type: "t", Key: "good", Request: goodReq, Value: goodValue, Index: 3},
+		},
+	}
+	buf, err := encodeMsgpack(&snap)
+	require.NoError(err)
+	require.NoError(ioutil.WriteFile(path, buf, 0600))
+
+	typ := TestType(t)
+	typ.On("Fetch", mock.Anything, mock.Anything).
+		Return(func(o FetchOptions, r Request) FetchResult {
+			time.Sleep(10 * time.Millisecond)
+			return FetchResult{}
+		}, func(o FetchOptions, r Request) error {
+			return errors.New("no servers")
+		})
+	c := New(&Options{SnapshotPath: path})
+	defer c.Close()
+	c.RegisterType("t", typ, testSnapshotOptions())
+
+	// The bad entries are skipped and the good one is still restored.
+	n, err := c.LoadSnapshot()
+	require.NoError(err)
+	require.Equal(1, n)
+
+	result, meta, err := c.Get("t", &testSnapshotRequest{Key: "web"})
+	require.NoError(err)
+	require.Equal(&testSnapshotValue{Name: "web"}, result)
+	require.Equal(uint64(3), meta.Index)
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// typeStats are the counters kept for each registered type. They must be
+// accessed atomically.
+type typeStats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+}
+
+// Stats is a point in time view of the cache contents, used for debugging.
+type Stats struct {
+	// Count and Bytes are the number of entries in the cache and their
+	// total estimated size.
+	Count int
+	Bytes int64
+
+	// MaxEntries and MaxBytes are the configured limits, or zero if there
+	// is no limit.
+	MaxEntries int
+	MaxBytes   int64
+
+	// Types has the counters for each registered type, sorted by name.
+	Types []TypeStats
+
+	// Entries has the details of each entry, sorted by type and key.
+	Entries []EntryStats
+}
+
+// TypeStats are the statistics for a single registered type.
+type TypeStats struct {
+	Name  string
+	Count int
+	Bytes int64
+
+	// Hits and Misses count the Get calls that were and weren't served
+	// from the cache. Evictions counts the entries removed because the
+	// cache was over its limits and Expirations the entries that were not
+	// requested within the LastGetTTL.
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+}
+
+// EntryStats describes a single cache entry. The ACL token of the request
+// is deliberately left out.
+type EntryStats struct {
+	Type       string
+	Datacenter string
+	Key        string
+	Index      uint64
+	Size       int64
+	Valid      bool
+	Fetching   bool
+
+	// FetchedAt is when the current value was fetched and Age is how long
+	// the value has been stale for, which is the same as the age returned
+	// by Get.
+	FetchedAt time.Time
+	Age       time.Duration
+
+	// Expires is when the entry is removed unless it is requested again.
+	Expires time.Time
+
+	// Error is the error from the last fetch, if it failed.
+	Error string
+}
+
+// Stats returns the current statistics of the cache.
+func (c *Cache) Stats() *Stats {
+	stats := &Stats{
+		MaxEntries: c.options.MaxEntries,
+		MaxBytes:   c.options.MaxBytes,
+	}
+
+	c.typesLock.RLock()
+	types := make(map[string]*TypeStats, len(c.types))
+	refresh := make(map[string]bool, len(c.types))
+	for name, tEntry := range c.types {
+		types[name] = &TypeStats{
+			Name:        name,
+			Hits:        atomic.LoadUint64(&tEntry.Stats.Hits),
+			Misses:      atomic.LoadUint64(&tEntry.Stats.Misses),
+			Evictions:   atomic.LoadUint64(&tEntry.Stats.Evictions),
+			Expirations: atomic.LoadUint64(&tEntry.Stats.Expirations),
+		}
+		refresh[name] = tEntry.Opts.Refresh
+	}
+	c.typesLock.RUnlock()
+
+	c.entriesLock.RLock()
+	stats.Count = len(c.entries)
+	stats.Bytes = c.entriesBytes
+	for _, entry := range c.entries {
+		es := EntryStats{
+			Type:       entry.Type,
+			Datacenter: entry.Datacenter,
+			Key:        entry.Key,
+			Index:      entry.Index,
+			Size:       entry.Size,
+			Valid:      entry.Valid,
+			Fetching:   entry.Fetching,
+			FetchedAt:  entry.FetchedAt,
+		}
+		if refresh[entry.Type] {
+			if !entry.RefreshLostContact.IsZero() {
+				es.Age = time.Since(entry.RefreshLostContact)
+			}
+		} else if !entry.FetchedAt.IsZero() {
+			es.Age = time.Since(entry.FetchedAt)
+		}
+		if entry.Expiry != nil {
+			es.Expires = entry.Expiry.Expires
+		}
+		if entry.Error != nil {
+			es.Error = entry.Error.Error()
+		}
+		stats.Entries = append(stats.Entries, es)
+
+		if ts, ok := types[entry.Type]; ok {
+			ts.Count++
+			ts.Bytes += entry.Size
+		}
+	}
+	c.entriesLock.RUnlock()
+
+	for _, ts := range types {
+		stats.Types = append(stats.Types, *ts)
+	}
+	sort.Slice(stats.Types, func(i, j int) bool {
+		return stats.Types[i].Name < stats.Types[j].Name
+	})
+	sort.Slice(stats.Entries, func(i, j int) bool {
+		a, b := stats.Entries[i], stats.Entries[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Datacenter != b.Datacenter {
+			return a.Datacenter < b.Datacenter
+		}
+		return a.Key < b.Key
+	})
+	return stats
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/types"
 	"github.com/hashicorp/go-cleanhttp"
+	"golang.org/x/net/http2"
 )
 
 const (
@@ -52,6 +53,77 @@ type CheckNotifier interface {
 	UpdateCheck(checkID types.CheckID, status, output string)
 }
 
+// StatusHandler is a CheckNotifier that only passes a status change on to
+// the inner notifier after the new status has been seen a number of times
+// in a row, so that a single failed or successful check doesn't make the
+// check flap. Until then the previous status is kept and the output says how
+// many more results are needed. Warning and critical results both count as
+// failures.
+type StatusHandler struct {
+	inner                  CheckNotifier
+	logger                 *log.Logger
+	successBeforePassing   int
+	failuresBeforeCritical int
+
+	lock            sync.Mutex
+	status          string
+	successCounter  int
+	failuresCounter int
+}
+
+// NewStatusHandler returns a StatusHandler for a check whose current status
+// is the given one. Thresholds of zero or one pass every status on
+// immediately.
+func NewStatusHandler(inner CheckNotifier, logger *log.Logger, successBeforePassing, failuresBeforeCritical int, status string) *StatusHandler {
+	return &StatusHandler{
+		inner:                  inner,
+		logger:                 logger,
+		successBeforePassing:   successBeforePassing,
+		failuresBeforeCritical: failuresBeforeCritical,
+		status:                 status,
+	}
+}
+
+// UpdateCheck is used to record the result of a check.
+func (s *StatusHandler) UpdateCheck(checkID types.CheckID, status, output string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var count, threshold int
+	if status == api.HealthPassing {
+		s.successCounter++
+		s.failuresCounter = 0
+		count, threshold = s.successCounter, s.successBeforePassing
+	} else {
+		s.failuresCounter++
+		s.successCounter = 0
+		count, threshold = s.failuresCounter, s.failuresBeforeCritical
+	}
+
+	if count >= threshold || status == s.status {
+		s.status = status
+		s.inner.UpdateCheck(checkID, status, output)
+		return
+	}
+
+	s.logger.Printf("[WARN] agent: Check %q was %s but has not reached the threshold %d/%d",
+		checkID, status, count, threshold)
+	s.inner.UpdateCheck(checkID, s.status,
+		fmt.Sprintf("%s\n\nCheck was %s %d of %d times needed to change status", output, status, count, threshold))
+}
+
+// checkTimeout returns the timeout for a network check. For long (>10s)
+// interval checks the timeout is 10s, otherwise the timeout is the interval.
+// This means that a check *should* return before the next check begins.
+func checkTimeout(timeout, interval time.Duration) time.Duration {
+	if timeout > 0 && timeout < interval {
+		return timeout
+	} else if interval < 10*time.Second {
+		return interval
+	}
+	return 10 * time.Second
+}
+
 // CheckMonitor is used to periodically invoke a script to
 // determine the health of a given check. It is compatible with
 // nagios plugins and expects the output in the same format.
@@ -327,18 +399,9 @@ func (c *CheckHTTP) Start() {
 
 		// Create the HTTP client.
 		c.httpClient = &http.Client{
-			Timeout:   10 * time.Second,
+			Timeout:   checkTimeout(c.Timeout, c.Interval),
 			Transport: trans,
 		}
-
-		// For long (>10s) interval checks the http timeout is 10s, otherwise the
-		// timeout is the interval. This means that a check *should* return
-		// before the next check begins.
-		if c.Timeout > 0 && c.Timeout < c.Interval {
-			c.httpClient.Timeout = c.Timeout
-		} else if c.Interval < 10*time.Second {
-			c.httpClient.Timeout = c.Interval
-		}
 	}
 
 	c.stop = false
@@ -440,6 +503,39 @@ func (c *CheckHTTP) check() {
 	}
 }
 
+// CheckH2C is used to periodically make an HTTP/2 request over cleartext
+// TCP to determine the health of a given check. The server must accept
+// HTTP/2 with prior knowledge, without an upgrade from HTTP/1.1. The check
+// status is determined by the response code in the same way as CheckHTTP.
+type CheckH2C struct {
+	CheckHTTP
+}
+
+// Start is used to start an H2C check.
+// The check runs until stop is called
+func (c *CheckH2C) Start() {
+	c.stopLock.Lock()
+	if c.httpClient == nil {
+		timeout := checkTimeout(c.Timeout, c.Interval)
+
+		// The transport only speaks HTTP/2, so dialing the plain TCP
+		// connection in place of a TLS one gives us h2c.
+		trans := &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.DialTimeout(network, addr, timeout)
+			},
+		}
+		c.httpClient = &http.Client{
+			Timeout:   timeout,
+			Transport: trans,
+		}
+	}
+	c.stopLock.Unlock()
+
+	c.CheckHTTP.Start()
+}
+
 // CheckTCP is used to periodically make an TCP/UDP connection to
 // determine the health of a given check.
 // The check is passing if the connection succeeds
@@ -522,6 +618,99 @@ func (c *CheckTCP) check() {
 	c.Notify.UpdateCheck(c.CheckID, api.HealthPassing, fmt.Sprintf("TCP connect %s: Success", c.TCP))
 }
 
+// CheckUDP is used to periodically send a datagram to a UDP service to
+// determine the health of a given check.
+// The check is passing if the service replies, or if no reply arrives
+// before the timeout and the host didn't report the port as unreachable.
+// The check is critical if the port is unreachable or the datagram can't
+// be sent.
+type CheckUDP struct {
+	Notify   CheckNotifier
+	CheckID  types.CheckID
+	UDP      string
+	Payload  string
+	Interval time.Duration
+	Timeout  time.Duration
+	Logger   *log.Logger
+
+	timeout  time.Duration
+	stop     bool
+	stopCh   chan struct{}
+	stopLock sync.Mutex
+}
+
+// Start is used to start a UDP check.
+// The check runs until stop is called
+func (c *CheckUDP) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	c.timeout = checkTimeout(c.Timeout, c.Interval)
+	c.stop = false
+	c.stopCh = make(chan struct{})
+	go c.run()
+}
+
+// Stop is used to stop a UDP check.
+func (c *CheckUDP) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	if !c.stop {
+		c.stop = true
+		close(c.stopCh)
+	}
+}
+
+// run is invoked by a goroutine to run until Stop() is called
+func (c *CheckUDP) run() {
+	// Get the randomized initial pause time
+	initialPauseTime := lib.RandomStagger(c.Interval)
+	next := time.After(initialPauseTime)
+	for {
+		select {
+		case <-next:
+			c.check()
+			next = time.After(c.Interval)
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// check is invoked periodically to perform the UDP check
+func (c *CheckUDP) check() {
+	conn, err := net.DialTimeout(`udp`, c.UDP, c.timeout)
+	if err != nil {
+		c.Logger.Printf("[WARN] agent: Check %q socket connection failed: %s", c.CheckID, err)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
+		return
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := conn.Write([]byte(c.Payload)); err != nil {
+		c.Logger.Printf("[WARN] agent: Check %q failed to send datagram: %s", c.CheckID, err)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
+		return
+	}
+
+	// Since the socket is connected, an ICMP port unreachable message sent
+	// back by the host is returned as an error from the read.
+	buf := make([]byte, BufSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			c.Logger.Printf("[DEBUG] agent: Check %q is passing", c.CheckID)
+			c.Notify.UpdateCheck(c.CheckID, api.HealthPassing, fmt.Sprintf("UDP send %s: Success, no reply", c.UDP))
+			return
+		}
+		c.Logger.Printf("[WARN] agent: Check %q is now critical: %s", c.CheckID, err)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
+		return
+	}
+	c.Logger.Printf("[DEBUG] agent: Check %q is passing", c.CheckID)
+	c.Notify.UpdateCheck(c.CheckID, api.HealthPassing, fmt.Sprintf("UDP send %s: Success, received %d byte reply", c.UDP, n))
+}
+
 // CheckDocker is used to periodically invoke a script to
 // determine the health of an application running inside a
 // Docker Container. We assume that the script is compatible
@@ -707,3 +896,86 @@ func (c *CheckGRPC) Stop() {
 		close(c.stopCh)
 	}
 }
+
+// CheckOSService is used to periodically query the state of an OS service
+// to determine the health of a given check.
+// The check is passing if the service is active.
+// The check is warning if the service is starting or reloading.
+// The check is critical if the service is in any other state or if its
+// state can't be queried.
+type CheckOSService struct {
+	Notify    CheckNotifier
+	CheckID   types.CheckID
+	OSService string
+	Interval  time.Duration
+	Timeout   time.Duration
+	Logger    *log.Logger
+	Client    OSServiceClient
+
+	timeout  time.Duration
+	stop     bool
+	stopCh   chan struct{}
+	stopLock sync.Mutex
+}
+
+// Start is used to start an OS service check.
+// The check runs until stop is called
+func (c *CheckOSService) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	c.timeout = checkTimeout(c.Timeout, c.Interval)
+	c.stop = false
+	c.stopCh = make(chan struct{})
+	go c.run()
+}
+
+// Stop is used to stop an OS service check.
+func (c *CheckOSService) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	if !c.stop {
+		c.stop = true
+		close(c.stopCh)
+	}
+}
+
+// run is invoked by a goroutine to run until Stop() is called
+func (c *CheckOSService) run() {
+	// Get the randomized initial pause time
+	initialPauseTime := lib.RandomStagger(c.Interval)
+	next := time.After(initialPauseTime)
+	for {
+		select {
+		case <-next:
+			c.check()
+			next = time.After(c.Interval)
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// check is invoked periodically to perform the OS service check
+func (c *CheckOSService) check() {
+	state, err := c.Client.ServiceState(c.OSService, c.timeout)
+	if err != nil {
+		c.Logger.Printf("[WARN] agent: Check %q failed to query service %q: %s", c.CheckID, c.OSService, err)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
+		return
+	}
+
+	result := fmt.Sprintf("Service %s: %s", c.OSService, state)
+	switch state.Status() {
+	case api.HealthPassing:
+		c.Logger.Printf("[DEBUG] agent: Check %q is passing", c.CheckID)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthPassing, result)
+
+	case api.HealthWarning:
+		c.Logger.Printf("[WARN] agent: Check %q is now warning", c.CheckID)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthWarning, result)
+
+	default:
+		c.Logger.Printf("[WARN] agent: Check %q is now critical", c.CheckID)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, result)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/hashicorp/consul/types"
 	uuid "github.com/hashicorp/go-uuid"
+	"golang.org/x/net/http2"
 )
 
 func uniqueID() string {
@@ -810,3 +811,360 @@ func TestCheck_Docker(t *testing.T) {
 		})
 	}
 }
+
+func mockUDPServer(t *testing.T, reply bool) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if reply {
+				conn.WriteTo(buf[:n], addr)
+			}
+		}
+	}()
+	return conn
+}
+
+func expectUDPStatus(t *testing.T, udp string, status string, output string) {
+	notif := mock.NewNotify()
+	check := &CheckUDP{
+		Notify:   notif,
+		CheckID:  types.CheckID("foo"),
+		UDP:      udp,
+		Payload:  "ping",
+		Interval: 50 * time.Millisecond,
+		Logger:   log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+	}
+	check.Start()
+	defer check.Stop()
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notif.Updates("foo"), 2; got < want {
+			r.Fatalf("got %d updates want at least %d", got, want)
+		}
+		if got, want := notif.State("foo"), status; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+		if got := notif.Output("foo"); !strings.Contains(got, output) {
+			r.Fatalf("got output %q want %q", got, output)
+		}
+	})
+}
+
+func TestCheckUDP(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reply", func(t *testing.T) {
+		server := mockUDPServer(t, true)
+		defer server.Close()
+		expectUDPStatus(t, server.LocalAddr().String(), api.HealthPassing, "received 4 byte reply")
+	})
+
+	t.Run("no reply", func(t *testing.T) {
+		server := mockUDPServer(t, false)
+		defer server.Close()
+		expectUDPStatus(t, server.LocalAddr().String(), api.HealthPassing, "no reply")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		// Nothing is listening on the port once the server is closed, so
+		// the host answers with an ICMP port unreachable.
+		server := mockUDPServer(t, false)
+		addr := server.LocalAddr().String()
+		server.Close()
+		expectUDPStatus(t, addr, api.HealthCritical, "refused")
+	})
+}
+
+func mockH2CServer(t *testing.T, code int) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			w.WriteHeader(http.StatusHTTPVersionNotSupported)
+			return
+		}
+		w.WriteHeader(code)
+		fmt.Fprint(w, r.Proto)
+	})
+	server := &http2.Server{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+		}
+	}()
+	return listener
+}
+
+func TestCheckH2C(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc   string
+		code   int
+		status string
+	}{
+		{"ok", http.StatusOK, api.HealthPassing},
+		{"too many requests", http.StatusTooManyRequests, api.HealthWarning},
+		{"error", http.StatusServiceUnavailable, api.HealthCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := mockH2CServer(t, tt.code)
+			defer server.Close()
+
+			notif := mock.NewNotify()
+			check := &CheckH2C{
+				CheckHTTP: CheckHTTP{
+					Notify:   notif,
+					CheckID:  types.CheckID("foo"),
+					HTTP:     "http://" + server.Addr().String() + "/health",
+					Interval: 10 * time.Millisecond,
+					Logger:   log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+				},
+			}
+			check.Start()
+			defer check.Stop()
+			retry.Run(t, func(r *retry.R) {
+				if got, want := notif.State("foo"), tt.status; got != want {
+					r.Fatalf("got state %q want %q", got, want)
+				}
+				if got := notif.Output("foo"); !strings.Contains(got, "HTTP/2.0") {
+					r.Fatalf("got output %q want HTTP/2.0", got)
+				}
+			})
+		})
+	}
+
+	t.Run("http/1.1 only", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		notif := mock.NewNotify()
+		check := &CheckH2C{
+			CheckHTTP: CheckHTTP{
+				Notify:   notif,
+				CheckID:  types.CheckID("foo"),
+				HTTP:     server.URL,
+				Interval: 10 * time.Millisecond,
+				Logger:   log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+			},
+		}
+		check.Start()
+		defer check.Stop()
+		retry.Run(t, func(r *retry.R) {
+			if got, want := notif.Updates("foo"), 1; got < want {
+				r.Fatalf("got %d updates want at least %d", got, want)
+			}
+			if got, want := notif.State("foo"), api.HealthCritical; got != want {
+				r.Fatalf("got state %q want %q", got, want)
+			}
+		})
+	})
+}
+
+type fakeOSServiceClient struct {
+	state *OSServiceState
+	err   error
+}
+
+func (c *fakeOSServiceClient) ServiceState(name string, timeout time.Duration) (*OSServiceState, error) {
+	return c.state, c.err
+}
+
+func TestCheckOSService(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc   string
+		state  *OSServiceState
+		err    error
+		status string
+		output string
+	}{
+		{
+			"running",
+			&OSServiceState{LoadState: "loaded", ActiveState: "active", SubState: "running"},
+			nil,
+			api.HealthPassing,
+			"Service nginx: active (running)",
+		},
+		{
+			"starting",
+			&OSServiceState{LoadState: "loaded", ActiveState: "activating", SubState: "start"},
+			nil,
+			api.HealthWarning,
+			"Service nginx: activating (start)",
+		},
+		{
+			"reloading",
+			&OSServiceState{LoadState: "loaded", ActiveState: "reloading", SubState: "reload"},
+			nil,
+			api.HealthWarning,
+			"Service nginx: reloading (reload)",
+		},
+		{
+			"failed",
+			&OSServiceState{LoadState: "loaded", ActiveState: "failed", SubState: "failed"},
+			nil,
+			api.HealthCritical,
+			"Service nginx: failed (failed)",
+		},
+		{
+			"not found",
+			&OSServiceState{LoadState: "not-found", ActiveState: "inactive", SubState: "dead"},
+			nil,
+			api.HealthCritical,
+			"Service nginx: not-found",
+		},
+		{
+			"query error",
+			nil,
+			fmt.Errorf("failed to connect to the system bus"),
+			api.HealthCritical,
+			"failed to connect to the system bus",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			notif := mock.NewNotify()
+			check := &CheckOSService{
+				Notify:    notif,
+				CheckID:   types.CheckID("foo"),
+				OSService: "nginx",
+				Interval:  10 * time.Millisecond,
+				Logger:    log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+				Client:    &fakeOSServiceClient{state: tt.state, err: tt.err},
+			}
+			check.Start()
+			defer check.Stop()
+			retry.Run(t, func(r *retry.R) {
+				if got, want := notif.State("foo"), tt.status; got != want {
+					r.Fatalf("got state %q want %q", got, want)
+				}
+				if got, want := notif.Output("foo"), tt.output; got != want {
+					r.Fatalf("got output %q want %q", got, want)
+				}
+			})
+		})
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	t.Parallel()
+
+	type result struct {
+		status     string
+		wantStatus string
+	}
+	tests := []struct {
+		desc                   string
+		successBeforePassing   int
+		failuresBeforeCritical int
+		results                []result
+	}{
+		{
+			"no thresholds",
+			0, 0,
+			[]result{
+				{api.HealthPassing, api.HealthPassing},
+				{api.HealthCritical, api.HealthCritical},
+				{api.HealthWarning, api.HealthWarning},
+				{api.HealthPassing, api.HealthPassing},
+			},
+		},
+		{
+			"success threshold",
+			3, 0,
+			[]result{
+				{api.HealthPassing, api.HealthCritical},
+				{api.HealthPassing, api.HealthCritical},
+				{api.HealthCritical, api.HealthCritical},
+				{api.HealthPassing, api.HealthCritical},
+				{api.HealthPassing, api.HealthCritical},
+				{api.HealthPassing, api.HealthPassing},
+				{api.HealthPassing, api.HealthPassing},
+			},
+		},
+		{
+			"failure threshold",
+			0, 2,
+			[]result{
+				{api.HealthPassing, api.HealthPassing},
+				{api.HealthCritical, api.HealthPassing},
+				{api.HealthPassing, api.HealthPassing},
+				{api.HealthCritical, api.HealthPassing},
+				{api.HealthCritical, api.HealthCritical},
+				{api.HealthPassing, api.HealthPassing},
+			},
+		},
+		{
+			"warnings count as failures",
+			0, 2,
+			[]result{
+				{api.HealthPassing, api.HealthPassing},
+				{api.HealthWarning, api.HealthPassing},
+				{api.HealthCritical, api.HealthCritical},
+				{api.HealthWarning, api.HealthWarning},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			notif := mock.NewNotify()
+			logger := log.New(ioutil.Discard, uniqueID(), log.LstdFlags)
+			handler := NewStatusHandler(notif, logger, tt.successBeforePassing, tt.failuresBeforeCritical, api.HealthCritical)
+			for i, r := range tt.results {
+				handler.UpdateCheck("foo", r.status, "output")
+				if got := notif.State("foo"); got != r.wantStatus {
+					t.Fatalf("result %d: got state %q want %q", i, got, r.wantStatus)
+				}
+				held := r.status != r.wantStatus
+				if got := notif.Output("foo"); held != strings.Contains(got, "times needed to change status") {
+					t.Fatalf("result %d: bad output %q", i, got)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusHandler_CheckTCP(t *testing.T) {
+	t.Parallel()
+
+	listener := mockTCPServer(`tcp`)
+	defer listener.Close()
+
+	notif := mock.NewNotify()
+	logger := log.New(ioutil.Discard, uniqueID(), log.LstdFlags)
+	check := &CheckTCP{
+		Notify:   NewStatusHandler(notif, logger, 3, 0, api.HealthCritical),
+		CheckID:  types.CheckID("foo"),
+		TCP:      listener.Addr().String(),
+		Interval: 10 * time.Millisecond,
+		Logger:   logger,
+	}
+	check.Start()
+	defer check.Stop()
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notif.Updates("foo"), 3; got < want {
+			r.Fatalf("got %d updates want at least %d", got, want)
+		}
+		if got, want := notif.State("foo"), api.HealthPassing; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+	})
+}
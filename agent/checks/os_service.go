@@ -0,0 +1,163 @@
+package checks
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	systemdDest      = "org.freedesktop.systemd1"
+	systemdPath      = "/org/freedesktop/systemd1"
+	systemdManager   = "org.freedesktop.systemd1.Manager"
+	systemdUnit      = "org.freedesktop.systemd1.Unit"
+	dbusPropertiesIf = "org.freedesktop.DBus.Properties"
+)
+
+// OSServiceClient looks up the state of services managed by the operating
+// system.
+type OSServiceClient interface {
+	ServiceState(name string, timeout time.Duration) (*OSServiceState, error)
+}
+
+// OSServiceState is the state of an OS service, using the systemd unit
+// states.
+type OSServiceState struct {
+	// LoadState is whether the unit definition was loaded, e.g. "loaded" or
+	// "not-found".
+	LoadState string
+
+	// ActiveState is the high level state of the unit, e.g. "active",
+	// "activating" or "failed".
+	ActiveState string
+
+	// SubState is the unit type specific state, e.g. "running" or "exited".
+	SubState string
+}
+
+// Status maps the state to a health check status.
+func (s *OSServiceState) Status() string {
+	if s.LoadState != "loaded" {
+		return api.HealthCritical
+	}
+	switch s.ActiveState {
+	case "active":
+		return api.HealthPassing
+	case "activating", "reloading":
+		return api.HealthWarning
+	default:
+		return api.HealthCritical
+	}
+}
+
+func (s *OSServiceState) String() string {
+	if s.LoadState != "loaded" {
+		return s.LoadState
+	}
+	return fmt.Sprintf("%s (%s)", s.ActiveState, s.SubState)
+}
+
+// SystemdClient is an OSServiceClient that queries systemd over D-Bus. The
+// connection to the system bus is made on first use and made again after a
+// failed query.
+type SystemdClient struct {
+	lock sync.Mutex
+	conn *dbus.Conn
+}
+
+// NewSystemdClient returns a client for the systemd instance on the system
+// bus.
+func NewSystemdClient() *SystemdClient {
+	return &SystemdClient{}
+}
+
+// ServiceState returns the state of the systemd unit with the given name.
+// Names without a unit type suffix are taken to be services, as with
+// systemctl.
+func (c *SystemdClient) ServiceState(name string, timeout time.Duration) (*OSServiceState, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !strings.Contains(name, ".") {
+		name += ".service"
+	}
+
+	state, err := c.serviceState(name, timeout)
+	if err != nil && c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	return state, err
+}
+
+func (c *SystemdClient) serviceState(name string, timeout time.Duration) (*OSServiceState, error) {
+	if c.conn == nil {
+		conn, err := dbus.SystemBusPrivate()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to the system bus: %v", err)
+		}
+		if err := conn.Auth(nil); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to authenticate to the system bus: %v", err)
+		}
+		if err := conn.Hello(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to register with the system bus: %v", err)
+		}
+		c.conn = conn
+	}
+
+	// LoadUnit returns the unit even if it isn't running, unlike GetUnit.
+	var path dbus.ObjectPath
+	manager := c.conn.Object(systemdDest, systemdPath)
+	if err := systemdCall(manager, timeout, systemdManager+".LoadUnit", name).Store(&path); err != nil {
+		return nil, fmt.Errorf("failed to load unit %q: %v", name, err)
+	}
+
+	var props map[string]dbus.Variant
+	unit := c.conn.Object(systemdDest, path)
+	if err := systemdCall(unit, timeout, dbusPropertiesIf+".GetAll", systemdUnit).Store(&props); err != nil {
+		return nil, fmt.Errorf("failed to get state of unit %q: %v", name, err)
+	}
+
+	var state OSServiceState
+	for prop, dst := range map[string]*string{
+		"LoadState":   &state.LoadState,
+		"ActiveState": &state.ActiveState,
+		"SubState":    &state.SubState,
+	} {
+		v, ok := props[prop].Value().(string)
+		if !ok {
+			return nil, fmt.Errorf("unit %q has no %s property", name, prop)
+		}
+		*dst = v
+	}
+	return &state, nil
+}
+
+// Close closes the connection to the system bus, if any.
+func (c *SystemdClient) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// systemdCall calls a method on the object, giving up after the timeout.
+func systemdCall(obj dbus.BusObject, timeout time.Duration, method string, args ...interface{}) *dbus.Call {
+	call := obj.Go(method, 0, make(chan *dbus.Call, 1), args...)
+	select {
+	case call = <-call.Done:
+		return call
+	case <-time.After(timeout):
+		return &dbus.Call{Err: fmt.Errorf("timed out after %v", timeout)}
+	}
+}
@@ -27,7 +27,16 @@ func FixupCheckType(raw interface{}) error {
 		"deregister_critical_service_after": "DeregisterCriticalServiceAfter",
 		"docker_container_id":               "DockerContainerID",
 		"tls_skip_verify":                   "TLSSkipVerify",
+		"tls_server_name":                   "TLSServerName",
+		"ca_cert":                           "CACert",
+		"client_cert":                       "ClientCert",
+		"client_key":                        "ClientKey",
+		"use_connect_cert":                  "UseConnectCert",
+		"udp_payload":                       "UDPPayload",
+		"os_service":                        "OSService",
 		"service_id":                        "ServiceID",
+		"success_before_passing":            "SuccessBeforePassing",
+		"failures_before_critical":          "FailuresBeforeCritical",
 	})
 
 	parseDuration := func(v interface{}) (time.Duration, error) {
@@ -353,6 +353,7 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 
 	// determine port values and replace values <= 0 and > 65535 with -1
 	dnsPort := b.portVal("ports.dns", c.Ports.DNS)
+	dnsTLSPort := b.portVal("ports.dns_tls", c.Ports.DNSTLS)
 	httpPort := b.portVal("ports.http", c.Ports.HTTP)
 	httpsPort := b.portVal("ports.https", c.Ports.HTTPS)
 	serverPort := b.portVal("ports.server", c.Ports.Server)
@@ -460,6 +461,7 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 	// determine client addresses
 	clientAddrs := b.expandIPs("client_addr", c.ClientAddr)
 	dnsAddrs := b.makeAddrs(b.expandAddrs("addresses.dns", c.Addresses.DNS), clientAddrs, dnsPort)
+	dnsTLSAddrs := b.makeAddrs(b.expandAddrs("addresses.dns", c.Addresses.DNS), clientAddrs, dnsTLSPort)
 	httpAddrs := b.makeAddrs(b.expandAddrs("addresses.http", c.Addresses.HTTP), clientAddrs, httpPort)
 	httpsAddrs := b.makeAddrs(b.expandAddrs("addresses.https", c.Addresses.HTTPS), clientAddrs, httpsPort)
 	grpcAddrs := b.makeAddrs(b.expandAddrs("addresses.grpc", c.Addresses.GRPC), clientAddrs, grpcPort)
@@ -526,6 +528,17 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		})
 	}
 
+	// kv history
+	var kvHistory []structs.KVSHistoryConfig
+	for _, h := range c.KVHistory {
+		prefix := b.stringVal(h.Prefix)
+		kvHistory = append(kvHistory, structs.KVSHistoryConfig{
+			Prefix:      prefix,
+			MaxVersions: b.intVal(h.MaxVersions),
+			Retention:   b.durationVal(fmt.Sprintf("kv_history[%s].retention", prefix), h.Retention),
+		})
+	}
+
 	// Parse the metric filters
 	var telemetryAllowedPrefixes, telemetryBlockedPrefixes []string
 	for _, rule := range c.Telemetry.PrefixFilter {
@@ -686,6 +699,7 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		ACLReplicationToken:       b.stringValWithDefault(c.ACL.Tokens.Replication, b.stringVal(c.ACLReplicationToken)),
 		ACLTokenTTL:               b.durationValWithDefault("acl.token_ttl", c.ACL.TokenTTL, b.durationVal("acl_ttl", c.ACLTTL)),
 		ACLPolicyTTL:              b.durationVal("acl.policy_ttl", c.ACL.PolicyTTL),
+		ACLRoleTTL:                b.durationVal("acl.role_ttl", c.ACL.RoleTTL),
 		ACLToken:                  b.stringValWithDefault(c.ACL.Tokens.Default, b.stringVal(c.ACLToken)),
 		ACLTokenReplication:       b.boolValWithDefault(c.ACL.TokenReplication, b.boolValWithDefault(c.EnableACLReplication, enableTokenReplication)),
 		ACLEnableTokenPersistence: b.boolValWithDefault(c.ACL.EnableTokenPersistence, false),
@@ -702,6 +716,7 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		// DNS
 		DNSAddrs:              dnsAddrs,
 		DNSAllowStale:         b.boolVal(c.DNS.AllowStale),
+		DNSAltDomain:          b.stringVal(c.DNSAltDomain),
 		DNSARecordLimit:       b.intVal(c.DNS.ARecordLimit),
 		DNSDisableCompression: b.boolVal(c.DNS.DisableCompression),
 		DNSDomain:             b.stringVal(c.DNSDomain),
@@ -710,6 +725,9 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		DNSNodeTTL:            b.durationVal("dns_config.node_ttl", c.DNS.NodeTTL),
 		DNSOnlyPassing:        b.boolVal(c.DNS.OnlyPassing),
 		DNSPort:               dnsPort,
+		DNSTLSAddrs:           dnsTLSAddrs,
+		DNSTLSPort:            dnsTLSPort,
+		DNSEnableDoH:          b.boolVal(c.DNS.EnableDoH),
 		DNSRecursorTimeout:    b.durationVal("recursor_timeout", c.DNS.RecursorTimeout),
 		DNSRecursors:          dnsRecursors,
 		DNSServiceTTL:         dnsServiceTTL,
@@ -718,6 +736,11 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		DNSNodeMetaTXT:        b.boolValWithDefault(c.DNS.NodeMetaTXT, true),
 		DNSUseCache:           b.boolVal(c.DNS.UseCache),
 		DNSCacheMaxAge:        b.durationVal("dns_config.cache_max_age", c.DNS.CacheMaxAge),
+		DNSNegativeCacheSize:  b.intVal(c.DNS.NegativeCacheSize),
+		DNSNegativeCacheTTL:   b.durationVal("dns_config.negative_cache_ttl", c.DNS.NegativeCacheTTL),
+		DNSSECEnabled:         b.boolVal(c.DNS.DNSSEC.Enabled),
+		DNSSECKeyFile:         b.stringVal(c.DNS.DNSSEC.KeyFile),
+		DNSSECPrivateKeyFile:  b.stringVal(c.DNS.DNSSEC.PrivateKeyFile),
 
 		// HTTP
 		HTTPPort:            httpPort,
@@ -761,6 +784,10 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		BindAddr:                                bindAddr,
 		Bootstrap:                               b.boolVal(c.Bootstrap),
 		BootstrapExpect:                         b.intVal(c.BootstrapExpect),
+		CacheMaxBytes:                           b.intVal(c.Cache.MaxBytes),
+		CacheMaxEntries:                         b.intVal(c.Cache.MaxEntries),
+		CachePersist:                            b.boolVal(c.Cache.Persist),
+		CachePersistInterval:                    b.durationVal("cache.persist_interval", c.Cache.PersistInterval),
 		CAFile:                                  b.stringVal(c.CAFile),
 		CAPath:                                  b.stringVal(c.CAPath),
 		CertFile:                                b.stringVal(c.CertFile),
@@ -796,6 +823,7 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		EnableDebug:                             b.boolVal(c.EnableDebug),
 		EnableRemoteScriptChecks:                enableRemoteScriptChecks,
 		EnableLocalScriptChecks:                 enableLocalScriptChecks,
+		EnableRemoteCheckTLSFiles:               b.boolVal(c.EnableRemoteCheckTLSFiles),
 		EnableSyslog:                            b.boolVal(c.EnableSyslog),
 		EnableUI:                                b.boolVal(c.UI),
 		EncryptKey:                              b.stringVal(c.EncryptKey),
@@ -804,6 +832,7 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		GRPCPort:                                grpcPort,
 		GRPCAddrs:                               grpcAddrs,
 		KeyFile:                                 b.stringVal(c.KeyFile),
+		KVHistory:                               kvHistory,
 		LeaveDrainTime:                          b.durationVal("performance.leave_drain_time", c.Performance.LeaveDrainTime),
 		LeaveOnTerm:                             leaveOnTerm,
 		LogLevel:                                b.stringVal(c.LogLevel),
@@ -819,9 +848,14 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		RPCAdvertiseAddr:                        rpcAdvertiseAddr,
 		RPCBindAddr:                             rpcBindAddr,
 		RPCHoldTimeout:                          b.durationVal("performance.rpc_hold_timeout", c.Performance.RPCHoldTimeout),
+		RPCMaxBlockingQueries:                   b.intVal(c.Limits.RPCMaxBlockingQueries),
 		RPCMaxBurst:                             b.intVal(c.Limits.RPCMaxBurst),
 		RPCProtocol:                             b.intVal(c.RPCProtocol),
 		RPCRateLimit:                            rate.Limit(b.float64Val(c.Limits.RPCRate)),
+		RPCServerMaxBurst:                       b.intVal(c.Limits.RPCServerMaxBurst),
+		RPCServerRateLimit:                      rate.Limit(b.float64Val(c.Limits.RPCServerRate)),
+		RPCServerSourceMaxBurst:                 b.intVal(c.Limits.RPCServerMaxBurstPerSource),
+		RPCServerSourceRateLimit:                rate.Limit(b.float64Val(c.Limits.RPCServerRatePerSource)),
 		RaftProtocol:                            b.intVal(c.RaftProtocol),
 		RaftSnapshotThreshold:                   b.intVal(c.RaftSnapshotThreshold),
 		RaftSnapshotInterval:                    b.durationVal("raft_snapshot_interval", c.RaftSnapshotInterval),
@@ -947,6 +981,31 @@ func (b *Builder) Validate(rt RuntimeConfig) error {
 	if rt.AutopilotMaxTrailingLogs < 0 {
 		return fmt.Errorf("autopilot.max_trailing_logs cannot be %d. Must be greater than or equal to zero", rt.AutopilotMaxTrailingLogs)
 	}
+	if rt.CacheMaxBytes < 0 {
+		return fmt.Errorf("cache.max_bytes cannot be %d. Must be greater than or equal to zero", rt.CacheMaxBytes)
+	}
+	if rt.CacheMaxEntries < 0 {
+		return fmt.Errorf("cache.max_entries cannot be %d. Must be greater than or equal to zero", rt.CacheMaxEntries)
+	}
+	if rt.CachePersist && rt.CachePersistInterval <= 0 {
+		return fmt.Errorf("cache.persist_interval cannot be %s. Must be positive", rt.CachePersistInterval)
+	}
+	kvHistoryPrefixes := make(map[string]bool)
+	for _, h := range rt.KVHistory {
+		if kvHistoryPrefixes[h.Prefix] {
+			return fmt.Errorf("kv_history[%s] is defined more than once", h.Prefix)
+		}
+		kvHistoryPrefixes[h.Prefix] = true
+		if h.MaxVersions < 0 {
+			return fmt.Errorf("kv_history[%s].max_versions cannot be %d. Must be greater than or equal to zero", h.Prefix, h.MaxVersions)
+		}
+		if h.Retention < 0 {
+			return fmt.Errorf("kv_history[%s].retention cannot be %s. Must be greater than or equal to zero", h.Prefix, h.Retention)
+		}
+		if h.MaxVersions == 0 && h.Retention == 0 {
+			return fmt.Errorf("kv_history[%s] must set max_versions or retention", h.Prefix)
+		}
+	}
 	if rt.ACLDatacenter != "" && !reDatacenter.MatchString(rt.ACLDatacenter) {
 		return fmt.Errorf("acl_datacenter cannot be %q. Please use only [a-z0-9-_].", rt.ACLDatacenter)
 	}
@@ -959,9 +1018,27 @@ func (b *Builder) Validate(rt RuntimeConfig) error {
 	if rt.DNSUDPAnswerLimit < 0 {
 		return fmt.Errorf("dns_config.udp_answer_limit cannot be %d. Must be greater than or equal to zero", rt.DNSUDPAnswerLimit)
 	}
+	if rt.DNSAltDomain != "" && strings.EqualFold(strings.TrimSuffix(rt.DNSAltDomain, "."), strings.TrimSuffix(rt.DNSDomain, ".")) {
+		return fmt.Errorf("alt_domain cannot be the same as domain")
+	}
+	if rt.DNSNegativeCacheSize < 0 {
+		return fmt.Errorf("dns_config.negative_cache_size cannot be %d. Must be greater than or equal to zero", rt.DNSNegativeCacheSize)
+	}
+	if rt.DNSNegativeCacheSize > 0 && rt.DNSNegativeCacheTTL <= 0 {
+		return fmt.Errorf("dns_config.negative_cache_ttl must be positive when dns_config.negative_cache_size is set")
+	}
 	if rt.DNSARecordLimit < 0 {
 		return fmt.Errorf("dns_config.a_record_limit cannot be %d. Must be greater than or equal to zero", rt.DNSARecordLimit)
 	}
+	if rt.DNSEnableDoH && rt.HTTPSPort <= 0 {
+		return fmt.Errorf("dns_config.enable_doh requires the HTTPS listener to be enabled with ports.https")
+	}
+	if rt.DNSTLSPort > 0 && (rt.CertFile == "" || rt.KeyFile == "") {
+		return fmt.Errorf("ports.dns_tls requires cert_file and key_file to be set")
+	}
+	if (rt.DNSSECKeyFile == "") != (rt.DNSSECPrivateKeyFile == "") {
+		return fmt.Errorf("dns_config.dnssec.key_file and dns_config.dnssec.private_key_file must be set together")
+	}
 	if err := structs.ValidateMetadata(rt.NodeMeta, false); err != nil {
 		return fmt.Errorf("node_meta invalid: %v", err)
 	}
@@ -1140,16 +1217,27 @@ func (b *Builder) checkVal(v *CheckDefinition) *structs.CheckDefinition {
 		Header:                         v.Header,
 		Method:                         b.stringVal(v.Method),
 		TCP:                            b.stringVal(v.TCP),
+		UDP:                            b.stringVal(v.UDP),
+		UDPPayload:                     b.stringVal(v.UDPPayload),
+		H2C:                            b.stringVal(v.H2C),
+		OSService:                      b.stringVal(v.OSService),
 		Interval:                       b.durationVal(fmt.Sprintf("check[%s].interval", id), v.Interval),
 		DockerContainerID:              b.stringVal(v.DockerContainerID),
 		Shell:                          b.stringVal(v.Shell),
 		GRPC:                           b.stringVal(v.GRPC),
 		GRPCUseTLS:                     b.boolVal(v.GRPCUseTLS),
 		TLSSkipVerify:                  b.boolVal(v.TLSSkipVerify),
+		TLSServerName:                  b.stringVal(v.TLSServerName),
+		CACert:                         b.stringVal(v.CACert),
+		ClientCert:                     b.stringVal(v.ClientCert),
+		ClientKey:                      b.stringVal(v.ClientKey),
+		UseConnectCert:                 b.boolVal(v.UseConnectCert),
 		AliasNode:                      b.stringVal(v.AliasNode),
 		AliasService:                   b.stringVal(v.AliasService),
 		Timeout:                        b.durationVal(fmt.Sprintf("check[%s].timeout", id), v.Timeout),
 		TTL:                            b.durationVal(fmt.Sprintf("check[%s].ttl", id), v.TTL),
+		SuccessBeforePassing:           b.intVal(v.SuccessBeforePassing),
+		FailuresBeforeCritical:         b.intVal(v.FailuresBeforeCritical),
 		DeregisterCriticalServiceAfter: b.durationVal(fmt.Sprintf("check[%s].deregister_critical_service_after", id), v.DeregisterCriticalServiceAfter),
 	}
 }
@@ -1212,6 +1300,8 @@ func (b *Builder) serviceKindVal(v *string) structs.ServiceKind {
 	switch *v {
 	case string(structs.ServiceKindConnectProxy):
 		return structs.ServiceKindConnectProxy
+	case string(structs.ServiceKindMeshGateway):
+		return structs.ServiceKindMeshGateway
 	default:
 		return structs.ServiceKindTypical
 	}
@@ -1248,6 +1338,9 @@ func (b *Builder) upstreamsVal(v []Upstream) structs.Upstreams {
 			LocalBindAddress:     b.stringVal(u.LocalBindAddress),
 			LocalBindPort:        b.intVal(u.LocalBindPort),
 			Config:               u.Config,
+			MeshGateway: structs.MeshGatewayConfig{
+				Mode: structs.MeshGatewayMode(b.stringVal(u.MeshGateway.Mode)),
+			},
 		}
 		if ups[i].DestinationType == "" {
 			ups[i].DestinationType = structs.UpstreamDestTypeService
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/consul/lib"
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/mitchellh/mapstructure"
@@ -69,7 +70,7 @@ func Parse(data string, format string) (c Config, err error) {
 	// []map[string]interface{} or are arrays of structs which
 	// encoding/json will decode to []map[string]interface{}. Therefore,
 	// we need to be able to specify exceptions for this mapping. The
-	// patchSliceOfMaps() implements that mapping. All fields of type
+	// PatchSliceOfMaps() implements that mapping. All fields of type
 	// []map[string]interface{} are mapped to map[string]interface{} if
 	// it contains at most one value. If there is more than one value it
 	// panics. To define exceptions one can specify the nested field
@@ -77,8 +78,9 @@ func Parse(data string, format string) (c Config, err error) {
 	//
 	// todo(fs): There might be an easier way to achieve the same thing
 	// todo(fs): but this approach works for now.
-	m := patchSliceOfMaps(raw, []string{
+	m := lib.PatchSliceOfMaps(raw, []string{
 		"checks",
+		"kv_history",
 		"segments",
 		"service.checks",
 		"services",
@@ -177,6 +179,7 @@ type Config struct {
 	BindAddr                         *string                  `json:"bind_addr,omitempty" hcl:"bind_addr" mapstructure:"bind_addr"`
 	Bootstrap                        *bool                    `json:"bootstrap,omitempty" hcl:"bootstrap" mapstructure:"bootstrap"`
 	BootstrapExpect                  *int                     `json:"bootstrap_expect,omitempty" hcl:"bootstrap_expect" mapstructure:"bootstrap_expect"`
+	Cache                            Cache                    `json:"cache,omitempty" hcl:"cache" mapstructure:"cache"`
 	CAFile                           *string                  `json:"ca_file,omitempty" hcl:"ca_file" mapstructure:"ca_file"`
 	CAPath                           *string                  `json:"ca_path,omitempty" hcl:"ca_path" mapstructure:"ca_path"`
 	CertFile                         *string                  `json:"cert_file,omitempty" hcl:"cert_file" mapstructure:"cert_file"`
@@ -186,6 +189,7 @@ type Config struct {
 	ClientAddr                       *string                  `json:"client_addr,omitempty" hcl:"client_addr" mapstructure:"client_addr"`
 	Connect                          Connect                  `json:"connect,omitempty" hcl:"connect" mapstructure:"connect"`
 	DNS                              DNS                      `json:"dns_config,omitempty" hcl:"dns_config" mapstructure:"dns_config"`
+	DNSAltDomain                     *string                  `json:"alt_domain,omitempty" hcl:"alt_domain" mapstructure:"alt_domain"`
 	DNSDomain                        *string                  `json:"domain,omitempty" hcl:"domain" mapstructure:"domain"`
 	DNSRecursors                     []string                 `json:"recursors,omitempty" hcl:"recursors" mapstructure:"recursors"`
 	DataDir                          *string                  `json:"data_dir,omitempty" hcl:"data_dir" mapstructure:"data_dir"`
@@ -204,6 +208,7 @@ type Config struct {
 	EnableDebug                      *bool                    `json:"enable_debug,omitempty" hcl:"enable_debug" mapstructure:"enable_debug"`
 	EnableScriptChecks               *bool                    `json:"enable_script_checks,omitempty" hcl:"enable_script_checks" mapstructure:"enable_script_checks"`
 	EnableLocalScriptChecks          *bool                    `json:"enable_local_script_checks,omitempty" hcl:"enable_local_script_checks" mapstructure:"enable_local_script_checks"`
+	EnableRemoteCheckTLSFiles        *bool                    `json:"enable_remote_check_tls_files,omitempty" hcl:"enable_remote_check_tls_files" mapstructure:"enable_remote_check_tls_files"`
 	EnableSyslog                     *bool                    `json:"enable_syslog,omitempty" hcl:"enable_syslog" mapstructure:"enable_syslog"`
 	EncryptKey                       *string                  `json:"encrypt,omitempty" hcl:"encrypt" mapstructure:"encrypt"`
 	EncryptVerifyIncoming            *bool                    `json:"encrypt_verify_incoming,omitempty" hcl:"encrypt_verify_incoming" mapstructure:"encrypt_verify_incoming"`
@@ -212,6 +217,7 @@ type Config struct {
 	GossipWAN                        GossipWANConfig          `json:"gossip_wan,omitempty" hcl:"gossip_wan" mapstructure:"gossip_wan"`
 	HTTPConfig                       HTTPConfig               `json:"http_config,omitempty" hcl:"http_config" mapstructure:"http_config"`
 	KeyFile                          *string                  `json:"key_file,omitempty" hcl:"key_file" mapstructure:"key_file"`
+	KVHistory                        []KVHistory              `json:"kv_history,omitempty" hcl:"kv_history" mapstructure:"kv_history"`
 	LeaveOnTerm                      *bool                    `json:"leave_on_terminate,omitempty" hcl:"leave_on_terminate" mapstructure:"leave_on_terminate"`
 	Limits                           Limits                   `json:"limits,omitempty" hcl:"limits" mapstructure:"limits"`
 	LogLevel                         *string                  `json:"log_level,omitempty" hcl:"log_level" mapstructure:"log_level"`
@@ -349,6 +355,13 @@ type Autopilot struct {
 	UpgradeVersionTag       *string `json:"upgrade_version_tag,omitempty" hcl:"upgrade_version_tag" mapstructure:"upgrade_version_tag"`
 }
 
+type Cache struct {
+	MaxBytes        *int    `json:"max_bytes,omitempty" hcl:"max_bytes" mapstructure:"max_bytes"`
+	MaxEntries      *int    `json:"max_entries,omitempty" hcl:"max_entries" mapstructure:"max_entries"`
+	Persist         *bool   `json:"persist,omitempty" hcl:"persist" mapstructure:"persist"`
+	PersistInterval *string `json:"persist_interval,omitempty" hcl:"persist_interval" mapstructure:"persist_interval"`
+}
+
 // ServiceWeights defines the registration of weights used in DNS for a Service
 type ServiceWeights struct {
 	Passing *int `json:"passing,omitempty" hcl:"passing" mapstructure:"passing"`
@@ -386,16 +399,27 @@ type CheckDefinition struct {
 	Header                         map[string][]string `json:"header,omitempty" hcl:"header" mapstructure:"header"`
 	Method                         *string             `json:"method,omitempty" hcl:"method" mapstructure:"method"`
 	TCP                            *string             `json:"tcp,omitempty" hcl:"tcp" mapstructure:"tcp"`
+	UDP                            *string             `json:"udp,omitempty" hcl:"udp" mapstructure:"udp"`
+	UDPPayload                     *string             `json:"udp_payload,omitempty" hcl:"udp_payload" mapstructure:"udp_payload"`
+	H2C                            *string             `json:"h2c,omitempty" hcl:"h2c" mapstructure:"h2c"`
+	OSService                      *string             `json:"os_service,omitempty" hcl:"os_service" mapstructure:"os_service"`
 	Interval                       *string             `json:"interval,omitempty" hcl:"interval" mapstructure:"interval"`
 	DockerContainerID              *string             `json:"docker_container_id,omitempty" hcl:"docker_container_id" mapstructure:"docker_container_id"`
 	Shell                          *string             `json:"shell,omitempty" hcl:"shell" mapstructure:"shell"`
 	GRPC                           *string             `json:"grpc,omitempty" hcl:"grpc" mapstructure:"grpc"`
 	GRPCUseTLS                     *bool               `json:"grpc_use_tls,omitempty" hcl:"grpc_use_tls" mapstructure:"grpc_use_tls"`
 	TLSSkipVerify                  *bool               `json:"tls_skip_verify,omitempty" hcl:"tls_skip_verify" mapstructure:"tls_skip_verify"`
+	TLSServerName                  *string             `json:"tls_server_name,omitempty" hcl:"tls_server_name" mapstructure:"tls_server_name"`
+	CACert                         *string             `json:"ca_cert,omitempty" hcl:"ca_cert" mapstructure:"ca_cert"`
+	ClientCert                     *string             `json:"client_cert,omitempty" hcl:"client_cert" mapstructure:"client_cert"`
+	ClientKey                      *string             `json:"client_key,omitempty" hcl:"client_key" mapstructure:"client_key"`
+	UseConnectCert                 *bool               `json:"use_connect_cert,omitempty" hcl:"use_connect_cert" mapstructure:"use_connect_cert"`
 	AliasNode                      *string             `json:"alias_node,omitempty" hcl:"alias_node" mapstructure:"alias_node"`
 	AliasService                   *string             `json:"alias_service,omitempty" hcl:"alias_service" mapstructure:"alias_service"`
 	Timeout                        *string             `json:"timeout,omitempty" hcl:"timeout" mapstructure:"timeout"`
 	TTL                            *string             `json:"ttl,omitempty" hcl:"ttl" mapstructure:"ttl"`
+	SuccessBeforePassing           *int                `json:"success_before_passing,omitempty" hcl:"success_before_passing" mapstructure:"success_before_passing"`
+	FailuresBeforeCritical         *int                `json:"failures_before_critical,omitempty" hcl:"failures_before_critical" mapstructure:"failures_before_critical"`
 	DeregisterCriticalServiceAfter *string             `json:"deregister_critical_service_after,omitempty" hcl:"deregister_critical_service_after" mapstructure:"deregister_critical_service_after"`
 }
 
@@ -493,6 +517,16 @@ type Upstream struct {
 	// It can be used to pass arbitrary configuration for this specific upstream
 	// to the proxy.
 	Config map[string]interface{} `json:"config,omitempty" hcl:"config" mapstructure:"config"`
+
+	// MeshGateway is the configuration for mesh gateway usage of this upstream
+	MeshGateway MeshGatewayConfig `json:"mesh_gateway,omitempty" hcl:"mesh_gateway" mapstructure:"mesh_gateway"`
+}
+
+// MeshGatewayConfig controls how Mesh Gateways are used for upstream Connect
+// services.
+type MeshGatewayConfig struct {
+	// Mode is the mode that should be used for the upstream connection.
+	Mode *string `json:"mode,omitempty" hcl:"mode" mapstructure:"mode"`
 }
 
 // Connect is the agent-global connect configuration.
@@ -542,6 +576,13 @@ type SOA struct {
 	Minttl  *uint32 `json:"min_ttl,omitempty" hcl:"min_ttl" mapstructure:"min_ttl"`
 }
 
+// DNSSEC is the configuration of DNSSEC signing for DNS
+type DNSSEC struct {
+	Enabled        *bool   `json:"enabled,omitempty" hcl:"enabled" mapstructure:"enabled"`
+	KeyFile        *string `json:"key_file,omitempty" hcl:"key_file" mapstructure:"key_file"`
+	PrivateKeyFile *string `json:"private_key_file,omitempty" hcl:"private_key_file" mapstructure:"private_key_file"`
+}
+
 type DNS struct {
 	AllowStale         *bool             `json:"allow_stale,omitempty" hcl:"allow_stale" mapstructure:"allow_stale"`
 	ARecordLimit       *int              `json:"a_record_limit,omitempty" hcl:"a_record_limit" mapstructure:"a_record_limit"`
@@ -557,6 +598,10 @@ type DNS struct {
 	SOA                *SOA              `json:"soa,omitempty" hcl:"soa" mapstructure:"soa"`
 	UseCache           *bool             `json:"use_cache,omitempty" hcl:"use_cache" mapstructure:"use_cache"`
 	CacheMaxAge        *string           `json:"cache_max_age,omitempty" hcl:"cache_max_age" mapstructure:"cache_max_age"`
+	DNSSEC             DNSSEC            `json:"dnssec,omitempty" hcl:"dnssec" mapstructure:"dnssec"`
+	EnableDoH          *bool             `json:"enable_doh,omitempty" hcl:"enable_doh" mapstructure:"enable_doh"`
+	NegativeCacheSize  *int              `json:"negative_cache_size,omitempty" hcl:"negative_cache_size" mapstructure:"negative_cache_size"`
+	NegativeCacheTTL   *string           `json:"negative_cache_ttl,omitempty" hcl:"negative_cache_ttl" mapstructure:"negative_cache_ttl"`
 }
 
 type HTTPConfig struct {
@@ -598,6 +643,7 @@ type Telemetry struct {
 
 type Ports struct {
 	DNS            *int `json:"dns,omitempty" hcl:"dns" mapstructure:"dns"`
+	DNSTLS         *int `json:"dns_tls,omitempty" hcl:"dns_tls" mapstructure:"dns_tls"`
 	HTTP           *int `json:"http,omitempty" hcl:"http" mapstructure:"http"`
 	HTTPS          *int `json:"https,omitempty" hcl:"https" mapstructure:"https"`
 	SerfLAN        *int `json:"serf_lan,omitempty" hcl:"serf_lan" mapstructure:"serf_lan"`
@@ -617,8 +663,19 @@ type UnixSocket struct {
 }
 
 type Limits struct {
-	RPCMaxBurst *int     `json:"rpc_max_burst,omitempty" hcl:"rpc_max_burst" mapstructure:"rpc_max_burst"`
-	RPCRate     *float64 `json:"rpc_rate,omitempty" hcl:"rpc_rate" mapstructure:"rpc_rate"`
+	RPCMaxBlockingQueries      *int     `json:"rpc_max_blocking_queries,omitempty" hcl:"rpc_max_blocking_queries" mapstructure:"rpc_max_blocking_queries"`
+	RPCMaxBurst                *int     `json:"rpc_max_burst,omitempty" hcl:"rpc_max_burst" mapstructure:"rpc_max_burst"`
+	RPCRate                    *float64 `json:"rpc_rate,omitempty" hcl:"rpc_rate" mapstructure:"rpc_rate"`
+	RPCServerMaxBurst          *int     `json:"rpc_server_max_burst,omitempty" hcl:"rpc_server_max_burst" mapstructure:"rpc_server_max_burst"`
+	RPCServerMaxBurstPerSource *int     `json:"rpc_server_max_burst_per_source,omitempty" hcl:"rpc_server_max_burst_per_source" mapstructure:"rpc_server_max_burst_per_source"`
+	RPCServerRate              *float64 `json:"rpc_server_rate,omitempty" hcl:"rpc_server_rate" mapstructure:"rpc_server_rate"`
+	RPCServerRatePerSource     *float64 `json:"rpc_server_rate_per_source,omitempty" hcl:"rpc_server_rate_per_source" mapstructure:"rpc_server_rate_per_source"`
+}
+
+type KVHistory struct {
+	MaxVersions *int    `json:"max_versions,omitempty" hcl:"max_versions" mapstructure:"max_versions"`
+	Prefix      *string `json:"prefix,omitempty" hcl:"prefix" mapstructure:"prefix"`
+	Retention   *string `json:"retention,omitempty" hcl:"retention" mapstructure:"retention"`
 }
 
 type Segment struct {
@@ -633,6 +690,7 @@ type ACL struct {
 	Enabled                *bool   `json:"enabled,omitempty" hcl:"enabled" mapstructure:"enabled"`
 	TokenReplication       *bool   `json:"enable_token_replication,omitempty" hcl:"enable_token_replication" mapstructure:"enable_token_replication"`
 	PolicyTTL              *string `json:"policy_ttl,omitempty" hcl:"policy_ttl" mapstructure:"policy_ttl"`
+	RoleTTL                *string `json:"role_ttl,omitempty" hcl:"role_ttl" mapstructure:"role_ttl"`
 	TokenTTL               *string `json:"token_ttl,omitempty" hcl:"token_ttl" mapstructure:"token_ttl"`
 	DownPolicy             *string `json:"down_policy,omitempty" hcl:"down_policy" mapstructure:"down_policy"`
 	DefaultPolicy          *string `json:"default_policy,omitempty" hcl:"default_policy" mapstructure:"default_policy"`
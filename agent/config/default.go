@@ -45,10 +45,17 @@ func DefaultSource() Source {
 		acl_ttl = "30s"
 		acl = {
 			policy_ttl = "30s"
+			role_ttl = "30s"
 		}
 		bind_addr = "0.0.0.0"
 		bootstrap = false
 		bootstrap_expect = 0
+		cache = {
+			max_bytes = 0
+			max_entries = 0
+			persist = false
+			persist_interval = "1m"
+		}
 		check_update_interval = "5m"
 		client_addr = "127.0.0.1"
 		datacenter = "` + consul.DefaultDC + `"
@@ -96,10 +103,16 @@ func DefaultSource() Source {
 			udp_answer_limit = 3
 			max_stale = "87600h"
 			recursor_timeout = "2s"
+			negative_cache_ttl = "5s"
 		}
 		limits = {
 			rpc_rate = -1
 			rpc_max_burst = 1000
+			rpc_server_rate = -1
+			rpc_server_max_burst = 1000
+			rpc_server_rate_per_source = -1
+			rpc_server_max_burst_per_source = 1000
+			rpc_max_blocking_queries = 0
 		}
 		performance = {
 			leave_drain_time = "5s"
@@ -108,6 +121,7 @@ func DefaultSource() Source {
 		}
 		ports = {
 			dns = 8600
+			dns_tls = -1
 			http = 8500
 			https = -1
 			grpc = -1
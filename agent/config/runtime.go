@@ -155,6 +155,12 @@ type RuntimeConfig struct {
 	// hcl: acl.token_ttl = "duration"
 	ACLPolicyTTL time.Duration
 
+	// ACLRoleTTL is used to control the time-to-live of cached ACL roles. This has
+	// a major impact on performance. By default, it is set to 30 seconds.
+	//
+	// hcl: acl.role_ttl = "duration"
+	ACLRoleTTL time.Duration
+
 	// ACLToken is the default token used to make requests if a per-request
 	// token is not provided. If not configured the 'anonymous' token is used.
 	//
@@ -239,6 +245,14 @@ type RuntimeConfig struct {
 	// hcl: dns_config { a_record_limit = int }
 	DNSARecordLimit int
 
+	// DNSAltDomain is an alternate DNS domain that is answered exactly like
+	// DNSDomain. Responses use whichever domain the query was made in, which
+	// allows clients to move from one domain to the other gradually.
+	//
+	// hcl: alt_domain = string
+	// flag: -alt-domain string
+	DNSAltDomain string
+
 	// DNSDisableCompression is used to control whether DNS responses are
 	// compressed. In Consul 0.7 this was turned on by default and this
 	// config was added as an opt-out.
@@ -329,6 +343,34 @@ type RuntimeConfig struct {
 	// hcl: dns_config { cache_max_age = "duration" }
 	DNSCacheMaxAge time.Duration
 
+	// DNSNegativeCacheSize is the number of NXDOMAIN responses the DNS server
+	// keeps so that repeated queries for names that don't exist aren't sent
+	// to the servers. Zero disables the cache.
+	//
+	// hcl: dns_config { negative_cache_size = int }
+	DNSNegativeCacheSize int
+
+	// DNSNegativeCacheTTL is how long an NXDOMAIN response is kept in the
+	// negative cache, unless the SOA minimum TTL is set and shorter.
+	//
+	// hcl: dns_config { negative_cache_ttl = "duration" }
+	DNSNegativeCacheTTL time.Duration
+
+	// DNSSECEnabled controls whether responses in the DNS domain are signed
+	// for clients that set the DNSSEC OK bit.
+	//
+	// hcl: dns_config { dnssec { enabled = (true|false) } }
+	DNSSECEnabled bool
+
+	// DNSSECKeyFile and DNSSECPrivateKeyFile are the public and private key
+	// files, as written by dnssec-keygen, for the key used to sign the DNS
+	// domain. If they are not set then the servers generate a key, which
+	// they keep in the state store and hand out to every agent.
+	//
+	// hcl: dns_config { dnssec { key_file = string private_key_file = string } }
+	DNSSECKeyFile        string
+	DNSSECPrivateKeyFile string
+
 	// HTTPBlockEndpoints is a list of endpoint prefixes to block in the
 	// HTTP API. Any requests to these will get a 403 response.
 	//
@@ -424,6 +466,34 @@ type RuntimeConfig struct {
 	// flag: -bootstrap-expect=int
 	BootstrapExpect int
 
+	// CacheMaxBytes is the maximum total estimated size of the agent cache
+	// entries in bytes. The least recently used entries are evicted when it
+	// is exceeded. Zero means no limit.
+	//
+	// hcl: cache { max_bytes = int }
+	CacheMaxBytes int
+
+	// CacheMaxEntries is the maximum number of agent cache entries. The
+	// least recently used entries are evicted when it is exceeded. Zero
+	// means no limit.
+	//
+	// hcl: cache { max_entries = int }
+	CacheMaxEntries int
+
+	// CachePersist enables writing the entries of the agent cache that
+	// support it to a snapshot in the data directory, so they can be served
+	// after a restart while the servers can't be reached.
+	//
+	// hcl: cache { persist = (true|false) }
+	CachePersist bool
+
+	// CachePersistInterval is how often the agent cache snapshot is written
+	// when CachePersist is enabled. It is also written when the agent shuts
+	// down.
+	//
+	// hcl: cache { persist_interval = "duration" }
+	CachePersistInterval time.Duration
+
 	// CAFile is a path to a certificate authority file. This is used with
 	// VerifyIncoming or VerifyOutgoing to verify the TLS connection.
 	//
@@ -590,6 +660,27 @@ type RuntimeConfig struct {
 	// flags: -dns-port int
 	DNSPort int
 
+	// DNSTLSAddrs contains the list of TCP addresses the DNS-over-TLS
+	// server will bind to. The ip addresses are the same as for DNSAddrs.
+	// If the DNS-over-TLS endpoint is disabled (ports.dns_tls <= 0) the list
+	// is empty.
+	//
+	// hcl: client_addr = string addresses { dns = string } ports { dns_tls = int }
+	DNSTLSAddrs []net.Addr
+
+	// DNSTLSPort is the port the DNS-over-TLS server listens on. The default
+	// is -1 which disables the endpoint. It uses the agent's TLS
+	// certificate.
+	//
+	// hcl: ports { dns_tls = int }
+	DNSTLSPort int
+
+	// DNSEnableDoH enables DNS-over-HTTPS queries, as described in RFC 8484,
+	// on the /dns-query path of the HTTPS listener.
+	//
+	// hcl: dns_config { enable_doh = (true|false) }
+	DNSEnableDoH bool
+
 	// DNSSOA is the settings applied for DNS SOA
 	// hcl: soa {}
 	DNSSOA RuntimeSOAConfig
@@ -690,6 +781,14 @@ type RuntimeConfig struct {
 	// flag: -enable-script-checks
 	EnableRemoteScriptChecks bool
 
+	// EnableRemoteCheckTLSFiles controls whether HTTP and gRPC checks
+	// registered through the HTTP API may set CACert, ClientCert and
+	// ClientKey. These are paths on the agent, so allowing them lets anyone
+	// who can register a check present any key pair the agent can read.
+	//
+	// hcl: enable_remote_check_tls_files = (true|false)
+	EnableRemoteCheckTLSFiles bool
+
 	// EnableSyslog is used to also tee all the logs over to syslog. Only supported
 	// on linux and OSX. Other platforms will generate an error.
 	//
@@ -793,6 +892,23 @@ type RuntimeConfig struct {
 	// hcl: key_file = string
 	KeyFile string
 
+	// KVHistory configures the KV prefixes whose keys have their past
+	// versions recorded by the servers, so they can be read back with the
+	// history and at-index KV reads. It should be the same on all the
+	// servers.
+	//
+	// hcl: kv_history {
+	//   # prefix is the key prefix to record the history of.
+	//   prefix = string
+	//
+	//   # max_versions is the number of versions kept per key.
+	//   max_versions = int
+	//
+	//   # retention is how long versions are kept.
+	//   retention = "duration"
+	// }
+	KVHistory []structs.KVSHistoryConfig
+
 	// LeaveDrainTime is used to wait after a server has left the LAN Serf
 	// pool for RPCs to drain and new requests to be sent to other servers.
 	//
@@ -900,6 +1016,30 @@ type RuntimeConfig struct {
 	RPCRateLimit rate.Limit
 	RPCMaxBurst  int
 
+	// RPCServerRateLimit and RPCServerMaxBurst control how frequently a
+	// server accepts RPC calls from all sources combined, using the same
+	// token bucket semantics as RPCRateLimit and RPCMaxBurst. These only
+	// apply to servers.
+	//
+	// hcl: limit { rpc_server_rate = (float64|MaxFloat64) rpc_server_max_burst = int }
+	RPCServerRateLimit rate.Limit
+	RPCServerMaxBurst  int
+
+	// RPCServerSourceRateLimit and RPCServerSourceMaxBurst control how
+	// frequently a server accepts RPC calls from a single source IP. Other
+	// servers in the local datacenter are exempt. These only apply to
+	// servers.
+	//
+	// hcl: limit { rpc_server_rate_per_source = (float64|MaxFloat64) rpc_server_max_burst_per_source = int }
+	RPCServerSourceRateLimit rate.Limit
+	RPCServerSourceMaxBurst  int
+
+	// RPCMaxBlockingQueries caps the number of blocking queries a server
+	// will have in flight at once. A value of 0 disables the cap.
+	//
+	// hcl: limit { rpc_max_blocking_queries = int }
+	RPCMaxBlockingQueries int
+
 	// RPCProtocol is the Consul protocol version to use.
 	//
 	// hcl: protocol = int
@@ -344,6 +344,17 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "-alt-domain",
+			args: []string{
+				`-alt-domain=alt`,
+				`-data-dir=` + dataDir,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.DNSAltDomain = "alt"
+				rt.DataDir = dataDir
+			},
+		},
 		{
 			desc: "-enable-script-checks",
 			args: []string{
@@ -1491,6 +1502,46 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{`autopilot = { max_trailing_logs = -1 }`},
 			err:  "autopilot.max_trailing_logs cannot be -1. Must be greater than or equal to zero",
 		},
+		{
+			desc: "cache.max_entries invalid",
+			args: []string{
+				`-datacenter=a`,
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "cache": { "max_entries": -1 } }`},
+			hcl:  []string{`cache = { max_entries = -1 }`},
+			err:  "cache.max_entries cannot be -1. Must be greater than or equal to zero",
+		},
+		{
+			desc: "kv_history duplicate prefix",
+			args: []string{
+				`-datacenter=a`,
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "kv_history": [ { "prefix": "foo/", "max_versions": 1 }, { "prefix": "foo/", "retention": "1h" } ] }`},
+			hcl:  []string{`kv_history { prefix = "foo/" max_versions = 1 } kv_history { prefix = "foo/" retention = "1h" }`},
+			err:  "kv_history[foo/] is defined more than once",
+		},
+		{
+			desc: "kv_history without limits",
+			args: []string{
+				`-datacenter=a`,
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "kv_history": [ { "prefix": "foo/" } ] }`},
+			hcl:  []string{`kv_history { prefix = "foo/" }`},
+			err:  "kv_history[foo/] must set max_versions or retention",
+		},
+		{
+			desc: "cache.persist_interval invalid",
+			args: []string{
+				`-datacenter=a`,
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "cache": { "persist": true, "persist_interval": "0s" } }`},
+			hcl:  []string{`cache = { persist = true persist_interval = "0s" }`},
+			err:  "cache.persist_interval cannot be 0s. Must be positive",
+		},
 		{
 			desc: "bind_addr cannot be empty",
 			args: []string{`-data-dir=` + dataDir},
@@ -1710,6 +1761,42 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{`dns_config = { udp_answer_limit = -1 }`},
 			err:  "dns_config.udp_answer_limit cannot be -1. Must be greater than or equal to zero",
 		},
+		{
+			desc: "dns_config.dnssec key files set together",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "dns_config": { "dnssec": { "key_file": "a.key" } } }`},
+			hcl:  []string{`dns_config = { dnssec = { key_file = "a.key" } }`},
+			err:  "dns_config.dnssec.key_file and dns_config.dnssec.private_key_file must be set together",
+		},
+		{
+			desc: "alt_domain same as domain",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "domain": "consul", "alt_domain": "Consul." }`},
+			hcl:  []string{`domain = "consul" alt_domain = "Consul."`},
+			err:  "alt_domain cannot be the same as domain",
+		},
+		{
+			desc: "dns_config.negative_cache_size invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "dns_config": { "negative_cache_size": -1 } }`},
+			hcl:  []string{`dns_config = { negative_cache_size = -1 }`},
+			err:  "dns_config.negative_cache_size cannot be -1. Must be greater than or equal to zero",
+		},
+		{
+			desc: "dns_config.negative_cache_ttl invalid",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "dns_config": { "negative_cache_size": 10, "negative_cache_ttl": "0s" } }`},
+			hcl:  []string{`dns_config = { negative_cache_size = 10 negative_cache_ttl = "0s" }`},
+			err:  "dns_config.negative_cache_ttl must be positive when dns_config.negative_cache_size is set",
+		},
 		{
 			desc: "dns_config.a_record_limit invalid",
 			args: []string{
@@ -2431,7 +2518,7 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 
 		{
 			// This tests that we correct added the nested paths to arrays of objects
-			// to the exceptions in patchSliceOfMaps in config.go (for single service)
+			// to the exceptions in PatchSliceOfMaps in config.go (for single service)
 			desc: "service.connectsidecar_service with checks and upstreams",
 			args: []string{
 				`-data-dir=` + dataDir,
@@ -2526,7 +2613,7 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 		},
 		{
 			// This tests that we correct added the nested paths to arrays of objects
-			// to the exceptions in patchSliceOfMaps in config.go (for service*s*)
+			// to the exceptions in PatchSliceOfMaps in config.go (for service*s*)
 			desc: "services.connect.sidecar_service with checks and upstreams",
 			args: []string{
 				`-data-dir=` + dataDir,
@@ -2901,6 +2988,7 @@ func TestFullConfig(t *testing.T) {
 				"enable_key_list_policy": false,
 				"enable_token_persistence": true,
 				"policy_ttl": "1123s",
+				"role_ttl": "9876s",
 				"token_ttl": "3321s",
 				"enable_token_replication" : true,
 				"tokens" : {
@@ -2931,6 +3019,12 @@ func TestFullConfig(t *testing.T) {
 			"bind_addr": "16.99.34.17",
 			"bootstrap": true,
 			"bootstrap_expect": 53,
+			"cache": {
+				"max_bytes": 21746,
+				"max_entries": 4379,
+				"persist": true,
+				"persist_interval": "25326s"
+			},
 			"ca_file": "erA7T0PM",
 			"ca_path": "mQEN1Mfp",
 			"cert_file": "7s4QAzDk",
@@ -3055,6 +3149,7 @@ func TestFullConfig(t *testing.T) {
 			"discard_check_output": true,
 			"discovery_max_stale": "5s",
 			"domain": "7W1xXSqd",
+			"alt_domain": "ux2eL4Ws",
 			"dns_config": {
 				"allow_stale": true,
 				"a_record_limit": 29907,
@@ -3069,13 +3164,22 @@ func TestFullConfig(t *testing.T) {
 				},
 				"udp_answer_limit": 29909,
 				"use_cache": true,
-				"cache_max_age": "5m"
+				"cache_max_age": "5m",
+				"enable_doh": true,
+				"negative_cache_size": 29910,
+				"negative_cache_ttl": "22s",
+				"dnssec": {
+					"enabled": true,
+					"key_file": "Kd9e4tP5.key",
+					"private_key_file": "Kd9e4tP5.private"
+				}
 			},
 			"enable_acl_replication": true,
 			"enable_agent_tls_for_checks": true,
 			"enable_debug": true,
 			"enable_script_checks": true,
 			"enable_local_script_checks": true,
+			"enable_remote_check_tls_files": true,
 			"enable_syslog": true,
 			"encrypt": "A4wELWqH",
 			"encrypt_verify_incoming": true,
@@ -3089,10 +3193,26 @@ func TestFullConfig(t *testing.T) {
 				}
 			},
 			"key_file": "IEkkwgIA",
+			"kv_history": [
+				{
+					"prefix": "Wbh7Eo5J/",
+					"max_versions": 2391,
+					"retention": "31594s"
+				},
+				{
+					"prefix": "c3dcdTzB/",
+					"max_versions": 5720
+				}
+			],
 			"leave_on_terminate": true,
 			"limits": {
 				"rpc_rate": 12029.43,
-				"rpc_max_burst": 44848
+				"rpc_max_burst": 44848,
+				"rpc_server_rate": 7719.12,
+				"rpc_server_max_burst": 31906,
+				"rpc_server_rate_per_source": 612.9,
+				"rpc_server_max_burst_per_source": 2377,
+				"rpc_max_blocking_queries": 18316
 			},
 			"log_level": "k1zo9Spt",
 			"node_id": "AsUIlw99",
@@ -3110,6 +3230,7 @@ func TestFullConfig(t *testing.T) {
 			"pid_file": "43xN80Km",
 			"ports": {
 				"dns": 7001,
+				"dns_tls": 7853,
 				"http": 7999,
 				"https": 15127,
 				"server": 3757,
@@ -3453,6 +3574,7 @@ func TestFullConfig(t *testing.T) {
 				enable_key_list_policy = false
 				enable_token_persistence = true
 				policy_ttl = "1123s"
+				role_ttl = "9876s"
 				token_ttl = "3321s"
 				enable_token_replication = true
 				tokens = {
@@ -3483,6 +3605,12 @@ func TestFullConfig(t *testing.T) {
 			bind_addr = "16.99.34.17"
 			bootstrap = true
 			bootstrap_expect = 53
+			cache = {
+				max_bytes = 21746
+				max_entries = 4379
+				persist = true
+				persist_interval = "25326s"
+			}
 			ca_file = "erA7T0PM"
 			ca_path = "mQEN1Mfp"
 			cert_file = "7s4QAzDk"
@@ -3611,6 +3739,7 @@ func TestFullConfig(t *testing.T) {
 			discard_check_output = true
 			discovery_max_stale = "5s"
 			domain = "7W1xXSqd"
+			alt_domain = "ux2eL4Ws"
 			dns_config {
 				allow_stale = true
 				a_record_limit = 29907
@@ -3626,12 +3755,21 @@ func TestFullConfig(t *testing.T) {
 				udp_answer_limit = 29909
 				use_cache = true
 				cache_max_age = "5m"
+				enable_doh = true
+				negative_cache_size = 29910
+				negative_cache_ttl = "22s"
+				dnssec {
+					enabled = true
+					key_file = "Kd9e4tP5.key"
+					private_key_file = "Kd9e4tP5.private"
+				}
 			}
 			enable_acl_replication = true
 			enable_agent_tls_for_checks = true
 			enable_debug = true
 			enable_script_checks = true
 			enable_local_script_checks = true
+			enable_remote_check_tls_files = true
 			enable_syslog = true
 			encrypt = "A4wELWqH"
 			encrypt_verify_incoming = true
@@ -3645,10 +3783,26 @@ func TestFullConfig(t *testing.T) {
 				}
 			}
 			key_file = "IEkkwgIA"
+			kv_history = [
+				{
+					prefix = "Wbh7Eo5J/"
+					max_versions = 2391
+					retention = "31594s"
+				},
+				{
+					prefix = "c3dcdTzB/"
+					max_versions = 5720
+				}
+			]
 			leave_on_terminate = true
 			limits {
 				rpc_rate = 12029.43
 				rpc_max_burst = 44848
+				rpc_server_rate = 7719.12
+				rpc_server_max_burst = 31906
+				rpc_server_rate_per_source = 612.9
+				rpc_server_max_burst_per_source = 2377
+				rpc_max_blocking_queries = 18316
 			}
 			log_level = "k1zo9Spt"
 			node_id = "AsUIlw99"
@@ -3666,6 +3820,7 @@ func TestFullConfig(t *testing.T) {
 			pid_file = "43xN80Km"
 			ports {
 				dns = 7001,
+				dns_tls = 7853,
 				http = 7999,
 				https = 15127
 				server = 3757
@@ -4127,6 +4282,7 @@ func TestFullConfig(t *testing.T) {
 		ACLReplicationToken:              "5795983a",
 		ACLTokenTTL:                      3321 * time.Second,
 		ACLPolicyTTL:                     1123 * time.Second,
+		ACLRoleTTL:                       9876 * time.Second,
 		ACLToken:                         "418fdff1",
 		ACLTokenReplication:              true,
 		AdvertiseAddrLAN:                 ipAddr("17.99.29.16"),
@@ -4141,6 +4297,10 @@ func TestFullConfig(t *testing.T) {
 		BindAddr:                         ipAddr("16.99.34.17"),
 		Bootstrap:                        true,
 		BootstrapExpect:                  53,
+		CacheMaxBytes:                    21746,
+		CacheMaxEntries:                  4379,
+		CachePersist:                     true,
+		CachePersistInterval:             25326 * time.Second,
 		CAFile:                           "erA7T0PM",
 		CAPath:                           "mQEN1Mfp",
 		CertFile:                         "7s4QAzDk",
@@ -4242,6 +4402,7 @@ func TestFullConfig(t *testing.T) {
 		DNSAddrs:                         []net.Addr{tcpAddr("93.95.95.81:7001"), udpAddr("93.95.95.81:7001")},
 		DNSARecordLimit:                  29907,
 		DNSAllowStale:                    true,
+		DNSAltDomain:                     "ux2eL4Ws",
 		DNSDisableCompression:            true,
 		DNSDomain:                        "7W1xXSqd",
 		DNSEnableTruncate:                true,
@@ -4257,6 +4418,14 @@ func TestFullConfig(t *testing.T) {
 		DNSNodeMetaTXT:                   true,
 		DNSUseCache:                      true,
 		DNSCacheMaxAge:                   5 * time.Minute,
+		DNSNegativeCacheSize:             29910,
+		DNSNegativeCacheTTL:              22 * time.Second,
+		DNSSECEnabled:                    true,
+		DNSTLSAddrs:                      []net.Addr{tcpAddr("93.95.95.81:7853")},
+		DNSTLSPort:                       7853,
+		DNSEnableDoH:                     true,
+		DNSSECKeyFile:                    "Kd9e4tP5.key",
+		DNSSECPrivateKeyFile:             "Kd9e4tP5.private",
 		DataDir:                          dataDir,
 		Datacenter:                       "rzo029wg",
 		DevMode:                          true,
@@ -4273,6 +4442,7 @@ func TestFullConfig(t *testing.T) {
 		EnableDebug:                      true,
 		EnableRemoteScriptChecks:         true,
 		EnableLocalScriptChecks:          true,
+		EnableRemoteCheckTLSFiles:        true,
 		EnableSyslog:                     true,
 		EnableUI:                         true,
 		EncryptKey:                       "A4wELWqH",
@@ -4288,6 +4458,7 @@ func TestFullConfig(t *testing.T) {
 		HTTPSAddrs:                       []net.Addr{tcpAddr("95.17.17.19:15127")},
 		HTTPSPort:                        15127,
 		KeyFile:                          "IEkkwgIA",
+		KVHistory:                        []structs.KVSHistoryConfig{{Prefix: "Wbh7Eo5J/", MaxVersions: 2391, Retention: 31594 * time.Second}, {Prefix: "c3dcdTzB/", MaxVersions: 5720}},
 		LeaveDrainTime:                   8265 * time.Second,
 		LeaveOnTerm:                      true,
 		LogLevel:                         "k1zo9Spt",
@@ -4303,6 +4474,11 @@ func TestFullConfig(t *testing.T) {
 		RPCProtocol:                      30793,
 		RPCRateLimit:                     12029.43,
 		RPCMaxBurst:                      44848,
+		RPCServerRateLimit:               7719.12,
+		RPCServerMaxBurst:                31906,
+		RPCServerSourceRateLimit:         612.9,
+		RPCServerSourceMaxBurst:          2377,
+		RPCMaxBlockingQueries:            18316,
 		RaftProtocol:                     19016,
 		RaftSnapshotThreshold:            16384,
 		RaftSnapshotInterval:             30 * time.Second,
@@ -4945,6 +5121,7 @@ func TestSanitize(t *testing.T) {
 		"ACLMasterToken": "hidden",
 		"ACLPolicyTTL": "0s",
 		"ACLReplicationToken": "hidden",
+		"ACLRoleTTL": "0s",
 		"ACLTokenReplication": false,
 		"ACLTokenTTL": "0s",
 		"ACLToken": "hidden",
@@ -4964,6 +5141,10 @@ func TestSanitize(t *testing.T) {
 		"BootstrapExpect": 0,
 		"CAFile": "",
 		"CAPath": "",
+		"CacheMaxBytes": 0,
+		"CacheMaxEntries": 0,
+		"CachePersist": false,
+		"CachePersistInterval": "0s",
 		"CertFile": "",
 		"CheckDeregisterIntervalMin": "0s",
 		"CheckReapInterval": "0s",
@@ -4971,10 +5152,15 @@ func TestSanitize(t *testing.T) {
 		"Checks": [{
 			"AliasNode": "",
 			"AliasService": "",
+			"CACert": "",
+			"ClientCert": "",
+			"ClientKey": "hidden",
 			"DeregisterCriticalServiceAfter": "0s",
 			"DockerContainerID": "",
+			"FailuresBeforeCritical": 0,
 			"GRPC": "",
 			"GRPCUseTLS": false,
+			"H2C": "",
 			"HTTP": "",
 			"Header": {},
 			"ID": "",
@@ -4982,15 +5168,21 @@ func TestSanitize(t *testing.T) {
 			"Method": "",
 			"Name": "zoo",
 			"Notes": "",
+			"OSService": "",
 			"ScriptArgs": [],
 			"ServiceID": "",
 			"Shell": "",
 			"Status": "",
+			"SuccessBeforePassing": 0,
 			"TCP": "",
+			"TLSServerName": "",
 			"TLSSkipVerify": false,
 			"TTL": "0s",
 			"Timeout": "0s",
-			"Token": "hidden"
+			"Token": "hidden",
+			"UDP": "",
+			"UDPPayload": "",
+			"UseConnectCert": false
 		}],
 		"ClientAddrs": [],
 		"ConnectCAConfig": {},
@@ -5033,16 +5225,25 @@ func TestSanitize(t *testing.T) {
 			"udp://1.2.3.4:5678"
 		],
 		"DNSAllowStale": false,
+		"DNSAltDomain": "",
 		"DNSDisableCompression": false,
 		"DNSDomain": "",
 		"DNSEnableTruncate": false,
 		"DNSMaxStale": "0s",
+		"DNSNegativeCacheSize": 0,
+		"DNSNegativeCacheTTL": "0s",
 		"DNSNodeMetaTXT": false,
 		"DNSNodeTTL": "0s",
 		"DNSOnlyPassing": false,
+		"DNSEnableDoH": false,
 		"DNSPort": 0,
+		"DNSTLSAddrs": [],
+		"DNSTLSPort": 0,
 		"DNSRecursorTimeout": "0s",
 		"DNSRecursors": [],
+		"DNSSECEnabled": false,
+		"DNSSECKeyFile": "hidden",
+		"DNSSECPrivateKeyFile": "hidden",
 		"DNSServiceTTL": {},
 		"DNSSOA": {
 			"Refresh": 3600,
@@ -5068,6 +5269,7 @@ func TestSanitize(t *testing.T) {
 		"EnableAgentTLSForChecks": false,
 		"EnableDebug": false,
 		"EnableLocalScriptChecks": false,
+		"EnableRemoteCheckTLSFiles": false,
 		"EnableRemoteScriptChecks": false,
 		"EnableSyslog": false,
 		"EnableUI": false,
@@ -5086,6 +5288,7 @@ func TestSanitize(t *testing.T) {
 		"HTTPSAddrs": [],
 		"HTTPSPort": 0,
 		"KeyFile": "hidden",
+		"KVHistory": [],
 		"LeaveDrainTime": "0s",
 		"LeaveOnTerm": false,
 		"LogLevel": "",
@@ -5101,9 +5304,14 @@ func TestSanitize(t *testing.T) {
 		"RPCAdvertiseAddr": "",
 		"RPCBindAddr": "",
 		"RPCHoldTimeout": "0s",
+		"RPCMaxBlockingQueries": 0,
 		"RPCMaxBurst": 0,
 		"RPCProtocol": 0,
 		"RPCRateLimit": 0,
+		"RPCServerMaxBurst": 0,
+		"RPCServerRateLimit": 0,
+		"RPCServerSourceMaxBurst": 0,
+		"RPCServerSourceRateLimit": 0,
 		"RaftProtocol": 0,
 		"RaftSnapshotInterval": "0s",
 		"RaftSnapshotThreshold": 0,
@@ -5139,24 +5347,35 @@ func TestSanitize(t *testing.T) {
 			"Check": {
 				"AliasNode": "",
 				"AliasService": "",
+				"CACert": "",
 				"CheckID": "",
+				"ClientCert": "",
+				"ClientKey": "hidden",
 				"DeregisterCriticalServiceAfter": "0s",
 				"DockerContainerID": "",
+				"FailuresBeforeCritical": 0,
 				"GRPC": "",
 				"GRPCUseTLS": false,
+				"H2C": "",
 				"HTTP": "",
 				"Header": {},
 				"Interval": "0s",
 				"Method": "",
 				"Name": "blurb",
 				"Notes": "",
+				"OSService": "",
 				"ScriptArgs": [],
 				"Shell": "",
 				"Status": "",
+				"SuccessBeforePassing": 0,
 				"TCP": "",
+				"TLSServerName": "",
 				"TLSSkipVerify": false,
 				"TTL": "0s",
-				"Timeout": "0s"
+				"Timeout": "0s",
+				"UDP": "",
+				"UDPPayload": "",
+				"UseConnectCert": false
 			},
 			"Checks": [],
 			"Connect": null,
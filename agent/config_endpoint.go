@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Config switches on the different CRUD operations for config entries.
+func (s *HTTPServer) Config(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		return s.configGet(resp, req)
+
+	case "DELETE":
+		return s.configDelete(resp, req)
+
+	default:
+		return nil, MethodNotAllowedError{req.Method, []string{"GET", "DELETE"}}
+	}
+}
+
+// configGet gets either a specific config entry, or lists all config entries
+// of a kind if no name is provided.
+func (s *HTTPServer) configGet(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.ConfigEntryQuery
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	pathArgs := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/v1/config/"), "/", 2)
+
+	switch {
+	case len(pathArgs) == 2 && pathArgs[1] != "":
+		// Both kind/name provided.
+		args.Kind = pathArgs[0]
+		args.Name = pathArgs[1]
+
+		var reply structs.IndexedConfigEntries
+		defer setMeta(resp, &reply.QueryMeta)
+		if err := s.agent.RPC("ConfigEntry.Get", &args, &reply); err != nil {
+			return nil, err
+		}
+
+		if len(reply.Entries) == 0 {
+			resp.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(resp, "Config entry not found for %q / %q", args.Kind, args.Name)
+			return nil, nil
+		}
+
+		return reply.Entries[0], nil
+
+	case pathArgs[0] != "":
+		// Only kind provided, list entries.
+		args.Kind = pathArgs[0]
+		if _, err := structs.MakeConfigEntry(args.Kind, ""); err != nil {
+			return nil, BadRequestError{Reason: err.Error()}
+		}
+
+		var reply structs.IndexedConfigEntries
+		defer setMeta(resp, &reply.QueryMeta)
+		if err := s.agent.RPC("ConfigEntry.List", &args, &reply); err != nil {
+			return nil, err
+		}
+
+		// Use empty list instead of nil.
+		if reply.Entries == nil {
+			reply.Entries = make([]structs.ConfigEntry, 0)
+		}
+		return reply.Entries, nil
+
+	default:
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(resp, "Must provide either a kind or both kind and name")
+		return nil, nil
+	}
+}
+
+// configDelete deletes the given config entry.
+func (s *HTTPServer) configDelete(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.ConfigEntryRequest
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+	pathArgs := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/v1/config/"), "/", 2)
+
+	if len(pathArgs) != 2 || pathArgs[1] == "" {
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(resp, "Must provide both a kind and name to delete")
+		return nil, nil
+	}
+
+	entry, err := structs.MakeConfigEntry(pathArgs[0], pathArgs[1])
+	if err != nil {
+		return nil, BadRequestError{Reason: err.Error()}
+	}
+	args.Entry = entry
+
+	var reply struct{}
+	if err := s.agent.RPC("ConfigEntry.Delete", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+// ConfigApply applies the given config entry update.
+func (s *HTTPServer) ConfigApply(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.ConfigEntryRequest{
+		Op: structs.ConfigEntryUpsert,
+	}
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+
+	var raw map[string]interface{}
+	if err := decodeBody(req, &raw, nil); err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("Request decoding failed: %v", err)}
+	}
+
+	entry, err := structs.DecodeConfigEntry(raw)
+	if err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("Request decoding failed: %v", err)}
+	}
+	args.Entry = entry
+
+	var reply struct{}
+	if err := s.agent.RPC("ConfigEntry.Apply", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
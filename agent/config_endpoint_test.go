@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Get(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Create some config entries.
+	reqs := []structs.ConfigEntryRequest{
+		{
+			Datacenter: "dc1",
+			Entry: &structs.ServiceConfigEntry{
+				Name: "foo",
+			},
+		},
+		{
+			Datacenter: "dc1",
+			Entry: &structs.ServiceConfigEntry{
+				Name: "bar",
+			},
+		},
+		{
+			Datacenter: "dc1",
+			Entry: &structs.ProxyConfigEntry{
+				Name: structs.ProxyConfigGlobal,
+			},
+		},
+	}
+	for _, req := range reqs {
+		var out struct{}
+		require.NoError(a.RPC("ConfigEntry.Apply", &req, &out))
+	}
+
+	t.Run("get a single service entry", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/service-defaults/foo", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.Config(resp, req)
+		require.NoError(err)
+
+		value := obj.(structs.ConfigEntry)
+		require.Equal(structs.ServiceDefaults, value.GetKind())
+		entry := value.(*structs.ServiceConfigEntry)
+		require.Equal("foo", entry.Name)
+		require.Equal(structs.DefaultServiceProtocol, entry.Protocol)
+	})
+	t.Run("list both service entries", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/service-defaults", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.Config(resp, req)
+		require.NoError(err)
+
+		value := obj.([]structs.ConfigEntry)
+		require.Len(value, 2)
+		require.Equal("bar", value[0].GetName())
+		require.Equal("foo", value[1].GetName())
+	})
+	t.Run("get global proxy config", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/proxy-defaults/global", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.Config(resp, req)
+		require.NoError(err)
+
+		value := obj.(structs.ConfigEntry)
+		require.Equal(structs.ProxyDefaults, value.GetKind())
+		require.Equal(structs.ProxyConfigGlobal, value.GetName())
+	})
+	t.Run("get a missing entry", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/service-defaults/baz", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.Config(resp, req)
+		require.NoError(err)
+		require.Nil(obj)
+		require.Equal(http.StatusNotFound, resp.Code)
+	})
+	t.Run("list an invalid kind", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/nope", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.Config(resp, req)
+		require.Error(err)
+		require.IsType(BadRequestError{}, err)
+	})
+}
+
+func TestConfig_Delete(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Create some config entries.
+	reqs := []structs.ConfigEntryRequest{
+		{
+			Datacenter: "dc1",
+			Entry: &structs.ServiceConfigEntry{
+				Name: "foo",
+			},
+		},
+		{
+			Datacenter: "dc1",
+			Entry: &structs.ServiceConfigEntry{
+				Name: "bar",
+			},
+		},
+	}
+	for _, req := range reqs {
+		var out struct{}
+		require.NoError(a.RPC("ConfigEntry.Apply", &req, &out))
+	}
+
+	// Delete an entry.
+	{
+		req, _ := http.NewRequest("DELETE", "/v1/config/service-defaults/bar", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.Config(resp, req)
+		require.NoError(err)
+	}
+	// Get the remaining entry.
+	{
+		args := structs.ConfigEntryQuery{
+			Kind:       structs.ServiceDefaults,
+			Datacenter: "dc1",
+		}
+		var out structs.IndexedConfigEntries
+		require.NoError(a.RPC("ConfigEntry.List", &args, &out))
+		require.Equal(structs.ServiceDefaults, out.Kind)
+		require.Len(out.Entries, 1)
+		entry := out.Entries[0].(*structs.ServiceConfigEntry)
+		require.Equal(entry.Name, "foo")
+	}
+}
+
+func TestConfig_Apply(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Create some config entries.
+	body := bytes.NewBuffer([]byte(`
+	{
+		"Kind": "service-defaults",
+		"Name": "foo",
+		"Protocol": "tcp"
+	}`))
+
+	req, _ := http.NewRequest("PUT", "/v1/config", body)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.ConfigApply(resp, req)
+	require.NoError(err)
+	if resp.Code != 200 {
+		t.Fatal(resp.Body.String())
+	}
+
+	// Get the remaining entry.
+	{
+		args := structs.ConfigEntryQuery{
+			Kind:       structs.ServiceDefaults,
+			Name:       "foo",
+			Datacenter: "dc1",
+		}
+		var out structs.IndexedConfigEntries
+		require.NoError(a.RPC("ConfigEntry.Get", &args, &out))
+		require.Len(out.Entries, 1)
+		entry := out.Entries[0].(*structs.ServiceConfigEntry)
+		require.Equal(entry.Name, "foo")
+	}
+}
+
+func TestConfig_Apply_Decoding(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	cases := map[string]string{
+		"missing kind":    `{"Name": "foo"}`,
+		"invalid kind":    `{"Kind": "nope", "Name": "foo"}`,
+		"non-string kind": `{"Kind": 3, "Name": "foo"}`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest("PUT", "/v1/config", bytes.NewBufferString(body))
+			resp := httptest.NewRecorder()
+			_, err := a.srv.ConfigApply(resp, req)
+			require.Error(t, err)
+			require.IsType(t, BadRequestError{}, err, fmt.Sprintf("%v", err))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package connect
+
+import (
+	"fmt"
+)
+
+const (
+	internal = "internal"
+)
+
+// DatacenterSNI returns the SNI suffix shared by every service in the given
+// datacenter. Mesh gateways match it to forward connections to the right
+// datacenter.
+func DatacenterSNI(dc string, trustDomain string) string {
+	return fmt.Sprintf("%s.%s.%s", dc, internal, trustDomain)
+}
+
+// ServiceSNI returns the SNI name a proxy sets when connecting to a service,
+// or to one of its subsets if subset is not empty. Mesh gateways use it to
+// route the connection without terminating TLS.
+func ServiceSNI(service string, subset string, namespace string, datacenter string, trustDomain string) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	sni := fmt.Sprintf("%s.%s.%s", service, namespace, DatacenterSNI(datacenter, trustDomain))
+	if subset != "" {
+		sni = subset + "." + sni
+	}
+	return sni
+}
@@ -0,0 +1,23 @@
+package connect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testTrustDomain = "1c053652-8512-4373-90cf-5a7f6263a994.consul"
+
+func TestDatacenterSNI(t *testing.T) {
+	require.Equal(t, "foo.internal."+testTrustDomain,
+		DatacenterSNI("foo", testTrustDomain))
+}
+
+func TestServiceSNI(t *testing.T) {
+	require.Equal(t, "api.default.foo.internal."+testTrustDomain,
+		ServiceSNI("api", "", "", "foo", testTrustDomain))
+	require.Equal(t, "api.team.foo.internal."+testTrustDomain,
+		ServiceSNI("api", "", "team", "foo", testTrustDomain))
+	require.Equal(t, "v2.api.default.foo.internal."+testTrustDomain,
+		ServiceSNI("api", "v2", "", "foo", testTrustDomain))
+}
@@ -0,0 +1,168 @@
+package consul
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/consul/authmethod"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-bexpr"
+	"github.com/hashicorp/hil"
+	"github.com/hashicorp/hil/ast"
+
+	// register the auth method types that ship with Consul
+	_ "github.com/hashicorp/consul/agent/consul/authmethod/jwtauth"
+	_ "github.com/hashicorp/consul/agent/consul/authmethod/testauth"
+)
+
+// bindingRuleSelectorData is the datum binding rule selectors are evaluated
+// against. The fields verified by the auth method are exposed as
+// "value.<field>".
+type bindingRuleSelectorData struct {
+	Value map[string]string `bexpr:"value"`
+}
+
+// validateBindingRuleSelector checks that the selector is a valid boolean
+// expression. An empty selector matches everything.
+func validateBindingRuleSelector(selector string) error {
+	if selector == "" {
+		return nil
+	}
+	_, err := bexpr.CreateEvaluatorForType(selector, nil, (*bindingRuleSelectorData)(nil))
+	return err
+}
+
+// doesBindingRuleMatch returns true if the selector of the rule matches the
+// fields returned by the auth method.
+func doesBindingRuleMatch(rule *structs.ACLBindingRule, fields map[string]string) bool {
+	if rule.Selector == "" {
+		return true
+	}
+
+	eval, err := bexpr.CreateEvaluatorForType(rule.Selector, nil, (*bindingRuleSelectorData)(nil))
+	if err != nil {
+		return false
+	}
+
+	result, err := eval.Evaluate(&bindingRuleSelectorData{Value: fields})
+	if err != nil {
+		return false
+	}
+	return result
+}
+
+// computeBindingRuleBindName interpolates the "${value.<field>}" references
+// in the bind name of the rule with the fields returned by the auth method.
+func computeBindingRuleBindName(bindName string, fields map[string]string) (string, error) {
+	tree, err := hil.Parse(bindName)
+	if err != nil {
+		return "", err
+	}
+
+	vars := make(map[string]ast.Variable, len(fields))
+	for field, value := range fields {
+		vars["value."+field] = ast.Variable{
+			Type:  ast.TypeString,
+			Value: value,
+		}
+	}
+
+	res, err := hil.Eval(tree, &hil.EvalConfig{
+		GlobalScope: &ast.BasicScope{VarMap: vars},
+	})
+	if err != nil {
+		return "", err
+	}
+	if res.Type != hil.TypeString {
+		return "", fmt.Errorf("bind name did not evaluate to a string")
+	}
+	return res.Value.(string), nil
+}
+
+// isValidBindName reports whether the computed bind name is acceptable for
+// the bind type.
+func isValidBindName(bindType, bindName string) bool {
+	switch bindType {
+	case structs.BindingRuleBindTypeService:
+		return validServiceIdentityName.MatchString(bindName)
+	case structs.BindingRuleBindTypeRole:
+		return validRoleName.MatchString(bindName)
+	default:
+		return false
+	}
+}
+
+// validateBindingRuleBindName checks the bind name of a binding rule against
+// the fields the auth method makes available. Every field is set to a value
+// that's valid on its own so only the static parts of the bind name and
+// references to unknown fields can make it fail.
+func validateBindingRuleBindName(bindType, bindName string, availableFields []string) error {
+	if bindName == "" {
+		return fmt.Errorf("bind name is empty")
+	}
+
+	fields := make(map[string]string, len(availableFields))
+	for _, field := range availableFields {
+		fields[field] = "fake"
+	}
+
+	computed, err := computeBindingRuleBindName(bindName, fields)
+	if err != nil {
+		return err
+	}
+	if !isValidBindName(bindType, computed) {
+		return fmt.Errorf("bind name %q is not valid for bind type %q", bindName, bindType)
+	}
+	return nil
+}
+
+// evaluateBindingRules runs the binding rules of the auth method against the
+// verified fields and returns the service identities and role links the
+// resulting token should be granted.
+func (s *Server) evaluateBindingRules(validator authmethod.Validator, fields map[string]string) ([]*structs.ACLServiceIdentity, []structs.ACLTokenRoleLink, error) {
+	state := s.fsm.State()
+
+	_, rules, err := state.ACLBindingRuleList(nil, validator.Name())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		serviceIdentities []*structs.ACLServiceIdentity
+		roleLinks         []structs.ACLTokenRoleLink
+	)
+	for _, rule := range rules {
+		if !doesBindingRuleMatch(rule, fields) {
+			continue
+		}
+
+		bindName, err := computeBindingRuleBindName(rule.BindName, fields)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot compute %q bind name for bind target: %v", rule.BindType, err)
+		}
+		if !isValidBindName(rule.BindType, bindName) {
+			return nil, nil, fmt.Errorf("computed %q bind name for bind target is invalid: %q", rule.BindType, bindName)
+		}
+
+		switch rule.BindType {
+		case structs.BindingRuleBindTypeService:
+			serviceIdentities = append(serviceIdentities, &structs.ACLServiceIdentity{
+				ServiceName: bindName,
+			})
+
+		case structs.BindingRuleBindTypeRole:
+			// Roles are linked by name so that they may be created after
+			// the binding rule. Missing roles are skipped.
+			_, role, err := state.ACLRoleGetByName(nil, bindName)
+			if err != nil {
+				return nil, nil, err
+			}
+			if role != nil {
+				roleLinks = append(roleLinks, structs.ACLTokenRoleLink{
+					ID: role.ID,
+				})
+			}
+		}
+	}
+
+	return serviceIdentities, roleLinks, nil
+}
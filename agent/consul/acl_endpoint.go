@@ -10,6 +10,7 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/authmethod"
 	"github.com/hashicorp/consul/agent/consul/state"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/lib"
@@ -24,7 +25,17 @@ const (
 )
 
 // Regex for matching
-var validPolicyName = regexp.MustCompile(`^[A-Za-z0-9\-_]{1,128}$`)
+var (
+	validPolicyName = regexp.MustCompile(`^[A-Za-z0-9\-_]{1,128}$`)
+	validRoleName   = regexp.MustCompile(`^[A-Za-z0-9\-_]{1,256}$`)
+
+	validAuthMethodName = regexp.MustCompile(`^[A-Za-z0-9\-_]{1,128}$`)
+
+	// Service identity names are used verbatim in the rules of their
+	// synthetic policies so they are restricted to characters that are valid
+	// in service names and never need escaping.
+	validServiceIdentityName = regexp.MustCompile(`^[a-z0-9]([a-z0-9\-_]{0,254}[a-z0-9])?$`)
+)
 
 // ACL endpoint is used to manipulate ACLs
 type ACL struct {
@@ -211,6 +222,9 @@ func (a *ACL) TokenRead(args *structs.ACLTokenGetRequest, reply *structs.ACLToke
 
 			if args.TokenIDType == structs.ACLTokenAccessor {
 				index, token, err = state.ACLTokenGetByAccessor(ws, args.TokenID)
+				if token != nil && token.IsExpired(time.Now()) {
+					token = nil
+				}
 				if token != nil {
 					a.srv.filterACLWithAuthorizer(rule, &token)
 					if !rule.ACLWrite() {
@@ -219,6 +233,9 @@ func (a *ACL) TokenRead(args *structs.ACLTokenGetRequest, reply *structs.ACLToke
 				}
 			} else {
 				index, token, err = state.ACLTokenGetBySecret(ws, args.TokenID)
+				if token != nil && token.IsExpired(time.Now()) {
+					token = nil
+				}
 			}
 
 			if err != nil {
@@ -256,7 +273,7 @@ func (a *ACL) TokenClone(args *structs.ACLTokenSetRequest, reply *structs.ACLTok
 	_, token, err := a.srv.fsm.State().ACLTokenGetByAccessor(nil, args.ACLToken.AccessorID)
 	if err != nil {
 		return err
-	} else if token == nil {
+	} else if token == nil || token.IsExpired(time.Now()) {
 		return acl.ErrNotFound
 	} else if !a.srv.InACLDatacenter() && !token.Local {
 		// global token writes must be forwarded to the primary DC
@@ -268,12 +285,19 @@ func (a *ACL) TokenClone(args *structs.ACLTokenSetRequest, reply *structs.ACLTok
 		return fmt.Errorf("Cannot clone a legacy ACL with this endpoint")
 	}
 
+	if token.AuthMethod != "" {
+		return fmt.Errorf("Cannot clone a token created from an auth method")
+	}
+
 	cloneReq := structs.ACLTokenSetRequest{
 		Datacenter: args.Datacenter,
 		ACLToken: structs.ACLToken{
-			Policies:    token.Policies,
-			Local:       token.Local,
-			Description: token.Description,
+			Policies:          token.Policies,
+			Roles:             token.Roles,
+			ServiceIdentities: token.ServiceIdentities,
+			Local:             token.Local,
+			Description:       token.Description,
+			ExpirationTime:    token.ExpirationTime,
 		},
 		WriteRequest: args.WriteRequest,
 	}
@@ -282,7 +306,7 @@ func (a *ACL) TokenClone(args *structs.ACLTokenSetRequest, reply *structs.ACLTok
 		cloneReq.ACLToken.Description = args.ACLToken.Description
 	}
 
-	return a.tokenSetInternal(&cloneReq, reply, false)
+	return a.tokenSetInternal(&cloneReq, reply, false, false)
 }
 
 func (a *ACL) TokenSet(args *structs.ACLTokenSetRequest, reply *structs.ACLToken) error {
@@ -310,10 +334,10 @@ func (a *ACL) TokenSet(args *structs.ACLTokenSetRequest, reply *structs.ACLToken
 		return acl.ErrPermissionDenied
 	}
 
-	return a.tokenSetInternal(args, reply, false)
+	return a.tokenSetInternal(args, reply, false, false)
 }
 
-func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.ACLToken, upgrade bool) error {
+func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.ACLToken, upgrade, fromLogin bool) error {
 	token := &args.ACLToken
 
 	if !a.srv.LocalTokensEnabled() {
@@ -342,6 +366,41 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 		}
 
 		token.CreateTime = time.Now()
+
+		// Only the login endpoint may bind a token to an auth method so that
+		// logging out can't be used to delete arbitrary tokens.
+		if !fromLogin && token.AuthMethod != "" {
+			return fmt.Errorf("AuthMethod field is disallowed outside of Login")
+		}
+
+		// Ensure an ExpirationTTL is valid if provided.
+		if token.ExpirationTTL != 0 {
+			if token.ExpirationTTL < 0 {
+				return fmt.Errorf("Token Expiration TTL '%s' should be > 0", token.ExpirationTTL)
+			}
+			if token.HasExpirationTime() {
+				return fmt.Errorf("Token Expiration TTL and Expiration Time cannot both be set")
+			}
+
+			expirationTime := token.CreateTime.Add(token.ExpirationTTL)
+			token.ExpirationTime = &expirationTime
+			token.ExpirationTTL = 0
+		}
+
+		if token.HasExpirationTime() {
+			if token.CreateTime.After(*token.ExpirationTime) {
+				return fmt.Errorf("ExpirationTime cannot be before CreateTime")
+			}
+
+			expiresIn := token.ExpirationTime.Sub(token.CreateTime)
+			if expiresIn > a.srv.config.ACLTokenMaxExpirationTTL {
+				return fmt.Errorf("ExpirationTime cannot be more than %s in the future (was %s)",
+					a.srv.config.ACLTokenMaxExpirationTTL, expiresIn)
+			} else if expiresIn < a.srv.config.ACLTokenMinExpirationTTL {
+				return fmt.Errorf("ExpirationTime cannot be less than %s in the future (was %s)",
+					a.srv.config.ACLTokenMinExpirationTTL, expiresIn)
+			}
+		}
 	} else {
 		// Token Update
 		if _, err := uuid.ParseUUID(token.AccessorID); err != nil {
@@ -365,7 +424,7 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 		if err != nil {
 			return fmt.Errorf("Failed to lookup the acl token %q: %v", token.AccessorID, err)
 		}
-		if existing == nil {
+		if existing == nil || existing.IsExpired(time.Now()) {
 			return fmt.Errorf("Cannot find token %q", token.AccessorID)
 		}
 		if token.SecretID == "" {
@@ -379,6 +438,22 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 			return fmt.Errorf("cannot toggle local mode of %s", token.AccessorID)
 		}
 
+		if token.AuthMethod == "" {
+			token.AuthMethod = existing.AuthMethod
+		} else if existing.AuthMethod != token.AuthMethod {
+			return fmt.Errorf("Cannot change AuthMethod of %s", token.AccessorID)
+		}
+
+		if token.ExpirationTTL != 0 {
+			return fmt.Errorf("Cannot change expiration time of %s", token.AccessorID)
+		}
+
+		if !token.HasExpirationTime() {
+			token.ExpirationTime = existing.ExpirationTime
+		} else if !existing.HasExpirationTime() || !token.ExpirationTime.Equal(*existing.ExpirationTime) {
+			return fmt.Errorf("Cannot change expiration time of %s", token.AccessorID)
+		}
+
 		if upgrade {
 			token.CreateTime = time.Now()
 		} else {
@@ -413,6 +488,46 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 	}
 	token.Policies = policies
 
+	roleIDs := make(map[string]struct{})
+	var roles []structs.ACLTokenRoleLink
+
+	// Validate all the role names and convert them to role IDs
+	for _, link := range token.Roles {
+		if link.ID == "" {
+			_, role, err := state.ACLRoleGetByName(nil, link.Name)
+			if err != nil {
+				return fmt.Errorf("Error looking up role for name %q: %v", link.Name, err)
+			}
+			if role == nil {
+				return fmt.Errorf("No such ACL role with name %q", link.Name)
+			}
+			link.ID = role.ID
+		}
+
+		// Do not store the role name within raft/memdb as the role could be renamed in the future.
+		link.Name = ""
+
+		// dedup role links by id
+		if _, ok := roleIDs[link.ID]; !ok {
+			roles = append(roles, link)
+			roleIDs[link.ID] = struct{}{}
+		}
+	}
+	token.Roles = roles
+
+	for _, srvid := range token.ServiceIdentities {
+		if srvid.ServiceName == "" {
+			return fmt.Errorf("Service identity is missing the service name field on this token")
+		}
+		if !validServiceIdentityName.MatchString(srvid.ServiceName) {
+			return fmt.Errorf("Service identity %q has an invalid name. Only lowercase alphanumeric characters, '-' and '_' are allowed", srvid.ServiceName)
+		}
+		if token.Local && len(srvid.Datacenters) > 0 {
+			return fmt.Errorf("Service identity %q cannot specify a list of datacenters on a local token", srvid.ServiceName)
+		}
+	}
+	token.ServiceIdentities = dedupeServiceIdentities(token.ServiceIdentities)
+
 	if token.Rules != "" {
 		return fmt.Errorf("Rules cannot be specified for this token")
 	}
@@ -449,6 +564,42 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 	return nil
 }
 
+// dedupeServiceIdentities merges the service identities granting the same
+// service into one, keeping the order in which the services were first seen.
+// A service identity without datacenters is valid everywhere so it absorbs
+// any other identity for the same service.
+func dedupeServiceIdentities(in []*structs.ACLServiceIdentity) []*structs.ACLServiceIdentity {
+	if len(in) <= 1 {
+		return in
+	}
+
+	var out []*structs.ACLServiceIdentity
+	byName := make(map[string]*structs.ACLServiceIdentity)
+	for _, srvid := range in {
+		existing, ok := byName[srvid.ServiceName]
+		if !ok {
+			srvid = srvid.Clone()
+			byName[srvid.ServiceName] = srvid
+			out = append(out, srvid)
+			continue
+		}
+
+		if len(existing.Datacenters) == 0 {
+			continue
+		}
+		if len(srvid.Datacenters) == 0 {
+			existing.Datacenters = nil
+			continue
+		}
+		for _, dc := range srvid.Datacenters {
+			if !lib.StrContains(existing.Datacenters, dc) {
+				existing.Datacenters = append(existing.Datacenters, dc)
+			}
+		}
+	}
+	return out
+}
+
 func (a *ACL) TokenDelete(args *structs.ACLTokenDeleteRequest, reply *string) error {
 	if err := a.aclPreCheck(); err != nil {
 		return err
@@ -548,13 +699,18 @@ func (a *ACL) TokenList(args *structs.ACLTokenListRequest, reply *structs.ACLTok
 
 	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
-			index, tokens, err := state.ACLTokenList(ws, args.IncludeLocal, args.IncludeGlobal, args.Policy)
+			index, tokens, err := state.ACLTokenList(ws, args.IncludeLocal, args.IncludeGlobal, args.Policy, args.Role, args.AuthMethod)
 			if err != nil {
 				return err
 			}
 
+			now := time.Now()
+
 			stubs := make([]*structs.ACLTokenListStub, 0, len(tokens))
 			for _, token := range tokens {
+				if token.IsExpired(now) {
+					continue
+				}
 				stubs = append(stubs, token.Stub())
 			}
 			reply.Index, reply.Tokens = index, stubs
@@ -589,6 +745,16 @@ func (a *ACL) TokenBatchRead(args *structs.ACLTokenBatchGetRequest, reply *struc
 				return err
 			}
 
+			// Expired tokens are treated as already deleted.
+			now := time.Now()
+			unexpired := make(structs.ACLTokens, 0, len(tokens))
+			for _, token := range tokens {
+				if !token.IsExpired(now) {
+					unexpired = append(unexpired, token)
+				}
+			}
+			tokens = unexpired
+
 			a.srv.filterACLWithAuthorizer(rule, &tokens)
 
 			reply.Index, reply.Tokens = index, tokens
@@ -879,6 +1045,17 @@ func (a *ACL) PolicyResolve(args *structs.ACLPolicyBatchGetRequest, reply *struc
 	for _, policyID := range identity.PolicyIDs() {
 		idMap[policyID] = nil
 	}
+
+	// policies linked through the token's roles are also allowed
+	roles, err := a.srv.acls.collectRolesForIdentity(identity, identity.RoleIDs())
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		for _, link := range role.Policies {
+			idMap[link.ID] = nil
+		}
+	}
 	for _, policy := range policies {
 		idMap[policy.ID] = policy
 	}
@@ -901,6 +1078,868 @@ func (a *ACL) PolicyResolve(args *structs.ACLPolicyBatchGetRequest, reply *struc
 	return nil
 }
 
+func (a *ACL) RoleRead(args *structs.ACLRoleGetRequest, reply *structs.ACLRoleResponse) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if done, err := a.srv.forward("ACL.RoleRead", args, args, reply); done {
+		return err
+	}
+
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			var (
+				index uint64
+				role  *structs.ACLRole
+				err   error
+			)
+			if args.RoleIDType == structs.ACLRoleName {
+				index, role, err = state.ACLRoleGetByName(ws, args.RoleID)
+			} else {
+				index, role, err = state.ACLRoleGetByID(ws, args.RoleID)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Role = index, role
+			return nil
+		})
+}
+
+func (a *ACL) RoleBatchRead(args *structs.ACLRoleBatchGetRequest, reply *structs.ACLRoleBatchResponse) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if done, err := a.srv.forward("ACL.RoleBatchRead", args, args, reply); done {
+		return err
+	}
+
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, roles, err := state.ACLRoleBatchGet(ws, args.RoleIDs)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Roles = index, roles
+			return nil
+		})
+}
+
+func (a *ACL) RoleSet(args *structs.ACLRoleSetRequest, reply *structs.ACLRole) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if !a.srv.InACLDatacenter() {
+		args.Datacenter = a.srv.config.ACLDatacenter
+	}
+
+	if done, err := a.srv.forward("ACL.RoleSet", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "role", "upsert"}, time.Now())
+
+	// Verify token is permitted to modify ACLs
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLWrite() {
+		return acl.ErrPermissionDenied
+	}
+
+	role := &args.Role
+	state := a.srv.fsm.State()
+
+	// Almost all of the checks here are also done in the state store. However,
+	// we want to prevent the raft operations when we know they are going to fail
+	// so we still do them here.
+
+	// ensure a name is set
+	if role.Name == "" {
+		return fmt.Errorf("Invalid Role: no Name is set")
+	}
+
+	if !validRoleName.MatchString(role.Name) {
+		return fmt.Errorf("Invalid Role: invalid Name. Only alphanumeric characters, '-' and '_' are allowed")
+	}
+
+	if role.ID == "" {
+		// with no role ID one will be generated
+		var err error
+
+		role.ID, err = lib.GenerateUUID(a.srv.checkRoleUUID)
+		if err != nil {
+			return err
+		}
+
+		// validate the name is unique
+		if _, existing, err := state.ACLRoleGetByName(nil, role.Name); err != nil {
+			return fmt.Errorf("acl role lookup by name failed: %v", err)
+		} else if existing != nil {
+			return fmt.Errorf("Invalid Role: A Role with Name %q already exists", role.Name)
+		}
+	} else {
+		if _, err := uuid.ParseUUID(role.ID); err != nil {
+			return fmt.Errorf("Role ID invalid UUID")
+		}
+
+		// Verify the role exists
+		_, existing, err := state.ACLRoleGetByID(nil, role.ID)
+		if err != nil {
+			return fmt.Errorf("acl role lookup failed: %v", err)
+		} else if existing == nil {
+			return fmt.Errorf("cannot find role %s", role.ID)
+		}
+
+		if existing.Name != role.Name {
+			if _, nameMatch, err := state.ACLRoleGetByName(nil, role.Name); err != nil {
+				return fmt.Errorf("acl role lookup by name failed: %v", err)
+			} else if nameMatch != nil {
+				return fmt.Errorf("Invalid Role: A role with name %q already exists", role.Name)
+			}
+		}
+	}
+
+	policyIDs := make(map[string]struct{})
+	var policies []structs.ACLRolePolicyLink
+
+	// Validate all the policy names and convert them to policy IDs
+	for _, link := range role.Policies {
+		if link.ID == "" {
+			_, policy, err := state.ACLPolicyGetByName(nil, link.Name)
+			if err != nil {
+				return fmt.Errorf("Error looking up policy for name %q: %v", link.Name, err)
+			}
+			if policy == nil {
+				return fmt.Errorf("No such ACL policy with name %q", link.Name)
+			}
+			link.ID = policy.ID
+		}
+
+		// Do not store the policy name within raft/memdb as the policy could be renamed in the future.
+		link.Name = ""
+
+		// dedup policy links by id
+		if _, ok := policyIDs[link.ID]; !ok {
+			policies = append(policies, link)
+			policyIDs[link.ID] = struct{}{}
+		}
+	}
+	role.Policies = policies
+
+	// calculate the hash for this role
+	role.SetHash(true)
+
+	req := &structs.ACLRoleBatchSetRequest{
+		Roles: structs.ACLRoles{role},
+	}
+
+	resp, err := a.srv.raftApply(structs.ACLRoleSetRequestType, req)
+	if err != nil {
+		return fmt.Errorf("Failed to apply role upsert request: %v", err)
+	}
+
+	// Remove from the cache to prevent stale cache usage
+	a.srv.acls.cache.RemoveRole(role.ID)
+
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	if _, role, err := a.srv.fsm.State().ACLRoleGetByID(nil, role.ID); err == nil && role != nil {
+		*reply = *role
+	}
+
+	return nil
+}
+
+func (a *ACL) RoleDelete(args *structs.ACLRoleDeleteRequest, reply *string) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if !a.srv.InACLDatacenter() {
+		args.Datacenter = a.srv.config.ACLDatacenter
+	}
+
+	if done, err := a.srv.forward("ACL.RoleDelete", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "role", "delete"}, time.Now())
+
+	// Verify token is permitted to modify ACLs
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLWrite() {
+		return acl.ErrPermissionDenied
+	}
+
+	_, role, err := a.srv.fsm.State().ACLRoleGetByID(nil, args.RoleID)
+	if err != nil {
+		return err
+	}
+
+	if role == nil {
+		return nil
+	}
+
+	req := structs.ACLRoleBatchDeleteRequest{
+		RoleIDs: []string{args.RoleID},
+	}
+
+	resp, err := a.srv.raftApply(structs.ACLRoleDeleteRequestType, &req)
+	if err != nil {
+		return fmt.Errorf("Failed to apply role delete request: %v", err)
+	}
+
+	a.srv.acls.cache.RemoveRole(role.ID)
+
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	*reply = role.Name
+
+	return nil
+}
+
+func (a *ACL) RoleList(args *structs.ACLRoleListRequest, reply *structs.ACLRoleListResponse) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if done, err := a.srv.forward("ACL.RoleList", args, args, reply); done {
+		return err
+	}
+
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, roles, err := state.ACLRoleList(ws, args.Policy)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Roles = index, roles
+			return nil
+		})
+}
+
+// RoleResolve is used to retrieve a subset of the roles associated with a given token
+// The role ids in the args simply act as a filter on the role set assigned to the token
+func (a *ACL) RoleResolve(args *structs.ACLRoleBatchGetRequest, reply *structs.ACLRoleBatchResponse) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if done, err := a.srv.forward("ACL.RoleResolve", args, args, reply); done {
+		return err
+	}
+
+	// get full list of roles for this token
+	identity, err := a.srv.acls.resolveIdentityFromToken(args.Token)
+	if err != nil {
+		return err
+	} else if identity == nil || identity.IsExpired(time.Now()) {
+		return acl.ErrNotFound
+	}
+
+	roles, err := a.srv.acls.collectRolesForIdentity(identity, identity.RoleIDs())
+	if err != nil {
+		return err
+	}
+
+	idMap := make(map[string]*structs.ACLRole)
+	for _, roleID := range identity.RoleIDs() {
+		idMap[roleID] = nil
+	}
+	for _, role := range roles {
+		idMap[role.ID] = role
+	}
+
+	for _, roleID := range args.RoleIDs {
+		if role, ok := idMap[roleID]; ok {
+			// only add non-deleted roles
+			if role != nil {
+				reply.Roles = append(reply.Roles, role)
+			}
+		} else {
+			// send a permission denied to indicate that the request included
+			// role ids not associated with this token
+			return acl.ErrPermissionDenied
+		}
+	}
+
+	a.srv.setQueryMeta(&reply.QueryMeta)
+
+	return nil
+}
+
+func (a *ACL) AuthMethodRead(args *structs.ACLAuthMethodGetRequest, reply *structs.ACLAuthMethodResponse) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if done, err := a.srv.forward("ACL.AuthMethodRead", args, args, reply); done {
+		return err
+	}
+
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, method, err := state.ACLAuthMethodGetByName(ws, args.AuthMethodName)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.AuthMethod = index, method
+			return nil
+		})
+}
+
+// AuthMethodSet creates or updates an auth method. Auth methods and the
+// tokens created by logging in with them are local to the datacenter so
+// the write is never forwarded to the ACL datacenter.
+func (a *ACL) AuthMethodSet(args *structs.ACLAuthMethodSetRequest, reply *structs.ACLAuthMethod) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if !a.srv.LocalTokensEnabled() {
+		return fmt.Errorf("Local tokens are disabled")
+	}
+
+	if done, err := a.srv.forward("ACL.AuthMethodSet", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "authmethod", "upsert"}, time.Now())
+
+	// Verify token is permitted to modify ACLs
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLWrite() {
+		return acl.ErrPermissionDenied
+	}
+
+	method := &args.AuthMethod
+	state := a.srv.fsm.State()
+
+	// ensure a name is set
+	if method.Name == "" {
+		return fmt.Errorf("Invalid Auth Method: no Name is set")
+	}
+	if !validAuthMethodName.MatchString(method.Name) {
+		return fmt.Errorf("Invalid Auth Method: invalid Name. Only alphanumeric characters, '-' and '_' are allowed")
+	}
+
+	// Check to see if the method exists first.
+	_, existing, err := state.ACLAuthMethodGetByName(nil, method.Name)
+	if err != nil {
+		return fmt.Errorf("acl auth method lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		if method.Type == "" {
+			method.Type = existing.Type
+		} else if existing.Type != method.Type {
+			return fmt.Errorf("the Type field is immutable")
+		}
+	}
+
+	if !authmethod.IsRegisteredType(method.Type) {
+		return fmt.Errorf("Invalid Auth Method: Type should be one of: %v", authmethod.Types())
+	}
+
+	// Instantiate a validator but do not cache it yet. This will validate the
+	// configuration.
+	if _, err := authmethod.NewValidator(method); err != nil {
+		return fmt.Errorf("Invalid Auth Method: %v", err)
+	}
+
+	req := &structs.ACLAuthMethodBatchSetRequest{
+		AuthMethods: structs.ACLAuthMethods{method},
+	}
+
+	resp, err := a.srv.raftApply(structs.ACLAuthMethodSetRequestType, req)
+	if err != nil {
+		return fmt.Errorf("Failed to apply auth method upsert request: %v", err)
+	}
+
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	if _, method, err := a.srv.fsm.State().ACLAuthMethodGetByName(nil, method.Name); err == nil && method != nil {
+		*reply = *method
+	}
+
+	return nil
+}
+
+// AuthMethodDelete deletes an auth method along with its binding rules and
+// all of the tokens created by logging in with it.
+func (a *ACL) AuthMethodDelete(args *structs.ACLAuthMethodDeleteRequest, reply *bool) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if !a.srv.LocalTokensEnabled() {
+		return fmt.Errorf("Local tokens are disabled")
+	}
+
+	if done, err := a.srv.forward("ACL.AuthMethodDelete", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "authmethod", "delete"}, time.Now())
+
+	// Verify token is permitted to modify ACLs
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLWrite() {
+		return acl.ErrPermissionDenied
+	}
+
+	state := a.srv.fsm.State()
+
+	_, method, err := state.ACLAuthMethodGetByName(nil, args.AuthMethodName)
+	if err != nil {
+		return err
+	}
+
+	if method == nil {
+		return nil
+	}
+
+	// grab the tokens here so we can invalidate our cache later on
+	_, tokens, err := state.ACLTokenList(nil, true, true, "", "", method.Name)
+	if err != nil {
+		return err
+	}
+
+	req := structs.ACLAuthMethodBatchDeleteRequest{
+		AuthMethodNames: []string{args.AuthMethodName},
+	}
+
+	resp, err := a.srv.raftApply(structs.ACLAuthMethodDeleteRequestType, &req)
+	if err != nil {
+		return fmt.Errorf("Failed to apply auth method delete request: %v", err)
+	}
+
+	// Purge the identities from the cache to prevent using the deleted tokens
+	for _, token := range tokens {
+		a.srv.acls.cache.RemoveIdentity(token.SecretID)
+	}
+
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	if reply != nil {
+		*reply = true
+	}
+
+	return nil
+}
+
+func (a *ACL) AuthMethodList(args *structs.ACLAuthMethodListRequest, reply *structs.ACLAuthMethodListResponse) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if done, err := a.srv.forward("ACL.AuthMethodList", args, args, reply); done {
+		return err
+	}
+
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, methods, err := state.ACLAuthMethodList(ws)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.AuthMethods = index, methods
+			return nil
+		})
+}
+
+func (a *ACL) BindingRuleRead(args *structs.ACLBindingRuleGetRequest, reply *structs.ACLBindingRuleResponse) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if done, err := a.srv.forward("ACL.BindingRuleRead", args, args, reply); done {
+		return err
+	}
+
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, rule, err := state.ACLBindingRuleGetByID(ws, args.BindingRuleID)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.BindingRule = index, rule
+			return nil
+		})
+}
+
+func (a *ACL) BindingRuleSet(args *structs.ACLBindingRuleSetRequest, reply *structs.ACLBindingRule) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if !a.srv.LocalTokensEnabled() {
+		return fmt.Errorf("Local tokens are disabled")
+	}
+
+	if done, err := a.srv.forward("ACL.BindingRuleSet", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "bindingrule", "upsert"}, time.Now())
+
+	// Verify token is permitted to modify ACLs
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLWrite() {
+		return acl.ErrPermissionDenied
+	}
+
+	rule := &args.BindingRule
+	state := a.srv.fsm.State()
+
+	if rule.ID == "" {
+		// with no binding rule ID one will be generated
+		var err error
+
+		rule.ID, err = lib.GenerateUUID(a.srv.checkBindingRuleUUID)
+		if err != nil {
+			return err
+		}
+	} else {
+		if _, err := uuid.ParseUUID(rule.ID); err != nil {
+			return fmt.Errorf("Binding Rule ID invalid UUID")
+		}
+
+		// Verify the binding rule exists
+		_, existing, err := state.ACLBindingRuleGetByID(nil, rule.ID)
+		if err != nil {
+			return fmt.Errorf("acl binding rule lookup failed: %v", err)
+		} else if existing == nil {
+			return fmt.Errorf("cannot find binding rule %s", rule.ID)
+		}
+
+		if rule.AuthMethod == "" {
+			rule.AuthMethod = existing.AuthMethod
+		} else if existing.AuthMethod != rule.AuthMethod {
+			return fmt.Errorf("the AuthMethod field is immutable")
+		}
+	}
+
+	// Validate all the fields
+	if rule.AuthMethod == "" {
+		return fmt.Errorf("Invalid Binding Rule: no AuthMethod is set")
+	}
+
+	_, method, err := state.ACLAuthMethodGetByName(nil, rule.AuthMethod)
+	if err != nil {
+		return fmt.Errorf("acl auth method lookup failed: %v", err)
+	} else if method == nil {
+		return fmt.Errorf("cannot find auth method with name %q", rule.AuthMethod)
+	}
+
+	validator, err := authmethod.NewValidator(method)
+	if err != nil {
+		return err
+	}
+
+	if err := validateBindingRuleSelector(rule.Selector); err != nil {
+		return fmt.Errorf("invalid Binding Rule: Selector is invalid: %v", err)
+	}
+
+	switch rule.BindType {
+	case structs.BindingRuleBindTypeService:
+	case structs.BindingRuleBindTypeRole:
+	case "":
+		return fmt.Errorf("Invalid Binding Rule: no BindType is set")
+	default:
+		return fmt.Errorf("Invalid Binding Rule: unknown BindType %q", rule.BindType)
+	}
+
+	if err := validateBindingRuleBindName(rule.BindType, rule.BindName, validator.AvailableFields()); err != nil {
+		return fmt.Errorf("Invalid Binding Rule: invalid BindName: %v", err)
+	}
+
+	req := &structs.ACLBindingRuleBatchSetRequest{
+		BindingRules: structs.ACLBindingRules{rule},
+	}
+
+	resp, err := a.srv.raftApply(structs.ACLBindingRuleSetRequestType, req)
+	if err != nil {
+		return fmt.Errorf("Failed to apply binding rule upsert request: %v", err)
+	}
+
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	if _, rule, err := a.srv.fsm.State().ACLBindingRuleGetByID(nil, rule.ID); err == nil && rule != nil {
+		*reply = *rule
+	}
+
+	return nil
+}
+
+func (a *ACL) BindingRuleDelete(args *structs.ACLBindingRuleDeleteRequest, reply *bool) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if !a.srv.LocalTokensEnabled() {
+		return fmt.Errorf("Local tokens are disabled")
+	}
+
+	if done, err := a.srv.forward("ACL.BindingRuleDelete", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "bindingrule", "delete"}, time.Now())
+
+	// Verify token is permitted to modify ACLs
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLWrite() {
+		return acl.ErrPermissionDenied
+	}
+
+	_, rule, err := a.srv.fsm.State().ACLBindingRuleGetByID(nil, args.BindingRuleID)
+	if err != nil {
+		return err
+	}
+
+	if rule == nil {
+		return nil
+	}
+
+	req := structs.ACLBindingRuleBatchDeleteRequest{
+		BindingRuleIDs: []string{args.BindingRuleID},
+	}
+
+	resp, err := a.srv.raftApply(structs.ACLBindingRuleDeleteRequestType, &req)
+	if err != nil {
+		return fmt.Errorf("Failed to apply binding rule delete request: %v", err)
+	}
+
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	if reply != nil {
+		*reply = true
+	}
+
+	return nil
+}
+
+func (a *ACL) BindingRuleList(args *structs.ACLBindingRuleListRequest, reply *structs.ACLBindingRuleListResponse) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if done, err := a.srv.forward("ACL.BindingRuleList", args, args, reply); done {
+		return err
+	}
+
+	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, rules, err := state.ACLBindingRuleList(ws, args.AuthMethod)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.BindingRules = index, rules
+			return nil
+		})
+}
+
+// Login exchanges a bearer token accepted by an auth method for a new local
+// token. The verified fields of the bearer token are run through the binding
+// rules of the auth method to decide what the token grants.
+func (a *ACL) Login(args *structs.ACLLoginRequest, reply *structs.ACLToken) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if !a.srv.LocalTokensEnabled() {
+		return fmt.Errorf("Local tokens are disabled")
+	}
+
+	if args.Token != "" {
+		return fmt.Errorf("Cannot login while already holding an ACL token")
+	}
+
+	if args.Auth == nil {
+		return fmt.Errorf("Invalid Login request: Missing auth parameters")
+	}
+
+	if done, err := a.srv.forward("ACL.Login", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "login"}, time.Now())
+
+	auth := args.Auth
+
+	// 1. take the bearer token and use the matching validator to verify it
+	_, method, err := a.srv.fsm.State().ACLAuthMethodGetByName(nil, auth.AuthMethod)
+	if err != nil {
+		return err
+	} else if method == nil {
+		return acl.ErrNotFound
+	}
+
+	validator, err := authmethod.NewValidator(method)
+	if err != nil {
+		return err
+	}
+
+	verifiedFields, err := validator.ValidateLogin(auth.BearerToken)
+	if err != nil {
+		return acl.PermissionDeniedError{Cause: err.Error()}
+	}
+
+	// 2. send the verified fields through the binding rules
+	serviceIdentities, roleLinks, err := a.srv.evaluateBindingRules(validator, verifiedFields)
+	if err != nil {
+		return err
+	}
+
+	// We try to prevent the creation of a useless token without taking a
+	// trip through the state store if we can.
+	if len(serviceIdentities) == 0 && len(roleLinks) == 0 {
+		return acl.ErrPermissionDenied
+	}
+
+	// 3. create a local token bound to the auth method
+	req := structs.ACLTokenSetRequest{
+		Datacenter: args.Datacenter,
+		ACLToken: structs.ACLToken{
+			Description:       "token created via login",
+			Local:             true,
+			AuthMethod:        auth.AuthMethod,
+			ServiceIdentities: serviceIdentities,
+			Roles:             roleLinks,
+		},
+		WriteRequest: args.WriteRequest,
+	}
+
+	return a.tokenSetInternal(&req, reply, false, true)
+}
+
+// Logout deletes the token making the request. Only tokens created by Login
+// may be deleted this way.
+func (a *ACL) Logout(args *structs.ACLLogoutRequest, reply *bool) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	if !a.srv.LocalTokensEnabled() {
+		return fmt.Errorf("Local tokens are disabled")
+	}
+
+	if args.Token == "" {
+		return acl.ErrNotFound
+	}
+
+	if done, err := a.srv.forward("ACL.Logout", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "logout"}, time.Now())
+
+	_, token, err := a.srv.fsm.State().ACLTokenGetBySecret(nil, args.Token)
+	if err != nil {
+		return err
+	} else if token == nil || token.IsExpired(time.Now()) {
+		return acl.ErrNotFound
+	} else if token.AuthMethod == "" {
+		// Can't "logout" of a token that wasn't a result of login.
+		return acl.ErrPermissionDenied
+	}
+
+	// No need to check for the local-ness of the token as tokens created
+	// by an auth method are always local.
+	req := &structs.ACLTokenBatchDeleteRequest{
+		TokenIDs: []string{token.AccessorID},
+	}
+
+	resp, err := a.srv.raftApply(structs.ACLTokenDeleteRequestType, req)
+	if err != nil {
+		return fmt.Errorf("Failed to apply token delete request: %v", err)
+	}
+
+	// Purge the identity from the cache to prevent using the previous definition of the identity
+	a.srv.acls.cache.RemoveIdentity(token.SecretID)
+
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	if reply != nil {
+		*reply = true
+	}
+
+	return nil
+}
+
 // makeACLETag returns an ETag for the given parent and policy.
 func makeACLETag(parent string, policy *acl.Policy) string {
 	return fmt.Sprintf("%s:%s", parent, policy.ID)
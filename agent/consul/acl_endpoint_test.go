@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/authmethod/jwtauth"
+	"github.com/hashicorp/consul/agent/consul/authmethod/testauth"
 	"github.com/hashicorp/consul/agent/structs"
 	tokenStore "github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/lib"
@@ -808,6 +810,175 @@ func TestACLEndpoint_TokenSet(t *testing.T) {
 	})
 }
 
+func TestACLEndpoint_TokenSet_expiration(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLTokenMinExpirationTTL = 10 * time.Millisecond
+		c.ACLTokenMaxExpirationTTL = 5 * time.Second
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	endpoint := ACL{srv: s1}
+
+	tokenSet := func(token structs.ACLToken) (*structs.ACLToken, error) {
+		req := structs.ACLTokenSetRequest{
+			Datacenter:   "dc1",
+			ACLToken:     token,
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		resp := structs.ACLToken{}
+		if err := endpoint.TokenSet(&req, &resp); err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+
+	t.Run("Create with TTL", func(t *testing.T) {
+		token, err := tokenSet(structs.ACLToken{
+			Description:   "foobar",
+			ExpirationTTL: 4 * time.Second,
+		})
+		require.NoError(t, err)
+		require.True(t, token.HasExpirationTime())
+		require.Equal(t, time.Duration(0), token.ExpirationTTL)
+		require.Equal(t, token.CreateTime.Add(4*time.Second), *token.ExpirationTime)
+
+		t.Run("Update keeps expiration", func(t *testing.T) {
+			updated, err := tokenSet(structs.ACLToken{
+				AccessorID:  token.AccessorID,
+				Description: "new-description",
+			})
+			require.NoError(t, err)
+			require.True(t, updated.HasExpirationTime())
+			require.True(t, token.ExpirationTime.Equal(*updated.ExpirationTime))
+		})
+
+		t.Run("Update with same expiration", func(t *testing.T) {
+			_, err := tokenSet(structs.ACLToken{
+				AccessorID:     token.AccessorID,
+				Description:    "new-description",
+				ExpirationTime: token.ExpirationTime,
+			})
+			require.NoError(t, err)
+		})
+
+		t.Run("Update cannot change TTL", func(t *testing.T) {
+			_, err := tokenSet(structs.ACLToken{
+				AccessorID:    token.AccessorID,
+				ExpirationTTL: time.Second,
+			})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "Cannot change expiration time")
+		})
+
+		t.Run("Update cannot change expiration time", func(t *testing.T) {
+			expTime := token.ExpirationTime.Add(-time.Second)
+			_, err := tokenSet(structs.ACLToken{
+				AccessorID:     token.AccessorID,
+				ExpirationTime: &expTime,
+			})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "Cannot change expiration time")
+		})
+	})
+
+	t.Run("Create with expiration time", func(t *testing.T) {
+		expTime := time.Now().Add(4 * time.Second)
+		token, err := tokenSet(structs.ACLToken{
+			Description:    "foobar",
+			ExpirationTime: &expTime,
+		})
+		require.NoError(t, err)
+		require.True(t, expTime.Equal(*token.ExpirationTime))
+	})
+
+	t.Run("Create with TTL and expiration time", func(t *testing.T) {
+		expTime := time.Now().Add(4 * time.Second)
+		_, err := tokenSet(structs.ACLToken{
+			ExpirationTime: &expTime,
+			ExpirationTTL:  4 * time.Second,
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Expiration TTL and Expiration Time cannot both be set")
+	})
+
+	t.Run("Create with negative TTL", func(t *testing.T) {
+		_, err := tokenSet(structs.ACLToken{
+			ExpirationTTL: -time.Second,
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "should be > 0")
+	})
+
+	t.Run("Create with TTL too long", func(t *testing.T) {
+		_, err := tokenSet(structs.ACLToken{
+			ExpirationTTL: time.Minute,
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cannot be more than 5s in the future")
+	})
+
+	t.Run("Create with TTL too short", func(t *testing.T) {
+		_, err := tokenSet(structs.ACLToken{
+			ExpirationTTL: time.Millisecond,
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cannot be less than 10ms in the future")
+	})
+
+	t.Run("Create with expiration time in the past", func(t *testing.T) {
+		expTime := time.Now().Add(-time.Minute)
+		_, err := tokenSet(structs.ACLToken{
+			ExpirationTime: &expTime,
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "ExpirationTime cannot be before CreateTime")
+	})
+
+	t.Run("Expired tokens are hidden", func(t *testing.T) {
+		token, err := tokenSet(structs.ACLToken{
+			Description:   "short-lived",
+			ExpirationTTL: 20 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		time.Sleep(50 * time.Millisecond)
+
+		tokenResp, err := retrieveTestToken(codec, "root", "dc1", token.AccessorID)
+		require.NoError(t, err)
+		require.Nil(t, tokenResp.Token)
+
+		listResp := structs.ACLTokenListResponse{}
+		err = endpoint.TokenList(&structs.ACLTokenListRequest{
+			Datacenter:   "dc1",
+			QueryOptions: structs.QueryOptions{Token: "root"},
+		}, &listResp)
+		require.NoError(t, err)
+		for _, stub := range listResp.Tokens {
+			require.NotEqual(t, token.AccessorID, stub.AccessorID)
+		}
+
+		_, err = endpoint.srv.ResolveToken(token.SecretID)
+		require.True(t, acl.IsErrNotFound(err), "err: %v", err)
+
+		_, err = tokenSet(structs.ACLToken{
+			AccessorID:  token.AccessorID,
+			Description: "revived",
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Cannot find token")
+	})
+}
+
 func TestACLEndpoint_TokenSet_anon(t *testing.T) {
 	t.Parallel()
 
@@ -1490,95 +1661,1281 @@ func TestACLEndpoint_PolicyResolve(t *testing.T) {
 	require.EqualValues(t, retrievedPolicies, policies)
 }
 
-// upsertTestToken creates a token for testing purposes
-func upsertTestToken(codec rpc.ClientCodec, masterToken string, datacenter string) (*structs.ACLToken, error) {
-	arg := structs.ACLTokenSetRequest{
-		Datacenter: datacenter,
+func TestACLEndpoint_TokenSet_roles(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	r1, err := upsertTestRole(codec, "root", "dc1")
+	require.NoError(t, err)
+
+	acl := ACL{srv: s1}
+
+	// link the role both by ID and by name, the duplicate is dropped
+	req := structs.ACLTokenSetRequest{
+		Datacenter: "dc1",
 		ACLToken: structs.ACLToken{
-			Description: "User token",
-			Local:       false,
-			Policies:    nil,
+			Description: "foobar",
+			Roles: []structs.ACLTokenRoleLink{
+				structs.ACLTokenRoleLink{
+					ID: r1.ID,
+				},
+				structs.ACLTokenRoleLink{
+					Name: r1.Name,
+				},
+			},
 		},
-		WriteRequest: structs.WriteRequest{Token: masterToken},
+		WriteRequest: structs.WriteRequest{Token: "root"},
 	}
+	resp := structs.ACLToken{}
 
-	var out structs.ACLToken
-
-	err := msgpackrpc.CallWithCodec(codec, "ACL.TokenSet", &arg, &out)
+	require.NoError(t, acl.TokenSet(&req, &resp))
 
-	if err != nil {
-		return nil, err
+	tokenResp, err := retrieveTestToken(codec, "root", "dc1", resp.AccessorID)
+	require.NoError(t, err)
+	token := tokenResp.Token
+	require.Len(t, token.Roles, 1)
+	require.Equal(t, r1.ID, token.Roles[0].ID)
+	require.Equal(t, r1.Name, token.Roles[0].Name)
+
+	// linking a role that doesn't exist fails
+	req.ACLToken.Roles = []structs.ACLTokenRoleLink{
+		structs.ACLTokenRoleLink{
+			Name: "does-not-exist",
+		},
 	}
+	require.Error(t, acl.TokenSet(&req, &resp))
+}
 
-	if out.AccessorID == "" {
-		return nil, fmt.Errorf("AccessorID is nil: %v", out)
-	}
+func TestACLEndpoint_TokenSet_serviceIdentities(t *testing.T) {
+	t.Parallel()
 
-	return &out, nil
-}
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
 
-// retrieveTestToken returns a policy for testing purposes
-func retrieveTestToken(codec rpc.ClientCodec, masterToken string, datacenter string, id string) (*structs.ACLTokenResponse, error) {
-	arg := structs.ACLTokenGetRequest{
-		Datacenter:   datacenter,
-		TokenID:      id,
-		TokenIDType:  structs.ACLTokenAccessor,
-		QueryOptions: structs.QueryOptions{Token: masterToken},
-	}
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
 
-	var out structs.ACLTokenResponse
+	acl := ACL{srv: s1}
 
-	err := msgpackrpc.CallWithCodec(codec, "ACL.TokenRead", &arg, &out)
+	t.Run("Create", func(t *testing.T) {
+		req := structs.ACLTokenSetRequest{
+			Datacenter: "dc1",
+			ACLToken: structs.ACLToken{
+				Description: "foobar",
+				ServiceIdentities: []*structs.ACLServiceIdentity{
+					&structs.ACLServiceIdentity{
+						ServiceName: "web",
+						Datacenters: []string{"dc1"},
+					},
+					&structs.ACLServiceIdentity{
+						ServiceName: "db",
+					},
+					&structs.ACLServiceIdentity{
+						ServiceName: "web",
+						Datacenters: []string{"dc2", "dc1"},
+					},
+				},
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		resp := structs.ACLToken{}
 
-	if err != nil {
-		return nil, err
+		require.NoError(t, acl.TokenSet(&req, &resp))
+
+		tokenResp, err := retrieveTestToken(codec, "root", "dc1", resp.AccessorID)
+		require.NoError(t, err)
+		token := tokenResp.Token
+		require.Equal(t, []*structs.ACLServiceIdentity{
+			&structs.ACLServiceIdentity{
+				ServiceName: "web",
+				Datacenters: []string{"dc1", "dc2"},
+			},
+			&structs.ACLServiceIdentity{
+				ServiceName: "db",
+			},
+		}, token.ServiceIdentities)
+	})
+
+	invalid := map[string]structs.ACLToken{
+		"missing name": structs.ACLToken{
+			ServiceIdentities: []*structs.ACLServiceIdentity{
+				&structs.ACLServiceIdentity{},
+			},
+		},
+		"uppercase name": structs.ACLToken{
+			ServiceIdentities: []*structs.ACLServiceIdentity{
+				&structs.ACLServiceIdentity{ServiceName: "Web"},
+			},
+		},
+		"quoted name": structs.ACLToken{
+			ServiceIdentities: []*structs.ACLServiceIdentity{
+				&structs.ACLServiceIdentity{ServiceName: `web" { policy = "write" } service "`},
+			},
+		},
+		"trailing dash": structs.ACLToken{
+			ServiceIdentities: []*structs.ACLServiceIdentity{
+				&structs.ACLServiceIdentity{ServiceName: "web-"},
+			},
+		},
+		"local with datacenters": structs.ACLToken{
+			Local: true,
+			ServiceIdentities: []*structs.ACLServiceIdentity{
+				&structs.ACLServiceIdentity{
+					ServiceName: "web",
+					Datacenters: []string{"dc1"},
+				},
+			},
+		},
+	}
+	for name, token := range invalid {
+		token := token
+		t.Run(name, func(t *testing.T) {
+			req := structs.ACLTokenSetRequest{
+				Datacenter:   "dc1",
+				ACLToken:     token,
+				WriteRequest: structs.WriteRequest{Token: "root"},
+			}
+			resp := structs.ACLToken{}
+			require.Error(t, acl.TokenSet(&req, &resp))
+		})
 	}
+}
 
-	return &out, nil
+func TestACLEndpoint_RoleRead(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	role, err := upsertTestRole(codec, "root", "dc1")
+	require.NoError(t, err)
+
+	acl := ACL{srv: s1}
+
+	t.Run("by id", func(t *testing.T) {
+		req := structs.ACLRoleGetRequest{
+			Datacenter:   "dc1",
+			RoleID:       role.ID,
+			QueryOptions: structs.QueryOptions{Token: "root"},
+		}
+
+		resp := structs.ACLRoleResponse{}
+
+		require.NoError(t, acl.RoleRead(&req, &resp))
+		require.Equal(t, role, resp.Role)
+	})
+
+	t.Run("by name", func(t *testing.T) {
+		req := structs.ACLRoleGetRequest{
+			Datacenter:   "dc1",
+			RoleID:       role.Name,
+			RoleIDType:   structs.ACLRoleName,
+			QueryOptions: structs.QueryOptions{Token: "root"},
+		}
+
+		resp := structs.ACLRoleResponse{}
+
+		require.NoError(t, acl.RoleRead(&req, &resp))
+		require.Equal(t, role, resp.Role)
+	})
 }
 
-// upsertTestPolicy creates a policy for testing purposes
-func upsertTestPolicy(codec rpc.ClientCodec, masterToken string, datacenter string) (*structs.ACLPolicy, error) {
-	// Make sure test policies can't collide
-	policyUnq, err := uuid.GenerateUUID()
-	if err != nil {
-		return nil, err
-	}
+func TestACLEndpoint_RoleBatchRead(t *testing.T) {
+	t.Parallel()
 
-	arg := structs.ACLPolicySetRequest{
-		Datacenter: datacenter,
-		Policy: structs.ACLPolicy{
-			Name: fmt.Sprintf("test-policy-%s", policyUnq),
-		},
-		WriteRequest: structs.WriteRequest{Token: masterToken},
-	}
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
 
-	var out structs.ACLPolicy
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
 
-	err = msgpackrpc.CallWithCodec(codec, "ACL.PolicySet", &arg, &out)
+	r1, err := upsertTestRole(codec, "root", "dc1")
+	require.NoError(t, err)
 
-	if err != nil {
-		return nil, err
-	}
+	r2, err := upsertTestRole(codec, "root", "dc1")
+	require.NoError(t, err)
 
-	if out.ID == "" {
-		return nil, fmt.Errorf("ID is nil: %v", out)
+	acl := ACL{srv: s1}
+	roles := []string{r1.ID, r2.ID}
+
+	req := structs.ACLRoleBatchGetRequest{
+		Datacenter:   "dc1",
+		RoleIDs:      roles,
+		QueryOptions: structs.QueryOptions{Token: "root"},
 	}
 
-	return &out, nil
-}
+	resp := structs.ACLRoleBatchResponse{}
 
-// retrieveTestPolicy returns a policy for testing purposes
-func retrieveTestPolicy(codec rpc.ClientCodec, masterToken string, datacenter string, id string) (*structs.ACLPolicyResponse, error) {
-	arg := structs.ACLPolicyGetRequest{
-		Datacenter:   datacenter,
-		PolicyID:     id,
-		QueryOptions: structs.QueryOptions{Token: masterToken},
+	require.NoError(t, acl.RoleBatchRead(&req, &resp))
+	var retrievedRoles []string
+	for _, role := range resp.Roles {
+		retrievedRoles = append(retrievedRoles, role.ID)
 	}
+	require.ElementsMatch(t, roles, retrievedRoles)
+}
 
-	var out structs.ACLPolicyResponse
+func TestACLEndpoint_RoleSet(t *testing.T) {
+	t.Parallel()
 
-	err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyRead", &arg, &out)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	p1, err := upsertTestPolicy(codec, "root", "dc1")
+	require.NoError(t, err)
+
+	p2, err := upsertTestPolicy(codec, "root", "dc1")
+	require.NoError(t, err)
+
+	acl := ACL{srv: s1}
+	var roleID string
+
+	// Create it
+	{
+		req := structs.ACLRoleSetRequest{
+			Datacenter: "dc1",
+			Role: structs.ACLRole{
+				Description: "foobar",
+				Name:        "baz",
+				Policies: []structs.ACLRolePolicyLink{
+					structs.ACLRolePolicyLink{
+						ID: p1.ID,
+					},
+				},
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		resp := structs.ACLRole{}
+
+		require.NoError(t, acl.RoleSet(&req, &resp))
+		require.NotEmpty(t, resp.ID)
+
+		// Get the role directly to validate that it exists
+		roleResp, err := retrieveTestRole(codec, "root", "dc1", resp.ID)
+		require.NoError(t, err)
+		role := roleResp.Role
+
+		require.NotNil(t, role.ID)
+		require.Equal(t, "foobar", role.Description)
+		require.Equal(t, "baz", role.Name)
+		require.Len(t, role.Policies, 1)
+		require.Equal(t, p1.ID, role.Policies[0].ID)
+		require.Equal(t, p1.Name, role.Policies[0].Name)
+
+		roleID = role.ID
+	}
+
+	// Update it, linking the policy by name
+	{
+		req := structs.ACLRoleSetRequest{
+			Datacenter: "dc1",
+			Role: structs.ACLRole{
+				ID:          roleID,
+				Description: "bat",
+				Name:        "bar",
+				Policies: []structs.ACLRolePolicyLink{
+					structs.ACLRolePolicyLink{
+						Name: p2.Name,
+					},
+				},
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		resp := structs.ACLRole{}
+
+		require.NoError(t, acl.RoleSet(&req, &resp))
+
+		roleResp, err := retrieveTestRole(codec, "root", "dc1", resp.ID)
+		require.NoError(t, err)
+		role := roleResp.Role
+
+		require.Equal(t, roleID, role.ID)
+		require.Equal(t, "bat", role.Description)
+		require.Equal(t, "bar", role.Name)
+		require.Len(t, role.Policies, 1)
+		require.Equal(t, p2.ID, role.Policies[0].ID)
+	}
+
+	// Invalid names are rejected
+	{
+		req := structs.ACLRoleSetRequest{
+			Datacenter: "dc1",
+			Role: structs.ACLRole{
+				Name: "invalid name",
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		resp := structs.ACLRole{}
+
+		require.Error(t, acl.RoleSet(&req, &resp))
+	}
+
+	// Duplicate names are rejected
+	{
+		req := structs.ACLRoleSetRequest{
+			Datacenter: "dc1",
+			Role: structs.ACLRole{
+				Name: "bar",
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		resp := structs.ACLRole{}
+
+		require.Error(t, acl.RoleSet(&req, &resp))
+	}
+}
+
+func TestACLEndpoint_RoleDelete(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	existingRole, err := upsertTestRole(codec, "root", "dc1")
+	require.NoError(t, err)
+
+	acl := ACL{srv: s1}
+
+	req := structs.ACLRoleDeleteRequest{
+		Datacenter:   "dc1",
+		RoleID:       existingRole.ID,
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+
+	var resp string
+
+	require.NoError(t, acl.RoleDelete(&req, &resp))
+	require.Equal(t, existingRole.Name, resp)
+
+	// Make sure the role is gone
+	roleResp, err := retrieveTestRole(codec, "root", "dc1", existingRole.ID)
+	require.NoError(t, err)
+	require.Nil(t, roleResp.Role)
+}
+
+func TestACLEndpoint_RoleList(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	r1, err := upsertTestRole(codec, "root", "dc1")
+	require.NoError(t, err)
+
+	r2, err := upsertTestRole(codec, "root", "dc1")
+	require.NoError(t, err)
+
+	acl := ACL{srv: s1}
+
+	req := structs.ACLRoleListRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}
+
+	resp := structs.ACLRoleListResponse{}
+
+	require.NoError(t, acl.RoleList(&req, &resp))
+
+	var retrievedRoles []string
+	for _, v := range resp.Roles {
+		retrievedRoles = append(retrievedRoles, v.ID)
+	}
+	require.ElementsMatch(t, []string{r1.ID, r2.ID}, retrievedRoles)
+}
+
+func TestACLEndpoint_RoleResolve(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	r1, err := upsertTestRole(codec, "root", "dc1")
+	require.NoError(t, err)
+
+	r2, err := upsertTestRole(codec, "root", "dc1")
+	require.NoError(t, err)
+
+	acl := ACL{srv: s1}
+
+	// Assign only the first role to a token
+	tokenUpsertReq := structs.ACLTokenSetRequest{
+		Datacenter: "dc1",
+		ACLToken: structs.ACLToken{
+			Roles: []structs.ACLTokenRoleLink{
+				structs.ACLTokenRoleLink{
+					ID: r1.ID,
+				},
+			},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	token := structs.ACLToken{}
+	require.NoError(t, acl.TokenSet(&tokenUpsertReq, &token))
+	require.NotEmpty(t, token.SecretID)
+
+	resp := structs.ACLRoleBatchResponse{}
+	req := structs.ACLRoleBatchGetRequest{
+		Datacenter:   "dc1",
+		RoleIDs:      []string{r1.ID},
+		QueryOptions: structs.QueryOptions{Token: token.SecretID},
+	}
+	require.NoError(t, acl.RoleResolve(&req, &resp))
+	require.Len(t, resp.Roles, 1)
+	require.Equal(t, r1.ID, resp.Roles[0].ID)
+
+	// roles not linked to the token can't be resolved with it
+	req.RoleIDs = []string{r1.ID, r2.ID}
+	err = acl.RoleResolve(&req, &resp)
+	require.EqualError(t, err, "Permission denied")
+}
+
+// upsertTestToken creates a token for testing purposes
+func TestACLEndpoint_AuthMethodSet(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	acl := ACL{srv: s1}
+
+	newReq := func(method structs.ACLAuthMethod) *structs.ACLAuthMethodSetRequest {
+		return &structs.ACLAuthMethodSetRequest{
+			Datacenter:   "dc1",
+			AuthMethod:   method,
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+	}
+
+	// Create it
+	{
+		req := newReq(structs.ACLAuthMethod{
+			Name:        "test",
+			Type:        testauth.Type,
+			Description: "test",
+			Config: map[string]interface{}{
+				"SessionID": "4f64b8e1-4f86-4ec2-8fd3-4f0cd0f0a1b6",
+			},
+		})
+		resp := structs.ACLAuthMethod{}
+
+		require.NoError(t, acl.AuthMethodSet(req, &resp))
+
+		// Get the method over RPC to validate the Config survives msgpack
+		methodResp, err := retrieveTestAuthMethod(codec, "root", "dc1", "test")
+		require.NoError(t, err)
+		method := methodResp.AuthMethod
+
+		require.Equal(t, "test", method.Name)
+		require.Equal(t, "test", method.Description)
+		require.Equal(t, testauth.Type, method.Type)
+		require.Equal(t, map[string]interface{}{
+			"SessionID": "4f64b8e1-4f86-4ec2-8fd3-4f0cd0f0a1b6",
+		}, method.Config)
+	}
+
+	// Update it, keeping the type
+	{
+		req := newReq(structs.ACLAuthMethod{
+			Name:        "test",
+			Description: "modified",
+		})
+		resp := structs.ACLAuthMethod{}
+
+		require.NoError(t, acl.AuthMethodSet(req, &resp))
+		require.Equal(t, "modified", resp.Description)
+		require.Equal(t, testauth.Type, resp.Type)
+	}
+
+	for name, tc := range map[string]struct {
+		method structs.ACLAuthMethod
+	}{
+		"no name": {
+			structs.ACLAuthMethod{Type: testauth.Type},
+		},
+		"invalid name": {
+			structs.ACLAuthMethod{Name: "bad name", Type: testauth.Type},
+		},
+		"unknown type": {
+			structs.ACLAuthMethod{Name: "other", Type: "invalid"},
+		},
+		"type change": {
+			structs.ACLAuthMethod{Name: "test", Type: jwtauth.Type},
+		},
+		"invalid config": {
+			structs.ACLAuthMethod{
+				Name:   "other",
+				Type:   testauth.Type,
+				Config: map[string]interface{}{"Bogus": "value"},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := structs.ACLAuthMethod{}
+			require.Error(t, acl.AuthMethodSet(newReq(tc.method), &resp))
+		})
+	}
+}
+
+func TestACLEndpoint_AuthMethodDelete(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	testSessionID := testauth.StartSession()
+	defer testauth.ResetSession(testSessionID)
+	testauth.InstallSessionToken(testSessionID, "fake-web", map[string]string{"service": "web"})
+
+	method, err := upsertTestAuthMethod(codec, "root", "dc1", testSessionID)
+	require.NoError(t, err)
+
+	rule, err := upsertTestBindingRule(codec, "root", "dc1", method.Name, "", structs.BindingRuleBindTypeService, "${value.service}")
+	require.NoError(t, err)
+
+	acl := ACL{srv: s1}
+
+	login := structs.ACLLoginRequest{
+		Auth: &structs.ACLLoginParams{
+			AuthMethod:  method.Name,
+			BearerToken: "fake-web",
+		},
+		Datacenter: "dc1",
+	}
+	var token structs.ACLToken
+	require.NoError(t, acl.Login(&login, &token))
+
+	req := structs.ACLAuthMethodDeleteRequest{
+		Datacenter:     "dc1",
+		AuthMethodName: method.Name,
+		WriteRequest:   structs.WriteRequest{Token: "root"},
+	}
+	var ignored bool
+	require.NoError(t, acl.AuthMethodDelete(&req, &ignored))
+
+	// Make sure the method, its rules and its tokens are gone
+	methodResp, err := retrieveTestAuthMethod(codec, "root", "dc1", method.Name)
+	require.NoError(t, err)
+	require.Nil(t, methodResp.AuthMethod)
+
+	ruleResp, err := retrieveTestBindingRule(codec, "root", "dc1", rule.ID)
+	require.NoError(t, err)
+	require.Nil(t, ruleResp.BindingRule)
+
+	tokenResp, err := retrieveTestToken(codec, "root", "dc1", token.AccessorID)
+	require.NoError(t, err)
+	require.Nil(t, tokenResp.Token)
+
+	// deleting a missing method is not an error
+	require.NoError(t, acl.AuthMethodDelete(&req, &ignored))
+}
+
+func TestACLEndpoint_AuthMethodList(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	m1, err := upsertTestAuthMethod(codec, "root", "dc1", "")
+	require.NoError(t, err)
+
+	m2, err := upsertTestAuthMethod(codec, "root", "dc1", "")
+	require.NoError(t, err)
+
+	req := structs.ACLAuthMethodListRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}
+	resp := structs.ACLAuthMethodListResponse{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.AuthMethodList", &req, &resp))
+
+	var names []string
+	for _, method := range resp.AuthMethods {
+		names = append(names, method.Name)
+	}
+	require.ElementsMatch(t, []string{m1.Name, m2.Name}, names)
+
+	// listing requires acl:read
+	req.Token = ""
+	require.True(t, acl.IsErrPermissionDenied(msgpackrpc.CallWithCodec(codec, "ACL.AuthMethodList", &req, &resp)))
+}
+
+func TestACLEndpoint_BindingRuleSet(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	method, err := upsertTestAuthMethod(codec, "root", "dc1", "")
+	require.NoError(t, err)
+
+	acl := ACL{srv: s1}
+
+	newReq := func(rule structs.ACLBindingRule) *structs.ACLBindingRuleSetRequest {
+		return &structs.ACLBindingRuleSetRequest{
+			Datacenter:   "dc1",
+			BindingRule:  rule,
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+	}
+
+	var ruleID string
+
+	// Create it
+	{
+		req := newReq(structs.ACLBindingRule{
+			Description: "foobar",
+			AuthMethod:  method.Name,
+			Selector:    "value.team == web",
+			BindType:    structs.BindingRuleBindTypeService,
+			BindName:    "web-${value.service}",
+		})
+		resp := structs.ACLBindingRule{}
+
+		require.NoError(t, acl.BindingRuleSet(req, &resp))
+		require.NotEmpty(t, resp.ID)
+
+		ruleResp, err := retrieveTestBindingRule(codec, "root", "dc1", resp.ID)
+		require.NoError(t, err)
+		rule := ruleResp.BindingRule
+
+		require.Equal(t, "foobar", rule.Description)
+		require.Equal(t, method.Name, rule.AuthMethod)
+		require.Equal(t, "value.team == web", rule.Selector)
+		require.Equal(t, structs.BindingRuleBindTypeService, rule.BindType)
+		require.Equal(t, "web-${value.service}", rule.BindName)
+
+		ruleID = rule.ID
+	}
+
+	// Update it, keeping the auth method
+	{
+		req := newReq(structs.ACLBindingRule{
+			ID:       ruleID,
+			BindType: structs.BindingRuleBindTypeRole,
+			BindName: "role-${value.team}",
+		})
+		resp := structs.ACLBindingRule{}
+
+		require.NoError(t, acl.BindingRuleSet(req, &resp))
+		require.Equal(t, method.Name, resp.AuthMethod)
+		require.Equal(t, structs.BindingRuleBindTypeRole, resp.BindType)
+		require.Equal(t, "", resp.Selector)
+	}
+
+	for name, tc := range map[string]structs.ACLBindingRule{
+		"missing auth method": {
+			BindType: structs.BindingRuleBindTypeService,
+			BindName: "web",
+		},
+		"unknown auth method": {
+			AuthMethod: "not-found",
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "web",
+		},
+		"bad selector": {
+			AuthMethod: method.Name,
+			Selector:   "value.team ==",
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "web",
+		},
+		"missing bind type": {
+			AuthMethod: method.Name,
+			BindName:   "web",
+		},
+		"unknown bind type": {
+			AuthMethod: method.Name,
+			BindType:   "policy",
+			BindName:   "web",
+		},
+		"missing bind name": {
+			AuthMethod: method.Name,
+			BindType:   structs.BindingRuleBindTypeService,
+		},
+		"invalid bind name": {
+			AuthMethod: method.Name,
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "Web!",
+		},
+		"unknown field": {
+			AuthMethod: method.Name,
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "${value.namespace}",
+		},
+		"unknown id": {
+			ID:         "b7f1a5ae-4462-4d7d-9ab2-0a2cb3f7d3f1",
+			AuthMethod: method.Name,
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "web",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := structs.ACLBindingRule{}
+			require.Error(t, acl.BindingRuleSet(newReq(tc), &resp))
+		})
+	}
+}
+
+func TestACLEndpoint_BindingRuleDelete(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	method, err := upsertTestAuthMethod(codec, "root", "dc1", "")
+	require.NoError(t, err)
+
+	rule, err := upsertTestBindingRule(codec, "root", "dc1", method.Name, "", structs.BindingRuleBindTypeService, "web")
+	require.NoError(t, err)
+
+	acl := ACL{srv: s1}
+
+	req := structs.ACLBindingRuleDeleteRequest{
+		Datacenter:    "dc1",
+		BindingRuleID: rule.ID,
+		WriteRequest:  structs.WriteRequest{Token: "root"},
+	}
+	var ignored bool
+	require.NoError(t, acl.BindingRuleDelete(&req, &ignored))
+
+	ruleResp, err := retrieveTestBindingRule(codec, "root", "dc1", rule.ID)
+	require.NoError(t, err)
+	require.Nil(t, ruleResp.BindingRule)
+}
+
+func TestACLEndpoint_BindingRuleList(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	m1, err := upsertTestAuthMethod(codec, "root", "dc1", "")
+	require.NoError(t, err)
+	m2, err := upsertTestAuthMethod(codec, "root", "dc1", "")
+	require.NoError(t, err)
+
+	r1, err := upsertTestBindingRule(codec, "root", "dc1", m1.Name, "", structs.BindingRuleBindTypeService, "web")
+	require.NoError(t, err)
+	r2, err := upsertTestBindingRule(codec, "root", "dc1", m2.Name, "", structs.BindingRuleBindTypeService, "db")
+	require.NoError(t, err)
+
+	list := func(methodName string) []string {
+		req := structs.ACLBindingRuleListRequest{
+			Datacenter:   "dc1",
+			AuthMethod:   methodName,
+			QueryOptions: structs.QueryOptions{Token: "root"},
+		}
+		resp := structs.ACLBindingRuleListResponse{}
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.BindingRuleList", &req, &resp))
+
+		var ids []string
+		for _, rule := range resp.BindingRules {
+			ids = append(ids, rule.ID)
+		}
+		return ids
+	}
+
+	require.ElementsMatch(t, []string{r1.ID, r2.ID}, list(""))
+	require.ElementsMatch(t, []string{r2.ID}, list(m2.Name))
+}
+
+func TestACLEndpoint_Login_Logout(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	testSessionID := testauth.StartSession()
+	defer testauth.ResetSession(testSessionID)
+
+	testauth.InstallSessionToken(testSessionID, "fake-web", map[string]string{
+		"service": "web",
+		"team":    "frontend",
+	})
+	testauth.InstallSessionToken(testSessionID, "fake-db", map[string]string{
+		"service": "db",
+		"team":    "backend",
+	})
+	testauth.InstallSessionToken(testSessionID, "fake-ops", map[string]string{
+		"service": "monitor",
+		"team":    "ops",
+	})
+
+	method, err := upsertTestAuthMethod(codec, "root", "dc1", testSessionID)
+	require.NoError(t, err)
+
+	_, err = upsertTestBindingRule(codec, "root", "dc1", method.Name, "value.team != ops", structs.BindingRuleBindTypeService, "${value.service}")
+	require.NoError(t, err)
+	_, err = upsertTestBindingRule(codec, "root", "dc1", method.Name, "value.team == backend", structs.BindingRuleBindTypeRole, "${value.team}-role")
+	require.NoError(t, err)
+
+	var role structs.ACLRole
+	roleReq := structs.ACLRoleSetRequest{
+		Datacenter:   "dc1",
+		Role:         structs.ACLRole{Name: "backend-role"},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.RoleSet", &roleReq, &role))
+
+	endpoint := ACL{srv: s1}
+
+	login := func(bearerToken string) (*structs.ACLToken, error) {
+		req := structs.ACLLoginRequest{
+			Auth: &structs.ACLLoginParams{
+				AuthMethod:  method.Name,
+				BearerToken: bearerToken,
+			},
+			Datacenter: "dc1",
+		}
+		var resp structs.ACLToken
+		if err := endpoint.Login(&req, &resp); err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+
+	logout := func(secretID string) error {
+		req := structs.ACLLogoutRequest{
+			Datacenter:   "dc1",
+			WriteRequest: structs.WriteRequest{Token: secretID},
+		}
+		var ignored bool
+		return endpoint.Logout(&req, &ignored)
+	}
+
+	t.Run("unknown method", func(t *testing.T) {
+		req := structs.ACLLoginRequest{
+			Auth: &structs.ACLLoginParams{
+				AuthMethod:  "not-found",
+				BearerToken: "fake-web",
+			},
+			Datacenter: "dc1",
+		}
+		var resp structs.ACLToken
+		require.True(t, acl.IsErrNotFound(endpoint.Login(&req, &resp)))
+	})
+
+	t.Run("unknown bearer token", func(t *testing.T) {
+		_, err := login("fake-unknown")
+		require.True(t, acl.IsErrPermissionDenied(err))
+	})
+
+	t.Run("no matching rules", func(t *testing.T) {
+		_, err := login("fake-ops")
+		require.True(t, acl.IsErrPermissionDenied(err))
+	})
+
+	t.Run("service identity", func(t *testing.T) {
+		token, err := login("fake-web")
+		require.NoError(t, err)
+		require.True(t, token.Local)
+		require.Equal(t, method.Name, token.AuthMethod)
+		require.Len(t, token.ServiceIdentities, 1)
+		require.Equal(t, "web", token.ServiceIdentities[0].ServiceName)
+		require.Empty(t, token.Roles)
+
+		require.NoError(t, logout(token.SecretID))
+
+		tokenResp, err := retrieveTestToken(codec, "root", "dc1", token.AccessorID)
+		require.NoError(t, err)
+		require.Nil(t, tokenResp.Token)
+	})
+
+	t.Run("service identity and role", func(t *testing.T) {
+		token, err := login("fake-db")
+		require.NoError(t, err)
+		require.Len(t, token.ServiceIdentities, 1)
+		require.Equal(t, "db", token.ServiceIdentities[0].ServiceName)
+		require.Len(t, token.Roles, 1)
+		require.Equal(t, role.ID, token.Roles[0].ID)
+
+		require.NoError(t, logout(token.SecretID))
+	})
+
+	t.Run("logout of a regular token", func(t *testing.T) {
+		token, err := upsertTestToken(codec, "root", "dc1")
+		require.NoError(t, err)
+
+		require.True(t, acl.IsErrPermissionDenied(logout(token.SecretID)))
+		require.True(t, acl.IsErrNotFound(logout("")))
+	})
+
+	t.Run("token set cannot bind to a method", func(t *testing.T) {
+		req := structs.ACLTokenSetRequest{
+			Datacenter: "dc1",
+			ACLToken: structs.ACLToken{
+				Local:      true,
+				AuthMethod: method.Name,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var resp structs.ACLToken
+		require.Error(t, endpoint.TokenSet(&req, &resp))
+	})
+}
+
+func upsertTestToken(codec rpc.ClientCodec, masterToken string, datacenter string) (*structs.ACLToken, error) {
+	arg := structs.ACLTokenSetRequest{
+		Datacenter: datacenter,
+		ACLToken: structs.ACLToken{
+			Description: "User token",
+			Local:       false,
+			Policies:    nil,
+		},
+		WriteRequest: structs.WriteRequest{Token: masterToken},
+	}
+
+	var out structs.ACLToken
+
+	err := msgpackrpc.CallWithCodec(codec, "ACL.TokenSet", &arg, &out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out.AccessorID == "" {
+		return nil, fmt.Errorf("AccessorID is nil: %v", out)
+	}
+
+	return &out, nil
+}
+
+// retrieveTestToken returns a policy for testing purposes
+func retrieveTestToken(codec rpc.ClientCodec, masterToken string, datacenter string, id string) (*structs.ACLTokenResponse, error) {
+	arg := structs.ACLTokenGetRequest{
+		Datacenter:   datacenter,
+		TokenID:      id,
+		TokenIDType:  structs.ACLTokenAccessor,
+		QueryOptions: structs.QueryOptions{Token: masterToken},
+	}
+
+	var out structs.ACLTokenResponse
+
+	err := msgpackrpc.CallWithCodec(codec, "ACL.TokenRead", &arg, &out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// upsertTestPolicy creates a policy for testing purposes
+func upsertTestPolicy(codec rpc.ClientCodec, masterToken string, datacenter string) (*structs.ACLPolicy, error) {
+	// Make sure test policies can't collide
+	policyUnq, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	arg := structs.ACLPolicySetRequest{
+		Datacenter: datacenter,
+		Policy: structs.ACLPolicy{
+			Name: fmt.Sprintf("test-policy-%s", policyUnq),
+		},
+		WriteRequest: structs.WriteRequest{Token: masterToken},
+	}
+
+	var out structs.ACLPolicy
+
+	err = msgpackrpc.CallWithCodec(codec, "ACL.PolicySet", &arg, &out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out.ID == "" {
+		return nil, fmt.Errorf("ID is nil: %v", out)
+	}
+
+	return &out, nil
+}
+
+// retrieveTestPolicy returns a policy for testing purposes
+func retrieveTestPolicy(codec rpc.ClientCodec, masterToken string, datacenter string, id string) (*structs.ACLPolicyResponse, error) {
+	arg := structs.ACLPolicyGetRequest{
+		Datacenter:   datacenter,
+		PolicyID:     id,
+		QueryOptions: structs.QueryOptions{Token: masterToken},
+	}
+
+	var out structs.ACLPolicyResponse
+
+	err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyRead", &arg, &out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// upsertTestRole creates a role for testing purposes
+func upsertTestRole(codec rpc.ClientCodec, masterToken string, datacenter string) (*structs.ACLRole, error) {
+	// Make sure test roles can't collide
+	roleUnq, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	arg := structs.ACLRoleSetRequest{
+		Datacenter: datacenter,
+		Role: structs.ACLRole{
+			Name: fmt.Sprintf("test-role-%s", roleUnq),
+		},
+		WriteRequest: structs.WriteRequest{Token: masterToken},
+	}
+
+	var out structs.ACLRole
+
+	err = msgpackrpc.CallWithCodec(codec, "ACL.RoleSet", &arg, &out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out.ID == "" {
+		return nil, fmt.Errorf("ID is nil: %v", out)
+	}
+
+	return &out, nil
+}
+
+// retrieveTestRole returns a role for testing purposes
+func retrieveTestRole(codec rpc.ClientCodec, masterToken string, datacenter string, id string) (*structs.ACLRoleResponse, error) {
+	arg := structs.ACLRoleGetRequest{
+		Datacenter:   datacenter,
+		RoleID:       id,
+		QueryOptions: structs.QueryOptions{Token: masterToken},
+	}
+
+	var out structs.ACLRoleResponse
+
+	err := msgpackrpc.CallWithCodec(codec, "ACL.RoleRead", &arg, &out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func upsertTestAuthMethod(codec rpc.ClientCodec, masterToken string, datacenter string, sessionID string) (*structs.ACLAuthMethod, error) {
+	// Make sure test auth methods can't collide
+	methodUnq, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	arg := structs.ACLAuthMethodSetRequest{
+		Datacenter: datacenter,
+		AuthMethod: structs.ACLAuthMethod{
+			Name: "test-method-" + methodUnq,
+			Type: testauth.Type,
+			Config: map[string]interface{}{
+				"SessionID": sessionID,
+				"Fields":    []string{"service", "team"},
+			},
+		},
+		WriteRequest: structs.WriteRequest{Token: masterToken},
+	}
+
+	var out structs.ACLAuthMethod
+
+	err = msgpackrpc.CallWithCodec(codec, "ACL.AuthMethodSet", &arg, &out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// retrieveTestAuthMethod returns an auth method for testing purposes
+func retrieveTestAuthMethod(codec rpc.ClientCodec, masterToken string, datacenter string, name string) (*structs.ACLAuthMethodResponse, error) {
+	arg := structs.ACLAuthMethodGetRequest{
+		Datacenter:     datacenter,
+		AuthMethodName: name,
+		QueryOptions:   structs.QueryOptions{Token: masterToken},
+	}
+
+	var out structs.ACLAuthMethodResponse
+
+	err := msgpackrpc.CallWithCodec(codec, "ACL.AuthMethodRead", &arg, &out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func upsertTestBindingRule(codec rpc.ClientCodec, masterToken string, datacenter string, methodName string, selector string, bindType string, bindName string) (*structs.ACLBindingRule, error) {
+	arg := structs.ACLBindingRuleSetRequest{
+		Datacenter: datacenter,
+		BindingRule: structs.ACLBindingRule{
+			AuthMethod: methodName,
+			Selector:   selector,
+			BindType:   bindType,
+			BindName:   bindName,
+		},
+		WriteRequest: structs.WriteRequest{Token: masterToken},
+	}
+
+	var out structs.ACLBindingRule
+
+	err := msgpackrpc.CallWithCodec(codec, "ACL.BindingRuleSet", &arg, &out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out.ID == "" {
+		return nil, fmt.Errorf("ID is nil: %v", out)
+	}
+
+	return &out, nil
+}
+
+// retrieveTestBindingRule returns a binding rule for testing purposes
+func retrieveTestBindingRule(codec rpc.ClientCodec, masterToken string, datacenter string, id string) (*structs.ACLBindingRuleResponse, error) {
+	arg := structs.ACLBindingRuleGetRequest{
+		Datacenter:    datacenter,
+		BindingRuleID: id,
+		QueryOptions:  structs.QueryOptions{Token: masterToken},
+	}
+
+	var out structs.ACLBindingRuleResponse
+
+	err := msgpackrpc.CallWithCodec(codec, "ACL.BindingRuleRead", &arg, &out)
 
 	if err != nil {
 		return nil, err
@@ -171,6 +171,162 @@ func (s *Server) fetchACLPolicies(lastRemoteIndex uint64) (*structs.ACLPolicyLis
 	return &response, nil
 }
 
+func diffACLRoles(local structs.ACLRoles, remote structs.ACLRoles, lastRemoteIndex uint64) ([]string, []string) {
+	local.Sort()
+	remote.Sort()
+
+	var deletions []string
+	var updates []string
+	var localIdx int
+	var remoteIdx int
+	for localIdx, remoteIdx = 0, 0; localIdx < len(local) && remoteIdx < len(remote); {
+		if local[localIdx].ID == remote[remoteIdx].ID {
+			// role is in both the local and remote state - need to check raft indices and the Hash
+			if remote[remoteIdx].ModifyIndex > lastRemoteIndex && !bytes.Equal(remote[remoteIdx].Hash, local[localIdx].Hash) {
+				updates = append(updates, remote[remoteIdx].ID)
+			}
+			// increment both indices when equal
+			localIdx += 1
+			remoteIdx += 1
+		} else if local[localIdx].ID < remote[remoteIdx].ID {
+			// role no longer in remoted state - needs deleting
+			deletions = append(deletions, local[localIdx].ID)
+
+			// increment just the local index
+			localIdx += 1
+		} else {
+			// local state doesn't have this role - needs updating
+			updates = append(updates, remote[remoteIdx].ID)
+
+			// increment just the remote index
+			remoteIdx += 1
+		}
+	}
+
+	for ; localIdx < len(local); localIdx += 1 {
+		deletions = append(deletions, local[localIdx].ID)
+	}
+
+	for ; remoteIdx < len(remote); remoteIdx += 1 {
+		updates = append(updates, remote[remoteIdx].ID)
+	}
+
+	return deletions, updates
+}
+
+func (s *Server) deleteLocalACLRoles(deletions []string, ctx context.Context) (bool, error) {
+	ticker := time.NewTicker(time.Second / time.Duration(s.config.ACLReplicationApplyLimit))
+	defer ticker.Stop()
+
+	for i := 0; i < len(deletions); i += aclBatchDeleteSize {
+		req := structs.ACLRoleBatchDeleteRequest{}
+
+		if i+aclBatchDeleteSize > len(deletions) {
+			req.RoleIDs = deletions[i:]
+		} else {
+			req.RoleIDs = deletions[i : i+aclBatchDeleteSize]
+		}
+
+		resp, err := s.raftApply(structs.ACLRoleDeleteRequestType, &req)
+		if err != nil {
+			return false, fmt.Errorf("Failed to apply role deletions: %v", err)
+		}
+		if respErr, ok := resp.(error); ok && respErr != nil {
+			return false, fmt.Errorf("Failed to apply role deletions: %v", respErr)
+		}
+
+		if i+aclBatchDeleteSize < len(deletions) {
+			select {
+			case <-ctx.Done():
+				return true, nil
+			case <-ticker.C:
+				// do nothing - ready for the next batch
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func (s *Server) updateLocalACLRoles(roles structs.ACLRoles, ctx context.Context) (bool, error) {
+	ticker := time.NewTicker(time.Second / time.Duration(s.config.ACLReplicationApplyLimit))
+	defer ticker.Stop()
+
+	// outer loop handles submitting a batch
+	for batchStart := 0; batchStart < len(roles); {
+		// inner loop finds the last element to include in this batch.
+		batchSize := 0
+		batchEnd := batchStart
+		for ; batchEnd < len(roles) && batchSize < aclBatchUpsertSize; batchEnd += 1 {
+			batchSize += roles[batchEnd].EstimateSize()
+		}
+
+		req := structs.ACLRoleBatchSetRequest{
+			Roles: roles[batchStart:batchEnd],
+		}
+
+		resp, err := s.raftApply(structs.ACLRoleSetRequestType, &req)
+		if err != nil {
+			return false, fmt.Errorf("Failed to apply role upserts: %v", err)
+		}
+		if respErr, ok := resp.(error); ok && respErr != nil {
+			return false, fmt.Errorf("Failed to apply role upsert: %v", respErr)
+		}
+		s.logger.Printf("[DEBUG] acl: role replication - upserted 1 batch with %d roles of size %d", batchEnd-batchStart, batchSize)
+
+		// roles[batchEnd] wasn't include as the slicing doesn't include the element at the stop index
+		batchStart = batchEnd
+
+		// prevent waiting if we are done
+		if batchEnd < len(roles) {
+			select {
+			case <-ctx.Done():
+				return true, nil
+			case <-ticker.C:
+				// nothing to do - just rate limiting
+			}
+		}
+	}
+	return false, nil
+}
+
+func (s *Server) fetchACLRolesBatch(roleIDs []string) (*structs.ACLRoleBatchResponse, error) {
+	req := structs.ACLRoleBatchGetRequest{
+		Datacenter: s.config.ACLDatacenter,
+		RoleIDs:    roleIDs,
+		QueryOptions: structs.QueryOptions{
+			AllowStale: true,
+			Token:      s.tokens.ReplicationToken(),
+		},
+	}
+
+	var response structs.ACLRoleBatchResponse
+	if err := s.RPC("ACL.RoleBatchRead", &req, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (s *Server) fetchACLRoles(lastRemoteIndex uint64) (*structs.ACLRoleListResponse, error) {
+	defer metrics.MeasureSince([]string{"leader", "replication", "acl", "role", "fetch"}, time.Now())
+
+	req := structs.ACLRoleListRequest{
+		Datacenter: s.config.ACLDatacenter,
+		QueryOptions: structs.QueryOptions{
+			AllowStale:    true,
+			MinQueryIndex: lastRemoteIndex,
+			Token:         s.tokens.ReplicationToken(),
+		},
+	}
+
+	var response structs.ACLRoleListResponse
+	if err := s.RPC("ACL.RoleList", &req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
 type tokenDiffResults struct {
 	LocalDeletes  []string
 	LocalUpserts  []string
@@ -441,6 +597,88 @@ func (s *Server) replicateACLPolicies(lastRemoteIndex uint64, ctx context.Contex
 	return remote.QueryMeta.Index, false, nil
 }
 
+func (s *Server) replicateACLRoles(lastRemoteIndex uint64, ctx context.Context) (uint64, bool, error) {
+	remote, err := s.fetchACLRoles(lastRemoteIndex)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to retrieve remote ACL roles: %v", err)
+	}
+
+	s.logger.Printf("[DEBUG] acl: finished fetching remote roles: %d", len(remote.Roles))
+
+	// Need to check if we should be stopping. This will be common as the fetching process is a blocking
+	// RPC which could have been hanging around for a long time and during that time leadership could
+	// have been lost.
+	select {
+	case <-ctx.Done():
+		return 0, true, nil
+	default:
+		// do nothing
+	}
+
+	// Measure everything after the remote query, which can block for long
+	// periods of time. This metric is a good measure of how expensive the
+	// replication process is.
+	defer metrics.MeasureSince([]string{"leader", "replication", "acl", "role", "apply"}, time.Now())
+
+	_, local, err := s.fsm.State().ACLRoleList(nil, "")
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to retrieve local ACL roles: %v", err)
+	}
+
+	// If the remote index ever goes backwards, it's a good indication that
+	// the remote side was rebuilt and we should do a full sync since we
+	// can't make any assumptions about what's going on.
+	if remote.QueryMeta.Index < lastRemoteIndex {
+		s.logger.Printf("[WARN] consul: ACL role replication remote index moved backwards (%d to %d), forcing a full ACL role sync", lastRemoteIndex, remote.QueryMeta.Index)
+		lastRemoteIndex = 0
+	}
+
+	s.logger.Printf("[DEBUG] acl: role replication - local: %d, remote: %d", len(local), len(remote.Roles))
+	// Calculate the changes required to bring the state into sync and then
+	// apply them.
+	deletions, updates := diffACLRoles(local, remote.Roles, lastRemoteIndex)
+
+	s.logger.Printf("[DEBUG] acl: role replication - deletions: %d, updates: %d", len(deletions), len(updates))
+
+	var roles *structs.ACLRoleBatchResponse
+	if len(updates) > 0 {
+		roles, err = s.fetchACLRolesBatch(updates)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to retrieve ACL role updates: %v", err)
+		}
+		s.logger.Printf("[DEBUG] acl: role replication - downloaded %d roles", len(roles.Roles))
+	}
+
+	if len(deletions) > 0 {
+		s.logger.Printf("[DEBUG] acl: role replication - performing deletions")
+
+		exit, err := s.deleteLocalACLRoles(deletions, ctx)
+		if exit {
+			return 0, true, nil
+		}
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to delete local ACL roles: %v", err)
+		}
+		s.logger.Printf("[DEBUG] acl: role replication - finished deletions")
+	}
+
+	if len(updates) > 0 {
+		s.logger.Printf("[DEBUG] acl: role replication - performing updates")
+		exit, err := s.updateLocalACLRoles(roles.Roles, ctx)
+		if exit {
+			return 0, true, nil
+		}
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to update local ACL roles: %v", err)
+		}
+		s.logger.Printf("[DEBUG] acl: role replication - finished updates")
+	}
+
+	// Return the index we got back from the remote side, since we've synced
+	// up with the remote state as of that index.
+	return remote.QueryMeta.Index, false, nil
+}
+
 func (s *Server) replicateACLTokens(lastRemoteIndex uint64, ctx context.Context) (uint64, bool, error) {
 	remote, err := s.fetchACLTokens(lastRemoteIndex)
 	if err != nil {
@@ -464,7 +702,7 @@ func (s *Server) replicateACLTokens(lastRemoteIndex uint64, ctx context.Context)
 	// replication process is.
 	defer metrics.MeasureSince([]string{"leader", "replication", "acl", "token", "apply"}, time.Now())
 
-	_, local, err := s.fsm.State().ACLTokenList(nil, false, true, "")
+	_, local, err := s.fsm.State().ACLTokenList(nil, false, true, "", "", "")
 	if err != nil {
 		return 0, false, fmt.Errorf("failed to retrieve local ACL tokens: %v", err)
 	}
@@ -554,6 +792,14 @@ func (s *Server) updateACLReplicationStatusIndex(index uint64) {
 	s.aclReplicationStatus.ReplicatedIndex = index
 }
 
+func (s *Server) updateACLReplicationStatusRoleIndex(index uint64) {
+	s.aclReplicationStatusLock.Lock()
+	defer s.aclReplicationStatusLock.Unlock()
+
+	s.aclReplicationStatus.LastSuccess = time.Now().Round(time.Second).UTC()
+	s.aclReplicationStatus.ReplicatedRoleIndex = index
+}
+
 func (s *Server) updateACLReplicationStatusTokenIndex(index uint64) {
 	s.aclReplicationStatusLock.Lock()
 	defer s.aclReplicationStatusLock.Unlock()
@@ -126,6 +126,85 @@ func TestACLReplication_diffACLPolicies(t *testing.T) {
 		"c6e8fffd-cbd9-4ecd-99fe-ab2f200c7926"})
 }
 
+func TestACLReplication_diffACLRoles(t *testing.T) {
+	local := structs.ACLRoles{
+		&structs.ACLRole{
+			ID:          "44ef9aec-7654-4401-901b-4d4a8b3c80fc",
+			Name:        "role1",
+			Description: "role1 - already in sync",
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		},
+		&structs.ACLRole{
+			ID:          "8ea41efb-8519-4091-bc91-c42da0cda9ae",
+			Name:        "role2",
+			Description: "role2 - updated but not changed",
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 25},
+		},
+		&structs.ACLRole{
+			ID:          "539f1cb6-40aa-464f-ae66-a900d26bc1b2",
+			Name:        "role3",
+			Description: "role3 - updated and changed",
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 25},
+		},
+		&structs.ACLRole{
+			ID:          "e9d33298-6490-4466-99cb-ba93af64fa76",
+			Name:        "role4",
+			Description: "role4 - needs deleting",
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 25},
+		},
+	}
+
+	remote := structs.ACLRoles{
+		&structs.ACLRole{
+			ID:          "44ef9aec-7654-4401-901b-4d4a8b3c80fc",
+			Name:        "role1",
+			Description: "role1 - already in sync",
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		},
+		&structs.ACLRole{
+			ID:          "8ea41efb-8519-4091-bc91-c42da0cda9ae",
+			Name:        "role2",
+			Description: "role2 - updated but not changed",
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 50},
+		},
+		&structs.ACLRole{
+			ID:          "539f1cb6-40aa-464f-ae66-a900d26bc1b2",
+			Name:        "role3",
+			Description: "role3 - updated and changed",
+			Hash:        []byte{5, 6, 7, 8},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 50},
+		},
+		&structs.ACLRole{
+			ID:          "c6e8fffd-cbd9-4ecd-99fe-ab2f200c7926",
+			Name:        "role5",
+			Description: "role5 - needs adding",
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 50},
+		},
+	}
+
+	// Do the full diff. This full exercises the main body of the loop
+	deletions, updates := diffACLRoles(local, remote, 28)
+	require.ElementsMatch(t, updates, []string{
+		"c6e8fffd-cbd9-4ecd-99fe-ab2f200c7926",
+		"539f1cb6-40aa-464f-ae66-a900d26bc1b2"})
+	require.Equal(t, []string{"e9d33298-6490-4466-99cb-ba93af64fa76"}, deletions)
+
+	deletions, updates = diffACLRoles(local, nil, 28)
+	require.Len(t, updates, 0)
+	require.Len(t, deletions, 4)
+
+	deletions, updates = diffACLRoles(nil, remote, 28)
+	require.Len(t, deletions, 0)
+	require.Len(t, updates, 4)
+}
+
 func TestACLReplication_diffACLTokens(t *testing.T) {
 	local := structs.ACLTokens{
 		// When a just-upgraded (1.3->1.4+) secondary DC is replicating from an
@@ -330,9 +409,9 @@ func TestACLReplication_Tokens(t *testing.T) {
 
 	checkSame := func(t *retry.R) error {
 		// only account for global tokens - local tokens shouldn't be replicated
-		index, remote, err := s1.fsm.State().ACLTokenList(nil, false, true, "")
+		index, remote, err := s1.fsm.State().ACLTokenList(nil, false, true, "", "", "")
 		require.NoError(t, err)
-		_, local, err := s2.fsm.State().ACLTokenList(nil, false, true, "")
+		_, local, err := s2.fsm.State().ACLTokenList(nil, false, true, "", "", "")
 		require.NoError(t, err)
 
 		require.Len(t, local, len(remote))
@@ -426,7 +505,7 @@ func TestACLReplication_Tokens(t *testing.T) {
 	})
 
 	// verify dc2 local tokens didn't get blown away
-	_, local, err := s2.fsm.State().ACLTokenList(nil, true, false, "")
+	_, local, err := s2.fsm.State().ACLTokenList(nil, true, false, "", "", "")
 	require.NoError(t, err)
 	require.Len(t, local, 50)
 
@@ -513,7 +592,7 @@ func TestACLReplication_Policies(t *testing.T) {
 		status = s2.aclReplicationStatus
 		s2.aclReplicationStatusLock.RUnlock()
 		if !status.Enabled || !status.Running ||
-			status.ReplicationType != structs.ACLReplicatePolicies ||
+			status.ReplicationType != structs.ACLReplicateRoles ||
 			status.ReplicatedIndex != index ||
 			status.SourceDatacenter != "dc1" {
 			return fmt.Errorf("ACL replication status differs")
@@ -565,6 +644,127 @@ func TestACLReplication_Policies(t *testing.T) {
 	})
 }
 
+func TestACLReplication_Roles(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	client := rpcClient(t, s1)
+	defer client.Close()
+
+	dir2, s2 := testServerWithConfig(t, func(c *Config) {
+		c.Datacenter = "dc2"
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLTokenReplication = false
+		c.ACLReplicationRate = 100
+		c.ACLReplicationBurst = 100
+		c.ACLReplicationApplyLimit = 1000000
+	})
+	s2.tokens.UpdateReplicationToken("root", tokenStore.TokenSourceConfig)
+	testrpc.WaitForLeader(t, s2.RPC, "dc2")
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+
+	// Try to join.
+	joinWAN(t, s2, s1)
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	testrpc.WaitForLeader(t, s1.RPC, "dc2")
+
+	// Create a bunch of new roles
+	var roles structs.ACLRoles
+	for i := 0; i < 50; i++ {
+		arg := structs.ACLRoleSetRequest{
+			Datacenter: "dc1",
+			Role: structs.ACLRole{
+				Name:        fmt.Sprintf("role-%d", i),
+				Description: fmt.Sprintf("role-%d", i),
+				Policies: []structs.ACLRolePolicyLink{
+					structs.ACLRolePolicyLink{
+						ID: structs.ACLPolicyGlobalManagementID,
+					},
+				},
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var role structs.ACLRole
+		require.NoError(t, s1.RPC("ACL.RoleSet", &arg, &role))
+		roles = append(roles, &role)
+	}
+
+	checkSame := func(t *retry.R) error {
+		index, remote, err := s1.fsm.State().ACLRoleList(nil, "")
+		require.NoError(t, err)
+		_, local, err := s2.fsm.State().ACLRoleList(nil, "")
+		require.NoError(t, err)
+
+		require.Len(t, local, len(remote))
+		remote.Sort()
+		local.Sort()
+		for i, role := range remote {
+			require.Equal(t, role.Hash, local[i].Hash)
+		}
+
+		var status structs.ACLReplicationStatus
+		s2.aclReplicationStatusLock.RLock()
+		status = s2.aclReplicationStatus
+		s2.aclReplicationStatusLock.RUnlock()
+		if !status.Enabled || !status.Running ||
+			status.ReplicationType != structs.ACLReplicateRoles ||
+			status.ReplicatedRoleIndex != index ||
+			status.SourceDatacenter != "dc1" {
+			return fmt.Errorf("ACL replication status differs")
+		}
+
+		return nil
+	}
+	// Wait for the replica to converge.
+	retry.Run(t, func(r *retry.R) {
+		checkSame(r)
+	})
+
+	// Update those roles
+	for i := 0; i < 50; i++ {
+		arg := structs.ACLRoleSetRequest{
+			Datacenter: "dc1",
+			Role: structs.ACLRole{
+				ID:          roles[i].ID,
+				Name:        fmt.Sprintf("role-%d-modified", i),
+				Description: fmt.Sprintf("role-%d-modified", i),
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var role structs.ACLRole
+		require.NoError(t, s1.RPC("ACL.RoleSet", &arg, &role))
+	}
+
+	// Wait for the replica to converge.
+	retry.Run(t, func(r *retry.R) {
+		checkSame(r)
+	})
+
+	for _, role := range roles {
+		arg := structs.ACLRoleDeleteRequest{
+			Datacenter:   "dc1",
+			RoleID:       role.ID,
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+
+		var dontCare string
+		require.NoError(t, s1.RPC("ACL.RoleDelete", &arg, &dontCare))
+	}
+
+	// Wait for the replica to converge.
+	retry.Run(t, func(r *retry.R) {
+		checkSame(r)
+	})
+}
+
 func TestACLReplication_TokensRedacted(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServerWithConfig(t, func(c *Config) {
@@ -2,6 +2,7 @@ package consul
 
 import (
 	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
@@ -11,8 +12,8 @@ import (
 var serverACLCacheConfig *structs.ACLCachesConfig = &structs.ACLCachesConfig{
 	// The server's ACL caching has a few underlying assumptions:
 	//
-	// 1 - All policies can be resolved locally. Hence we do not cache any
-	//     unparsed policies as we have memdb for that.
+	// 1 - All policies and roles can be resolved locally. Hence we do not
+	//     cache any unparsed policies or roles as we have memdb for that.
 	// 2 - While there could be many identities being used within a DC the
 	//     number of distinct policies and combined multi-policy authorizers
 	//     will be much less.
@@ -25,6 +26,7 @@ var serverACLCacheConfig *structs.ACLCachesConfig = &structs.ACLCachesConfig{
 	Policies:       0,
 	ParsedPolicies: 512,
 	Authorizers:    1024,
+	Roles:          0,
 }
 
 func (s *Server) checkTokenUUID(id string) (bool, error) {
@@ -55,6 +57,28 @@ func (s *Server) checkPolicyUUID(id string) (bool, error) {
 	return !structs.ACLIDReserved(id), nil
 }
 
+func (s *Server) checkRoleUUID(id string) (bool, error) {
+	state := s.fsm.State()
+	if _, role, err := state.ACLRoleGetByID(nil, id); err != nil {
+		return false, err
+	} else if role != nil {
+		return false, nil
+	}
+
+	return !structs.ACLIDReserved(id), nil
+}
+
+func (s *Server) checkBindingRuleUUID(id string) (bool, error) {
+	state := s.fsm.State()
+	if _, rule, err := state.ACLBindingRuleGetByID(nil, id); err != nil {
+		return false, err
+	} else if rule != nil {
+		return false, nil
+	}
+
+	return !structs.ACLIDReserved(id), nil
+}
+
 func (s *Server) updateACLAdvertisement() {
 	// One thing to note is that once in new ACL mode the server will
 	// never transition to legacy ACL mode. This is not currently a
@@ -145,7 +169,7 @@ func (s *Server) ResolveIdentityFromToken(token string) (bool, structs.ACLIdenti
 	index, aclToken, err := s.fsm.State().ACLTokenGetBySecret(nil, token)
 	if err != nil {
 		return true, nil, err
-	} else if aclToken != nil {
+	} else if aclToken != nil && !aclToken.IsExpired(time.Now()) {
 		return true, aclToken, nil
 	}
 
@@ -166,6 +190,19 @@ func (s *Server) ResolvePolicyFromID(policyID string) (bool, *structs.ACLPolicy,
 	return s.InACLDatacenter() || index > 0, policy, acl.ErrNotFound
 }
 
+func (s *Server) ResolveRoleFromID(roleID string) (bool, *structs.ACLRole, error) {
+	index, role, err := s.fsm.State().ACLRoleGetByID(nil, roleID)
+	if err != nil {
+		return true, nil, err
+	} else if role != nil {
+		return true, role, nil
+	}
+
+	// Not finding the role is only an authoritative answer when we have
+	// replicated roles, otherwise fall back to remote resolution.
+	return s.InACLDatacenter() || index > 0, role, acl.ErrNotFound
+}
+
 func (s *Server) ResolveToken(token string) (acl.Authorizer, error) {
 	return s.acls.ResolveToken(token)
 }
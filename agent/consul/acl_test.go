@@ -142,6 +142,76 @@ func testIdentityForToken(token string) (bool, structs.ACLIdentity, error) {
 				},
 			},
 		}, nil
+	case "found-role":
+		return true, &structs.ACLToken{
+			AccessorID: "5f57c1f6-6a89-4186-9445-531b316e01df",
+			SecretID:   "a1a54629-5050-4d17-8a4e-560d2423f835",
+			Roles: []structs.ACLTokenRoleLink{
+				structs.ACLTokenRoleLink{
+					ID: "found",
+				},
+			},
+		}, nil
+	case "found-policy-and-role":
+		return true, &structs.ACLToken{
+			AccessorID: "5f57c1f6-6a89-4186-9445-531b316e01df",
+			SecretID:   "a1a54629-5050-4d17-8a4e-560d2423f835",
+			Policies: []structs.ACLTokenPolicyLink{
+				structs.ACLTokenPolicyLink{
+					ID: "node-wr",
+				},
+				structs.ACLTokenPolicyLink{
+					ID: "dc2-key-wr",
+				},
+			},
+			Roles: []structs.ACLTokenRoleLink{
+				structs.ACLTokenRoleLink{
+					ID: "service-ro",
+				},
+			},
+		}, nil
+	case "found-service-identity":
+		return true, &structs.ACLToken{
+			AccessorID: "b2bdbc3a-9a81-4c43-bb5b-2bf2a87e64c5",
+			SecretID:   "found-service-identity",
+			ServiceIdentities: []*structs.ACLServiceIdentity{
+				&structs.ACLServiceIdentity{
+					ServiceName: "web",
+				},
+				&structs.ACLServiceIdentity{
+					ServiceName: "db",
+					Datacenters: []string{"dc2"},
+				},
+			},
+		}, nil
+	case "found-policy-and-service-identity":
+		return true, &structs.ACLToken{
+			AccessorID: "9e1ec8ce-9a05-4d2b-a94b-31e2b2bbd4c6",
+			SecretID:   "found-policy-and-service-identity",
+			Policies: []structs.ACLTokenPolicyLink{
+				structs.ACLTokenPolicyLink{
+					ID: "acl-wr",
+				},
+			},
+			ServiceIdentities: []*structs.ACLServiceIdentity{
+				&structs.ACLServiceIdentity{
+					ServiceName: "web",
+				},
+			},
+		}, nil
+	case "missing-role":
+		return true, &structs.ACLToken{
+			AccessorID: "435a75af-1763-4980-89f4-f0951dda53b4",
+			SecretID:   "b1b6be70-ed2e-4c80-8495-bdb3db110b1e",
+			Roles: []structs.ACLTokenRoleLink{
+				structs.ACLTokenRoleLink{
+					ID: "not-found",
+				},
+				structs.ACLTokenRoleLink{
+					ID: "acl-ro",
+				},
+			},
+		}, nil
 	case anonymousToken:
 		return true, &structs.ACLToken{
 			AccessorID: "00000000-0000-0000-0000-000000000002",
@@ -197,6 +267,65 @@ func testPolicyForID(policyID string) (bool, *structs.ACLPolicy, error) {
 			Datacenters: []string{"dc2"},
 			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
 		}, nil
+	case "service-ro":
+		return true, &structs.ACLPolicy{
+			ID:          "service-ro",
+			Name:        "service-ro",
+			Description: "service-ro",
+			Rules:       `service_prefix "" { policy = "read" }`,
+			Syntax:      acl.SyntaxCurrent,
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		}, nil
+	default:
+		return true, nil, acl.ErrNotFound
+	}
+}
+
+func testRoleForID(roleID string) (bool, *structs.ACLRole, error) {
+	switch roleID {
+	case "found":
+		return true, &structs.ACLRole{
+			ID:          "found",
+			Name:        "found",
+			Description: "found",
+			Policies: []structs.ACLRolePolicyLink{
+				structs.ACLRolePolicyLink{
+					ID: "node-wr",
+				},
+				structs.ACLRolePolicyLink{
+					ID: "dc2-key-wr",
+				},
+			},
+			RaftIndex: structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		}, nil
+	case "acl-ro":
+		return true, &structs.ACLRole{
+			ID:          "acl-ro",
+			Name:        "acl-ro",
+			Description: "acl-ro",
+			Policies: []structs.ACLRolePolicyLink{
+				structs.ACLRolePolicyLink{
+					ID: "acl-ro",
+				},
+			},
+			RaftIndex: structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		}, nil
+	case "service-ro":
+		return true, &structs.ACLRole{
+			ID:          "service-ro",
+			Name:        "service-ro",
+			Description: "service-ro",
+			Policies: []structs.ACLRolePolicyLink{
+				structs.ACLRolePolicyLink{
+					ID: "service-ro",
+				},
+				// duplicates a policy linked directly to the token
+				structs.ACLRolePolicyLink{
+					ID: "node-wr",
+				},
+			},
+			RaftIndex: structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		}, nil
 	default:
 		return true, nil, acl.ErrNotFound
 	}
@@ -210,9 +339,11 @@ type ACLResolverTestDelegate struct {
 	legacy          bool
 	localTokens     bool
 	localPolicies   bool
+	localRoles      bool
 	getPolicyFn     func(*structs.ACLPolicyResolveLegacyRequest, *structs.ACLPolicyResolveLegacyResponse) error
 	tokenReadFn     func(*structs.ACLTokenGetRequest, *structs.ACLTokenResponse) error
 	policyResolveFn func(*structs.ACLPolicyBatchGetRequest, *structs.ACLPolicyBatchResponse) error
+	roleResolveFn   func(*structs.ACLRoleBatchGetRequest, *structs.ACLRoleBatchResponse) error
 }
 
 func (d *ACLResolverTestDelegate) ACLsEnabled() bool {
@@ -243,6 +374,14 @@ func (d *ACLResolverTestDelegate) ResolvePolicyFromID(policyID string) (bool, *s
 	return testPolicyForID(policyID)
 }
 
+func (d *ACLResolverTestDelegate) ResolveRoleFromID(roleID string) (bool, *structs.ACLRole, error) {
+	if !d.localRoles {
+		return false, nil, nil
+	}
+
+	return testRoleForID(roleID)
+}
+
 func (d *ACLResolverTestDelegate) RPC(method string, args interface{}, reply interface{}) error {
 	switch method {
 	case "ACL.GetPolicy":
@@ -260,6 +399,11 @@ func (d *ACLResolverTestDelegate) RPC(method string, args interface{}, reply int
 			return d.policyResolveFn(args.(*structs.ACLPolicyBatchGetRequest), reply.(*structs.ACLPolicyBatchResponse))
 		}
 		panic("Bad Test Implmentation: should provide a policyResolveFn to the ACLResolverTestDelegate")
+	case "ACL.RoleResolve":
+		if d.roleResolveFn != nil {
+			return d.roleResolveFn(args.(*structs.ACLRoleBatchGetRequest), reply.(*structs.ACLRoleBatchResponse))
+		}
+		panic("Bad Test Implmentation: should provide a roleResolveFn to the ACLResolverTestDelegate")
 	}
 	panic("Bad Test Implementation: Was the ACLResolver updated to use new RPC methods")
 }
@@ -276,6 +420,7 @@ func newTestACLResolver(t *testing.T, delegate ACLResolverDelegate, cb func(*ACL
 			Policies:       4,
 			ParsedPolicies: 4,
 			Authorizers:    4,
+			Roles:          4,
 		},
 		AutoDisable: true,
 		Delegate:    delegate,
@@ -1066,6 +1211,7 @@ func TestACLResolver_LocalTokensAndPolicies(t *testing.T) {
 		legacy:        false,
 		localTokens:   true,
 		localPolicies: true,
+		localRoles:    true,
 		// No need to provide any of the RPC callbacks
 	}
 	r := newTestACLResolver(t, delegate, nil)
@@ -1093,6 +1239,54 @@ func TestACLResolver_LocalTokensAndPolicies(t *testing.T) {
 		require.True(t, authz.NodeWrite("foo", nil))
 	})
 
+	t.Run("Missing Role", func(t *testing.T) {
+		authz, err := r.ResolveToken("missing-role")
+		require.NoError(t, err)
+		require.NotNil(t, authz)
+		require.True(t, authz.ACLRead())
+		require.False(t, authz.NodeWrite("foo", nil))
+	})
+
+	t.Run("Role", func(t *testing.T) {
+		authz, err := r.ResolveToken("found-role")
+		require.NotNil(t, authz)
+		require.NoError(t, err)
+		require.False(t, authz.ACLRead())
+		require.True(t, authz.NodeWrite("foo", nil))
+	})
+
+	t.Run("Policies and Roles", func(t *testing.T) {
+		authz, err := r.ResolveToken("found-policy-and-role")
+		require.NotNil(t, authz)
+		require.NoError(t, err)
+		require.False(t, authz.ACLRead())
+		require.True(t, authz.NodeWrite("foo", nil))
+		require.True(t, authz.ServiceRead("bar"))
+	})
+
+	t.Run("Service Identity", func(t *testing.T) {
+		authz, err := r.ResolveToken("found-service-identity")
+		require.NotNil(t, authz)
+		require.NoError(t, err)
+		require.False(t, authz.ACLRead())
+		require.True(t, authz.ServiceWrite("web", nil))
+		require.True(t, authz.ServiceWrite("web-sidecar-proxy", nil))
+		require.True(t, authz.ServiceRead("anything"))
+		require.True(t, authz.NodeRead("foo"))
+		require.False(t, authz.NodeWrite("foo", nil))
+		// the db service identity is only valid in dc2
+		require.False(t, authz.ServiceWrite("db", nil))
+	})
+
+	t.Run("Policies and Service Identities", func(t *testing.T) {
+		authz, err := r.ResolveToken("found-policy-and-service-identity")
+		require.NotNil(t, authz)
+		require.NoError(t, err)
+		require.True(t, authz.ACLWrite())
+		require.True(t, authz.ServiceWrite("web", nil))
+		require.False(t, authz.ServiceWrite("db", nil))
+	})
+
 	t.Run("Anonymous", func(t *testing.T) {
 		authz, err := r.ResolveToken("")
 		require.NotNil(t, authz)
@@ -1126,6 +1320,7 @@ func TestACLResolver_LocalPolicies(t *testing.T) {
 		legacy:        false,
 		localTokens:   false,
 		localPolicies: true,
+		localRoles:    true,
 		tokenReadFn: func(args *structs.ACLTokenGetRequest, reply *structs.ACLTokenResponse) error {
 			_, token, err := testIdentityForToken(args.TokenID)
 
@@ -1160,6 +1355,31 @@ func TestACLResolver_LocalPolicies(t *testing.T) {
 		require.True(t, authz.NodeWrite("foo", nil))
 	})
 
+	t.Run("Missing Role", func(t *testing.T) {
+		authz, err := r.ResolveToken("missing-role")
+		require.NoError(t, err)
+		require.NotNil(t, authz)
+		require.True(t, authz.ACLRead())
+		require.False(t, authz.NodeWrite("foo", nil))
+	})
+
+	t.Run("Role", func(t *testing.T) {
+		authz, err := r.ResolveToken("found-role")
+		require.NotNil(t, authz)
+		require.NoError(t, err)
+		require.False(t, authz.ACLRead())
+		require.True(t, authz.NodeWrite("foo", nil))
+	})
+
+	t.Run("Policies and Roles", func(t *testing.T) {
+		authz, err := r.ResolveToken("found-policy-and-role")
+		require.NotNil(t, authz)
+		require.NoError(t, err)
+		require.False(t, authz.ACLRead())
+		require.True(t, authz.NodeWrite("foo", nil))
+		require.True(t, authz.ServiceRead("bar"))
+	})
+
 	t.Run("Anonymous", func(t *testing.T) {
 		authz, err := r.ResolveToken("")
 		require.NotNil(t, authz)
@@ -1185,6 +1405,52 @@ func TestACLResolver_LocalPolicies(t *testing.T) {
 	})
 }
 
+func TestACLResolver_RemoteRoles(t *testing.T) {
+	t.Parallel()
+	var resolved []string
+	delegate := &ACLResolverTestDelegate{
+		enabled:       true,
+		datacenter:    "dc1",
+		legacy:        false,
+		localTokens:   true,
+		localPolicies: true,
+		localRoles:    false,
+		roleResolveFn: func(args *structs.ACLRoleBatchGetRequest, reply *structs.ACLRoleBatchResponse) error {
+			for _, roleID := range args.RoleIDs {
+				resolved = append(resolved, roleID)
+				_, role, _ := testRoleForID(roleID)
+				if role != nil {
+					reply.Roles = append(reply.Roles, role)
+				}
+			}
+			return nil
+		},
+	}
+	r := newTestACLResolver(t, delegate, nil)
+
+	authz, err := r.ResolveToken("missing-role")
+	require.NoError(t, err)
+	require.NotNil(t, authz)
+	require.True(t, authz.ACLRead())
+	require.ElementsMatch(t, []string{"not-found", "acl-ro"}, resolved)
+
+	// both the found and missing role are cached now
+	entry := r.cache.GetRole("acl-ro")
+	require.NotNil(t, entry)
+	require.NotNil(t, entry.Role)
+	entry = r.cache.GetRole("not-found")
+	require.NotNil(t, entry)
+	require.Nil(t, entry.Role)
+
+	// the cached roles are used without another RPC
+	resolved = nil
+	authz, err = r.ResolveToken("missing-role")
+	require.NoError(t, err)
+	require.NotNil(t, authz)
+	require.True(t, authz.ACLRead())
+	require.Empty(t, resolved)
+}
+
 func TestACLResolver_Legacy(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,219 @@
+// Package jwtauth implements an auth method that validates JSON Web Tokens
+// signed by a static set of RSA or ECDSA public keys.
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/hashicorp/consul/agent/consul/authmethod"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Type is the auth method type name for the JWT auth method.
+const Type = "jwt"
+
+func init() {
+	authmethod.Register(Type, func(method *structs.ACLAuthMethod) (authmethod.Validator, error) {
+		return NewValidator(method)
+	})
+}
+
+// Config is the collection of all settings that pertain to doing a JWT
+// auth method login against a static set of public keys.
+type Config struct {
+	// JWTValidationPubKeys is a list of PEM-encoded RSA or ECDSA public keys
+	// used to verify the signature of the presented JWT. At least one is
+	// required.
+	JWTValidationPubKeys []string
+
+	// BoundIssuer if set requires the "iss" claim of the JWT to match.
+	BoundIssuer string
+
+	// BoundAudiences if set requires the "aud" claim of the JWT to contain
+	// at least one of the listed audiences.
+	BoundAudiences []string
+
+	// ClaimMappings maps top level string claims of the JWT to the field
+	// names exposed to binding rule selectors and bind names.
+	ClaimMappings map[string]string
+}
+
+// Validator is the wrapper around the relevant portions of the JWT auth
+// method configuration that is used to verify bearer tokens.
+type Validator struct {
+	name   string
+	config *Config
+	keys   []crypto.PublicKey
+}
+
+// NewValidator creates a Validator for the given JWT auth method. The
+// public keys are parsed up front so a broken config is rejected when the
+// auth method is written.
+func NewValidator(method *structs.ACLAuthMethod) (*Validator, error) {
+	if method.Type != Type {
+		return nil, fmt.Errorf("%q is not a JWT auth method", method.Name)
+	}
+
+	var config Config
+	if err := authmethod.ParseConfig(method.Config, &config); err != nil {
+		return nil, err
+	}
+
+	if len(config.JWTValidationPubKeys) == 0 {
+		return nil, fmt.Errorf("JWTValidationPubKeys must be set")
+	}
+
+	keys := make([]crypto.PublicKey, 0, len(config.JWTValidationPubKeys))
+	for i, raw := range config.JWTValidationPubKeys {
+		key, err := parsePublicKeyPEM([]byte(raw))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing JWTValidationPubKeys[%d]: %v", i, err)
+		}
+		keys = append(keys, key)
+	}
+
+	for claim, field := range config.ClaimMappings {
+		if claim == "" || field == "" {
+			return nil, fmt.Errorf("ClaimMappings cannot contain empty claims or fields")
+		}
+	}
+
+	return &Validator{
+		name:   method.Name,
+		config: &config,
+		keys:   keys,
+	}, nil
+}
+
+func (v *Validator) Name() string { return v.name }
+
+// ValidateLogin verifies the signature, expiry, issuer and audience of the
+// JWT and returns the mapped claims.
+func (v *Validator) ValidateLogin(loginToken string) (map[string]string, error) {
+	parser := &jwt.Parser{ValidMethods: validSigningMethods}
+
+	// The token has to be signed by one of the configured keys. A bad
+	// signature moves on to the next key while any other failure, such as
+	// an expired token, fails the login outright.
+	var claims jwt.MapClaims
+	for _, key := range v.keys {
+		claims = jwt.MapClaims{}
+		_, err := parser.ParseWithClaims(loginToken, claims, func(*jwt.Token) (interface{}, error) {
+			return key, nil
+		})
+		if err == nil {
+			break
+		}
+
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors == jwt.ValidationErrorSignatureInvalid {
+			claims = nil
+			continue
+		}
+		return nil, fmt.Errorf("invalid JWT: %v", err)
+	}
+	if claims == nil {
+		return nil, errors.New("no known key successfully validated the token signature")
+	}
+
+	if v.config.BoundIssuer != "" && !claims.VerifyIssuer(v.config.BoundIssuer, true) {
+		return nil, errors.New("invalid JWT: issuer does not match BoundIssuer")
+	}
+
+	if len(v.config.BoundAudiences) > 0 && !verifyAudience(claims, v.config.BoundAudiences) {
+		return nil, errors.New("invalid JWT: audience does not match any of BoundAudiences")
+	}
+
+	fields := make(map[string]string)
+	for claim, field := range v.config.ClaimMappings {
+		raw, ok := claims[claim]
+		if !ok {
+			continue
+		}
+		value, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("claim %q is not a string", claim)
+		}
+		fields[field] = value
+	}
+
+	return fields, nil
+}
+
+func (v *Validator) AvailableFields() []string {
+	fields := make([]string, 0, len(v.config.ClaimMappings))
+	for _, field := range v.config.ClaimMappings {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// validSigningMethods excludes "none" and the HMAC algorithms. Verifying an
+// HMAC with a public key as the secret would let anyone forge tokens.
+var validSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+}
+
+// verifyAudience checks that the "aud" claim, which may be either a single
+// string or a list of them, contains at least one of the bound audiences.
+func verifyAudience(claims jwt.MapClaims, bound []string) bool {
+	var audiences []string
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = []string{aud}
+	case []interface{}:
+		for _, raw := range aud {
+			if s, ok := raw.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+
+	for _, aud := range audiences {
+		for _, b := range bound {
+			if aud == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("data does not contain any valid RSA or ECDSA public keys")
+	}
+
+	if pub, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		switch pub.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			return pub, nil
+		default:
+			return nil, errors.New("data does not contain any valid RSA or ECDSA public keys")
+		}
+	}
+
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		switch cert.PublicKey.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			return cert.PublicKey, nil
+		}
+	}
+
+	if pub, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return pub, nil
+	}
+
+	return nil, errors.New("data does not contain any valid RSA or ECDSA public keys")
+}
@@ -0,0 +1,192 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func testKeyPair(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return key, string(pub)
+}
+
+func testSign(t *testing.T, key interface{}, method jwt.SigningMethod, claims jwt.MapClaims) string {
+	t.Helper()
+
+	raw, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return raw
+}
+
+func testMethod(pubKeys ...string) *structs.ACLAuthMethod {
+	return &structs.ACLAuthMethod{
+		Name: "test-jwt",
+		Type: Type,
+		Config: map[string]interface{}{
+			"JWTValidationPubKeys": pubKeys,
+			"BoundIssuer":          "consul-test",
+			"BoundAudiences":       []string{"consul"},
+			"ClaimMappings": map[string]interface{}{
+				"sub":     "subject",
+				"service": "service",
+			},
+		},
+	}
+}
+
+func TestNewValidator(t *testing.T) {
+	t.Parallel()
+
+	_, pub := testKeyPair(t)
+
+	t.Run("valid", func(t *testing.T) {
+		v, err := NewValidator(testMethod(pub))
+		require.NoError(t, err)
+		require.Equal(t, "test-jwt", v.Name())
+		require.Equal(t, []string{"service", "subject"}, v.AvailableFields())
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		method := testMethod(pub)
+		method.Type = "testing"
+		_, err := NewValidator(method)
+		require.Error(t, err)
+	})
+
+	t.Run("no keys", func(t *testing.T) {
+		_, err := NewValidator(testMethod())
+		require.Error(t, err)
+	})
+
+	t.Run("bad key", func(t *testing.T) {
+		_, err := NewValidator(testMethod("not a key"))
+		require.Error(t, err)
+	})
+
+	t.Run("unknown config", func(t *testing.T) {
+		method := testMethod(pub)
+		method.Config["Bogus"] = "value"
+		_, err := NewValidator(method)
+		require.Error(t, err)
+	})
+}
+
+func TestValidator_ValidateLogin(t *testing.T) {
+	t.Parallel()
+
+	key, pub := testKeyPair(t)
+	otherKey, _ := testKeyPair(t)
+
+	v, err := NewValidator(testMethod(pub))
+	require.NoError(t, err)
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":     "consul-test",
+			"aud":     []string{"consul", "other"},
+			"sub":     "system:serviceaccount:default:web",
+			"exp":     time.Now().Add(time.Hour).Unix(),
+			"service": "web",
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		token := testSign(t, key, jwt.SigningMethodES256, validClaims())
+		fields, err := v.ValidateLogin(token)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"subject": "system:serviceaccount:default:web",
+			"service": "web",
+		}, fields)
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		_, err := v.ValidateLogin("not-a-jwt")
+		require.Error(t, err)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		token := testSign(t, otherKey, jwt.SigningMethodES256, validClaims())
+		_, err := v.ValidateLogin(token)
+		require.Error(t, err)
+	})
+
+	t.Run("hmac rejected", func(t *testing.T) {
+		token := testSign(t, []byte(pub), jwt.SigningMethodHS256, validClaims())
+		_, err := v.ValidateLogin(token)
+		require.Error(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		claims := validClaims()
+		claims["exp"] = time.Now().Add(-time.Hour).Unix()
+		token := testSign(t, key, jwt.SigningMethodES256, claims)
+		_, err := v.ValidateLogin(token)
+		require.Error(t, err)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		claims := validClaims()
+		claims["iss"] = "someone-else"
+		token := testSign(t, key, jwt.SigningMethodES256, claims)
+		_, err := v.ValidateLogin(token)
+		require.Error(t, err)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		claims := validClaims()
+		claims["aud"] = "vault"
+		token := testSign(t, key, jwt.SigningMethodES256, claims)
+		_, err := v.ValidateLogin(token)
+		require.Error(t, err)
+	})
+
+	t.Run("non-string claim", func(t *testing.T) {
+		claims := validClaims()
+		claims["service"] = 42
+		token := testSign(t, key, jwt.SigningMethodES256, claims)
+		_, err := v.ValidateLogin(token)
+		require.Error(t, err)
+	})
+}
+
+func TestValidator_ValidateLogin_RSA(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	pub := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	v, err := NewValidator(testMethod(pub))
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{
+		"iss":     "consul-test",
+		"aud":     "consul",
+		"service": "db",
+	}
+
+	fields, err := v.ValidateLogin(testSign(t, key, jwt.SigningMethodRS256, claims))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"service": "db"}, fields)
+}
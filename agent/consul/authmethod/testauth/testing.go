@@ -0,0 +1,144 @@
+// Package testauth implements a fake auth method for use in tests. Bearer
+// tokens and the fields they resolve to are installed ahead of time into a
+// session which the auth method's config refers to.
+package testauth
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/consul/agent/consul/authmethod"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-uuid"
+)
+
+// Type is the auth method type name for the fake auth method.
+const Type = "testing"
+
+func init() {
+	authmethod.Register(Type, func(method *structs.ACLAuthMethod) (authmethod.Validator, error) {
+		return NewValidator(method)
+	})
+}
+
+var (
+	tokenDatabaseMu sync.Mutex
+	tokenDatabase   map[string]map[string]map[string]string // session => token => fieldmap
+)
+
+// StartSession creates a new, empty token session and returns its ID.
+func StartSession() string {
+	sessionID, err := uuid.GenerateUUID()
+	if err != nil {
+		panic(err)
+	}
+	ResetSession(sessionID)
+	return sessionID
+}
+
+// ResetSession removes all of the tokens installed into the session.
+func ResetSession(sessionID string) {
+	tokenDatabaseMu.Lock()
+	defer tokenDatabaseMu.Unlock()
+	if tokenDatabase == nil {
+		tokenDatabase = make(map[string]map[string]map[string]string)
+	}
+	tokenDatabase[sessionID] = make(map[string]map[string]string)
+}
+
+// InstallSessionToken makes the given bearer token valid for logins against
+// auth methods using the session, resolving to the given fields.
+func InstallSessionToken(sessionID string, token string, fields map[string]string) {
+	tokenDatabaseMu.Lock()
+	defer tokenDatabaseMu.Unlock()
+	if tokenDatabase == nil {
+		tokenDatabase = make(map[string]map[string]map[string]string)
+	}
+	tokens, ok := tokenDatabase[sessionID]
+	if !ok {
+		tokens = make(map[string]map[string]string)
+		tokenDatabase[sessionID] = tokens
+	}
+
+	copied := make(map[string]string, len(fields))
+	for k, v := range fields {
+		copied[k] = v
+	}
+	tokens[token] = copied
+}
+
+// GetSessionToken returns the fields installed for the bearer token, if any.
+func GetSessionToken(sessionID string, token string) (map[string]string, bool) {
+	tokenDatabaseMu.Lock()
+	defer tokenDatabaseMu.Unlock()
+	if tokenDatabase == nil {
+		return nil, false
+	}
+	tokens, ok := tokenDatabase[sessionID]
+	if !ok {
+		return nil, false
+	}
+	fields, ok := tokens[token]
+	if !ok {
+		return nil, false
+	}
+
+	copied := make(map[string]string, len(fields))
+	for k, v := range fields {
+		copied[k] = v
+	}
+	return copied, true
+}
+
+// Config is the configuration of a fake auth method.
+type Config struct {
+	// SessionID is the ID of the session holding the valid bearer tokens.
+	SessionID string
+
+	// Fields lists the field names a binding rule may refer to.
+	Fields []string
+}
+
+// Validator is a fake authmethod.Validator that accepts bearer tokens
+// installed into its session.
+type Validator struct {
+	name   string
+	config *Config
+}
+
+// NewValidator creates a fake Validator for the given auth method.
+func NewValidator(method *structs.ACLAuthMethod) (*Validator, error) {
+	if method.Type != Type {
+		return nil, fmt.Errorf("%q is not a testing auth method", method.Name)
+	}
+
+	var config Config
+	if err := authmethod.ParseConfig(method.Config, &config); err != nil {
+		return nil, err
+	}
+
+	return &Validator{
+		name:   method.Name,
+		config: &config,
+	}, nil
+}
+
+func (v *Validator) Name() string { return v.name }
+
+// ValidateLogin returns the fields installed for the bearer token in the
+// auth method's session.
+func (v *Validator) ValidateLogin(loginToken string) (map[string]string, error) {
+	fields, valid := GetSessionToken(v.config.SessionID, loginToken)
+	if !valid {
+		return nil, fmt.Errorf("unknown bearer token")
+	}
+	return fields, nil
+}
+
+func (v *Validator) AvailableFields() []string {
+	fields := make([]string, len(v.config.Fields))
+	copy(fields, v.config.Fields)
+	sort.Strings(fields)
+	return fields
+}
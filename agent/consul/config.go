@@ -243,6 +243,11 @@ type Config struct {
 	// a substantial cost.
 	ACLPolicyTTL time.Duration
 
+	// ACLRoleTTL controls the time-to-live of cached ACL roles.
+	// It can be set to zero to disable caching, but this adds
+	// a substantial cost.
+	ACLRoleTTL time.Duration
+
 	// ACLDisabledTTL is the time between checking if ACLs should be
 	// enabled. This
 	ACLDisabledTTL time.Duration
@@ -282,6 +287,27 @@ type Config struct {
 	// used to limit the amount of Raft bandwidth used for replication.
 	ACLReplicationApplyLimit int
 
+	// ACLTokenMinExpirationTTL and ACLTokenMaxExpirationTTL bound how far in
+	// the future a token's expiration time may be set when it is created.
+	ACLTokenMinExpirationTTL time.Duration
+	ACLTokenMaxExpirationTTL time.Duration
+
+	// ACLTokenReapingRate is the max number of expired token reaping rounds
+	// that the leader runs per second.
+	ACLTokenReapingRate rate.Limit
+
+	// ACLTokenReapingBurst is how many reaping rounds can be bursted after a
+	// period of idleness.
+	ACLTokenReapingBurst int
+
+	// KVSExpiryReapingRate is the max number of expired KV entry reaping
+	// rounds that the leader runs per second.
+	KVSExpiryReapingRate rate.Limit
+
+	// KVSExpiryReapingBurst is how many reaping rounds can be bursted after
+	// a period of idleness.
+	KVSExpiryReapingBurst int
+
 	// ACLEnableKeyListPolicy is used to gate enforcement of the new "list" policy that
 	// protects listing keys by prefix. This behavior is opt-in
 	// by default in Consul 1.0 and later.
@@ -310,6 +336,12 @@ type Config struct {
 	// to reduce overhead. It is unlikely a user would ever need to tune this.
 	TombstoneTTLGranularity time.Duration
 
+	// KVSHistory configures the KV prefixes whose keys have their past
+	// versions recorded. It's applied when the writes are, so it must be
+	// the same on all the servers. The leader warns about servers whose
+	// configuration differs.
+	KVSHistory []structs.KVSHistoryConfig
+
 	// Minimum Session TTL
 	SessionTTLMin time.Duration
 
@@ -354,6 +386,23 @@ type Config struct {
 	RPCRate     rate.Limit
 	RPCMaxBurst int
 
+	// RPCServerRate and RPCServerMaxBurst are the server-side counterpart of
+	// RPCRate and RPCMaxBurst. They bound the total rate of RPCs a server
+	// will accept over the network from all sources combined. Other servers
+	// in the local datacenter are exempt so that forwarding is unaffected.
+	RPCServerRate     rate.Limit
+	RPCServerMaxBurst int
+
+	// RPCServerSourceRate and RPCServerSourceMaxBurst bound the rate of RPCs
+	// a server will accept from any single source IP address. Other servers
+	// in the local datacenter are exempt so that forwarding is unaffected.
+	RPCServerSourceRate     rate.Limit
+	RPCServerSourceMaxBurst int
+
+	// RPCMaxBlockingQueries caps the number of blocking queries a server
+	// will have in flight at once. A value of 0 disables the cap.
+	RPCMaxBlockingQueries int
+
 	// LeaveDrainTime is used to wait after a server has left the LAN Serf
 	// pool for RPCs to drain and new requests to be sent to other servers.
 	LeaveDrainTime time.Duration
@@ -443,12 +492,19 @@ func DefaultConfig() *Config {
 		ReconcileInterval:        60 * time.Second,
 		ProtocolVersion:          ProtocolVersion2Compatible,
 		ACLPolicyTTL:             30 * time.Second,
+		ACLRoleTTL:               30 * time.Second,
 		ACLTokenTTL:              30 * time.Second,
 		ACLDefaultPolicy:         "allow",
 		ACLDownPolicy:            "extend-cache",
 		ACLReplicationRate:       1,
 		ACLReplicationBurst:      5,
 		ACLReplicationApplyLimit: 100, // ops / sec
+		ACLTokenMinExpirationTTL: time.Minute,
+		ACLTokenMaxExpirationTTL: 24 * time.Hour,
+		ACLTokenReapingRate:      1,
+		ACLTokenReapingBurst:     5,
+		KVSExpiryReapingRate:     1,
+		KVSExpiryReapingBurst:    5,
 		TombstoneTTL:             15 * time.Minute,
 		TombstoneTTLGranularity:  30 * time.Second,
 		SessionTTLMin:            10 * time.Second,
@@ -463,6 +519,11 @@ func DefaultConfig() *Config {
 		RPCRate:     rate.Inf,
 		RPCMaxBurst: 1000,
 
+		RPCServerRate:           rate.Inf,
+		RPCServerMaxBurst:       1000,
+		RPCServerSourceRate:     rate.Inf,
+		RPCServerSourceMaxBurst: 1000,
+
 		TLSMinVersion: "tls10",
 
 		// TODO (slackpad) - Until #3744 is done, we need to keep these
@@ -0,0 +1,104 @@
+package consul
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/miekg/dns"
+)
+
+// DNSSEC endpoint is used to share a generated zone signing key between the
+// agents that sign a Consul DNS domain.
+type DNSSEC struct {
+	srv *Server
+}
+
+// ZoneKey returns the zone signing key for the requested domain. The first
+// request for a domain generates the key, which is kept in the state store
+// from then on.
+func (d *DNSSEC) ZoneKey(args *structs.DNSSECKeyRequest, reply *structs.DNSSECKeyResponse) error {
+	if done, err := d.srv.forward("DNSSEC.ZoneKey", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"dnssec", "zone_key"}, time.Now())
+
+	// This action requires operator read access, as for the Connect CA
+	// configuration, since the reply holds the private key.
+	rule, err := d.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.OperatorRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	if args.Domain == "" {
+		return fmt.Errorf("Must provide a domain")
+	}
+	domain := dns.Fqdn(args.Domain)
+
+	state := d.srv.fsm.State()
+	_, key, err := state.DNSSECKey(nil, domain)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		generated, err := generateDNSSECKey(domain)
+		if err != nil {
+			return fmt.Errorf("failed to generate DNSSEC key: %v", err)
+		}
+
+		// Another request may have stored a key in the meantime, in
+		// which case this one is dropped and theirs is read below.
+		req := structs.DNSSECKeyRequest{
+			Datacenter: args.Datacenter,
+			Domain:     domain,
+			Key:        generated,
+		}
+		resp, err := d.srv.raftApply(structs.DNSSECKeyRequestType, &req)
+		if err != nil {
+			d.srv.logger.Printf("[ERR] consul.dnssec: Apply failed: %v", err)
+			return err
+		}
+		if respErr, ok := resp.(error); ok {
+			return respErr
+		}
+
+		if _, key, err = state.DNSSECKey(nil, domain); err != nil {
+			return err
+		}
+		if key == nil {
+			return fmt.Errorf("DNSSEC key for %q was not stored", domain)
+		}
+	}
+
+	reply.Key = key
+	return nil
+}
+
+// generateDNSSECKey makes a new ECDSA P-256 signing key for the domain.
+func generateDNSSECKey(domain string) (*structs.DNSSECKey, error) {
+	dnskey := &dns.DNSKEY{
+		Hdr: dns.RR_Header{
+			Name:   domain,
+			Rrtype: dns.TypeDNSKEY,
+			Class:  dns.ClassINET,
+			Ttl:    3600,
+		},
+		Flags:     dns.ZONE | dns.SEP,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	priv, err := dnskey.Generate(256)
+	if err != nil {
+		return nil, err
+	}
+	return &structs.DNSSECKey{
+		Domain:     domain,
+		PublicKey:  dnskey.String(),
+		PrivateKey: dnskey.PrivateKeyString(priv),
+	}, nil
+}
@@ -0,0 +1,94 @@
+package consul
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSSEC_ZoneKey(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// The first request generates a key.
+	args := structs.DNSSECKeyRequest{
+		Datacenter: "dc1",
+		Domain:     "consul",
+	}
+	var out structs.DNSSECKeyResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "DNSSEC.ZoneKey", &args, &out))
+	require.NotNil(t, out.Key)
+	require.Equal(t, "consul.", out.Key.Domain)
+	require.Contains(t, out.Key.PublicKey, "DNSKEY")
+	require.Contains(t, out.Key.PrivateKey, "PrivateKey:")
+
+	// Later requests get the same key.
+	var again structs.DNSSECKeyResponse
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "DNSSEC.ZoneKey", &args, &again))
+	require.Equal(t, out.Key, again.Key)
+
+	// The key isn't in the KV store.
+	list := structs.KeyRequest{
+		Datacenter: "dc1",
+		Key:        "",
+	}
+	var entries structs.IndexedDirEntries
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.List", &list, &entries))
+	require.Len(t, entries.Entries, 0)
+
+	// A domain is required.
+	args.Domain = ""
+	err := msgpackrpc.CallWithCodec(codec, "DNSSEC.ZoneKey", &args, &out)
+	require.Error(t, err)
+}
+
+func TestDNSSEC_ZoneKey_ACLDeny(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Without a token the key can't be read.
+	args := structs.DNSSECKeyRequest{
+		Datacenter: "dc1",
+		Domain:     "consul.",
+	}
+	var out structs.DNSSECKeyResponse
+	err := msgpackrpc.CallWithCodec(codec, "DNSSEC.ZoneKey", &args, &out)
+	require.True(t, acl.IsErrPermissionDenied(err), "bad: %v", err)
+
+	// A token with operator read can.
+	req := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:  "User token",
+			Type:  structs.ACLTokenTypeClient,
+			Rules: `operator = "read"`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.Apply", &req, &args.Token))
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "DNSSEC.ZoneKey", &args, &out))
+	require.NotNil(t, out.Key)
+}
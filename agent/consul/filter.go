@@ -50,6 +50,29 @@ func FilterKeys(authorizer acl.Authorizer, keys []string) []string {
 	return keys[:FilterEntries(&kf)]
 }
 
+type kvsEventFilter struct {
+	authorizer acl.Authorizer
+	events     structs.KVSEvents
+}
+
+func (k *kvsEventFilter) Len() int {
+	return len(k.events)
+}
+func (k *kvsEventFilter) Filter(i int) bool {
+	return !k.authorizer.KeyRead(k.events[i].Key)
+}
+
+func (k *kvsEventFilter) Move(dst, src, span int) {
+	copy(k.events[dst:dst+span], k.events[src:src+span])
+}
+
+// FilterKVSEvents is used to filter a list of KV change events
+// by applying an ACL policy
+func FilterKVSEvents(authorizer acl.Authorizer, events structs.KVSEvents) structs.KVSEvents {
+	ef := kvsEventFilter{authorizer: authorizer, events: events}
+	return events[:FilterEntries(&ef)]
+}
+
 type txnResultsFilter struct {
 	authorizer acl.Authorizer
 	results    structs.TxnResults
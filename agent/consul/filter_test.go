@@ -82,6 +82,29 @@ func TestFilter_Keys(t *testing.T) {
 	}
 }
 
+func TestFilter_KVSEvents(t *testing.T) {
+	t.Parallel()
+	policy, _ := acl.NewPolicyFromSource("", 0, testFilterRules, acl.SyntaxLegacy, nil)
+	aclR, _ := acl.NewPolicyAuthorizer(acl.DenyAll(), []*acl.Policy{policy}, nil)
+
+	events := structs.KVSEvents{
+		&structs.KVSEvent{Type: structs.KVSEventPut, Key: "foo/test"},
+		&structs.KVSEvent{Type: structs.KVSEventDelete, Key: "foo/priv/nope"},
+		&structs.KVSEvent{Type: structs.KVSEventDelete, Key: "foo/other"},
+		&structs.KVSEvent{Type: structs.KVSEventPut, Key: "zoo"},
+	}
+	events = FilterKVSEvents(aclR, events)
+
+	var outL []string
+	for _, e := range events {
+		outL = append(outL, e.Key)
+	}
+	expected := []string{"foo/test", "foo/other"}
+	if !reflect.DeepEqual(outL, expected) {
+		t.Fatalf("bad: %#v %#v", outL, expected)
+	}
+}
+
 func TestFilter_TxnResults(t *testing.T) {
 	t.Parallel()
 	policy, _ := acl.NewPolicyFromSource("", 0, testFilterRules, acl.SyntaxLegacy, nil)
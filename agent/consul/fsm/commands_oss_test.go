@@ -394,6 +394,45 @@ func TestFSM_KVSDelete(t *testing.T) {
 	}
 }
 
+func TestFSM_KVSHistoryTime(t *testing.T) {
+	t.Parallel()
+	fsm, err := New(nil, os.Stderr)
+	require.NoError(t, err)
+	fsm.SetKVSHistoryConfig([]structs.KVSHistoryConfig{
+		{Prefix: "foo/", MaxVersions: 10},
+	})
+
+	// The versions get the time the leader stamped on the request rather
+	// than the time they're applied at.
+	applied := time.Date(2019, 4, 1, 12, 0, 0, 0, time.UTC)
+	req := structs.KVSRequest{
+		Datacenter: "dc1",
+		Op:         api.KVSet,
+		DirEnt: structs.DirEntry{
+			Key:   "foo/a",
+			Value: []byte("1"),
+		},
+	}
+	req.ApplyTime = applied
+	buf, err := structs.Encode(structs.KVSRequestType, req)
+	require.NoError(t, err)
+	require.Nil(t, fsm.Apply(makeLog(buf)))
+
+	req.Op = api.KVDelete
+	req.ApplyTime = applied.Add(time.Minute)
+	buf, err = structs.Encode(structs.KVSRequestType, req)
+	require.NoError(t, err)
+	log := makeLog(buf)
+	log.Index = 2
+	require.Nil(t, fsm.Apply(log))
+
+	_, history, err := fsm.state.KVSHistory(nil, "foo/a")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.True(t, applied.Equal(history[0].Time))
+	require.True(t, applied.Add(time.Minute).Equal(history[1].Time))
+}
+
 func TestFSM_KVSDeleteTree(t *testing.T) {
 	t.Parallel()
 	fsm, err := New(nil, os.Stderr)
@@ -1395,13 +1434,40 @@ func TestFSM_ConfigEntry(t *testing.T) {
 		entry.RaftIndex.CreateIndex = 1
 		entry.RaftIndex.ModifyIndex = 1
 
-		proxyConf, ok := config.(*structs.ProxyConfigEntry)
-		require.True(ok)
+		require.Equal(entry, config)
+	}
+}
 
-		// Read the map[string]interface{} back out.
-		value, _ := proxyConf.Config["foo"].([]uint8)
-		proxyConf.Config["foo"] = structs.Uint8ToString(value)
+func TestFSM_DNSSECKey(t *testing.T) {
+	t.Parallel()
 
-		require.Equal(entry, config)
+	require := require.New(t)
+	fsm, err := New(nil, os.Stderr)
+	require.NoError(err)
+
+	// Store a key.
+	req := &structs.DNSSECKeyRequest{
+		Domain: "consul.",
+		Key: &structs.DNSSECKey{
+			Domain:     "consul.",
+			PublicKey:  "public",
+			PrivateKey: "private",
+		},
 	}
+	buf, err := structs.Encode(structs.DNSSECKeyRequestType, req)
+	require.NoError(err)
+	require.Equal(true, fsm.Apply(makeLog(buf)))
+
+	// A second key for the domain is dropped.
+	req.Key.PrivateKey = "other"
+	buf, err = structs.Encode(structs.DNSSECKeyRequestType, req)
+	require.NoError(err)
+	log := makeLog(buf)
+	log.Index = 2
+	require.Equal(false, fsm.Apply(log))
+
+	_, key, err := fsm.state.DNSSECKey(nil, "consul.")
+	require.NoError(err)
+	require.Equal("private", key.PrivateKey)
+	require.Equal(uint64(1), key.ModifyIndex)
 }
@@ -60,6 +60,10 @@ type FSM struct {
 	state     *state.Store
 
 	gc *state.TombstoneGC
+
+	// kvsHistory is the KV history configuration, applied to every state
+	// store created by a restore.
+	kvsHistory []structs.KVSHistoryConfig
 }
 
 // New is used to construct a new FSM with a blank state.
@@ -95,6 +99,13 @@ func (c *FSM) State() *state.Store {
 	return c.state
 }
 
+// SetKVSHistoryConfig sets which keys have their versions recorded in the KV
+// history. It must be called before any log is applied.
+func (c *FSM) SetKVSHistoryConfig(config []structs.KVSHistoryConfig) {
+	c.kvsHistory = config
+	c.State().SetKVSHistoryConfig(config)
+}
+
 func (c *FSM) Apply(log *raft.Log) interface{} {
 	buf := log.Data
 	msgType := structs.MessageType(buf[0])
@@ -140,40 +151,21 @@ func (c *FSM) Restore(old io.ReadCloser) error {
 	if err != nil {
 		return err
 	}
+	stateNew.SetKVSHistoryConfig(c.kvsHistory)
 
 	// Set up a new restore transaction
 	restore := stateNew.Restore()
 	defer restore.Abort()
 
-	// Create a decoder
-	dec := codec.NewDecoder(old, msgpackHandle)
-
-	// Read in the header
-	var header snapshotHeader
-	if err := dec.Decode(&header); err != nil {
-		return err
-	}
-
 	// Populate the new state
-	msgType := make([]byte, 1)
-	for {
-		// Read the message type
-		_, err := old.Read(msgType)
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return err
-		}
-
-		// Decode
-		msg := structs.MessageType(msgType[0])
+	handler := func(header *SnapshotHeader, msg structs.MessageType, dec *codec.Decoder) error {
 		if fn := restorers[msg]; fn != nil {
-			if err := fn(&header, restore, dec); err != nil {
-				return err
-			}
-		} else {
-			return fmt.Errorf("Unrecognized msg type %d", msg)
+			return fn(header, restore, dec)
 		}
+		return fmt.Errorf("Unrecognized msg type %d", msg)
+	}
+	if err := ReadSnapshot(old, handler); err != nil {
+		return err
 	}
 	restore.Commit()
 
@@ -2,6 +2,7 @@ package fsm
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -18,8 +19,8 @@ type snapshot struct {
 	state *state.Snapshot
 }
 
-// snapshotHeader is the first entry in our snapshot
-type snapshotHeader struct {
+// SnapshotHeader is the first entry in our snapshot
+type SnapshotHeader struct {
 	// LastIndex is the last index that affects the data.
 	// This is used when we do the restore for watchers.
 	LastIndex uint64
@@ -38,7 +39,7 @@ func registerPersister(fn persister) {
 }
 
 // restorer is a function used to load back a snapshot of the FSM state.
-type restorer func(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error
+type restorer func(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error
 
 // restorers is a map of restore functions by message type.
 var restorers map[structs.MessageType]restorer
@@ -60,7 +61,7 @@ func (s *snapshot) Persist(sink raft.SnapshotSink) error {
 	defer metrics.MeasureSince([]string{"fsm", "persist"}, time.Now())
 
 	// Write the header
-	header := snapshotHeader{
+	header := SnapshotHeader{
 		LastIndex: s.state.LastIndex(),
 	}
 	encoder := codec.NewEncoder(sink, msgpackHandle)
@@ -82,3 +83,34 @@ func (s *snapshot) Persist(sink raft.SnapshotSink) error {
 func (s *snapshot) Release() {
 	s.state.Close()
 }
+
+// ReadSnapshot decodes a snapshot written by Persist. The handler is called
+// for each record with its message type and a decoder positioned at the
+// record, which the handler must decode exactly once.
+func ReadSnapshot(r io.Reader, handler func(header *SnapshotHeader, msg structs.MessageType, dec *codec.Decoder) error) error {
+	// Create a decoder
+	dec := codec.NewDecoder(r, msgpackHandle)
+
+	// Read in the header
+	var header SnapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+
+	msgType := make([]byte, 1)
+	for {
+		// Read the message type
+		_, err := r.Read(msgType)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// Decode
+		msg := structs.MessageType(msgType[0])
+		if err := handler(&header, msg, dec); err != nil {
+			return err
+		}
+	}
+}
@@ -14,6 +14,7 @@ func init() {
 	registerRestorer(structs.RegisterRequestType, restoreRegistration)
 	registerRestorer(structs.KVSRequestType, restoreKV)
 	registerRestorer(structs.TombstoneRequestType, restoreTombstone)
+	registerRestorer(structs.KVSHistoryRequestType, restoreKVSHistory)
 	registerRestorer(structs.SessionRequestType, restoreSession)
 	registerRestorer(structs.ACLRequestType, restoreACL)
 	registerRestorer(structs.ACLBootstrapRequestType, restoreACLBootstrap)
@@ -28,6 +29,10 @@ func init() {
 	registerRestorer(structs.ACLTokenSetRequestType, restoreToken)
 	registerRestorer(structs.ACLPolicySetRequestType, restorePolicy)
 	registerRestorer(structs.ConfigEntryRequestType, restoreConfigEntry)
+	registerRestorer(structs.ACLRoleSetRequestType, restoreRole)
+	registerRestorer(structs.ACLAuthMethodSetRequestType, restoreAuthMethod)
+	registerRestorer(structs.ACLBindingRuleSetRequestType, restoreBindingRule)
+	registerRestorer(structs.DNSSECKeyRequestType, restoreDNSSECKey)
 }
 
 func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) error {
@@ -46,6 +51,9 @@ func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) err
 	if err := s.persistTombstones(sink, encoder); err != nil {
 		return err
 	}
+	if err := s.persistKVSHistory(sink, encoder); err != nil {
+		return err
+	}
 	if err := s.persistPreparedQueries(sink, encoder); err != nil {
 		return err
 	}
@@ -67,6 +75,9 @@ func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) err
 	if err := s.persistConfigEntries(sink, encoder); err != nil {
 		return err
 	}
+	if err := s.persistDNSSECKeys(sink, encoder); err != nil {
+		return err
+	}
 	if err := s.persistIndex(sink, encoder); err != nil {
 		return err
 	}
@@ -201,6 +212,48 @@ func (s *snapshot) persistACLs(sink raft.SnapshotSink,
 		}
 	}
 
+	roles, err := s.state.ACLRoles()
+	if err != nil {
+		return err
+	}
+
+	for role := roles.Next(); role != nil; role = roles.Next() {
+		if _, err := sink.Write([]byte{byte(structs.ACLRoleSetRequestType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(role.(*structs.ACLRole)); err != nil {
+			return err
+		}
+	}
+
+	methods, err := s.state.ACLAuthMethods()
+	if err != nil {
+		return err
+	}
+
+	for method := methods.Next(); method != nil; method = methods.Next() {
+		if _, err := sink.Write([]byte{byte(structs.ACLAuthMethodSetRequestType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(method.(*structs.ACLAuthMethod)); err != nil {
+			return err
+		}
+	}
+
+	rules, err := s.state.ACLBindingRules()
+	if err != nil {
+		return err
+	}
+
+	for rule := rules.Next(); rule != nil; rule = rules.Next() {
+		if _, err := sink.Write([]byte{byte(structs.ACLBindingRuleSetRequestType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(rule.(*structs.ACLBindingRule)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -222,6 +275,10 @@ func (s *snapshot) persistKVs(sink raft.SnapshotSink,
 	return nil
 }
 
+// tombstoneTreeFlag is set in the flags of the KV entries tombstones are
+// serialized as when they're for a recursive delete.
+const tombstoneTreeFlag uint64 = 1
+
 func (s *snapshot) persistTombstones(sink raft.SnapshotSink,
 	encoder *codec.Encoder) error {
 	stones, err := s.state.Tombstones()
@@ -244,6 +301,9 @@ func (s *snapshot) persistTombstones(sink raft.SnapshotSink,
 				ModifyIndex: s.Index,
 			},
 		}
+		if s.Tree {
+			fake.Flags = tombstoneTreeFlag
+		}
 		if err := encoder.Encode(fake); err != nil {
 			return err
 		}
@@ -251,6 +311,24 @@ func (s *snapshot) persistTombstones(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *snapshot) persistKVSHistory(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	entries, err := s.state.KVSHistory()
+	if err != nil {
+		return err
+	}
+
+	for entry := entries.Next(); entry != nil; entry = entries.Next() {
+		if _, err := sink.Write([]byte{byte(structs.KVSHistoryRequestType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(entry.(*structs.KVSHistoryEntry)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *snapshot) persistPreparedQueries(sink raft.SnapshotSink,
 	encoder *codec.Encoder) error {
 	queries, err := s.state.PreparedQueries()
@@ -388,6 +466,24 @@ func (s *snapshot) persistConfigEntries(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *snapshot) persistDNSSECKeys(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	keys, err := s.state.DNSSECKeys()
+	if err != nil {
+		return err
+	}
+
+	for key := keys.Next(); key != nil; key = keys.Next() {
+		if _, err := sink.Write([]byte{byte(structs.DNSSECKeyRequestType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(key.(*structs.DNSSECKey)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *snapshot) persistIndex(sink raft.SnapshotSink, encoder *codec.Encoder) error {
 	// Get all the indexes
 	iter, err := s.state.Indexes()
@@ -408,7 +504,7 @@ func (s *snapshot) persistIndex(sink raft.SnapshotSink, encoder *codec.Encoder)
 	return nil
 }
 
-func restoreRegistration(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreRegistration(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.RegisterRequest
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -419,7 +515,7 @@ func restoreRegistration(header *snapshotHeader, restore *state.Restore, decoder
 	return nil
 }
 
-func restoreKV(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreKV(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.DirEntry
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -430,7 +526,7 @@ func restoreKV(header *snapshotHeader, restore *state.Restore, decoder *codec.De
 	return nil
 }
 
-func restoreTombstone(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreTombstone(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.DirEntry
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -442,6 +538,7 @@ func restoreTombstone(header *snapshotHeader, restore *state.Restore, decoder *c
 	stone := &state.Tombstone{
 		Key:   req.Key,
 		Index: req.ModifyIndex,
+		Tree:  req.Flags&tombstoneTreeFlag != 0,
 	}
 	if err := restore.Tombstone(stone); err != nil {
 		return err
@@ -449,7 +546,18 @@ func restoreTombstone(header *snapshotHeader, restore *state.Restore, decoder *c
 	return nil
 }
 
-func restoreSession(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreKVSHistory(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.KVSHistoryEntry
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := restore.KVSHistory(&req); err != nil {
+		return err
+	}
+	return nil
+}
+
+func restoreSession(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.Session
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -460,7 +568,7 @@ func restoreSession(header *snapshotHeader, restore *state.Restore, decoder *cod
 	return nil
 }
 
-func restoreACL(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreACL(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.ACL
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -473,7 +581,7 @@ func restoreACL(header *snapshotHeader, restore *state.Restore, decoder *codec.D
 }
 
 // DEPRECATED (ACL-Legacy-Compat) - remove once v1 acl compat is removed
-func restoreACLBootstrap(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreACLBootstrap(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.ACLBootstrap
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -484,7 +592,7 @@ func restoreACLBootstrap(header *snapshotHeader, restore *state.Restore, decoder
 	return restore.IndexRestore(&state.IndexEntry{Key: "acl-token-bootstrap", Value: req.ModifyIndex})
 }
 
-func restoreCoordinates(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreCoordinates(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.Coordinates
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -495,7 +603,7 @@ func restoreCoordinates(header *snapshotHeader, restore *state.Restore, decoder
 	return nil
 }
 
-func restorePreparedQuery(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restorePreparedQuery(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.PreparedQuery
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -506,7 +614,7 @@ func restorePreparedQuery(header *snapshotHeader, restore *state.Restore, decode
 	return nil
 }
 
-func restoreAutopilot(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreAutopilot(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req autopilot.Config
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -517,7 +625,7 @@ func restoreAutopilot(header *snapshotHeader, restore *state.Restore, decoder *c
 	return nil
 }
 
-func restoreIntention(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreIntention(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.Intention
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -528,7 +636,7 @@ func restoreIntention(header *snapshotHeader, restore *state.Restore, decoder *c
 	return nil
 }
 
-func restoreConnectCA(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreConnectCA(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.CARoot
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -539,7 +647,7 @@ func restoreConnectCA(header *snapshotHeader, restore *state.Restore, decoder *c
 	return nil
 }
 
-func restoreConnectCAProviderState(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreConnectCAProviderState(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.CAConsulProviderState
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -550,7 +658,7 @@ func restoreConnectCAProviderState(header *snapshotHeader, restore *state.Restor
 	return nil
 }
 
-func restoreConnectCAConfig(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreConnectCAConfig(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.CAConfiguration
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -561,7 +669,7 @@ func restoreConnectCAConfig(header *snapshotHeader, restore *state.Restore, deco
 	return nil
 }
 
-func restoreIndex(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreIndex(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req state.IndexEntry
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -569,7 +677,7 @@ func restoreIndex(header *snapshotHeader, restore *state.Restore, decoder *codec
 	return restore.IndexRestore(&req)
 }
 
-func restoreToken(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreToken(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.ACLToken
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -586,7 +694,7 @@ func restoreToken(header *snapshotHeader, restore *state.Restore, decoder *codec
 	return restore.ACLToken(&req)
 }
 
-func restorePolicy(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restorePolicy(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.ACLPolicy
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -594,10 +702,45 @@ func restorePolicy(header *snapshotHeader, restore *state.Restore, decoder *code
 	return restore.ACLPolicy(&req)
 }
 
-func restoreConfigEntry(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreRole(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.ACLRole
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	return restore.ACLRole(&req)
+}
+
+func restoreAuthMethod(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.ACLAuthMethod
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	if err := req.FixupConfig(); err != nil {
+		return err
+	}
+	return restore.ACLAuthMethod(&req)
+}
+
+func restoreBindingRule(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.ACLBindingRule
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	return restore.ACLBindingRule(&req)
+}
+
+func restoreConfigEntry(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.ConfigEntryRequest
 	if err := decoder.Decode(&req); err != nil {
 		return err
 	}
 	return restore.ConfigEntry(req.Entry)
 }
+
+func restoreDNSSECKey(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.DNSSECKey
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	return restore.DNSSECKey(&req)
+}
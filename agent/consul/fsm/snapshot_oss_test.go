@@ -96,6 +96,42 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	policy.SetHash(true)
 	require.NoError(fsm.state.ACLPolicySet(1, &policy))
 
+	role := &structs.ACLRole{
+		ID:          "86dedd19-8fae-4594-8294-4e6948a81f9a",
+		Name:        "some-role",
+		Description: "test snapshot role",
+		Policies: []structs.ACLRolePolicyLink{
+			{
+				ID: structs.ACLPolicyGlobalManagementID,
+			},
+		},
+	}
+	role.SetHash(true)
+	require.NoError(fsm.state.ACLRoleSet(1, role))
+
+	method := &structs.ACLAuthMethod{
+		Name:        "some-method",
+		Type:        "testing",
+		Description: "test snapshot auth method",
+		Config: map[string]interface{}{
+			"SessionID": "952ebfa8-2a42-46f0-bcd3-fd98a842000e",
+			"Nested": map[string]interface{}{
+				"foo": "bar",
+			},
+		},
+	}
+	require.NoError(fsm.state.ACLAuthMethodSet(1, method))
+
+	bindingRule := &structs.ACLBindingRule{
+		ID:          "85184c52-5997-4a84-9817-5945f2632a17",
+		Description: "test snapshot binding rule",
+		AuthMethod:  "some-method",
+		Selector:    "value.team == web",
+		BindType:    structs.BindingRuleBindTypeService,
+		BindName:    "${value.service}",
+	}
+	require.NoError(fsm.state.ACLBindingRuleSet(1, bindingRule))
+
 	token := &structs.ACLToken{
 		AccessorID:  "30fca056-9fbb-4455-b94a-bf0e2bc575d6",
 		SecretID:    "cbe1c6fd-d865-4034-9d6d-64fef7fb46a9",
@@ -112,6 +148,16 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	}
 	require.NoError(fsm.state.ACLBootstrap(10, 0, token, false))
 
+	expirationTime := time.Now().Add(-time.Minute)
+	expiredToken := &structs.ACLToken{
+		AccessorID:     "0d0a6e97-1d0f-4b55-9e5c-5d7e8c1f21a4",
+		SecretID:       "9c7b0b7b-39c3-4c5f-8a8e-1b7a7b0e6b3c",
+		Description:    "Expired Token",
+		Local:          true,
+		ExpirationTime: &expirationTime,
+	}
+	require.NoError(fsm.state.ACLTokenSet(11, expiredToken, false))
+
 	fsm.state.KVSSet(11, &structs.DirEntry{
 		Key:   "/remove",
 		Value: []byte("foo"),
@@ -216,6 +262,16 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	require.NoError(fsm.state.EnsureConfigEntry(18, serviceConfig))
 	require.NoError(fsm.state.EnsureConfigEntry(19, proxyConfig))
 
+	// DNSSEC keys
+	dnssecKey := &structs.DNSSECKey{
+		Domain:     "consul.",
+		PublicKey:  "public",
+		PrivateKey: "private",
+	}
+	ok, err = fsm.state.DNSSECKeyCreate(20, dnssecKey)
+	require.NoError(err)
+	require.True(ok)
+
 	// Snapshot
 	snap, err := fsm.Snapshot()
 	if err != nil {
@@ -320,6 +376,13 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	require.Equal(token.AccessorID, a.AccessorID)
 	require.Equal(token.ModifyIndex, a.ModifyIndex)
 
+	// Verify the expiration time and its index were restored
+	expired, err := fsm2.state.ACLTokenListExpired(true, time.Now(), 10)
+	require.NoError(err)
+	require.Len(expired, 1)
+	require.Equal(expiredToken.AccessorID, expired[0].AccessorID)
+	require.True(expirationTime.Equal(*expired[0].ExpirationTime))
+
 	// Verify the acl-token-bootstrap index was restored
 	canBootstrap, index, err := fsm2.state.CanBootstrapACLToken()
 	require.False(canBootstrap)
@@ -330,6 +393,26 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	require.NoError(err)
 	require.Equal(policy.Name, policy2.Name)
 
+	// Verify ACL Role is restored
+	_, role2, err := fsm2.state.ACLRoleGetByID(nil, role.ID)
+	require.NoError(err)
+	require.Equal(role.Name, role2.Name)
+	require.Len(role2.Policies, 1)
+	require.Equal(structs.ACLPolicyGlobalManagementID, role2.Policies[0].ID)
+
+	// Verify ACL Auth Method is restored
+	_, method2, err := fsm2.state.ACLAuthMethodGetByName(nil, method.Name)
+	require.NoError(err)
+	require.Equal(method.Description, method2.Description)
+	require.Equal(method.Config, method2.Config)
+
+	// Verify ACL Binding Rule is restored
+	_, bindingRule2, err := fsm2.state.ACLBindingRuleGetByID(nil, bindingRule.ID)
+	require.NoError(err)
+	require.Equal(bindingRule.AuthMethod, bindingRule2.AuthMethod)
+	require.Equal(bindingRule.Selector, bindingRule2.Selector)
+	require.Equal(bindingRule.BindName, bindingRule2.BindName)
+
 	// Verify tombstones are restored
 	func() {
 		snap := fsm2.state.Snapshot()
@@ -411,6 +494,11 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	require.NoError(err)
 	assert.Equal(proxyConfig, proxyConfEntry)
 
+	// Verify DNSSEC keys are restored
+	_, restoredKey, err := fsm2.state.DNSSECKey(nil, "consul.")
+	require.NoError(err)
+	assert.Equal(dnssecKey, restoredKey)
+
 	// Snapshot
 	snap, err = fsm2.Snapshot()
 	if err != nil {
@@ -477,6 +565,94 @@ func TestFSM_BadRestore_OSS(t *testing.T) {
 	}
 }
 
+func TestFSM_SnapshotRestore_KVSHistory(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	config := []structs.KVSHistoryConfig{{Prefix: "foo/", MaxVersions: 5}}
+	fsm, err := New(nil, os.Stderr)
+	require.NoError(err)
+	fsm.SetKVSHistoryConfig(config)
+
+	// Add some history.
+	require.NoError(fsm.state.KVSSet(1, &structs.DirEntry{Key: "foo/a", Value: []byte("1")}))
+	require.NoError(fsm.state.KVSSet(2, &structs.DirEntry{Key: "foo/a", Value: []byte("2")}))
+	require.NoError(fsm.state.KVSDelete(3, "foo/a"))
+
+	// Snapshot
+	snap, err := fsm.Snapshot()
+	require.NoError(err)
+	defer snap.Release()
+
+	// Persist
+	buf := bytes.NewBuffer(nil)
+	sink := &MockSink{buf, false}
+	require.NoError(snap.Persist(sink))
+
+	// Try to restore on a new FSM
+	fsm2, err := New(nil, os.Stderr)
+	require.NoError(err)
+	fsm2.SetKVSHistoryConfig(config)
+	require.NoError(fsm2.Restore(sink))
+
+	// Verify the history is restored.
+	_, history, err := fsm2.state.KVSHistory(nil, "foo/a")
+	require.NoError(err)
+	require.Len(history, 3)
+	require.Equal([]byte("1"), history[0].Value)
+	require.Equal([]byte("2"), history[1].Value)
+	require.True(history[2].Deleted)
+
+	// Verify the restored state store keeps the configuration.
+	require.NoError(fsm2.state.KVSSet(4, &structs.DirEntry{Key: "foo/a", Value: []byte("3")}))
+	_, history, err = fsm2.state.KVSHistory(nil, "foo/a")
+	require.NoError(err)
+	require.Len(history, 4)
+}
+
+func TestFSM_SnapshotRestore_TreeTombstone(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	fsm, err := New(nil, os.Stderr)
+	require.NoError(err)
+
+	// Leave a tombstone for a single and a recursive delete.
+	require.NoError(fsm.state.KVSSet(1, &structs.DirEntry{Key: "foo/a"}))
+	require.NoError(fsm.state.KVSSet(2, &structs.DirEntry{Key: "foo/b/c"}))
+	require.NoError(fsm.state.KVSDelete(3, "foo/a"))
+	require.NoError(fsm.state.KVSDeleteTree(4, "foo/b"))
+
+	// Snapshot
+	snap, err := fsm.Snapshot()
+	require.NoError(err)
+	defer snap.Release()
+
+	// Persist
+	buf := bytes.NewBuffer(nil)
+	sink := &MockSink{buf, false}
+	require.NoError(snap.Persist(sink))
+
+	// Try to restore on a new FSM
+	fsm2, err := New(nil, os.Stderr)
+	require.NoError(err)
+	require.NoError(fsm2.Restore(sink))
+
+	// Verify the tombstones are still told apart.
+	state2 := fsm2.state.Snapshot()
+	defer state2.Close()
+	stones, err := state2.Tombstones()
+	require.NoError(err)
+	var restored []*state.Tombstone
+	for stone := stones.Next(); stone != nil; stone = stones.Next() {
+		restored = append(restored, stone.(*state.Tombstone))
+	}
+	require.Equal([]*state.Tombstone{
+		{Key: "foo/a", Index: 3},
+		{Key: "foo/b", Index: 4, Tree: true},
+	}, restored)
+}
+
 func TestFSM_BadSnapshot_NilCAConfig(t *testing.T) {
 	t.Parallel()
 
@@ -57,6 +57,27 @@ func kvsPreApply(srv *Server, rule acl.Authorizer, op api.KVOp, dirEnt *structs.
 		}
 	}
 
+	// Turn the TTL into an expiration time. Like lock-delay this is based
+	// on wall-time, so it's done before commit using the leader's clock so
+	// that every peer stores the same expiration time.
+	switch op {
+	case api.KVSet, api.KVCAS, api.KVLock, api.KVUnlock:
+		if dirEnt.ExpirationTTL < 0 {
+			return false, fmt.Errorf("TTL '%s' should be > 0", dirEnt.ExpirationTTL)
+		}
+		dirEnt.ExpirationTime = nil
+		if dirEnt.ExpirationTTL != 0 {
+			expirationTime := time.Now().Add(dirEnt.ExpirationTTL)
+			dirEnt.ExpirationTime = &expirationTime
+			dirEnt.ExpirationTTL = 0
+		}
+
+	default:
+		if dirEnt.ExpirationTTL != 0 {
+			return false, fmt.Errorf("TTL can only be set when writing a key")
+		}
+	}
+
 	// If this is a lock, we must check for a lock-delay. Since lock-delay
 	// is based on wall-time, each peer would expire the lock-delay at a slightly
 	// different time. This means the enforcement of lock-delay cannot be done
@@ -153,6 +174,46 @@ func (k *KVS) Get(args *structs.KeyRequest, reply *structs.IndexedDirEntries) er
 		})
 }
 
+// History is used to lookup the versions of a single key.
+func (k *KVS) History(args *structs.KeyRequest, reply *structs.IndexedKVSHistory) error {
+	if done, err := k.srv.forward("KVS.History", args, args, reply); done {
+		return err
+	}
+
+	aclRule, err := k.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	return k.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, history, err := state.KVSHistory(ws, args.Key)
+			if err != nil {
+				return err
+			}
+			if aclRule != nil && !aclRule.KeyRead(args.Key) {
+				return acl.ErrPermissionDenied
+			}
+
+			reply.Enabled = state.KVSHistoryEnabled(args.Key)
+			if len(history) == 0 {
+				// Must provide non-zero index to prevent blocking
+				// Index 1 is impossible anyways (due to Raft internals)
+				if index == 0 {
+					reply.Index = 1
+				} else {
+					reply.Index = index
+				}
+				reply.Entries = nil
+			} else {
+				reply.Index = history[len(history)-1].ModifyIndex
+				reply.Entries = history
+			}
+			return nil
+		})
+}
+
 // List is used to list all keys with a given prefix.
 func (k *KVS) List(args *structs.KeyRequest, reply *structs.IndexedDirEntries) error {
 	if done, err := k.srv.forward("KVS.List", args, args, reply); done {
@@ -197,6 +258,48 @@ func (k *KVS) List(args *structs.KeyRequest, reply *structs.IndexedDirEntries) e
 		})
 }
 
+// Changes is used to get the changes to the keys with a given prefix after
+// the MinQueryIndex, so they can be followed without listing all the keys
+// every time one of them changes.
+func (k *KVS) Changes(args *structs.KVSChangesRequest, reply *structs.IndexedKVSChanges) error {
+	if done, err := k.srv.forward("KVS.Changes", args, args, reply); done {
+		return err
+	}
+
+	aclToken, err := k.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+
+	if aclToken != nil && k.srv.config.ACLEnableKeyListPolicy && !aclToken.KeyList(args.Prefix) {
+		return acl.ErrPermissionDenied
+	}
+
+	return k.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, reset, events, err := state.KVSChanges(ws, args.Prefix, args.MinQueryIndex, args.Resume)
+			if err != nil {
+				return err
+			}
+			if aclToken != nil {
+				events = FilterKVSEvents(aclToken, events)
+			}
+
+			// Must provide non-zero index to prevent blocking
+			// Index 1 is impossible anyways (due to Raft internals)
+			if index == 0 {
+				reply.Index = 1
+			} else {
+				reply.Index = index
+			}
+			reply.Reset = reset
+			reply.Events = events
+			return nil
+		})
+}
+
 // ListKeys is used to list all keys with a given prefix to a separator.
 func (k *KVS) ListKeys(args *structs.KeyListRequest, reply *structs.IndexedKeyList) error {
 	if done, err := k.srv.forward("KVS.ListKeys", args, args, reply); done {
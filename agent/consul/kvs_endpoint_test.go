@@ -2,6 +2,7 @@ package consul
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/pascaldekloe/goe/verify"
+	"github.com/stretchr/testify/require"
 )
 
 func TestKVS_Apply(t *testing.T) {
@@ -70,6 +72,76 @@ func TestKVS_Apply(t *testing.T) {
 	}
 }
 
+func TestKVS_Apply_TTL(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	arg := structs.KVSRequest{
+		Datacenter: "dc1",
+		Op:         api.KVSet,
+		DirEnt: structs.DirEntry{
+			Key:           "test",
+			Value:         []byte("test"),
+			ExpirationTTL: time.Hour,
+		},
+	}
+	var out bool
+	start := time.Now()
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The TTL is turned into an expiration time and isn't stored.
+	state := s1.fsm.State()
+	_, d, err := state.KVSGet(nil, "test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if d.ExpirationTTL != 0 {
+		t.Fatalf("bad: %v", d)
+	}
+	if !d.HasExpirationTime() || d.ExpirationTime.Before(start.Add(time.Hour)) || d.ExpirationTime.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("bad: %v", d.ExpirationTime)
+	}
+
+	// Expiration times can't be set directly.
+	expires := time.Now().Add(-time.Hour)
+	arg.DirEnt.ExpirationTTL = 0
+	arg.DirEnt.ExpirationTime = &expires
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, d, err = state.KVSGet(nil, "test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if d.HasExpirationTime() {
+		t.Fatalf("bad: %v", d.ExpirationTime)
+	}
+
+	// Negative TTLs are rejected.
+	arg.DirEnt.ExpirationTime = nil
+	arg.DirEnt.ExpirationTTL = -time.Second
+	err = msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out)
+	if err == nil || !strings.Contains(err.Error(), "should be > 0") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// TTLs can only be set on writes.
+	arg.Op = api.KVDelete
+	arg.DirEnt.ExpirationTTL = time.Second
+	err = msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out)
+	if err == nil || !strings.Contains(err.Error(), "TTL can only be set") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestKVS_Apply_ACLDeny(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServerWithConfig(t, func(c *Config) {
@@ -223,6 +295,120 @@ func TestKVS_Get_ACLDeny(t *testing.T) {
 
 }
 
+func TestKVS_History(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+		c.KVSHistory = []structs.KVSHistoryConfig{{Prefix: "foo/", MaxVersions: 2}}
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	for _, value := range []string{"1", "2", "3"} {
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt: structs.DirEntry{
+				Key:   "foo/test",
+				Value: []byte(value),
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out bool
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out))
+	}
+
+	getR := structs.KeyRequest{
+		Datacenter:   "dc1",
+		Key:          "foo/test",
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}
+	var history structs.IndexedKVSHistory
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.History", &getR, &history))
+	require.Len(t, history.Entries, 2)
+	require.Equal(t, []byte("2"), history.Entries[0].Value)
+	require.Equal(t, []byte("3"), history.Entries[1].Value)
+	require.Equal(t, history.Entries[1].ModifyIndex, history.Index)
+
+	// Reading the history requires read access to the key.
+	getR.Token = ""
+	err := msgpackrpc.CallWithCodec(codec, "KVS.History", &getR, &history)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+}
+
+func TestKVS_Changes(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	apply := func(op api.KVOp, key string) {
+		arg := structs.KVSRequest{
+			Datacenter:   "dc1",
+			Op:           op,
+			DirEnt:       structs.DirEntry{Key: key, Value: []byte("test")},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out bool
+		require.NoError(t, s1.RPC("KVS.Apply", &arg, &out))
+	}
+	apply(api.KVSet, "foo/a")
+	apply(api.KVSet, "foo/b")
+	apply(api.KVSet, "zip")
+
+	// Starting without an index gives every key.
+	args := structs.KVSChangesRequest{
+		Datacenter:   "dc1",
+		Prefix:       "foo/",
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}
+	var changes structs.IndexedKVSChanges
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Changes", &args, &changes))
+	require.True(t, changes.Reset)
+	require.Len(t, changes.Events, 2)
+	require.Equal(t, "foo/a", changes.Events[0].Key)
+	require.Equal(t, "foo/b", changes.Events[1].Key)
+
+	// Block until the next change, which is the only one sent.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		apply(api.KVDelete, "foo/a")
+	}()
+	args.MinQueryIndex = changes.Index
+	var blocked structs.IndexedKVSChanges
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Changes", &args, &blocked))
+	require.False(t, blocked.Reset)
+	require.True(t, blocked.Index > changes.Index)
+	require.Len(t, blocked.Events, 1)
+	require.Equal(t, structs.KVSEventDelete, blocked.Events[0].Type)
+	require.Equal(t, "foo/a", blocked.Events[0].Key)
+	require.Equal(t, blocked.Index, blocked.Events[0].ModifyIndex)
+
+	// The keys that can't be read are left out.
+	args.Token = ""
+	args.MinQueryIndex = 0
+	var filtered structs.IndexedKVSChanges
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Changes", &args, &filtered))
+	require.True(t, filtered.Reset)
+	require.Len(t, filtered.Events, 0)
+}
+
 func TestKVSEndpoint_List(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
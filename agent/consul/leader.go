@@ -31,6 +31,10 @@ import (
 const (
 	newLeaderEvent      = "consul:new-leader"
 	barrierWriteTimeout = 2 * time.Minute
+
+	// kvsExpirationBatchSize is the max number of expired KV entries
+	// deleted in a single reaping round.
+	kvsExpirationBatchSize = 128
 )
 
 var (
@@ -273,6 +277,8 @@ func (s *Server) establishLeadership() error {
 
 	s.startCARootPruning()
 
+	s.startKVSExpirationReaping()
+
 	s.setConsistentReadReady()
 	return nil
 }
@@ -297,6 +303,10 @@ func (s *Server) revokeLeadership() error {
 
 	s.stopACLUpgrade()
 
+	s.stopACLTokenReaping()
+
+	s.stopKVSExpirationReaping()
+
 	s.resetConsistentReadReady()
 	s.autopilot.Stop()
 	return nil
@@ -577,6 +587,8 @@ func (s *Server) initializeACLs(upgrade bool) error {
 
 	// launch the upgrade go routine to generate accessors for everything
 
+	s.startACLTokenReaping()
+
 	return nil
 }
 
@@ -738,114 +750,291 @@ func (s *Server) startACLReplication() {
 
 	replicationType := structs.ACLReplicatePolicies
 
-	go func() {
-		var failedAttempts uint
-		limiter := rate.NewLimiter(rate.Limit(s.config.ACLReplicationRate), s.config.ACLReplicationBurst)
+	go s.runACLReplicator(ctx, "policy", s.replicateACLPolicies, s.updateACLReplicationStatusIndex)
+	s.logger.Printf("[INFO] acl: started ACL Policy replication")
 
-		var lastRemoteIndex uint64
-		for {
-			if err := limiter.Wait(ctx); err != nil {
-				return
+	replicationType = structs.ACLReplicateRoles
+
+	go s.runACLReplicator(ctx, "role", s.replicateACLRoles, s.updateACLReplicationStatusRoleIndex)
+	s.logger.Printf("[INFO] acl: started ACL Role replication")
+
+	if s.config.ACLTokenReplication {
+		replicationType = structs.ACLReplicateTokens
+
+		go s.runACLReplicator(ctx, "token", s.replicateACLTokens, s.updateACLReplicationStatusTokenIndex)
+		s.logger.Printf("[INFO] acl: started ACL Token replication")
+	}
+
+	s.updateACLReplicationStatusRunning(replicationType)
+
+	s.aclReplicationEnabled = true
+}
+
+type replicateFunc func(lastRemoteIndex uint64, ctx context.Context) (uint64, bool, error)
+
+// runACLReplicator repeatedly runs one kind of ACL replication until the
+// context is cancelled, backing off exponentially after errors. The
+// updateIndex function records the remote index replicated up to.
+func (s *Server) runACLReplicator(ctx context.Context, desc string, replicate replicateFunc, updateIndex func(uint64)) {
+	var failedAttempts uint
+	limiter := rate.NewLimiter(rate.Limit(s.config.ACLReplicationRate), s.config.ACLReplicationBurst)
+
+	var lastRemoteIndex uint64
+	for {
+		if err := limiter.Wait(ctx); err != nil {
+			return
+		}
+
+		if s.tokens.ReplicationToken() == "" {
+			continue
+		}
+
+		index, exit, err := replicate(lastRemoteIndex, ctx)
+		if exit {
+			return
+		}
+
+		if err != nil {
+			lastRemoteIndex = 0
+			s.updateACLReplicationStatusError()
+			s.logger.Printf("[WARN] consul: ACL %s replication error (will retry if still leader): %v", desc, err)
+			if (1 << failedAttempts) < aclReplicationMaxRetryBackoff {
+				failedAttempts++
 			}
 
-			if s.tokens.ReplicationToken() == "" {
-				continue
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After((1 << failedAttempts) * time.Second):
+				// do nothing
 			}
+		} else {
+			lastRemoteIndex = index
+			updateIndex(index)
+			s.logger.Printf("[DEBUG] consul: ACL %s replication completed through remote index %d", desc, index)
+			failedAttempts = 0
+		}
+	}
+}
 
-			index, exit, err := s.replicateACLPolicies(lastRemoteIndex, ctx)
-			if exit {
+func (s *Server) stopACLReplication() {
+	s.aclReplicationLock.Lock()
+	defer s.aclReplicationLock.Unlock()
+
+	if !s.aclReplicationEnabled {
+		return
+	}
+
+	s.aclReplicationCancel()
+	s.aclReplicationCancel = nil
+	s.updateACLReplicationStatusStopped()
+	s.aclReplicationEnabled = false
+}
+
+func (s *Server) startACLTokenReaping() {
+	s.aclTokenReapLock.Lock()
+	defer s.aclTokenReapLock.Unlock()
+
+	if s.aclTokenReapEnabled {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.aclTokenReapCancel = cancel
+
+	go func() {
+		limiter := rate.NewLimiter(s.config.ACLTokenReapingRate, s.config.ACLTokenReapingBurst)
+
+		for {
+			if err := limiter.Wait(ctx); err != nil {
 				return
 			}
 
-			if err != nil {
-				lastRemoteIndex = 0
-				s.updateACLReplicationStatusError()
-				s.logger.Printf("[WARN] consul: ACL policy replication error (will retry if still leader): %v", err)
-				if (1 << failedAttempts) < aclReplicationMaxRetryBackoff {
-					failedAttempts++
+			if s.LocalTokensEnabled() {
+				if _, err := s.reapExpiredLocalACLTokens(); err != nil {
+					s.logger.Printf("[ERR] acl: error reaping expired local ACL tokens: %v", err)
 				}
-
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After((1 << failedAttempts) * time.Second):
-					// do nothing
+			}
+			if s.InACLDatacenter() {
+				if _, err := s.reapExpiredGlobalACLTokens(); err != nil {
+					s.logger.Printf("[ERR] acl: error reaping expired global ACL tokens: %v", err)
 				}
-			} else {
-				lastRemoteIndex = index
-				s.updateACLReplicationStatusIndex(index)
-				s.logger.Printf("[DEBUG] consul: ACL policy replication completed through remote index %d", index)
-				failedAttempts = 0
 			}
 		}
 	}()
 
-	s.logger.Printf("[INFO] acl: started ACL Policy replication")
+	s.aclTokenReapEnabled = true
+}
 
-	if s.config.ACLTokenReplication {
-		replicationType = structs.ACLReplicateTokens
+func (s *Server) stopACLTokenReaping() {
+	s.aclTokenReapLock.Lock()
+	defer s.aclTokenReapLock.Unlock()
 
-		go func() {
-			var failedAttempts uint
-			limiter := rate.NewLimiter(rate.Limit(s.config.ACLReplicationRate), s.config.ACLReplicationBurst)
-			var lastRemoteIndex uint64
-			for {
-				if err := limiter.Wait(ctx); err != nil {
-					return
-				}
+	if !s.aclTokenReapEnabled {
+		return
+	}
 
-				if s.tokens.ReplicationToken() == "" {
-					continue
-				}
+	s.aclTokenReapCancel()
+	s.aclTokenReapCancel = nil
+	s.aclTokenReapEnabled = false
+}
 
-				index, exit, err := s.replicateACLTokens(lastRemoteIndex, ctx)
-				if exit {
-					return
-				}
+func (s *Server) reapExpiredGlobalACLTokens() (int, error) {
+	return s.reapExpiredACLTokens(false, true)
+}
 
-				if err != nil {
-					lastRemoteIndex = 0
-					s.updateACLReplicationStatusError()
-					s.logger.Printf("[WARN] consul: ACL token replication error (will retry if still leader): %v", err)
-					if (1 << failedAttempts) < aclReplicationMaxRetryBackoff {
-						failedAttempts++
-					}
+func (s *Server) reapExpiredLocalACLTokens() (int, error) {
+	return s.reapExpiredACLTokens(true, false)
+}
 
-					select {
-					case <-ctx.Done():
-						return
-					case <-time.After((1 << failedAttempts) * time.Second):
-						// do nothing
-					}
-				} else {
-					lastRemoteIndex = index
-					s.updateACLReplicationStatusTokenIndex(index)
-					s.logger.Printf("[DEBUG] consul: ACL token replication completed through remote index %d", index)
-					failedAttempts = 0
-				}
-			}
-		}()
+// reapExpiredACLTokens deletes a single batch of expired tokens of the given
+// locality and returns how many were deleted. Global tokens are only reaped
+// in the primary datacenter; secondaries pick up the deletions through token
+// replication.
+func (s *Server) reapExpiredACLTokens(local, global bool) (int, error) {
+	if !s.ACLsEnabled() {
+		return 0, nil
+	}
+	if s.UseLegacyACLs() {
+		return 0, nil
+	}
+	if local == global {
+		return 0, fmt.Errorf("cannot reap both local and global tokens in the same request")
+	}
 
-		s.logger.Printf("[INFO] acl: started ACL Token replication")
+	locality := "global"
+	if local {
+		locality = "local"
 	}
 
-	s.updateACLReplicationStatusRunning(replicationType)
+	state := s.fsm.State()
+	tokens, err := state.ACLTokenListExpired(local, time.Now(), aclBatchDeleteSize)
+	if err != nil {
+		return 0, err
+	}
 
-	s.aclReplicationEnabled = true
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+
+	defer metrics.MeasureSince([]string{"leader", "reapExpiredACLTokens"}, time.Now())
+
+	var (
+		secretIDs []string
+		req       structs.ACLTokenBatchDeleteRequest
+	)
+	for _, token := range tokens {
+		if token.Local != local {
+			return 0, fmt.Errorf("expired index for local=%v returned a mismatched token with local=%v: %s", local, token.Local, token.AccessorID)
+		}
+		req.TokenIDs = append(req.TokenIDs, token.AccessorID)
+		secretIDs = append(secretIDs, token.SecretID)
+	}
+
+	s.logger.Printf("[INFO] acl: deleting %d expired %s tokens", len(req.TokenIDs), locality)
+	resp, err := s.raftApply(structs.ACLTokenDeleteRequestType, &req)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to apply token expiration deletions: %v", err)
+	}
+
+	// Purge the identities from the cache
+	for _, secretID := range secretIDs {
+		s.acls.cache.RemoveIdentity(secretID)
+	}
+
+	if respErr, ok := resp.(error); ok {
+		return 0, respErr
+	}
+
+	return len(req.TokenIDs), nil
 }
 
-func (s *Server) stopACLReplication() {
-	s.aclReplicationLock.Lock()
-	defer s.aclReplicationLock.Unlock()
+func (s *Server) startKVSExpirationReaping() {
+	s.kvsExpirationReapLock.Lock()
+	defer s.kvsExpirationReapLock.Unlock()
 
-	if !s.aclReplicationEnabled {
+	if s.kvsExpirationReapEnabled {
 		return
 	}
 
-	s.aclReplicationCancel()
-	s.aclReplicationCancel = nil
-	s.updateACLReplicationStatusStopped()
-	s.aclReplicationEnabled = false
+	ctx, cancel := context.WithCancel(context.Background())
+	s.kvsExpirationReapCancel = cancel
+
+	go func() {
+		limiter := rate.NewLimiter(s.config.KVSExpiryReapingRate, s.config.KVSExpiryReapingBurst)
+
+		for {
+			if err := limiter.Wait(ctx); err != nil {
+				return
+			}
+
+			if _, err := s.reapExpiredKVs(); err != nil {
+				s.logger.Printf("[ERR] consul.kvs: error reaping expired KV entries: %v", err)
+			}
+		}
+	}()
+
+	s.kvsExpirationReapEnabled = true
+}
+
+func (s *Server) stopKVSExpirationReaping() {
+	s.kvsExpirationReapLock.Lock()
+	defer s.kvsExpirationReapLock.Unlock()
+
+	if !s.kvsExpirationReapEnabled {
+		return
+	}
+
+	s.kvsExpirationReapCancel()
+	s.kvsExpirationReapCancel = nil
+	s.kvsExpirationReapEnabled = false
+}
+
+// reapExpiredKVs deletes a single batch of expired KV entries and returns
+// how many were deleted. Each entry is deleted with a check-and-set on its
+// modify index so an entry that was written again after it was listed is
+// left alone. The deletes leave tombstones like any other, so blocking
+// queries on the keys return.
+func (s *Server) reapExpiredKVs() (int, error) {
+	state := s.fsm.State()
+	entries, err := state.KVSListExpired(time.Now(), kvsExpirationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	defer metrics.MeasureSince([]string{"leader", "reapExpiredKVs"}, time.Now())
+
+	var deleted int
+	for _, entry := range entries {
+		req := structs.KVSRequest{
+			Datacenter: s.config.Datacenter,
+			Op:         api.KVDeleteCAS,
+			DirEnt: structs.DirEntry{
+				Key: entry.Key,
+				RaftIndex: structs.RaftIndex{
+					ModifyIndex: entry.ModifyIndex,
+				},
+			},
+		}
+		resp, err := s.raftApply(structs.KVSRequestType, &req)
+		if err != nil {
+			return deleted, fmt.Errorf("Failed to apply KV expiration deletion: %v", err)
+		}
+		if respErr, ok := resp.(error); ok {
+			return deleted, respErr
+		}
+		if ok, _ := resp.(bool); ok {
+			deleted++
+		}
+	}
+
+	s.logger.Printf("[DEBUG] consul.kvs: deleted %d expired KV entries", deleted)
+	metrics.IncrCounter([]string{"kvs", "expired"}, float32(deleted))
+	return deleted, nil
 }
 
 // getOrCreateAutopilotConfig is used to get the autopilot config, initializing it if necessary
@@ -1272,6 +1461,13 @@ func (s *Server) handleAliveMember(member serf.Member) error {
 			Port:    parts.Port,
 		}
 
+		// Every server records the KV history as it applies writes, so
+		// they'll disagree about it if they're configured differently.
+		if member.Tags["kv_history"] != kvsHistoryConfigHash(s.config.KVSHistory) {
+			s.logger.Printf("[WARN] consul: server %s has a different kv_history configuration than this server, "+
+				"the KV history will differ between servers until they all use the same one", member.Name)
+		}
+
 		// Attempt to join the consul server
 		if err := s.joinConsulServer(member, parts); err != nil {
 			return err
@@ -786,6 +786,62 @@ func TestLeader_ReapTombstones(t *testing.T) {
 	})
 }
 
+func TestLeader_KVSExpiryReaping(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.KVSExpiryReapingRate = 100
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	setKey := func(key string, ttl time.Duration) {
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt: structs.DirEntry{
+				Key:           key,
+				Value:         []byte("test"),
+				ExpirationTTL: ttl,
+			},
+		}
+		var out bool
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out))
+	}
+
+	setKey("expiring", 200*time.Millisecond)
+	setKey("lasting", time.Hour)
+	setKey("forever", 0)
+
+	// Writing a key again without a TTL clears its expiration.
+	setKey("rewritten", 200*time.Millisecond)
+	setKey("rewritten", 0)
+
+	state := s1.fsm.State()
+	idx, _, err := state.KVSGet(nil, "expiring")
+	require.NoError(t, err)
+
+	retry.Run(t, func(r *retry.R) {
+		_, got, err := state.KVSGet(nil, "expiring")
+		require.NoError(r, err)
+		require.Nil(r, got)
+	})
+
+	// The delete leaves a tombstone so blocking queries on the key return
+	// with a higher index.
+	newIdx, _, err := state.KVSGet(nil, "expiring")
+	require.NoError(t, err)
+	require.True(t, newIdx > idx, "index %d should be greater than %d", newIdx, idx)
+
+	for _, key := range []string{"lasting", "forever", "rewritten"} {
+		_, got, err := state.KVSGet(nil, key)
+		require.NoError(t, err)
+		require.NotNil(t, got, key)
+	}
+}
+
 func TestLeader_RollRaftServer(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServerWithConfig(t, func(c *Config) {
@@ -1206,3 +1262,55 @@ func TestLeader_ACLUpgrade(t *testing.T) {
 		require.Equal(t, client.ACL.Rules, token.Rules)
 	})
 }
+
+func TestLeader_ACL_TokenReaping(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLTokenMinExpirationTTL = 10 * time.Millisecond
+		c.ACLTokenReapingRate = 100
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	createToken := func(local bool, ttl time.Duration) *structs.ACLToken {
+		req := structs.ACLTokenSetRequest{
+			Datacenter: "dc1",
+			ACLToken: structs.ACLToken{
+				Description:   "expiring",
+				Local:         local,
+				ExpirationTTL: ttl,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out structs.ACLToken
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.TokenSet", &req, &out))
+		return &out
+	}
+
+	localExpiring := createToken(true, 200*time.Millisecond)
+	globalExpiring := createToken(false, 200*time.Millisecond)
+	localLasting := createToken(true, time.Hour)
+	globalLasting := createToken(false, time.Hour)
+
+	state := s1.fsm.State()
+
+	retry.Run(t, func(r *retry.R) {
+		for _, token := range []*structs.ACLToken{localExpiring, globalExpiring} {
+			_, got, err := state.ACLTokenGetByAccessor(nil, token.AccessorID)
+			require.NoError(r, err)
+			require.Nil(r, got)
+		}
+	})
+
+	for _, token := range []*structs.ACLToken{localLasting, globalLasting} {
+		_, got, err := state.ACLTokenGetByAccessor(nil, token.AccessorID)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/rpc"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -18,6 +20,7 @@ import (
 	"github.com/hashicorp/memberlist"
 	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/hashicorp/yamux"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -43,6 +46,11 @@ const (
 	// value is ever reached. However, it prevents us from blocking
 	// the requesting goroutine forever.
 	enqueueLimit = 30 * time.Second
+
+	// rpcSourceLimiterIdle is how long a per-source RPC limiter can go
+	// unused before it is dropped. An idle limiter has refilled its bucket
+	// by then, so dropping it doesn't change its behavior.
+	rpcSourceLimiterIdle = 5 * time.Minute
 )
 
 // listen is used to listen for incoming RPC connections
@@ -144,7 +152,7 @@ func (s *Server) handleMultiplexV2(conn net.Conn) {
 // handleConsulConn is used to service a single Consul RPC connection
 func (s *Server) handleConsulConn(conn net.Conn) {
 	defer conn.Close()
-	rpcCodec := msgpackrpc.NewServerCodec(conn)
+	rpcCodec := s.limitedCodec(conn, msgpackrpc.NewServerCodec(conn))
 	for {
 		select {
 		case <-s.shutdownCh:
@@ -174,6 +182,196 @@ func (s *Server) handleSnapshotConn(conn net.Conn) {
 	}()
 }
 
+// rpcLimiter enforces the server-side RPC quotas. Requests arriving over
+// the network are checked against a global token bucket and a token bucket
+// per source IP, and blocking queries are capped by the number in flight.
+// Requests from other servers in the local datacenter are exempt from both
+// buckets, so that client load can't get forwarded writes or autopilot's
+// health checks between servers rejected.
+type rpcLimiter struct {
+	sync.Mutex
+
+	global      *rate.Limiter
+	sourceRate  rate.Limit
+	sourceBurst int
+	sources     map[string]*rpcSourceLimiter
+	lastPrune   time.Time
+
+	maxBlocking int
+	blocking    int
+}
+
+// rpcSourceLimiter is the token bucket for a single source IP.
+type rpcSourceLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newRPCLimiter returns a limiter set up with the quotas in config.
+func newRPCLimiter(config *Config) *rpcLimiter {
+	l := &rpcLimiter{
+		sources: make(map[string]*rpcSourceLimiter),
+	}
+	l.reload(config)
+	return l
+}
+
+// reload applies new quotas. The per-source buckets are reset so that they
+// pick up the new rate.
+func (l *rpcLimiter) reload(config *Config) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.global = rate.NewLimiter(config.RPCServerRate, config.RPCServerMaxBurst)
+	l.sourceRate = config.RPCServerSourceRate
+	l.sourceBurst = config.RPCServerSourceMaxBurst
+	l.sources = make(map[string]*rpcSourceLimiter)
+	l.maxBlocking = config.RPCMaxBlockingQueries
+}
+
+// allow returns an error if an RPC from the given source IP should be
+// rejected. Exempt sources are never rejected and don't take tokens from
+// either bucket.
+func (l *rpcLimiter) allow(source string, exempt bool) error {
+	if exempt {
+		return nil
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if !l.global.Allow() {
+		metrics.IncrCounterWithLabels([]string{"rpc", "rate_limit", "exceeded"}, 1,
+			[]metrics.Label{{Name: "limit", Value: "global"}})
+		return structs.ErrRPCServerRateExceeded
+	}
+	if l.sourceRate == rate.Inf {
+		return nil
+	}
+
+	now := time.Now()
+	if now.Sub(l.lastPrune) > rpcSourceLimiterIdle {
+		for ip, sl := range l.sources {
+			if now.Sub(sl.lastSeen) > rpcSourceLimiterIdle {
+				delete(l.sources, ip)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	sl, ok := l.sources[source]
+	if !ok {
+		sl = &rpcSourceLimiter{limiter: rate.NewLimiter(l.sourceRate, l.sourceBurst)}
+		l.sources[source] = sl
+	}
+	sl.lastSeen = now
+	if !sl.limiter.AllowN(now, 1) {
+		metrics.IncrCounterWithLabels([]string{"rpc", "rate_limit", "exceeded"}, 1,
+			[]metrics.Label{{Name: "limit", Value: "source"}})
+		return structs.ErrRPCServerRateExceeded
+	}
+	return nil
+}
+
+// acquireBlocking reserves a slot for a blocking query, returning false if
+// the cap on in-flight blocking queries has been reached. Each successful
+// call must be paired with a call to releaseBlocking.
+func (l *rpcLimiter) acquireBlocking() bool {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.maxBlocking > 0 && l.blocking >= l.maxBlocking {
+		metrics.IncrCounterWithLabels([]string{"rpc", "rate_limit", "exceeded"}, 1,
+			[]metrics.Label{{Name: "limit", Value: "blocking_queries"}})
+		return false
+	}
+	l.blocking++
+	metrics.SetGauge([]string{"rpc", "queries_blocking"}, float32(l.blocking))
+	return true
+}
+
+// releaseBlocking returns a slot reserved with acquireBlocking.
+func (l *rpcLimiter) releaseBlocking() {
+	l.Lock()
+	defer l.Unlock()
+
+	l.blocking--
+	metrics.SetGauge([]string{"rpc", "queries_blocking"}, float32(l.blocking))
+}
+
+// limitedServerCodec wraps a server codec so that requests exceeding the
+// server's RPC quotas are answered with an error before they are dispatched
+// to an endpoint.
+type limitedServerCodec struct {
+	rpc.ServerCodec
+
+	limiter *rpcLimiter
+	source  string
+
+	// exempt is checked for every request rather than once for the
+	// connection, as servers can join or leave while it's open.
+	exempt func(ip string) bool
+}
+
+// limitedCodec wraps the codec for a connection with the server's RPC
+// limiter. Connections from other servers in the local datacenter are exempt
+// from the limits.
+func (s *Server) limitedCodec(conn net.Conn, codec rpc.ServerCodec) rpc.ServerCodec {
+	source := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(source); err == nil {
+		source = host
+	}
+	return &limitedServerCodec{
+		ServerCodec: codec,
+		limiter:     s.rpcLimiter,
+		source:      source,
+		exempt:      s.isLocalServerIP,
+	}
+}
+
+// isLocalServerIP returns true if the given IP belongs to another known
+// server in the local datacenter. This server is skipped as it never makes
+// RPCs to itself over the network.
+func (s *Server) isLocalServerIP(ip string) bool {
+	for _, server := range s.serverLookup.Servers() {
+		if server.Name == s.config.NodeName {
+			continue
+		}
+		if addr, ok := server.Addr.(*net.TCPAddr); ok && addr.IP.String() == ip {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadRequestHeader reads the next request that is within the server's RPC
+// quotas. Requests over quota have their body discarded and are answered
+// directly with ErrRPCServerRateExceeded.
+func (c *limitedServerCodec) ReadRequestHeader(r *rpc.Request) error {
+	for {
+		if err := c.ServerCodec.ReadRequestHeader(r); err != nil {
+			return err
+		}
+
+		limitErr := c.limiter.allow(c.source, c.exempt(c.source))
+		if limitErr == nil {
+			return nil
+		}
+
+		if err := c.ServerCodec.ReadRequestBody(nil); err != nil {
+			return err
+		}
+		resp := rpc.Response{
+			ServiceMethod: r.ServiceMethod,
+			Seq:           r.Seq,
+			Error:         limitErr.Error(),
+		}
+		if err := c.ServerCodec.WriteResponse(&resp, struct{}{}); err != nil {
+			return err
+		}
+	}
+}
+
 // canRetry returns true if the given situation is safe for a retry.
 func canRetry(args interface{}, err error) bool {
 	// No leader errors are always safe to retry since no state could have
@@ -182,6 +380,12 @@ func canRetry(args interface{}, err error) bool {
 		return true
 	}
 
+	// Requests rejected by a server's RPC quotas never reach an endpoint,
+	// so they are safe to retry as well.
+	if structs.IsErrRPCServerRateExceeded(err) {
+		return true
+	}
+
 	// Reads are safe to retry for stream errors, such as if a server was
 	// being shut down.
 	info, ok := args.(structs.RPCInfo)
@@ -335,6 +539,12 @@ func (s *Server) globalRPC(method string, args interface{},
 // raftApply is used to encode a message, run it through raft, and return
 // the FSM response along with any errors
 func (s *Server) raftApply(t structs.MessageType, msg interface{}) (interface{}, error) {
+	// Wall-time can't be used while applying, since each server would see a
+	// different one, so writes are stamped with the leader's time instead.
+	if w, ok := msg.(interface{ SetApplyTime(time.Time) }); ok {
+		w.SetApplyTime(time.Now().UTC())
+	}
+
 	buf, err := structs.Encode(t, msg)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to encode request: %v", err)
@@ -377,6 +587,12 @@ func (s *Server) blockingQuery(queryOpts *structs.QueryOptions, queryMeta *struc
 		queryOpts.MaxQueryTime = defaultQueryTime
 	}
 
+	// Enforce the cap on in-flight blocking queries.
+	if !s.rpcLimiter.acquireBlocking() {
+		return structs.ErrRPCServerRateExceeded
+	}
+	defer s.rpcLimiter.releaseBlocking()
+
 	// Apply a small amount of jitter to the request.
 	queryOpts.MaxQueryTime += lib.RandomStagger(queryOpts.MaxQueryTime / jitterFraction)
 
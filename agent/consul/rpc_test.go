@@ -3,17 +3,20 @@ package consul
 import (
 	"bytes"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/consul/agent/consul/state"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 func TestRPC_NoLeader_Fail(t *testing.T) {
@@ -315,3 +318,147 @@ func TestRPC_ReadyForConsistentReads(t *testing.T) {
 		}
 	})
 }
+
+func TestRPC_ServerRateLimit(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCServerRate = rate.Every(time.Hour)
+		c.RPCServerMaxBurst = 2
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	// The first calls fit in the burst.
+	var out struct{}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out))
+	}
+
+	// The next one is rejected, and the connection stays usable.
+	for i := 0; i < 2; i++ {
+		err := msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out)
+		require.True(t, structs.IsErrRPCServerRateExceeded(err), "bad: %v", err)
+	}
+
+	// Reloading the config resets the limits.
+	s1.config.RPCServerRate = rate.Inf
+	require.NoError(t, s1.ReloadConfig(s1.config))
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out))
+}
+
+func TestRPC_ServerRateLimit_LocalServersExempt(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCServerRate = rate.Every(time.Hour)
+		c.RPCServerMaxBurst = 0
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// The global bucket is empty, so other callers are rejected.
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+	var out struct{}
+	err := msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out)
+	require.True(t, structs.IsErrRPCServerRateExceeded(err), "bad: %v", err)
+
+	dir2, s2 := testServerDCBootstrap(t, "dc1", false)
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+	joinLAN(t, s2, s1)
+	testrpc.WaitForLeader(t, s2.RPC, "dc1")
+
+	// A write forwarded by another server still gets to the leader.
+	arg := structs.KVSRequest{
+		Datacenter: "dc1",
+		Op:         api.KVSet,
+		DirEnt: structs.DirEntry{
+			Key:   "test",
+			Value: []byte("test"),
+		},
+	}
+	var applied bool
+	require.NoError(t, s2.RPC("KVS.Apply", &arg, &applied))
+	_, d, err := s1.fsm.State().KVSGet(nil, "test")
+	require.NoError(t, err)
+	require.NotNil(t, d)
+}
+
+func TestRPC_rpcLimiter(t *testing.T) {
+	t.Parallel()
+
+	config := DefaultConfig()
+	config.RPCServerSourceRate = rate.Every(time.Hour)
+	config.RPCServerSourceMaxBurst = 1
+	config.RPCMaxBlockingQueries = 1
+	l := newRPCLimiter(config)
+
+	t.Run("per source", func(t *testing.T) {
+		require.NoError(t, l.allow("10.0.0.1", false))
+		require.Equal(t, structs.ErrRPCServerRateExceeded, l.allow("10.0.0.1", false))
+
+		// Other sources have their own bucket.
+		require.NoError(t, l.allow("10.0.0.2", false))
+
+		// Exempt sources skip the limits.
+		require.NoError(t, l.allow("10.0.0.1", true))
+	})
+
+	t.Run("blocking queries", func(t *testing.T) {
+		require.True(t, l.acquireBlocking())
+		require.False(t, l.acquireBlocking())
+		l.releaseBlocking()
+		require.True(t, l.acquireBlocking())
+		l.releaseBlocking()
+	})
+}
+
+func TestRPC_blockingQuery_maxBlocking(t *testing.T) {
+	t.Parallel()
+	dir, s := testServerWithConfig(t, func(c *Config) {
+		c.RPCMaxBlockingQueries = 1
+	})
+	defer os.RemoveAll(dir)
+	defer s.Shutdown()
+
+	var once sync.Once
+	started := make(chan struct{})
+	fn := func(ws memdb.WatchSet, state *state.Store) error {
+		once.Do(func() { close(started) })
+		ws.Add(make(chan struct{}))
+		return nil
+	}
+
+	// Start a blocking query that holds the only slot until it times out.
+	errCh := make(chan error, 1)
+	go func() {
+		opts := structs.QueryOptions{
+			MinQueryIndex: 10,
+			MaxQueryTime:  500 * time.Millisecond,
+		}
+		var meta structs.QueryMeta
+		errCh <- s.blockingQuery(&opts, &meta, fn)
+	}()
+	<-started
+
+	// A second blocking query is over the cap.
+	opts := structs.QueryOptions{MinQueryIndex: 10}
+	var meta structs.QueryMeta
+	err := s.blockingQuery(&opts, &meta, fn)
+	require.Equal(t, structs.ErrRPCServerRateExceeded, err)
+
+	// Non-blocking queries aren't subject to the cap.
+	opts = structs.QueryOptions{}
+	require.NoError(t, s.blockingQuery(&opts, &meta, fn))
+
+	// Once the first query returns its slot is free again.
+	require.NoError(t, <-errCh)
+	opts = structs.QueryOptions{
+		MinQueryIndex: 10,
+		MaxQueryTime:  10 * time.Millisecond,
+	}
+	require.NoError(t, s.blockingQuery(&opts, &meta, fn))
+}
@@ -2,6 +2,10 @@ package consul
 
 import (
 	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUserEventNames(t *testing.T) {
@@ -20,3 +24,24 @@ func TestUserEventNames(t *testing.T) {
 		t.Fatalf("bad: %v", raw)
 	}
 }
+
+func TestKVSHistoryConfigHash(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "", kvsHistoryConfigHash(nil))
+
+	a := []structs.KVSHistoryConfig{
+		{Prefix: "foo/", MaxVersions: 3},
+		{Prefix: "bar/", Retention: time.Hour},
+	}
+	hash := kvsHistoryConfigHash(a)
+	require.NotEmpty(t, hash)
+
+	// The order of the prefixes doesn't matter.
+	b := []structs.KVSHistoryConfig{a[1], a[0]}
+	require.Equal(t, hash, kvsHistoryConfigHash(b))
+	require.Equal(t, "foo/", a[0].Prefix)
+
+	// Any other change does.
+	b[0].Retention = 2 * time.Hour
+	require.NotEqual(t, hash, kvsHistoryConfigHash(b))
+}
@@ -109,6 +109,18 @@ type Server struct {
 	aclReplicationLock    sync.RWMutex
 	aclReplicationEnabled bool
 
+	// aclTokenReapCancel is used to shut down the ACL token expiration reap
+	// goroutine when we lose leadership.
+	aclTokenReapCancel  context.CancelFunc
+	aclTokenReapLock    sync.RWMutex
+	aclTokenReapEnabled bool
+
+	// kvsExpirationReapCancel is used to shut down the KV expiration reap
+	// goroutine when we lose leadership.
+	kvsExpirationReapCancel  context.CancelFunc
+	kvsExpirationReapLock    sync.RWMutex
+	kvsExpirationReapEnabled bool
+
 	// DEPRECATED (ACL-Legacy-Compat) - only needed while we support both
 	// useNewACLs is used to determine whether we can use new ACLs or not
 	useNewACLs int32
@@ -197,6 +209,10 @@ type Server struct {
 	Listener  net.Listener
 	rpcServer *rpc.Server
 
+	// rpcLimiter enforces the server-side RPC quotas on requests arriving
+	// over the network, and caps the number of blocking queries in flight.
+	rpcLimiter *rpcLimiter
+
 	// rpcTLS is the TLS config for incoming TLS requests
 	rpcTLS *tls.Config
 
@@ -332,6 +348,7 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 		reconcileCh:      make(chan serf.Member, reconcileChSize),
 		router:           router.NewRouter(logger, config.Datacenter),
 		rpcServer:        rpc.NewServer(),
+		rpcLimiter:       newRPCLimiter(config),
 		rpcTLS:           tlsConfigurator.IncomingRPCConfig(),
 		reassertLeaderCh: make(chan chan error),
 		segmentLAN:       make(map[string]*serf.Serf, len(config.Segments)),
@@ -494,6 +511,7 @@ func (s *Server) setupRaft() error {
 	if err != nil {
 		return err
 	}
+	s.fsm.SetKVSHistoryConfig(s.config.KVSHistory)
 
 	var serverAddressProvider raft.ServerAddressProvider = nil
 	if s.config.RaftConfig.ProtocolVersion >= 3 { //ServerAddressProvider needs server ids to work correctly, which is only supported in protocol version 3 or higher
@@ -602,6 +620,7 @@ func (s *Server) setupRaft() error {
 			if err != nil {
 				return fmt.Errorf("recovery failed to make temp FSM: %v", err)
 			}
+			tmpFsm.SetKVSHistoryConfig(s.config.KVSHistory)
 			if err := raft.RecoverCluster(s.config.RaftConfig, tmpFsm,
 				log, stable, snap, trans, configuration); err != nil {
 				return fmt.Errorf("recovery failed: %v", err)
@@ -1115,6 +1134,7 @@ func (s *Server) GetLANCoordinate() (lib.CoordinateSet, error) {
 // ReloadConfig is used to have the Server do an online reload of
 // relevant configuration information
 func (s *Server) ReloadConfig(config *Config) error {
+	s.rpcLimiter.reload(config)
 	return nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/raft"
 	"github.com/hashicorp/serf/serf"
+	"github.com/mitchellh/hashstructure"
 )
 
 const (
@@ -70,6 +72,9 @@ func (s *Server) setupSerf(conf *serf.Config, ch chan serf.Event, path string, w
 	if s.config.UseTLS {
 		conf.Tags["use_tls"] = "1"
 	}
+	if hash := kvsHistoryConfigHash(s.config.KVSHistory); hash != "" {
+		conf.Tags["kv_history"] = hash
+	}
 
 	if s.acls.ACLsEnabled() {
 		// we start in legacy mode and allow upgrading later
@@ -368,3 +373,23 @@ func (s *Server) lanNodeFailed(me serf.MemberEvent) {
 		s.serverLookup.RemoveServer(serverMeta)
 	}
 }
+
+// kvsHistoryConfigHash returns a hash of the KV history configuration so that
+// servers can tell if they're configured differently, or "" if no history is
+// kept. The order of the prefixes doesn't change the hash.
+func kvsHistoryConfigHash(config []structs.KVSHistoryConfig) string {
+	if len(config) == 0 {
+		return ""
+	}
+	sorted := make([]structs.KVSHistoryConfig, len(config))
+	copy(sorted, config)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Prefix < sorted[j].Prefix
+	})
+	hash, err := hashstructure.Hash(sorted, nil)
+	if err != nil {
+		// This can only fail for types hashstructure doesn't support.
+		panic(err)
+	}
+	return fmt.Sprintf("%x", hash)
+}
@@ -1,7 +1,9 @@
 package state
 
 import (
+	"encoding/binary"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/go-memdb"
@@ -58,6 +60,130 @@ func (s *TokenPoliciesIndex) PrefixFromArgs(args ...interface{}) ([]byte, error)
 	return val, nil
 }
 
+type TokenRolesIndex struct {
+}
+
+func (s *TokenRolesIndex) FromObject(obj interface{}) (bool, [][]byte, error) {
+	token, ok := obj.(*structs.ACLToken)
+	if !ok {
+		return false, nil, fmt.Errorf("object is not an ACLToken")
+	}
+
+	links := token.Roles
+
+	numLinks := len(links)
+	if numLinks == 0 {
+		return false, nil, nil
+	}
+
+	vals := make([][]byte, 0, numLinks)
+	for _, link := range links {
+		vals = append(vals, []byte(link.ID+"\x00"))
+	}
+
+	return true, vals, nil
+}
+
+func (s *TokenRolesIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	return (&TokenPoliciesIndex{}).FromArgs(args...)
+}
+
+func (s *TokenRolesIndex) PrefixFromArgs(args ...interface{}) ([]byte, error) {
+	return (&TokenPoliciesIndex{}).PrefixFromArgs(args...)
+}
+
+// TokenExpirationIndex indexes the tokens of a single locality (local or
+// global) that have an expiration time by that time, so expired tokens can be
+// iterated oldest first.
+type TokenExpirationIndex struct {
+	LocalFilter bool
+}
+
+func (s *TokenExpirationIndex) encodeTime(t time.Time) []byte {
+	val := t.Unix()
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(val))
+	return buf
+}
+
+func (s *TokenExpirationIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	token, ok := obj.(*structs.ACLToken)
+	if !ok {
+		return false, nil, fmt.Errorf("object is not an ACLToken")
+	}
+	if s.LocalFilter != token.Local {
+		return false, nil, nil
+	}
+	if !token.HasExpirationTime() {
+		return false, nil, nil
+	}
+	if token.ExpirationTime.Unix() < 0 {
+		return false, nil, fmt.Errorf("token expiration time cannot be before the unix epoch: %s", token.ExpirationTime)
+	}
+
+	buf := s.encodeTime(*token.ExpirationTime)
+
+	return true, buf, nil
+}
+
+func (s *TokenExpirationIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+	arg, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a time.Time: %#v", args[0])
+	}
+	if arg.Unix() < 0 {
+		return nil, fmt.Errorf("argument must be a time.Time after the unix epoch: %s", args[0])
+	}
+
+	buf := s.encodeTime(arg)
+
+	return buf, nil
+}
+
+func (s *TokenExpirationIndex) PrefixFromArgs(args ...interface{}) ([]byte, error) {
+	// Listing with no arguments walks every indexed token in expiration
+	// order.
+	if len(args) == 0 {
+		return nil, nil
+	}
+	return s.FromArgs(args...)
+}
+
+type RolePoliciesIndex struct {
+}
+
+func (s *RolePoliciesIndex) FromObject(obj interface{}) (bool, [][]byte, error) {
+	role, ok := obj.(*structs.ACLRole)
+	if !ok {
+		return false, nil, fmt.Errorf("object is not an ACLRole")
+	}
+
+	links := role.Policies
+
+	numLinks := len(links)
+	if numLinks == 0 {
+		return false, nil, nil
+	}
+
+	vals := make([][]byte, 0, numLinks)
+	for _, link := range links {
+		vals = append(vals, []byte(link.ID+"\x00"))
+	}
+
+	return true, vals, nil
+}
+
+func (s *RolePoliciesIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	return (&TokenPoliciesIndex{}).FromArgs(args...)
+}
+
+func (s *RolePoliciesIndex) PrefixFromArgs(args ...interface{}) ([]byte, error) {
+	return (&TokenPoliciesIndex{}).PrefixFromArgs(args...)
+}
+
 func tokensTableSchema() *memdb.TableSchema {
 	return &memdb.TableSchema{
 		Name: "acl-tokens",
@@ -87,6 +213,21 @@ func tokensTableSchema() *memdb.TableSchema {
 				Unique:       false,
 				Indexer:      &TokenPoliciesIndex{},
 			},
+			"roles": &memdb.IndexSchema{
+				Name:         "roles",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer:      &TokenRolesIndex{},
+			},
+			"authmethod": &memdb.IndexSchema{
+				Name:         "authmethod",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "AuthMethod",
+					Lowercase: false,
+				},
+			},
 			"local": &memdb.IndexSchema{
 				Name:         "local",
 				AllowMissing: false,
@@ -100,6 +241,18 @@ func tokensTableSchema() *memdb.TableSchema {
 					},
 				},
 			},
+			"expires-global": &memdb.IndexSchema{
+				Name:         "expires-global",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer:      &TokenExpirationIndex{LocalFilter: false},
+			},
+			"expires-local": &memdb.IndexSchema{
+				Name:         "expires-local",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer:      &TokenExpirationIndex{LocalFilter: true},
+			},
 
 			//DEPRECATED (ACL-Legacy-Compat) - This index is only needed while we support upgrading v1 to v2 acls
 			// This table indexes all the ACL tokens that do not have an AccessorID
@@ -146,9 +299,86 @@ func policiesTableSchema() *memdb.TableSchema {
 	}
 }
 
+func rolesTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "acl-roles",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UUIDFieldIndex{
+					Field: "ID",
+				},
+			},
+			"name": &memdb.IndexSchema{
+				Name:         "name",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Name",
+					Lowercase: true,
+				},
+			},
+			"policies": &memdb.IndexSchema{
+				Name: "policies",
+				// Need to allow missing for roles without policies
+				AllowMissing: true,
+				Unique:       false,
+				Indexer:      &RolePoliciesIndex{},
+			},
+		},
+	}
+}
+
+func authMethodsTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "acl-auth-methods",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Name",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+func bindingRulesTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "acl-binding-rules",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UUIDFieldIndex{
+					Field: "ID",
+				},
+			},
+			"authmethod": &memdb.IndexSchema{
+				Name:         "authmethod",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "AuthMethod",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
 func init() {
 	registerSchema(tokensTableSchema)
 	registerSchema(policiesTableSchema)
+	registerSchema(rolesTableSchema)
+	registerSchema(authMethodsTableSchema)
+	registerSchema(bindingRulesTableSchema)
 }
 
 // ACLTokens is used when saving a snapshot
@@ -193,6 +423,66 @@ func (s *Restore) ACLPolicy(policy *structs.ACLPolicy) error {
 	return nil
 }
 
+// ACLRoles is used when saving a snapshot
+func (s *Snapshot) ACLRoles() (memdb.ResultIterator, error) {
+	iter, err := s.tx.Get("acl-roles", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+func (s *Restore) ACLRole(role *structs.ACLRole) error {
+	if err := s.tx.Insert("acl-roles", role); err != nil {
+		return fmt.Errorf("failed restoring acl role: %s", err)
+	}
+
+	if err := indexUpdateMaxTxn(s.tx, role.ModifyIndex, "acl-roles"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// ACLAuthMethods is used when saving a snapshot
+func (s *Snapshot) ACLAuthMethods() (memdb.ResultIterator, error) {
+	iter, err := s.tx.Get("acl-auth-methods", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+func (s *Restore) ACLAuthMethod(method *structs.ACLAuthMethod) error {
+	if err := s.tx.Insert("acl-auth-methods", method); err != nil {
+		return fmt.Errorf("failed restoring acl auth method: %s", err)
+	}
+
+	if err := indexUpdateMaxTxn(s.tx, method.ModifyIndex, "acl-auth-methods"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// ACLBindingRules is used when saving a snapshot
+func (s *Snapshot) ACLBindingRules() (memdb.ResultIterator, error) {
+	iter, err := s.tx.Get("acl-binding-rules", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+func (s *Restore) ACLBindingRule(rule *structs.ACLBindingRule) error {
+	if err := s.tx.Insert("acl-binding-rules", rule); err != nil {
+		return fmt.Errorf("failed restoring acl binding rule: %s", err)
+	}
+
+	if err := indexUpdateMaxTxn(s.tx, rule.ModifyIndex, "acl-binding-rules"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
 // ACLBootstrap is used to perform a one-time ACL bootstrap operation on a
 // cluster to get the first management token.
 func (s *Store) ACLBootstrap(idx, resetIndex uint64, token *structs.ACLToken, legacy bool) error {
@@ -266,6 +556,38 @@ func (s *Store) resolveTokenPolicyLinks(tx *memdb.Txn, token *structs.ACLToken,
 	return nil
 }
 
+func (s *Store) resolveTokenRoleLinks(tx *memdb.Txn, token *structs.ACLToken, allowMissing bool) error {
+	for linkIndex, link := range token.Roles {
+		if link.ID != "" {
+			role, err := s.getRoleWithTxn(tx, nil, link.ID, "id")
+
+			if err != nil {
+				return err
+			}
+
+			if role != nil {
+				// the name doesn't matter here
+				token.Roles[linkIndex].Name = role.Name
+			} else if !allowMissing {
+				return fmt.Errorf("No such role with ID: %s", link.ID)
+			}
+		} else {
+			return fmt.Errorf("Encountered a Token with roles linked by Name in the state store")
+		}
+	}
+	return nil
+}
+
+// fixupTokenLinks corrects both the policy and the role links of a token
+// retrieved from memdb. See fixupTokenPolicyLinks and fixupTokenRoleLinks.
+func (s *Store) fixupTokenLinks(tx *memdb.Txn, original *structs.ACLToken) (*structs.ACLToken, error) {
+	token, err := s.fixupTokenPolicyLinks(tx, original)
+	if err != nil {
+		return nil, err
+	}
+	return s.fixupTokenRoleLinks(tx, token)
+}
+
 // fixupTokenPolicyLinks is to be used when retrieving tokens from memdb. The policy links could have gotten
 // stale when a linked policy was deleted or renamed. This will correct them and generate a newly allocated
 // token only when fixes are needed. If the policy links are still accurate then we just return the original
@@ -315,6 +637,53 @@ func (s *Store) fixupTokenPolicyLinks(tx *memdb.Txn, original *structs.ACLToken)
 	return token, nil
 }
 
+// fixupTokenRoleLinks is the role equivalent of fixupTokenPolicyLinks. Links
+// to deleted roles are dropped and links to renamed roles get the new name.
+func (s *Store) fixupTokenRoleLinks(tx *memdb.Txn, original *structs.ACLToken) (*structs.ACLToken, error) {
+	owned := false
+	token := original
+
+	cloneToken := func(t *structs.ACLToken, copyNumLinks int) *structs.ACLToken {
+		clone := *t
+		clone.Roles = make([]structs.ACLTokenRoleLink, copyNumLinks)
+		copy(clone.Roles, t.Roles[:copyNumLinks])
+		return &clone
+	}
+
+	for linkIndex, link := range original.Roles {
+		if link.ID == "" {
+			return nil, fmt.Errorf("Detected corrupted token within the state store - missing role link ID")
+		}
+
+		role, err := s.getRoleWithTxn(tx, nil, link.ID, "id")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if role == nil {
+			if !owned {
+				// clone the token as we cannot touch the original
+				token = cloneToken(original, linkIndex)
+				owned = true
+			}
+			// if already owned then we just don't append it.
+		} else if role.Name != link.Name {
+			if !owned {
+				token = cloneToken(original, linkIndex)
+				owned = true
+			}
+
+			// append the corrected role
+			token.Roles = append(token.Roles, structs.ACLTokenRoleLink{ID: link.ID, Name: role.Name})
+		} else if owned {
+			token.Roles = append(token.Roles, link)
+		}
+	}
+
+	return token, nil
+}
+
 // ACLTokenSet is used to insert an ACL rule into the state store.
 func (s *Store) ACLTokenSet(idx uint64, token *structs.ACLToken, legacy bool) error {
 	tx := s.db.Txn(true)
@@ -355,7 +724,7 @@ func (s *Store) ACLTokenBatchSet(idx uint64, tokens structs.ACLTokens, cas bool)
 
 // aclTokenSetTxn is the inner method used to insert an ACL token with the
 // proper indexes into the state store.
-func (s *Store) aclTokenSetTxn(tx *memdb.Txn, idx uint64, token *structs.ACLToken, cas, allowMissingPolicyIDs, legacy bool) error {
+func (s *Store) aclTokenSetTxn(tx *memdb.Txn, idx uint64, token *structs.ACLToken, cas, allowMissingPolicyAndRoleIDs, legacy bool) error {
 	// Check that the ID is set
 	if token.SecretID == "" {
 		return ErrMissingACLTokenSecret
@@ -405,17 +774,30 @@ func (s *Store) aclTokenSetTxn(tx *memdb.Txn, idx uint64, token *structs.ACLToke
 	}
 
 	if legacy && original != nil {
-		if len(original.Policies) > 0 || original.Type == "" {
+		if len(original.Policies) > 0 || len(original.Roles) > 0 || original.Type == "" {
 			return fmt.Errorf("failed inserting acl token: cannot use legacy endpoint to modify a non-legacy token")
 		}
 
 		token.AccessorID = original.AccessorID
 	}
 
-	if err := s.resolveTokenPolicyLinks(tx, token, allowMissingPolicyIDs); err != nil {
+	if err := s.resolveTokenPolicyLinks(tx, token, allowMissingPolicyAndRoleIDs); err != nil {
+		return err
+	}
+
+	if err := s.resolveTokenRoleLinks(tx, token, allowMissingPolicyAndRoleIDs); err != nil {
 		return err
 	}
 
+	if token.AuthMethod != "" {
+		method, err := s.getAuthMethodWithTxn(tx, nil, token.AuthMethod)
+		if err != nil {
+			return err
+		} else if method == nil {
+			return fmt.Errorf("No such auth method with Name: %s", token.AuthMethod)
+		}
+	}
+
 	// Set the indexes
 	if original != nil {
 		if original.AccessorID != "" && token.AccessorID != original.AccessorID {
@@ -495,7 +877,7 @@ func (s *Store) aclTokenGetTxn(tx *memdb.Txn, ws memdb.WatchSet, value, index st
 	ws.Add(watchCh)
 
 	if rawToken != nil {
-		token, err := s.fixupTokenPolicyLinks(tx, rawToken.(*structs.ACLToken))
+		token, err := s.fixupTokenLinks(tx, rawToken.(*structs.ACLToken))
 		if err != nil {
 			return nil, err
 		}
@@ -506,7 +888,7 @@ func (s *Store) aclTokenGetTxn(tx *memdb.Txn, ws memdb.WatchSet, value, index st
 }
 
 // ACLTokenList is used to list out all of the ACLs in the state store.
-func (s *Store) ACLTokenList(ws memdb.WatchSet, local, global bool, policy string) (uint64, structs.ACLTokens, error) {
+func (s *Store) ACLTokenList(ws memdb.WatchSet, local, global bool, policy, role, methodName string) (uint64, structs.ACLTokens, error) {
 	tx := s.db.Txn(false)
 	defer tx.Abort()
 
@@ -517,30 +899,43 @@ func (s *Store) ACLTokenList(ws memdb.WatchSet, local, global bool, policy strin
 	// to false but for defaulted structs (zero values for both) we want it to list out
 	// all tokens so our checks just ensure that global == local
 
-	if policy != "" {
+	needLocalityFilter := false
+	if policy == "" && role == "" && methodName == "" {
+		if global == local {
+			iter, err = tx.Get("acl-tokens", "id")
+		} else if global {
+			iter, err = tx.Get("acl-tokens", "local", false)
+		} else {
+			iter, err = tx.Get("acl-tokens", "local", true)
+		}
+	} else if policy != "" && role == "" && methodName == "" {
 		iter, err = tx.Get("acl-tokens", "policies", policy)
-		if err == nil && global != local {
-			iter = memdb.NewFilterIterator(iter, func(raw interface{}) bool {
-				token, ok := raw.(*structs.ACLToken)
-				if !ok {
-					return true
-				}
-
-				if global && !token.Local {
-					return false
-				} else if local && token.Local {
-					return false
-				}
+		needLocalityFilter = true
+	} else if policy == "" && role != "" && methodName == "" {
+		iter, err = tx.Get("acl-tokens", "roles", role)
+		needLocalityFilter = true
+	} else if policy == "" && role == "" && methodName != "" {
+		iter, err = tx.Get("acl-tokens", "authmethod", methodName)
+		needLocalityFilter = true
+	} else {
+		return 0, nil, fmt.Errorf("can only filter by one of policy, role, or authmethod at a time")
+	}
 
+	if err == nil && needLocalityFilter && global != local {
+		iter = memdb.NewFilterIterator(iter, func(raw interface{}) bool {
+			token, ok := raw.(*structs.ACLToken)
+			if !ok {
 				return true
-			})
-		}
-	} else if global == local {
-		iter, err = tx.Get("acl-tokens", "id")
-	} else if global {
-		iter, err = tx.Get("acl-tokens", "local", false)
-	} else {
-		iter, err = tx.Get("acl-tokens", "local", true)
+			}
+
+			if global && !token.Local {
+				return false
+			} else if local && token.Local {
+				return false
+			}
+
+			return true
+		})
 	}
 
 	if err != nil {
@@ -550,7 +945,7 @@ func (s *Store) ACLTokenList(ws memdb.WatchSet, local, global bool, policy strin
 
 	var result structs.ACLTokens
 	for raw := iter.Next(); raw != nil; raw = iter.Next() {
-		token, err := s.fixupTokenPolicyLinks(tx, raw.(*structs.ACLToken))
+		token, err := s.fixupTokenLinks(tx, raw.(*structs.ACLToken))
 
 		if err != nil {
 			return 0, nil, err
@@ -586,6 +981,45 @@ func (s *Store) ACLTokenListUpgradeable(max int) (structs.ACLTokens, <-chan stru
 	return tokens, iter.WatchCh(), nil
 }
 
+// ACLTokenListExpired returns up to max tokens of the given locality whose
+// expiration time is before asOf, oldest first.
+func (s *Store) ACLTokenListExpired(local bool, asOf time.Time, max int) (structs.ACLTokens, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	iter, err := tx.Get("acl-tokens", s.expiresIndexName(local)+"_prefix")
+	if err != nil {
+		return nil, fmt.Errorf("failed acl token listing: %v", err)
+	}
+
+	var tokens structs.ACLTokens
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		token := raw.(*structs.ACLToken)
+		if token.ExpirationTime.Unix() > asOf.Unix() {
+			// The index is ordered by expiration time (at second
+			// granularity) so everything after this token is unexpired too.
+			break
+		}
+		if !token.IsExpired(asOf) {
+			continue
+		}
+
+		tokens = append(tokens, token)
+		if len(tokens) >= max {
+			break
+		}
+	}
+
+	return tokens, nil
+}
+
+func (s *Store) expiresIndexName(local bool) string {
+	if local {
+		return "expires-local"
+	}
+	return "expires-global"
+}
+
 // ACLTokenDeleteBySecret is used to remove an existing ACL from the state store. If
 // the ACL does not exist this is a no-op and no error is returned.
 func (s *Store) ACLTokenDeleteBySecret(idx uint64, secret string) error {
@@ -876,3 +1310,615 @@ func (s *Store) aclPolicyDeleteTxn(tx *memdb.Txn, idx uint64, value, index strin
 	}
 	return nil
 }
+
+func (s *Store) ACLRoleBatchSet(idx uint64, roles structs.ACLRoles) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	for _, role := range roles {
+		// this is only used when doing batch insertions for replication
+		// where the linked policies may not have been replicated yet.
+		if err := s.aclRoleSetTxn(tx, idx, role, true); err != nil {
+			return err
+		}
+	}
+
+	if err := indexUpdateMaxTxn(tx, idx, "acl-roles"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+func (s *Store) ACLRoleSet(idx uint64, role *structs.ACLRole) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	if err := s.aclRoleSetTxn(tx, idx, role, false); err != nil {
+		return err
+	}
+	if err := indexUpdateMaxTxn(tx, idx, "acl-roles"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+func (s *Store) aclRoleSetTxn(tx *memdb.Txn, idx uint64, role *structs.ACLRole, allowMissingPolicyIDs bool) error {
+	// Check that the ID is set
+	if role.ID == "" {
+		return ErrMissingACLRoleID
+	}
+
+	if role.Name == "" {
+		return ErrMissingACLRoleName
+	}
+
+	existing, err := tx.First("acl-roles", "id", role.ID)
+	if err != nil {
+		return fmt.Errorf("failed acl role lookup: %v", err)
+	}
+
+	// ensure the name is unique (cannot conflict with another role with a different ID)
+	nameMatch, err := tx.First("acl-roles", "name", role.Name)
+	if err != nil {
+		return fmt.Errorf("failed acl role lookup: %v", err)
+	}
+	if nameMatch != nil && role.ID != nameMatch.(*structs.ACLRole).ID {
+		return fmt.Errorf("A role with name %q already exists", role.Name)
+	}
+
+	if err := s.resolveRolePolicyLinks(tx, role, allowMissingPolicyIDs); err != nil {
+		return err
+	}
+
+	// Set the indexes
+	if existing != nil {
+		role.CreateIndex = existing.(*structs.ACLRole).CreateIndex
+		role.ModifyIndex = idx
+	} else {
+		role.CreateIndex = idx
+		role.ModifyIndex = idx
+	}
+
+	if err := tx.Insert("acl-roles", role); err != nil {
+		return fmt.Errorf("failed inserting acl role: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) resolveRolePolicyLinks(tx *memdb.Txn, role *structs.ACLRole, allowMissing bool) error {
+	for linkIndex, link := range role.Policies {
+		if link.ID == "" {
+			return fmt.Errorf("Encountered a Role with policies linked by Name in the state store")
+		}
+
+		policy, err := s.getPolicyWithTxn(tx, nil, link.ID, "id")
+		if err != nil {
+			return err
+		}
+
+		if policy != nil {
+			// the name doesn't matter here
+			role.Policies[linkIndex].Name = policy.Name
+		} else if !allowMissing {
+			return fmt.Errorf("No such policy with ID: %s", link.ID)
+		}
+	}
+	return nil
+}
+
+// fixupRolePolicyLinks is the role equivalent of fixupTokenPolicyLinks. The
+// original role is returned unless one of its policy links needed correcting.
+func (s *Store) fixupRolePolicyLinks(tx *memdb.Txn, original *structs.ACLRole) (*structs.ACLRole, error) {
+	owned := false
+	role := original
+
+	cloneRole := func(r *structs.ACLRole, copyNumLinks int) *structs.ACLRole {
+		clone := *r
+		clone.Policies = make([]structs.ACLRolePolicyLink, copyNumLinks)
+		copy(clone.Policies, r.Policies[:copyNumLinks])
+		return &clone
+	}
+
+	for linkIndex, link := range original.Policies {
+		if link.ID == "" {
+			return nil, fmt.Errorf("Detected corrupted role within the state store - missing policy link ID")
+		}
+
+		policy, err := s.getPolicyWithTxn(tx, nil, link.ID, "id")
+		if err != nil {
+			return nil, err
+		}
+
+		if policy == nil {
+			if !owned {
+				// clone the role as we cannot touch the original
+				role = cloneRole(original, linkIndex)
+				owned = true
+			}
+			// if already owned then we just don't append it.
+		} else if policy.Name != link.Name {
+			if !owned {
+				role = cloneRole(original, linkIndex)
+				owned = true
+			}
+
+			// append the corrected policy
+			role.Policies = append(role.Policies, structs.ACLRolePolicyLink{ID: link.ID, Name: policy.Name})
+		} else if owned {
+			role.Policies = append(role.Policies, link)
+		}
+	}
+
+	return role, nil
+}
+
+func (s *Store) ACLRoleGetByID(ws memdb.WatchSet, id string) (uint64, *structs.ACLRole, error) {
+	return s.aclRoleGet(ws, id, "id")
+}
+
+func (s *Store) ACLRoleGetByName(ws memdb.WatchSet, name string) (uint64, *structs.ACLRole, error) {
+	return s.aclRoleGet(ws, name, "name")
+}
+
+func (s *Store) ACLRoleBatchGet(ws memdb.WatchSet, ids []string) (uint64, structs.ACLRoles, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	roles := make(structs.ACLRoles, 0)
+	for _, rid := range ids {
+		role, err := s.getRoleWithTxn(tx, ws, rid, "id")
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if role != nil {
+			roles = append(roles, role)
+		}
+	}
+
+	idx := maxIndexTxn(tx, "acl-roles")
+
+	return idx, roles, nil
+}
+
+func (s *Store) getRoleWithTxn(tx *memdb.Txn, ws memdb.WatchSet, value, index string) (*structs.ACLRole, error) {
+	watchCh, rawRole, err := tx.FirstWatch("acl-roles", index, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed acl role lookup: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if rawRole == nil {
+		return nil, nil
+	}
+
+	return s.fixupRolePolicyLinks(tx, rawRole.(*structs.ACLRole))
+}
+
+func (s *Store) aclRoleGet(ws memdb.WatchSet, value, index string) (uint64, *structs.ACLRole, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	role, err := s.getRoleWithTxn(tx, ws, value, index)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	idx := maxIndexTxn(tx, "acl-roles")
+
+	return idx, role, nil
+}
+
+// ACLRoleList lists all the roles or only those linking the given policy
+// when one is provided.
+func (s *Store) ACLRoleList(ws memdb.WatchSet, policy string) (uint64, structs.ACLRoles, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	var iter memdb.ResultIterator
+	var err error
+
+	if policy != "" {
+		iter, err = tx.Get("acl-roles", "policies", policy)
+	} else {
+		iter, err = tx.Get("acl-roles", "id")
+	}
+
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed acl role lookup: %v", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var result structs.ACLRoles
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		role, err := s.fixupRolePolicyLinks(tx, raw.(*structs.ACLRole))
+		if err != nil {
+			return 0, nil, err
+		}
+		result = append(result, role)
+	}
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, "acl-roles")
+
+	return idx, result, nil
+}
+
+func (s *Store) ACLRoleDeleteByID(idx uint64, id string) error {
+	return s.aclRoleDelete(idx, id, "id")
+}
+
+func (s *Store) ACLRoleDeleteByName(idx uint64, name string) error {
+	return s.aclRoleDelete(idx, name, "name")
+}
+
+func (s *Store) ACLRoleBatchDelete(idx uint64, roleIDs []string) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	for _, roleID := range roleIDs {
+		if err := s.aclRoleDeleteTxn(tx, idx, roleID, "id"); err != nil {
+			return err
+		}
+	}
+
+	if err := indexUpdateMaxTxn(tx, idx, "acl-roles"); err != nil {
+		return fmt.Errorf("failed updating index: %v", err)
+	}
+	tx.Commit()
+	return nil
+}
+
+func (s *Store) aclRoleDelete(idx uint64, value, index string) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	if err := s.aclRoleDeleteTxn(tx, idx, value, index); err != nil {
+		return err
+	}
+	if err := indexUpdateMaxTxn(tx, idx, "acl-roles"); err != nil {
+		return fmt.Errorf("failed updating index: %v", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+func (s *Store) aclRoleDeleteTxn(tx *memdb.Txn, idx uint64, value, index string) error {
+	// Look up the existing role
+	rawRole, err := tx.First("acl-roles", index, value)
+	if err != nil {
+		return fmt.Errorf("failed acl role lookup: %v", err)
+	}
+
+	if rawRole == nil {
+		return nil
+	}
+
+	if err := tx.Delete("acl-roles", rawRole); err != nil {
+		return fmt.Errorf("failed deleting acl role: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) ACLAuthMethodBatchSet(idx uint64, methods structs.ACLAuthMethods) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	for _, method := range methods {
+		if err := s.aclAuthMethodSetTxn(tx, idx, method); err != nil {
+			return err
+		}
+	}
+
+	if err := indexUpdateMaxTxn(tx, idx, "acl-auth-methods"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+func (s *Store) ACLAuthMethodSet(idx uint64, method *structs.ACLAuthMethod) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	if err := s.aclAuthMethodSetTxn(tx, idx, method); err != nil {
+		return err
+	}
+	if err := indexUpdateMaxTxn(tx, idx, "acl-auth-methods"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+func (s *Store) aclAuthMethodSetTxn(tx *memdb.Txn, idx uint64, method *structs.ACLAuthMethod) error {
+	// Check that the Name and Type are set
+	if method.Name == "" {
+		return ErrMissingACLAuthMethodName
+	} else if method.Type == "" {
+		return fmt.Errorf("Missing ACL Auth Method Type")
+	}
+
+	existing, err := tx.First("acl-auth-methods", "id", method.Name)
+	if err != nil {
+		return fmt.Errorf("failed acl auth method lookup: %v", err)
+	}
+
+	// Set the indexes
+	if existing != nil {
+		existingMethod := existing.(*structs.ACLAuthMethod)
+		if method.Type != existingMethod.Type {
+			return fmt.Errorf("Changing the Type of an auth method is not permitted")
+		}
+		method.CreateIndex = existingMethod.CreateIndex
+		method.ModifyIndex = idx
+	} else {
+		method.CreateIndex = idx
+		method.ModifyIndex = idx
+	}
+
+	if err := tx.Insert("acl-auth-methods", method); err != nil {
+		return fmt.Errorf("failed inserting acl auth method: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) ACLAuthMethodGetByName(ws memdb.WatchSet, name string) (uint64, *structs.ACLAuthMethod, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	method, err := s.getAuthMethodWithTxn(tx, ws, name)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	idx := maxIndexTxn(tx, "acl-auth-methods")
+
+	return idx, method, nil
+}
+
+func (s *Store) getAuthMethodWithTxn(tx *memdb.Txn, ws memdb.WatchSet, name string) (*structs.ACLAuthMethod, error) {
+	watchCh, rawMethod, err := tx.FirstWatch("acl-auth-methods", "id", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed acl auth method lookup: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if rawMethod == nil {
+		return nil, nil
+	}
+
+	return rawMethod.(*structs.ACLAuthMethod), nil
+}
+
+func (s *Store) ACLAuthMethodList(ws memdb.WatchSet) (uint64, structs.ACLAuthMethods, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	iter, err := tx.Get("acl-auth-methods", "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed acl auth method lookup: %v", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var result structs.ACLAuthMethods
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		result = append(result, raw.(*structs.ACLAuthMethod))
+	}
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, "acl-auth-methods")
+
+	return idx, result, nil
+}
+
+// ACLAuthMethodDeleteByName deletes the named auth method along with its
+// binding rules and all of the tokens created by logging in with it.
+func (s *Store) ACLAuthMethodDeleteByName(idx uint64, name string) error {
+	return s.ACLAuthMethodBatchDelete(idx, []string{name})
+}
+
+func (s *Store) ACLAuthMethodBatchDelete(idx uint64, names []string) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	for _, name := range names {
+		if err := s.aclAuthMethodDeleteTxn(tx, idx, name); err != nil {
+			return err
+		}
+	}
+
+	if err := indexUpdateMaxTxn(tx, idx, "acl-auth-methods"); err != nil {
+		return fmt.Errorf("failed updating index: %v", err)
+	}
+	tx.Commit()
+	return nil
+}
+
+func (s *Store) aclAuthMethodDeleteTxn(tx *memdb.Txn, idx uint64, name string) error {
+	// Look up the existing method
+	rawMethod, err := tx.First("acl-auth-methods", "id", name)
+	if err != nil {
+		return fmt.Errorf("failed acl auth method lookup: %v", err)
+	}
+
+	if rawMethod == nil {
+		return nil
+	}
+	method := rawMethod.(*structs.ACLAuthMethod)
+
+	// Tokens and binding rules are meaningless without their auth method.
+	if _, err := tx.DeleteAll("acl-binding-rules", "authmethod", method.Name); err != nil {
+		return fmt.Errorf("failed deleting acl binding rules: %v", err)
+	}
+	if err := indexUpdateMaxTxn(tx, idx, "acl-binding-rules"); err != nil {
+		return fmt.Errorf("failed updating index: %v", err)
+	}
+
+	if _, err := tx.DeleteAll("acl-tokens", "authmethod", method.Name); err != nil {
+		return fmt.Errorf("failed deleting acl tokens: %v", err)
+	}
+	if err := indexUpdateMaxTxn(tx, idx, "acl-tokens"); err != nil {
+		return fmt.Errorf("failed updating index: %v", err)
+	}
+
+	if err := tx.Delete("acl-auth-methods", method); err != nil {
+		return fmt.Errorf("failed deleting acl auth method: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) ACLBindingRuleBatchSet(idx uint64, rules structs.ACLBindingRules) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	for _, rule := range rules {
+		if err := s.aclBindingRuleSetTxn(tx, idx, rule); err != nil {
+			return err
+		}
+	}
+
+	if err := indexUpdateMaxTxn(tx, idx, "acl-binding-rules"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+func (s *Store) ACLBindingRuleSet(idx uint64, rule *structs.ACLBindingRule) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	if err := s.aclBindingRuleSetTxn(tx, idx, rule); err != nil {
+		return err
+	}
+	if err := indexUpdateMaxTxn(tx, idx, "acl-binding-rules"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+func (s *Store) aclBindingRuleSetTxn(tx *memdb.Txn, idx uint64, rule *structs.ACLBindingRule) error {
+	// Check that the ID and AuthMethod are set
+	if rule.ID == "" {
+		return ErrMissingACLBindingRuleID
+	} else if rule.AuthMethod == "" {
+		return ErrMissingACLBindingRuleAuthMethod
+	}
+
+	existing, err := tx.First("acl-binding-rules", "id", rule.ID)
+	if err != nil {
+		return fmt.Errorf("failed acl binding rule lookup: %v", err)
+	}
+
+	// Set the indexes
+	if existing != nil {
+		rule.CreateIndex = existing.(*structs.ACLBindingRule).CreateIndex
+		rule.ModifyIndex = idx
+	} else {
+		rule.CreateIndex = idx
+		rule.ModifyIndex = idx
+	}
+
+	if method, err := s.getAuthMethodWithTxn(tx, nil, rule.AuthMethod); err != nil {
+		return err
+	} else if method == nil {
+		return fmt.Errorf("failed inserting acl binding rule: auth method not found")
+	}
+
+	if err := tx.Insert("acl-binding-rules", rule); err != nil {
+		return fmt.Errorf("failed inserting acl binding rule: %v", err)
+	}
+	return nil
+}
+
+func (s *Store) ACLBindingRuleGetByID(ws memdb.WatchSet, id string) (uint64, *structs.ACLBindingRule, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	watchCh, rawRule, err := tx.FirstWatch("acl-binding-rules", "id", id)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed acl binding rule lookup: %v", err)
+	}
+	ws.Add(watchCh)
+
+	var rule *structs.ACLBindingRule
+	if rawRule != nil {
+		rule = rawRule.(*structs.ACLBindingRule)
+	}
+
+	idx := maxIndexTxn(tx, "acl-binding-rules")
+
+	return idx, rule, nil
+}
+
+// ACLBindingRuleList lists all the binding rules or only those of the given
+// auth method when one is provided.
+func (s *Store) ACLBindingRuleList(ws memdb.WatchSet, methodName string) (uint64, structs.ACLBindingRules, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	var iter memdb.ResultIterator
+	var err error
+
+	if methodName != "" {
+		iter, err = tx.Get("acl-binding-rules", "authmethod", methodName)
+	} else {
+		iter, err = tx.Get("acl-binding-rules", "id")
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed acl binding rule lookup: %v", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var result structs.ACLBindingRules
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		result = append(result, raw.(*structs.ACLBindingRule))
+	}
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, "acl-binding-rules")
+
+	return idx, result, nil
+}
+
+func (s *Store) ACLBindingRuleDeleteByID(idx uint64, id string) error {
+	return s.ACLBindingRuleBatchDelete(idx, []string{id})
+}
+
+func (s *Store) ACLBindingRuleBatchDelete(idx uint64, ids []string) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	for _, id := range ids {
+		// Look up the existing rule
+		rawRule, err := tx.First("acl-binding-rules", "id", id)
+		if err != nil {
+			return fmt.Errorf("failed acl binding rule lookup: %v", err)
+		}
+
+		if rawRule == nil {
+			continue
+		}
+
+		if err := tx.Delete("acl-binding-rules", rawRule); err != nil {
+			return fmt.Errorf("failed deleting acl binding rule: %v", err)
+		}
+	}
+
+	if err := indexUpdateMaxTxn(tx, idx, "acl-binding-rules"); err != nil {
+		return fmt.Errorf("failed updating index: %v", err)
+	}
+	tx.Commit()
+	return nil
+}
@@ -56,9 +56,31 @@ func setupExtraPolicies(t *testing.T, s *Store) {
 	require.NoError(t, s.ACLPolicyBatchSet(2, policies))
 }
 
+func setupExtraRoles(t *testing.T, s *Store) {
+	roles := structs.ACLRoles{
+		&structs.ACLRole{
+			ID:          "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1",
+			Name:        "node-reader",
+			Description: "Allows reading all node information",
+			Policies: []structs.ACLRolePolicyLink{
+				structs.ACLRolePolicyLink{
+					ID: "a0625e95-9b3e-42de-a8d6-ceef5b6f3286",
+				},
+			},
+		},
+	}
+
+	for _, role := range roles {
+		role.SetHash(true)
+	}
+
+	require.NoError(t, s.ACLRoleBatchSet(2, roles))
+}
+
 func testACLTokensStateStore(t *testing.T) *Store {
 	s := testACLStateStore(t)
 	setupExtraPolicies(t, s)
+	setupExtraRoles(t, s)
 	return s
 }
 
@@ -140,7 +162,7 @@ func TestStateStore_ACLBootstrap(t *testing.T) {
 	require.Equal(t, uint64(3), index)
 
 	// Make sure the ACLs are in an expected state.
-	_, tokens, err := s.ACLTokenList(nil, true, true, "")
+	_, tokens, err := s.ACLTokenList(nil, true, true, "", "", "")
 	require.NoError(t, err)
 	require.Len(t, tokens, 1)
 	compareTokens(token1, tokens[0])
@@ -154,7 +176,7 @@ func TestStateStore_ACLBootstrap(t *testing.T) {
 	err = s.ACLBootstrap(32, index, token2.Clone(), false)
 	require.NoError(t, err)
 
-	_, tokens, err = s.ACLTokenList(nil, true, true, "")
+	_, tokens, err = s.ACLTokenList(nil, true, true, "", "", "")
 	require.NoError(t, err)
 	require.Len(t, tokens, 2)
 }
@@ -662,6 +684,111 @@ func TestStateStore_ACLTokens_ListUpgradeable(t *testing.T) {
 	require.Len(t, tokens, 0)
 }
 
+func TestStateStore_ACLTokens_ListExpired(t *testing.T) {
+	t.Parallel()
+	s := testACLTokensStateStore(t)
+
+	now := time.Now()
+	timeAt := func(offset time.Duration) *time.Time {
+		t := now.Add(offset)
+		return &t
+	}
+
+	tokens := structs.ACLTokens{
+		// local token expired an hour ago
+		&structs.ACLToken{
+			AccessorID:     "f1093997-b6c7-496d-bfb8-6b1b1895641b",
+			SecretID:       "34ec8eb3-095d-417a-a937-b439af7a8e8b",
+			Local:          true,
+			ExpirationTime: timeAt(-time.Hour),
+		},
+		// local token expired a minute ago
+		&structs.ACLToken{
+			AccessorID:     "4915fc9d-3726-4171-b588-6c271f45eecd",
+			SecretID:       "f6998577-fd9b-4e6c-b202-cc3820513d32",
+			Local:          true,
+			ExpirationTime: timeAt(-time.Minute),
+		},
+		// local token expiring in an hour
+		&structs.ACLToken{
+			AccessorID:     "80c900e1-2fc5-4553-ba59-bc2b6c6a9302",
+			SecretID:       "e9f3b5a3-bbc1-4a01-a8c2-0f2e6c0b05df",
+			Local:          true,
+			ExpirationTime: timeAt(time.Hour),
+		},
+		// local token that never expires
+		&structs.ACLToken{
+			AccessorID: "a2719052-40b3-4a4b-baeb-f3df1831a217",
+			SecretID:   "ff826eaf-4b88-4881-aaef-52b1089e5d5d",
+			Local:      true,
+		},
+		// global token expired a minute ago
+		&structs.ACLToken{
+			AccessorID:     "54866514-3cf2-4fec-8a8a-710583831834",
+			SecretID:       "8de2dd39-134d-4cb1-950b-b7ab96ea20ba",
+			ExpirationTime: timeAt(-time.Minute),
+		},
+		// global token expiring in an hour
+		&structs.ACLToken{
+			AccessorID:     "47eea4da-bda1-48a6-901c-3e36d2d9262f",
+			SecretID:       "548bdb8e-c0d6-477b-bcc4-67fb836e9e61",
+			ExpirationTime: timeAt(time.Hour),
+		},
+	}
+
+	require.NoError(t, s.ACLTokenBatchSet(2, tokens, false))
+
+	accessors := func(tokens structs.ACLTokens) []string {
+		var out []string
+		for _, token := range tokens {
+			out = append(out, token.AccessorID)
+		}
+		return out
+	}
+
+	t.Run("local", func(t *testing.T) {
+		expired, err := s.ACLTokenListExpired(true, now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"f1093997-b6c7-496d-bfb8-6b1b1895641b",
+			"4915fc9d-3726-4171-b588-6c271f45eecd",
+		}, accessors(expired))
+	})
+
+	t.Run("local max", func(t *testing.T) {
+		expired, err := s.ACLTokenListExpired(true, now, 1)
+		require.NoError(t, err)
+		require.Equal(t, []string{"f1093997-b6c7-496d-bfb8-6b1b1895641b"}, accessors(expired))
+	})
+
+	t.Run("global", func(t *testing.T) {
+		expired, err := s.ACLTokenListExpired(false, now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"54866514-3cf2-4fec-8a8a-710583831834"}, accessors(expired))
+	})
+
+	t.Run("later", func(t *testing.T) {
+		expired, err := s.ACLTokenListExpired(true, now.Add(2*time.Hour), 10)
+		require.NoError(t, err)
+		require.Len(t, expired, 3)
+
+		expired, err = s.ACLTokenListExpired(false, now.Add(2*time.Hour), 10)
+		require.NoError(t, err)
+		require.Len(t, expired, 2)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		require.NoError(t, s.ACLTokenBatchDelete(3, []string{
+			"f1093997-b6c7-496d-bfb8-6b1b1895641b",
+			"4915fc9d-3726-4171-b588-6c271f45eecd",
+		}))
+
+		expired, err := s.ACLTokenListExpired(true, now, 10)
+		require.NoError(t, err)
+		require.Len(t, expired, 0)
+	})
+}
+
 func TestStateStore_ACLToken_List(t *testing.T) {
 	t.Parallel()
 	s := testACLTokensStateStore(t)
@@ -709,6 +836,16 @@ func TestStateStore_ACLToken_List(t *testing.T) {
 			},
 			Local: true,
 		},
+		// the role specific token
+		&structs.ACLToken{
+			AccessorID: "211f0360-ef53-41d3-9d4d-db84396eb6c0",
+			SecretID:   "ee97c18a-4aa3-4eb0-bfb3-7fb0b8e1ebbf",
+			Roles: []structs.ACLTokenRoleLink{
+				structs.ACLTokenRoleLink{
+					ID: "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1",
+				},
+			},
+		},
 	}
 
 	require.NoError(t, s.ACLTokenBatchSet(2, tokens, false))
@@ -718,6 +855,7 @@ func TestStateStore_ACLToken_List(t *testing.T) {
 		local     bool
 		global    bool
 		policy    string
+		role      string
 		accessors []string
 	}
 
@@ -729,6 +867,7 @@ func TestStateStore_ACLToken_List(t *testing.T) {
 			policy: "",
 			accessors: []string{
 				structs.ACLTokenAnonymousID,
+				"211f0360-ef53-41d3-9d4d-db84396eb6c0",
 				"47eea4da-bda1-48a6-901c-3e36d2d9262f",
 				"54866514-3cf2-4fec-8a8a-710583831834",
 			},
@@ -771,6 +910,22 @@ func TestStateStore_ACLToken_List(t *testing.T) {
 				"47eea4da-bda1-48a6-901c-3e36d2d9262f",
 			},
 		},
+		{
+			name:   "Role",
+			local:  true,
+			global: true,
+			role:   "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1",
+			accessors: []string{
+				"211f0360-ef53-41d3-9d4d-db84396eb6c0",
+			},
+		},
+		{
+			name:      "Role - Local",
+			local:     true,
+			global:    false,
+			role:      "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1",
+			accessors: []string{},
+		},
 		{
 			name:   "All",
 			local:  true,
@@ -778,6 +933,7 @@ func TestStateStore_ACLToken_List(t *testing.T) {
 			policy: "",
 			accessors: []string{
 				structs.ACLTokenAnonymousID,
+				"211f0360-ef53-41d3-9d4d-db84396eb6c0",
 				"47eea4da-bda1-48a6-901c-3e36d2d9262f",
 				"4915fc9d-3726-4171-b588-6c271f45eecd",
 				"54866514-3cf2-4fec-8a8a-710583831834",
@@ -790,7 +946,7 @@ func TestStateStore_ACLToken_List(t *testing.T) {
 		tc := tc // capture range variable
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			_, tokens, err := s.ACLTokenList(nil, tc.local, tc.global, tc.policy)
+			_, tokens, err := s.ACLTokenList(nil, tc.local, tc.global, tc.policy, tc.role, "")
 			require.NoError(t, err)
 			require.Len(t, tokens, len(tc.accessors))
 			tokens.Sort()
@@ -799,6 +955,12 @@ func TestStateStore_ACLToken_List(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("Policy and Role", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := s.ACLTokenList(nil, true, true, "a0625e95-9b3e-42de-a8d6-ceef5b6f3286", "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1", "")
+		require.Error(t, err)
+	})
 }
 
 func TestStateStore_ACLToken_FixupPolicyLinks(t *testing.T) {
@@ -851,7 +1013,7 @@ func TestStateStore_ACLToken_FixupPolicyLinks(t *testing.T) {
 	require.Equal(t, "node-read-renamed", retrieved.Policies[0].Name)
 
 	// list tokens without stale links
-	_, tokens, err := s.ACLTokenList(nil, true, true, "")
+	_, tokens, err := s.ACLTokenList(nil, true, true, "", "", "")
 	require.NoError(t, err)
 
 	found := false
@@ -895,7 +1057,7 @@ func TestStateStore_ACLToken_FixupPolicyLinks(t *testing.T) {
 	require.Len(t, retrieved.Policies, 0)
 
 	// list tokens without stale links
-	_, tokens, err = s.ACLTokenList(nil, true, true, "")
+	_, tokens, err = s.ACLTokenList(nil, true, true, "", "", "")
 	require.NoError(t, err)
 
 	found = false
@@ -1467,147 +1629,499 @@ func TestStateStore_ACLPolicy_Delete(t *testing.T) {
 	})
 }
 
-func TestStateStore_ACLTokens_Snapshot_Restore(t *testing.T) {
-	s := testStateStore(t)
+func TestStateStore_ACLRole_SetGet(t *testing.T) {
+	t.Parallel()
 
-	policies := structs.ACLPolicies{
-		&structs.ACLPolicy{
-			ID:          "ca1fc52c-3676-4050-82ed-ca223e38b2c9",
-			Name:        "policy1",
-			Description: "policy1",
-			Rules:       `node_prefix "" { policy = "read" }`,
-			Syntax:      acl.SyntaxCurrent,
-		},
-		&structs.ACLPolicy{
-			ID:          "7b70fa0f-58cd-412d-93c3-a0f17bb19a3e",
-			Name:        "policy2",
-			Description: "policy2",
-			Rules:       `acl = "read"`,
-			Syntax:      acl.SyntaxCurrent,
-		},
-	}
+	t.Run("Missing ID", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
 
-	for _, policy := range policies {
-		policy.SetHash(true)
-	}
+		role := structs.ACLRole{
+			Name:        "test-role",
+			Description: "test",
+		}
 
-	require.NoError(t, s.ACLPolicyBatchSet(2, policies))
+		require.Error(t, s.ACLRoleSet(3, &role))
+	})
 
-	tokens := structs.ACLTokens{
-		&structs.ACLToken{
-			AccessorID:  "68016c3d-835b-450c-a6f9-75db9ba740be",
-			SecretID:    "838f72b5-5c15-4a9e-aa6d-31734c3a0286",
-			Description: "token1",
-			Policies: []structs.ACLTokenPolicyLink{
-				structs.ACLTokenPolicyLink{
-					ID:   "ca1fc52c-3676-4050-82ed-ca223e38b2c9",
-					Name: "policy1",
-				},
-				structs.ACLTokenPolicyLink{
-					ID:   "7b70fa0f-58cd-412d-93c3-a0f17bb19a3e",
-					Name: "policy2",
-				},
-			},
-			Hash:      []byte{1, 2, 3, 4},
-			RaftIndex: structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
-		},
-		&structs.ACLToken{
-			AccessorID:  "b2125a1b-2a52-41d4-88f3-c58761998a46",
-			SecretID:    "ba5d9239-a4ab-49b9-ae09-1f19eed92204",
-			Description: "token2",
-			Policies: []structs.ACLTokenPolicyLink{
-				structs.ACLTokenPolicyLink{
-					ID:   "ca1fc52c-3676-4050-82ed-ca223e38b2c9",
-					Name: "policy1",
-				},
-				structs.ACLTokenPolicyLink{
-					ID:   "7b70fa0f-58cd-412d-93c3-a0f17bb19a3e",
-					Name: "policy2",
+	t.Run("Missing Name", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+
+		role := structs.ACLRole{
+			ID:          "2c74a9b8-271c-4a21-b727-200db397c01c",
+			Description: "test",
+		}
+
+		require.Error(t, s.ACLRoleSet(3, &role))
+	})
+
+	t.Run("Missing Policy", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+
+		role := structs.ACLRole{
+			ID:   "2c74a9b8-271c-4a21-b727-200db397c01c",
+			Name: "test-role",
+			Policies: []structs.ACLRolePolicyLink{
+				structs.ACLRolePolicyLink{
+					ID: "ae4b2ad3-ab8f-4d26-97bd-0bc8bc5e1cfa",
 				},
 			},
-			Hash:      []byte{1, 2, 3, 4},
-			RaftIndex: structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
-		},
-	}
+		}
 
-	require.NoError(t, s.ACLTokenBatchSet(2, tokens, false))
+		require.Error(t, s.ACLRoleSet(3, &role))
+	})
 
-	// Snapshot the ACLs.
-	snap := s.Snapshot()
-	defer snap.Close()
+	t.Run("Duplicate Name", func(t *testing.T) {
+		t.Parallel()
+		s := testACLTokensStateStore(t)
 
-	// Alter the real state store.
-	require.NoError(t, s.ACLTokenDeleteByAccessor(3, tokens[0].AccessorID))
+		role := structs.ACLRole{
+			ID:   "2c74a9b8-271c-4a21-b727-200db397c01c",
+			Name: "node-reader",
+		}
 
-	// Verify the snapshot.
-	require.Equal(t, uint64(2), snap.LastIndex())
+		require.Error(t, s.ACLRoleSet(3, &role))
+	})
 
-	iter, err := snap.ACLTokens()
-	require.NoError(t, err)
+	t.Run("New", func(t *testing.T) {
+		t.Parallel()
+		s := testACLTokensStateStore(t)
 
-	var dump structs.ACLTokens
-	for token := iter.Next(); token != nil; token = iter.Next() {
-		dump = append(dump, token.(*structs.ACLToken))
-	}
-	require.ElementsMatch(t, dump, tokens)
+		role := structs.ACLRole{
+			ID:          "f1093997-b6c7-496d-bfb8-6b1b1895641b",
+			Name:        "my-new-role",
+			Description: "test",
+			Policies: []structs.ACLRolePolicyLink{
+				structs.ACLRolePolicyLink{
+					ID: "a0625e95-9b3e-42de-a8d6-ceef5b6f3286",
+				},
+			},
+		}
 
-	// Restore the values into a new state store.
-	func() {
-		s := testStateStore(t)
-		restore := s.Restore()
-		for _, token := range dump {
-			require.NoError(t, restore.ACLToken(token))
+		require.NoError(t, s.ACLRoleSet(3, &role))
+
+		idx, rrole, err := s.ACLRoleGetByID(nil, "f1093997-b6c7-496d-bfb8-6b1b1895641b")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), idx)
+		require.NotNil(t, rrole)
+		require.Equal(t, "my-new-role", rrole.Name)
+		require.Equal(t, "test", rrole.Description)
+		require.Len(t, rrole.Policies, 1)
+		require.Equal(t, "a0625e95-9b3e-42de-a8d6-ceef5b6f3286", rrole.Policies[0].ID)
+		require.Equal(t, "node-read", rrole.Policies[0].Name)
+		require.Equal(t, uint64(3), rrole.CreateIndex)
+		require.Equal(t, uint64(3), rrole.ModifyIndex)
+
+		idx, rrole, err = s.ACLRoleGetByName(nil, "my-new-role")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), idx)
+		require.NotNil(t, rrole)
+		require.Equal(t, "f1093997-b6c7-496d-bfb8-6b1b1895641b", rrole.ID)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+		s := testACLTokensStateStore(t)
+
+		update := structs.ACLRole{
+			ID:          "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1",
+			Name:        "node-reader-modified",
+			Description: "Modified",
+			Policies: []structs.ACLRolePolicyLink{
+				structs.ACLRolePolicyLink{
+					ID: structs.ACLPolicyGlobalManagementID,
+				},
+			},
 		}
-		restore.Commit()
 
-		// need to ensure we have the policies or else the links will be removed
-		require.NoError(t, s.ACLPolicyBatchSet(2, policies))
+		require.NoError(t, s.ACLRoleSet(3, &update))
 
-		// Read the restored ACLs back out and verify that they match.
-		idx, res, err := s.ACLTokenList(nil, true, true, "")
+		idx, rrole, err := s.ACLRoleGetByID(nil, "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1")
 		require.NoError(t, err)
-		require.Equal(t, uint64(2), idx)
-		require.ElementsMatch(t, tokens, res)
-		require.Equal(t, uint64(2), s.maxIndex("acl-tokens"))
-	}()
+		require.Equal(t, uint64(3), idx)
+		require.NotNil(t, rrole)
+		require.Equal(t, "node-reader-modified", rrole.Name)
+		require.Equal(t, "Modified", rrole.Description)
+		require.Len(t, rrole.Policies, 1)
+		require.Equal(t, structs.ACLPolicyGlobalManagementID, rrole.Policies[0].ID)
+		require.Equal(t, "global-management", rrole.Policies[0].Name)
+		require.Equal(t, uint64(2), rrole.CreateIndex)
+		require.Equal(t, uint64(3), rrole.ModifyIndex)
+	})
 }
 
-func TestStateStore_ACLPolicies_Snapshot_Restore(t *testing.T) {
-	s := testStateStore(t)
+func TestStateStore_ACLRole_UpsertBatchRead(t *testing.T) {
+	t.Parallel()
 
-	policies := structs.ACLPolicies{
-		&structs.ACLPolicy{
-			ID:          "68016c3d-835b-450c-a6f9-75db9ba740be",
-			Name:        "838f72b5-5c15-4a9e-aa6d-31734c3a0286",
-			Description: "policy1",
-			Rules:       `acl = "read"`,
-			Hash:        []byte{1, 2, 3, 4},
-			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
-		},
-		&structs.ACLPolicy{
-			ID:          "b2125a1b-2a52-41d4-88f3-c58761998a46",
-			Name:        "ba5d9239-a4ab-49b9-ae09-1f19eed92204",
-			Description: "policy2",
-			Rules:       `operator = "read"`,
-			Hash:        []byte{1, 2, 3, 4},
-			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
-		},
-	}
+	t.Run("Normal", func(t *testing.T) {
+		t.Parallel()
+		s := testACLTokensStateStore(t)
 
-	require.NoError(t, s.ACLPolicyBatchSet(2, policies))
+		roles := structs.ACLRoles{
+			&structs.ACLRole{
+				ID:   "a4f68bd6-3af5-4f56-b764-3c6f20247879",
+				Name: "role1",
+				Policies: []structs.ACLRolePolicyLink{
+					structs.ACLRolePolicyLink{
+						ID:   "a0625e95-9b3e-42de-a8d6-ceef5b6f3286",
+						Name: "node-read",
+					},
+				},
+			},
+			&structs.ACLRole{
+				ID:          "a2719052-40b3-4a4b-baeb-f3df1831a217",
+				Name:        "role2",
+				Description: "second role",
+			},
+		}
 
-	// Snapshot the ACLs.
-	snap := s.Snapshot()
-	defer snap.Close()
+		require.NoError(t, s.ACLRoleBatchSet(3, roles))
 
-	// Alter the real state store.
-	require.NoError(t, s.ACLPolicyDeleteByID(3, policies[0].ID))
+		idx, rroles, err := s.ACLRoleBatchGet(nil, []string{
+			"a4f68bd6-3af5-4f56-b764-3c6f20247879",
+			"a2719052-40b3-4a4b-baeb-f3df1831a217"})
 
-	// Verify the snapshot.
-	require.Equal(t, uint64(2), snap.LastIndex())
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), idx)
+		require.Len(t, rroles, 2)
+		require.ElementsMatch(t, roles, rroles)
+		require.Equal(t, uint64(3), rroles[0].CreateIndex)
+		require.Equal(t, uint64(3), rroles[0].ModifyIndex)
+		require.Equal(t, uint64(3), rroles[1].CreateIndex)
+		require.Equal(t, uint64(3), rroles[1].ModifyIndex)
+	})
 
-	iter, err := snap.ACLPolicies()
-	require.NoError(t, err)
+	t.Run("Missing Policies", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+
+		// batch sets are used by replication so links to policies that
+		// have not been replicated yet are allowed.
+		roles := structs.ACLRoles{
+			&structs.ACLRole{
+				ID:   "a4f68bd6-3af5-4f56-b764-3c6f20247879",
+				Name: "role1",
+				Policies: []structs.ACLRolePolicyLink{
+					structs.ACLRolePolicyLink{
+						ID: "ae4b2ad3-ab8f-4d26-97bd-0bc8bc5e1cfa",
+					},
+				},
+			},
+		}
+
+		require.NoError(t, s.ACLRoleBatchSet(2, roles))
+
+		_, rrole, err := s.ACLRoleGetByID(nil, "a4f68bd6-3af5-4f56-b764-3c6f20247879")
+		require.NoError(t, err)
+		require.NotNil(t, rrole)
+		// the dangling link is hidden when reading the role back
+		require.Len(t, rrole.Policies, 0)
+	})
+}
+
+func TestStateStore_ACLRole_List(t *testing.T) {
+	t.Parallel()
+	s := testACLTokensStateStore(t)
+
+	roles := structs.ACLRoles{
+		&structs.ACLRole{
+			ID:   "a4f68bd6-3af5-4f56-b764-3c6f20247879",
+			Name: "role1",
+			Policies: []structs.ACLRolePolicyLink{
+				structs.ACLRolePolicyLink{
+					ID: structs.ACLPolicyGlobalManagementID,
+				},
+			},
+		},
+		&structs.ACLRole{
+			ID:          "a2719052-40b3-4a4b-baeb-f3df1831a217",
+			Name:        "role2",
+			Description: "second role",
+		},
+	}
+
+	require.NoError(t, s.ACLRoleBatchSet(3, roles))
+
+	_, rroles, err := s.ACLRoleList(nil, "")
+	require.NoError(t, err)
+	require.Len(t, rroles, 3)
+	rroles.Sort()
+	require.Equal(t, "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1", rroles[0].ID)
+	require.Equal(t, "node-reader", rroles[0].Name)
+	require.Equal(t, "a2719052-40b3-4a4b-baeb-f3df1831a217", rroles[1].ID)
+	require.Equal(t, "role2", rroles[1].Name)
+	require.Equal(t, "second role", rroles[1].Description)
+	require.Len(t, rroles[1].Policies, 0)
+	require.Equal(t, "a4f68bd6-3af5-4f56-b764-3c6f20247879", rroles[2].ID)
+	require.Equal(t, "role1", rroles[2].Name)
+	require.Len(t, rroles[2].Policies, 1)
+	require.Equal(t, "global-management", rroles[2].Policies[0].Name)
+
+	_, rroles, err = s.ACLRoleList(nil, "a0625e95-9b3e-42de-a8d6-ceef5b6f3286")
+	require.NoError(t, err)
+	require.Len(t, rroles, 1)
+	require.Equal(t, "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1", rroles[0].ID)
+}
+
+func TestStateStore_ACLRole_FixupPolicyLinks(t *testing.T) {
+	t.Parallel()
+	s := testACLTokensStateStore(t)
+
+	// rename the policy linked to by the role and ensure reads pick up the
+	// new name without the role needing to be updated.
+	renamed := structs.ACLPolicy{
+		ID:    "a0625e95-9b3e-42de-a8d6-ceef5b6f3286",
+		Name:  "node-read-renamed",
+		Rules: `node_prefix "" { policy = "read" }`,
+	}
+	require.NoError(t, s.ACLPolicySet(3, &renamed))
+
+	_, rrole, err := s.ACLRoleGetByID(nil, "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1")
+	require.NoError(t, err)
+	require.Len(t, rrole.Policies, 1)
+	require.Equal(t, "node-read-renamed", rrole.Policies[0].Name)
+
+	// now delete the policy and ensure the link is dropped
+	require.NoError(t, s.ACLPolicyDeleteByID(4, "a0625e95-9b3e-42de-a8d6-ceef5b6f3286"))
+
+	_, rrole, err = s.ACLRoleGetByID(nil, "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1")
+	require.NoError(t, err)
+	require.Len(t, rrole.Policies, 0)
+}
+
+func TestStateStore_ACLRole_Delete(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ID", func(t *testing.T) {
+		t.Parallel()
+		s := testACLTokensStateStore(t)
+
+		require.NoError(t, s.ACLRoleDeleteByID(3, "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1"))
+
+		idx, rrole, err := s.ACLRoleGetByID(nil, "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), idx)
+		require.Nil(t, rrole)
+	})
+
+	t.Run("Name", func(t *testing.T) {
+		t.Parallel()
+		s := testACLTokensStateStore(t)
+
+		require.NoError(t, s.ACLRoleDeleteByName(3, "node-reader"))
+
+		_, rrole, err := s.ACLRoleGetByName(nil, "node-reader")
+		require.NoError(t, err)
+		require.Nil(t, rrole)
+	})
+
+	t.Run("Multiple", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+
+		roles := structs.ACLRoles{
+			&structs.ACLRole{
+				ID:   "f1093997-b6c7-496d-bfb8-6b1b1895641b",
+				Name: "role1",
+			},
+			&structs.ACLRole{
+				ID:   "a0bfe8d4-b2f3-4b48-b387-f28afb820eab",
+				Name: "role2",
+			},
+		}
+
+		require.NoError(t, s.ACLRoleBatchSet(2, roles))
+
+		require.NoError(t, s.ACLRoleBatchDelete(3, []string{
+			"f1093997-b6c7-496d-bfb8-6b1b1895641b",
+			"a0bfe8d4-b2f3-4b48-b387-f28afb820eab"}))
+
+		_, rroles, err := s.ACLRoleList(nil, "")
+		require.NoError(t, err)
+		require.Len(t, rroles, 0)
+	})
+
+	t.Run("Linked Tokens", func(t *testing.T) {
+		t.Parallel()
+		s := testACLTokensStateStore(t)
+
+		token := &structs.ACLToken{
+			AccessorID: "211f0360-ef53-41d3-9d4d-db84396eb6c0",
+			SecretID:   "ee97c18a-4aa3-4eb0-bfb3-7fb0b8e1ebbf",
+			Roles: []structs.ACLTokenRoleLink{
+				structs.ACLTokenRoleLink{
+					ID: "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1",
+				},
+			},
+		}
+		require.NoError(t, s.ACLTokenSet(3, token, false))
+
+		_, rtoken, err := s.ACLTokenGetByAccessor(nil, "211f0360-ef53-41d3-9d4d-db84396eb6c0")
+		require.NoError(t, err)
+		require.Len(t, rtoken.Roles, 1)
+		require.Equal(t, "node-reader", rtoken.Roles[0].Name)
+
+		require.NoError(t, s.ACLRoleDeleteByID(4, "6f3e5ac7-2f06-4d1e-8b42-c1a6d2b3f9a1"))
+
+		// the link to the deleted role is no longer returned
+		_, rtoken, err = s.ACLTokenGetByAccessor(nil, "211f0360-ef53-41d3-9d4d-db84396eb6c0")
+		require.NoError(t, err)
+		require.Len(t, rtoken.Roles, 0)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+
+		// deletion of non-existent roles is not an error
+		require.NoError(t, s.ACLRoleDeleteByName(3, "not-found"))
+		require.NoError(t, s.ACLRoleDeleteByID(3, "376d0cae-dd50-4213-9668-2c7797a7fb2d"))
+	})
+}
+
+func TestStateStore_ACLTokens_Snapshot_Restore(t *testing.T) {
+	s := testStateStore(t)
+
+	policies := structs.ACLPolicies{
+		&structs.ACLPolicy{
+			ID:          "ca1fc52c-3676-4050-82ed-ca223e38b2c9",
+			Name:        "policy1",
+			Description: "policy1",
+			Rules:       `node_prefix "" { policy = "read" }`,
+			Syntax:      acl.SyntaxCurrent,
+		},
+		&structs.ACLPolicy{
+			ID:          "7b70fa0f-58cd-412d-93c3-a0f17bb19a3e",
+			Name:        "policy2",
+			Description: "policy2",
+			Rules:       `acl = "read"`,
+			Syntax:      acl.SyntaxCurrent,
+		},
+	}
+
+	for _, policy := range policies {
+		policy.SetHash(true)
+	}
+
+	require.NoError(t, s.ACLPolicyBatchSet(2, policies))
+
+	tokens := structs.ACLTokens{
+		&structs.ACLToken{
+			AccessorID:  "68016c3d-835b-450c-a6f9-75db9ba740be",
+			SecretID:    "838f72b5-5c15-4a9e-aa6d-31734c3a0286",
+			Description: "token1",
+			Policies: []structs.ACLTokenPolicyLink{
+				structs.ACLTokenPolicyLink{
+					ID:   "ca1fc52c-3676-4050-82ed-ca223e38b2c9",
+					Name: "policy1",
+				},
+				structs.ACLTokenPolicyLink{
+					ID:   "7b70fa0f-58cd-412d-93c3-a0f17bb19a3e",
+					Name: "policy2",
+				},
+			},
+			Hash:      []byte{1, 2, 3, 4},
+			RaftIndex: structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		},
+		&structs.ACLToken{
+			AccessorID:  "b2125a1b-2a52-41d4-88f3-c58761998a46",
+			SecretID:    "ba5d9239-a4ab-49b9-ae09-1f19eed92204",
+			Description: "token2",
+			Policies: []structs.ACLTokenPolicyLink{
+				structs.ACLTokenPolicyLink{
+					ID:   "ca1fc52c-3676-4050-82ed-ca223e38b2c9",
+					Name: "policy1",
+				},
+				structs.ACLTokenPolicyLink{
+					ID:   "7b70fa0f-58cd-412d-93c3-a0f17bb19a3e",
+					Name: "policy2",
+				},
+			},
+			Hash:      []byte{1, 2, 3, 4},
+			RaftIndex: structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		},
+	}
+
+	require.NoError(t, s.ACLTokenBatchSet(2, tokens, false))
+
+	// Snapshot the ACLs.
+	snap := s.Snapshot()
+	defer snap.Close()
+
+	// Alter the real state store.
+	require.NoError(t, s.ACLTokenDeleteByAccessor(3, tokens[0].AccessorID))
+
+	// Verify the snapshot.
+	require.Equal(t, uint64(2), snap.LastIndex())
+
+	iter, err := snap.ACLTokens()
+	require.NoError(t, err)
+
+	var dump structs.ACLTokens
+	for token := iter.Next(); token != nil; token = iter.Next() {
+		dump = append(dump, token.(*structs.ACLToken))
+	}
+	require.ElementsMatch(t, dump, tokens)
+
+	// Restore the values into a new state store.
+	func() {
+		s := testStateStore(t)
+		restore := s.Restore()
+		for _, token := range dump {
+			require.NoError(t, restore.ACLToken(token))
+		}
+		restore.Commit()
+
+		// need to ensure we have the policies or else the links will be removed
+		require.NoError(t, s.ACLPolicyBatchSet(2, policies))
+
+		// Read the restored ACLs back out and verify that they match.
+		idx, res, err := s.ACLTokenList(nil, true, true, "", "", "")
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), idx)
+		require.ElementsMatch(t, tokens, res)
+		require.Equal(t, uint64(2), s.maxIndex("acl-tokens"))
+	}()
+}
+
+func TestStateStore_ACLPolicies_Snapshot_Restore(t *testing.T) {
+	s := testStateStore(t)
+
+	policies := structs.ACLPolicies{
+		&structs.ACLPolicy{
+			ID:          "68016c3d-835b-450c-a6f9-75db9ba740be",
+			Name:        "838f72b5-5c15-4a9e-aa6d-31734c3a0286",
+			Description: "policy1",
+			Rules:       `acl = "read"`,
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		},
+		&structs.ACLPolicy{
+			ID:          "b2125a1b-2a52-41d4-88f3-c58761998a46",
+			Name:        "ba5d9239-a4ab-49b9-ae09-1f19eed92204",
+			Description: "policy2",
+			Rules:       `operator = "read"`,
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		},
+	}
+
+	require.NoError(t, s.ACLPolicyBatchSet(2, policies))
+
+	// Snapshot the ACLs.
+	snap := s.Snapshot()
+	defer snap.Close()
+
+	// Alter the real state store.
+	require.NoError(t, s.ACLPolicyDeleteByID(3, policies[0].ID))
+
+	// Verify the snapshot.
+	require.Equal(t, uint64(2), snap.LastIndex())
+
+	iter, err := snap.ACLPolicies()
+	require.NoError(t, err)
 
 	var dump structs.ACLPolicies
 	for policy := iter.Next(); policy != nil; policy = iter.Next() {
@@ -1632,3 +2146,519 @@ func TestStateStore_ACLPolicies_Snapshot_Restore(t *testing.T) {
 		require.Equal(t, uint64(2), s.maxIndex("acl-policies"))
 	}()
 }
+
+func TestStateStore_ACLRoles_Snapshot_Restore(t *testing.T) {
+	s := testStateStore(t)
+
+	roles := structs.ACLRoles{
+		&structs.ACLRole{
+			ID:          "68016c3d-835b-450c-a6f9-75db9ba740be",
+			Name:        "838f72b5-5c15-4a9e-aa6d-31734c3a0286",
+			Description: "role1",
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		},
+		&structs.ACLRole{
+			ID:          "b2125a1b-2a52-41d4-88f3-c58761998a46",
+			Name:        "ba5d9239-a4ab-49b9-ae09-1f19eed92204",
+			Description: "role2",
+			Hash:        []byte{1, 2, 3, 4},
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		},
+	}
+
+	require.NoError(t, s.ACLRoleBatchSet(2, roles))
+
+	// Snapshot the ACLs.
+	snap := s.Snapshot()
+	defer snap.Close()
+
+	// Alter the real state store.
+	require.NoError(t, s.ACLRoleDeleteByID(3, roles[0].ID))
+
+	// Verify the snapshot.
+	require.Equal(t, uint64(2), snap.LastIndex())
+
+	iter, err := snap.ACLRoles()
+	require.NoError(t, err)
+
+	var dump structs.ACLRoles
+	for role := iter.Next(); role != nil; role = iter.Next() {
+		dump = append(dump, role.(*structs.ACLRole))
+	}
+	require.ElementsMatch(t, dump, roles)
+
+	// Restore the values into a new state store.
+	func() {
+		s := testStateStore(t)
+		restore := s.Restore()
+		for _, role := range dump {
+			require.NoError(t, restore.ACLRole(role))
+		}
+		restore.Commit()
+
+		// Read the restored ACLs back out and verify that they match.
+		idx, res, err := s.ACLRoleList(nil, "")
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), idx)
+		require.ElementsMatch(t, roles, res)
+		require.Equal(t, uint64(2), s.maxIndex("acl-roles"))
+	}()
+}
+
+func setupExtraAuthMethods(t *testing.T, s *Store) {
+	methods := structs.ACLAuthMethods{
+		&structs.ACLAuthMethod{
+			Name:        "test",
+			Type:        "testing",
+			Description: "test",
+		},
+	}
+
+	require.NoError(t, s.ACLAuthMethodBatchSet(2, methods))
+}
+
+func TestStateStore_ACLAuthMethod_SetGet(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Missing Name", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+
+		method := structs.ACLAuthMethod{
+			Name:        "",
+			Type:        "testing",
+			Description: "test",
+		}
+
+		require.Error(t, s.ACLAuthMethodSet(3, &method))
+	})
+
+	t.Run("Missing Type", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+
+		method := structs.ACLAuthMethod{
+			Name:        "test",
+			Type:        "",
+			Description: "test",
+		}
+
+		require.Error(t, s.ACLAuthMethodSet(3, &method))
+	})
+
+	t.Run("New", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+
+		method := structs.ACLAuthMethod{
+			Name:        "test",
+			Type:        "testing",
+			Description: "test",
+			Config: map[string]interface{}{
+				"SessionID": "4f64b8e1-4f86-4ec2-8fd3-4f0cd0f0a1b6",
+			},
+		}
+
+		require.NoError(t, s.ACLAuthMethodSet(3, &method))
+
+		idx, rmethod, err := s.ACLAuthMethodGetByName(nil, "test")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), idx)
+		require.NotNil(t, rmethod)
+		require.Equal(t, "test", rmethod.Name)
+		require.Equal(t, "testing", rmethod.Type)
+		require.Equal(t, method.Config, rmethod.Config)
+		require.Equal(t, uint64(3), rmethod.CreateIndex)
+		require.Equal(t, uint64(3), rmethod.ModifyIndex)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+		setupExtraAuthMethods(t, s)
+
+		update := structs.ACLAuthMethod{
+			Name:        "test",
+			Type:        "testing",
+			Description: "modified",
+		}
+
+		require.NoError(t, s.ACLAuthMethodSet(3, &update))
+
+		idx, rmethod, err := s.ACLAuthMethodGetByName(nil, "test")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), idx)
+		require.NotNil(t, rmethod)
+		require.Equal(t, "modified", rmethod.Description)
+		require.Equal(t, uint64(2), rmethod.CreateIndex)
+		require.Equal(t, uint64(3), rmethod.ModifyIndex)
+	})
+
+	t.Run("Type Change", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+		setupExtraAuthMethods(t, s)
+
+		update := structs.ACLAuthMethod{
+			Name: "test",
+			Type: "jwt",
+		}
+
+		require.Error(t, s.ACLAuthMethodSet(3, &update))
+	})
+}
+
+func TestStateStore_ACLAuthMethod_List(t *testing.T) {
+	t.Parallel()
+	s := testACLStateStore(t)
+
+	methods := structs.ACLAuthMethods{
+		&structs.ACLAuthMethod{
+			Name:        "test-1",
+			Type:        "testing",
+			Description: "test-1",
+		},
+		&structs.ACLAuthMethod{
+			Name:        "test-2",
+			Type:        "testing",
+			Description: "test-2",
+		},
+	}
+
+	require.NoError(t, s.ACLAuthMethodBatchSet(2, methods))
+
+	_, rmethods, err := s.ACLAuthMethodList(nil)
+	require.NoError(t, err)
+	require.Len(t, rmethods, 2)
+	rmethods.Sort()
+	require.Equal(t, "test-1", rmethods[0].Name)
+	require.Equal(t, "test-2", rmethods[1].Name)
+}
+
+func TestStateStore_ACLAuthMethod_Delete(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Name", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+		setupExtraAuthMethods(t, s)
+
+		_, rmethod, err := s.ACLAuthMethodGetByName(nil, "test")
+		require.NoError(t, err)
+		require.NotNil(t, rmethod)
+
+		require.NoError(t, s.ACLAuthMethodDeleteByName(3, "test"))
+
+		_, rmethod, err = s.ACLAuthMethodGetByName(nil, "test")
+		require.NoError(t, err)
+		require.Nil(t, rmethod)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+
+		// deletion of non-existant methods is not an error
+		require.NoError(t, s.ACLAuthMethodDeleteByName(3, "not-found"))
+	})
+
+	t.Run("Cascades", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+		setupExtraAuthMethods(t, s)
+
+		rule := &structs.ACLBindingRule{
+			ID:         "3ebcc27b-f8ba-4611-b385-79a065dfb983",
+			AuthMethod: "test",
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "web",
+		}
+		require.NoError(t, s.ACLBindingRuleSet(3, rule))
+
+		token := &structs.ACLToken{
+			AccessorID: "e8aeb69a-0ace-42b9-b95f-d1d9eafe1561",
+			SecretID:   "a8b9c0f1-7a47-4a3b-a37f-76a2c3d0e2e4",
+			AuthMethod: "test",
+			Local:      true,
+			ServiceIdentities: []*structs.ACLServiceIdentity{
+				{ServiceName: "web"},
+			},
+		}
+		require.NoError(t, s.ACLTokenSet(4, token, false))
+
+		require.NoError(t, s.ACLAuthMethodDeleteByName(5, "test"))
+
+		_, rrule, err := s.ACLBindingRuleGetByID(nil, rule.ID)
+		require.NoError(t, err)
+		require.Nil(t, rrule)
+
+		_, rtoken, err := s.ACLTokenGetByAccessor(nil, token.AccessorID)
+		require.NoError(t, err)
+		require.Nil(t, rtoken)
+	})
+}
+
+func TestStateStore_ACLToken_AuthMethod(t *testing.T) {
+	t.Parallel()
+	s := testACLStateStore(t)
+	setupExtraAuthMethods(t, s)
+
+	t.Run("Unknown Method", func(t *testing.T) {
+		token := &structs.ACLToken{
+			AccessorID: "3f8a6c5e-4ee1-4b73-9b0a-fa4e8fbfb1a0",
+			SecretID:   "5b6b0c4e-9d16-4c4b-8c5c-13bd6d6c8d55",
+			AuthMethod: "not-found",
+			Local:      true,
+		}
+		require.Error(t, s.ACLTokenSet(3, token, false))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		token := &structs.ACLToken{
+			AccessorID: "e8aeb69a-0ace-42b9-b95f-d1d9eafe1561",
+			SecretID:   "a8b9c0f1-7a47-4a3b-a37f-76a2c3d0e2e4",
+			AuthMethod: "test",
+			Local:      true,
+		}
+		require.NoError(t, s.ACLTokenSet(3, token, false))
+
+		_, tokens, err := s.ACLTokenList(nil, true, true, "", "", "test")
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		require.Equal(t, token.AccessorID, tokens[0].AccessorID)
+
+		_, _, err = s.ACLTokenList(nil, true, true, structs.ACLPolicyGlobalManagementID, "", "test")
+		require.Error(t, err)
+	})
+}
+
+func TestStateStore_ACLBindingRule_SetGet(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Missing ID", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+		setupExtraAuthMethods(t, s)
+
+		rule := structs.ACLBindingRule{
+			AuthMethod: "test",
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "web",
+		}
+
+		require.Error(t, s.ACLBindingRuleSet(3, &rule))
+	})
+
+	t.Run("Missing AuthMethod", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+		setupExtraAuthMethods(t, s)
+
+		rule := structs.ACLBindingRule{
+			ID:       "3ebcc27b-f8ba-4611-b385-79a065dfb983",
+			BindType: structs.BindingRuleBindTypeService,
+			BindName: "web",
+		}
+
+		require.Error(t, s.ACLBindingRuleSet(3, &rule))
+	})
+
+	t.Run("Unknown AuthMethod", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+		setupExtraAuthMethods(t, s)
+
+		rule := structs.ACLBindingRule{
+			ID:         "3ebcc27b-f8ba-4611-b385-79a065dfb983",
+			AuthMethod: "not-found",
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "web",
+		}
+
+		require.Error(t, s.ACLBindingRuleSet(3, &rule))
+	})
+
+	t.Run("New and Update", func(t *testing.T) {
+		t.Parallel()
+		s := testACLStateStore(t)
+		setupExtraAuthMethods(t, s)
+
+		rule := structs.ACLBindingRule{
+			ID:          "3ebcc27b-f8ba-4611-b385-79a065dfb983",
+			Description: "test",
+			AuthMethod:  "test",
+			Selector:    "value.team == web",
+			BindType:    structs.BindingRuleBindTypeService,
+			BindName:    "web",
+		}
+
+		require.NoError(t, s.ACLBindingRuleSet(3, &rule))
+
+		idx, rrule, err := s.ACLBindingRuleGetByID(nil, rule.ID)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), idx)
+		require.NotNil(t, rrule)
+		require.Equal(t, "value.team == web", rrule.Selector)
+		require.Equal(t, uint64(3), rrule.CreateIndex)
+		require.Equal(t, uint64(3), rrule.ModifyIndex)
+
+		update := rule.Clone()
+		update.BindType = structs.BindingRuleBindTypeRole
+		update.BindName = "web-role"
+		require.NoError(t, s.ACLBindingRuleSet(4, update))
+
+		idx, rrule, err = s.ACLBindingRuleGetByID(nil, rule.ID)
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), idx)
+		require.Equal(t, structs.BindingRuleBindTypeRole, rrule.BindType)
+		require.Equal(t, "web-role", rrule.BindName)
+		require.Equal(t, uint64(3), rrule.CreateIndex)
+		require.Equal(t, uint64(4), rrule.ModifyIndex)
+	})
+}
+
+func TestStateStore_ACLBindingRule_List(t *testing.T) {
+	t.Parallel()
+	s := testACLStateStore(t)
+	setupExtraAuthMethods(t, s)
+	require.NoError(t, s.ACLAuthMethodSet(3, &structs.ACLAuthMethod{
+		Name: "other",
+		Type: "testing",
+	}))
+
+	rules := structs.ACLBindingRules{
+		&structs.ACLBindingRule{
+			ID:         "3ebcc27b-f8ba-4611-b385-79a065dfb983",
+			AuthMethod: "test",
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "web",
+		},
+		&structs.ACLBindingRule{
+			ID:         "9669b2d7-455c-4d70-a0ac-457fd7969a2e",
+			AuthMethod: "other",
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "db",
+		},
+	}
+
+	require.NoError(t, s.ACLBindingRuleBatchSet(4, rules))
+
+	_, rrules, err := s.ACLBindingRuleList(nil, "")
+	require.NoError(t, err)
+	require.Len(t, rrules, 2)
+	rrules.Sort()
+	require.Equal(t, rules[0].ID, rrules[0].ID)
+	require.Equal(t, rules[1].ID, rrules[1].ID)
+
+	_, rrules, err = s.ACLBindingRuleList(nil, "other")
+	require.NoError(t, err)
+	require.Len(t, rrules, 1)
+	require.Equal(t, rules[1].ID, rrules[0].ID)
+}
+
+func TestStateStore_ACLBindingRule_Delete(t *testing.T) {
+	t.Parallel()
+	s := testACLStateStore(t)
+	setupExtraAuthMethods(t, s)
+
+	rule := &structs.ACLBindingRule{
+		ID:         "3ebcc27b-f8ba-4611-b385-79a065dfb983",
+		AuthMethod: "test",
+		BindType:   structs.BindingRuleBindTypeService,
+		BindName:   "web",
+	}
+	require.NoError(t, s.ACLBindingRuleSet(3, rule))
+
+	require.NoError(t, s.ACLBindingRuleDeleteByID(4, rule.ID))
+
+	_, rrule, err := s.ACLBindingRuleGetByID(nil, rule.ID)
+	require.NoError(t, err)
+	require.Nil(t, rrule)
+
+	// deletion of non-existant rules is not an error
+	require.NoError(t, s.ACLBindingRuleDeleteByID(5, rule.ID))
+}
+
+func TestStateStore_ACLAuthMethods_Snapshot_Restore(t *testing.T) {
+	s := testStateStore(t)
+
+	methods := structs.ACLAuthMethods{
+		&structs.ACLAuthMethod{
+			Name:        "test-1",
+			Type:        "testing",
+			Description: "test-1",
+			RaftIndex:   structs.RaftIndex{CreateIndex: 2, ModifyIndex: 2},
+		},
+		&structs.ACLAuthMethod{
+			Name:        "test-2",
+			Type:        "testing",
+			Description: "test-2",
+			RaftIndex:   structs.RaftIndex{CreateIndex: 2, ModifyIndex: 2},
+		},
+	}
+	rules := structs.ACLBindingRules{
+		&structs.ACLBindingRule{
+			ID:         "3ebcc27b-f8ba-4611-b385-79a065dfb983",
+			AuthMethod: "test-1",
+			BindType:   structs.BindingRuleBindTypeService,
+			BindName:   "web",
+			RaftIndex:  structs.RaftIndex{CreateIndex: 3, ModifyIndex: 3},
+		},
+	}
+
+	require.NoError(t, s.ACLAuthMethodBatchSet(2, methods))
+	require.NoError(t, s.ACLBindingRuleBatchSet(3, rules))
+
+	// Snapshot the ACLs.
+	snap := s.Snapshot()
+	defer snap.Close()
+
+	// Alter the real state store.
+	require.NoError(t, s.ACLAuthMethodDeleteByName(4, "test-1"))
+
+	// Verify the snapshot.
+	require.Equal(t, uint64(3), snap.LastIndex())
+
+	iter, err := snap.ACLAuthMethods()
+	require.NoError(t, err)
+
+	var methodDump structs.ACLAuthMethods
+	for method := iter.Next(); method != nil; method = iter.Next() {
+		methodDump = append(methodDump, method.(*structs.ACLAuthMethod))
+	}
+	require.ElementsMatch(t, methodDump, methods)
+
+	iter, err = snap.ACLBindingRules()
+	require.NoError(t, err)
+
+	var ruleDump structs.ACLBindingRules
+	for rule := iter.Next(); rule != nil; rule = iter.Next() {
+		ruleDump = append(ruleDump, rule.(*structs.ACLBindingRule))
+	}
+	require.ElementsMatch(t, ruleDump, rules)
+
+	// Restore the values into a new state store.
+	func() {
+		s := testStateStore(t)
+		restore := s.Restore()
+		for _, method := range methodDump {
+			require.NoError(t, restore.ACLAuthMethod(method))
+		}
+		for _, rule := range ruleDump {
+			require.NoError(t, restore.ACLBindingRule(rule))
+		}
+		restore.Commit()
+
+		// Read the restored ACLs back out and verify that they match.
+		idx, rmethods, err := s.ACLAuthMethodList(nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), idx)
+		require.ElementsMatch(t, methods, rmethods)
+
+		idx, rrules, err := s.ACLBindingRuleList(nil, "")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), idx)
+		require.ElementsMatch(t, rules, rrules)
+	}()
+}
@@ -0,0 +1,101 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+// dnssecKeysTableSchema returns a new table schema used for storing the zone
+// signing keys generated for the DNS domains.
+func dnssecKeysTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "dnssec-keys",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Domain",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
+func init() {
+	registerSchema(dnssecKeysTableSchema)
+}
+
+// DNSSECKeys is used to pull all the generated DNSSEC keys for a snapshot.
+func (s *Snapshot) DNSSECKeys() (memdb.ResultIterator, error) {
+	iter, err := s.tx.Get("dnssec-keys", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// DNSSECKey is used when restoring from a snapshot.
+func (s *Restore) DNSSECKey(key *structs.DNSSECKey) error {
+	if err := s.tx.Insert("dnssec-keys", key); err != nil {
+		return fmt.Errorf("failed restoring dnssec key: %s", err)
+	}
+
+	if err := indexUpdateMaxTxn(s.tx, key.ModifyIndex, "dnssec-keys"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// DNSSECKey returns the generated zone signing key for the given domain, or
+// nil if there is none yet.
+func (s *Store) DNSSECKey(ws memdb.WatchSet, domain string) (uint64, *structs.DNSSECKey, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	idx := maxIndexTxn(tx, "dnssec-keys")
+
+	watchCh, key, err := tx.FirstWatch("dnssec-keys", "id", domain)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed dnssec key lookup: %s", err)
+	}
+	ws.Add(watchCh)
+
+	if key == nil {
+		return idx, nil, nil
+	}
+	return idx, key.(*structs.DNSSECKey), nil
+}
+
+// DNSSECKeyCreate stores a generated zone signing key, unless there already
+// is one for the domain. A key is never replaced, since resolvers only trust
+// the key whose DS record was published, so it returns false if the domain
+// already had one.
+func (s *Store) DNSSECKeyCreate(idx uint64, key *structs.DNSSECKey) (bool, error) {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	existing, err := tx.First("dnssec-keys", "id", key.Domain)
+	if err != nil {
+		return false, fmt.Errorf("failed dnssec key lookup: %s", err)
+	}
+	if existing != nil {
+		return false, nil
+	}
+
+	key.CreateIndex = idx
+	key.ModifyIndex = idx
+	if err := tx.Insert("dnssec-keys", key); err != nil {
+		return false, fmt.Errorf("failed inserting dnssec key: %s", err)
+	}
+	if err := tx.Insert("index", &IndexEntry{"dnssec-keys", idx}); err != nil {
+		return false, fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return true, nil
+}
@@ -0,0 +1,91 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore_DNSSECKey(t *testing.T) {
+	t.Parallel()
+	s := testStateStore(t)
+
+	// There is no key to start with.
+	ws := memdb.NewWatchSet()
+	idx, key, err := s.DNSSECKey(ws, "consul.")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), idx)
+	require.Nil(t, key)
+
+	// Storing a key fires the watch.
+	ok, err := s.DNSSECKeyCreate(1, &structs.DNSSECKey{
+		Domain:     "consul.",
+		PublicKey:  "public",
+		PrivateKey: "private",
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, watchFired(ws))
+
+	idx, key, err = s.DNSSECKey(nil, "CONSUL.")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), idx)
+	require.Equal(t, "private", key.PrivateKey)
+	require.Equal(t, uint64(1), key.CreateIndex)
+
+	// The key is never replaced.
+	ok, err = s.DNSSECKeyCreate(2, &structs.DNSSECKey{
+		Domain:     "consul.",
+		PublicKey:  "other",
+		PrivateKey: "other",
+	})
+	require.NoError(t, err)
+	require.False(t, ok)
+	idx, key, err = s.DNSSECKey(nil, "consul.")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), idx)
+	require.Equal(t, "private", key.PrivateKey)
+
+	// Other domains get their own key.
+	ok, err = s.DNSSECKeyCreate(3, &structs.DNSSECKey{Domain: "example."})
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestStateStore_DNSSECKey_Snapshot_Restore(t *testing.T) {
+	t.Parallel()
+	s := testStateStore(t)
+
+	key := &structs.DNSSECKey{
+		Domain:     "consul.",
+		PublicKey:  "public",
+		PrivateKey: "private",
+	}
+	ok, err := s.DNSSECKeyCreate(1, key)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	snap := s.Snapshot()
+	defer snap.Close()
+	iter, err := snap.DNSSECKeys()
+	require.NoError(t, err)
+	var dump []*structs.DNSSECKey
+	for key := iter.Next(); key != nil; key = iter.Next() {
+		dump = append(dump, key.(*structs.DNSSECKey))
+	}
+	require.Equal(t, []*structs.DNSSECKey{key}, dump)
+
+	s2 := testStateStore(t)
+	restore := s2.Restore()
+	for _, key := range dump {
+		require.NoError(t, restore.DNSSECKey(key))
+	}
+	restore.Commit()
+
+	idx, restored, err := s2.DNSSECKey(nil, "consul.")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), idx)
+	require.Equal(t, key, restored)
+}
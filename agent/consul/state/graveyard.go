@@ -6,10 +6,18 @@ import (
 	"github.com/hashicorp/go-memdb"
 )
 
+// tombstonesReapedIndexName keeps track of the last raft index tombstones were
+// reaped up to. Deletes at or before it may no longer have a tombstone.
+const tombstonesReapedIndexName = "tombstones_reaped"
+
 // Tombstone is the internal type used to track tombstones.
 type Tombstone struct {
 	Key   string
 	Index uint64
+
+	// Tree is set when the tombstone is for a recursive delete, which
+	// removed every key starting with Key rather than just Key.
+	Tree bool
 }
 
 // Graveyard manages a set of tombstones.
@@ -24,10 +32,31 @@ func NewGraveyard(gc *TombstoneGC) *Graveyard {
 	return &Graveyard{gc: gc}
 }
 
-// InsertTxn adds a new tombstone.
+// InsertTxn adds a new tombstone for a single key.
 func (g *Graveyard) InsertTxn(tx *memdb.Txn, key string, idx uint64) error {
+	return g.insertTxn(tx, key, idx, false)
+}
+
+// InsertTreeTxn adds a new tombstone for a recursive delete of the keys
+// starting with the given prefix.
+func (g *Graveyard) InsertTreeTxn(tx *memdb.Txn, prefix string, idx uint64) error {
+	return g.insertTxn(tx, prefix, idx, true)
+}
+
+func (g *Graveyard) insertTxn(tx *memdb.Txn, key string, idx uint64, tree bool) error {
+	// A tombstone replaces the one for the same key, so keep it marked as
+	// recursive if it was, otherwise the keys under it that the earlier
+	// delete removed would be lost track of.
+	existing, err := g.GetTxn(tx, key)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Tree {
+		tree = true
+	}
+
 	// Insert the tombstone.
-	stone := &Tombstone{Key: key, Index: idx}
+	stone := &Tombstone{Key: key, Index: idx, Tree: tree}
 	if err := tx.Insert("tombstones", stone); err != nil {
 		return fmt.Errorf("failed inserting tombstone: %s", err)
 	}
@@ -61,6 +90,37 @@ func (g *Graveyard) GetMaxIndexTxn(tx *memdb.Txn, prefix string) (uint64, error)
 	return lindex, nil
 }
 
+// ListTxn returns the tombstones whose key matches the given prefix.
+func (g *Graveyard) ListTxn(tx *memdb.Txn, prefix string) ([]*Tombstone, error) {
+	stones, err := tx.Get("tombstones", "id_prefix", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying tombstones: %s", err)
+	}
+
+	var out []*Tombstone
+	for stone := stones.Next(); stone != nil; stone = stones.Next() {
+		out = append(out, stone.(*Tombstone))
+	}
+	return out, nil
+}
+
+// GetTxn returns the tombstone for the given key, or nil if there is none.
+func (g *Graveyard) GetTxn(tx *memdb.Txn, key string) (*Tombstone, error) {
+	stone, err := tx.First("tombstones", "id", key)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying tombstones: %s", err)
+	}
+	if stone == nil {
+		return nil, nil
+	}
+	return stone.(*Tombstone), nil
+}
+
+// ReapedIndexTxn returns the last index tombstones were reaped up to.
+func (g *Graveyard) ReapedIndexTxn(tx *memdb.Txn) uint64 {
+	return maxIndexTxn(tx, tombstonesReapedIndexName)
+}
+
 // DumpTxn returns all the tombstones.
 func (g *Graveyard) DumpTxn(tx *memdb.Txn) (memdb.ResultIterator, error) {
 	iter, err := tx.Get("tombstones", "id")
@@ -110,5 +170,9 @@ func (g *Graveyard) ReapTxn(tx *memdb.Txn, idx uint64) error {
 			return fmt.Errorf("failed deleting tombstone: %s", err)
 		}
 	}
+
+	if err := indexUpdateMaxTxn(tx, idx, tombstonesReapedIndexName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
 	return nil
 }
@@ -1,7 +1,9 @@
 package state
 
 import (
+	"encoding/binary"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -9,6 +11,10 @@ import (
 	"github.com/hashicorp/go-memdb"
 )
 
+// kvsClearedIndexName keeps track of the last raft index the whole KV store was
+// deleted at, which doesn't leave a tombstone.
+const kvsClearedIndexName = "kvs_cleared"
+
 // kvsTableSchema returns a new table schema used for storing key/value data for
 // Consul's kv store.
 func kvsTableSchema() *memdb.TableSchema {
@@ -32,10 +38,143 @@ func kvsTableSchema() *memdb.TableSchema {
 					Field: "Session",
 				},
 			},
+			"expires": &memdb.IndexSchema{
+				Name:         "expires",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer:      &KVSExpirationIndex{},
+			},
+			"change": &memdb.IndexSchema{
+				Name:         "change",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer:      &KVSChangeIndex{},
+			},
 		},
 	}
 }
 
+// KVSExpirationIndex indexes the KV entries that have an expiration time by
+// that time, at second granularity.
+type KVSExpirationIndex struct {
+}
+
+func (s *KVSExpirationIndex) encodeTime(t time.Time) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(t.Unix()))
+	return buf
+}
+
+func (s *KVSExpirationIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	entry, ok := obj.(*structs.DirEntry)
+	if !ok {
+		return false, nil, fmt.Errorf("object is not a DirEntry")
+	}
+	if !entry.HasExpirationTime() {
+		return false, nil, nil
+	}
+	if entry.ExpirationTime.Unix() < 0 {
+		return false, nil, fmt.Errorf("kvs expiration time cannot be before the unix epoch: %s", entry.ExpirationTime)
+	}
+
+	return true, s.encodeTime(*entry.ExpirationTime), nil
+}
+
+func (s *KVSExpirationIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+	arg, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a time.Time: %#v", args[0])
+	}
+	if arg.Unix() < 0 {
+		return nil, fmt.Errorf("argument must be a time.Time after the unix epoch: %s", args[0])
+	}
+
+	return s.encodeTime(arg), nil
+}
+
+func (s *KVSExpirationIndex) PrefixFromArgs(args ...interface{}) ([]byte, error) {
+	// Listing with no arguments walks every indexed entry in expiration
+	// order.
+	if len(args) == 0 {
+		return nil, nil
+	}
+	return s.FromArgs(args...)
+}
+
+// KVSChangeIndex indexes the KV entries by their modify index and the
+// tombstones by the index of their delete, so the changes after an index are
+// found without walking the older ones.
+//
+// The memdb index can only be walked by prefix, so the index is encoded with
+// a byte for each of its bits, most significant first. Every index after a
+// given one then shares a prefix of its bits followed by a 1 where it had a
+// 0, which makes at most 64 prefixes to walk, see kvsChangePrefixesAfter.
+type KVSChangeIndex struct {
+}
+
+func (s *KVSChangeIndex) encodeIndex(idx uint64) []byte {
+	buf := make([]byte, 64)
+	for i := range buf {
+		if idx&(1<<uint(63-i)) != 0 {
+			buf[i] = '1'
+		} else {
+			buf[i] = '0'
+		}
+	}
+	return buf
+}
+
+func (s *KVSChangeIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	switch v := obj.(type) {
+	case *structs.DirEntry:
+		return true, s.encodeIndex(v.ModifyIndex), nil
+	case *Tombstone:
+		return true, s.encodeIndex(v.Index), nil
+	default:
+		return false, nil, fmt.Errorf("object is not a DirEntry or a Tombstone")
+	}
+}
+
+func (s *KVSChangeIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+	arg, ok := args[0].(uint64)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a uint64: %#v", args[0])
+	}
+	return s.encodeIndex(arg), nil
+}
+
+func (s *KVSChangeIndex) PrefixFromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+	arg, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a string: %#v", args[0])
+	}
+	return []byte(arg), nil
+}
+
+// kvsChangePrefixesAfter returns the prefixes of the change index that cover
+// every index after the given one, in increasing order of the indexes they
+// cover.
+func kvsChangePrefixesAfter(idx uint64) []string {
+	bits := string((&KVSChangeIndex{}).encodeIndex(idx))
+
+	var prefixes []string
+	for i := len(bits) - 1; i >= 0; i-- {
+		if bits[i] == '0' {
+			prefixes = append(prefixes, bits[:i]+"1")
+		}
+	}
+	return prefixes
+}
+
 // tombstonesTableSchema returns a new table schema used for storing tombstones
 // during KV delete operations to prevent the index from sliding backwards.
 func tombstonesTableSchema() *memdb.TableSchema {
@@ -51,6 +190,12 @@ func tombstonesTableSchema() *memdb.TableSchema {
 					Lowercase: false,
 				},
 			},
+			"change": &memdb.IndexSchema{
+				Name:         "change",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer:      &KVSChangeIndex{},
+			},
 		},
 	}
 }
@@ -110,6 +255,38 @@ func (s *Store) ReapTombstones(index uint64) error {
 	return nil
 }
 
+// KVSListExpired returns up to max KV entries whose expiration time is
+// before asOf, oldest first.
+func (s *Store) KVSListExpired(asOf time.Time, max int) (structs.DirEntries, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	iter, err := tx.Get("kvs", "expires_prefix")
+	if err != nil {
+		return nil, fmt.Errorf("failed kvs lookup: %s", err)
+	}
+
+	var entries structs.DirEntries
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		entry := raw.(*structs.DirEntry)
+		if entry.ExpirationTime.Unix() > asOf.Unix() {
+			// The index is ordered by expiration time (at second
+			// granularity) so everything after this entry is unexpired too.
+			break
+		}
+		if !entry.IsExpired(asOf) {
+			continue
+		}
+
+		entries = append(entries, entry)
+		if len(entries) >= max {
+			break
+		}
+	}
+
+	return entries, nil
+}
+
 // KVSSet is used to store a key/value pair.
 func (s *Store) KVSSet(idx uint64, entry *structs.DirEntry) error {
 	tx := s.db.Txn(true)
@@ -162,6 +339,11 @@ func (s *Store) kvsSetTxn(tx *memdb.Txn, idx uint64, entry *structs.DirEntry, up
 		return fmt.Errorf("failed updating index: %s", err)
 	}
 
+	// Record the new version in the key's history, if any.
+	if err := s.kvsHistoryRecordTxn(tx, idx, entry.Key, entry); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -323,6 +505,137 @@ func (s *Store) KVSListKeys(ws memdb.WatchSet, prefix, sep string) (uint64, []st
 	return idx, keys, nil
 }
 
+// KVSChanges returns the changes to the keys under the given prefix after the
+// given index, oldest first, along with the index of the latest change. The
+// puts are read from the entries' modify indexes and the deletes from the
+// tombstones, including the ones for recursive deletes that started above the
+// prefix. Only the entries and tombstones changed after the index are visited,
+// through the change index, except for the keys under a recursive delete.
+//
+// When the changes can't be tracked from the index, because it's zero, the
+// whole store was deleted since, or resume is set and tombstones were reaped
+// since, a reset is returned instead with a put for every key.
+func (s *Store) KVSChanges(ws memdb.WatchSet, prefix string, since uint64, resume bool) (uint64, bool, structs.KVSEvents, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Gather the tombstones for recursive deletes of a prefix of the
+	// prefix, as those deleted everything under it. A single delete of a
+	// shorter key never touched the prefix.
+	var parents []*Tombstone
+	for i := 1; i < len(prefix); i++ {
+		stone, err := s.kvsGraveyard.GetTxn(tx, prefix[:i])
+		if err != nil {
+			return 0, false, nil, err
+		}
+		if stone != nil && stone.Tree {
+			parents = append(parents, &Tombstone{Key: prefix, Index: stone.Index, Tree: true})
+		}
+	}
+
+	reset := since == 0 || since < maxIndexTxn(tx, kvsClearedIndexName)
+	if resume && since < s.kvsGraveyard.ReapedIndexTxn(tx) {
+		reset = true
+	}
+	if reset {
+		// Get the entries and the index the same way as a list.
+		idx, ents, err := s.kvsListTxn(tx, ws, prefix)
+		if err != nil {
+			return 0, false, nil, err
+		}
+		if prefix != "" {
+			for _, stone := range parents {
+				if stone.Index > idx {
+					idx = stone.Index
+				}
+			}
+		}
+
+		var events structs.KVSEvents
+		for _, ent := range ents {
+			events = append(events, structs.NewKVSPutEvent(ent))
+		}
+		return idx, true, events, nil
+	}
+
+	// Every change under the prefix touches the entries under it, so watch
+	// those without walking them.
+	entries, err := tx.Get("kvs", "id_prefix", prefix)
+	if err != nil {
+		return 0, false, nil, fmt.Errorf("failed kvs lookup: %s", err)
+	}
+	ws.Add(entries.WatchCh())
+
+	// Gather the puts and deletes since the index.
+	var events structs.KVSEvents
+	for _, bits := range kvsChangePrefixesAfter(since) {
+		ents, err := tx.Get("kvs", "change_prefix", bits)
+		if err != nil {
+			return 0, false, nil, fmt.Errorf("failed kvs lookup: %s", err)
+		}
+		for raw := ents.Next(); raw != nil; raw = ents.Next() {
+			ent := raw.(*structs.DirEntry)
+			if strings.HasPrefix(ent.Key, prefix) {
+				events = append(events, structs.NewKVSPutEvent(ent))
+			}
+		}
+
+		stones, err := tx.Get("tombstones", "change_prefix", bits)
+		if err != nil {
+			return 0, false, nil, fmt.Errorf("failed querying tombstones: %s", err)
+		}
+		for raw := stones.Next(); raw != nil; raw = stones.Next() {
+			stone := raw.(*Tombstone)
+			if strings.HasPrefix(stone.Key, prefix) {
+				events = append(events, &structs.KVSEvent{
+					Type:        structs.KVSEventDelete,
+					Key:         stone.Key,
+					ModifyIndex: stone.Index,
+					Recurse:     stone.Tree,
+				})
+			}
+		}
+	}
+	for _, stone := range parents {
+		if stone.Index > since {
+			events = append(events, &structs.KVSEvent{
+				Type:        structs.KVSEventDelete,
+				Key:         stone.Key,
+				ModifyIndex: stone.Index,
+				Recurse:     stone.Tree,
+			})
+		}
+	}
+
+	// Order the puts and deletes by when they happened.
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].ModifyIndex < events[j].ModifyIndex
+	})
+
+	// A recursive delete removes everything under the key that's older,
+	// but the tombstone only holds the latest delete of the key, so send
+	// the keys under it that are still there again after it.
+	idx := since
+	var out structs.KVSEvents
+	for _, event := range events {
+		out = append(out, event)
+		idx = event.ModifyIndex
+		if !event.Recurse {
+			continue
+		}
+		ents, err := tx.Get("kvs", "id_prefix", event.Key)
+		if err != nil {
+			return 0, false, nil, fmt.Errorf("failed kvs lookup: %s", err)
+		}
+		for raw := ents.Next(); raw != nil; raw = ents.Next() {
+			if ent := raw.(*structs.DirEntry); ent.ModifyIndex < event.ModifyIndex {
+				out = append(out, structs.NewKVSPutEvent(ent))
+			}
+		}
+	}
+	return idx, false, out, nil
+}
+
 // KVSDelete is used to perform a shallow delete on a single key in the
 // the state store.
 func (s *Store) KVSDelete(idx uint64, key string) error {
@@ -363,6 +676,11 @@ func (s *Store) kvsDeleteTxn(tx *memdb.Txn, idx uint64, key string) error {
 		return fmt.Errorf("failed updating index: %s", err)
 	}
 
+	// Record the deletion in the key's history, if any.
+	if err := s.kvsHistoryRecordTxn(tx, idx, key, nil); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -472,6 +790,25 @@ func (s *Store) KVSDeleteTree(idx uint64, prefix string) error {
 // existing transaction.
 func (s *Store) kvsDeleteTreeTxn(tx *memdb.Txn, idx uint64, prefix string) error {
 
+	// Record the deletion of the keys that have a history before they are
+	// gone. This is skipped when no history is configured so deleting a
+	// large tree stays cheap.
+	if len(s.kvsHistory) > 0 {
+		entries, err := tx.Get("kvs", "id_prefix", prefix)
+		if err != nil {
+			return fmt.Errorf("failed kvs lookup: %s", err)
+		}
+		var keys []string
+		for entry := entries.Next(); entry != nil; entry = entries.Next() {
+			keys = append(keys, entry.(*structs.DirEntry).Key)
+		}
+		for _, key := range keys {
+			if err := s.kvsHistoryRecordTxn(tx, idx, key, nil); err != nil {
+				return err
+			}
+		}
+	}
+
 	// For prefix deletes, only insert one tombstone and delete the entire subtree
 
 	deleted, err := tx.DeletePrefix("kvs", "id_prefix", prefix)
@@ -482,9 +819,11 @@ func (s *Store) kvsDeleteTreeTxn(tx *memdb.Txn, idx uint64, prefix string) error
 
 	if deleted {
 		if prefix != "" { // don't insert a tombstone if the entire tree is deleted, all watchers on keys will see the max_index of the tree
-			if err := s.kvsGraveyard.InsertTxn(tx, prefix, idx); err != nil {
+			if err := s.kvsGraveyard.InsertTreeTxn(tx, prefix, idx); err != nil {
 				return fmt.Errorf("failed adding to graveyard: %s", err)
 			}
+		} else if err := tx.Insert("index", &IndexEntry{kvsClearedIndexName, idx}); err != nil {
+			return fmt.Errorf("failed updating index: %s", err)
 		}
 		if err := tx.Insert("index", &IndexEntry{"kvs", idx}); err != nil {
 			return fmt.Errorf("failed updating index: %s", err)
@@ -0,0 +1,225 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+// kvsHistoryTableSchema returns a new table schema used for storing the past
+// versions of the keys that have a KV history configured.
+func kvsHistoryTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "kvs_history",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field:     "Key",
+							Lowercase: false,
+						},
+						&memdb.UintFieldIndex{
+							Field: "ModifyIndex",
+						},
+					},
+				},
+			},
+			"key": &memdb.IndexSchema{
+				Name:         "key",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Key",
+					Lowercase: false,
+				},
+			},
+		},
+	}
+}
+
+func init() {
+	registerSchema(kvsHistoryTableSchema)
+}
+
+// SetKVSHistoryConfig sets the prefixes whose keys have their writes and
+// deletes recorded in the KV history. It must be called before the state
+// store is used, and all the servers should use the same configuration as
+// the history is recorded when the writes are applied.
+func (s *Store) SetKVSHistoryConfig(config []structs.KVSHistoryConfig) {
+	s.kvsHistory = config
+}
+
+// SetKVSHistoryTime sets the time given to the versions recorded in the KV
+// history by the following writes. The FSM sets this to the time the leader
+// stamped on each request so that every server records the same time.
+func (s *Store) SetKVSHistoryTime(t time.Time) {
+	s.kvsHistoryTime = t
+}
+
+// kvsHistoryConfig returns the history configuration for the given key, or
+// nil if no history is kept for it.
+func (s *Store) kvsHistoryConfig(key string) *structs.KVSHistoryConfig {
+	var found *structs.KVSHistoryConfig
+	for i, config := range s.kvsHistory {
+		if !strings.HasPrefix(key, config.Prefix) {
+			continue
+		}
+		if found == nil || len(config.Prefix) > len(found.Prefix) {
+			found = &s.kvsHistory[i]
+		}
+	}
+	return found
+}
+
+// KVSHistoryEnabled returns true if a history is kept for the given key.
+func (s *Store) KVSHistoryEnabled(key string) bool {
+	return s.kvsHistoryConfig(key) != nil
+}
+
+// KVSHistory is used to pull the full KV history for use during snapshots.
+func (s *Snapshot) KVSHistory() (memdb.ResultIterator, error) {
+	iter, err := s.tx.Get("kvs_history", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// KVSHistory is used when restoring from a snapshot.
+func (s *Restore) KVSHistory(entry *structs.KVSHistoryEntry) error {
+	if err := s.tx.Insert("kvs_history", entry); err != nil {
+		return fmt.Errorf("failed inserting kvs history entry: %s", err)
+	}
+
+	if err := indexUpdateMaxTxn(s.tx, entry.ModifyIndex, "kvs_history"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// kvsHistoryRecordTxn records a new version of a key in its history, if a
+// history is configured for the key, and removes the versions that are no
+// longer retained. The entry is nil when the key was deleted.
+func (s *Store) kvsHistoryRecordTxn(tx *memdb.Txn, idx uint64, key string, entry *structs.DirEntry) error {
+	config := s.kvsHistoryConfig(key)
+	if config == nil {
+		return nil
+	}
+
+	now := s.kvsHistoryTime
+	if now.IsZero() {
+		// Requests committed by leaders that don't stamp them with a time
+		// fall back to the local time.
+		now = time.Now().UTC()
+	}
+	version := &structs.KVSHistoryEntry{
+		Key:     key,
+		Deleted: entry == nil,
+		Time:    now,
+	}
+	version.ModifyIndex = idx
+	if entry != nil {
+		version.LockIndex = entry.LockIndex
+		version.Flags = entry.Flags
+		version.Value = entry.Value
+		version.Session = entry.Session
+		version.CreateIndex = entry.CreateIndex
+	}
+	if err := tx.Insert("kvs_history", version); err != nil {
+		return fmt.Errorf("failed inserting kvs history entry: %s", err)
+	}
+
+	// Remove the versions that are over the limits, oldest first.
+	history, err := s.kvsHistoryTxn(tx, nil, key)
+	if err != nil {
+		return err
+	}
+	cutoff := version.Time.Add(-config.Retention)
+	for i, old := range history[:len(history)-1] {
+		overMax := config.MaxVersions > 0 && len(history)-i > config.MaxVersions
+		tooOld := config.Retention > 0 && old.Time.Before(cutoff)
+		if !overMax && !tooOld {
+			break
+		}
+		if err := tx.Delete("kvs_history", old); err != nil {
+			return fmt.Errorf("failed deleting kvs history entry: %s", err)
+		}
+	}
+
+	if err := tx.Insert("index", &IndexEntry{"kvs_history", idx}); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// kvsHistoryTxn returns the recorded versions of a key, oldest first.
+func (s *Store) kvsHistoryTxn(tx *memdb.Txn, ws memdb.WatchSet, key string) (structs.KVSHistory, error) {
+	iter, err := tx.Get("kvs_history", "key", key)
+	if err != nil {
+		return nil, fmt.Errorf("failed kvs history lookup: %s", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var history structs.KVSHistory
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		history = append(history, raw.(*structs.KVSHistoryEntry))
+	}
+
+	// The index on the modify index isn't ordered, so sort here.
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].ModifyIndex < history[j].ModifyIndex
+	})
+	return history, nil
+}
+
+// KVSHistory returns the versions of a key, oldest first. The current entry
+// is always the last version returned, even for keys without a history, so
+// the versions cover every index from the first one on.
+func (s *Store) KVSHistory(ws memdb.WatchSet, key string) (uint64, structs.KVSHistory, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	idx, entry, err := s.kvsGetTxn(tx, ws, key)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	history, err := s.kvsHistoryTxn(tx, ws, key)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Leave out the versions past the retention that haven't been removed
+	// yet because the key hasn't been written since.
+	if config := s.kvsHistoryConfig(key); config != nil && config.Retention > 0 && len(history) > 0 {
+		cutoff := time.Now().Add(-config.Retention)
+		for len(history) > 1 && history[0].Time.Before(cutoff) {
+			history = history[1:]
+		}
+	}
+
+	// Add the current entry if it wasn't recorded, for example because the
+	// history was configured after the last write.
+	if entry != nil {
+		if n := len(history); n == 0 || history[n-1].ModifyIndex < entry.ModifyIndex {
+			history = append(history, &structs.KVSHistoryEntry{
+				LockIndex: entry.LockIndex,
+				Key:       entry.Key,
+				Flags:     entry.Flags,
+				Value:     entry.Value,
+				Session:   entry.Session,
+				RaftIndex: entry.RaftIndex,
+			})
+		}
+	}
+
+	return idx, history, nil
+}
@@ -0,0 +1,200 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+)
+
+// testKVSHistoryValues returns the values and deletion markers of the given
+// versions so they can be compared without the times.
+func testKVSHistoryValues(history structs.KVSHistory) []string {
+	var values []string
+	for _, entry := range history {
+		if entry.Deleted {
+			values = append(values, "<deleted>")
+		} else {
+			values = append(values, string(entry.Value))
+		}
+	}
+	return values
+}
+
+func TestStateStore_KVSHistory(t *testing.T) {
+	s := testStateStore(t)
+	s.SetKVSHistoryConfig([]structs.KVSHistoryConfig{
+		{Prefix: "foo/", MaxVersions: 3},
+		{Prefix: "foo/bar/", MaxVersions: 10},
+	})
+
+	// A key without a history only returns its current version.
+	testSetKey(t, s, 1, "zip", "a")
+	testSetKey(t, s, 2, "zip", "b")
+	idx, history, err := s.KVSHistory(nil, "zip")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), idx)
+	require.Equal(t, []string{"b"}, testKVSHistoryValues(history))
+	require.True(t, history[0].Time.IsZero())
+
+	// Missing keys have no versions.
+	_, history, err = s.KVSHistory(nil, "foo/nope")
+	require.NoError(t, err)
+	require.Len(t, history, 0)
+
+	// Writes and deletes are recorded, and the oldest versions are removed
+	// past the max.
+	ws := memdb.NewWatchSet()
+	_, _, err = s.KVSHistory(ws, "foo/a")
+	require.NoError(t, err)
+	testSetKey(t, s, 3, "foo/a", "1")
+	require.True(t, watchFired(ws))
+	testSetKey(t, s, 4, "foo/a", "2")
+	require.NoError(t, s.KVSDelete(5, "foo/a"))
+	testSetKey(t, s, 6, "foo/a", "3")
+
+	idx, history, err = s.KVSHistory(nil, "foo/a")
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), idx)
+	require.Equal(t, []string{"2", "<deleted>", "3"}, testKVSHistoryValues(history))
+	require.Equal(t, uint64(4), history[0].ModifyIndex)
+	require.Equal(t, uint64(5), history[1].ModifyIndex)
+	require.Equal(t, uint64(6), history[2].ModifyIndex)
+	require.Equal(t, uint64(6), history[2].CreateIndex)
+	require.False(t, history[2].Time.IsZero())
+
+	// The longest prefix is used.
+	for i := 7; i < 12; i++ {
+		testSetKey(t, s, uint64(i), "foo/bar/baz", "v")
+	}
+	_, history, err = s.KVSHistory(nil, "foo/bar/baz")
+	require.NoError(t, err)
+	require.Len(t, history, 5)
+
+	// Deleting a tree records a deletion for each key.
+	ok, err := s.KVSSetCAS(12, &structs.DirEntry{Key: "foo/b", Value: []byte("x")})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, s.KVSDeleteTree(13, "foo/"))
+	for _, key := range []string{"foo/a", "foo/b", "foo/bar/baz"} {
+		_, history, err = s.KVSHistory(nil, key)
+		require.NoError(t, err)
+		require.Equal(t, uint64(13), history[len(history)-1].ModifyIndex)
+		require.True(t, history[len(history)-1].Deleted)
+	}
+}
+
+func TestStateStore_KVSHistory_Retention(t *testing.T) {
+	s := testStateStore(t)
+	s.SetKVSHistoryConfig([]structs.KVSHistoryConfig{
+		{Prefix: "foo/", Retention: time.Hour},
+	})
+
+	// Restore some versions that are past the retention.
+	old := time.Now().Add(-2 * time.Hour)
+	restore := s.Restore()
+	for i, value := range []string{"1", "2"} {
+		entry := &structs.KVSHistoryEntry{
+			Key:   "foo/a",
+			Value: []byte(value),
+			Time:  old.Add(time.Duration(i) * time.Minute),
+		}
+		entry.ModifyIndex = uint64(i + 1)
+		require.NoError(t, restore.KVSHistory(entry))
+	}
+	require.NoError(t, restore.KVS(&structs.DirEntry{
+		Key:       "foo/a",
+		Value:     []byte("2"),
+		RaftIndex: structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+	}))
+	restore.Commit()
+
+	// Reads leave out the expired versions, except the current one.
+	_, history, err := s.KVSHistory(nil, "foo/a")
+	require.NoError(t, err)
+	require.Equal(t, []string{"2"}, testKVSHistoryValues(history))
+
+	// A write removes them.
+	testSetKey(t, s, 3, "foo/a", "3")
+	tx := s.db.Txn(false)
+	history, err = s.kvsHistoryTxn(tx, nil, "foo/a")
+	tx.Abort()
+	require.NoError(t, err)
+	require.Equal(t, []string{"3"}, testKVSHistoryValues(history))
+}
+
+func TestStateStore_KVSHistory_Time(t *testing.T) {
+	s := testStateStore(t)
+	s.SetKVSHistoryConfig([]structs.KVSHistoryConfig{
+		{Prefix: "foo/", Retention: time.Hour},
+	})
+
+	// Versions are given the time set for the writes, and retention is
+	// measured from it rather than from the local clock.
+	start := time.Date(2019, 4, 1, 12, 0, 0, 0, time.UTC)
+	s.SetKVSHistoryTime(start)
+	testSetKey(t, s, 1, "foo/a", "1")
+	s.SetKVSHistoryTime(start.Add(30 * time.Minute))
+	testSetKey(t, s, 2, "foo/a", "2")
+
+	tx := s.db.Txn(false)
+	history, err := s.kvsHistoryTxn(tx, nil, "foo/a")
+	tx.Abort()
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, start, history[0].Time)
+	require.Equal(t, start.Add(30*time.Minute), history[1].Time)
+
+	s.SetKVSHistoryTime(start.Add(90 * time.Minute))
+	require.NoError(t, s.KVSDelete(3, "foo/a"))
+	tx = s.db.Txn(false)
+	history, err = s.kvsHistoryTxn(tx, nil, "foo/a")
+	tx.Abort()
+	require.NoError(t, err)
+	require.Equal(t, []string{"2", "<deleted>"}, testKVSHistoryValues(history))
+}
+
+func TestStateStore_KVSHistory_Snapshot_Restore(t *testing.T) {
+	s := testStateStore(t)
+	s.SetKVSHistoryConfig([]structs.KVSHistoryConfig{
+		{Prefix: "foo/", MaxVersions: 5},
+	})
+	testSetKey(t, s, 1, "foo/a", "1")
+	testSetKey(t, s, 2, "foo/a", "2")
+	require.NoError(t, s.KVSDelete(3, "foo/a"))
+	testSetKey(t, s, 4, "foo/b", "1")
+
+	// Snapshot the history.
+	snap := s.Snapshot()
+	defer snap.Close()
+
+	// Alter the real state store.
+	testSetKey(t, s, 5, "foo/a", "3")
+
+	// Verify the snapshot.
+	require.Equal(t, uint64(4), snap.LastIndex())
+	iter, err := snap.KVSHistory()
+	require.NoError(t, err)
+	var dump structs.KVSHistory
+	for entry := iter.Next(); entry != nil; entry = iter.Next() {
+		dump = append(dump, entry.(*structs.KVSHistoryEntry))
+	}
+	require.Len(t, dump, 4)
+
+	// Restore the values into a new state store.
+	func() {
+		s := testStateStore(t)
+		restore := s.Restore()
+		for _, entry := range dump {
+			require.NoError(t, restore.KVSHistory(entry))
+		}
+		restore.Commit()
+
+		_, history, err := s.KVSHistory(nil, "foo/a")
+		require.NoError(t, err)
+		require.Equal(t, []string{"1", "2", "<deleted>"}, testKVSHistoryValues(history))
+		require.Equal(t, uint64(4), s.maxIndex("kvs_history"))
+	}()
+}
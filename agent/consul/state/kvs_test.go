@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStateStore_GC(t *testing.T) {
@@ -596,6 +597,293 @@ func TestStateStore_KVSListKeys(t *testing.T) {
 	}
 }
 
+// testApplyKVSEvents applies the given changes to a copy of the keys and
+// modify indexes a watcher knows about, like a client following them would.
+func testApplyKVSEvents(known map[string]uint64, reset bool, events structs.KVSEvents) map[string]uint64 {
+	out := make(map[string]uint64)
+	if !reset {
+		for key, idx := range known {
+			out[key] = idx
+		}
+	}
+	for _, event := range events {
+		switch event.Type {
+		case structs.KVSEventPut:
+			out[event.Key] = event.ModifyIndex
+		case structs.KVSEventDelete:
+			if !event.Recurse {
+				delete(out, event.Key)
+				continue
+			}
+			for key, idx := range out {
+				if strings.HasPrefix(key, event.Key) && idx < event.ModifyIndex {
+					delete(out, key)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func TestStateStore_kvsChangePrefixesAfter(t *testing.T) {
+	indexer := &KVSChangeIndex{}
+	covered := func(prefixes []string, idx uint64) int {
+		bits := string(indexer.encodeIndex(idx))
+		n := 0
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(bits, prefix) {
+				n++
+			}
+		}
+		return n
+	}
+
+	indexes := []uint64{0, 1, 2, 255, 256, 1<<32 - 1, 1 << 32, 1<<63 + 5, 1<<64 - 1}
+	for _, since := range indexes {
+		prefixes := kvsChangePrefixesAfter(since)
+		for _, idx := range indexes {
+			// Every index after is covered by exactly one prefix, and
+			// the others by none.
+			want := 0
+			if idx > since {
+				want = 1
+			}
+			require.Equal(t, want, covered(prefixes, idx), "since %d, index %d", since, idx)
+		}
+
+		// The prefixes cover increasing indexes.
+		var last uint64
+		for i, prefix := range prefixes {
+			var low uint64
+			for _, b := range prefix {
+				low <<= 1
+				if b == '1' {
+					low |= 1
+				}
+			}
+			low <<= uint(64 - len(prefix))
+			if i > 0 {
+				require.True(t, low > last, "since %d: %v", since, prefixes)
+			}
+			last = low
+		}
+	}
+}
+
+func TestStateStore_KVSChanges_ChangeIndex(t *testing.T) {
+	s := testStateStore(t)
+
+	// Write and delete across bit boundaries of the index.
+	testSetKey(t, s, 255, "foo/a", "a")
+	testSetKey(t, s, 256, "foo/b", "b")
+	testSetKey(t, s, 1<<32-1, "bar/c", "c")
+	testSetKey(t, s, 1<<32, "foo/c", "c")
+	if err := s.KVSDelete(1<<32+1, "foo/a"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	idx, reset, events, err := s.KVSChanges(nil, "foo/", 255, false)
+	require.NoError(t, err)
+	require.False(t, reset)
+	require.Equal(t, uint64(1<<32+1), idx)
+	require.Len(t, events, 3)
+	require.Equal(t, "foo/b", events[0].Key)
+	require.Equal(t, "foo/c", events[1].Key)
+	require.Equal(t, structs.KVSEventDelete, events[2].Type)
+	require.Equal(t, "foo/a", events[2].Key)
+
+	// Nothing changed after the latest index.
+	idx, reset, events, err = s.KVSChanges(nil, "foo/", 1<<32+1, false)
+	require.NoError(t, err)
+	require.False(t, reset)
+	require.Equal(t, uint64(1<<32+1), idx)
+	require.Len(t, events, 0)
+}
+
+func TestStateStore_KVSChanges(t *testing.T) {
+	s := testStateStore(t)
+
+	// Follows the changes under the prefix from the given index and checks
+	// the result matches a listing.
+	known := make(map[string]uint64)
+	follow := func(prefix string, since uint64, resume bool) (uint64, bool, structs.KVSEvents) {
+		t.Helper()
+		idx, reset, events, err := s.KVSChanges(nil, prefix, since, resume)
+		require.NoError(t, err)
+		known = testApplyKVSEvents(known, reset, events)
+
+		_, ents, err := s.KVSList(nil, prefix)
+		require.NoError(t, err)
+		expected := make(map[string]uint64)
+		for _, ent := range ents {
+			expected[ent.Key] = ent.ModifyIndex
+		}
+		require.Equal(t, expected, known)
+		return idx, reset, events
+	}
+
+	// Starting from zero gives a reset.
+	idx, reset, events := follow("foo/", 0, false)
+	require.Equal(t, uint64(0), idx)
+	require.True(t, reset)
+	require.Len(t, events, 0)
+
+	testSetKey(t, s, 1, "foo/a", "1")
+	testSetKey(t, s, 2, "foo/b", "2")
+	testSetKey(t, s, 3, "foo/bar", "3")
+	testSetKey(t, s, 4, "zip", "4")
+	idx, reset, events = follow("foo/", 0, false)
+	require.Equal(t, uint64(3), idx)
+	require.True(t, reset)
+	require.Len(t, events, 3)
+	require.Equal(t, "foo/a", events[0].Key)
+	require.Equal(t, []byte("1"), events[0].Value)
+
+	// Nothing changed under the prefix since.
+	ws := memdb.NewWatchSet()
+	idx, _, _, err := s.KVSChanges(ws, "foo/", 3, false)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), idx)
+	testSetKey(t, s, 5, "foo/c", "5")
+	require.True(t, watchFired(ws))
+
+	// A single delete only deletes the key.
+	require.NoError(t, s.KVSDelete(6, "foo/b"))
+	idx, reset, events = follow("foo/", 3, false)
+	require.Equal(t, uint64(6), idx)
+	require.False(t, reset)
+	require.Len(t, events, 2)
+	require.Equal(t, "foo/c", events[0].Key)
+	require.Equal(t, structs.KVSEventDelete, events[1].Type)
+	require.Equal(t, "foo/b", events[1].Key)
+	require.Equal(t, uint64(6), events[1].ModifyIndex)
+	require.False(t, events[1].Recurse)
+
+	// Puts and deletes come in order.
+	testSetKey(t, s, 7, "foo/d", "7")
+	require.NoError(t, s.KVSDeleteTree(8, "foo/ba"))
+	testSetKey(t, s, 9, "foo/a", "9")
+	idx, _, events = follow("foo/", 6, false)
+	require.Equal(t, uint64(9), idx)
+	require.Len(t, events, 3)
+	require.Equal(t, "foo/d", events[0].Key)
+	require.Equal(t, "foo/ba", events[1].Key)
+	require.True(t, events[1].Recurse)
+	require.Equal(t, "foo/a", events[2].Key)
+
+	// A later single delete of the same key keeps the tombstone recursive.
+	testSetKey(t, s, 10, "foo/e", "10")
+	testSetKey(t, s, 11, "foo/e/f", "11")
+	testSetKey(t, s, 12, "foo/e/g", "12")
+	require.NoError(t, s.KVSDeleteTree(13, "foo/e/f"))
+	testSetKey(t, s, 14, "foo/e/f", "14")
+	require.NoError(t, s.KVSDelete(15, "foo/e/f"))
+	idx, _, events = follow("foo/", 9, false)
+	require.Equal(t, uint64(15), idx)
+	require.Len(t, events, 3)
+	require.Equal(t, "foo/e/f", events[2].Key)
+	require.True(t, events[2].Recurse)
+
+	// A single delete of a shorter key doesn't touch the prefix.
+	known = nil
+	follow("foo/ez/", 0, false)
+	testSetKey(t, s, 16, "foo/ez/a", "16")
+	require.NoError(t, s.KVSDelete(17, "foo/e"))
+	idx, _, events = follow("foo/ez/", 15, false)
+	require.Equal(t, uint64(16), idx)
+	require.Len(t, events, 1)
+	require.Equal(t, structs.KVSEventPut, events[0].Type)
+
+	// A recursive delete above the prefix deletes the prefix.
+	testSetKey(t, s, 18, "foo/bar/baz", "18")
+	known = nil
+	follow("foo/bar/", 0, false)
+	require.NoError(t, s.KVSDeleteTree(19, "foo/b"))
+	idx, _, events = follow("foo/bar/", 18, false)
+	require.Equal(t, uint64(19), idx)
+	require.Len(t, events, 1)
+	require.Equal(t, structs.KVSEventDelete, events[0].Type)
+	require.Equal(t, "foo/bar/", events[0].Key)
+	require.True(t, events[0].Recurse)
+
+	// Resuming from before tombstones were reaped gives a reset.
+	known = nil
+	follow("foo/", 0, false)
+	require.NoError(t, s.ReapTombstones(8))
+	_, reset, _ = follow("foo/", 7, true)
+	require.True(t, reset)
+	_, reset, _ = follow("foo/", 8, true)
+	require.False(t, reset)
+	_, reset, _ = follow("foo/", 7, false)
+	require.False(t, reset)
+
+	// Deleting the whole store leaves no tombstone, so it gives a reset.
+	require.NoError(t, s.KVSDeleteTree(20, ""))
+	_, reset, events = follow("foo/", 19, false)
+	require.True(t, reset)
+	require.Len(t, events, 0)
+}
+
+func TestStateStore_KVSListExpired(t *testing.T) {
+	t.Parallel()
+	s := testStateStore(t)
+
+	now := time.Now()
+	timeAt := func(offset time.Duration) *time.Time {
+		t := now.Add(offset)
+		return &t
+	}
+
+	entries := structs.DirEntries{
+		// expired an hour ago
+		&structs.DirEntry{Key: "foo/a", ExpirationTime: timeAt(-time.Hour)},
+		// expired a minute ago
+		&structs.DirEntry{Key: "foo/b", ExpirationTime: timeAt(-time.Minute)},
+		// expiring in an hour
+		&structs.DirEntry{Key: "foo/c", ExpirationTime: timeAt(time.Hour)},
+		// never expires
+		&structs.DirEntry{Key: "foo/d"},
+	}
+	for i, entry := range entries {
+		require.NoError(t, s.KVSSet(uint64(i+1), entry))
+	}
+
+	keys := func(entries structs.DirEntries) []string {
+		var out []string
+		for _, entry := range entries {
+			out = append(out, entry.Key)
+		}
+		return out
+	}
+
+	t.Run("now", func(t *testing.T) {
+		expired, err := s.KVSListExpired(now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"foo/a", "foo/b"}, keys(expired))
+	})
+
+	t.Run("max", func(t *testing.T) {
+		expired, err := s.KVSListExpired(now, 1)
+		require.NoError(t, err)
+		require.Equal(t, []string{"foo/a"}, keys(expired))
+	})
+
+	t.Run("later", func(t *testing.T) {
+		expired, err := s.KVSListExpired(now.Add(2*time.Hour), 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"foo/a", "foo/b", "foo/c"}, keys(expired))
+	})
+
+	t.Run("overwritten", func(t *testing.T) {
+		// Writing an entry without an expiration time removes it from the
+		// index.
+		require.NoError(t, s.KVSSet(5, &structs.DirEntry{Key: "foo/a"}))
+		expired, err := s.KVSListExpired(now, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"foo/b"}, keys(expired))
+	})
+}
+
 func TestStateStore_KVSDelete(t *testing.T) {
 	s := testStateStore(t)
 
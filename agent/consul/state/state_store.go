@@ -3,7 +3,9 @@ package state
 import (
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/types"
 	"github.com/hashicorp/go-memdb"
 )
@@ -37,6 +39,26 @@ var (
 	// policy with an empty Name.
 	ErrMissingACLPolicyName = errors.New("Missing ACL Policy Name")
 
+	// ErrMissingACLRoleID is returned when a role set is called on
+	// a role with an empty ID.
+	ErrMissingACLRoleID = errors.New("Missing ACL Role ID")
+
+	// ErrMissingACLRoleName is returned when a role set is called on
+	// a role with an empty Name.
+	ErrMissingACLRoleName = errors.New("Missing ACL Role Name")
+
+	// ErrMissingACLAuthMethodName is returned when an auth method set is
+	// called on an auth method with an empty Name.
+	ErrMissingACLAuthMethodName = errors.New("Missing ACL Auth Method Name")
+
+	// ErrMissingACLBindingRuleID is returned when a binding rule set is
+	// called on a binding rule with an empty ID.
+	ErrMissingACLBindingRuleID = errors.New("Missing ACL Binding Rule ID")
+
+	// ErrMissingACLBindingRuleAuthMethod is returned when a binding rule set
+	// is called on a binding rule with an empty AuthMethod.
+	ErrMissingACLBindingRuleAuthMethod = errors.New("Missing ACL Binding Rule Auth Method")
+
 	// ErrMissingQueryID is returned when a Query set is called on
 	// a Query with an empty ID.
 	ErrMissingQueryID = errors.New("Missing Query ID")
@@ -79,6 +101,15 @@ type Store struct {
 
 	// lockDelay holds expiration times for locks associated with keys.
 	lockDelay *Delay
+
+	// kvsHistory configures which keys have their versions recorded in the
+	// KV history.
+	kvsHistory []structs.KVSHistoryConfig
+
+	// kvsHistoryTime is the time the versions recorded in the KV history
+	// are given. It's set by the FSM to the leader's time for the request
+	// being applied.
+	kvsHistoryTime time.Time
 }
 
 // Snapshot is used to provide a point-in-time snapshot. It
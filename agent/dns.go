@@ -4,8 +4,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"math"
+	"math/rand"
 	"net"
+	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -20,6 +24,7 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/miekg/dns"
 )
 
@@ -37,6 +42,10 @@ const (
 
 	defaultMaxUDPSize = 512
 
+	// dnsRoundRobinIdle is how long a resolver's position in a round-robin
+	// rotation is kept after its last query.
+	dnsRoundRobinIdle = 10 * time.Minute
+
 	MaxDNSLabelLength = 63
 )
 
@@ -65,7 +74,13 @@ type dnsConfig struct {
 	UDPAnswerLimit  int
 	ARecordLimit    int
 	NodeMetaTXT     bool
-	dnsSOAConfig    dnsSOAConfig
+	DNSSECEnabled   bool
+	// NegativeCacheSize is the number of NXDOMAIN responses to cache, or
+	// zero to disable the cache. Each is kept for NegativeCacheTTL, or the
+	// SOA minimum TTL if that is set and shorter.
+	NegativeCacheSize int
+	NegativeCacheTTL  time.Duration
+	dnsSOAConfig      dnsSOAConfig
 }
 
 // DNSServer is used to wrap an Agent and expose various
@@ -75,6 +90,7 @@ type DNSServer struct {
 	agent     *Agent
 	config    *dnsConfig
 	domain    string
+	altDomain string
 	recursors []string
 	logger    *log.Logger
 	// Those are handling prefix lookups
@@ -85,6 +101,20 @@ type DNSServer struct {
 	// be safely changed at runtime. It always contains a bool and is
 	// initialized with the value from config.DisableCompression.
 	disableCompression atomic.Value
+
+	// dnssecZoneKey is the key used to sign responses when DNSSEC is
+	// enabled. It is either loaded from the configured key files up front,
+	// or fetched lazily from the servers by dnssecKey.
+	dnssecZoneKey *dnssecKey
+	dnssecLock    sync.Mutex
+
+	// roundRobin holds the state for services using round-robin ordering.
+	roundRobin dnsRoundRobin
+
+	// negativeCache maps the question of a query that got an NXDOMAIN
+	// response to the time.Time the response expires. It is nil when the
+	// cache is disabled.
+	negativeCache *lru.Cache
 }
 
 func NewDNSServer(a *Agent) (*DNSServer, error) {
@@ -99,12 +129,17 @@ func NewDNSServer(a *Agent) (*DNSServer, error) {
 
 	// Make sure domain is FQDN, make it case insensitive for ServeMux
 	domain := dns.Fqdn(strings.ToLower(a.config.DNSDomain))
+	altDomain := ""
+	if a.config.DNSAltDomain != "" {
+		altDomain = dns.Fqdn(strings.ToLower(a.config.DNSAltDomain))
+	}
 
 	dnscfg := GetDNSConfig(a.config)
 	srv := &DNSServer{
 		agent:     a,
 		config:    dnscfg,
 		domain:    domain,
+		altDomain: altDomain,
 		logger:    a.logger,
 		recursors: recursors,
 		ttlRadix:  radix.New(),
@@ -124,6 +159,26 @@ func NewDNSServer(a *Agent) (*DNSServer, error) {
 
 	srv.disableCompression.Store(a.config.DNSDisableCompression)
 
+	if dnscfg.NegativeCacheSize > 0 {
+		cache, err := lru.New(dnscfg.NegativeCacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create DNS negative cache: %v", err)
+		}
+		srv.negativeCache = cache
+	}
+
+	if a.config.DNSSECEnabled && a.config.DNSSECKeyFile != "" {
+		key, err := loadDNSSECKeyFiles(a.config.DNSSECKeyFile, a.config.DNSSECPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load DNSSEC key: %v", err)
+		}
+		if dns.Fqdn(strings.ToLower(key.dnskey.Hdr.Name)) != domain {
+			return nil, fmt.Errorf("DNSSEC key is for %q, not the DNS domain %q", key.dnskey.Hdr.Name, domain)
+		}
+		a.logger.Printf("[INFO] dns: Loaded DNSSEC key with tag %d, DS record: %s", key.keyTag, key.ds())
+		srv.dnssecZoneKey = key
+	}
+
 	return srv, nil
 }
 
@@ -143,8 +198,12 @@ func GetDNSConfig(conf *config.RuntimeConfig) *dnsConfig {
 		ServiceTTL:      conf.DNSServiceTTL,
 		UDPAnswerLimit:  conf.DNSUDPAnswerLimit,
 		NodeMetaTXT:     conf.DNSNodeMetaTXT,
+		DNSSECEnabled:   conf.DNSSECEnabled,
 		UseCache:        conf.DNSUseCache,
 		CacheMaxAge:     conf.DNSCacheMaxAge,
+
+		NegativeCacheSize: conf.DNSNegativeCacheSize,
+		NegativeCacheTTL:  conf.DNSNegativeCacheTTL,
 		dnsSOAConfig: dnsSOAConfig{
 			Expire:  conf.DNSSOA.Expire,
 			Minttl:  conf.DNSSOA.Minttl,
@@ -170,23 +229,58 @@ func (d *DNSServer) GetTTLForService(service string) (time.Duration, bool) {
 	return time.Duration(0), false
 }
 
-func (d *DNSServer) ListenAndServe(network, addr string, notif func()) error {
+// handler returns the handler that routes queries to the domains, reverse
+// lookups and recursors.
+func (d *DNSServer) handler() dns.Handler {
 	mux := dns.NewServeMux()
 	mux.HandleFunc("arpa.", d.handlePtr)
 	mux.HandleFunc(d.domain, d.handleQuery)
+	if d.altDomain != "" {
+		mux.HandleFunc(d.altDomain, d.handleQuery)
+	}
 	if len(d.recursors) > 0 {
 		mux.HandleFunc(".", d.handleRecurse)
 	}
+	return mux
+}
+
+// responseDomain returns the domain that the given name is in, which is the
+// domain used in the response. Either domain can be a subdomain of the
+// other, so the longest one the name is in wins. Names outside both
+// domains, such as reverse lookups, get the primary domain.
+func (d *DNSServer) responseDomain(name string) string {
+	name = strings.ToLower(dns.Fqdn(name))
+	if d.altDomain == "" || !dns.IsSubDomain(d.altDomain, name) {
+		return d.domain
+	}
+	if dns.IsSubDomain(d.domain, name) && len(d.domain) > len(d.altDomain) {
+		return d.domain
+	}
+	return d.altDomain
+}
 
+// inDomain returns true if the name is in either of the domains.
+func (d *DNSServer) inDomain(name string) bool {
+	name = strings.ToLower(dns.Fqdn(name))
+	return dns.IsSubDomain(d.domain, name) ||
+		(d.altDomain != "" && dns.IsSubDomain(d.altDomain, name))
+}
+
+// ListenAndServe starts the server on the given network, which is one of
+// "udp", "tcp" or "tcp-tls" for DNS-over-TLS.
+func (d *DNSServer) ListenAndServe(network, addr string, notif func()) error {
 	d.Server = &dns.Server{
 		Addr:              addr,
 		Net:               network,
-		Handler:           mux,
+		Handler:           d.handler(),
 		NotifyStartedFunc: notif,
 	}
 	if network == "udp" {
 		d.UDPSize = 65535
 	}
+	if network == "tcp-tls" {
+		d.TLSConfig = d.agent.tlsConfigurator.IncomingDNSConfig()
+	}
 	return d.Server.ListenAndServe()
 }
 
@@ -275,6 +369,8 @@ func (d *DNSServer) handlePtr(resp dns.ResponseWriter, req *dns.Msg) {
 	// Get the QName without the domain suffix
 	qName := strings.ToLower(dns.Fqdn(req.Question[0].Name))
 
+	// Reverse lookups don't say which domain the client uses, so the names
+	// returned are always in the primary domain.
 	args := structs.DCSpecificRequest{
 		Datacenter: datacenter,
 		QueryOptions: structs.QueryOptions{
@@ -346,9 +442,16 @@ func (d *DNSServer) handlePtr(resp dns.ResponseWriter, req *dns.Msg) {
 // handleQuery is used to handle DNS queries in the configured domain
 func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 	q := req.Question[0]
+	rcode := dns.RcodeSuccess
 	defer func(s time.Time) {
 		metrics.MeasureSinceWithLabels([]string{"dns", "domain_query"}, s,
 			[]metrics.Label{{Name: "node", Value: d.agent.config.NodeName}})
+		metrics.MeasureSinceWithLabels([]string{"dns", "query"}, s,
+			[]metrics.Label{
+				{Name: "type", Value: dns.Type(q.Qtype).String()},
+				{Name: "kind", Value: d.queryKind(q.Name)},
+				{Name: "rcode", Value: dns.RcodeToString[rcode]},
+			})
 		d.logger.Printf("[DEBUG] dns: request for name %v type %v class %v (took %v) from client %s (%s)",
 			q.Name, dns.Type(q.Qtype), dns.Class(q.Qclass), time.Since(s), resp.RemoteAddr().String(),
 			resp.RemoteAddr().Network())
@@ -368,17 +471,18 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 	m.RecursionAvailable = (len(d.recursors) > 0)
 
 	ecsGlobal := true
+	domain := d.responseDomain(q.Name)
 
 	switch req.Question[0].Qtype {
 	case dns.TypeSOA:
-		ns, glue := d.nameservers(req.IsEdns0() != nil, maxRecursionLevelDefault)
-		m.Answer = append(m.Answer, d.soa())
+		ns, glue := d.nameservers(domain, req.IsEdns0() != nil, maxRecursionLevelDefault)
+		m.Answer = append(m.Answer, d.soa(domain))
 		m.Ns = append(m.Ns, ns...)
 		m.Extra = append(m.Extra, glue...)
 		m.SetRcode(req, dns.RcodeSuccess)
 
 	case dns.TypeNS:
-		ns, glue := d.nameservers(req.IsEdns0() != nil, maxRecursionLevelDefault)
+		ns, glue := d.nameservers(domain, req.IsEdns0() != nil, maxRecursionLevelDefault)
 		m.Answer = ns
 		m.Extra = glue
 		m.SetRcode(req, dns.RcodeSuccess)
@@ -386,30 +490,120 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 	case dns.TypeAXFR:
 		m.SetRcode(req, dns.RcodeNotImplemented)
 
+	case dns.TypeDNSKEY:
+		if d.config.DNSSECEnabled && strings.ToLower(dns.Fqdn(q.Name)) == d.domain {
+			d.dnssecDNSKEY(req, m)
+			break
+		}
+		ecsGlobal = d.dispatch(network, resp.RemoteAddr(), req, m)
+
 	default:
+		if d.negativeCached(q) {
+			d.addSOA(m)
+			m.SetRcode(req, dns.RcodeNameError)
+			break
+		}
 		ecsGlobal = d.dispatch(network, resp.RemoteAddr(), req, m)
+
+		// Answers that depend on the client's subnet can't be cached.
+		if m.Rcode == dns.RcodeNameError && ecsGlobal {
+			d.cacheNegative(q)
+		}
 	}
 
 	setEDNS(req, m, ecsGlobal)
 
+	// Sign the response if DNSSEC is enabled and the client asked for it.
+	// Only the primary domain is signed.
+	if d.config.DNSSECEnabled && dnssecOK(req) && domain == d.domain && m.Rcode != dns.RcodeServerFailure {
+		if err := d.dnssecSign(network, req, m); err != nil {
+			d.logger.Printf("[ERR] dns: failed to sign response: %v", err)
+			m = new(dns.Msg)
+			m.SetRcode(req, dns.RcodeServerFailure)
+			setEDNS(req, m, ecsGlobal)
+		}
+	}
+
 	// Write out the complete response
+	rcode = m.Rcode
 	if err := resp.WriteMsg(m); err != nil {
 		d.logger.Printf("[WARN] dns: failed to respond: %v", err)
 	}
 }
 
-func (d *DNSServer) soa() *dns.SOA {
+// negativeCacheKey returns the key of a question in the negative cache.
+func negativeCacheKey(q dns.Question) string {
+	return fmt.Sprintf("%s/%d/%d", strings.ToLower(dns.Fqdn(q.Name)), q.Qtype, q.Qclass)
+}
+
+// negativeCached returns true if the question recently got an NXDOMAIN
+// response that can be served again without asking the servers.
+func (d *DNSServer) negativeCached(q dns.Question) bool {
+	if d.negativeCache == nil {
+		return false
+	}
+	key := negativeCacheKey(q)
+	raw, ok := d.negativeCache.Get(key)
+	if !ok {
+		return false
+	}
+	if time.Now().After(raw.(time.Time)) {
+		d.negativeCache.Remove(key)
+		return false
+	}
+	metrics.IncrCounter([]string{"dns", "negative_cache", "hit"}, 1)
+	return true
+}
+
+// cacheNegative remembers that the question got an NXDOMAIN response. The
+// entry is kept for the negative cache TTL, or the SOA minimum TTL if that is
+// set and shorter, since resolvers won't cache the response for longer than
+// that either.
+func (d *DNSServer) cacheNegative(q dns.Question) {
+	if d.negativeCache == nil {
+		return
+	}
+	ttl := d.config.NegativeCacheTTL
+	if minTTL := time.Duration(d.config.dnsSOAConfig.Minttl) * time.Second; minTTL > 0 && minTTL < ttl {
+		ttl = minTTL
+	}
+	d.negativeCache.Add(negativeCacheKey(q), time.Now().Add(ttl))
+}
+
+// queryKind returns the kind of lookup a query in the domain is, such as
+// "service" or "node", for labelling metrics.
+func (d *DNSServer) queryKind(name string) string {
+	qName := strings.ToLower(dns.Fqdn(name))
+	labels := dns.SplitDomainName(strings.TrimSuffix(qName, d.responseDomain(qName)))
+	if len(labels) == 0 {
+		return "domain"
+	}
+
+	// The kind is the last label, or the one before it when a datacenter is
+	// given.
+	for i := len(labels) - 1; i >= 0 && i >= len(labels)-2; i-- {
+		switch kind := labels[i]; {
+		case kind == "service" || kind == "connect" || kind == "node" || kind == "query" || kind == "addr":
+			return kind
+		case strings.HasPrefix(kind, "_"):
+			return "service"
+		}
+	}
+	return "invalid"
+}
+
+func (d *DNSServer) soa(domain string) *dns.SOA {
 	return &dns.SOA{
 		Hdr: dns.RR_Header{
-			Name:   d.domain,
+			Name:   domain,
 			Rrtype: dns.TypeSOA,
 			Class:  dns.ClassINET,
 			// Has to be consistent with MinTTL to avoid invalidation
 			Ttl: d.config.dnsSOAConfig.Minttl,
 		},
-		Ns:      "ns." + d.domain,
+		Ns:      "ns." + domain,
 		Serial:  uint32(time.Now().Unix()),
-		Mbox:    "hostmaster." + d.domain,
+		Mbox:    "hostmaster." + domain,
 		Refresh: d.config.dnsSOAConfig.Refresh,
 		Retry:   d.config.dnsSOAConfig.Retry,
 		Expire:  d.config.dnsSOAConfig.Expire,
@@ -417,15 +611,20 @@ func (d *DNSServer) soa() *dns.SOA {
 	}
 }
 
-// addSOA is used to add an SOA record to a message for the given domain
+// addSOA is used to add an SOA record to a message for the domain of its
+// question
 func (d *DNSServer) addSOA(msg *dns.Msg) {
-	msg.Ns = append(msg.Ns, d.soa())
+	domain := d.domain
+	if len(msg.Question) > 0 {
+		domain = d.responseDomain(msg.Question[0].Name)
+	}
+	msg.Ns = append(msg.Ns, d.soa(domain))
 }
 
 // nameservers returns the names and ip addresses of up to three random servers
 // in the current cluster which serve as authoritative name servers for zone.
-func (d *DNSServer) nameservers(edns bool, maxRecursionLevel int) (ns []dns.RR, extra []dns.RR) {
-	out, err := d.lookupServiceNodes(d.agent.config.Datacenter, structs.ConsulServiceName, "", false, maxRecursionLevel)
+func (d *DNSServer) nameservers(domain string, edns bool, maxRecursionLevel int) (ns []dns.RR, extra []dns.RR) {
+	out, err := d.lookupServiceNodes(d.agent.config.Datacenter, structs.ConsulServiceName, "", false, false, maxRecursionLevel)
 	if err != nil {
 		d.logger.Printf("[WARN] dns: Unable to get list of servers: %s", err)
 		return nil, nil
@@ -447,13 +646,13 @@ func (d *DNSServer) nameservers(edns bool, maxRecursionLevel int) (ns []dns.RR,
 			continue
 		}
 
-		fqdn := name + ".node." + dc + "." + d.domain
+		fqdn := name + ".node." + dc + "." + domain
 		fqdn = dns.Fqdn(strings.ToLower(fqdn))
 
 		// NS record
 		nsrr := &dns.NS{
 			Hdr: dns.RR_Header{
-				Name:   d.domain,
+				Name:   domain,
 				Rrtype: dns.TypeNS,
 				Class:  dns.ClassINET,
 				Ttl:    uint32(d.config.NodeTTL / time.Second),
@@ -491,7 +690,8 @@ func (d *DNSServer) doDispatch(network string, remoteAddr net.Addr, req, resp *d
 
 	// Get the QName without the domain suffix
 	qName := strings.ToLower(dns.Fqdn(req.Question[0].Name))
-	qName = strings.TrimSuffix(qName, d.domain)
+	domain := d.responseDomain(qName)
+	qName = strings.TrimSuffix(qName, domain)
 
 	// Split into the label parts
 	labels := dns.SplitDomainName(qName)
@@ -531,7 +731,7 @@ PARSE:
 			}
 
 			// _name._tag.service.consul
-			d.serviceLookup(network, datacenter, labels[n-3][1:], tag, false, req, resp, maxRecursionLevel)
+			d.serviceLookup(network, datacenter, labels[n-3][1:], tag, false, remoteAddr, req, resp, maxRecursionLevel)
 
 			// Consul 0.3 and prior format for SRV queries
 		} else {
@@ -543,7 +743,7 @@ PARSE:
 			}
 
 			// tag[.tag].name.service.consul
-			d.serviceLookup(network, datacenter, labels[n-2], tag, false, req, resp, maxRecursionLevel)
+			d.serviceLookup(network, datacenter, labels[n-2], tag, false, remoteAddr, req, resp, maxRecursionLevel)
 		}
 
 	case "connect":
@@ -552,7 +752,7 @@ PARSE:
 		}
 
 		// name.connect.consul
-		d.serviceLookup(network, datacenter, labels[n-2], "", true, req, resp, maxRecursionLevel)
+		d.serviceLookup(network, datacenter, labels[n-2], "", true, remoteAddr, req, resp, maxRecursionLevel)
 
 	case "node":
 		if n == 1 {
@@ -588,7 +788,7 @@ PARSE:
 
 			resp.Answer = append(resp.Answer, &dns.A{
 				Hdr: dns.RR_Header{
-					Name:   qName + d.domain,
+					Name:   qName + domain,
 					Rrtype: dns.TypeA,
 					Class:  dns.ClassINET,
 					Ttl:    uint32(d.config.NodeTTL / time.Second),
@@ -604,7 +804,7 @@ PARSE:
 
 			resp.Answer = append(resp.Answer, &dns.AAAA{
 				Hdr: dns.RR_Header{
-					Name:   qName + d.domain,
+					Name:   qName + domain,
 					Rrtype: dns.TypeAAAA,
 					Class:  dns.ClassINET,
 					Ttl:    uint32(d.config.NodeTTL / time.Second),
@@ -1049,8 +1249,9 @@ func (d *DNSServer) trimDNSResponse(network string, req, resp *dns.Msg) (trimmed
 	return trimmed
 }
 
-// lookupServiceNodes returns nodes with a given service.
-func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string, connect bool, maxRecursionLevel int) (structs.IndexedCheckServiceNodes, error) {
+// lookupServiceNodes returns nodes with a given service. If near is set then
+// the nodes are sorted by round trip time from this agent.
+func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string, connect, near bool, maxRecursionLevel int) (structs.IndexedCheckServiceNodes, error) {
 	args := structs.ServiceSpecificRequest{
 		Connect:     connect,
 		Datacenter:  datacenter,
@@ -1063,6 +1264,13 @@ func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string, connect
 			MaxAge:     d.config.CacheMaxAge,
 		},
 	}
+	if near {
+		args.Source = structs.QuerySource{
+			Datacenter: d.agent.config.Datacenter,
+			Segment:    d.agent.config.SegmentName,
+			Node:       d.agent.config.NodeName,
+		}
+	}
 
 	var out structs.IndexedCheckServiceNodes
 
@@ -1107,9 +1315,151 @@ func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string, connect
 	return out, nil
 }
 
+// serviceOrdering returns the DNS answer ordering set in the service-defaults
+// config entry for the service. The entry is always fetched through the agent
+// cache, whatever dns_config.use_cache is set to, since the cache keeps it up
+// to date in the background and spares the servers an extra RPC for every
+// query.
+func (d *DNSServer) serviceOrdering(datacenter, service string) string {
+	args := structs.ConfigEntryQuery{
+		Kind:       structs.ServiceDefaults,
+		Name:       service,
+		Datacenter: datacenter,
+		QueryOptions: structs.QueryOptions{
+			Token:      d.agent.tokens.UserToken(),
+			AllowStale: d.config.AllowStale,
+			MaxAge:     d.config.CacheMaxAge,
+		},
+	}
+
+	raw, _, err := d.agent.cache.Get(cachetype.ConfigEntryName, &args)
+	if err != nil {
+		d.logger.Printf("[DEBUG] dns: failed to fetch service-defaults for %q: %v", service, err)
+		return structs.DNSOrderingRandom
+	}
+	out, ok := raw.(*structs.IndexedConfigEntries)
+	if !ok {
+		// This should never happen, but we want to protect against panics
+		d.logger.Printf("[DEBUG] dns: internal error: response type not correct")
+		return structs.DNSOrderingRandom
+	}
+
+	if len(out.Entries) > 0 {
+		if entry, ok := out.Entries[0].(*structs.ServiceConfigEntry); ok && entry.DNS.Ordering != "" {
+			return entry.DNS.Ordering
+		}
+	}
+	return structs.DNSOrderingRandom
+}
+
+// weightedShuffle randomly orders the nodes so that the chance of a node
+// coming before the others is proportional to its weight. Nodes with a zero
+// weight always come last.
+func weightedShuffle(nodes structs.CheckServiceNodes) {
+	// Each node gets a key of u^(1/weight) for a uniform random u, and
+	// sorting by key in descending order is a weighted random permutation.
+	keys := make([]float64, len(nodes))
+	for i, node := range nodes {
+		if weight := findWeight(node); weight > 0 {
+			keys[i] = math.Pow(rand.Float64(), 1/float64(weight))
+		} else {
+			keys[i] = -rand.Float64()
+		}
+	}
+	sort.Sort(&weightedNodes{nodes, keys})
+}
+
+// weightedNodes sorts nodes by descending key.
+type weightedNodes struct {
+	nodes structs.CheckServiceNodes
+	keys  []float64
+}
+
+func (w *weightedNodes) Len() int {
+	return len(w.nodes)
+}
+
+func (w *weightedNodes) Less(i, j int) bool {
+	return w.keys[i] > w.keys[j]
+}
+
+func (w *weightedNodes) Swap(i, j int) {
+	w.nodes[i], w.nodes[j] = w.nodes[j], w.nodes[i]
+	w.keys[i], w.keys[j] = w.keys[j], w.keys[i]
+}
+
+// dnsRoundRobin tracks the position of each resolver in the rotation of each
+// service that uses round-robin ordering.
+type dnsRoundRobin struct {
+	sync.Mutex
+	next      map[string]*dnsRoundRobinPosition
+	lastPrune time.Time
+}
+
+type dnsRoundRobinPosition struct {
+	offset   int
+	lastSeen time.Time
+}
+
+// rotate sorts the nodes into a stable order and then rotates them by the
+// number of times this resolver has asked for the service before, so that
+// each resolver cycles through all the instances.
+func (r *dnsRoundRobin) rotate(service string, remoteAddr net.Addr, nodes structs.CheckServiceNodes) {
+	if len(nodes) == 0 {
+		return
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Node.Node != nodes[j].Node.Node {
+			return nodes[i].Node.Node < nodes[j].Node.Node
+		}
+		return nodes[i].Service.ID < nodes[j].Service.ID
+	})
+
+	// Resolvers use a random source port for each query, so only the
+	// address identifies them.
+	resolver := ""
+	if remoteAddr != nil {
+		resolver = remoteAddr.String()
+		if host, _, err := net.SplitHostPort(resolver); err == nil {
+			resolver = host
+		}
+	}
+	key := resolver + "/" + service
+
+	r.Lock()
+	now := time.Now()
+	if r.next == nil {
+		r.next = make(map[string]*dnsRoundRobinPosition)
+	}
+	if now.Sub(r.lastPrune) > dnsRoundRobinIdle {
+		for k, pos := range r.next {
+			if now.Sub(pos.lastSeen) > dnsRoundRobinIdle {
+				delete(r.next, k)
+			}
+		}
+		r.lastPrune = now
+	}
+	pos, ok := r.next[key]
+	if !ok {
+		pos = &dnsRoundRobinPosition{}
+		r.next[key] = pos
+	}
+	offset := pos.offset % len(nodes)
+	pos.offset = offset + 1
+	pos.lastSeen = now
+	r.Unlock()
+
+	rotated := append(append(structs.CheckServiceNodes{}, nodes[offset:]...), nodes[:offset]...)
+	copy(nodes, rotated)
+}
+
 // serviceLookup is used to handle a service query
-func (d *DNSServer) serviceLookup(network, datacenter, service, tag string, connect bool, req, resp *dns.Msg, maxRecursionLevel int) {
-	out, err := d.lookupServiceNodes(datacenter, service, tag, connect, maxRecursionLevel)
+func (d *DNSServer) serviceLookup(network, datacenter, service, tag string, connect bool, remoteAddr net.Addr, req, resp *dns.Msg, maxRecursionLevel int) {
+	// Servers can only sort by distance within their own datacenter.
+	ordering := d.serviceOrdering(datacenter, service)
+	near := ordering == structs.DNSOrderingNearest && datacenter == d.agent.config.Datacenter
+
+	out, err := d.lookupServiceNodes(datacenter, service, tag, connect, near, maxRecursionLevel)
 	if err != nil {
 		d.logger.Printf("[ERR] dns: rpc error: %v", err)
 		resp.SetRcode(req, dns.RcodeServerFailure)
@@ -1123,8 +1473,17 @@ func (d *DNSServer) serviceLookup(network, datacenter, service, tag string, conn
 		return
 	}
 
-	// Perform a random shuffle
-	out.Nodes.Shuffle()
+	// Order the answers according to the service's policy
+	switch {
+	case near:
+		// Already sorted by the servers.
+	case ordering == structs.DNSOrderingRoundRobin:
+		d.roundRobin.rotate(service, remoteAddr, out.Nodes)
+	case ordering == structs.DNSOrderingWeighted:
+		weightedShuffle(out.Nodes)
+	default:
+		out.Nodes.Shuffle()
+	}
 
 	// Determine the TTL
 	ttl, _ := d.GetTTLForService(service)
@@ -1426,6 +1785,7 @@ func findWeight(node structs.CheckServiceNode) int {
 func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes, req, resp *dns.Msg, ttl time.Duration, maxRecursionLevel int) {
 	handled := make(map[string]struct{})
 	edns := req.IsEdns0() != nil
+	domain := d.responseDomain(req.Question[0].Name)
 
 	for _, node := range nodes {
 		// Avoid duplicate entries, possible if a node has
@@ -1448,7 +1808,7 @@ func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes
 			Priority: 1,
 			Weight:   uint16(weight),
 			Port:     uint16(node.Service.Port),
-			Target:   fmt.Sprintf("%s.node.%s.%s", node.Node.Node, dc, d.domain),
+			Target:   fmt.Sprintf("%s.node.%s.%s", node.Node.Node, dc, domain),
 		}
 		resp.Answer = append(resp.Answer, srvRec)
 
@@ -1475,13 +1835,13 @@ func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes
 					addr := hex.EncodeToString(record.A)
 
 					// Take the last 8 chars (4 bytes) of the encoded address to avoid junk bytes
-					srvRec.Target = fmt.Sprintf("%s.addr.%s.%s", addr[len(addr)-(net.IPv4len*2):], dc, d.domain)
+					srvRec.Target = fmt.Sprintf("%s.addr.%s.%s", addr[len(addr)-(net.IPv4len*2):], dc, domain)
 					record.Hdr.Name = srvRec.Target
 					resp.Extra = append(resp.Extra, record)
 
 				// IPv6
 				case *dns.AAAA:
-					srvRec.Target = fmt.Sprintf("%s.addr.%s.%s", hex.EncodeToString(record.AAAA), dc, d.domain)
+					srvRec.Target = fmt.Sprintf("%s.addr.%s.%s", hex.EncodeToString(record.AAAA), dc, domain)
 					record.Hdr.Name = srvRec.Target
 					resp.Extra = append(resp.Extra, record)
 
@@ -1559,10 +1919,7 @@ func (d *DNSServer) handleRecurse(resp dns.ResponseWriter, req *dns.Msg) {
 // resolveCNAME is used to recursively resolve CNAME records
 func (d *DNSServer) resolveCNAME(name string, maxRecursionLevel int) []dns.RR {
 	// If the CNAME record points to a Consul address, resolve it internally
-	// Convert query to lowercase because DNS is case insensitive; d.domain is
-	// already converted
-
-	if strings.HasSuffix(strings.ToLower(name), "."+d.domain) {
+	if d.inDomain(name) {
 		if maxRecursionLevel < 1 {
 			d.logger.Printf("[ERR] dns: Infinite recursion detected for %s, won't perform any CNAME resolution.", name)
 			return nil
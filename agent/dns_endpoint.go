@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// dohMediaType is the media type of DNS-over-HTTPS requests and
+	// responses, defined in RFC 8484.
+	dohMediaType = "application/dns-message"
+
+	// dohMaxMessageSize is the largest DNS message that can be sent over
+	// HTTPS.
+	dohMaxMessageSize = 65535
+)
+
+// DNSQuery answers DNS-over-HTTPS queries as described in RFC 8484. The
+// query is a DNS message in wire format, sent either base64url encoded in
+// the dns parameter of a GET request or as the body of a POST request. It
+// is answered by the same handlers as the DNS server.
+func (s *HTTPServer) DNSQuery(resp http.ResponseWriter, req *http.Request) {
+	var buf []byte
+	switch req.Method {
+	case "GET":
+		param := req.URL.Query().Get("dns")
+		if param == "" {
+			http.Error(resp, "Missing dns parameter", http.StatusBadRequest)
+			return
+		}
+		var err error
+		buf, err = base64.RawURLEncoding.DecodeString(param)
+		if err != nil {
+			http.Error(resp, fmt.Sprintf("Invalid dns parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+
+	case "POST":
+		if ct := req.Header.Get("Content-Type"); ct != dohMediaType {
+			http.Error(resp, fmt.Sprintf("Unsupported content type %q", ct), http.StatusUnsupportedMediaType)
+			return
+		}
+		var err error
+		buf, err = ioutil.ReadAll(http.MaxBytesReader(resp, req.Body, dohMaxMessageSize))
+		if err != nil {
+			http.Error(resp, fmt.Sprintf("Failed to read request: %v", err), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+	default:
+		resp.Header().Set("Allow", "GET,POST")
+		http.Error(resp, MethodNotAllowedError{req.Method, []string{"GET", "POST"}}.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	msg := new(dns.Msg)
+	if err := msg.Unpack(buf); err != nil {
+		http.Error(resp, fmt.Sprintf("Invalid DNS message: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(msg.Question) == 0 {
+		http.Error(resp, "DNS message has no question", http.StatusBadRequest)
+		return
+	}
+
+	w := &dohResponseWriter{remoteAddr: dohRemoteAddr(req)}
+	s.dnsHandler.ServeDNS(w, msg)
+	if w.msg == nil {
+		http.Error(resp, "No DNS response", http.StatusInternalServerError)
+		return
+	}
+
+	// The request ID should be zero for cacheability but is echoed back
+	// either way so clients can match the answer.
+	w.msg.Id = msg.Id
+	out, err := w.msg.Pack()
+	if err != nil {
+		http.Error(resp, fmt.Sprintf("Failed to pack DNS response: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	resp.Header().Set("Content-Type", dohMediaType)
+	if ttl, ok := dohMinTTL(w.msg); ok {
+		resp.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", ttl))
+	}
+	resp.Write(out)
+}
+
+// dohRemoteAddr returns the client address of the HTTP request. It is
+// always a TCP address so that responses aren't truncated to fit in a UDP
+// packet.
+func dohRemoteAddr(req *http.Request) net.Addr {
+	addr := &net.TCPAddr{}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err == nil {
+		addr.IP = net.ParseIP(strings.Trim(host, "[]"))
+	}
+	return addr
+}
+
+// dohMinTTL returns the smallest TTL of the records in the response, which
+// is how long the HTTP response may be cached for.
+func dohMinTTL(msg *dns.Msg) (uint32, bool) {
+	var ttl uint32
+	found := false
+	for _, section := range [][]dns.RR{msg.Answer, msg.Ns, msg.Extra} {
+		for _, rr := range section {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			if !found || rr.Header().Ttl < ttl {
+				ttl = rr.Header().Ttl
+				found = true
+			}
+		}
+	}
+	return ttl, found
+}
+
+// dohResponseWriter is a dns.ResponseWriter that captures the response to a
+// DNS-over-HTTPS query.
+type dohResponseWriter struct {
+	remoteAddr net.Addr
+	msg        *dns.Msg
+}
+
+func (w *dohResponseWriter) LocalAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func (w *dohResponseWriter) RemoteAddr() net.Addr {
+	return w.remoteAddr
+}
+
+func (w *dohResponseWriter) WriteMsg(msg *dns.Msg) error {
+	w.msg = msg
+	return nil
+}
+
+func (w *dohResponseWriter) Write(buf []byte) (int, error) {
+	msg := new(dns.Msg)
+	if err := msg.Unpack(buf); err != nil {
+		return 0, err
+	}
+	w.msg = msg
+	return len(buf), nil
+}
+
+func (w *dohResponseWriter) Close() error {
+	return nil
+}
+
+func (w *dohResponseWriter) TsigStatus() error {
+	return nil
+}
+
+func (w *dohResponseWriter) TsigTimersOnly(bool) {}
+
+func (w *dohResponseWriter) Hijack() {}
@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/freeport"
+	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSQuery(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	dnsServer, err := NewDNSServer(a.Agent)
+	require.NoError(t, err)
+	a.srv.dnsHandler = dnsServer.handler()
+
+	m := new(dns.Msg)
+	m.SetQuestion("foo.node.consul.", dns.TypeA)
+	m.Id = 0
+	buf, err := m.Pack()
+	require.NoError(t, err)
+
+	requireAnswer := func(t *testing.T, resp *httptest.ResponseRecorder) {
+		t.Helper()
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, dohMediaType, resp.Header().Get("Content-Type"))
+		require.Equal(t, "max-age=0", resp.Header().Get("Cache-Control"))
+
+		in := new(dns.Msg)
+		require.NoError(t, in.Unpack(resp.Body.Bytes()))
+		require.Len(t, in.Answer, 1)
+		aRec, ok := in.Answer[0].(*dns.A)
+		require.True(t, ok)
+		require.Equal(t, "127.0.0.1", aRec.A.String())
+	}
+
+	t.Run("GET", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/dns-query?dns="+base64.RawURLEncoding.EncodeToString(buf), nil)
+		resp := httptest.NewRecorder()
+		a.srv.DNSQuery(resp, req)
+		requireAnswer(t, resp)
+	})
+
+	t.Run("POST", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/dns-query", bytes.NewReader(buf))
+		req.Header.Set("Content-Type", dohMediaType)
+		resp := httptest.NewRecorder()
+		a.srv.DNSQuery(resp, req)
+		requireAnswer(t, resp)
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/dns-query", nil)
+		resp := httptest.NewRecorder()
+		a.srv.DNSQuery(resp, req)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+
+		req, _ = http.NewRequest("GET", "/dns-query?dns=AAAA", nil)
+		resp = httptest.NewRecorder()
+		a.srv.DNSQuery(resp, req)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+
+		req, _ = http.NewRequest("POST", "/dns-query", bytes.NewReader(buf))
+		req.Header.Set("Content-Type", "application/json")
+		resp = httptest.NewRecorder()
+		a.srv.DNSQuery(resp, req)
+		require.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+
+		req, _ = http.NewRequest("PUT", "/dns-query", bytes.NewReader(buf))
+		resp = httptest.NewRecorder()
+		a.srv.DNSQuery(resp, req)
+		require.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+		require.Equal(t, "GET,POST", resp.Header().Get("Allow"))
+	})
+}
+
+func TestDNS_OverTLS(t *testing.T) {
+	t.Parallel()
+
+	// The certificates in test/ have expired, so make fresh ones.
+	dir := testutil.TempDir(t, "dot")
+	defer os.RemoveAll(dir)
+	ca := connect.TestCA(t, nil)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, []byte(ca.RootCert), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(ca.SigningKey), 0600))
+
+	port := freeport.Get(1)[0]
+	a := NewTestAgent(t, t.Name(), `
+		cert_file = "`+certFile+`"
+		key_file = "`+keyFile+`"
+		ports {
+			dns_tls = `+strconv.Itoa(port)+`
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	m := new(dns.Msg)
+	m.SetQuestion("foo.node.consul.", dns.TypeA)
+	c := &dns.Client{
+		Net:       "tcp-tls",
+		TLSConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	in, _, err := c.Exchange(m, "127.0.0.1:"+strconv.Itoa(port))
+	require.NoError(t, err)
+	require.Len(t, in.Answer, 1)
+	aRec, ok := in.Answer[0].(*dns.A)
+	require.True(t, ok)
+	require.Equal(t, "127.0.0.1", aRec.A.String())
+}
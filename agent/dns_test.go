@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
@@ -6349,3 +6350,493 @@ func TestDNS_formatNodeRecord(t *testing.T) {
 	require.Len(t, records, 1)
 	require.Len(t, meta, 2)
 }
+
+func TestDNS_AltDomain(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		alt_domain = "test-domain"
+		node_name = "server1"
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "db",
+			Address: "127.0.0.2",
+			Port:    12345,
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	for _, domain := range []string{"consul.", "test-domain."} {
+		t.Run(domain, func(t *testing.T) {
+			query := func(name string, qtype uint16) *dns.Msg {
+				m := new(dns.Msg)
+				m.SetQuestion(name, qtype)
+				in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+				require.NoError(t, err)
+				return in
+			}
+
+			in := query("foo.node."+domain, dns.TypeA)
+			require.Len(t, in.Answer, 1)
+			require.Equal(t, "foo.node."+domain, in.Answer[0].Header().Name)
+
+			in = query("db.service."+domain, dns.TypeSRV)
+			require.Len(t, in.Answer, 1)
+			srvRec, ok := in.Answer[0].(*dns.SRV)
+			require.True(t, ok)
+			require.Equal(t, "7f000002.addr.dc1."+domain, srvRec.Target)
+			require.Len(t, in.Extra, 1)
+			require.Equal(t, "7f000002.addr.dc1."+domain, in.Extra[0].Header().Name)
+
+			in = query("7f000002.addr.dc1."+domain, dns.TypeA)
+			require.Len(t, in.Answer, 1)
+			aRec, ok := in.Answer[0].(*dns.A)
+			require.True(t, ok)
+			require.Equal(t, "7f000002.addr.dc1."+domain, aRec.Hdr.Name)
+			require.Equal(t, "127.0.0.2", aRec.A.String())
+
+			in = query(domain, dns.TypeSOA)
+			require.Len(t, in.Answer, 1)
+			soaRec, ok := in.Answer[0].(*dns.SOA)
+			require.True(t, ok)
+			require.Equal(t, domain, soaRec.Hdr.Name)
+			require.Equal(t, "ns."+domain, soaRec.Ns)
+			require.Equal(t, "hostmaster."+domain, soaRec.Mbox)
+			require.Len(t, in.Ns, 1)
+			nsRec, ok := in.Ns[0].(*dns.NS)
+			require.True(t, ok)
+			require.Equal(t, domain, nsRec.Hdr.Name)
+			require.Equal(t, "server1.node.dc1."+domain, nsRec.Ns)
+
+			in = query("nope.service."+domain, dns.TypeA)
+			require.Equal(t, dns.RcodeNameError, in.Rcode)
+			require.Len(t, in.Ns, 1)
+			require.Equal(t, domain, in.Ns[0].Header().Name)
+		})
+	}
+}
+
+func TestDNS_AltDomain_Nested(t *testing.T) {
+	t.Parallel()
+	for _, cfg := range []struct {
+		desc      string
+		domain    string
+		altDomain string
+	}{
+		{"primary inside alt", "dc.internal.", "internal."},
+		{"alt inside primary", "internal.", "dc.internal."},
+	} {
+		t.Run(cfg.desc, func(t *testing.T) {
+			a := NewTestAgent(t, t.Name(), fmt.Sprintf(`
+				domain = %q
+				alt_domain = %q
+				node_name = "server1"
+			`, cfg.domain, cfg.altDomain))
+			defer a.Shutdown()
+			testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+			args := &structs.RegisterRequest{
+				Datacenter: "dc1",
+				Node:       "foo",
+				Address:    "127.0.0.1",
+			}
+			var out struct{}
+			require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+			// Names are answered in the longest domain they're in.
+			for _, domain := range []string{cfg.domain, cfg.altDomain} {
+				m := new(dns.Msg)
+				m.SetQuestion("foo.node."+domain, dns.TypeA)
+				in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+				require.NoError(t, err)
+				require.Len(t, in.Answer, 1)
+				require.Equal(t, "foo.node."+domain, in.Answer[0].Header().Name)
+
+				m = new(dns.Msg)
+				m.SetQuestion(domain, dns.TypeSOA)
+				in, _, err = new(dns.Client).Exchange(m, a.DNSAddr())
+				require.NoError(t, err)
+				require.Len(t, in.Answer, 1)
+				soaRec, ok := in.Answer[0].(*dns.SOA)
+				require.True(t, ok)
+				require.Equal(t, domain, soaRec.Hdr.Name)
+				require.Equal(t, "ns."+domain, soaRec.Ns)
+				require.Len(t, in.Ns, 1)
+				require.Equal(t, "server1.node.dc1."+domain, in.Ns[0].(*dns.NS).Ns)
+			}
+		})
+	}
+}
+
+func TestDNS_ServiceLookup_Ordering(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	serviceNodes := []struct {
+		name    string
+		address string
+		coord   *coordinate.Coordinate
+	}{
+		{"foo1", "198.18.0.1", lib.GenerateCoordinate(1 * time.Millisecond)},
+		{"foo2", "198.18.0.2", lib.GenerateCoordinate(10 * time.Millisecond)},
+		{"foo3", "198.18.0.3", lib.GenerateCoordinate(30 * time.Millisecond)},
+	}
+	for _, service := range []string{"rr", "weighted", "near"} {
+		for _, cfg := range serviceNodes {
+			args := &structs.RegisterRequest{
+				Datacenter: "dc1",
+				Node:       cfg.name,
+				Address:    cfg.address,
+				Service: &structs.NodeService{
+					Service: service,
+					Port:    12345,
+					Weights: &structs.Weights{Passing: 1, Warning: 0},
+				},
+			}
+			// Make the last instance of the weighted service warning, which
+			// gives it a zero weight.
+			if service == "weighted" && cfg.name == "foo3" {
+				args.Check = &structs.HealthCheck{
+					Node:      cfg.name,
+					CheckID:   "weighted",
+					Name:      "weighted",
+					ServiceID: service,
+					Status:    api.HealthWarning,
+				}
+			}
+			var out struct{}
+			require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+			coordArgs := structs.CoordinateUpdateRequest{
+				Datacenter: "dc1",
+				Node:       cfg.name,
+				Coord:      cfg.coord,
+			}
+			require.NoError(t, a.RPC("Coordinate.Update", &coordArgs, &out))
+		}
+	}
+
+	orderings := map[string]string{
+		"rr":       structs.DNSOrderingRoundRobin,
+		"weighted": structs.DNSOrderingWeighted,
+		"near":     structs.DNSOrderingNearest,
+	}
+	for service, ordering := range orderings {
+		args := &structs.ConfigEntryRequest{
+			Datacenter: "dc1",
+			Op:         structs.ConfigEntryUpsert,
+			Entry: &structs.ServiceConfigEntry{
+				Name: service,
+				DNS: structs.ServiceDNSConfiguration{
+					Ordering: ordering,
+				},
+			},
+		}
+		var out struct{}
+		require.NoError(t, a.RPC("ConfigEntry.Apply", args, &out))
+	}
+
+	query := func(t require.TestingT, name string, qtype uint16) []string {
+		m := new(dns.Msg)
+		m.SetQuestion(name, qtype)
+		in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+
+		var addrs []string
+		for _, rr := range in.Answer {
+			switch rr := rr.(type) {
+			case *dns.A:
+				addrs = append(addrs, rr.A.String())
+			case *dns.SRV:
+				addrs = append(addrs, rr.Target)
+			}
+		}
+		return addrs
+	}
+
+	t.Run("round-robin", func(t *testing.T) {
+		want := []string{"198.18.0.1", "198.18.0.2", "198.18.0.3"}
+		first := query(t, "rr.service.consul.", dns.TypeA)
+		require.ElementsMatch(t, want, first)
+
+		// Every query moves the next instance to the front.
+		var firsts []string
+		for i := 0; i < 3; i++ {
+			addrs := query(t, "rr.service.consul.", dns.TypeA)
+			require.ElementsMatch(t, want, addrs)
+			firsts = append(firsts, addrs[0])
+		}
+		require.ElementsMatch(t, want, firsts)
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			addrs := query(t, "weighted.service.consul.", dns.TypeA)
+			require.Len(t, addrs, 3)
+			require.Equal(t, "198.18.0.3", addrs[2])
+
+			targets := query(t, "weighted.service.consul.", dns.TypeSRV)
+			require.Len(t, targets, 3)
+			require.Equal(t, "foo3.node.dc1.consul.", targets[2])
+		}
+	})
+
+	t.Run("nearest", func(t *testing.T) {
+		retry.Run(t, func(r *retry.R) {
+			// Put the agent right next to the first node.
+			coordArgs := structs.CoordinateUpdateRequest{
+				Datacenter: "dc1",
+				Node:       a.config.NodeName,
+				Coord:      lib.GenerateCoordinate(1 * time.Millisecond),
+			}
+			var out struct{}
+			require.NoError(r, a.RPC("Coordinate.Update", &coordArgs, &out))
+
+			addrs := query(r, "near.service.consul.", dns.TypeA)
+			require.Equal(r, []string{"198.18.0.1", "198.18.0.2", "198.18.0.3"}, addrs)
+		})
+	})
+}
+
+func TestDNS_ServiceLookup_Ordering_Cached(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	for i := 1; i <= 3; i++ {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       fmt.Sprintf("foo%d", i),
+			Address:    fmt.Sprintf("198.18.0.%d", i),
+			Service: &structs.NodeService{
+				Service: "rr",
+				Port:    12345,
+			},
+		}
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+
+	args := &structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Op:         structs.ConfigEntryUpsert,
+		Entry: &structs.ServiceConfigEntry{
+			Name: "rr",
+			DNS: structs.ServiceDNSConfiguration{
+				Ordering: structs.DNSOrderingRoundRobin,
+			},
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("ConfigEntry.Apply", args, &out))
+
+	// The entry comes from the agent cache even without use_cache, which is
+	// kept up to date by a blocking query, so every query after the first
+	// moves the next instance to the front.
+	want := []string{"198.18.0.1", "198.18.0.2", "198.18.0.3"}
+	var firsts []string
+	for i := 0; i < 3; i++ {
+		m := new(dns.Msg)
+		m.SetQuestion("rr.service.consul.", dns.TypeA)
+		in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+
+		var addrs []string
+		for _, rr := range in.Answer {
+			addrs = append(addrs, rr.(*dns.A).A.String())
+		}
+		require.ElementsMatch(t, want, addrs)
+		firsts = append(firsts, addrs[0])
+	}
+	require.ElementsMatch(t, want, firsts)
+
+	// The queries left the entry in the cache.
+	query := structs.ConfigEntryQuery{
+		Kind:       structs.ServiceDefaults,
+		Name:       "rr",
+		Datacenter: "dc1",
+	}
+	_, meta, err := a.cache.Get(cachetype.ConfigEntryName, &query)
+	require.NoError(t, err)
+	require.True(t, meta.Hit)
+}
+
+func TestDNS_NegativeCache(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		dns_config {
+			negative_cache_size = 10
+			negative_cache_ttl = "30s"
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	query := func(name string, qtype uint16) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion(name, qtype)
+		in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+		return in
+	}
+
+	in := query("db.service.consul.", dns.TypeA)
+	require.Equal(t, dns.RcodeNameError, in.Rcode)
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "db",
+			Port:    12345,
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	// The NXDOMAIN is served from the cache, with the SOA the servers
+	// would have given.
+	in = query("db.service.consul.", dns.TypeA)
+	require.Equal(t, dns.RcodeNameError, in.Rcode)
+	require.Len(t, in.Ns, 1)
+	_, ok := in.Ns[0].(*dns.SOA)
+	require.True(t, ok)
+
+	// Other query types and names aren't affected.
+	in = query("DB.service.consul.", dns.TypeSRV)
+	require.Equal(t, dns.RcodeSuccess, in.Rcode)
+	require.Len(t, in.Answer, 1)
+
+	// Entries expire after the negative cache TTL.
+	for _, srv := range a.dnsServers {
+		for _, key := range srv.negativeCache.Keys() {
+			srv.negativeCache.Add(key, time.Now().Add(-time.Second))
+		}
+	}
+	in = query("db.service.consul.", dns.TypeA)
+	require.Equal(t, dns.RcodeSuccess, in.Rcode)
+	require.Len(t, in.Answer, 1)
+}
+
+func TestDNS_NegativeCache_SOAMinTTL(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		dns_config {
+			negative_cache_size = 10
+			negative_cache_ttl = "30s"
+			soa {
+				min_ttl = 1
+			}
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	query := func(name string, qtype uint16) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion(name, qtype)
+		in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+		return in
+	}
+
+	in := query("db.service.consul.", dns.TypeA)
+	require.Equal(t, dns.RcodeNameError, in.Rcode)
+	start := time.Now()
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "db",
+			Port:    12345,
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	// The NXDOMAIN is served from the cache, with the SOA minimum TTL the
+	// servers would have given.
+	in = query("db.service.consul.", dns.TypeA)
+	require.Equal(t, dns.RcodeNameError, in.Rcode)
+	require.Len(t, in.Ns, 1)
+	soa, ok := in.Ns[0].(*dns.SOA)
+	require.True(t, ok)
+	require.Equal(t, uint32(1), soa.Minttl)
+
+	// The entry expires after the SOA minimum TTL, which is shorter than
+	// the negative cache TTL.
+	retry.Run(t, func(r *retry.R) {
+		in := query("db.service.consul.", dns.TypeA)
+		if in.Rcode != dns.RcodeSuccess {
+			r.Fatalf("bad rcode: %s", dns.RcodeToString[in.Rcode])
+		}
+	})
+	require.True(t, time.Since(start) >= time.Second)
+	require.True(t, time.Since(start) < 30*time.Second)
+}
+
+func TestDNS_NegativeCache_Disabled(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	m := new(dns.Msg)
+	m.SetQuestion("db.service.consul.", dns.TypeA)
+	in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+	require.NoError(t, err)
+	require.Equal(t, dns.RcodeNameError, in.Rcode)
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "db",
+			Port:    12345,
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	in, _, err = new(dns.Client).Exchange(m, a.DNSAddr())
+	require.NoError(t, err)
+	require.Equal(t, dns.RcodeSuccess, in.Rcode)
+	require.Len(t, in.Answer, 1)
+}
+
+func TestDNS_queryKind(t *testing.T) {
+	t.Parallel()
+	d := &DNSServer{domain: "consul.", altDomain: "alt."}
+
+	cases := map[string]string{
+		"consul.":                          "domain",
+		"db.service.consul.":               "service",
+		"v1.db.service.dc2.consul.":        "service",
+		"_db._tcp.service.consul.":         "service",
+		"_db._tcp.consul.":                 "service",
+		"web.connect.consul.":              "connect",
+		"foo.node.dc1.consul.":             "node",
+		"foo.NODE.alt.":                    "node",
+		"geo.query.consul.":                "query",
+		"7f000001.addr.dc1.consul.":        "addr",
+		"nope.consul.":                     "invalid",
+		"db.service.dc1.something.consul.": "invalid",
+	}
+	for name, kind := range cases {
+		require.Equal(t, kind, d.queryKind(name), name)
+	}
+}
@@ -0,0 +1,263 @@
+package agent
+
+import (
+	"crypto"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/miekg/dns"
+)
+
+const (
+	// dnssecSignatureValidity is how long a signature stays valid after it
+	// is made. Signatures are made online for every response, so this only
+	// needs to outlive the longest time a resolver will cache an answer.
+	dnssecSignatureValidity = 24 * time.Hour
+
+	// dnssecInceptionSkew backdates the start of a signature's validity to
+	// allow for clock skew between Consul and validating resolvers.
+	dnssecInceptionSkew = time.Hour
+)
+
+// dnssecKey is a zone signing key for the Consul DNS domain. A single key
+// is used as both the key signing key and the zone signing key.
+type dnssecKey struct {
+	dnskey *dns.DNSKEY
+	signer crypto.Signer
+	keyTag uint16
+}
+
+// newDNSSECKey pairs a DNSKEY record with its private key.
+func newDNSSECKey(dnskey *dns.DNSKEY, priv crypto.PrivateKey) (*dnssecKey, error) {
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", priv)
+	}
+	return &dnssecKey{
+		dnskey: dnskey,
+		signer: signer,
+		keyTag: dnskey.KeyTag(),
+	}, nil
+}
+
+// parseDNSSECKey parses a key in the BIND format used by dnssec-keygen: the
+// public DNSKEY record and the matching private key file contents.
+func parseDNSSECKey(public, private, file string) (*dnssecKey, error) {
+	rr, err := dns.NewRR(strings.TrimSpace(public))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DNSKEY record: %v", err)
+	}
+	dnskey, ok := rr.(*dns.DNSKEY)
+	if !ok {
+		return nil, fmt.Errorf("expected a DNSKEY record, got %s", dns.TypeToString[rr.Header().Rrtype])
+	}
+	priv, err := dnskey.ReadPrivateKey(strings.NewReader(private), file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	return newDNSSECKey(dnskey, priv)
+}
+
+// loadDNSSECKeyFiles reads a key from the .key and .private files written by
+// dnssec-keygen.
+func loadDNSSECKeyFiles(keyFile, privateKeyFile string) (*dnssecKey, error) {
+	public, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	private, err := ioutil.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return parseDNSSECKey(string(public), string(private), privateKeyFile)
+}
+
+// ds returns the DS record that must be published in the parent zone for
+// resolvers to trust this key.
+func (k *dnssecKey) ds() *dns.DS {
+	return k.dnskey.ToDS(dns.SHA256)
+}
+
+// sign makes a signature for the given RRset, which must be non-empty and
+// share a name, type and class.
+func (k *dnssecKey) sign(rrset []dns.RR, now time.Time) (*dns.RRSIG, error) {
+	sig := &dns.RRSIG{
+		Hdr: dns.RR_Header{
+			Ttl: rrset[0].Header().Ttl,
+		},
+		Algorithm:  k.dnskey.Algorithm,
+		KeyTag:     k.keyTag,
+		SignerName: k.dnskey.Hdr.Name,
+		Inception:  uint32(now.Add(-dnssecInceptionSkew).Unix()),
+		Expiration: uint32(now.Add(dnssecSignatureValidity).Unix()),
+	}
+	if err := sig.Sign(k.signer, rrset); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// dnssecOK returns true if the client set the DNSSEC OK bit in the request.
+func dnssecOK(req *dns.Msg) bool {
+	edns := req.IsEdns0()
+	return edns != nil && edns.Do()
+}
+
+// dnssecKey returns the zone signing key. If no key was configured, the key
+// is fetched from the servers of the primary datacenter, which generate it
+// for the first agent to ask, so that all agents sign with the same key.
+func (d *DNSServer) dnssecKey() (*dnssecKey, error) {
+	d.dnssecLock.Lock()
+	defer d.dnssecLock.Unlock()
+
+	if d.dnssecZoneKey != nil {
+		return d.dnssecZoneKey, nil
+	}
+
+	dc := d.agent.config.PrimaryDatacenter
+	if dc == "" {
+		dc = d.agent.config.Datacenter
+	}
+	args := structs.DNSSECKeyRequest{
+		Datacenter: dc,
+		Domain:     d.domain,
+	}
+	args.Token = d.agent.tokens.AgentToken()
+	var out structs.DNSSECKeyResponse
+	if err := d.agent.RPC("DNSSEC.ZoneKey", &args, &out); err != nil {
+		return nil, err
+	}
+	if out.Key == nil {
+		return nil, fmt.Errorf("no DNSSEC key returned for %q", d.domain)
+	}
+	key, err := parseDNSSECKey(out.Key.PublicKey, out.Key.PrivateKey, "state store")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode DNSSEC key for %q: %v", d.domain, err)
+	}
+	d.logger.Printf("[INFO] dns: Loaded DNSSEC key with tag %d, DS record: %s", key.keyTag, key.ds())
+	d.dnssecZoneKey = key
+	return key, nil
+}
+
+// dnssecDNSKEY answers a DNSKEY query for the zone apex.
+func (d *DNSServer) dnssecDNSKEY(req, resp *dns.Msg) {
+	key, err := d.dnssecKey()
+	if err != nil {
+		d.logger.Printf("[ERR] dns: failed to load DNSSEC key: %v", err)
+		resp.SetRcode(req, dns.RcodeServerFailure)
+		return
+	}
+	dnskey := *key.dnskey
+	dnskey.Hdr.Name = req.Question[0].Name
+	resp.Answer = append(resp.Answer, &dnskey)
+}
+
+// dnssecSign signs every RRset in the response that belongs to the zone and
+// adds a denial of existence for empty answers. Denial uses a single NSEC
+// record at the query name whose next name is the immediate successor of the
+// query name and whose type bitmap only has RRSIG and NSEC. This proves the
+// query name has no data of the queried type without revealing the rest of
+// the zone, so NXDOMAIN answers become NODATA answers.
+func (d *DNSServer) dnssecSign(network string, req, resp *dns.Msg) error {
+	key, err := d.dnssecKey()
+	if err != nil {
+		return err
+	}
+
+	if resp.Rcode == dns.RcodeNameError || (resp.Rcode == dns.RcodeSuccess && len(resp.Answer) == 0 && !resp.Truncated) {
+		resp.Rcode = dns.RcodeSuccess
+		hasSOA := false
+		for _, rr := range resp.Ns {
+			if rr.Header().Rrtype == dns.TypeSOA {
+				hasSOA = true
+			}
+		}
+		if !hasSOA {
+			d.addSOA(resp)
+		}
+
+		qName := req.Question[0].Name
+		resp.Ns = append(resp.Ns, &dns.NSEC{
+			Hdr: dns.RR_Header{
+				Name:   qName,
+				Rrtype: dns.TypeNSEC,
+				Class:  dns.ClassINET,
+				Ttl:    d.config.dnsSOAConfig.Minttl,
+			},
+			NextDomain: `\000.` + qName,
+			TypeBitMap: []uint16{dns.TypeRRSIG, dns.TypeNSEC},
+		})
+	}
+
+	now := time.Now()
+	if resp.Answer, err = d.dnssecSignSection(key, resp.Answer, now); err != nil {
+		return err
+	}
+	if resp.Ns, err = d.dnssecSignSection(key, resp.Ns, now); err != nil {
+		return err
+	}
+	if resp.Extra, err = d.dnssecSignSection(key, resp.Extra, now); err != nil {
+		return err
+	}
+
+	// The signatures can push a UDP response over the client's limit, in
+	// which case the client needs to retry over TCP.
+	if network == "udp" {
+		maxSize := defaultMaxUDPSize
+		if edns := req.IsEdns0(); edns != nil && int(edns.UDPSize()) > maxSize {
+			maxSize = int(edns.UDPSize())
+		}
+		if resp.Len() > maxSize {
+			resp.Truncated = true
+			resp.Answer = nil
+			resp.Ns = nil
+			var extra []dns.RR
+			for _, rr := range resp.Extra {
+				if rr.Header().Rrtype == dns.TypeOPT {
+					extra = append(extra, rr)
+				}
+			}
+			resp.Extra = extra
+		}
+	}
+
+	// Let the client know the response carries DNSSEC records.
+	if opt := resp.IsEdns0(); opt != nil {
+		opt.SetDo()
+	}
+	return nil
+}
+
+// dnssecSignSection returns the records with a signature appended for each
+// RRset owned by the zone. Records outside the zone, such as the results of
+// recursing for a CNAME target, are left unsigned.
+func (d *DNSServer) dnssecSignSection(key *dnssecKey, rrs []dns.RR, now time.Time) ([]dns.RR, error) {
+	var order []string
+	rrsets := make(map[string][]dns.RR)
+	for _, rr := range rrs {
+		hdr := rr.Header()
+		if hdr.Rrtype == dns.TypeOPT || hdr.Rrtype == dns.TypeRRSIG {
+			continue
+		}
+		if !dns.IsSubDomain(d.domain, strings.ToLower(hdr.Name)) {
+			continue
+		}
+		id := fmt.Sprintf("%s/%d/%d", strings.ToLower(hdr.Name), hdr.Rrtype, hdr.Class)
+		if _, ok := rrsets[id]; !ok {
+			order = append(order, id)
+		}
+		rrsets[id] = append(rrsets[id], rr)
+	}
+
+	for _, id := range order {
+		sig, err := key.sign(rrsets[id], now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign %s: %v", id, err)
+		}
+		rrs = append(rrs, sig)
+	}
+	return rrs, nil
+}
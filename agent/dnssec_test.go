@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+// generateDNSSECKey makes a new ECDSA P-256 signing key for the domain.
+func generateDNSSECKey(domain string) (*dnssecKey, error) {
+	dnskey := &dns.DNSKEY{
+		Hdr: dns.RR_Header{
+			Name:   domain,
+			Rrtype: dns.TypeDNSKEY,
+			Class:  dns.ClassINET,
+			Ttl:    3600,
+		},
+		Flags:     dns.ZONE | dns.SEP,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	priv, err := dnskey.Generate(256)
+	if err != nil {
+		return nil, err
+	}
+	return newDNSSECKey(dnskey, priv)
+}
+
+// dnssecQuery sends a query with the DNSSEC OK bit set.
+func dnssecQuery(t *testing.T, a *TestAgent, name string, qtype uint16) *dns.Msg {
+	t.Helper()
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	m.SetEdns0(4096, true)
+
+	c := &dns.Client{Net: "tcp"}
+	in, _, err := c.Exchange(m, a.DNSAddr())
+	require.NoError(t, err)
+	return in
+}
+
+// requireSigned verifies that every RRset in the records has a valid
+// signature made with the given key.
+func requireSigned(t *testing.T, key *dns.DNSKEY, rrs []dns.RR) {
+	t.Helper()
+	rrsets := make(map[string][]dns.RR)
+	sigs := make(map[string]*dns.RRSIG)
+	for _, rr := range rrs {
+		hdr := rr.Header()
+		switch rr := rr.(type) {
+		case *dns.OPT:
+		case *dns.RRSIG:
+			sigs[fmt.Sprintf("%s/%d", strings.ToLower(hdr.Name), rr.TypeCovered)] = rr
+		default:
+			id := fmt.Sprintf("%s/%d", strings.ToLower(hdr.Name), hdr.Rrtype)
+			rrsets[id] = append(rrsets[id], rr)
+		}
+	}
+
+	require.NotEmpty(t, rrsets)
+	for id, rrset := range rrsets {
+		sig, ok := sigs[id]
+		require.True(t, ok, "no signature for %s", id)
+		require.Equal(t, key.KeyTag(), sig.KeyTag)
+		require.NoError(t, sig.Verify(key, rrset), "bad signature for %s", id)
+		require.True(t, sig.ValidityPeriod(time.Now()), "signature for %s not valid now", id)
+	}
+}
+
+func TestDNSSEC_KeyFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := testutil.TempDir(t, "dnssec")
+	defer os.RemoveAll(dir)
+
+	key, err := generateDNSSECKey("consul.")
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, "Kconsul.key")
+	privateKeyFile := filepath.Join(dir, "Kconsul.private")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(key.dnskey.String()+"\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(privateKeyFile, []byte(key.dnskey.PrivateKeyString(key.signer)), 0600))
+
+	a := NewTestAgent(t, t.Name(), `
+		dns_config {
+			dnssec {
+				enabled = true
+				key_file = "`+keyFile+`"
+				private_key_file = "`+privateKeyFile+`"
+			}
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "db",
+			Port:    12345,
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	t.Run("DNSKEY", func(t *testing.T) {
+		in := dnssecQuery(t, a, "consul.", dns.TypeDNSKEY)
+		require.Len(t, in.Answer, 2)
+		dnskey, ok := in.Answer[0].(*dns.DNSKEY)
+		require.True(t, ok)
+		require.Equal(t, key.dnskey.PublicKey, dnskey.PublicKey)
+		requireSigned(t, key.dnskey, in.Answer)
+	})
+
+	t.Run("node lookup", func(t *testing.T) {
+		in := dnssecQuery(t, a, "foo.node.consul.", dns.TypeA)
+		require.Equal(t, dns.RcodeSuccess, in.Rcode)
+		require.True(t, in.IsEdns0().Do())
+		requireSigned(t, key.dnskey, in.Answer)
+	})
+
+	t.Run("service lookup", func(t *testing.T) {
+		in := dnssecQuery(t, a, "db.service.consul.", dns.TypeSRV)
+		require.Equal(t, dns.RcodeSuccess, in.Rcode)
+		requireSigned(t, key.dnskey, in.Answer)
+		requireSigned(t, key.dnskey, in.Extra)
+
+		in = dnssecQuery(t, a, "db.service.consul.", dns.TypeA)
+		require.Equal(t, dns.RcodeSuccess, in.Rcode)
+		requireSigned(t, key.dnskey, in.Answer)
+	})
+
+	t.Run("denial of existence", func(t *testing.T) {
+		in := dnssecQuery(t, a, "nope.service.consul.", dns.TypeA)
+		require.Equal(t, dns.RcodeSuccess, in.Rcode)
+		require.Empty(t, in.Answer)
+		requireSigned(t, key.dnskey, in.Ns)
+
+		var nsec *dns.NSEC
+		for _, rr := range in.Ns {
+			if rr, ok := rr.(*dns.NSEC); ok {
+				nsec = rr
+			}
+		}
+		require.NotNil(t, nsec)
+		require.Equal(t, "nope.service.consul.", nsec.Hdr.Name)
+		require.Equal(t, `\000.nope.service.consul.`, nsec.NextDomain)
+		require.Equal(t, []uint16{dns.TypeRRSIG, dns.TypeNSEC}, nsec.TypeBitMap)
+	})
+
+	t.Run("unsigned without DO", func(t *testing.T) {
+		m := new(dns.Msg)
+		m.SetQuestion("foo.node.consul.", dns.TypeA)
+		in, _, err := new(dns.Client).Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+		require.Len(t, in.Answer, 1)
+		for _, rr := range append(in.Ns, in.Extra...) {
+			_, ok := rr.(*dns.RRSIG)
+			require.False(t, ok)
+		}
+	})
+}
+
+func TestDNSSEC_GeneratedKey(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		dns_config {
+			dnssec {
+				enabled = true
+			}
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	in := dnssecQuery(t, a, "consul.", dns.TypeDNSKEY)
+	require.Len(t, in.Answer, 2)
+	dnskey, ok := in.Answer[0].(*dns.DNSKEY)
+	require.True(t, ok)
+	requireSigned(t, dnskey, in.Answer)
+
+	in = dnssecQuery(t, a, "foo.node.consul.", dns.TypeA)
+	require.Len(t, in.Answer, 2)
+	requireSigned(t, dnskey, in.Answer)
+
+	// The key was generated by the servers for other agents to use, and
+	// not stored in the KV store.
+	get := structs.DNSSECKeyRequest{
+		Datacenter: "dc1",
+		Domain:     "consul.",
+	}
+	var key structs.DNSSECKeyResponse
+	require.NoError(t, a.RPC("DNSSEC.ZoneKey", &get, &key))
+	stored, err := parseDNSSECKey(key.Key.PublicKey, key.Key.PrivateKey, "test")
+	require.NoError(t, err)
+	require.Equal(t, dnskey.PublicKey, stored.dnskey.PublicKey)
+
+	list := structs.KeyRequest{
+		Datacenter: "dc1",
+		Key:        "",
+	}
+	var entries structs.IndexedDirEntries
+	require.NoError(t, a.RPC("KVS.List", &list, &entries))
+	require.Len(t, entries.Entries, 0)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/miekg/dns"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 )
@@ -73,6 +74,10 @@ type HTTPServer struct {
 
 	// proto is filled by the agent to "http" or "https".
 	proto string
+
+	// dnsHandler answers DNS-over-HTTPS queries. It is only set for HTTPS
+	// servers when DNS-over-HTTPS is enabled.
+	dnsHandler dns.Handler
 }
 
 type redirectFS struct {
@@ -200,6 +205,9 @@ func (s *HTTPServer) handler(enableDebug bool) http.Handler {
 	}
 
 	mux.HandleFunc("/", s.Index)
+	if s.dnsHandler != nil {
+		handleFuncMetrics("/dns-query", s.DNSQuery)
+	}
 	for pattern, fn := range endpoints {
 		thisFn := fn
 		methods, _ := allowedMethods[pattern]
@@ -339,7 +347,7 @@ func (s *HTTPServer) wrap(handler endpoint, methods []string) http.HandlerFunc {
 			case isForbidden(err):
 				resp.WriteHeader(http.StatusForbidden)
 				fmt.Fprint(resp, err.Error())
-			case structs.IsErrRPCRateExceeded(err):
+			case structs.IsErrRPCRateExceeded(err), structs.IsErrRPCServerRateExceeded(err):
 				resp.WriteHeader(http.StatusTooManyRequests)
 			case isMethodNotAllowed(err):
 				// RFC2616 states that for 405 Method Not Allowed the response
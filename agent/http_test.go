@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -432,6 +433,24 @@ func TestHTTPAPIResponseHeaders(t *testing.T) {
 	}
 }
 
+func TestHTTPAPI_RateExceeded(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	// Both the client's and the servers' RPC limits are reported as 429s.
+	for _, rpcErr := range []error{structs.ErrRPCRateExceeded, structs.ErrRPCServerRateExceeded} {
+		resp := httptest.NewRecorder()
+		handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+			return nil, errors.New(rpcErr.Error())
+		}
+
+		req, _ := http.NewRequest("GET", "/v1/kv/key", nil)
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+		require.Equal(t, http.StatusTooManyRequests, resp.Code, "error %q", rpcErr)
+	}
+}
+
 func TestContentTypeIsJSON(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -2,11 +2,13 @@ package agent
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
@@ -17,6 +19,11 @@ const (
 	// of a KV entry. If it exceeds this amount, the client is
 	// likely abusing the KV store.
 	maxKVSize = 512 * 1024
+
+	// kvsStreamMaxQueryTime caps how long each blocking query made for a KV
+	// stream waits on the servers, so that a stream whose client went away
+	// doesn't hold one for long.
+	kvsStreamMaxQueryTime = 30 * time.Second
 )
 
 func (s *HTTPServer) KVSEndpoint(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -39,9 +46,20 @@ func (s *HTTPServer) KVSEndpoint(resp http.ResponseWriter, req *http.Request) (i
 	// Switch on the method
 	switch req.Method {
 	case "GET":
+		if _, ok := params["stream"]; ok {
+			for _, flag := range []string{"keys", "history", "recurse", "at-index", "raw"} {
+				if conflictingFlags(resp, req, "stream", flag) {
+					return nil, nil
+				}
+			}
+			return s.KVSStream(resp, req, &args)
+		}
 		if keyList {
 			return s.KVSGetKeys(resp, req, &args)
 		}
+		if _, ok := params["history"]; ok {
+			return s.KVSGetHistory(resp, req, &args)
+		}
 		return s.KVSGet(resp, req, &args)
 	case "PUT":
 		return s.KVSPut(resp, req, &args)
@@ -54,6 +72,10 @@ func (s *HTTPServer) KVSEndpoint(resp http.ResponseWriter, req *http.Request) (i
 
 // KVSGet handles a GET request
 func (s *HTTPServer) KVSGet(resp http.ResponseWriter, req *http.Request, args *structs.KeyRequest) (interface{}, error) {
+	if conflictingFlags(resp, req, "recurse", "at-index") {
+		return nil, nil
+	}
+
 	// Check for recurse
 	method := "KVS.Get"
 	params := req.URL.Query()
@@ -63,6 +85,45 @@ func (s *HTTPServer) KVSGet(resp http.ResponseWriter, req *http.Request, args *s
 		return nil, nil
 	}
 
+	// Check for a read at a past index, which is answered from the history
+	// of the key.
+	if _, ok := params["at-index"]; ok {
+		atIndex, err := strconv.ParseUint(params.Get("at-index"), 10, 64)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid at-index: %v", err)
+			return nil, nil
+		}
+
+		var out structs.IndexedKVSHistory
+		if err := s.agent.RPC("KVS.History", &args, &out); err != nil {
+			return nil, err
+		}
+		setMeta(resp, &out.QueryMeta)
+
+		// Without a history, or before the oldest version still kept, there's
+		// no telling whether the key existed at the index.
+		version := out.Entries.AtIndex(atIndex)
+		if !out.Enabled || version == nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "No history retained for index %d", atIndex)
+			return nil, nil
+		}
+		entry := version.DirEntry()
+		if entry == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return nil, nil
+		}
+
+		if _, ok := params["raw"]; ok {
+			body := entry.Value
+			resp.Header().Set("Content-Length", strconv.FormatInt(int64(len(body)), 10))
+			resp.Write(body)
+			return nil, nil
+		}
+		return structs.DirEntries{entry}, nil
+	}
+
 	// Make the RPC
 	var out structs.IndexedDirEntries
 	if err := s.agent.RPC(method, &args, &out); err != nil {
@@ -88,6 +149,107 @@ func (s *HTTPServer) KVSGet(resp http.ResponseWriter, req *http.Request, args *s
 	return out.Entries, nil
 }
 
+// KVSGetHistory handles a GET request for the history of a key
+func (s *HTTPServer) KVSGetHistory(resp http.ResponseWriter, req *http.Request, args *structs.KeyRequest) (interface{}, error) {
+	if missingKey(resp, args) {
+		return nil, nil
+	}
+
+	// Make the RPC
+	var out structs.IndexedKVSHistory
+	if err := s.agent.RPC("KVS.History", &args, &out); err != nil {
+		return nil, err
+	}
+	setMeta(resp, &out.QueryMeta)
+
+	// Check if we get a not found
+	if len(out.Entries) == 0 {
+		resp.WriteHeader(http.StatusNotFound)
+		return nil, nil
+	}
+	return out.Entries, nil
+}
+
+// kvsStreamChanges is a batch of changes written as a line of a KV stream.
+type kvsStreamChanges struct {
+	Index  uint64
+	Reset  bool
+	Events structs.KVSEvents
+}
+
+// KVSStream handles a GET request streaming the changes to the keys under a
+// prefix, as a line of JSON per batch of changes. It starts from the given
+// index, or with every key if there's none, and runs until the client goes
+// away or a request to the servers fails.
+func (s *HTTPServer) KVSStream(resp http.ResponseWriter, req *http.Request, args *structs.KeyRequest) (interface{}, error) {
+	flusher, ok := resp.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("Streaming not supported")
+	}
+
+	// The index given may have been saved from an earlier stream, so have
+	// the first request check that the deletes since can still be tracked.
+	changesArgs := structs.KVSChangesRequest{
+		Datacenter:   args.Datacenter,
+		Prefix:       args.Key,
+		Resume:       args.MinQueryIndex > 0,
+		QueryOptions: args.QueryOptions,
+	}
+	if changesArgs.MaxQueryTime <= 0 || changesArgs.MaxQueryTime > kvsStreamMaxQueryTime {
+		changesArgs.MaxQueryTime = kvsStreamMaxQueryTime
+	}
+
+	// Make the first RPC before sending the header so errors such as ACL
+	// denials get their status code.
+	var out structs.IndexedKVSChanges
+	if err := s.agent.RPC("KVS.Changes", &changesArgs, &out); err != nil {
+		return nil, err
+	}
+	setMeta(resp, &out.QueryMeta)
+	resp.Header().Set("Content-Type", "application/x-ndjson")
+	resp.WriteHeader(http.StatusOK)
+
+	// Stream the changes until the connection is closed.
+	enc := json.NewEncoder(resp)
+	for {
+		if out.Reset || len(out.Events) > 0 {
+			changes := kvsStreamChanges{
+				Index:  out.Index,
+				Reset:  out.Reset,
+				Events: out.Events,
+			}
+			if err := enc.Encode(&changes); err != nil {
+				return nil, nil
+			}
+			flusher.Flush()
+		}
+
+		// Wait for the next changes in the background so the stream ends
+		// as soon as the client goes away, rather than when the query
+		// returns.
+		changesArgs.MinQueryIndex = out.Index
+		changesArgs.Resume = false
+		rpcArgs := changesArgs
+		var next structs.IndexedKVSChanges
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- s.agent.RPC("KVS.Changes", &rpcArgs, &next)
+		}()
+		select {
+		case <-req.Context().Done():
+			return nil, nil
+		case err := <-errCh:
+			if err != nil {
+				// The header was already sent, so end the stream and let
+				// the client resume it from the last index.
+				s.agent.logger.Printf("[ERR] http: Error streaming KV changes for %q: %v from=%s", args.Key, err, req.RemoteAddr)
+				return nil, nil
+			}
+		}
+		out = next
+	}
+}
+
 // KVSGetKeys handles a GET request for keys
 func (s *HTTPServer) KVSGetKeys(resp http.ResponseWriter, req *http.Request, args *structs.KeyRequest) (interface{}, error) {
 	// Check for a separator, due to historic spelling error,
@@ -159,6 +321,17 @@ func (s *HTTPServer) KVSPut(resp http.ResponseWriter, req *http.Request, args *s
 		applyReq.DirEnt.Flags = flagVal
 	}
 
+	// Check for a TTL
+	if _, ok := params["ttl"]; ok {
+		ttl, err := time.ParseDuration(params.Get("ttl"))
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid TTL: %v", err)
+			return nil, nil
+		}
+		applyReq.DirEnt.ExpirationTTL = ttl
+	}
+
 	// Check for cas value
 	if _, ok := params["cas"]; ok {
 		casVal, err := strconv.ParseUint(params.Get("cas"), 10, 64)
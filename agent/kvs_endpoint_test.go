@@ -2,11 +2,14 @@ package agent
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/testrpc"
 
@@ -290,6 +293,344 @@ func TestKVSEndpoint_CAS(t *testing.T) {
 	}
 }
 
+func TestKVSEndpoint_TTL(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	start := time.Now()
+	{
+		buf := bytes.NewBuffer([]byte("test"))
+		req, _ := http.NewRequest("PUT", "/v1/kv/test?ttl=1h", buf)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if res := obj.(bool); !res {
+			t.Fatalf("should work")
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/kv/test", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.KVSEndpoint(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	d := obj.(structs.DirEntries)[0]
+
+	// Check the expiration time
+	if !d.HasExpirationTime() || d.ExpirationTime.Before(start.Add(time.Hour)) || d.ExpirationTime.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("bad: %v", d)
+	}
+
+	// Invalid TTLs are rejected
+	{
+		buf := bytes.NewBuffer([]byte("test"))
+		req, _ := http.NewRequest("PUT", "/v1/kv/test?ttl=soon", buf)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Code != 400 {
+			t.Fatalf("expected 400, got %d", resp.Code)
+		}
+	}
+}
+
+func TestKVSEndpoint_History(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		kv_history {
+			prefix = "foo/"
+			max_versions = 10
+		}
+	`)
+	defer a.Shutdown()
+
+	// Write a few versions, remembering the index of each.
+	var indexes []uint64
+	for _, value := range []string{"1", "2"} {
+		buf := bytes.NewBuffer([]byte(value))
+		req, _ := http.NewRequest("PUT", "/v1/kv/foo/test", buf)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		req, _ = http.NewRequest("GET", "/v1/kv/foo/test", nil)
+		resp = httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		indexes = append(indexes, obj.(structs.DirEntries)[0].ModifyIndex)
+	}
+	{
+		req, _ := http.NewRequest("DELETE", "/v1/kv/foo/test", nil)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Read the history back.
+	{
+		req, _ := http.NewRequest("GET", "/v1/kv/foo/test?history", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		assertIndex(t, resp)
+
+		history := obj.(structs.KVSHistory)
+		if len(history) != 3 {
+			t.Fatalf("bad: %v", history)
+		}
+		if string(history[0].Value) != "1" || string(history[1].Value) != "2" || !history[2].Deleted {
+			t.Fatalf("bad: %v", history)
+		}
+	}
+
+	// Read the key at each index.
+	for i, value := range []string{"1", "2"} {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/v1/kv/foo/test?at-index=%d", indexes[i]), nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		d := obj.(structs.DirEntries)[0]
+		if string(d.Value) != value || d.ModifyIndex != indexes[i] {
+			t.Fatalf("bad: %v", d)
+		}
+	}
+
+	// The key didn't exist after the delete.
+	{
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/v1/kv/foo/test?at-index=%d", indexes[1]+1000), nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if obj != nil || resp.Code != 404 {
+			t.Fatalf("expected 404, got %d: %v", resp.Code, obj)
+		}
+	}
+
+	// Nothing is known before the oldest version.
+	{
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/v1/kv/foo/test?at-index=%d", indexes[0]-1), nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if obj != nil || resp.Code != 400 {
+			t.Fatalf("expected 400, got %d: %v", resp.Code, obj)
+		}
+	}
+
+	// Invalid indexes are rejected, and so is combining with recurse.
+	for _, query := range []string{"at-index=nope", "at-index=1&recurse"} {
+		req, _ := http.NewRequest("GET", "/v1/kv/foo/test?"+query, nil)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Code != 400 {
+			t.Fatalf("%s: expected 400, got %d", query, resp.Code)
+		}
+	}
+}
+
+func TestKVSEndpoint_History_NotRetained(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		kv_history {
+			prefix = "foo/"
+			max_versions = 1
+		}
+	`)
+	defer a.Shutdown()
+
+	put := func(key, value string) uint64 {
+		buf := bytes.NewBuffer([]byte(value))
+		req, _ := http.NewRequest("PUT", "/v1/kv/"+key, buf)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		req, _ = http.NewRequest("GET", "/v1/kv/"+key, nil)
+		resp = httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return obj.(structs.DirEntries)[0].ModifyIndex
+	}
+	atIndex := func(key string, index uint64) int {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/v1/kv/%s?at-index=%d", key, index), nil)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp.Code
+	}
+
+	// The first version has been trimmed by the second write, so the key's
+	// value at its index is unknown.
+	first := put("foo/test", "1")
+	second := put("foo/test", "2")
+	if code := atIndex("foo/test", first); code != 400 {
+		t.Fatalf("expected 400, got %d", code)
+	}
+	if code := atIndex("foo/test", second); code != 200 {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	// Keys without a history configured can't be read at any index, even
+	// the one of their current version.
+	index := put("bar/test", "1")
+	if code := atIndex("bar/test", index); code != 400 {
+		t.Fatalf("expected 400, got %d", code)
+	}
+}
+
+func TestKVSEndpoint_Stream(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	kv := func(method, key string) {
+		req, _ := http.NewRequest(method, "/v1/kv/"+key, bytes.NewBuffer([]byte("test")))
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	kv("PUT", "foo/a")
+	kv("PUT", "zip")
+
+	// Streaming can't be combined with the other reads.
+	{
+		req, _ := http.NewRequest("GET", "/v1/kv/foo/?stream&keys", nil)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Code != 400 {
+			t.Fatalf("expected 400, got %d", resp.Code)
+		}
+	}
+
+	srv := httptest.NewServer(a.srv.Handler)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/v1/kv/foo/?stream")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("bad content type: %s", ct)
+	}
+
+	// The stream starts with every key under the prefix.
+	dec := json.NewDecoder(resp.Body)
+	var changes kvsStreamChanges
+	if err := dec.Decode(&changes); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !changes.Reset || len(changes.Events) != 1 || changes.Events[0].Key != "foo/a" {
+		t.Fatalf("bad: %#v", changes)
+	}
+	if string(changes.Events[0].Value) != "test" {
+		t.Fatalf("bad: %#v", changes.Events[0])
+	}
+
+	// Then only the changes follow, which may come in one or more lines.
+	kv("PUT", "foo/b")
+	kv("PUT", "zip")
+	kv("DELETE", "foo/a")
+	var events structs.KVSEvents
+	for len(events) < 2 {
+		changes = kvsStreamChanges{}
+		if err := dec.Decode(&changes); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if changes.Reset {
+			t.Fatalf("bad: %#v", changes)
+		}
+		events = append(events, changes.Events...)
+	}
+	if len(events) != 2 {
+		t.Fatalf("bad: %#v", events)
+	}
+	if events[0].Type != structs.KVSEventPut || events[0].Key != "foo/b" {
+		t.Fatalf("bad: %#v", events[0])
+	}
+	if events[1].Type != structs.KVSEventDelete || events[1].Key != "foo/a" {
+		t.Fatalf("bad: %#v", events[1])
+	}
+	if changes.Index != events[1].ModifyIndex {
+		t.Fatalf("bad index: %d", changes.Index)
+	}
+}
+
+// flushNotifier is a ResponseRecorder that signals every flush.
+type flushNotifier struct {
+	*httptest.ResponseRecorder
+	flushCh chan struct{}
+}
+
+func (f *flushNotifier) Flush() {
+	f.ResponseRecorder.Flush()
+	f.flushCh <- struct{}{}
+}
+
+func TestKVSEndpoint_Stream_Cancel(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", "/v1/kv/foo/?stream&wait=10m", nil)
+	req = req.WithContext(ctx)
+	resp := &flushNotifier{httptest.NewRecorder(), make(chan struct{}, 1)}
+	doneCh := make(chan error, 1)
+	go func() {
+		_, err := a.srv.KVSEndpoint(resp, req)
+		doneCh <- err
+	}()
+
+	// Once the stream is waiting for changes, going away ends it right
+	// away rather than after the blocking query returns.
+	select {
+	case <-resp.flushCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stream didn't start")
+	}
+	cancel()
+	select {
+	case err := <-doneCh:
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stream didn't end")
+	}
+}
+
 func TestKVSEndpoint_ListKeys(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
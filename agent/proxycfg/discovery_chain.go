@@ -0,0 +1,316 @@
+package proxycfg
+
+import (
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// DiscoveryTarget identifies a set of service instances that traffic for an
+// upstream may be sent to. Every target gets its own Envoy cluster.
+type DiscoveryTarget struct {
+	Service       string
+	ServiceSubset string
+	Datacenter    string
+
+	// The remaining fields are resolved from the config entries of the
+	// target's service. They are not part of the target's identity.
+
+	// Protocol is the protocol of the target's service taken from its
+	// service-defaults config entry. It may differ from the protocol of the
+	// chain when a route or split sends requests to another service.
+	Protocol string
+
+	// Filter is the bexpr filter of the target's subset used when watching
+	// the target's health.
+	Filter string
+
+	// ConnectTimeout overrides the connect timeout of the target's cluster
+	// when non-zero.
+	ConnectTimeout time.Duration
+
+	// Failover lists the identifiers of the targets to fail over to, in
+	// order, when none of the target's own instances are healthy. They are
+	// held in the chain's FailoverTargets.
+	Failover []string
+
+	// MeshGateway is the mesh gateway mode of the upstream the target belongs
+	// to. It decides whether a target in another datacenter is reached
+	// through a mesh gateway.
+	MeshGateway structs.MeshGatewayMode
+
+	// UnknownSubset is set when the target names a subset that the
+	// service-resolver of its service doesn't define. Such targets aren't
+	// watched and their cluster has no endpoints, so traffic routed to them
+	// fails rather than reaching every instance of the service.
+	UnknownSubset bool
+}
+
+// Identifier returns a string that uniquely identifies the target. It is used
+// as the Envoy cluster name and as the key of the target's endpoints in the
+// snapshot. For targets without a subset it matches the identifier of the
+// equivalent service upstream so existing cluster names are unchanged.
+func (t DiscoveryTarget) Identifier() string {
+	id := serviceIDPrefix + t.Service
+	sep := "?"
+	if t.Datacenter != "" {
+		id += sep + "dc=" + t.Datacenter
+		sep = "&"
+	}
+	if t.ServiceSubset != "" {
+		id += sep + "subset=" + t.ServiceSubset
+	}
+	return id
+}
+
+// DiscoveryRoute is a single compiled route of a discovery chain.
+type DiscoveryRoute struct {
+	// Definition is the route as written in the service-router config entry.
+	// It is nil for the catch-all route appended to every chain.
+	Definition *structs.ServiceRoute
+
+	// Target is the identifier of the target matching requests are sent to.
+	// It is empty when the requests are split across Splits instead.
+	Target string
+
+	// Splits divides the matching requests between several targets. It is
+	// populated when a service-splitter applies to the route's destination.
+	Splits []DiscoverySplit
+}
+
+// DiscoverySplit sends a portion of the requests matching a route to a
+// single target.
+type DiscoverySplit struct {
+	// Weight is the percentage of requests sent to the target.
+	Weight float32
+
+	// Target is the identifier of the target.
+	Target string
+}
+
+// DiscoveryChain is the result of compiling the centralized config entries
+// that apply to a single service upstream into the routes and targets a proxy
+// needs to configure.
+type DiscoveryChain struct {
+	ServiceName string
+	Datacenter  string
+
+	// Protocol is the protocol of the upstream service taken from its
+	// service-defaults config entry.
+	Protocol string
+
+	// Routes holds the routes in the order they should be evaluated. It is
+	// only populated for L7 protocols in which case the last route always
+	// matches every request and sends it to the default target.
+	Routes []DiscoveryRoute
+
+	// Targets holds every target the chain may send traffic to, keyed by
+	// target identifier. It always includes the default target.
+	Targets map[string]DiscoveryTarget
+
+	// FailoverTargets holds the targets that are only used as failover for
+	// one of the Targets, keyed by target identifier. They don't get a
+	// cluster of their own but their endpoints are added to the cluster of
+	// the target they are failover for.
+	FailoverTargets map[string]DiscoveryTarget
+
+	// DefaultTarget is the identifier of the target used when no route
+	// matches.
+	DefaultTarget string
+}
+
+// IsHTTP returns true if the chain's protocol requires an L7 HTTP listener.
+func (c *DiscoveryChain) IsHTTP() bool {
+	return isHTTPProtocol(c.Protocol)
+}
+
+func isHTTPProtocol(protocol string) bool {
+	switch protocol {
+	case "http", "http2", "grpc":
+		return true
+	default:
+		return false
+	}
+}
+
+// configEntries holds the centralized config entries used to compile
+// discovery chains indexed by kind and then by name.
+type configEntries map[string]map[string]structs.ConfigEntry
+
+// set replaces all the entries of the given kind.
+func (e configEntries) set(kind string, entries []structs.ConfigEntry) {
+	byName := make(map[string]structs.ConfigEntry, len(entries))
+	for _, entry := range entries {
+		byName[entry.GetName()] = entry
+	}
+	e[kind] = byName
+}
+
+func (e configEntries) serviceDefaults(name string) *structs.ServiceConfigEntry {
+	entry, _ := e[structs.ServiceDefaults][name].(*structs.ServiceConfigEntry)
+	return entry
+}
+
+func (e configEntries) serviceRouter(name string) *structs.ServiceRouterConfigEntry {
+	entry, _ := e[structs.ServiceRouter][name].(*structs.ServiceRouterConfigEntry)
+	return entry
+}
+
+func (e configEntries) serviceSplitter(name string) *structs.ServiceSplitterConfigEntry {
+	entry, _ := e[structs.ServiceSplitter][name].(*structs.ServiceSplitterConfigEntry)
+	return entry
+}
+
+func (e configEntries) serviceResolver(name string) *structs.ServiceResolverConfigEntry {
+	entry, _ := e[structs.ServiceResolver][name].(*structs.ServiceResolverConfigEntry)
+	return entry
+}
+
+// compileDiscoveryChain compiles the config entries that apply to the given
+// service upstream into a DiscoveryChain.
+func compileDiscoveryChain(u structs.Upstream, entries configEntries) *DiscoveryChain {
+	chain := &DiscoveryChain{
+		ServiceName:     u.DestinationName,
+		Datacenter:      u.Datacenter,
+		Protocol:        structs.DefaultServiceProtocol,
+		Targets:         make(map[string]DiscoveryTarget),
+		FailoverTargets: make(map[string]DiscoveryTarget),
+	}
+	if defaults := entries.serviceDefaults(u.DestinationName); defaults != nil && defaults.Protocol != "" {
+		chain.Protocol = defaults.Protocol
+	}
+
+	defaultTarget := DiscoveryTarget{
+		Service:     u.DestinationName,
+		Datacenter:  u.Datacenter,
+		MeshGateway: u.MeshGateway.Mode,
+	}
+	chain.DefaultTarget = chain.addTarget(defaultTarget, entries)
+
+	// Routing rules only make sense for L7 protocols.
+	if !chain.IsHTTP() {
+		return chain
+	}
+
+	if router := entries.serviceRouter(u.DestinationName); router != nil {
+		for i := range router.Routes {
+			route := &router.Routes[i]
+
+			target := defaultTarget
+			if dest := route.Destination; dest != nil {
+				if dest.Service != "" {
+					target.Service = dest.Service
+				}
+				target.ServiceSubset = dest.ServiceSubset
+			}
+
+			chain.Routes = append(chain.Routes, chain.routeTo(route, target, entries))
+		}
+	}
+
+	// Everything that doesn't match a route goes to the default target.
+	chain.Routes = append(chain.Routes, chain.routeTo(nil, defaultTarget, entries))
+
+	return chain
+}
+
+// routeTo returns a route that sends requests to the given target, adding
+// every target it references to the chain. If a service-splitter exists for
+// the target's service the requests are split between the targets it lists
+// instead. Targets that name a subset explicitly bypass the splitter, as do
+// the splits themselves so that splitters can't form loops.
+func (c *DiscoveryChain) routeTo(def *structs.ServiceRoute, target DiscoveryTarget, entries configEntries) DiscoveryRoute {
+	route := DiscoveryRoute{Definition: def}
+
+	var splitter *structs.ServiceSplitterConfigEntry
+	if target.ServiceSubset == "" {
+		splitter = entries.serviceSplitter(target.Service)
+	}
+	if splitter == nil {
+		route.Target = c.addTarget(target, entries)
+		return route
+	}
+
+	for _, split := range splitter.Splits {
+		splitTarget := DiscoveryTarget{
+			Service:       target.Service,
+			ServiceSubset: split.ServiceSubset,
+			Datacenter:    target.Datacenter,
+			MeshGateway:   target.MeshGateway,
+		}
+		if split.Service != "" {
+			splitTarget.Service = split.Service
+		}
+
+		route.Splits = append(route.Splits, DiscoverySplit{
+			Weight: split.Weight,
+			Target: c.addTarget(splitTarget, entries),
+		})
+	}
+	return route
+}
+
+// addTarget resolves the target using the service-resolver of its service,
+// adds it and any targets it fails over to to the chain and returns its
+// identifier.
+//
+// Targets naming a subset that the resolver doesn't define, or naming a
+// subset of a service without a resolver, are added with UnknownSubset set
+// and without failover.
+func (c *DiscoveryChain) addTarget(target DiscoveryTarget, entries configEntries) string {
+	target.Protocol = structs.DefaultServiceProtocol
+	if defaults := entries.serviceDefaults(target.Service); defaults != nil && defaults.Protocol != "" {
+		target.Protocol = defaults.Protocol
+	}
+
+	resolver := entries.serviceResolver(target.Service)
+	if resolver != nil && target.ServiceSubset == "" {
+		target.ServiceSubset = resolver.DefaultSubset
+	}
+	if target.ServiceSubset != "" {
+		var subset structs.ServiceResolverSubset
+		ok := false
+		if resolver != nil {
+			subset, ok = resolver.Subsets[target.ServiceSubset]
+		}
+		if !ok {
+			target.UnknownSubset = true
+			id := target.Identifier()
+			c.Targets[id] = target
+			return id
+		}
+		target.Filter = subset.Filter
+	}
+	if resolver == nil {
+		id := target.Identifier()
+		c.Targets[id] = target
+		return id
+	}
+	target.ConnectTimeout = resolver.ConnectTimeout
+
+	failover, ok := resolver.Failover[target.ServiceSubset]
+	if !ok {
+		failover = resolver.Failover["*"]
+	}
+	for _, dc := range failover.Datacenters {
+		if dc == target.Datacenter {
+			continue
+		}
+		failoverTarget := DiscoveryTarget{
+			Service:        target.Service,
+			ServiceSubset:  target.ServiceSubset,
+			Datacenter:     dc,
+			Protocol:       target.Protocol,
+			Filter:         target.Filter,
+			ConnectTimeout: target.ConnectTimeout,
+			MeshGateway:    target.MeshGateway,
+		}
+		failoverID := failoverTarget.Identifier()
+		c.FailoverTargets[failoverID] = failoverTarget
+		target.Failover = append(target.Failover, failoverID)
+	}
+
+	id := target.Identifier()
+	c.Targets[id] = target
+	return id
+}
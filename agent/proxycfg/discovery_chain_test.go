@@ -0,0 +1,432 @@
+package proxycfg
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestDiscoveryTarget_Identifier(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]DiscoveryTarget{
+		"service:db":                  {Service: "db"},
+		"service:db?dc=dc2":           {Service: "db", Datacenter: "dc2"},
+		"service:db?subset=v2":        {Service: "db", ServiceSubset: "v2"},
+		"service:db?dc=dc2&subset=v2": {Service: "db", ServiceSubset: "v2", Datacenter: "dc2"},
+	}
+	for expect, target := range cases {
+		require.Equal(t, expect, target.Identifier())
+	}
+
+	// Targets without subsets share the identifier of the equivalent upstream.
+	u := structs.Upstream{DestinationName: "db", Datacenter: "dc2"}
+	require.Equal(t, u.Identifier(), DiscoveryTarget{Service: "db", Datacenter: "dc2"}.Identifier())
+}
+
+func TestCompileDiscoveryChain(t *testing.T) {
+	t.Parallel()
+
+	upstream := structs.Upstream{DestinationName: "db", Datacenter: "dc2"}
+	router := &structs.ServiceRouterConfigEntry{
+		Name: "db",
+		Routes: []structs.ServiceRoute{
+			{
+				Match: &structs.ServiceRouteMatch{
+					HTTP: &structs.ServiceRouteHTTPMatch{PathPrefix: "/admin"},
+				},
+				Destination: &structs.ServiceRouteDestination{Service: "admin"},
+			},
+			{
+				Match: &structs.ServiceRouteMatch{
+					HTTP: &structs.ServiceRouteHTTPMatch{PathPrefix: "/v2"},
+				},
+				Destination: &structs.ServiceRouteDestination{ServiceSubset: "v2"},
+			},
+		},
+	}
+	subsets := &structs.ServiceResolverConfigEntry{
+		Name: "db",
+		Subsets: map[string]structs.ServiceResolverSubset{
+			"v1": {Filter: "Service.Meta.version == v1"},
+			"v2": {Filter: "Service.Meta.version == v2"},
+		},
+	}
+
+	t.Run("no entries", func(t *testing.T) {
+		chain := compileDiscoveryChain(upstream, nil)
+		require.Equal(t, structs.DefaultServiceProtocol, chain.Protocol)
+		require.False(t, chain.IsHTTP())
+		require.Empty(t, chain.Routes)
+		require.Equal(t, map[string]DiscoveryTarget{
+			"service:db?dc=dc2": {Service: "db", Datacenter: "dc2", Protocol: "tcp"},
+		}, chain.Targets)
+		require.Equal(t, "service:db?dc=dc2", chain.DefaultTarget)
+	})
+
+	t.Run("router with tcp protocol", func(t *testing.T) {
+		entries := make(configEntries)
+		entries.set(structs.ServiceRouter, []structs.ConfigEntry{router})
+
+		chain := compileDiscoveryChain(upstream, entries)
+		require.Empty(t, chain.Routes)
+		require.Len(t, chain.Targets, 1)
+	})
+
+	t.Run("router with http protocol", func(t *testing.T) {
+		entries := make(configEntries)
+		entries.set(structs.ServiceDefaults, []structs.ConfigEntry{
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+		})
+		entries.set(structs.ServiceRouter, []structs.ConfigEntry{router})
+		entries.set(structs.ServiceResolver, []structs.ConfigEntry{subsets})
+
+		chain := compileDiscoveryChain(upstream, entries)
+		require.Equal(t, "http", chain.Protocol)
+		require.True(t, chain.IsHTTP())
+		require.Equal(t, []DiscoveryRoute{
+			{Definition: &router.Routes[0], Target: "service:admin?dc=dc2"},
+			{Definition: &router.Routes[1], Target: "service:db?dc=dc2&subset=v2"},
+			{Target: "service:db?dc=dc2"},
+		}, chain.Routes)
+		require.Equal(t, map[string]DiscoveryTarget{
+			"service:db?dc=dc2":    {Service: "db", Datacenter: "dc2", Protocol: "http"},
+			"service:admin?dc=dc2": {Service: "admin", Datacenter: "dc2", Protocol: "tcp"},
+			"service:db?dc=dc2&subset=v2": {
+				Service:       "db",
+				ServiceSubset: "v2",
+				Datacenter:    "dc2",
+				Protocol:      "http",
+				Filter:        "Service.Meta.version == v2",
+			},
+		}, chain.Targets)
+	})
+
+	t.Run("splitter for the default target", func(t *testing.T) {
+		entries := make(configEntries)
+		entries.set(structs.ServiceDefaults, []structs.ConfigEntry{
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+		})
+		entries.set(structs.ServiceRouter, []structs.ConfigEntry{router})
+		entries.set(structs.ServiceSplitter, []structs.ConfigEntry{
+			&structs.ServiceSplitterConfigEntry{
+				Name: "db",
+				Splits: []structs.ServiceSplit{
+					{Weight: 90, ServiceSubset: "v1"},
+					{Weight: 10, ServiceSubset: "v2"},
+				},
+			},
+		})
+		entries.set(structs.ServiceResolver, []structs.ConfigEntry{subsets})
+
+		chain := compileDiscoveryChain(upstream, entries)
+		require.Equal(t, []DiscoveryRoute{
+			{Definition: &router.Routes[0], Target: "service:admin?dc=dc2"},
+			// Routes to an explicit subset bypass the splitter.
+			{Definition: &router.Routes[1], Target: "service:db?dc=dc2&subset=v2"},
+			{
+				Splits: []DiscoverySplit{
+					{Weight: 90, Target: "service:db?dc=dc2&subset=v1"},
+					{Weight: 10, Target: "service:db?dc=dc2&subset=v2"},
+				},
+			},
+		}, chain.Routes)
+		require.Equal(t, map[string]DiscoveryTarget{
+			"service:db?dc=dc2":    {Service: "db", Datacenter: "dc2", Protocol: "http"},
+			"service:admin?dc=dc2": {Service: "admin", Datacenter: "dc2", Protocol: "tcp"},
+			"service:db?dc=dc2&subset=v1": {
+				Service:       "db",
+				ServiceSubset: "v1",
+				Datacenter:    "dc2",
+				Protocol:      "http",
+				Filter:        "Service.Meta.version == v1",
+			},
+			"service:db?dc=dc2&subset=v2": {
+				Service:       "db",
+				ServiceSubset: "v2",
+				Datacenter:    "dc2",
+				Protocol:      "http",
+				Filter:        "Service.Meta.version == v2",
+			},
+		}, chain.Targets)
+	})
+
+	t.Run("unknown subsets", func(t *testing.T) {
+		entries := make(configEntries)
+		entries.set(structs.ServiceDefaults, []structs.ConfigEntry{
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+		})
+		entries.set(structs.ServiceSplitter, []structs.ConfigEntry{
+			&structs.ServiceSplitterConfigEntry{
+				Name: "db",
+				Splits: []structs.ServiceSplit{
+					{Weight: 50, ServiceSubset: "v1"},
+					{Weight: 50, ServiceSubset: "v3"},
+					{Weight: 0, Service: "admin", ServiceSubset: "v1"},
+				},
+			},
+		})
+		resolver := *subsets
+		resolver.Failover = map[string]structs.ServiceResolverFailover{
+			"*": {Datacenters: []string{"dc3"}},
+		}
+		entries.set(structs.ServiceResolver, []structs.ConfigEntry{&resolver})
+
+		chain := compileDiscoveryChain(upstream, entries)
+		require.False(t, chain.Targets["service:db?dc=dc2&subset=v1"].UnknownSubset)
+
+		// Subsets the resolver doesn't define, and subsets of services
+		// without a resolver, select no instances and don't fail over.
+		require.Equal(t, DiscoveryTarget{
+			Service:       "db",
+			ServiceSubset: "v3",
+			Datacenter:    "dc2",
+			Protocol:      "http",
+			UnknownSubset: true,
+		}, chain.Targets["service:db?dc=dc2&subset=v3"])
+		require.True(t, chain.Targets["service:admin?dc=dc2&subset=v1"].UnknownSubset)
+		require.Equal(t, []string{"service:db?dc=dc3"}, chain.Targets["service:db?dc=dc2"].Failover)
+		require.NotContains(t, chain.FailoverTargets, "service:db?dc=dc3&subset=v3")
+	})
+
+	t.Run("splitter for a routed service", func(t *testing.T) {
+		entries := make(configEntries)
+		entries.set(structs.ServiceDefaults, []structs.ConfigEntry{
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+		})
+		entries.set(structs.ServiceRouter, []structs.ConfigEntry{router})
+		entries.set(structs.ServiceSplitter, []structs.ConfigEntry{
+			&structs.ServiceSplitterConfigEntry{
+				Name: "admin",
+				Splits: []structs.ServiceSplit{
+					{Weight: 50},
+					{Weight: 50, Service: "admin-v2"},
+				},
+			},
+		})
+
+		chain := compileDiscoveryChain(upstream, entries)
+		require.Len(t, chain.Routes, 3)
+		require.Equal(t, []DiscoverySplit{
+			{Weight: 50, Target: "service:admin?dc=dc2"},
+			{Weight: 50, Target: "service:admin-v2?dc=dc2"},
+		}, chain.Routes[0].Splits)
+		require.Empty(t, chain.Routes[0].Target)
+		require.Contains(t, chain.Targets, "service:admin-v2?dc=dc2")
+	})
+
+	t.Run("targets use the protocol of their service", func(t *testing.T) {
+		entries := make(configEntries)
+		entries.set(structs.ServiceDefaults, []structs.ConfigEntry{
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+			&structs.ServiceConfigEntry{Name: "admin", Protocol: "grpc"},
+		})
+		entries.set(structs.ServiceRouter, []structs.ConfigEntry{router})
+
+		chain := compileDiscoveryChain(upstream, entries)
+		require.Equal(t, "http", chain.Protocol)
+		require.Equal(t, "http", chain.Targets["service:db?dc=dc2"].Protocol)
+		require.Equal(t, "grpc", chain.Targets["service:admin?dc=dc2"].Protocol)
+	})
+
+	t.Run("splitter with tcp protocol", func(t *testing.T) {
+		entries := make(configEntries)
+		entries.set(structs.ServiceSplitter, []structs.ConfigEntry{
+			&structs.ServiceSplitterConfigEntry{
+				Name: "db",
+				Splits: []structs.ServiceSplit{
+					{Weight: 100, ServiceSubset: "v1"},
+				},
+			},
+		})
+
+		chain := compileDiscoveryChain(upstream, entries)
+		require.Empty(t, chain.Routes)
+		require.Len(t, chain.Targets, 1)
+	})
+
+	t.Run("resolver with default subset and failover", func(t *testing.T) {
+		entries := make(configEntries)
+		entries.set(structs.ServiceResolver, []structs.ConfigEntry{
+			&structs.ServiceResolverConfigEntry{
+				Name:          "db",
+				DefaultSubset: "v1",
+				Subsets: map[string]structs.ServiceResolverSubset{
+					"v1": {Filter: "Service.Meta.version == v1"},
+					"v2": {Filter: "Service.Meta.version == v2"},
+				},
+				Failover: map[string]structs.ServiceResolverFailover{
+					"v1": {Datacenters: []string{"dc2", "dc3"}},
+				},
+				ConnectTimeout: 15 * time.Second,
+			},
+		})
+
+		// The chain's own datacenter is skipped when failing over.
+		chain := compileDiscoveryChain(upstream, entries)
+		require.Empty(t, chain.Routes)
+		require.Equal(t, "service:db?dc=dc2&subset=v1", chain.DefaultTarget)
+		require.Equal(t, map[string]DiscoveryTarget{
+			"service:db?dc=dc2&subset=v1": {
+				Service:        "db",
+				ServiceSubset:  "v1",
+				Datacenter:     "dc2",
+				Protocol:       "tcp",
+				Filter:         "Service.Meta.version == v1",
+				ConnectTimeout: 15 * time.Second,
+				Failover:       []string{"service:db?dc=dc3&subset=v1"},
+			},
+		}, chain.Targets)
+		require.Equal(t, map[string]DiscoveryTarget{
+			"service:db?dc=dc3&subset=v1": {
+				Service:        "db",
+				ServiceSubset:  "v1",
+				Datacenter:     "dc3",
+				Protocol:       "tcp",
+				Filter:         "Service.Meta.version == v1",
+				ConnectTimeout: 15 * time.Second,
+			},
+		}, chain.FailoverTargets)
+	})
+
+	t.Run("resolver for routed subsets", func(t *testing.T) {
+		entries := make(configEntries)
+		entries.set(structs.ServiceDefaults, []structs.ConfigEntry{
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+		})
+		entries.set(structs.ServiceRouter, []structs.ConfigEntry{router})
+		entries.set(structs.ServiceResolver, []structs.ConfigEntry{
+			&structs.ServiceResolverConfigEntry{
+				Name: "db",
+				Subsets: map[string]structs.ServiceResolverSubset{
+					"v2": {Filter: "Service.Meta.version == v2"},
+				},
+				Failover: map[string]structs.ServiceResolverFailover{
+					"*": {Datacenters: []string{"dc1"}},
+				},
+			},
+		})
+
+		chain := compileDiscoveryChain(upstream, entries)
+		require.Equal(t, "service:db?dc=dc2", chain.DefaultTarget)
+		require.Equal(t, "service:db?dc=dc2&subset=v2", chain.Routes[1].Target)
+
+		v2 := chain.Targets["service:db?dc=dc2&subset=v2"]
+		require.Equal(t, "Service.Meta.version == v2", v2.Filter)
+		require.Equal(t, []string{"service:db?dc=dc1&subset=v2"}, v2.Failover)
+		require.Equal(t, []string{"service:db?dc=dc1"}, chain.Targets["service:db?dc=dc2"].Failover)
+
+		// The resolver of the admin service doesn't apply.
+		require.Empty(t, chain.Targets["service:admin?dc=dc2"].Failover)
+		require.Len(t, chain.FailoverTargets, 2)
+	})
+}
+
+func TestState_updateDiscoveryChains(t *testing.T) {
+	t.Parallel()
+
+	types := NewTestCacheTypes(t)
+	types.health.value.Store(&structs.IndexedCheckServiceNodes{
+		Nodes: TestUpstreamNodes(t),
+	})
+
+	s, err := newState(structs.TestNodeServiceProxy(t), "")
+	require.NoError(t, err)
+	s.logger = log.New(os.Stderr, "", log.LstdFlags)
+	s.source = &structs.QuerySource{Datacenter: "dc1"}
+	s.cache = TestCacheWithTypes(t, types)
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	defer s.cancel()
+
+	snap := ConfigSnapshot{
+		UpstreamEndpoints: make(map[string]structs.CheckServiceNodes),
+		UpstreamChains:    make(map[string]*DiscoveryChain),
+	}
+
+	// Without any config entries only the upstreams are watched.
+	require.NoError(t, s.updateDiscoveryChains(&snap))
+	require.Len(t, snap.UpstreamChains, 1)
+	require.Contains(t, snap.UpstreamChains, "service:db")
+	require.Empty(t, s.targetWatches)
+
+	// Routing to another service starts a watch for it.
+	s.configEntries.set(structs.ServiceDefaults, []structs.ConfigEntry{
+		&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+	})
+	s.configEntries.set(structs.ServiceRouter, []structs.ConfigEntry{
+		&structs.ServiceRouterConfigEntry{
+			Name: "db",
+			Routes: []structs.ServiceRoute{
+				{Destination: &structs.ServiceRouteDestination{Service: "admin"}},
+			},
+		},
+	})
+	require.NoError(t, s.updateDiscoveryChains(&snap))
+	require.Len(t, s.targetWatches, 1)
+	require.Contains(t, s.targetWatches, "service:admin")
+
+	var u cache.UpdateEvent
+	select {
+	case u = <-s.ch:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for target watch")
+	}
+	require.Equal(t, "service:admin", u.CorrelationID)
+	require.NoError(t, s.handleUpdate(u, &snap))
+	require.Equal(t, TestUpstreamNodes(t), snap.UpstreamEndpoints["service:admin"])
+
+	// Removing the router stops the watch and drops its endpoints.
+	s.configEntries.set(structs.ServiceRouter, nil)
+	require.NoError(t, s.updateDiscoveryChains(&snap))
+	require.Empty(t, s.targetWatches)
+	require.NotContains(t, snap.UpstreamEndpoints, "service:admin")
+
+	// Late results for the stopped watch are ignored.
+	require.NoError(t, s.handleUpdate(u, &snap))
+	require.NotContains(t, snap.UpstreamEndpoints, "service:admin")
+
+	// Resolved subsets and failover targets are watched with the subset's
+	// filter.
+	resolver := &structs.ServiceResolverConfigEntry{
+		Name:          "db",
+		DefaultSubset: "v1",
+		Subsets: map[string]structs.ServiceResolverSubset{
+			"v1": {Filter: "Service.Meta.version == v1"},
+		},
+		Failover: map[string]structs.ServiceResolverFailover{
+			"*": {Datacenters: []string{"dc2"}},
+		},
+	}
+	s.configEntries.set(structs.ServiceResolver, []structs.ConfigEntry{resolver})
+	require.NoError(t, s.updateDiscoveryChains(&snap))
+	require.Len(t, s.targetWatches, 2)
+	require.Equal(t, "Service.Meta.version == v1", s.targetWatches["service:db?subset=v1"].filter)
+	require.Equal(t, "Service.Meta.version == v1", s.targetWatches["service:db?dc=dc2&subset=v1"].filter)
+
+	// Changing the filter restarts the watch.
+	resolver.Subsets["v1"] = structs.ServiceResolverSubset{Filter: "Service.Meta.version == 1"}
+	require.NoError(t, s.updateDiscoveryChains(&snap))
+	require.Len(t, s.targetWatches, 2)
+	require.Equal(t, "Service.Meta.version == 1", s.targetWatches["service:db?subset=v1"].filter)
+
+	// Subsets the resolver doesn't define aren't watched.
+	s.configEntries.set(structs.ServiceRouter, []structs.ConfigEntry{
+		&structs.ServiceRouterConfigEntry{
+			Name: "db",
+			Routes: []structs.ServiceRoute{
+				{Destination: &structs.ServiceRouteDestination{ServiceSubset: "v9"}},
+			},
+		},
+	})
+	require.NoError(t, s.updateDiscoveryChains(&snap))
+	require.True(t, snap.UpstreamChains["service:db"].Targets["service:db?subset=v9"].UnknownSubset)
+	require.Len(t, s.targetWatches, 2)
+	require.NotContains(t, s.targetWatches, "service:db?subset=v9")
+}
@@ -131,7 +131,7 @@ func (m *Manager) syncState() {
 	// Traverse the local state and ensure all proxy services are registered
 	services := m.State.Services()
 	for svcID, svc := range services {
-		if svc.Kind != structs.ServiceKindConnectProxy {
+		if svc.Kind != structs.ServiceKindConnectProxy && svc.Kind != structs.ServiceKindMeshGateway {
 			continue
 		}
 		// TODO(banks): need to work out when to default some stuff. For example
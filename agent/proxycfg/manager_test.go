@@ -55,6 +55,7 @@ func TestManager_BasicLifecycle(t *testing.T) {
 		&structs.IndexedCheckServiceNodes{
 			Nodes: TestUpstreamNodes(t),
 		})
+	types.configs.value.Store(&structs.IndexedConfigEntries{})
 
 	logger := log.New(os.Stderr, "", log.LstdFlags)
 	state := local.NewState(local.Config{}, logger, &token.Store{})
@@ -106,15 +107,26 @@ func TestManager_BasicLifecycle(t *testing.T) {
 	// We should see the initial config delivered but not until after the
 	// coalesce timeout
 	expectSnap := &ConfigSnapshot{
-		ProxyID: webProxy.ID,
-		Address: webProxy.Address,
-		Port:    webProxy.Port,
-		Proxy:   webProxy.Proxy,
-		Roots:   roots,
-		Leaf:    leaf,
+		Kind:       structs.ServiceKindConnectProxy,
+		Service:    webProxy.Service,
+		Datacenter: "dc1",
+		ProxyID:    webProxy.ID,
+		Address:    webProxy.Address,
+		Port:       webProxy.Port,
+		Proxy:      webProxy.Proxy,
+		Roots:      roots,
+		Leaf:       leaf,
 		UpstreamEndpoints: map[string]structs.CheckServiceNodes{
 			"service:db": TestUpstreamNodes(t),
 		},
+		UpstreamChains: map[string]*DiscoveryChain{
+			"service:db": compileDiscoveryChain(webProxy.Proxy.Upstreams[0], nil),
+		},
+		MeshGateway: configSnapshotMeshGateway{
+			ServiceGroups:    map[string]structs.CheckServiceNodes{},
+			GatewayGroups:    map[string]structs.CheckServiceNodes{},
+			ServiceResolvers: map[string]*structs.ServiceResolverConfigEntry{},
+		},
 	}
 	start := time.Now()
 	assertWatchChanRecvs(t, wCh, expectSnap)
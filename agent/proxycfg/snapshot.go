@@ -9,6 +9,16 @@ import (
 // It is meant to be point-in-time coherent and is used to deliver the current
 // config state to observers who need it to be pushed in (e.g. XDS server).
 type ConfigSnapshot struct {
+	// Kind is the kind of the service the snapshot was generated for. It is
+	// either a connect-proxy or a mesh-gateway.
+	Kind structs.ServiceKind
+
+	// Service is the name of the service the snapshot was generated for.
+	Service string
+
+	// Datacenter is the datacenter of the local agent.
+	Datacenter string
+
 	ProxyID           string
 	Address           string
 	Port              int
@@ -17,12 +27,45 @@ type ConfigSnapshot struct {
 	Leaf              *structs.IssuedCert
 	UpstreamEndpoints map[string]structs.CheckServiceNodes
 
+	// UpstreamChains holds the compiled discovery chain of each service
+	// upstream keyed by upstream identifier. The endpoints of every chain
+	// target are stored in UpstreamEndpoints under the target identifier.
+	UpstreamChains map[string]*DiscoveryChain
+
+	// MeshGateway holds the state only needed by mesh gateways.
+	MeshGateway configSnapshotMeshGateway
+
 	// Skip intentions for now as we don't push those down yet, just pre-warm them.
 }
 
+type configSnapshotMeshGateway struct {
+	// WatchedServicesSet is true once the list of services in the local
+	// datacenter has been received.
+	WatchedServicesSet bool
+
+	// ServiceGroups holds the Connect-capable instances of every service in
+	// the local datacenter, keyed by service name.
+	ServiceGroups map[string]structs.CheckServiceNodes
+
+	// GatewayGroups holds the mesh gateways of every other datacenter, keyed
+	// by datacenter.
+	GatewayGroups map[string]structs.CheckServiceNodes
+
+	// ServiceResolvers holds the service-resolver config entries of the local
+	// datacenter, keyed by service name. Each subset they define is routed
+	// to the matching subset of the service's instances.
+	ServiceResolvers map[string]*structs.ServiceResolverConfigEntry
+}
+
 // Valid returns whether or not the snapshot has all required fields filled yet.
 func (s *ConfigSnapshot) Valid() bool {
-	return s.Roots != nil && s.Leaf != nil
+	switch s.Kind {
+	case structs.ServiceKindMeshGateway:
+		// Gateways don't terminate TLS so they have no need for a leaf cert.
+		return s.Roots != nil && s.MeshGateway.WatchedServicesSet
+	default:
+		return s.Roots != nil && s.Leaf != nil
+	}
 }
 
 // Clone makes a deep copy of the snapshot we can send to other goroutines
@@ -20,14 +20,23 @@ const (
 	rootsWatchID                     = "roots"
 	leafWatchID                      = "leaf"
 	intentionsWatchID                = "intentions"
+	configEntriesWatchIDPrefix       = "config-entries:"
+	serviceListWatchID               = "service-list"
+	datacentersWatchID               = "datacenters"
+	connectServiceIDPrefix           = "connect-service:"
+	meshGatewayIDPrefix              = "mesh-gateway:"
 	serviceIDPrefix                  = string(structs.UpstreamDestTypeService) + ":"
 	preparedQueryIDPrefix            = string(structs.UpstreamDestTypePreparedQuery) + ":"
 	defaultPreparedQueryPollInterval = 30 * time.Second
+	defaultDatacentersPollInterval   = 30 * time.Second
 )
 
+// meshGatewayFilter selects the mesh gateway instances from a service dump.
+var meshGatewayFilter = fmt.Sprintf("Service.Kind == %q", structs.ServiceKindMeshGateway)
+
 // state holds all the state needed to maintain the config for a registered
-// connect-proxy service. When a proxy registration is changed, the entire state
-// is discarded and a new one created.
+// connect-proxy or mesh-gateway service. When a proxy registration is changed,
+// the entire state is discarded and a new one created.
 type state struct {
 	// logger, source and cache are required to be set before calling Watch.
 	logger *log.Logger
@@ -38,6 +47,8 @@ type state struct {
 	ctx    context.Context
 	cancel func()
 
+	kind     structs.ServiceKind
+	service  string
 	proxyID  string
 	address  string
 	port     int
@@ -47,6 +58,39 @@ type state struct {
 	ch     chan cache.UpdateEvent
 	snapCh chan ConfigSnapshot
 	reqCh  chan chan *ConfigSnapshot
+
+	// configEntries holds the latest centralized config entries used to
+	// compile the discovery chains of the service upstreams.
+	configEntries configEntries
+
+	// targetWatches holds the health watches for discovery chain targets that
+	// are not upstreams in their own right, keyed by target identifier. Only
+	// accessed from the run goroutine.
+	targetWatches map[string]targetWatch
+
+	// watchedServices and watchedDatacenters hold the cancel functions of the
+	// health watches of a mesh gateway, keyed by service name and datacenter
+	// respectively. Only accessed from the run goroutine.
+	watchedServices    map[string]context.CancelFunc
+	watchedDatacenters map[string]context.CancelFunc
+}
+
+// targetWatch is a health watch for a single discovery chain target.
+type targetWatch struct {
+	cancel context.CancelFunc
+
+	// filter is the subset filter the watch was started with. The watch is
+	// restarted when the service-resolver changes it.
+	filter string
+}
+
+// discoveryChainKinds are the config entry kinds that are watched in order to
+// compile the discovery chains of service upstreams.
+var discoveryChainKinds = []string{
+	structs.ServiceDefaults,
+	structs.ServiceRouter,
+	structs.ServiceSplitter,
+	structs.ServiceResolver,
 }
 
 // newState populates the state struct by copying relevant fields from the
@@ -57,8 +101,8 @@ type state struct {
 // The returned state needs it's required dependencies to be set before Watch
 // can be called.
 func newState(ns *structs.NodeService, token string) (*state, error) {
-	if ns.Kind != structs.ServiceKindConnectProxy {
-		return nil, errors.New("not a connect-proxy")
+	if ns.Kind != structs.ServiceKindConnectProxy && ns.Kind != structs.ServiceKindMeshGateway {
+		return nil, errors.New("not a connect-proxy or mesh-gateway")
 	}
 
 	// Copy the config map
@@ -72,6 +116,8 @@ func newState(ns *structs.NodeService, token string) (*state, error) {
 	}
 
 	return &state{
+		kind:     ns.Kind,
+		service:  ns.Service,
 		proxyID:  ns.ID,
 		address:  ns.Address,
 		port:     ns.Port,
@@ -87,6 +133,12 @@ func newState(ns *structs.NodeService, token string) (*state, error) {
 		ch:     make(chan cache.UpdateEvent, 10),
 		snapCh: make(chan ConfigSnapshot, 1),
 		reqCh:  make(chan chan *ConfigSnapshot, 1),
+
+		configEntries: make(configEntries),
+		targetWatches: make(map[string]targetWatch),
+
+		watchedServices:    make(map[string]context.CancelFunc),
+		watchedDatacenters: make(map[string]context.CancelFunc),
 	}, nil
 }
 
@@ -119,11 +171,26 @@ func (s *state) Close() error {
 // initWatches sets up the watches needed based on current proxy registration
 // state.
 func (s *state) initWatches() error {
-	// Watch for root changes
-	err := s.cache.Notify(s.ctx, cachetype.ConnectCARootName, &structs.DCSpecificRequest{
+	switch s.kind {
+	case structs.ServiceKindMeshGateway:
+		return s.initWatchesMeshGateway()
+	default:
+		return s.initWatchesConnectProxy()
+	}
+}
+
+// watchRoots starts the watch for CA root changes.
+func (s *state) watchRoots() error {
+	return s.cache.Notify(s.ctx, cachetype.ConnectCARootName, &structs.DCSpecificRequest{
 		Datacenter:   s.source.Datacenter,
 		QueryOptions: structs.QueryOptions{Token: s.token},
 	}, rootsWatchID, s.ch)
+}
+
+// initWatchesConnectProxy sets up the watches needed by a connect-proxy.
+func (s *state) initWatchesConnectProxy() error {
+	// Watch for root changes
+	err := s.watchRoots()
 	if err != nil {
 		return err
 	}
@@ -156,6 +223,21 @@ func (s *state) initWatches() error {
 		return err
 	}
 
+	// Watch the config entries that make up the discovery chains of service
+	// upstreams.
+	if s.hasServiceUpstreams() {
+		for _, kind := range discoveryChainKinds {
+			err = s.cache.Notify(s.ctx, cachetype.ConfigEntriesName, &structs.ConfigEntryQuery{
+				Kind:         kind,
+				Datacenter:   s.source.Datacenter,
+				QueryOptions: structs.QueryOptions{Token: s.token},
+			}, configEntriesWatchIDPrefix+kind, s.ch)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	// Watch for updates to service endpoints for all upstreams
 	for _, u := range s.proxyCfg.Upstreams {
 		dc := s.source.Datacenter
@@ -174,13 +256,12 @@ func (s *state) initWatches() error {
 		case structs.UpstreamDestTypeService:
 			fallthrough
 		case "": // Treat unset as the default Service type
-			err = s.cache.Notify(s.ctx, cachetype.HealthServicesName, &structs.ServiceSpecificRequest{
-				Datacenter:   dc,
-				QueryOptions: structs.QueryOptions{Token: s.token},
-				ServiceName:  u.DestinationName,
-				Connect:      true,
-			}, u.Identifier(), s.ch)
-
+			target := DiscoveryTarget{
+				Service:     u.DestinationName,
+				Datacenter:  u.Datacenter,
+				MeshGateway: u.MeshGateway.Mode,
+			}
+			err = s.watchTarget(s.ctx, u.Identifier(), target)
 			if err != nil {
 				return err
 			}
@@ -192,6 +273,174 @@ func (s *state) initWatches() error {
 	return nil
 }
 
+// initWatchesMeshGateway sets up the watches needed by a mesh gateway. The
+// watches on the individual services and datacenters are started once the
+// lists of services and datacenters are known.
+func (s *state) initWatchesMeshGateway() error {
+	// Watch for root changes
+	err := s.watchRoots()
+	if err != nil {
+		return err
+	}
+
+	// Watch for all services in the local datacenter
+	err = s.cache.Notify(s.ctx, cachetype.CatalogListServicesName, &structs.DCSpecificRequest{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+	}, serviceListWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+
+	// Watch the service-resolvers of the local datacenter to know the subsets
+	// connections may be routed to.
+	err = s.cache.Notify(s.ctx, cachetype.ConfigEntriesName, &structs.ConfigEntryQuery{
+		Kind:         structs.ServiceResolver,
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+	}, configEntriesWatchIDPrefix+structs.ServiceResolver, s.ch)
+	if err != nil {
+		return err
+	}
+
+	// Watch for all known datacenters. The list can't be watched with a
+	// blocking query so it is polled.
+	return s.cache.Notify(s.ctx, cachetype.CatalogDatacentersName, &structs.DatacentersRequest{
+		QueryOptions: structs.QueryOptions{Token: s.token, MaxAge: defaultDatacentersPollInterval},
+	}, datacentersWatchID, s.ch)
+}
+
+// gatewayDatacenter returns the datacenter whose mesh gateways traffic for
+// the target should be sent through, or an empty string if the target's
+// instances should be connected to directly.
+func (s *state) gatewayDatacenter(target DiscoveryTarget) string {
+	if target.Datacenter == "" || target.Datacenter == s.source.Datacenter {
+		return ""
+	}
+	switch target.MeshGateway {
+	case structs.MeshGatewayModeLocal:
+		return s.source.Datacenter
+	case structs.MeshGatewayModeRemote:
+		return target.Datacenter
+	default:
+		return ""
+	}
+}
+
+// targetWatchFilter returns the filter used to watch the endpoints of the
+// target. Targets reached through a mesh gateway watch the gateways instead
+// of the target's own instances.
+func (s *state) targetWatchFilter(target DiscoveryTarget) string {
+	if s.gatewayDatacenter(target) != "" {
+		return meshGatewayFilter
+	}
+	return target.Filter
+}
+
+// watchTarget starts a watch on the endpoints of the target and delivers its
+// results with the given correlation ID. The endpoints are the target's own
+// healthy instances unless the target is reached through a mesh gateway, in
+// which case they are the gateways to send its traffic to.
+func (s *state) watchTarget(ctx context.Context, correlationID string, target DiscoveryTarget) error {
+	if dc := s.gatewayDatacenter(target); dc != "" {
+		return s.cache.Notify(ctx, cachetype.InternalServiceDumpName, &structs.DCSpecificRequest{
+			Datacenter:   dc,
+			QueryOptions: structs.QueryOptions{Token: s.token, Filter: meshGatewayFilter},
+		}, correlationID, s.ch)
+	}
+
+	dc := s.source.Datacenter
+	if target.Datacenter != "" {
+		dc = target.Datacenter
+	}
+	return s.cache.Notify(ctx, cachetype.HealthServicesName, &structs.ServiceSpecificRequest{
+		Datacenter:   dc,
+		QueryOptions: structs.QueryOptions{Token: s.token, Filter: target.Filter},
+		ServiceName:  target.Service,
+		Connect:      true,
+	}, correlationID, s.ch)
+}
+
+// hasServiceUpstreams returns true if any of the proxy's upstreams is a
+// service upstream and so has a discovery chain.
+func (s *state) hasServiceUpstreams() bool {
+	for _, u := range s.proxyCfg.Upstreams {
+		if isServiceUpstream(u) {
+			return true
+		}
+	}
+	return false
+}
+
+func isServiceUpstream(u structs.Upstream) bool {
+	return u.DestinationType == "" || u.DestinationType == structs.UpstreamDestTypeService
+}
+
+// isUpstreamID returns true if id is the identifier of one of the proxy's
+// upstreams.
+func (s *state) isUpstreamID(id string) bool {
+	for _, u := range s.proxyCfg.Upstreams {
+		if u.Identifier() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// updateDiscoveryChains recompiles the discovery chains of all the service
+// upstreams and makes sure there is a health watch for each of their targets.
+// Watches for targets that are no longer referenced are stopped.
+func (s *state) updateDiscoveryChains(snap *ConfigSnapshot) error {
+	desired := make(map[string]DiscoveryTarget)
+	for _, u := range s.proxyCfg.Upstreams {
+		if !isServiceUpstream(u) {
+			continue
+		}
+
+		chain := compileDiscoveryChain(u, s.configEntries)
+		snap.UpstreamChains[u.Identifier()] = chain
+
+		for _, targets := range []map[string]DiscoveryTarget{chain.Targets, chain.FailoverTargets} {
+			for id, target := range targets {
+				// Upstreams are already watched by initWatches.
+				if s.isUpstreamID(id) {
+					continue
+				}
+				if target.UnknownSubset {
+					s.logger.Printf("[WARN] Upstream %s of proxy %s routes to subset %q of service %q "+
+						"which its service-resolver doesn't define, no traffic will be sent to it",
+						u.Identifier(), s.proxyID, target.ServiceSubset, target.Service)
+					continue
+				}
+				desired[id] = target
+			}
+		}
+	}
+
+	for id, watch := range s.targetWatches {
+		if target, ok := desired[id]; !ok || s.targetWatchFilter(target) != watch.filter {
+			watch.cancel()
+			delete(s.targetWatches, id)
+			delete(snap.UpstreamEndpoints, id)
+		}
+	}
+
+	for id, target := range desired {
+		if _, ok := s.targetWatches[id]; ok {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(s.ctx)
+		if err := s.watchTarget(ctx, id, target); err != nil {
+			cancel()
+			return err
+		}
+		s.targetWatches[id] = targetWatch{cancel: cancel, filter: s.targetWatchFilter(target)}
+	}
+
+	return nil
+}
+
 func (s *state) run() {
 	// Close the channel we return from Watch when we stop so consumers can stop
 	// watching and clean up their goroutines. It's important we do this here and
@@ -200,11 +449,24 @@ func (s *state) run() {
 	defer close(s.snapCh)
 
 	snap := ConfigSnapshot{
+		Kind:              s.kind,
+		Service:           s.service,
+		Datacenter:        s.source.Datacenter,
 		ProxyID:           s.proxyID,
 		Address:           s.address,
 		Port:              s.port,
 		Proxy:             s.proxyCfg,
 		UpstreamEndpoints: make(map[string]structs.CheckServiceNodes),
+		UpstreamChains:    make(map[string]*DiscoveryChain),
+		MeshGateway: configSnapshotMeshGateway{
+			ServiceGroups:    make(map[string]structs.CheckServiceNodes),
+			GatewayGroups:    make(map[string]structs.CheckServiceNodes),
+			ServiceResolvers: make(map[string]*structs.ServiceResolverConfigEntry),
+		},
+	}
+	if err := s.updateDiscoveryChains(&snap); err != nil {
+		s.logger.Printf("[ERR] Failed to compile discovery chains for proxy %s: %s",
+			s.proxyID, err)
 	}
 	// This turns out to be really fiddly/painful by just using time.Timer.C
 	// directly in the code below since you can't detect when a timer is stopped
@@ -279,13 +541,25 @@ func (s *state) run() {
 }
 
 func (s *state) handleUpdate(u cache.UpdateEvent, snap *ConfigSnapshot) error {
-	switch u.CorrelationID {
-	case rootsWatchID:
+	if u.CorrelationID == rootsWatchID {
 		roots, ok := u.Result.(*structs.IndexedCARoots)
 		if !ok {
 			return fmt.Errorf("invalid type for roots response: %T", u.Result)
 		}
 		snap.Roots = roots
+		return nil
+	}
+
+	switch s.kind {
+	case structs.ServiceKindMeshGateway:
+		return s.handleUpdateMeshGateway(u, snap)
+	default:
+		return s.handleUpdateConnectProxy(u, snap)
+	}
+}
+
+func (s *state) handleUpdateConnectProxy(u cache.UpdateEvent, snap *ConfigSnapshot) error {
+	switch u.CorrelationID {
 	case leafWatchID:
 		leaf, ok := u.Result.(*structs.IssuedCert)
 		if !ok {
@@ -297,11 +571,24 @@ func (s *state) handleUpdate(u cache.UpdateEvent, snap *ConfigSnapshot) error {
 	default:
 		// Service discovery result, figure out which type
 		switch {
+		case strings.HasPrefix(u.CorrelationID, configEntriesWatchIDPrefix):
+			resp, ok := u.Result.(*structs.IndexedConfigEntries)
+			if !ok {
+				return fmt.Errorf("invalid type for config entries response: %T", u.Result)
+			}
+			kind := strings.TrimPrefix(u.CorrelationID, configEntriesWatchIDPrefix)
+			s.configEntries.set(kind, resp.Entries)
+			return s.updateDiscoveryChains(snap)
+
 		case strings.HasPrefix(u.CorrelationID, serviceIDPrefix):
 			resp, ok := u.Result.(*structs.IndexedCheckServiceNodes)
 			if !ok {
 				return fmt.Errorf("invalid type for service response: %T", u.Result)
 			}
+			// Drop late results from target watches that were already stopped.
+			if _, ok := s.targetWatches[u.CorrelationID]; !ok && !s.isUpstreamID(u.CorrelationID) {
+				return nil
+			}
 			snap.UpstreamEndpoints[u.CorrelationID] = resp.Nodes
 
 		case strings.HasPrefix(u.CorrelationID, preparedQueryIDPrefix):
@@ -318,6 +605,128 @@ func (s *state) handleUpdate(u cache.UpdateEvent, snap *ConfigSnapshot) error {
 	return nil
 }
 
+func (s *state) handleUpdateMeshGateway(u cache.UpdateEvent, snap *ConfigSnapshot) error {
+	switch u.CorrelationID {
+	case serviceListWatchID:
+		services, ok := u.Result.(*structs.IndexedServices)
+		if !ok {
+			return fmt.Errorf("invalid type for services response: %T", u.Result)
+		}
+
+		for svc := range services.Services {
+			if _, ok := s.watchedServices[svc]; ok {
+				continue
+			}
+			ctx, cancel := context.WithCancel(s.ctx)
+			err := s.cache.Notify(ctx, cachetype.HealthServicesName, &structs.ServiceSpecificRequest{
+				Datacenter:   s.source.Datacenter,
+				QueryOptions: structs.QueryOptions{Token: s.token},
+				ServiceName:  svc,
+				Connect:      true,
+			}, connectServiceIDPrefix+svc, s.ch)
+			if err != nil {
+				cancel()
+				return err
+			}
+			s.watchedServices[svc] = cancel
+		}
+
+		for svc, cancel := range s.watchedServices {
+			if _, ok := services.Services[svc]; !ok {
+				cancel()
+				delete(s.watchedServices, svc)
+				delete(snap.MeshGateway.ServiceGroups, svc)
+			}
+		}
+		snap.MeshGateway.WatchedServicesSet = true
+
+	case datacentersWatchID:
+		datacentersRaw, ok := u.Result.(*[]string)
+		if !ok {
+			return fmt.Errorf("invalid type for datacenters response: %T", u.Result)
+		}
+		if datacentersRaw == nil {
+			return errors.New("invalid response with a nil datacenter list")
+		}
+
+		datacenters := make(map[string]bool, len(*datacentersRaw))
+		for _, dc := range *datacentersRaw {
+			// Traffic for the local datacenter is routed to its services
+			// directly.
+			if dc == s.source.Datacenter {
+				continue
+			}
+			datacenters[dc] = true
+
+			if _, ok := s.watchedDatacenters[dc]; ok {
+				continue
+			}
+			ctx, cancel := context.WithCancel(s.ctx)
+			err := s.cache.Notify(ctx, cachetype.InternalServiceDumpName, &structs.DCSpecificRequest{
+				Datacenter:   dc,
+				QueryOptions: structs.QueryOptions{Token: s.token, Filter: meshGatewayFilter},
+			}, meshGatewayIDPrefix+dc, s.ch)
+			if err != nil {
+				cancel()
+				return err
+			}
+			s.watchedDatacenters[dc] = cancel
+		}
+
+		for dc, cancel := range s.watchedDatacenters {
+			if !datacenters[dc] {
+				cancel()
+				delete(s.watchedDatacenters, dc)
+				delete(snap.MeshGateway.GatewayGroups, dc)
+			}
+		}
+
+	case configEntriesWatchIDPrefix + structs.ServiceResolver:
+		resp, ok := u.Result.(*structs.IndexedConfigEntries)
+		if !ok {
+			return fmt.Errorf("invalid type for config entries response: %T", u.Result)
+		}
+		resolvers := make(map[string]*structs.ServiceResolverConfigEntry, len(resp.Entries))
+		for _, entry := range resp.Entries {
+			if resolver, ok := entry.(*structs.ServiceResolverConfigEntry); ok {
+				resolvers[resolver.Name] = resolver
+			}
+		}
+		snap.MeshGateway.ServiceResolvers = resolvers
+
+	default:
+		switch {
+		case strings.HasPrefix(u.CorrelationID, connectServiceIDPrefix):
+			resp, ok := u.Result.(*structs.IndexedCheckServiceNodes)
+			if !ok {
+				return fmt.Errorf("invalid type for service response: %T", u.Result)
+			}
+			svc := strings.TrimPrefix(u.CorrelationID, connectServiceIDPrefix)
+			// Drop late results from watches that were already stopped.
+			if _, ok := s.watchedServices[svc]; !ok {
+				return nil
+			}
+			snap.MeshGateway.ServiceGroups[svc] = resp.Nodes
+
+		case strings.HasPrefix(u.CorrelationID, meshGatewayIDPrefix):
+			resp, ok := u.Result.(*structs.IndexedCheckServiceNodes)
+			if !ok {
+				return fmt.Errorf("invalid type for gateways response: %T", u.Result)
+			}
+			dc := strings.TrimPrefix(u.CorrelationID, meshGatewayIDPrefix)
+			// Drop late results from watches that were already stopped.
+			if _, ok := s.watchedDatacenters[dc]; !ok {
+				return nil
+			}
+			snap.MeshGateway.GatewayGroups[dc] = resp.Nodes
+
+		default:
+			return errors.New("unknown correlation ID")
+		}
+	}
+	return nil
+}
+
 // CurrentSnapshot synchronously returns the current ConfigSnapshot if there is
 // one ready. If we don't have one yet because not all necessary parts have been
 // returned (i.e. both roots and leaf cert), nil is returned.
@@ -335,7 +744,8 @@ func (s *state) Changed(ns *structs.NodeService, token string) bool {
 	if ns == nil {
 		return true
 	}
-	return ns.Kind != structs.ServiceKindConnectProxy ||
+	return ns.Kind != s.kind ||
+		s.service != ns.Service ||
 		s.proxyID != ns.ID ||
 		s.address != ns.Address ||
 		s.port != ns.Port ||
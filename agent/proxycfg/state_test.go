@@ -1,10 +1,15 @@
 package proxycfg
 
 import (
+	"context"
+	"log"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
@@ -90,6 +95,26 @@ func TestStateChanged(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name:  "different service name",
+			ns:    structs.TestNodeServiceMeshGateway(t),
+			token: "foo",
+			mutate: func(ns structs.NodeService, token string) (*structs.NodeService, string) {
+				ns.Service = "badger"
+				return &ns, token
+			},
+			want: true,
+		},
+		{
+			name:  "mesh gateway turned into a proxy",
+			ns:    structs.TestNodeServiceMeshGateway(t),
+			token: "foo",
+			mutate: func(ns structs.NodeService, token string) (*structs.NodeService, string) {
+				ns.Kind = structs.ServiceKindConnectProxy
+				return &ns, token
+			},
+			want: true,
+		},
 		{
 			name:  "different proxy upstreams",
 			ns:    structs.TestNodeServiceProxy(t),
@@ -112,3 +137,139 @@ func TestStateChanged(t *testing.T) {
 		})
 	}
 }
+
+func TestState_MeshGatewayWatches(t *testing.T) {
+	t.Parallel()
+
+	types := NewTestCacheTypes(t)
+	types.health.value.Store(&structs.IndexedCheckServiceNodes{
+		Nodes: TestUpstreamNodes(t),
+	})
+	types.dump.value.Store(&structs.IndexedCheckServiceNodes{
+		Nodes: TestGatewayNodesInDC(t, "dc2"),
+	})
+
+	s, err := newState(structs.TestNodeServiceMeshGateway(t), "")
+	require.NoError(t, err)
+	s.logger = log.New(os.Stderr, "", log.LstdFlags)
+	s.source = &structs.QuerySource{Datacenter: "dc1"}
+	s.cache = TestCacheWithTypes(t, types)
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	defer s.cancel()
+
+	snap := ConfigSnapshot{
+		Kind: structs.ServiceKindMeshGateway,
+		MeshGateway: configSnapshotMeshGateway{
+			ServiceGroups: make(map[string]structs.CheckServiceNodes),
+			GatewayGroups: make(map[string]structs.CheckServiceNodes),
+		},
+	}
+
+	recv := func() cache.UpdateEvent {
+		t.Helper()
+		select {
+		case u := <-s.ch:
+			return u
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for watch")
+			return cache.UpdateEvent{}
+		}
+	}
+
+	// The gateway isn't valid until it knows the services to route to.
+	snap.Roots, _ = TestCerts(t)
+	require.False(t, snap.Valid())
+
+	// Every service in the local datacenter is watched.
+	require.NoError(t, s.handleUpdate(cache.UpdateEvent{
+		CorrelationID: serviceListWatchID,
+		Result: &structs.IndexedServices{
+			Services: structs.Services{"db": nil},
+		},
+	}, &snap))
+	require.Len(t, s.watchedServices, 1)
+	require.Contains(t, s.watchedServices, "db")
+	require.True(t, snap.Valid())
+
+	u := recv()
+	require.Equal(t, "connect-service:db", u.CorrelationID)
+	require.NoError(t, s.handleUpdate(u, &snap))
+	require.Equal(t, TestUpstreamNodes(t), snap.MeshGateway.ServiceGroups["db"])
+
+	// The gateways of every other datacenter are watched.
+	dcs := []string{"dc1", "dc2"}
+	require.NoError(t, s.handleUpdate(cache.UpdateEvent{
+		CorrelationID: datacentersWatchID,
+		Result:        &dcs,
+	}, &snap))
+	require.Len(t, s.watchedDatacenters, 1)
+	require.Contains(t, s.watchedDatacenters, "dc2")
+
+	u = recv()
+	require.Equal(t, "mesh-gateway:dc2", u.CorrelationID)
+	require.NoError(t, s.handleUpdate(u, &snap))
+	require.Equal(t, TestGatewayNodesInDC(t, "dc2"), snap.MeshGateway.GatewayGroups["dc2"])
+
+	// Service-resolvers are kept to route connections to subsets.
+	resolver := &structs.ServiceResolverConfigEntry{
+		Kind: structs.ServiceResolver,
+		Name: "db",
+		Subsets: map[string]structs.ServiceResolverSubset{
+			"v1": {Filter: "Service.Meta.version == v1"},
+		},
+	}
+	require.NoError(t, s.handleUpdate(cache.UpdateEvent{
+		CorrelationID: "config-entries:" + structs.ServiceResolver,
+		Result: &structs.IndexedConfigEntries{
+			Kind:    structs.ServiceResolver,
+			Entries: []structs.ConfigEntry{resolver},
+		},
+	}, &snap))
+	require.Equal(t, map[string]*structs.ServiceResolverConfigEntry{"db": resolver},
+		snap.MeshGateway.ServiceResolvers)
+
+	// Removed services and datacenters are no longer watched.
+	require.NoError(t, s.handleUpdate(cache.UpdateEvent{
+		CorrelationID: serviceListWatchID,
+		Result:        &structs.IndexedServices{},
+	}, &snap))
+	require.Empty(t, s.watchedServices)
+	require.Empty(t, snap.MeshGateway.ServiceGroups)
+
+	dcs = []string{"dc1"}
+	require.NoError(t, s.handleUpdate(cache.UpdateEvent{
+		CorrelationID: datacentersWatchID,
+		Result:        &dcs,
+	}, &snap))
+	require.Empty(t, s.watchedDatacenters)
+	require.Empty(t, snap.MeshGateway.GatewayGroups)
+
+	// Late results for the stopped watches are ignored.
+	require.NoError(t, s.handleUpdate(u, &snap))
+	require.Empty(t, snap.MeshGateway.GatewayGroups)
+}
+
+func TestState_UpstreamMeshGatewayModes(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		mode      structs.MeshGatewayMode
+		gatewayDC string
+	}{
+		{structs.MeshGatewayModeDefault, ""},
+		{structs.MeshGatewayModeNone, ""},
+		{structs.MeshGatewayModeLocal, "dc1"},
+		{structs.MeshGatewayModeRemote, "dc2"},
+	} {
+		s := &state{source: &structs.QuerySource{Datacenter: "dc1"}}
+
+		target := DiscoveryTarget{Service: "db", Datacenter: "dc2", MeshGateway: tc.mode}
+		require.Equal(t, tc.gatewayDC, s.gatewayDatacenter(target), string(tc.mode))
+
+		// Targets in the local datacenter never use gateways.
+		target.Datacenter = "dc1"
+		require.Empty(t, s.gatewayDatacenter(target), string(tc.mode))
+		target.Datacenter = ""
+		require.Empty(t, s.gatewayDatacenter(target), string(tc.mode))
+	}
+}
@@ -21,6 +21,10 @@ type TestCacheTypes struct {
 	intentions *ControllableCacheType
 	health     *ControllableCacheType
 	query      *ControllableCacheType
+	configs    *ControllableCacheType
+	services   *ControllableCacheType
+	dcs        *ControllableCacheType
+	dump       *ControllableCacheType
 }
 
 // NewTestCacheTypes creates a set of ControllableCacheTypes for all types that
@@ -33,8 +37,13 @@ func NewTestCacheTypes(t testing.T) *TestCacheTypes {
 		intentions: NewControllableCacheType(t),
 		health:     NewControllableCacheType(t),
 		query:      NewControllableCacheType(t),
+		configs:    NewControllableCacheType(t),
+		services:   NewControllableCacheType(t),
+		dcs:        NewControllableCacheType(t),
+		dump:       NewControllableCacheType(t),
 	}
 	ct.query.blocking = false
+	ct.dcs.blocking = false
 	return ct
 }
 
@@ -65,6 +74,24 @@ func TestCacheWithTypes(t testing.T, types *TestCacheTypes) *cache.Cache {
 	c.RegisterType(cachetype.PreparedQueryName, types.query, &cache.RegisterOptions{
 		Refresh: false,
 	})
+	c.RegisterType(cachetype.ConfigEntriesName, types.configs, &cache.RegisterOptions{
+		Refresh:        true,
+		RefreshTimer:   0,
+		RefreshTimeout: 10 * time.Minute,
+	})
+	c.RegisterType(cachetype.CatalogListServicesName, types.services, &cache.RegisterOptions{
+		Refresh:        true,
+		RefreshTimer:   0,
+		RefreshTimeout: 10 * time.Minute,
+	})
+	c.RegisterType(cachetype.CatalogDatacentersName, types.dcs, &cache.RegisterOptions{
+		Refresh: false,
+	})
+	c.RegisterType(cachetype.InternalServiceDumpName, types.dump, &cache.RegisterOptions{
+		Refresh:        true,
+		RefreshTimer:   0,
+		RefreshTimeout: 10 * time.Minute,
+	})
 	return c
 }
 
@@ -145,13 +172,33 @@ func TestUpstreamNodes(t testing.T) structs.CheckServiceNodes {
 	}
 }
 
+// TestUpstreamNodesInDC returns a sample service discovery result for a
+// remote datacenter, useful for testing failover.
+func TestUpstreamNodesInDC(t testing.T, dc string) structs.CheckServiceNodes {
+	return structs.CheckServiceNodes{
+		structs.CheckServiceNode{
+			Node: &structs.Node{
+				ID:         "test3",
+				Node:       "test1-" + dc,
+				Address:    "10.20.1.1",
+				Datacenter: dc,
+			},
+			Service: structs.TestNodeService(t),
+		},
+	}
+}
+
 // TestConfigSnapshot returns a fully populated snapshot
 func TestConfigSnapshot(t testing.T) *ConfigSnapshot {
 	roots, leaf := TestCerts(t)
+	upstreams := structs.TestUpstreams(t)
 	return &ConfigSnapshot{
-		ProxyID: "web-sidecar-proxy",
-		Address: "0.0.0.0",
-		Port:    9999,
+		Kind:       structs.ServiceKindConnectProxy,
+		Service:    "web-sidecar-proxy",
+		Datacenter: "dc1",
+		ProxyID:    "web-sidecar-proxy",
+		Address:    "0.0.0.0",
+		Port:       9999,
 		Proxy: structs.ConnectProxyConfig{
 			DestinationServiceID:   "web",
 			DestinationServiceName: "web",
@@ -160,14 +207,130 @@ func TestConfigSnapshot(t testing.T) *ConfigSnapshot {
 			Config: map[string]interface{}{
 				"foo": "bar",
 			},
-			Upstreams: structs.TestUpstreams(t),
+			Upstreams: upstreams,
 		},
 		Roots: roots,
 		Leaf:  leaf,
 		UpstreamEndpoints: map[string]structs.CheckServiceNodes{
 			"service:db": TestUpstreamNodes(t),
 		},
+		UpstreamChains: map[string]*DiscoveryChain{
+			"service:db": compileDiscoveryChain(upstreams[0], nil),
+		},
+	}
+}
+
+// TestGatewayNodesInDC returns a sample service dump of the mesh gateways of
+// a remote datacenter. The gateways' nodes have WAN addresses.
+func TestGatewayNodesInDC(t testing.T, dc string) structs.CheckServiceNodes {
+	return structs.CheckServiceNodes{
+		structs.CheckServiceNode{
+			Node: &structs.Node{
+				ID:         "mesh-gateway-1",
+				Node:       "mesh-gateway-1-" + dc,
+				Address:    "10.30.1.1",
+				Datacenter: dc,
+				TaggedAddresses: map[string]string{
+					"lan": "10.30.1.1",
+					"wan": "198.18.1.1",
+				},
+			},
+			Service: &structs.NodeService{
+				Kind:    structs.ServiceKindMeshGateway,
+				ID:      "mesh-gateway",
+				Service: "mesh-gateway",
+				Port:    8443,
+			},
+		},
+	}
+}
+
+// TestConfigSnapshotMeshGateway returns a fully populated snapshot of a mesh
+// gateway in dc1 that knows about the gateways of dc2 and the db service.
+func TestConfigSnapshotMeshGateway(t testing.T) *ConfigSnapshot {
+	roots, _ := TestCerts(t)
+	return &ConfigSnapshot{
+		Kind:       structs.ServiceKindMeshGateway,
+		Service:    "mesh-gateway",
+		Datacenter: "dc1",
+		ProxyID:    "mesh-gateway",
+		Address:    "1.2.3.4",
+		Port:       8443,
+		Proxy: structs.ConnectProxyConfig{
+			Config: map[string]interface{}{
+				"connect_timeout_ms": 2000,
+			},
+		},
+		Roots: roots,
+		MeshGateway: configSnapshotMeshGateway{
+			WatchedServicesSet: true,
+			ServiceGroups: map[string]structs.CheckServiceNodes{
+				"db": TestUpstreamNodes(t),
+			},
+			GatewayGroups: map[string]structs.CheckServiceNodes{
+				"dc2": TestGatewayNodesInDC(t, "dc2"),
+			},
+			ServiceResolvers: map[string]*structs.ServiceResolverConfigEntry{},
+		},
+	}
+}
+
+// TestDiscoveryChain returns the discovery chain of a service upstream when
+// no config entries apply to it.
+func TestDiscoveryChain(t testing.T, u structs.Upstream) *DiscoveryChain {
+	return compileDiscoveryChain(u, nil)
+}
+
+// TestConfigSnapshotWithEntries returns a fully populated snapshot whose
+// discovery chains are compiled from the given config entries. Every chain
+// target that isn't an upstream itself or an unknown subset is given the
+// sample endpoints, and failover targets the sample endpoints of their
+// datacenter.
+func TestConfigSnapshotWithEntries(t testing.T, entries ...structs.ConfigEntry) *ConfigSnapshot {
+	return TestConfigSnapshotWithUpstreamEntries(t, nil, entries...)
+}
+
+// TestConfigSnapshotWithUpstreamEntries is like TestConfigSnapshotWithEntries
+// but calls mutate, if not nil, on the snapshot's upstreams before their
+// discovery chains are compiled.
+func TestConfigSnapshotWithUpstreamEntries(t testing.T, mutate func([]structs.Upstream), entries ...structs.ConfigEntry) *ConfigSnapshot {
+	snap := TestConfigSnapshot(t)
+	if mutate != nil {
+		mutate(snap.Proxy.Upstreams)
+	}
+
+	byKind := make(map[string][]structs.ConfigEntry)
+	for _, entry := range entries {
+		require.NoError(t, entry.Normalize())
+		require.NoError(t, entry.Validate())
+		byKind[entry.GetKind()] = append(byKind[entry.GetKind()], entry)
+	}
+	compiled := make(configEntries)
+	for kind, kindEntries := range byKind {
+		compiled.set(kind, kindEntries)
+	}
+
+	for _, u := range snap.Proxy.Upstreams {
+		if !isServiceUpstream(u) {
+			continue
+		}
+		chain := compileDiscoveryChain(u, compiled)
+		snap.UpstreamChains[u.Identifier()] = chain
+		for id, target := range chain.Targets {
+			if target.UnknownSubset {
+				continue
+			}
+			if _, ok := snap.UpstreamEndpoints[id]; !ok {
+				snap.UpstreamEndpoints[id] = TestUpstreamNodes(t)
+			}
+		}
+		for id, target := range chain.FailoverTargets {
+			if _, ok := snap.UpstreamEndpoints[id]; !ok {
+				snap.UpstreamEndpoints[id] = TestUpstreamNodesInDC(t, target.Datacenter)
+			}
+		}
 	}
+	return snap
 }
 
 // ControllableCacheType is a cache.Type that simulates a typical blocking RPC
@@ -11,6 +11,7 @@ import (
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/sentinel"
+	"github.com/hashicorp/go-msgpack/codec"
 	"golang.org/x/crypto/blake2b"
 )
 
@@ -46,6 +47,13 @@ const (
 	ACLPolicyID   ACLPolicyIDType = "id"
 )
 
+type ACLRoleIDType string
+
+const (
+	ACLRoleName ACLRoleIDType = "name"
+	ACLRoleID   ACLRoleIDType = "id"
+)
+
 const (
 	// All policy ids with the first 120 bits set to all zeroes are
 	// reserved for builtin policies. Policy creation will ensure we
@@ -84,6 +92,23 @@ session_prefix "" {
 	// This is the policy ID for anonymous access. This is configurable by the
 	// user.
 	ACLTokenAnonymousID = "00000000-0000-0000-0000-000000000002"
+
+	// aclPolicyTemplateServiceIdentity is the template for the rules of the
+	// policy synthesized for a service identity. It is filled in with the
+	// service name twice, once for the service and once for its sidecar proxy.
+	aclPolicyTemplateServiceIdentity = `
+service "%[1]s" {
+	policy = "write"
+}
+service "%[1]s-sidecar-proxy" {
+	policy = "write"
+}
+service_prefix "" {
+	policy = "read"
+}
+node_prefix "" {
+	policy = "read"
+}`
 )
 
 func ACLIDReserved(id string) bool {
@@ -112,7 +137,60 @@ type ACLIdentity interface {
 	ID() string
 	SecretToken() string
 	PolicyIDs() []string
+	RoleIDs() []string
+	ServiceIdentityList() []*ACLServiceIdentity
 	EmbeddedPolicy() *ACLPolicy
+	IsExpired(asOf time.Time) bool
+}
+
+// ACLServiceIdentity represents a high-level grant of all necessary privileges
+// to assume the identity of the named service in the catalog and within
+// Connect. The privileges are granted by a policy synthesized when the token
+// is resolved so no policy has to be stored for it.
+type ACLServiceIdentity struct {
+	ServiceName string
+
+	// Datacenters that the synthetic policy is valid within.
+	//   - If empty then the policy is valid within all datacenters
+	Datacenters []string `json:",omitempty"`
+}
+
+func (s *ACLServiceIdentity) Clone() *ACLServiceIdentity {
+	s2 := *s
+	s2.Datacenters = nil
+	if len(s.Datacenters) > 0 {
+		s2.Datacenters = make([]string, len(s.Datacenters))
+		copy(s2.Datacenters, s.Datacenters)
+	}
+	return &s2
+}
+
+func (s *ACLServiceIdentity) EstimateSize() int {
+	size := len(s.ServiceName)
+	for _, dc := range s.Datacenters {
+		size += len(dc)
+	}
+	return size
+}
+
+// SyntheticPolicy returns the policy granting the privileges of the service
+// identity. Its ID is derived from the rules so the same service identity
+// always maps to the same policy.
+func (s *ACLServiceIdentity) SyntheticPolicy() *ACLPolicy {
+	// The service name is validated before the token is persisted so it
+	// doesn't need escaping here.
+	rules := fmt.Sprintf(aclPolicyTemplateServiceIdentity, s.ServiceName)
+
+	hasher := fnv.New128a()
+	policy := &ACLPolicy{}
+	policy.ID = fmt.Sprintf("%x", hasher.Sum([]byte(rules)))
+	policy.Name = fmt.Sprintf("synthetic-policy-%s", policy.ID)
+	policy.Description = "synthetic policy"
+	policy.Rules = rules
+	policy.Syntax = acl.SyntaxCurrent
+	policy.Datacenters = s.Datacenters
+	policy.SetHash(true)
+	return policy
 }
 
 type ACLTokenPolicyLink struct {
@@ -120,6 +198,11 @@ type ACLTokenPolicyLink struct {
 	Name string `hash:"ignore"`
 }
 
+type ACLTokenRoleLink struct {
+	ID   string
+	Name string `hash:"ignore"`
+}
+
 type ACLToken struct {
 	// This is the UUID used for tracking and management purposes
 	AccessorID string
@@ -135,6 +218,17 @@ type ACLToken struct {
 	// the list of policy names gets validated and the policy IDs get stored herein
 	Policies []ACLTokenPolicyLink
 
+	// List of role links. Like policy links only the IDs are stored and the
+	// names are filled in when the token is read.
+	Roles []ACLTokenRoleLink `json:",omitempty"`
+
+	// List of services to generate synthetic policies for.
+	ServiceIdentities []*ACLServiceIdentity `json:",omitempty"`
+
+	// AuthMethod is the name of the auth method used to create this token
+	// through a login. It is empty for tokens created any other way.
+	AuthMethod string `json:",omitempty"`
+
 	// Type is the V1 Token Type
 	// DEPRECATED (ACL-Legacy-Compat) - remove once we no longer support v1 ACL compat
 	// Even though we are going to auto upgrade management tokens we still
@@ -150,6 +244,23 @@ type ACLToken struct {
 	// to the ACL datacenter and replicated to others.
 	Local bool
 
+	// ExpirationTime represents the point after which a token should be
+	// considered revoked and is eligible for destruction. The zero value
+	// represents NO expiration.
+	//
+	// This is a pointer value so that the zero value is omitted properly
+	// during json serialization. time.Time does not respect json omitempty
+	// directives unfortunately.
+	ExpirationTime *time.Time `json:",omitempty"`
+
+	// ExpirationTTL is a convenience field for helping set ExpirationTime to a
+	// value of CreateTime+ExpirationTTL. This can only be set during
+	// TokenCreate and is cleared and used to initialize the ExpirationTime
+	// field before being persisted to the state store or raft log.
+	//
+	// This is a string version of a time.Duration like "2m".
+	ExpirationTTL time.Duration `json:",omitempty"`
+
 	// The time when this token was created
 	CreateTime time.Time `json:",omitempty"`
 
@@ -167,11 +278,23 @@ type ACLToken struct {
 func (t *ACLToken) Clone() *ACLToken {
 	t2 := *t
 	t2.Policies = nil
+	t2.Roles = nil
+	t2.ServiceIdentities = nil
 
 	if len(t.Policies) > 0 {
 		t2.Policies = make([]ACLTokenPolicyLink, len(t.Policies))
 		copy(t2.Policies, t.Policies)
 	}
+	if len(t.Roles) > 0 {
+		t2.Roles = make([]ACLTokenRoleLink, len(t.Roles))
+		copy(t2.Roles, t.Roles)
+	}
+	if len(t.ServiceIdentities) > 0 {
+		t2.ServiceIdentities = make([]*ACLServiceIdentity, len(t.ServiceIdentities))
+		for i, s := range t.ServiceIdentities {
+			t2.ServiceIdentities[i] = s.Clone()
+		}
+	}
 	return &t2
 }
 
@@ -191,6 +314,40 @@ func (t *ACLToken) PolicyIDs() []string {
 	return ids
 }
 
+func (t *ACLToken) RoleIDs() []string {
+	var ids []string
+	for _, link := range t.Roles {
+		ids = append(ids, link.ID)
+	}
+	return ids
+}
+
+func (t *ACLToken) ServiceIdentityList() []*ACLServiceIdentity {
+	if len(t.ServiceIdentities) == 0 {
+		return nil
+	}
+
+	out := make([]*ACLServiceIdentity, 0, len(t.ServiceIdentities))
+	for _, s := range t.ServiceIdentities {
+		out = append(out, s.Clone())
+	}
+	return out
+}
+
+// HasExpirationTime returns true if the token has an expiration time set.
+func (t *ACLToken) HasExpirationTime() bool {
+	return t.ExpirationTime != nil && !t.ExpirationTime.IsZero()
+}
+
+// IsExpired returns true if the token has an expiration time at or before
+// asOf. Tokens without an expiration time never expire.
+func (t *ACLToken) IsExpired(asOf time.Time) bool {
+	if asOf.IsZero() || !t.HasExpirationTime() {
+		return false
+	}
+	return t.ExpirationTime.Before(asOf)
+}
+
 func (t *ACLToken) EmbeddedPolicy() *ACLPolicy {
 	// DEPRECATED (ACL-Legacy-Compat)
 	//
@@ -244,6 +401,17 @@ func (t *ACLToken) SetHash(force bool) []byte {
 			hash.Write([]byte(link.ID))
 		}
 
+		for _, link := range t.Roles {
+			hash.Write([]byte(link.ID))
+		}
+
+		for _, srvid := range t.ServiceIdentities {
+			hash.Write([]byte(srvid.ServiceName))
+			for _, dc := range srvid.Datacenters {
+				hash.Write([]byte(dc))
+			}
+		}
+
 		// Finalize the hash
 		hashVal := hash.Sum(nil)
 
@@ -254,11 +422,17 @@ func (t *ACLToken) SetHash(force bool) []byte {
 }
 
 func (t *ACLToken) EstimateSize() int {
-	// 33 = 16 (RaftIndex) + 8 (Hash) + 8 (CreateTime) + 1 (Local)
-	size := 33 + len(t.AccessorID) + len(t.SecretID) + len(t.Description) + len(t.Type) + len(t.Rules)
+	// 41 = 16 (RaftIndex) + 8 (Hash) + 8 (ExpirationTime) + 8 (CreateTime) + 1 (Local)
+	size := 41 + len(t.AccessorID) + len(t.SecretID) + len(t.Description) + len(t.Type) + len(t.Rules) + len(t.AuthMethod)
 	for _, link := range t.Policies {
 		size += len(link.ID) + len(link.Name)
 	}
+	for _, link := range t.Roles {
+		size += len(link.ID) + len(link.Name)
+	}
+	for _, srvid := range t.ServiceIdentities {
+		size += srvid.EstimateSize()
+	}
 	return size
 }
 
@@ -266,30 +440,38 @@ func (t *ACLToken) EstimateSize() int {
 type ACLTokens []*ACLToken
 
 type ACLTokenListStub struct {
-	AccessorID  string
-	Description string
-	Policies    []ACLTokenPolicyLink
-	Local       bool
-	CreateTime  time.Time `json:",omitempty"`
-	Hash        []byte
-	CreateIndex uint64
-	ModifyIndex uint64
-	Legacy      bool `json:",omitempty"`
+	AccessorID        string
+	Description       string
+	Policies          []ACLTokenPolicyLink
+	Roles             []ACLTokenRoleLink    `json:",omitempty"`
+	ServiceIdentities []*ACLServiceIdentity `json:",omitempty"`
+	AuthMethod        string                `json:",omitempty"`
+	Local             bool
+	ExpirationTime    *time.Time `json:",omitempty"`
+	CreateTime        time.Time  `json:",omitempty"`
+	Hash              []byte
+	CreateIndex       uint64
+	ModifyIndex       uint64
+	Legacy            bool `json:",omitempty"`
 }
 
 type ACLTokenListStubs []*ACLTokenListStub
 
 func (token *ACLToken) Stub() *ACLTokenListStub {
 	return &ACLTokenListStub{
-		AccessorID:  token.AccessorID,
-		Description: token.Description,
-		Policies:    token.Policies,
-		Local:       token.Local,
-		CreateTime:  token.CreateTime,
-		Hash:        token.Hash,
-		CreateIndex: token.CreateIndex,
-		ModifyIndex: token.ModifyIndex,
-		Legacy:      token.Rules != "",
+		AccessorID:        token.AccessorID,
+		Description:       token.Description,
+		Policies:          token.Policies,
+		Roles:             token.Roles,
+		ServiceIdentities: token.ServiceIdentities,
+		AuthMethod:        token.AuthMethod,
+		Local:             token.Local,
+		ExpirationTime:    token.ExpirationTime,
+		CreateTime:        token.CreateTime,
+		Hash:              token.Hash,
+		CreateIndex:       token.CreateIndex,
+		ModifyIndex:       token.ModifyIndex,
+		Legacy:            token.Rules != "",
 	}
 }
 
@@ -500,11 +682,217 @@ func (policies ACLPolicies) Merge(cache *ACLCaches, sentinel sentinel.Evaluator)
 	return acl.MergePolicies(parsed), nil
 }
 
+type ACLRolePolicyLink struct {
+	ID   string
+	Name string `hash:"ignore"`
+}
+
+// ACLRole is a named set of policies. Linking a role to a token grants the
+// token all of the role's policies so that permissions shared by many tokens
+// can be changed in a single place.
+type ACLRole struct {
+	// ID is the internal UUID associated with the role
+	ID string
+
+	// Name is the unique name to reference the role by.
+	Name string
+
+	// Description is a human readable description (Optional)
+	Description string
+
+	// List of policy links.
+	// Note this is the list of IDs and not the names. Prior to role creation
+	// the list of policy names gets validated and the policy IDs get stored herein
+	Policies []ACLRolePolicyLink
+
+	// Hash of the contents of the role
+	// This does not take into account the ID (which is immutable)
+	// nor the raft metadata.
+	//
+	// This is needed mainly for replication purposes. When replicating from
+	// one DC to another keeping the content Hash will allow us to avoid
+	// unnecessary calls to the authoritative DC
+	Hash []byte
+
+	// Embedded Raft Metadata
+	RaftIndex `hash:"ignore"`
+}
+
+func (r *ACLRole) Clone() *ACLRole {
+	r2 := *r
+	r2.Policies = nil
+
+	if len(r.Policies) > 0 {
+		r2.Policies = make([]ACLRolePolicyLink, len(r.Policies))
+		copy(r2.Policies, r.Policies)
+	}
+	return &r2
+}
+
+func (r *ACLRole) SetHash(force bool) []byte {
+	if force || r.Hash == nil {
+		// Initialize a 256bit Blake2 hash (32 bytes)
+		hash, err := blake2b.New256(nil)
+		if err != nil {
+			panic(err)
+		}
+
+		// Write all the user set fields
+		hash.Write([]byte(r.Name))
+		hash.Write([]byte(r.Description))
+		for _, link := range r.Policies {
+			hash.Write([]byte(link.ID))
+		}
+
+		// Finalize the hash
+		hashVal := hash.Sum(nil)
+
+		// Set and return the hash
+		r.Hash = hashVal
+	}
+	return r.Hash
+}
+
+func (r *ACLRole) EstimateSize() int {
+	// This is just an estimate. There is other data structure overhead
+	// pointers etc that this does not account for.
+
+	// 60 = 36 (uuid) + 16 (RaftIndex) + 8 (Hash)
+	size := 60 + len(r.Name) + len(r.Description)
+	for _, link := range r.Policies {
+		size += len(link.ID) + len(link.Name)
+	}
+
+	return size
+}
+
+type ACLRoles []*ACLRole
+
+func (roles ACLRoles) Sort() {
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].ID < roles[j].ID
+	})
+}
+
+const (
+	// BindingRuleBindTypeService binds the tokens created by a login to a
+	// service identity named after the rule's BindName.
+	BindingRuleBindTypeService = "service"
+
+	// BindingRuleBindTypeRole binds the tokens created by a login to the
+	// role named after the rule's BindName.
+	BindingRuleBindTypeRole = "role"
+)
+
+// ACLAuthMethod configures a way for workloads to exchange a credential they
+// already hold, such as a signed JWT, for an ACL token. Auth methods are
+// local to the datacenter they are created in.
+type ACLAuthMethod struct {
+	// Name is the unique name to reference the auth method by.
+	Name string
+
+	// Type selects the validator used to verify the credentials presented
+	// at login.
+	Type string
+
+	// Description is a human readable description (Optional)
+	Description string
+
+	// Config is the configuration of the validator. Its contents depend on
+	// the Type.
+	Config map[string]interface{}
+
+	// Embedded Raft Metadata
+	RaftIndex `hash:"ignore"`
+}
+
+// FixupConfig coerces the Config back into JSON compatible values after the
+// auth method was msgpack decoded. Like the Config of a ProxyConfigEntry it is
+// free form so decoding it turns strings into byte slices and nested maps
+// into map[interface{}]interface{}.
+func (m *ACLAuthMethod) FixupConfig() error {
+	if m.Config == nil {
+		return nil
+	}
+	config, err := jsonSafeProxyConfig(m.Config)
+	if err != nil {
+		return err
+	}
+	m.Config = config
+	return nil
+}
+
+// fixupAuthMethodConfigs calls FixupConfig on all of the given auth methods.
+// It is hooked into the decoding of the requests and responses holding auth
+// methods as msgpack doesn't use the BinaryUnmarshaler of nested values.
+func fixupAuthMethodConfigs(methods ...*ACLAuthMethod) error {
+	for _, method := range methods {
+		if method == nil {
+			continue
+		}
+		if err := method.FixupConfig(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ACLAuthMethods []*ACLAuthMethod
+
+func (methods ACLAuthMethods) Sort() {
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+}
+
+// ACLBindingRule decides what the tokens created by logging in with an auth
+// method are linked to. Every rule of the auth method whose Selector matches
+// the identity verified at login contributes a binding to the new token.
+type ACLBindingRule struct {
+	// ID is the internal UUID associated with the binding rule
+	ID string
+
+	// Description is a human readable description (Optional)
+	Description string
+
+	// AuthMethod is the name of the auth method the rule applies to.
+	AuthMethod string
+
+	// Selector is a boolean expression evaluated against the fields of the
+	// verified identity. An empty selector matches every identity.
+	Selector string
+
+	// BindType is either BindingRuleBindTypeService or
+	// BindingRuleBindTypeRole.
+	BindType string
+
+	// BindName is the name of the service identity or role to bind. It may
+	// reference the fields of the verified identity as ${value.<field>}.
+	BindName string
+
+	// Embedded Raft Metadata
+	RaftIndex `hash:"ignore"`
+}
+
+func (r *ACLBindingRule) Clone() *ACLBindingRule {
+	r2 := *r
+	return &r2
+}
+
+type ACLBindingRules []*ACLBindingRule
+
+func (rules ACLBindingRules) Sort() {
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+}
+
 type ACLReplicationType string
 
 const (
 	ACLReplicateLegacy   ACLReplicationType = "legacy"
 	ACLReplicatePolicies ACLReplicationType = "policies"
+	ACLReplicateRoles    ACLReplicationType = "roles"
 	ACLReplicateTokens   ACLReplicationType = "tokens"
 )
 
@@ -516,6 +904,7 @@ type ACLReplicationStatus struct {
 	SourceDatacenter     string
 	ReplicationType      ACLReplicationType
 	ReplicatedIndex      uint64
+	ReplicatedRoleIndex  uint64
 	ReplicatedTokenIndex uint64
 	LastSuccess          time.Time
 	LastError            time.Time
@@ -561,6 +950,8 @@ type ACLTokenListRequest struct {
 	IncludeLocal  bool   // Whether local tokens should be included
 	IncludeGlobal bool   // Whether global tokens should be included
 	Policy        string // Policy filter
+	Role          string // Role filter
+	AuthMethod    string // Auth method filter
 	Datacenter    string // The datacenter to perform the request within
 	QueryOptions
 }
@@ -719,3 +1110,342 @@ type ACLPolicyBatchSetRequest struct {
 type ACLPolicyBatchDeleteRequest struct {
 	PolicyIDs []string
 }
+
+// ACLRoleSetRequest is used at the RPC layer for creation and update requests
+type ACLRoleSetRequest struct {
+	Role       ACLRole // The role to upsert
+	Datacenter string  // The datacenter to perform the request within
+	WriteRequest
+}
+
+func (r *ACLRoleSetRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLRoleDeleteRequest is used at the RPC layer deletion requests
+type ACLRoleDeleteRequest struct {
+	RoleID     string // id of the role to delete
+	Datacenter string // The datacenter to perform the request within
+	WriteRequest
+}
+
+func (r *ACLRoleDeleteRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLRoleGetRequest is used at the RPC layer to perform role read operations
+type ACLRoleGetRequest struct {
+	RoleID     string        // id or name used for the role lookup
+	RoleIDType ACLRoleIDType // The Type of ID used to lookup the role
+	Datacenter string        // The datacenter to perform the request within
+	QueryOptions
+}
+
+func (r *ACLRoleGetRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLRoleListRequest is used at the RPC layer to request a listing of roles
+type ACLRoleListRequest struct {
+	Policy     string // Policy filter
+	Datacenter string // The datacenter to perform the request within
+	QueryOptions
+}
+
+func (r *ACLRoleListRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+type ACLRoleListResponse struct {
+	Roles ACLRoles
+	QueryMeta
+}
+
+// ACLRoleBatchGetRequest is used at the RPC layer to request a subset of
+// the roles associated with the token used for retrieval
+type ACLRoleBatchGetRequest struct {
+	RoleIDs    []string // List of role ids to fetch
+	Datacenter string   // The datacenter to perform the request within
+	QueryOptions
+}
+
+func (r *ACLRoleBatchGetRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLRoleResponse returns a single role + metadata
+type ACLRoleResponse struct {
+	Role *ACLRole
+	QueryMeta
+}
+
+type ACLRoleBatchResponse struct {
+	Roles []*ACLRole
+	QueryMeta
+}
+
+// ACLRoleBatchSetRequest is used at the Raft layer for batching
+// multiple role creations and updates
+//
+// This is particularly useful during replication
+type ACLRoleBatchSetRequest struct {
+	Roles ACLRoles
+}
+
+// ACLRoleBatchDeleteRequest is used at the Raft layer for batching
+// multiple role deletions
+//
+// This is particularly useful during replication
+type ACLRoleBatchDeleteRequest struct {
+	RoleIDs []string
+}
+
+// ACLAuthMethodSetRequest is used at the RPC layer for creation and update requests
+type ACLAuthMethodSetRequest struct {
+	AuthMethod ACLAuthMethod // The auth method to upsert
+	Datacenter string        // The datacenter to perform the request within
+	WriteRequest
+}
+
+func (r *ACLAuthMethodSetRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+func (r *ACLAuthMethodSetRequest) MarshalBinary() (data []byte, err error) {
+	// Use a type alias to prevent recursively calling this function.
+	type alias ACLAuthMethodSetRequest
+
+	bs := make([]byte, 128)
+	enc := codec.NewEncoderBytes(&bs, msgpackHandle)
+	if err := enc.Encode((*alias)(r)); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (r *ACLAuthMethodSetRequest) UnmarshalBinary(data []byte) error {
+	type alias ACLAuthMethodSetRequest
+
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	if err := dec.Decode((*alias)(r)); err != nil {
+		return err
+	}
+	return fixupAuthMethodConfigs(&r.AuthMethod)
+}
+
+// ACLAuthMethodDeleteRequest is used at the RPC layer deletion requests
+type ACLAuthMethodDeleteRequest struct {
+	AuthMethodName string // name of the auth method to delete
+	Datacenter     string // The datacenter to perform the request within
+	WriteRequest
+}
+
+func (r *ACLAuthMethodDeleteRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLAuthMethodGetRequest is used at the RPC layer to perform auth method read operations
+type ACLAuthMethodGetRequest struct {
+	AuthMethodName string // name used for the auth method lookup
+	Datacenter     string // The datacenter to perform the request within
+	QueryOptions
+}
+
+func (r *ACLAuthMethodGetRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLAuthMethodListRequest is used at the RPC layer to request a listing of auth methods
+type ACLAuthMethodListRequest struct {
+	Datacenter string // The datacenter to perform the request within
+	QueryOptions
+}
+
+func (r *ACLAuthMethodListRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+type ACLAuthMethodListResponse struct {
+	AuthMethods ACLAuthMethods
+	QueryMeta
+}
+
+func (r *ACLAuthMethodListResponse) MarshalBinary() (data []byte, err error) {
+	// Use a type alias to prevent recursively calling this function.
+	type alias ACLAuthMethodListResponse
+
+	bs := make([]byte, 128)
+	enc := codec.NewEncoderBytes(&bs, msgpackHandle)
+	if err := enc.Encode((*alias)(r)); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (r *ACLAuthMethodListResponse) UnmarshalBinary(data []byte) error {
+	type alias ACLAuthMethodListResponse
+
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	if err := dec.Decode((*alias)(r)); err != nil {
+		return err
+	}
+	return fixupAuthMethodConfigs(r.AuthMethods...)
+}
+
+// ACLAuthMethodResponse returns a single auth method + metadata
+type ACLAuthMethodResponse struct {
+	AuthMethod *ACLAuthMethod
+	QueryMeta
+}
+
+func (r *ACLAuthMethodResponse) MarshalBinary() (data []byte, err error) {
+	// Use a type alias to prevent recursively calling this function.
+	type alias ACLAuthMethodResponse
+
+	bs := make([]byte, 128)
+	enc := codec.NewEncoderBytes(&bs, msgpackHandle)
+	if err := enc.Encode((*alias)(r)); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (r *ACLAuthMethodResponse) UnmarshalBinary(data []byte) error {
+	type alias ACLAuthMethodResponse
+
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	if err := dec.Decode((*alias)(r)); err != nil {
+		return err
+	}
+	return fixupAuthMethodConfigs(r.AuthMethod)
+}
+
+// ACLAuthMethodBatchSetRequest is used at the Raft layer for batching
+// multiple auth method creations and updates
+type ACLAuthMethodBatchSetRequest struct {
+	AuthMethods ACLAuthMethods
+}
+
+func (r *ACLAuthMethodBatchSetRequest) MarshalBinary() (data []byte, err error) {
+	// Use a type alias to prevent recursively calling this function.
+	type alias ACLAuthMethodBatchSetRequest
+
+	bs := make([]byte, 128)
+	enc := codec.NewEncoderBytes(&bs, msgpackHandle)
+	if err := enc.Encode((*alias)(r)); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (r *ACLAuthMethodBatchSetRequest) UnmarshalBinary(data []byte) error {
+	type alias ACLAuthMethodBatchSetRequest
+
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	if err := dec.Decode((*alias)(r)); err != nil {
+		return err
+	}
+	return fixupAuthMethodConfigs(r.AuthMethods...)
+}
+
+// ACLAuthMethodBatchDeleteRequest is used at the Raft layer for batching
+// multiple auth method deletions
+type ACLAuthMethodBatchDeleteRequest struct {
+	AuthMethodNames []string
+}
+
+// ACLBindingRuleSetRequest is used at the RPC layer for creation and update requests
+type ACLBindingRuleSetRequest struct {
+	BindingRule ACLBindingRule // The binding rule to upsert
+	Datacenter  string         // The datacenter to perform the request within
+	WriteRequest
+}
+
+func (r *ACLBindingRuleSetRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLBindingRuleDeleteRequest is used at the RPC layer deletion requests
+type ACLBindingRuleDeleteRequest struct {
+	BindingRuleID string // id of the binding rule to delete
+	Datacenter    string // The datacenter to perform the request within
+	WriteRequest
+}
+
+func (r *ACLBindingRuleDeleteRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLBindingRuleGetRequest is used at the RPC layer to perform binding rule read operations
+type ACLBindingRuleGetRequest struct {
+	BindingRuleID string // id used for the binding rule lookup
+	Datacenter    string // The datacenter to perform the request within
+	QueryOptions
+}
+
+func (r *ACLBindingRuleGetRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLBindingRuleListRequest is used at the RPC layer to request a listing of binding rules
+type ACLBindingRuleListRequest struct {
+	AuthMethod string // Auth method filter
+	Datacenter string // The datacenter to perform the request within
+	QueryOptions
+}
+
+func (r *ACLBindingRuleListRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+type ACLBindingRuleListResponse struct {
+	BindingRules ACLBindingRules
+	QueryMeta
+}
+
+// ACLBindingRuleResponse returns a single binding rule + metadata
+type ACLBindingRuleResponse struct {
+	BindingRule *ACLBindingRule
+	QueryMeta
+}
+
+// ACLBindingRuleBatchSetRequest is used at the Raft layer for batching
+// multiple binding rule creations and updates
+type ACLBindingRuleBatchSetRequest struct {
+	BindingRules ACLBindingRules
+}
+
+// ACLBindingRuleBatchDeleteRequest is used at the Raft layer for batching
+// multiple binding rule deletions
+type ACLBindingRuleBatchDeleteRequest struct {
+	BindingRuleIDs []string
+}
+
+// ACLLoginParams are the credentials presented to an auth method at login.
+type ACLLoginParams struct {
+	AuthMethod  string
+	BearerToken string
+}
+
+// ACLLoginRequest is used at the RPC layer to exchange the credentials
+// accepted by an auth method for a new local token.
+type ACLLoginRequest struct {
+	Auth       *ACLLoginParams
+	Datacenter string // The datacenter to perform the request within
+	WriteRequest
+}
+
+func (r *ACLLoginRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLLogoutRequest is used at the RPC layer to delete the token created by a
+// login. The token to delete is the one making the request.
+type ACLLogoutRequest struct {
+	Datacenter string // The datacenter to perform the request within
+	WriteRequest
+}
+
+func (r *ACLLogoutRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
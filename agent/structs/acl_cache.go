@@ -12,6 +12,7 @@ type ACLCachesConfig struct {
 	Policies       int
 	ParsedPolicies int
 	Authorizers    int
+	Roles          int
 }
 
 type ACLCaches struct {
@@ -19,6 +20,7 @@ type ACLCaches struct {
 	parsedPolicies *lru.TwoQueueCache // policy content hash -> acl.Policy
 	policies       *lru.TwoQueueCache // policy ID -> ACLPolicy
 	authorizers    *lru.TwoQueueCache // token secret -> acl.Authorizer
+	roles          *lru.TwoQueueCache // role ID -> ACLRole
 }
 
 type IdentityCacheEntry struct {
@@ -48,6 +50,15 @@ func (e *PolicyCacheEntry) Age() time.Duration {
 	return time.Since(e.CacheTime)
 }
 
+type RoleCacheEntry struct {
+	Role      *ACLRole
+	CacheTime time.Time
+}
+
+func (e *RoleCacheEntry) Age() time.Duration {
+	return time.Since(e.CacheTime)
+}
+
 type AuthorizerCacheEntry struct {
 	Authorizer acl.Authorizer
 	CacheTime  time.Time
@@ -97,6 +108,15 @@ func NewACLCaches(config *ACLCachesConfig) (*ACLCaches, error) {
 		cache.authorizers = authCache
 	}
 
+	if config != nil && config.Roles > 0 {
+		roleCache, err := lru.New2Q(config.Roles)
+		if err != nil {
+			return nil, err
+		}
+
+		cache.roles = roleCache
+	}
+
 	return cache, nil
 }
 
@@ -152,6 +172,19 @@ func (c *ACLCaches) GetAuthorizer(id string) *AuthorizerCacheEntry {
 	return nil
 }
 
+// GetRole fetches a role from the cache by id and returns it
+func (c *ACLCaches) GetRole(roleID string) *RoleCacheEntry {
+	if c == nil || c.roles == nil {
+		return nil
+	}
+
+	if raw, ok := c.roles.Get(roleID); ok {
+		return raw.(*RoleCacheEntry)
+	}
+
+	return nil
+}
+
 // PutIdentity adds a new identity to the cache
 func (c *ACLCaches) PutIdentity(id string, ident ACLIdentity) {
 	if c == nil || c.identities == nil {
@@ -193,6 +226,14 @@ func (c *ACLCaches) PutAuthorizerWithTTL(id string, authorizer acl.Authorizer, t
 	c.authorizers.Add(id, &AuthorizerCacheEntry{Authorizer: authorizer, CacheTime: time.Now(), TTL: ttl})
 }
 
+func (c *ACLCaches) PutRole(roleID string, role *ACLRole) {
+	if c == nil || c.roles == nil {
+		return
+	}
+
+	c.roles.Add(roleID, &RoleCacheEntry{Role: role, CacheTime: time.Now()})
+}
+
 func (c *ACLCaches) RemoveIdentity(id string) {
 	if c != nil && c.identities != nil {
 		c.identities.Remove(id)
@@ -205,6 +246,12 @@ func (c *ACLCaches) RemovePolicy(policyID string) {
 	}
 }
 
+func (c *ACLCaches) RemoveRole(roleID string) {
+	if c != nil && c.roles != nil {
+		c.roles.Remove(roleID)
+	}
+}
+
 func (c *ACLCaches) Purge() {
 	if c != nil {
 		if c.identities != nil {
@@ -219,5 +266,8 @@ func (c *ACLCaches) Purge() {
 		if c.authorizers != nil {
 			c.authorizers.Purge()
 		}
+		if c.roles != nil {
+			c.roles.Purge()
+		}
 	}
 }
@@ -16,7 +16,7 @@ func TestStructs_ACLCaches(t *testing.T) {
 		t.Run("Valid Sizes", func(t *testing.T) {
 			t.Parallel()
 			// 1 isn't valid due to a bug in golang-lru library
-			config := ACLCachesConfig{2, 2, 2, 2}
+			config := ACLCachesConfig{2, 2, 2, 2, 2}
 
 			cache, err := NewACLCaches(&config)
 			require.NoError(t, err)
@@ -25,12 +25,13 @@ func TestStructs_ACLCaches(t *testing.T) {
 			require.NotNil(t, cache.policies)
 			require.NotNil(t, cache.parsedPolicies)
 			require.NotNil(t, cache.authorizers)
+			require.NotNil(t, cache.roles)
 		})
 
 		t.Run("Zero Sizes", func(t *testing.T) {
 			t.Parallel()
 			// 1 isn't valid due to a bug in golang-lru library
-			config := ACLCachesConfig{0, 0, 0, 0}
+			config := ACLCachesConfig{0, 0, 0, 0, 0}
 
 			cache, err := NewACLCaches(&config)
 			require.NoError(t, err)
@@ -39,6 +40,7 @@ func TestStructs_ACLCaches(t *testing.T) {
 			require.Nil(t, cache.policies)
 			require.Nil(t, cache.parsedPolicies)
 			require.Nil(t, cache.authorizers)
+			require.Nil(t, cache.roles)
 		})
 	})
 
@@ -102,4 +104,21 @@ func TestStructs_ACLCaches(t *testing.T) {
 		require.NotNil(t, entry.Authorizer)
 		require.True(t, entry.Authorizer == acl.DenyAll())
 	})
+	t.Run("Roles", func(t *testing.T) {
+		t.Parallel()
+		// 1 isn't valid due to a bug in golang-lru library
+		config := ACLCachesConfig{Roles: 4}
+
+		cache, err := NewACLCaches(&config)
+		require.NoError(t, err)
+		require.NotNil(t, cache)
+
+		cache.PutRole("foo", &ACLRole{})
+		entry := cache.GetRole("foo")
+		require.NotNil(t, entry)
+		require.NotNil(t, entry.Role)
+
+		cache.RemoveRole("foo")
+		require.Nil(t, cache.GetRole("foo"))
+	})
 }
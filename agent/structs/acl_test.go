@@ -140,6 +140,67 @@ func TestStructs_ACLToken_EmbeddedPolicy(t *testing.T) {
 	})
 }
 
+func TestStructs_ACLServiceIdentity_SyntheticPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Rules", func(t *testing.T) {
+		srvid := &ACLServiceIdentity{ServiceName: "web"}
+		policy := srvid.SyntheticPolicy()
+
+		require.NotEmpty(t, policy.ID)
+		require.Equal(t, "synthetic-policy-"+policy.ID, policy.Name)
+		require.Equal(t, acl.SyntaxCurrent, policy.Syntax)
+		require.Contains(t, policy.Rules, `service "web" {`)
+		require.Contains(t, policy.Rules, `service "web-sidecar-proxy" {`)
+		require.Empty(t, policy.Datacenters)
+		require.NotNil(t, policy.Hash)
+
+		parsed, err := acl.NewPolicyFromSource(policy.ID, 0, policy.Rules, policy.Syntax, nil)
+		require.NoError(t, err)
+		authz, err := acl.NewPolicyAuthorizer(acl.DenyAll(), []*acl.Policy{parsed}, nil)
+		require.NoError(t, err)
+		require.True(t, authz.ServiceWrite("web", nil))
+		require.True(t, authz.ServiceWrite("web-sidecar-proxy", nil))
+		require.False(t, authz.ServiceWrite("db", nil))
+		require.True(t, authz.ServiceRead("db"))
+		require.True(t, authz.NodeRead("foo"))
+		require.False(t, authz.NodeWrite("foo", nil))
+	})
+
+	t.Run("Stable ID", func(t *testing.T) {
+		a := (&ACLServiceIdentity{ServiceName: "web"}).SyntheticPolicy()
+		b := (&ACLServiceIdentity{ServiceName: "web", Datacenters: []string{"dc1"}}).SyntheticPolicy()
+		c := (&ACLServiceIdentity{ServiceName: "db"}).SyntheticPolicy()
+
+		require.Equal(t, a.ID, b.ID)
+		require.NotEqual(t, a.Hash, b.Hash)
+		require.Equal(t, []string{"dc1"}, b.Datacenters)
+		require.NotEqual(t, a.ID, c.ID)
+	})
+}
+
+func TestStructs_ACLToken_ServiceIdentityList(t *testing.T) {
+	t.Parallel()
+
+	token := &ACLToken{
+		ServiceIdentities: []*ACLServiceIdentity{
+			&ACLServiceIdentity{
+				ServiceName: "web",
+				Datacenters: []string{"dc1"},
+			},
+		},
+	}
+
+	list := token.ServiceIdentityList()
+	require.Equal(t, token.ServiceIdentities, list)
+
+	// modifying the list must not modify the token
+	list[0].Datacenters[0] = "dc2"
+	require.Equal(t, "dc1", token.ServiceIdentities[0].Datacenters[0])
+
+	require.Nil(t, (&ACLToken{}).ServiceIdentityList())
+}
+
 func TestStructs_ACLToken_SetHash(t *testing.T) {
 	t.Parallel()
 
@@ -183,6 +244,18 @@ func TestStructs_ACLToken_SetHash(t *testing.T) {
 		h := token.SetHash(true)
 		require.NotEqual(t, original, h)
 	})
+
+	t.Run("Service Identities", func(t *testing.T) {
+		original := token.SetHash(true)
+		token.ServiceIdentities = []*ACLServiceIdentity{
+			&ACLServiceIdentity{ServiceName: "web"},
+		}
+		h := token.SetHash(true)
+		require.NotEqual(t, original, h)
+
+		token.ServiceIdentities[0].Datacenters = []string{"dc1"}
+		require.NotEqual(t, h, token.SetHash(true))
+	})
 }
 
 func TestStructs_ACLToken_EstimateSize(t *testing.T) {
@@ -208,7 +281,7 @@ func TestStructs_ACLToken_EstimateSize(t *testing.T) {
 
 	// this test is very contrived. Basically just tests that the
 	// math is okay and returns the value.
-	require.Equal(t, 120, token.EstimateSize())
+	require.Equal(t, 128, token.EstimateSize())
 }
 
 func TestStructs_ACLToken_Stub(t *testing.T) {
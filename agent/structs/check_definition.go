@@ -26,16 +26,27 @@ type CheckDefinition struct {
 	Header                         map[string][]string
 	Method                         string
 	TCP                            string
+	UDP                            string
+	UDPPayload                     string
+	H2C                            string
+	OSService                      string
 	Interval                       time.Duration
 	DockerContainerID              string
 	Shell                          string
 	GRPC                           string
 	GRPCUseTLS                     bool
 	TLSSkipVerify                  bool
+	TLSServerName                  string
+	CACert                         string
+	ClientCert                     string
+	ClientKey                      string
+	UseConnectCert                 bool
 	AliasNode                      string
 	AliasService                   string
 	Timeout                        time.Duration
 	TTL                            time.Duration
+	SuccessBeforePassing           int
+	FailuresBeforeCritical         int
 	DeregisterCriticalServiceAfter time.Duration
 }
 
@@ -73,12 +84,23 @@ func (c *CheckDefinition) CheckType() *CheckType {
 		Header:                         c.Header,
 		Method:                         c.Method,
 		TCP:                            c.TCP,
+		UDP:                            c.UDP,
+		UDPPayload:                     c.UDPPayload,
+		H2C:                            c.H2C,
+		OSService:                      c.OSService,
 		Interval:                       c.Interval,
 		DockerContainerID:              c.DockerContainerID,
 		Shell:                          c.Shell,
 		TLSSkipVerify:                  c.TLSSkipVerify,
+		TLSServerName:                  c.TLSServerName,
+		CACert:                         c.CACert,
+		ClientCert:                     c.ClientCert,
+		ClientKey:                      c.ClientKey,
+		UseConnectCert:                 c.UseConnectCert,
 		Timeout:                        c.Timeout,
 		TTL:                            c.TTL,
+		SuccessBeforePassing:           c.SuccessBeforePassing,
+		FailuresBeforeCritical:         c.FailuresBeforeCritical,
 		DeregisterCriticalServiceAfter: c.DeregisterCriticalServiceAfter,
 	}
 }
@@ -2,6 +2,7 @@ package structs
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
 	"time"
 
@@ -9,10 +10,12 @@ import (
 )
 
 // CheckType is used to create either the CheckMonitor or the CheckTTL.
-// The following types are supported: Script, HTTP, TCP, Docker, TTL, GRPC, Alias. Script,
-// HTTP, Docker, TCP and GRPC all require Interval. Only one of the types may
-// to be provided: TTL or Script/Interval or HTTP/Interval or TCP/Interval or
-// Docker/Interval or GRPC/Interval or AliasService.
+// The following types are supported: Script, HTTP, H2C, TCP, UDP, Docker, TTL,
+// GRPC, OSService, Alias. Script, HTTP, H2C, Docker, TCP, UDP, GRPC and
+// OSService all require Interval. Only one of the types may to be provided:
+// TTL or Script/Interval or HTTP/Interval or H2C/Interval or TCP/Interval or
+// UDP/Interval or Docker/Interval or GRPC/Interval or OSService/Interval or
+// AliasService.
 type CheckType struct {
 	// fields already embedded in CheckDefinition
 	// Note: CheckType.CheckID == CheckDefinition.ID
@@ -30,6 +33,10 @@ type CheckType struct {
 	Header            map[string][]string
 	Method            string
 	TCP               string
+	UDP               string
+	UDPPayload        string
+	H2C               string
+	OSService         string
 	Interval          time.Duration
 	AliasNode         string
 	AliasService      string
@@ -41,6 +48,23 @@ type CheckType struct {
 	Timeout           time.Duration
 	TTL               time.Duration
 
+	// SuccessBeforePassing and FailuresBeforeCritical are the number of
+	// consecutive successful or failed results needed before the status of
+	// an interval based check changes. Zero changes it on the first result.
+	SuccessBeforePassing   int
+	FailuresBeforeCritical int
+
+	// TLSServerName, CACert, ClientCert and ClientKey configure the TLS
+	// connection of HTTP and gRPC checks. CACert, ClientCert and ClientKey
+	// are paths to PEM encoded files on the agent. UseConnectCert presents
+	// the Connect leaf certificate of the check's service instead of
+	// ClientCert and ClientKey.
+	TLSServerName  string
+	CACert         string
+	ClientCert     string
+	ClientKey      string
+	UseConnectCert bool
+
 	// DeregisterCriticalServiceAfter, if >0, will cause the associated
 	// service, if any, to be deregistered if this check is critical for
 	// longer than this duration.
@@ -50,7 +74,8 @@ type CheckTypes []*CheckType
 
 // Validate returns an error message if the check is invalid
 func (c *CheckType) Validate() error {
-	intervalCheck := c.IsScript() || c.HTTP != "" || c.TCP != "" || c.GRPC != ""
+	intervalCheck := c.IsScript() || c.HTTP != "" || c.TCP != "" || c.GRPC != "" ||
+		c.UDP != "" || c.H2C != "" || c.OSService != ""
 
 	if c.Interval > 0 && c.TTL > 0 {
 		return fmt.Errorf("Interval and TTL cannot both be specified")
@@ -67,9 +92,53 @@ func (c *CheckType) Validate() error {
 	if !intervalCheck && !c.IsAlias() && c.TTL <= 0 {
 		return fmt.Errorf("TTL must be > 0 for TTL checks")
 	}
+	if c.H2C != "" {
+		u, err := url.Parse(c.H2C)
+		if err != nil {
+			return fmt.Errorf("H2C is not a valid URL: %v", err)
+		}
+		if u.Scheme != "http" {
+			return fmt.Errorf("H2C must be an http:// URL")
+		}
+	}
+	if c.SuccessBeforePassing < 0 || c.FailuresBeforeCritical < 0 {
+		return fmt.Errorf("SuccessBeforePassing and FailuresBeforeCritical must be >= 0")
+	}
+	if (c.SuccessBeforePassing > 0 || c.FailuresBeforeCritical > 0) && !intervalCheck {
+		return fmt.Errorf("SuccessBeforePassing and FailuresBeforeCritical are only supported for interval checks")
+	}
+	if c.UDPPayload != "" && c.UDP == "" {
+		return fmt.Errorf("UDPPayload can only be set for UDP checks")
+	}
+	if c.hasTLSConfig() {
+		if c.HTTP == "" && c.GRPC == "" {
+			return fmt.Errorf("TLSServerName, CACert, ClientCert, ClientKey and UseConnectCert are only supported for HTTP and gRPC checks")
+		}
+		if c.GRPC != "" && !c.GRPCUseTLS {
+			return fmt.Errorf("GRPCUseTLS must be set to use TLS settings for gRPC checks")
+		}
+	}
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return fmt.Errorf("ClientCert and ClientKey must be set together")
+	}
+	if c.UseConnectCert && c.ClientCert != "" {
+		return fmt.Errorf("UseConnectCert cannot be used with ClientCert and ClientKey")
+	}
 	return nil
 }
 
+// hasTLSConfig returns true if any of the TLS settings besides TLSSkipVerify
+// are set.
+func (c *CheckType) hasTLSConfig() bool {
+	return c.TLSServerName != "" || c.CACert != "" || c.ClientCert != "" || c.ClientKey != "" || c.UseConnectCert
+}
+
+// UsesTLSFiles returns true if the check reads any of its TLS settings from
+// files on the agent.
+func (c *CheckType) UsesTLSFiles() bool {
+	return c.CACert != "" || c.ClientCert != "" || c.ClientKey != ""
+}
+
 // Empty checks if the CheckType has no fields defined. Empty checks parsed from json configs are filtered out
 func (c *CheckType) Empty() bool {
 	return reflect.DeepEqual(c, &CheckType{})
@@ -105,6 +174,21 @@ func (c *CheckType) IsTCP() bool {
 	return c.TCP != "" && c.Interval > 0
 }
 
+// IsUDP checks if this is a UDP type
+func (c *CheckType) IsUDP() bool {
+	return c.UDP != "" && c.Interval > 0
+}
+
+// IsH2C checks if this is an H2C type
+func (c *CheckType) IsH2C() bool {
+	return c.H2C != "" && c.Interval > 0
+}
+
+// IsOSService checks if this is an OS service type
+func (c *CheckType) IsOSService() bool {
+	return c.OSService != "" && c.Interval > 0
+}
+
 // IsDocker returns true when checking a docker container.
 func (c *CheckType) IsDocker() bool {
 	return c.IsScript() && c.DockerContainerID != "" && c.Interval > 0
@@ -2,19 +2,37 @@ package structs
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/mitchellh/hashstructure"
+	"github.com/mitchellh/mapstructure"
 )
 
 const (
 	ServiceDefaults string = "service-defaults"
 	ProxyDefaults   string = "proxy-defaults"
+	ServiceRouter   string = "service-router"
+	ServiceSplitter string = "service-splitter"
+	ServiceResolver string = "service-resolver"
 
 	ProxyConfigGlobal string = "global"
 
 	DefaultServiceProtocol = "tcp"
+
+	// DNSOrderingRandom shuffles DNS answers for every query.
+	DNSOrderingRandom string = "random"
+	// DNSOrderingRoundRobin rotates DNS answers for each resolver that asks.
+	DNSOrderingRoundRobin string = "round-robin"
+	// DNSOrderingNearest sorts DNS answers by the estimated round trip time
+	// from the agent answering the query.
+	DNSOrderingNearest string = "nearest"
+	// DNSOrderingWeighted shuffles DNS answers so that instances come first
+	// in proportion to their passing or warning weight.
+	DNSOrderingWeighted string = "weighted"
 )
 
 // ConfigEntry is the
@@ -41,6 +59,7 @@ type ServiceConfigEntry struct {
 	Name     string
 	Protocol string
 	Connect  ConnectConfiguration
+	DNS      ServiceDNSConfiguration
 
 	RaftIndex
 }
@@ -68,11 +87,18 @@ func (e *ServiceConfigEntry) Normalize() error {
 	} else {
 		e.Protocol = strings.ToLower(e.Protocol)
 	}
+	e.DNS.Ordering = strings.ToLower(e.DNS.Ordering)
 
 	return nil
 }
 
 func (e *ServiceConfigEntry) Validate() error {
+	switch e.DNS.Ordering {
+	case "", DNSOrderingRandom, DNSOrderingRoundRobin, DNSOrderingNearest, DNSOrderingWeighted:
+	default:
+		return fmt.Errorf("Bad DNS.Ordering %q: must be one of %q, %q, %q or %q", e.DNS.Ordering,
+			DNSOrderingRandom, DNSOrderingRoundRobin, DNSOrderingNearest, DNSOrderingWeighted)
+	}
 	return nil
 }
 
@@ -96,6 +122,16 @@ type ConnectConfiguration struct {
 	SidecarProxy bool
 }
 
+// ServiceDNSConfiguration controls how the DNS interface answers queries for
+// a service.
+type ServiceDNSConfiguration struct {
+	// Ordering is one of the DNSOrdering* values and sets the order that
+	// instances are returned in. It applies to A, AAAA and SRV answers, so
+	// clients that don't support SRV weights still get weighted spreading.
+	// Prepared queries keep their own ordering. Defaults to random.
+	Ordering string
+}
+
 // ProxyConfigEntry is the top-level struct for global proxy configuration defaults.
 type ProxyConfigEntry struct {
 	Kind   string
@@ -156,6 +192,56 @@ func (e *ProxyConfigEntry) GetRaftIndex() *RaftIndex {
 	return &e.RaftIndex
 }
 
+func (e *ProxyConfigEntry) MarshalBinary() (data []byte, err error) {
+	// We mainly want to implement the BinaryMarshaller interface so that
+	// we can fixup some msgpack types to coerce them into JSON compatible
+	// values. No special encoding needs to be done - we just simply msgpack
+	// encode the struct which requires a type alias to prevent recursively
+	// calling this function.
+
+	type alias ProxyConfigEntry
+
+	a := alias(*e)
+
+	// bs will grow if needed but allocate enough to avoid reallocation in common
+	// case.
+	bs := make([]byte, 128)
+	enc := codec.NewEncoderBytes(&bs, msgpackHandle)
+	err = enc.Encode(a)
+	if err != nil {
+		return nil, err
+	}
+
+	return bs, nil
+}
+
+func (e *ProxyConfigEntry) UnmarshalBinary(data []byte) error {
+	// The goal here is to add a post-decoding operation to
+	// decoding of a ProxyConfigEntry. The cleanest way to do so
+	// is to implement the BinaryMarshaller interface and use a type
+	// alias to do the original round of decoding, followed by a walk
+	// of the Config to coerce everything into JSON compatible types.
+	type alias ProxyConfigEntry
+
+	var a alias
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	if err := dec.Decode(&a); err != nil {
+		return err
+	}
+
+	*e = ProxyConfigEntry(a)
+
+	if e.Config != nil {
+		config, err := jsonSafeProxyConfig(e.Config)
+		if err != nil {
+			return err
+		}
+		e.Config = config
+	}
+
+	return nil
+}
+
 type ConfigEntryOp string
 
 const (
@@ -234,11 +320,55 @@ func MakeConfigEntry(kind, name string) (ConfigEntry, error) {
 		return &ServiceConfigEntry{Name: name}, nil
 	case ProxyDefaults:
 		return &ProxyConfigEntry{Name: name}, nil
+	case ServiceRouter:
+		return &ServiceRouterConfigEntry{Name: name}, nil
+	case ServiceSplitter:
+		return &ServiceSplitterConfigEntry{Name: name}, nil
+	case ServiceResolver:
+		return &ServiceResolverConfigEntry{Name: name}, nil
 	default:
 		return nil, fmt.Errorf("invalid config entry kind: %s", kind)
 	}
 }
 
+// DecodeConfigEntry can be used to decode a ConfigEntry from a raw map value.
+// It is used in the HTTP API to decode ConfigEntry structs coming from JSON.
+// Unlike our custom binary encodings there is no preamble including the kind,
+// so the payload must first be decoded into a map[string]interface{} before
+// the kind can be inspected and the matching concrete type decoded.
+func DecodeConfigEntry(raw map[string]interface{}) (ConfigEntry, error) {
+	kindVal, ok := raw["Kind"]
+	if !ok {
+		kindVal, ok = raw["kind"]
+	}
+	if !ok {
+		return nil, fmt.Errorf("Payload does not contain a Kind/kind key at the top level")
+	}
+
+	kind, ok := kindVal.(string)
+	if !ok {
+		return nil, fmt.Errorf("Kind value in payload is not a string")
+	}
+
+	entry, err := MakeConfigEntry(kind, "")
+	if err != nil {
+		return nil, err
+	}
+
+	decodeConf := &mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		Result:           &entry,
+		WeaklyTypedInput: true,
+	}
+
+	decoder, err := mapstructure.NewDecoder(decodeConf)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, decoder.Decode(raw)
+}
+
 // ConfigEntryQuery is used when requesting info about a config entry.
 type ConfigEntryQuery struct {
 	Kind       string
@@ -252,6 +382,30 @@ func (c *ConfigEntryQuery) RequestDatacenter() string {
 	return c.Datacenter
 }
 
+func (r *ConfigEntryQuery) CacheInfo() cache.RequestInfo {
+	info := cache.RequestInfo{
+		Token:          r.Token,
+		Datacenter:     r.Datacenter,
+		MinIndex:       r.MinQueryIndex,
+		Timeout:        r.MaxQueryTime,
+		MaxAge:         r.MaxAge,
+		MustRevalidate: r.MustRevalidate,
+	}
+
+	v, err := hashstructure.Hash([]interface{}{
+		r.Kind,
+		r.Name,
+	}, nil)
+	if err == nil {
+		// If there is an error, we don't set the key. A blank key forces
+		// no cache for this request so the request is forwarded directly
+		// to the server.
+		info.Key = strconv.FormatUint(v, 10)
+	}
+
+	return info
+}
+
 // ServiceConfigRequest is used when requesting the resolved configuration
 // for a service.
 type ServiceConfigRequest struct {
@@ -0,0 +1,596 @@
+package structs
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/go-bexpr"
+)
+
+// validRetryOn lists the Envoy retry conditions that may be used in
+// ServiceRouteDestination.RetryOn.
+var validRetryOn = map[string]bool{
+	"5xx":                true,
+	"gateway-error":      true,
+	"connect-failure":    true,
+	"retriable-4xx":      true,
+	"refused-stream":     true,
+	"cancelled":          true,
+	"deadline-exceeded":  true,
+	"resource-exhausted": true,
+	"unavailable":        true,
+}
+
+// validSubsetName matches the names that may be given to service subsets.
+// Subset names end up in Envoy cluster names and DNS style identifiers so
+// they are restricted to lower case DNS labels.
+var validSubsetName = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// validHTTPMethods lists the HTTP methods that may be matched by a route.
+var validHTTPMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"POST":    true,
+	"PUT":     true,
+	"DELETE":  true,
+	"CONNECT": true,
+	"OPTIONS": true,
+	"TRACE":   true,
+	"PATCH":   true,
+}
+
+// ServiceRouterConfigEntry defines L7 (e.g. http) routing rules for a named
+// service exposed in Connect.
+//
+// This config entry represents the topmost part of the discovery chain. Each
+// route is evaluated in order and the first match wins. Requests not matched
+// by any route are sent to the service named by the entry itself.
+//
+// Routes are only applied to upstreams whose service-defaults protocol is one
+// of the L7 protocols (http, http2 or grpc).
+type ServiceRouterConfigEntry struct {
+	Kind string
+	Name string
+
+	// Routes is the list of routes to consider when processing L7 requests.
+	// The first rule to match in the list is terminal and stops further
+	// evaluation.
+	Routes []ServiceRoute
+
+	RaftIndex
+}
+
+func (e *ServiceRouterConfigEntry) GetKind() string {
+	return ServiceRouter
+}
+
+func (e *ServiceRouterConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Name
+}
+
+func (e *ServiceRouterConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	e.Kind = ServiceRouter
+
+	for _, route := range e.Routes {
+		if route.Match == nil || route.Match.HTTP == nil {
+			continue
+		}
+
+		httpMatch := route.Match.HTTP
+		for j := range httpMatch.Methods {
+			httpMatch.Methods[j] = strings.ToUpper(httpMatch.Methods[j])
+		}
+	}
+
+	return nil
+}
+
+func (e *ServiceRouterConfigEntry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	for i, route := range e.Routes {
+		eligibleForPrefixRewrite := false
+		if route.Match != nil && route.Match.HTTP != nil {
+			if err := route.Match.HTTP.validate(); err != nil {
+				return fmt.Errorf("Route[%d]: %v", i, err)
+			}
+			eligibleForPrefixRewrite = route.Match.HTTP.PathExact != "" ||
+				route.Match.HTTP.PathPrefix != ""
+		}
+
+		if route.Destination == nil {
+			continue
+		}
+
+		if route.Destination.PrefixRewrite != "" && !eligibleForPrefixRewrite {
+			return fmt.Errorf("Route[%d] cannot make use of PrefixRewrite without configuring either PathExact or PathPrefix", i)
+		}
+		if err := route.Destination.validate(); err != nil {
+			return fmt.Errorf("Route[%d]: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (e *ServiceRouterConfigEntry) CanRead(rule acl.Authorizer) bool {
+	return rule.ServiceRead(e.Name)
+}
+
+func (e *ServiceRouterConfigEntry) CanWrite(rule acl.Authorizer) bool {
+	return rule.ServiceWrite(e.Name, nil)
+}
+
+func (e *ServiceRouterConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+
+	return &e.RaftIndex
+}
+
+// ServiceRoute is a single routing rule that routes traffic to the
+// destination when the match criteria applies.
+type ServiceRoute struct {
+	Match       *ServiceRouteMatch       `json:",omitempty"`
+	Destination *ServiceRouteDestination `json:",omitempty"`
+}
+
+// ServiceRouteMatch is a set of criteria that can match incoming L7 requests.
+type ServiceRouteMatch struct {
+	HTTP *ServiceRouteHTTPMatch `json:",omitempty"`
+}
+
+// ServiceRouteHTTPMatch is a set of http-specific match criteria. At most one
+// of PathExact, PathPrefix or PathRegex may be set. All of the configured
+// criteria must match for the route to be selected.
+type ServiceRouteHTTPMatch struct {
+	PathExact  string `json:",omitempty"`
+	PathPrefix string `json:",omitempty"`
+	PathRegex  string `json:",omitempty"`
+
+	Header     []ServiceRouteHTTPMatchHeader     `json:",omitempty"`
+	QueryParam []ServiceRouteHTTPMatchQueryParam `json:",omitempty"`
+	Methods    []string                          `json:",omitempty"`
+}
+
+func (m *ServiceRouteHTTPMatch) validate() error {
+	pathParts := 0
+	if m.PathExact != "" {
+		pathParts++
+		if !strings.HasPrefix(m.PathExact, "/") {
+			return fmt.Errorf("PathExact doesn't start with '/': %q", m.PathExact)
+		}
+	}
+	if m.PathPrefix != "" {
+		pathParts++
+		if !strings.HasPrefix(m.PathPrefix, "/") {
+			return fmt.Errorf("PathPrefix doesn't start with '/': %q", m.PathPrefix)
+		}
+	}
+	if m.PathRegex != "" {
+		pathParts++
+		if _, err := regexp.Compile(m.PathRegex); err != nil {
+			return fmt.Errorf("PathRegex is invalid: %v", err)
+		}
+	}
+	if pathParts > 1 {
+		return fmt.Errorf("should only contain at most one of PathExact, PathPrefix, or PathRegex")
+	}
+
+	for j, hdr := range m.Header {
+		if hdr.Name == "" {
+			return fmt.Errorf("missing required Name field on Header[%d]", j)
+		}
+
+		hdrParts := 0
+		if hdr.Present {
+			hdrParts++
+		}
+		if hdr.Exact != "" {
+			hdrParts++
+		}
+		if hdr.Regex != "" {
+			hdrParts++
+			if _, err := regexp.Compile(hdr.Regex); err != nil {
+				return fmt.Errorf("Header[%d] Regex is invalid: %v", j, err)
+			}
+		}
+		if hdr.Prefix != "" {
+			hdrParts++
+		}
+		if hdr.Suffix != "" {
+			hdrParts++
+		}
+		if hdrParts != 1 {
+			return fmt.Errorf("should only contain one of Present, Exact, Prefix, Suffix, or Regex in Header[%d]", j)
+		}
+	}
+
+	for j, qm := range m.QueryParam {
+		if qm.Name == "" {
+			return fmt.Errorf("missing required Name field on QueryParam[%d]", j)
+		}
+
+		qmParts := 0
+		if qm.Present {
+			qmParts++
+		}
+		if qm.Exact != "" {
+			qmParts++
+		}
+		if qm.Regex != "" {
+			qmParts++
+			if _, err := regexp.Compile(qm.Regex); err != nil {
+				return fmt.Errorf("QueryParam[%d] Regex is invalid: %v", j, err)
+			}
+		}
+		if qmParts != 1 {
+			return fmt.Errorf("should only contain one of Present, Exact, or Regex in QueryParam[%d]", j)
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, method := range m.Methods {
+		if !validHTTPMethods[method] {
+			return fmt.Errorf("invalid HTTP method %q", method)
+		}
+		if found[method] {
+			return fmt.Errorf("Methods contains %q more than once", method)
+		}
+		found[method] = true
+	}
+
+	return nil
+}
+
+// ServiceRouteHTTPMatchHeader matches a single request header. Exactly one of
+// Present, Exact, Prefix, Suffix or Regex must be set. Invert negates the
+// result of the match.
+type ServiceRouteHTTPMatchHeader struct {
+	Name    string
+	Present bool   `json:",omitempty"`
+	Exact   string `json:",omitempty"`
+	Prefix  string `json:",omitempty"`
+	Suffix  string `json:",omitempty"`
+	Regex   string `json:",omitempty"`
+	Invert  bool   `json:",omitempty"`
+}
+
+// ServiceRouteHTTPMatchQueryParam matches a single query parameter. Exactly
+// one of Present, Exact or Regex must be set.
+type ServiceRouteHTTPMatchQueryParam struct {
+	Name    string
+	Present bool   `json:",omitempty"`
+	Exact   string `json:",omitempty"`
+	Regex   string `json:",omitempty"`
+}
+
+// ServiceRouteDestination describes how to proxy the actual matching request
+// to a service.
+type ServiceRouteDestination struct {
+	// Service is the service to resolve instead of the default service. If
+	// empty then the default service name is used.
+	Service string `json:",omitempty"`
+
+	// ServiceSubset is a named subset of the given service to resolve instead
+	// of the default subset of that service.
+	ServiceSubset string `json:",omitempty"`
+
+	// PrefixRewrite allows for the proxied request to have its matching path
+	// prefix modified before being sent to the destination. Only valid with
+	// PathExact or PathPrefix matches.
+	PrefixRewrite string `json:",omitempty"`
+
+	// RequestTimeout is the total amount of time permitted for the entire
+	// downstream request (and retries) to be processed.
+	RequestTimeout time.Duration `json:",omitempty"`
+
+	// NumRetries is the number of times to retry the request when a retryable
+	// result occurs.
+	NumRetries uint32 `json:",omitempty"`
+
+	// RetryOn lists the conditions under which a request is retried. It
+	// defaults to "5xx" if NumRetries is set.
+	RetryOn []string `json:",omitempty"`
+}
+
+func (d *ServiceRouteDestination) validate() error {
+	if d.RequestTimeout < 0 {
+		return fmt.Errorf("RequestTimeout cannot be negative")
+	}
+	for _, cond := range d.RetryOn {
+		if !validRetryOn[cond] {
+			return fmt.Errorf("invalid RetryOn condition %q", cond)
+		}
+	}
+	return nil
+}
+
+// ServiceSplitterConfigEntry defines how incoming requests are split across
+// different subsets of a single service (like during staged canary rollouts),
+// or perhaps across different services (like during a v2 rewrite or other
+// type of codebase migration).
+//
+// Splits are rendered as Envoy weighted clusters so they are only applied to
+// upstreams that use one of the L7 protocols (http, http2 or grpc).
+type ServiceSplitterConfigEntry struct {
+	Kind string
+	Name string
+
+	// Splits is the configurations for the details of the traffic splitting.
+	//
+	// The sum of weights across all splits must add up to 100.
+	Splits []ServiceSplit
+
+	RaftIndex
+}
+
+func (e *ServiceSplitterConfigEntry) GetKind() string {
+	return ServiceSplitter
+}
+
+func (e *ServiceSplitterConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Name
+}
+
+func (e *ServiceSplitterConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	e.Kind = ServiceSplitter
+
+	// This slightly massages inputs to enforce that the smallest representable
+	// weight is 1/10000 or .01%
+	for i, split := range e.Splits {
+		e.Splits[i].Weight = NormalizeServiceSplitWeight(split.Weight)
+	}
+
+	return nil
+}
+
+func (e *ServiceSplitterConfigEntry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	if len(e.Splits) == 0 {
+		return fmt.Errorf("no splits configured")
+	}
+
+	const maxScaledWeight = 100 * 100
+
+	found := make(map[string]bool)
+	sumScaled := 0
+	for i, split := range e.Splits {
+		if split.Weight < 0 || split.Weight > 100 {
+			return fmt.Errorf("Splits[%d] Weight must be between 0 and 100", i)
+		}
+
+		svc := split.Service
+		if svc == "" {
+			svc = e.Name
+		}
+		key := svc + "/" + split.ServiceSubset
+		if found[key] {
+			return fmt.Errorf("Splits[%d] duplicates the destination of another split", i)
+		}
+		found[key] = true
+
+		sumScaled += ScaleServiceSplitWeight(split.Weight)
+	}
+
+	if sumScaled != maxScaledWeight {
+		return fmt.Errorf("the sum of all split weights must be 100, not %.2f", float32(sumScaled)/100)
+	}
+
+	return nil
+}
+
+func (e *ServiceSplitterConfigEntry) CanRead(rule acl.Authorizer) bool {
+	return rule.ServiceRead(e.Name)
+}
+
+func (e *ServiceSplitterConfigEntry) CanWrite(rule acl.Authorizer) bool {
+	return rule.ServiceWrite(e.Name, nil)
+}
+
+func (e *ServiceSplitterConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+
+	return &e.RaftIndex
+}
+
+// ServiceSplit defines how much traffic to send to which set of service
+// instances during a traffic split.
+type ServiceSplit struct {
+	// Weight is a value between 0 and 100 reflecting what portion of traffic
+	// should be directed to this split.
+	//
+	// The smallest representable weight is 1/10000 or .01%
+	Weight float32
+
+	// Service is the service to resolve instead of the default (optional).
+	Service string `json:",omitempty"`
+
+	// ServiceSubset is a named subset of the given service to resolve instead
+	// of one defined as that service's DefaultSubset. If empty the default
+	// subset is used (optional).
+	ServiceSubset string `json:",omitempty"`
+}
+
+// ScaleServiceSplitWeight converts a split weight in percent to an integer
+// number of hundredths of a percent, which is the unit Envoy weights are
+// expressed in.
+func ScaleServiceSplitWeight(weight float32) int {
+	return int(math.Round(float64(weight * 100)))
+}
+
+// NormalizeServiceSplitWeight rounds a split weight to the smallest
+// representable weight of .01%.
+func NormalizeServiceSplitWeight(weight float32) float32 {
+	return float32(ScaleServiceSplitWeight(weight)) / 100
+}
+
+// ServiceResolverConfigEntry defines which instances of a service should
+// satisfy discovery requests for a given named service.
+//
+// This config entry represents the bottom part of the discovery chain. It is
+// applied to every target the chain resolves for the service, regardless of
+// the protocol of the upstream.
+type ServiceResolverConfigEntry struct {
+	Kind string
+	Name string
+
+	// DefaultSubset is the subset to use when no explicit subset is
+	// requested. If empty the unnamed subset of all instances is used.
+	DefaultSubset string `json:",omitempty"`
+
+	// Subsets is a map of subset name to subset definition for all usable
+	// named subsets of this service.
+	Subsets map[string]ServiceResolverSubset `json:",omitempty"`
+
+	// Failover controls when and how to reroute traffic to an alternate pool
+	// of service instances. The map is keyed by the subset it applies to, or
+	// by "*" to apply to any subset without an entry of its own (including
+	// the unnamed subset).
+	Failover map[string]ServiceResolverFailover `json:",omitempty"`
+
+	// ConnectTimeout is the timeout for establishing new network connections
+	// to this service. If zero the upstream's own settings are used.
+	ConnectTimeout time.Duration `json:",omitempty"`
+
+	RaftIndex
+}
+
+func (e *ServiceResolverConfigEntry) GetKind() string {
+	return ServiceResolver
+}
+
+func (e *ServiceResolverConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Name
+}
+
+func (e *ServiceResolverConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	e.Kind = ServiceResolver
+
+	return nil
+}
+
+func (e *ServiceResolverConfigEntry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	for name, subset := range e.Subsets {
+		if !validSubsetName.MatchString(name) {
+			return fmt.Errorf("Subset %q is invalid: names must be lower case alphanumerics and dashes", name)
+		}
+		if err := subset.validate(); err != nil {
+			return fmt.Errorf("Subset %q: %v", name, err)
+		}
+	}
+
+	if e.DefaultSubset != "" {
+		if _, ok := e.Subsets[e.DefaultSubset]; !ok {
+			return fmt.Errorf("DefaultSubset %q is not a valid subset", e.DefaultSubset)
+		}
+	}
+
+	for subset, failover := range e.Failover {
+		if subset != "*" {
+			if _, ok := e.Subsets[subset]; !ok {
+				return fmt.Errorf("Bad Failover[%q]: not a valid subset", subset)
+			}
+		}
+		if len(failover.Datacenters) == 0 {
+			return fmt.Errorf("Bad Failover[%q]: no Datacenters configured", subset)
+		}
+		for _, dc := range failover.Datacenters {
+			if dc == "" {
+				return fmt.Errorf("Bad Failover[%q]: found empty datacenter", subset)
+			}
+		}
+	}
+
+	if e.ConnectTimeout < 0 {
+		return fmt.Errorf("Bad ConnectTimeout '%s', must be >= 0", e.ConnectTimeout)
+	}
+
+	return nil
+}
+
+func (e *ServiceResolverConfigEntry) CanRead(rule acl.Authorizer) bool {
+	return rule.ServiceRead(e.Name)
+}
+
+func (e *ServiceResolverConfigEntry) CanWrite(rule acl.Authorizer) bool {
+	return rule.ServiceWrite(e.Name, nil)
+}
+
+func (e *ServiceResolverConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+
+	return &e.RaftIndex
+}
+
+// ServiceResolverSubset defines a way to select a portion of the Consul
+// catalog during service discovery. Anything that affects the ultimate
+// catalog query performed OR post-processing on the results of that sort of
+// query should be defined here.
+type ServiceResolverSubset struct {
+	// Filter is a bexpr filter expression evaluated against each health
+	// result of the service (a CheckServiceNode with Node, Service and Checks
+	// fields), such as `Service.Meta.version == v2`.
+	//
+	// If empty, all healthy instances are returned.
+	Filter string `json:",omitempty"`
+}
+
+func (s *ServiceResolverSubset) validate() error {
+	if s.Filter == "" {
+		return nil
+	}
+	if _, err := bexpr.CreateEvaluatorForType(s.Filter, nil, &CheckServiceNode{}); err != nil {
+		return fmt.Errorf("Filter is invalid: %v", err)
+	}
+	return nil
+}
+
+// ServiceResolverFailover lists the datacenters to send traffic to, in
+// order, when none of the instances of a subset in its own datacenter are
+// healthy.
+type ServiceResolverFailover struct {
+	Datacenters []string `json:",omitempty"`
+}
@@ -0,0 +1,534 @@
+package structs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceRouterConfigEntry(t *testing.T) {
+	t.Parallel()
+
+	httpMatch := func(http *ServiceRouteHTTPMatch) *ServiceRouteMatch {
+		return &ServiceRouteMatch{HTTP: http}
+	}
+	routeMatch := func(m *ServiceRouteMatch) ServiceRoute {
+		return ServiceRoute{Match: m}
+	}
+
+	cases := []struct {
+		name        string
+		entry       *ServiceRouterConfigEntry
+		validateErr string
+		check       func(t *testing.T, entry *ServiceRouterConfigEntry)
+	}{
+		{
+			name:        "missing name",
+			entry:       &ServiceRouterConfigEntry{},
+			validateErr: "Name is required",
+		},
+		{
+			name:  "empty",
+			entry: &ServiceRouterConfigEntry{Name: "web"},
+		},
+		{
+			name: "path prefix and exact",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					routeMatch(httpMatch(&ServiceRouteHTTPMatch{
+						PathPrefix: "/foo",
+						PathExact:  "/bar",
+					})),
+				},
+			},
+			validateErr: "at most one of PathExact, PathPrefix, or PathRegex",
+		},
+		{
+			name: "path prefix missing slash",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					routeMatch(httpMatch(&ServiceRouteHTTPMatch{
+						PathPrefix: "foo",
+					})),
+				},
+			},
+			validateErr: "PathPrefix doesn't start with '/'",
+		},
+		{
+			name: "bad path regex",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					routeMatch(httpMatch(&ServiceRouteHTTPMatch{
+						PathRegex: "(",
+					})),
+				},
+			},
+			validateErr: "PathRegex is invalid",
+		},
+		{
+			name: "header missing name",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					routeMatch(httpMatch(&ServiceRouteHTTPMatch{
+						Header: []ServiceRouteHTTPMatchHeader{{Exact: "foo"}},
+					})),
+				},
+			},
+			validateErr: "missing required Name field on Header[0]",
+		},
+		{
+			name: "header with two match types",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					routeMatch(httpMatch(&ServiceRouteHTTPMatch{
+						Header: []ServiceRouteHTTPMatchHeader{
+							{Name: "x-debug", Exact: "1", Prefix: "1"},
+						},
+					})),
+				},
+			},
+			validateErr: "should only contain one of Present, Exact, Prefix, Suffix, or Regex in Header[0]",
+		},
+		{
+			name: "query param with no match type",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					routeMatch(httpMatch(&ServiceRouteHTTPMatch{
+						QueryParam: []ServiceRouteHTTPMatchQueryParam{{Name: "debug"}},
+					})),
+				},
+			},
+			validateErr: "should only contain one of Present, Exact, or Regex in QueryParam[0]",
+		},
+		{
+			name: "methods are upper cased",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					routeMatch(httpMatch(&ServiceRouteHTTPMatch{
+						Methods: []string{"get", "Post"},
+					})),
+				},
+			},
+			check: func(t *testing.T, entry *ServiceRouterConfigEntry) {
+				require.Equal(t, []string{"GET", "POST"}, entry.Routes[0].Match.HTTP.Methods)
+			},
+		},
+		{
+			name: "invalid method",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					routeMatch(httpMatch(&ServiceRouteHTTPMatch{
+						Methods: []string{"FETCH"},
+					})),
+				},
+			},
+			validateErr: `invalid HTTP method "FETCH"`,
+		},
+		{
+			name: "duplicate method",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					routeMatch(httpMatch(&ServiceRouteHTTPMatch{
+						Methods: []string{"GET", "get"},
+					})),
+				},
+			},
+			validateErr: `Methods contains "GET" more than once`,
+		},
+		{
+			name: "prefix rewrite with regex match",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					{
+						Match: httpMatch(&ServiceRouteHTTPMatch{
+							PathRegex: "/foo.*",
+						}),
+						Destination: &ServiceRouteDestination{
+							PrefixRewrite: "/",
+						},
+					},
+				},
+			},
+			validateErr: "cannot make use of PrefixRewrite",
+		},
+		{
+			name: "negative timeout",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					{
+						Destination: &ServiceRouteDestination{
+							RequestTimeout: -1 * time.Second,
+						},
+					},
+				},
+			},
+			validateErr: "RequestTimeout cannot be negative",
+		},
+		{
+			name: "invalid retry condition",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					{
+						Destination: &ServiceRouteDestination{
+							NumRetries: 3,
+							RetryOn:    []string{"sometimes"},
+						},
+					},
+				},
+			},
+			validateErr: `invalid RetryOn condition "sometimes"`,
+		},
+		{
+			name: "valid",
+			entry: &ServiceRouterConfigEntry{
+				Name: "web",
+				Routes: []ServiceRoute{
+					{
+						Match: httpMatch(&ServiceRouteHTTPMatch{
+							PathPrefix: "/admin",
+							Header: []ServiceRouteHTTPMatchHeader{
+								{Name: "x-debug", Present: true, Invert: true},
+							},
+							QueryParam: []ServiceRouteHTTPMatchQueryParam{
+								{Name: "version", Exact: "2"},
+							},
+						}),
+						Destination: &ServiceRouteDestination{
+							Service:        "admin",
+							PrefixRewrite:  "/",
+							RequestTimeout: 5 * time.Second,
+							NumRetries:     2,
+							RetryOn:        []string{"connect-failure"},
+						},
+					},
+				},
+			},
+			check: func(t *testing.T, entry *ServiceRouterConfigEntry) {
+				require.Equal(t, ServiceRouter, entry.Kind)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.entry.Normalize())
+
+			err := tc.entry.Validate()
+			if tc.validateErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.validateErr)
+				return
+			}
+			require.NoError(t, err)
+			if tc.check != nil {
+				tc.check(t, tc.entry)
+			}
+		})
+	}
+}
+
+func TestServiceSplitterConfigEntry(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		entry       *ServiceSplitterConfigEntry
+		validateErr string
+		check       func(t *testing.T, entry *ServiceSplitterConfigEntry)
+	}{
+		{
+			name:        "missing name",
+			entry:       &ServiceSplitterConfigEntry{},
+			validateErr: "Name is required",
+		},
+		{
+			name:        "no splits",
+			entry:       &ServiceSplitterConfigEntry{Name: "web"},
+			validateErr: "no splits configured",
+		},
+		{
+			name: "weights under 100",
+			entry: &ServiceSplitterConfigEntry{
+				Name: "web",
+				Splits: []ServiceSplit{
+					{Weight: 90, ServiceSubset: "v1"},
+					{Weight: 9, ServiceSubset: "v2"},
+				},
+			},
+			validateErr: "the sum of all split weights must be 100",
+		},
+		{
+			name: "weights over 100",
+			entry: &ServiceSplitterConfigEntry{
+				Name: "web",
+				Splits: []ServiceSplit{
+					{Weight: 90, ServiceSubset: "v1"},
+					{Weight: 10.01, ServiceSubset: "v2"},
+				},
+			},
+			validateErr: "the sum of all split weights must be 100",
+		},
+		{
+			name: "negative weight",
+			entry: &ServiceSplitterConfigEntry{
+				Name: "web",
+				Splits: []ServiceSplit{
+					{Weight: 50, ServiceSubset: "v1"},
+					{Weight: -10, ServiceSubset: "v2"},
+				},
+			},
+			validateErr: "Splits[1] Weight must be between 0 and 100",
+		},
+		{
+			name: "duplicate destination",
+			entry: &ServiceSplitterConfigEntry{
+				Name: "web",
+				Splits: []ServiceSplit{
+					{Weight: 50, ServiceSubset: "v1"},
+					{Weight: 50, Service: "web", ServiceSubset: "v1"},
+				},
+			},
+			validateErr: "Splits[1] duplicates the destination of another split",
+		},
+		{
+			name: "weights are rounded",
+			entry: &ServiceSplitterConfigEntry{
+				Name: "web",
+				Splits: []ServiceSplit{
+					{Weight: 33.333, ServiceSubset: "v1"},
+					{Weight: 33.333, ServiceSubset: "v2"},
+					{Weight: 33.336, ServiceSubset: "v3"},
+				},
+			},
+			check: func(t *testing.T, entry *ServiceSplitterConfigEntry) {
+				require.Equal(t, ServiceSplitter, entry.Kind)
+				require.Equal(t, float32(33.33), entry.Splits[0].Weight)
+				require.Equal(t, float32(33.33), entry.Splits[1].Weight)
+				require.Equal(t, float32(33.34), entry.Splits[2].Weight)
+			},
+		},
+		{
+			name: "valid",
+			entry: &ServiceSplitterConfigEntry{
+				Name: "web",
+				Splits: []ServiceSplit{
+					{Weight: 90},
+					{Weight: 10, Service: "web-v2"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.entry.Normalize())
+
+			err := tc.entry.Validate()
+			if tc.validateErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.validateErr)
+				return
+			}
+			require.NoError(t, err)
+			if tc.check != nil {
+				tc.check(t, tc.entry)
+			}
+		})
+	}
+}
+
+func TestServiceResolverConfigEntry(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		entry       *ServiceResolverConfigEntry
+		validateErr string
+	}{
+		{
+			name:        "missing name",
+			entry:       &ServiceResolverConfigEntry{},
+			validateErr: "Name is required",
+		},
+		{
+			name:  "empty",
+			entry: &ServiceResolverConfigEntry{Name: "web"},
+		},
+		{
+			name: "invalid subset name",
+			entry: &ServiceResolverConfigEntry{
+				Name: "web",
+				Subsets: map[string]ServiceResolverSubset{
+					"V1": {Filter: "Service.Meta.version == v1"},
+				},
+			},
+			validateErr: `Subset "V1" is invalid`,
+		},
+		{
+			name: "invalid subset filter",
+			entry: &ServiceResolverConfigEntry{
+				Name: "web",
+				Subsets: map[string]ServiceResolverSubset{
+					"v1": {Filter: "Service.Nope == v1"},
+				},
+			},
+			validateErr: `Subset "v1": Filter is invalid`,
+		},
+		{
+			name: "default subset does not exist",
+			entry: &ServiceResolverConfigEntry{
+				Name:          "web",
+				DefaultSubset: "v1",
+			},
+			validateErr: `DefaultSubset "v1" is not a valid subset`,
+		},
+		{
+			name: "failover for unknown subset",
+			entry: &ServiceResolverConfigEntry{
+				Name: "web",
+				Failover: map[string]ServiceResolverFailover{
+					"v1": {Datacenters: []string{"dc2"}},
+				},
+			},
+			validateErr: `Bad Failover["v1"]: not a valid subset`,
+		},
+		{
+			name: "failover without datacenters",
+			entry: &ServiceResolverConfigEntry{
+				Name: "web",
+				Failover: map[string]ServiceResolverFailover{
+					"*": {},
+				},
+			},
+			validateErr: `Bad Failover["*"]: no Datacenters configured`,
+		},
+		{
+			name: "failover with empty datacenter",
+			entry: &ServiceResolverConfigEntry{
+				Name: "web",
+				Failover: map[string]ServiceResolverFailover{
+					"*": {Datacenters: []string{"dc2", ""}},
+				},
+			},
+			validateErr: `Bad Failover["*"]: found empty datacenter`,
+		},
+		{
+			name: "negative connect timeout",
+			entry: &ServiceResolverConfigEntry{
+				Name:           "web",
+				ConnectTimeout: -1 * time.Second,
+			},
+			validateErr: "Bad ConnectTimeout",
+		},
+		{
+			name: "valid",
+			entry: &ServiceResolverConfigEntry{
+				Name:          "web",
+				DefaultSubset: "v1",
+				Subsets: map[string]ServiceResolverSubset{
+					"v1":  {Filter: "Service.Meta.version == v1"},
+					"v2":  {Filter: "Service.Meta.version == v2 and Node.Datacenter == dc1"},
+					"all": {},
+				},
+				Failover: map[string]ServiceResolverFailover{
+					"v1": {Datacenters: []string{"dc2", "dc3"}},
+					"*":  {Datacenters: []string{"dc2"}},
+				},
+				ConnectTimeout: 15 * time.Second,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.entry.Normalize())
+			require.Equal(t, ServiceResolver, tc.entry.Kind)
+
+			err := tc.entry.Validate()
+			if tc.validateErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.validateErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestServiceRouterConfigEntry_BinaryRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	req := &ConfigEntryRequest{
+		Op:         ConfigEntryUpsert,
+		Datacenter: "dc1",
+		Entry: &ServiceRouterConfigEntry{
+			Kind: ServiceRouter,
+			Name: "web",
+			Routes: []ServiceRoute{
+				{
+					Match: &ServiceRouteMatch{
+						HTTP: &ServiceRouteHTTPMatch{
+							PathPrefix: "/admin",
+							Methods:    []string{"GET"},
+						},
+					},
+					Destination: &ServiceRouteDestination{
+						Service:        "admin",
+						RequestTimeout: 5 * time.Second,
+					},
+				},
+			},
+		},
+	}
+
+	buf, err := req.MarshalBinary()
+	require.NoError(t, err)
+
+	var out ConfigEntryRequest
+	require.NoError(t, out.UnmarshalBinary(buf))
+	require.Equal(t, req.Entry, out.Entry)
+}
+
+func TestServiceResolverConfigEntry_BinaryRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	req := &ConfigEntryRequest{
+		Op:         ConfigEntryUpsert,
+		Datacenter: "dc1",
+		Entry: &ServiceResolverConfigEntry{
+			Kind:          ServiceResolver,
+			Name:          "web",
+			DefaultSubset: "v1",
+			Subsets: map[string]ServiceResolverSubset{
+				"v1": {Filter: "Service.Meta.version == v1"},
+			},
+			Failover: map[string]ServiceResolverFailover{
+				"*": {Datacenters: []string{"dc2", "dc3"}},
+			},
+			ConnectTimeout: 15 * time.Second,
+		},
+	}
+
+	buf, err := req.MarshalBinary()
+	require.NoError(t, err)
+
+	var out ConfigEntryRequest
+	require.NoError(t, out.UnmarshalBinary(buf))
+	require.Equal(t, req.Entry, out.Entry)
+}
@@ -0,0 +1,137 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeConfigEntry(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		raw       map[string]interface{}
+		expected  ConfigEntry
+		expectErr string
+	}{
+		{
+			name: "service-defaults",
+			raw: map[string]interface{}{
+				"Kind":     ServiceDefaults,
+				"Name":     "web",
+				"Protocol": "http",
+				"Connect": map[string]interface{}{
+					"SidecarProxy": true,
+				},
+			},
+			expected: &ServiceConfigEntry{
+				Kind:     ServiceDefaults,
+				Name:     "web",
+				Protocol: "http",
+				Connect: ConnectConfiguration{
+					SidecarProxy: true,
+				},
+			},
+		},
+		{
+			name: "proxy-defaults lowercase kind",
+			raw: map[string]interface{}{
+				"kind": ProxyDefaults,
+				"name": ProxyConfigGlobal,
+				"config": map[string]interface{}{
+					"foo": "bar",
+				},
+			},
+			expected: &ProxyConfigEntry{
+				Kind: ProxyDefaults,
+				Name: ProxyConfigGlobal,
+				Config: map[string]interface{}{
+					"foo": "bar",
+				},
+			},
+		},
+		{
+			name:      "missing kind",
+			raw:       map[string]interface{}{"Name": "web"},
+			expectErr: "does not contain a Kind/kind key",
+		},
+		{
+			name:      "non-string kind",
+			raw:       map[string]interface{}{"Kind": 1},
+			expectErr: "is not a string",
+		},
+		{
+			name:      "unknown kind",
+			raw:       map[string]interface{}{"Kind": "nope"},
+			expectErr: "invalid config entry kind",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			entry, err := DecodeConfigEntry(tc.raw)
+			if tc.expectErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, entry)
+		})
+	}
+}
+
+func TestProxyConfigEntry_BinaryRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	req := &ConfigEntryRequest{
+		Op:         ConfigEntryUpsert,
+		Datacenter: "dc1",
+		Entry: &ProxyConfigEntry{
+			Kind: ProxyDefaults,
+			Name: ProxyConfigGlobal,
+			Config: map[string]interface{}{
+				"foo": "bar",
+				"nested": map[string]interface{}{
+					"baz": "qux",
+				},
+			},
+		},
+	}
+
+	buf, err := req.MarshalBinary()
+	require.NoError(t, err)
+
+	var out ConfigEntryRequest
+	require.NoError(t, out.UnmarshalBinary(buf))
+
+	// Strings must not come back as []byte and nested maps must have
+	// string keys so the entry can be JSON encoded.
+	entry := out.Entry.(*ProxyConfigEntry)
+	require.Equal(t, "bar", entry.Config["foo"])
+	require.Equal(t, map[string]interface{}{"baz": "qux"}, entry.Config["nested"])
+}
+
+func TestServiceConfigEntry_DNSOrdering(t *testing.T) {
+	t.Parallel()
+
+	entry := &ServiceConfigEntry{
+		Name: "web",
+		DNS: ServiceDNSConfiguration{
+			Ordering: "Round-Robin",
+		},
+	}
+	require.NoError(t, entry.Normalize())
+	require.Equal(t, DNSOrderingRoundRobin, entry.DNS.Ordering)
+	require.NoError(t, entry.Validate())
+
+	entry.DNS.Ordering = ""
+	require.NoError(t, entry.Validate())
+
+	entry.DNS.Ordering = "fastest"
+	err := entry.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Bad DNS.Ordering "fastest"`)
+}
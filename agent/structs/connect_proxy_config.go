@@ -122,6 +122,63 @@ type Upstream struct {
 	// It can be used to pass arbitrary configuration for this specific upstream
 	// to the proxy.
 	Config map[string]interface{} `bexpr:"-"`
+
+	// MeshGateway is the configuration for mesh gateway usage of this upstream
+	MeshGateway MeshGatewayConfig
+}
+
+// MeshGatewayMode controls how a proxy reaches an upstream in another
+// datacenter.
+type MeshGatewayMode string
+
+const (
+	// MeshGatewayModeDefault connects directly to the upstream's instances in
+	// the other datacenter. This is the same as MeshGatewayModeNone.
+	MeshGatewayModeDefault MeshGatewayMode = ""
+
+	// MeshGatewayModeNone connects directly to the upstream's instances in the
+	// other datacenter.
+	MeshGatewayModeNone MeshGatewayMode = "none"
+
+	// MeshGatewayModeLocal sends the traffic to a mesh gateway in the local
+	// datacenter which forwards it to the other datacenter.
+	MeshGatewayModeLocal MeshGatewayMode = "local"
+
+	// MeshGatewayModeRemote sends the traffic straight to a mesh gateway in
+	// the upstream's datacenter.
+	MeshGatewayModeRemote MeshGatewayMode = "remote"
+)
+
+// IsValid returns true if the mode is one of the known modes.
+func (m MeshGatewayMode) IsValid() bool {
+	switch m {
+	case MeshGatewayModeDefault, MeshGatewayModeNone, MeshGatewayModeLocal, MeshGatewayModeRemote:
+		return true
+	default:
+		return false
+	}
+}
+
+// MeshGatewayConfig controls how Mesh Gateways are used for upstream Connect
+// services.
+type MeshGatewayConfig struct {
+	// Mode is the mode that should be used for the upstream connection.
+	Mode MeshGatewayMode `json:",omitempty"`
+}
+
+// ToAPI returns the api struct with the same fields.
+func (c MeshGatewayConfig) ToAPI() api.MeshGatewayConfig {
+	return api.MeshGatewayConfig{
+		Mode: api.MeshGatewayMode(c.Mode),
+	}
+}
+
+// MeshGatewayConfigFromAPI is a helper for converting api.MeshGatewayConfig
+// to MeshGatewayConfig.
+func MeshGatewayConfigFromAPI(c api.MeshGatewayConfig) MeshGatewayConfig {
+	return MeshGatewayConfig{
+		Mode: MeshGatewayMode(c.Mode),
+	}
 }
 
 // Validate sanity checks the struct is valid
@@ -138,6 +195,10 @@ func (u *Upstream) Validate() error {
 	if u.LocalBindPort == 0 {
 		return fmt.Errorf("upstream local bind port cannot be zero")
 	}
+
+	if !u.MeshGateway.Mode.IsValid() {
+		return fmt.Errorf("upstream mesh gateway mode %q is invalid", u.MeshGateway.Mode)
+	}
 	return nil
 }
 
@@ -154,6 +215,7 @@ func (u *Upstream) ToAPI() api.Upstream {
 		LocalBindAddress:     u.LocalBindAddress,
 		LocalBindPort:        u.LocalBindPort,
 		Config:               u.Config,
+		MeshGateway:          u.MeshGateway.ToAPI(),
 	}
 }
 
@@ -189,5 +251,6 @@ func UpstreamFromAPI(u api.Upstream) Upstream {
 		LocalBindAddress:     u.LocalBindAddress,
 		LocalBindPort:        u.LocalBindPort,
 		Config:               u.Config,
+		MeshGateway:          MeshGatewayConfigFromAPI(u.MeshGateway),
 	}
 }
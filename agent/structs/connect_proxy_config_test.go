@@ -93,7 +93,8 @@ func TestUpstream_MarshalJSON(t *testing.T) {
 				"DestinationName": "foo",
 				"Datacenter": "dc1",
 				"LocalBindPort": 1234,
-				"Config": null
+				"Config": null,
+				"MeshGateway": {}
 			}`,
 			wantErr: false,
 		},
@@ -110,7 +111,8 @@ func TestUpstream_MarshalJSON(t *testing.T) {
 				"DestinationName": "foo",
 				"Datacenter": "dc1",
 				"LocalBindPort": 1234,
-				"Config": null
+				"Config": null,
+				"MeshGateway": {}
 			}`,
 			wantErr: false,
 		},
@@ -0,0 +1,38 @@
+package structs
+
+// DNSSECKey is a zone signing key generated by the servers for a Consul DNS
+// domain, so that every agent signs the domain with the same key without
+// one having to be configured. It's kept out of the KV store since it holds
+// the private key.
+type DNSSECKey struct {
+	// Domain is the fully qualified domain the key signs.
+	Domain string
+
+	// PublicKey is the DNSKEY record for the key, in zone file format.
+	PublicKey string
+
+	// PrivateKey is the private key, in the format used by dnssec-keygen.
+	PrivateKey string
+
+	RaftIndex
+}
+
+// DNSSECKeyRequest is used to get the zone signing key for a domain, which
+// is generated first if there isn't one yet. It's also the request applied
+// through raft to store a generated key, in which case Key is set.
+type DNSSECKeyRequest struct {
+	Datacenter string
+	Domain     string
+	Key        *DNSSECKey
+	WriteRequest
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (r *DNSSECKeyRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// DNSSECKeyResponse is the response to a DNSSECKeyRequest.
+type DNSSECKeyResponse struct {
+	Key *DNSSECKey
+}
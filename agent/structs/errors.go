@@ -12,6 +12,7 @@ const (
 	errNotReadyForConsistentReads = "Not ready to serve consistent reads"
 	errSegmentsNotSupported       = "Network segments are not supported in this version of Consul"
 	errRPCRateExceeded            = "RPC rate limit exceeded"
+	errRPCServerRateExceeded      = "Server RPC quota exhausted"
 	errServiceNotFound            = "Service not found: "
 )
 
@@ -22,6 +23,7 @@ var (
 	ErrNotReadyForConsistentReads = errors.New(errNotReadyForConsistentReads)
 	ErrSegmentsNotSupported       = errors.New(errSegmentsNotSupported)
 	ErrRPCRateExceeded            = errors.New(errRPCRateExceeded)
+	ErrRPCServerRateExceeded      = errors.New(errRPCServerRateExceeded)
 )
 
 func IsErrNoLeader(err error) bool {
@@ -32,6 +34,13 @@ func IsErrRPCRateExceeded(err error) bool {
 	return err != nil && strings.Contains(err.Error(), errRPCRateExceeded)
 }
 
+// IsErrRPCServerRateExceeded returns true if the error came from a server
+// rejecting an RPC because one of its RPC quotas was exceeded. No state will
+// have been changed, so the call is always safe to retry.
+func IsErrRPCServerRateExceeded(err error) bool {
+	return err != nil && strings.Contains(err.Error(), errRPCServerRateExceeded)
+}
+
 func IsErrServiceNotFound(err error) bool {
 	return err != nil && strings.Contains(err.Error(), errServiceNotFound)
 }
@@ -0,0 +1,26 @@
+package structs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrRPCRateExceeded(t *testing.T) {
+	t.Parallel()
+
+	// Errors returned over RPC lose their identity so they are matched by
+	// their text, which must not overlap between the two limits.
+	client := errors.New(ErrRPCRateExceeded.Error())
+	server := errors.New(ErrRPCServerRateExceeded.Error())
+
+	require.True(t, IsErrRPCRateExceeded(client))
+	require.False(t, IsErrRPCServerRateExceeded(client))
+
+	require.True(t, IsErrRPCServerRateExceeded(server))
+	require.False(t, IsErrRPCRateExceeded(server))
+
+	require.False(t, IsErrRPCRateExceeded(nil))
+	require.False(t, IsErrRPCServerRateExceeded(nil))
+}
@@ -262,19 +262,10 @@ func (p *ServiceDefinitionConnectProxy) MarshalJSON() ([]byte, error) {
 	// should always work because the config input is either HCL or JSON and
 	// both are JSON compatible.
 	if copy.Config != nil {
-		configCopyRaw, err := copystructure.Copy(copy.Config)
+		configCopy, err := jsonSafeProxyConfig(copy.Config)
 		if err != nil {
 			return nil, err
 		}
-		configCopy, ok := configCopyRaw.(map[string]interface{})
-		if !ok {
-			// This should never fail because we KNOW the input type,
-			// but we don't ever want to risk the panic.
-			return nil, fmt.Errorf("internal error: config copy is not right type")
-		}
-		if err := reflectwalk.Walk(configCopy, &proxyConfigWalker{}); err != nil {
-			return nil, err
-		}
 
 		copy.Config = configCopy
 	}
@@ -282,7 +273,30 @@ func (p *ServiceDefinitionConnectProxy) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&copy)
 }
 
-var typMapIfaceIface = reflect.TypeOf(map[interface{}]interface{}{})
+// jsonSafeProxyConfig returns a deep copy of the given opaque proxy config
+// with any msgpack artifacts converted back into JSON compatible values.
+func jsonSafeProxyConfig(config map[string]interface{}) (map[string]interface{}, error) {
+	configCopyRaw, err := copystructure.Copy(config)
+	if err != nil {
+		return nil, err
+	}
+	configCopy, ok := configCopyRaw.(map[string]interface{})
+	if !ok {
+		// This should never fail because we KNOW the input type,
+		// but we don't ever want to risk the panic.
+		return nil, fmt.Errorf("internal error: config copy is not right type")
+	}
+	if err := reflectwalk.Walk(configCopy, &proxyConfigWalker{}); err != nil {
+		return nil, err
+	}
+
+	return configCopy, nil
+}
+
+var (
+	typMapIfaceIface = reflect.TypeOf(map[interface{}]interface{}{})
+	typByteSlice     = reflect.TypeOf([]byte{})
+)
 
 // proxyConfigWalker implements interfaces for the reflectwalk package
 // (github.com/mitchellh/reflectwalk) that can be used to automatically
@@ -346,6 +360,26 @@ func (w *proxyConfigWalker) MapElem(m, k, v reflect.Value) error {
 	w.csKey = append(w.csKey, k)
 
 	w.lastValue = v
+
+	// Nested maps are decoded as map[interface{}]interface{} wrapped in an
+	// interface{}, the same as slice elements. Map values can't be set in
+	// place while walking, so decode and walk the replacement separately.
+	if !v.IsValid() || v.Kind() != reflect.Interface {
+		return nil
+	}
+
+	if inner := v.Elem(); inner.IsValid() && inner.Type() == typMapIfaceIface {
+		var target map[string]interface{}
+		if err := mapstructure.WeakDecode(inner.Interface(), &target); err != nil {
+			return err
+		}
+		if err := reflectwalk.Walk(target, &proxyConfigWalker{}); err != nil {
+			return err
+		}
+
+		m.SetMapIndex(k, reflect.ValueOf(target))
+	}
+
 	return nil
 }
 
@@ -403,7 +437,8 @@ func (w *proxyConfigWalker) SliceElem(i int, elem reflect.Value) error {
 		return nil
 	}
 
-	if inner := elem.Elem(); inner.Type() == typMapIfaceIface {
+	switch inner := elem.Elem(); inner.Type() {
+	case typMapIfaceIface:
 		// map[interface{}]interface{}, attempt to weakly decode into string keys
 		var target map[string]interface{}
 		if err := mapstructure.WeakDecode(inner.Interface(), &target); err != nil {
@@ -411,6 +446,11 @@ func (w *proxyConfigWalker) SliceElem(i int, elem reflect.Value) error {
 		}
 
 		elem.Set(reflect.ValueOf(target))
+
+	case typByteSlice:
+		// A string within a list. Slice() can't replace it as it only knows
+		// about the list once the element is being walked.
+		elem.Set(reflect.ValueOf(string(inner.Bytes())))
 	}
 
 	return nil
@@ -47,6 +47,11 @@ func TestAgentStructs_CheckTypes(t *testing.T) {
 		{&CheckType{HTTP: "http://foo/baz"}, fmt.Errorf("Interval must be > 0 for Script, HTTP, or TCP checks"), "Missing interval"},
 		{&CheckType{TTL: -1}, fmt.Errorf("TTL must be > 0 for TTL checks"), "Negative TTL"},
 		{&CheckType{TTL: 20 * time.Second, Interval: 10 * time.Second}, fmt.Errorf("Interval and TTL cannot both be specified"), "Interval and TTL both set"},
+		{&CheckType{UDP: "localhost:53"}, fmt.Errorf("Interval must be > 0 for Script, HTTP, or TCP checks"), "Missing UDP interval"},
+		{&CheckType{H2C: "https://foo/baz", Interval: 10 * time.Second}, fmt.Errorf("H2C must be an http:// URL"), "H2C with https"},
+		{&CheckType{TCP: "localhost:53", UDPPayload: "ping", Interval: 10 * time.Second}, fmt.Errorf("UDPPayload can only be set for UDP checks"), "UDPPayload without UDP"},
+		{&CheckType{TCP: "localhost:53", Interval: 10 * time.Second, FailuresBeforeCritical: -1}, fmt.Errorf("SuccessBeforePassing and FailuresBeforeCritical must be >= 0"), "Negative threshold"},
+		{&CheckType{TTL: 10 * time.Second, SuccessBeforePassing: 2}, fmt.Errorf("SuccessBeforePassing and FailuresBeforeCritical are only supported for interval checks"), "Threshold on TTL check"},
 	}
 	for _, tc := range cases {
 		svc.Check = *tc.in
@@ -183,6 +188,29 @@ func TestServiceDefinitionConnectProxy_json(t *testing.T) {
 			}
 		]
 	}
+}
+			`,
+			"",
+		},
+
+		{
+			"config with a list of strings",
+			&ServiceDefinitionConnectProxy{
+				Config: map[string]interface{}{
+					"args": []interface{}{
+						[]byte("foo"),
+						[]byte("bar"),
+					},
+				},
+			},
+			`
+{
+	"Config": {
+		"args": [
+			"foo",
+			"bar"
+		]
+	}
 }
 			`,
 			"",
@@ -33,29 +33,37 @@ type RaftIndex struct {
 // These are serialized between Consul servers and stored in Consul snapshots,
 // so entries must only ever be added.
 const (
-	RegisterRequestType        MessageType = 0
-	DeregisterRequestType                  = 1
-	KVSRequestType                         = 2
-	SessionRequestType                     = 3
-	ACLRequestType                         = 4 // DEPRECATED (ACL-Legacy-Compat)
-	TombstoneRequestType                   = 5
-	CoordinateBatchUpdateType              = 6
-	PreparedQueryRequestType               = 7
-	TxnRequestType                         = 8
-	AutopilotRequestType                   = 9
-	AreaRequestType                        = 10
-	ACLBootstrapRequestType                = 11
-	IntentionRequestType                   = 12
-	ConnectCARequestType                   = 13
-	ConnectCAProviderStateType             = 14
-	ConnectCAConfigType                    = 15 // FSM snapshots only.
-	IndexRequestType                       = 16 // FSM snapshots only.
-	ACLTokenSetRequestType                 = 17
-	ACLTokenDeleteRequestType              = 18
-	ACLPolicySetRequestType                = 19
-	ACLPolicyDeleteRequestType             = 20
-	ConnectCALeafRequestType               = 21
-	ConfigEntryRequestType                 = 22
+	RegisterRequestType             MessageType = 0
+	DeregisterRequestType                       = 1
+	KVSRequestType                              = 2
+	SessionRequestType                          = 3
+	ACLRequestType                              = 4 // DEPRECATED (ACL-Legacy-Compat)
+	TombstoneRequestType                        = 5
+	CoordinateBatchUpdateType                   = 6
+	PreparedQueryRequestType                    = 7
+	TxnRequestType                              = 8
+	AutopilotRequestType                        = 9
+	AreaRequestType                             = 10
+	ACLBootstrapRequestType                     = 11
+	IntentionRequestType                        = 12
+	ConnectCARequestType                        = 13
+	ConnectCAProviderStateType                  = 14
+	ConnectCAConfigType                         = 15 // FSM snapshots only.
+	IndexRequestType                            = 16 // FSM snapshots only.
+	ACLTokenSetRequestType                      = 17
+	ACLTokenDeleteRequestType                   = 18
+	ACLPolicySetRequestType                     = 19
+	ACLPolicyDeleteRequestType                  = 20
+	ConnectCALeafRequestType                    = 21
+	ConfigEntryRequestType                      = 22
+	ACLRoleSetRequestType                       = 23
+	ACLRoleDeleteRequestType                    = 24
+	ACLAuthMethodSetRequestType                 = 25
+	ACLAuthMethodDeleteRequestType              = 26
+	ACLBindingRuleSetRequestType                = 27
+	ACLBindingRuleDeleteRequestType             = 28
+	KVSHistoryRequestType                       = 29 // FSM snapshots only.
+	DNSSECKeyRequestType                        = 30
 )
 
 const (
@@ -198,6 +206,16 @@ type WriteRequest struct {
 	// Token is the ACL token ID. If not provided, the 'anonymous'
 	// token is assumed for backwards compatibility.
 	Token string
+
+	// ApplyTime is set by the leader to its current time just before the
+	// request is committed to raft, so that every server uses the same time
+	// for anything it records while applying the request.
+	ApplyTime time.Time `json:"-"`
+}
+
+// SetApplyTime sets the time the leader committed the request at.
+func (w *WriteRequest) SetApplyTime(t time.Time) {
+	w.ApplyTime = t
 }
 
 // WriteRequest only applies to writes, always false
@@ -314,6 +332,25 @@ type QuerySource struct {
 	Ip         string
 }
 
+// DatacentersRequest is used to query the list of known datacenters. It
+// carries no arguments of its own but the QueryOptions allow the result to be
+// cached.
+type DatacentersRequest struct {
+	QueryOptions
+}
+
+func (r *DatacentersRequest) CacheInfo() cache.RequestInfo {
+	return cache.RequestInfo{
+		Token:          "",
+		Datacenter:     "",
+		MinIndex:       0,
+		Timeout:        r.MaxQueryTime,
+		MaxAge:         r.MaxAge,
+		MustRevalidate: r.MustRevalidate,
+		Key:            "catalog-datacenters", // must not be empty for cache to work
+	}
+}
+
 // DCSpecificRequest is used to query about a specific DC
 type DCSpecificRequest struct {
 	Datacenter      string
@@ -414,6 +451,7 @@ func (r *ServiceSpecificRequest) CacheInfo() cache.RequestInfo {
 		r.TagFilter,
 		r.Connect,
 		r.Filter,
+		r.Source,
 	}, nil)
 	if err == nil {
 		// If there is an error, we don't set the key. A blank key forces
@@ -674,6 +712,11 @@ const (
 	// service proxies another service within Consul and speaks the connect
 	// protocol.
 	ServiceKindConnectProxy ServiceKind = "connect-proxy"
+
+	// ServiceKindMeshGateway is a Mesh Gateway for the Connect feature. This
+	// service will proxy connections based off the SNI header set by other
+	// connect proxies so that traffic can reach services in other datacenters.
+	ServiceKindMeshGateway ServiceKind = "mesh-gateway"
 )
 
 // NodeService is a service provided by a node
@@ -798,6 +841,48 @@ func (s *NodeService) Validate() error {
 			result = multierror.Append(result, fmt.Errorf(
 				"A Proxy cannot also be Connect Native, only typical services"))
 		}
+
+		for i, u := range s.Proxy.Upstreams {
+			if !u.MeshGateway.Mode.IsValid() {
+				result = multierror.Append(result, fmt.Errorf(
+					"Upstreams[%d] has an invalid MeshGateway.Mode %q", i, u.MeshGateway.Mode))
+			}
+		}
+	}
+
+	// MeshGateway validation
+	if s.Kind == ServiceKindMeshGateway {
+		// Gateways must have a port
+		if s.Port == 0 {
+			result = multierror.Append(result, fmt.Errorf(
+				"Port must be non-zero for a Mesh Gateway"))
+		}
+
+		// Gateways cannot have sidecars
+		if s.Connect.SidecarService != nil {
+			result = multierror.Append(result, fmt.Errorf(
+				"Mesh Gateways cannot have a sidecar service defined"))
+		}
+
+		if s.Connect.Native {
+			result = multierror.Append(result, fmt.Errorf(
+				"A Mesh Gateway cannot also be Connect Native"))
+		}
+
+		if s.Proxy.DestinationServiceName != "" {
+			result = multierror.Append(result, fmt.Errorf(
+				"The Proxy.DestinationServiceName configuration is invalid for Mesh Gateways"))
+		}
+
+		if s.Proxy.DestinationServiceID != "" {
+			result = multierror.Append(result, fmt.Errorf(
+				"The Proxy.DestinationServiceID configuration is invalid for Mesh Gateways"))
+		}
+
+		if len(s.Proxy.Upstreams) != 0 {
+			result = multierror.Append(result, fmt.Errorf(
+				"The Proxy.Upstreams configuration is invalid for Mesh Gateways"))
+		}
 	}
 
 	// Nested sidecar validation
@@ -1235,17 +1320,30 @@ type DirEntry struct {
 	Value     []byte
 	Session   string `json:",omitempty"`
 
+	// ExpirationTime is when the entry expires, after which it's deleted by
+	// the leader. Entries without an expiration time are kept until they
+	// are deleted explicitly.
+	ExpirationTime *time.Time `json:",omitempty"`
+
+	// ExpirationTTL is a convenience field for setting ExpirationTime to the
+	// time of the write plus the TTL. It's cleared and used to set
+	// ExpirationTime by the leader before the write is applied, so it's
+	// never stored.
+	ExpirationTTL time.Duration `json:",omitempty"`
+
 	RaftIndex
 }
 
 // Returns a clone of the given directory entry.
 func (d *DirEntry) Clone() *DirEntry {
 	return &DirEntry{
-		LockIndex: d.LockIndex,
-		Key:       d.Key,
-		Flags:     d.Flags,
-		Value:     d.Value,
-		Session:   d.Session,
+		LockIndex:      d.LockIndex,
+		Key:            d.Key,
+		Flags:          d.Flags,
+		Value:          d.Value,
+		Session:        d.Session,
+		ExpirationTime: d.ExpirationTime,
+		ExpirationTTL:  d.ExpirationTTL,
 		RaftIndex: RaftIndex{
 			CreateIndex: d.CreateIndex,
 			ModifyIndex: d.ModifyIndex,
@@ -1253,8 +1351,130 @@ func (d *DirEntry) Clone() *DirEntry {
 	}
 }
 
+// HasExpirationTime returns true if the entry has an expiration time set.
+func (d *DirEntry) HasExpirationTime() bool {
+	return d.ExpirationTime != nil && !d.ExpirationTime.IsZero()
+}
+
+// IsExpired returns true if the entry has expired as of the given time.
+func (d *DirEntry) IsExpired(asOf time.Time) bool {
+	if asOf.IsZero() || !d.HasExpirationTime() {
+		return false
+	}
+	return d.ExpirationTime.Before(asOf)
+}
+
 type DirEntries []*DirEntry
 
+// KVSHistoryConfig configures the history kept for the keys under a prefix.
+// Every write or delete of those keys is recorded as a version, and the
+// oldest versions are removed once there are more than MaxVersions of them
+// or they are older than Retention. The newest version is always kept.
+type KVSHistoryConfig struct {
+	// Prefix is the key prefix the history is kept for. When several
+	// prefixes match a key the longest one is used.
+	Prefix string
+
+	// MaxVersions is the maximum number of versions kept per key, or 0 for
+	// no limit.
+	MaxVersions int
+
+	// Retention is how long versions are kept, or 0 for no limit.
+	Retention time.Duration
+}
+
+// KVSHistoryEntry is a version of a key recorded in its KV history.
+type KVSHistoryEntry struct {
+	LockIndex uint64
+	Key       string
+	Flags     uint64
+	Value     []byte
+	Session   string `json:",omitempty"`
+
+	// Deleted is set for the version recorded when the key was deleted, in
+	// which case only the key and the indexes are set.
+	Deleted bool
+
+	// Time is when the version was written, as seen by the server.
+	Time time.Time
+
+	RaftIndex
+}
+
+// DirEntry returns the KV entry for the version, or nil if the version is
+// a deletion.
+func (e *KVSHistoryEntry) DirEntry() *DirEntry {
+	if e.Deleted {
+		return nil
+	}
+	return &DirEntry{
+		LockIndex: e.LockIndex,
+		Key:       e.Key,
+		Flags:     e.Flags,
+		Value:     e.Value,
+		Session:   e.Session,
+		RaftIndex: e.RaftIndex,
+	}
+}
+
+type KVSHistory []*KVSHistoryEntry
+
+// AtIndex returns the version of the key that was current at the given
+// index, or nil if there is none.
+func (h KVSHistory) AtIndex(index uint64) *KVSHistoryEntry {
+	var found *KVSHistoryEntry
+	for _, entry := range h {
+		if entry.ModifyIndex <= index && (found == nil || entry.ModifyIndex > found.ModifyIndex) {
+			found = entry
+		}
+	}
+	return found
+}
+
+// KVSEventType is the type of a change to a key.
+type KVSEventType string
+
+const (
+	KVSEventPut    KVSEventType = "put"
+	KVSEventDelete KVSEventType = "delete"
+)
+
+// KVSEvent is a change to a key in the KV store. A delete removes the key,
+// or for a recursive delete every key starting with it that was last
+// modified before the event's index. The keys under it that were kept are
+// sent again as puts after a recursive delete.
+type KVSEvent struct {
+	Type        KVSEventType
+	Key         string
+	ModifyIndex uint64
+
+	// Recurse is set for deletes of every key starting with Key.
+	Recurse bool `json:",omitempty"`
+
+	// The fields below are only set for puts.
+	CreateIndex uint64 `json:",omitempty"`
+	LockIndex   uint64 `json:",omitempty"`
+	Flags       uint64 `json:",omitempty"`
+	Value       []byte `json:",omitempty"`
+	Session     string `json:",omitempty"`
+}
+
+// NewKVSPutEvent returns a put event for the given entry.
+func NewKVSPutEvent(entry *DirEntry) *KVSEvent {
+	return &KVSEvent{
+		Type:        KVSEventPut,
+		Key:         entry.Key,
+		ModifyIndex: entry.ModifyIndex,
+		CreateIndex: entry.CreateIndex,
+		LockIndex:   entry.LockIndex,
+		Flags:       entry.Flags,
+		Value:       entry.Value,
+		Session:     entry.Session,
+	}
+}
+
+type KVSEvents []*KVSEvent
+
 // KVSRequest is used to operate on the Key-Value store
 type KVSRequest struct {
 	Datacenter string
@@ -1295,6 +1515,42 @@ type IndexedDirEntries struct {
 	QueryMeta
 }
 
+type IndexedKVSHistory struct {
+	Entries KVSHistory
+
+	// Enabled is set when a history is kept for the key. Otherwise the
+	// entries only hold the current version of the key.
+	Enabled bool
+	QueryMeta
+}
+
+// KVSChangesRequest is used to get the changes to the keys under a prefix
+// after the MinQueryIndex.
+type KVSChangesRequest struct {
+	Datacenter string
+	Prefix     string
+
+	// Resume is set when the MinQueryIndex wasn't returned by the previous
+	// request but was saved from an earlier one, so deletes after it may
+	// have had their tombstones reaped since. A reset is returned if so.
+	Resume bool
+
+	QueryOptions
+}
+
+func (r *KVSChangesRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+type IndexedKVSChanges struct {
+	// Reset is set when the changes couldn't be tracked from the requested
+	// index. Events then holds a put for every key under the prefix and
+	// the keys that aren't in it should be dropped.
+	Reset  bool
+	Events KVSEvents
+	QueryMeta
+}
+
 type IndexedKeyList struct {
 	Keys []string
 	QueryMeta
@@ -58,6 +58,16 @@ var expectedFieldConfigUpstreams bexpr.FieldConfigurations = bexpr.FieldConfigur
 		CoerceFn:            bexpr.CoerceInt,
 		SupportedOperations: []bexpr.MatchOperator{bexpr.MatchEqual, bexpr.MatchNotEqual},
 	},
+	"MeshGateway": &bexpr.FieldConfiguration{
+		StructFieldName: "MeshGateway",
+		SubFields: bexpr.FieldConfigurations{
+			"Mode": &bexpr.FieldConfiguration{
+				StructFieldName:     "Mode",
+				CoerceFn:            bexpr.CoerceString,
+				SupportedOperations: []bexpr.MatchOperator{bexpr.MatchEqual, bexpr.MatchNotEqual},
+			},
+		},
+	},
 }
 
 var expectedFieldConfigConnectProxyConfig bexpr.FieldConfigurations = bexpr.FieldConfigurations{
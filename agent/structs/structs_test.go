@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/api"
@@ -391,6 +392,18 @@ func TestStructs_NodeService_ValidateConnectProxy(t *testing.T) {
 			func(x *NodeService) { x.Connect.Native = true },
 			"cannot also be",
 		},
+
+		{
+			"connect-proxy: valid upstream mesh gateway mode",
+			func(x *NodeService) { x.Proxy.Upstreams[0].MeshGateway.Mode = MeshGatewayModeRemote },
+			"",
+		},
+
+		{
+			"connect-proxy: invalid upstream mesh gateway mode",
+			func(x *NodeService) { x.Proxy.Upstreams[0].MeshGateway.Mode = "foo" },
+			"invalid MeshGateway.Mode",
+		},
 	}
 
 	for _, tc := range cases {
@@ -410,6 +423,73 @@ func TestStructs_NodeService_ValidateConnectProxy(t *testing.T) {
 	}
 }
 
+func TestStructs_NodeService_ValidateMeshGateway(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Modify func(*NodeService)
+		Err    string
+	}{
+		{
+			"valid",
+			func(x *NodeService) {},
+			"",
+		},
+		{
+			"zero-port",
+			func(x *NodeService) { x.Port = 0 },
+			"Port must be non-zero",
+		},
+		{
+			"sidecar-service",
+			func(x *NodeService) { x.Connect.SidecarService = &ServiceDefinition{} },
+			"cannot have a sidecar service",
+		},
+		{
+			"connect-native",
+			func(x *NodeService) { x.Connect.Native = true },
+			"cannot also be Connect Native",
+		},
+		{
+			"proxy-destination-name",
+			func(x *NodeService) { x.Proxy.DestinationServiceName = "foo" },
+			"Proxy.DestinationServiceName configuration is invalid",
+		},
+		{
+			"proxy-destination-id",
+			func(x *NodeService) { x.Proxy.DestinationServiceID = "foo" },
+			"Proxy.DestinationServiceID configuration is invalid",
+		},
+		{
+			"proxy-upstreams",
+			func(x *NodeService) {
+				x.Proxy.Upstreams = []Upstream{
+					Upstream{
+						DestinationType: UpstreamDestTypeService,
+						DestinationName: "foo",
+						LocalBindPort:   12345,
+					},
+				}
+			},
+			"Proxy.Upstreams configuration is invalid",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ns := TestNodeServiceMeshGateway(t)
+			tc.Modify(ns)
+
+			err := ns.Validate()
+			if tc.Err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, strings.ToLower(err.Error()), strings.ToLower(tc.Err))
+			}
+		})
+	}
+}
+
 func TestStructs_NodeService_ValidateSidecarService(t *testing.T) {
 	cases := []struct {
 		Name   string
@@ -808,12 +888,14 @@ func TestStructs_CheckServiceNodes_Filter(t *testing.T) {
 }
 
 func TestStructs_DirEntry_Clone(t *testing.T) {
+	expires := time.Now().Add(time.Minute)
 	e := &DirEntry{
-		LockIndex: 5,
-		Key:       "hello",
-		Flags:     23,
-		Value:     []byte("this is a test"),
-		Session:   "session1",
+		LockIndex:      5,
+		Key:            "hello",
+		Flags:          23,
+		Value:          []byte("this is a test"),
+		Session:        "session1",
+		ExpirationTime: &expires,
 		RaftIndex: RaftIndex{
 			CreateIndex: 1,
 			ModifyIndex: 2,
@@ -831,6 +913,65 @@ func TestStructs_DirEntry_Clone(t *testing.T) {
 	}
 }
 
+func TestStructs_DirEntry_IsExpired(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Second)
+	future := now.Add(time.Second)
+
+	cases := []struct {
+		name    string
+		expires *time.Time
+		asOf    time.Time
+		expired bool
+	}{
+		{"no expiration", nil, now, false},
+		{"zero expiration", &time.Time{}, now, false},
+		{"zero as of", &past, time.Time{}, false},
+		{"expired", &past, now, true},
+		{"not expired", &future, now, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &DirEntry{Key: "hello", ExpirationTime: tc.expires}
+			if got := e.IsExpired(tc.asOf); got != tc.expired {
+				t.Fatalf("got %v, want %v", got, tc.expired)
+			}
+		})
+	}
+}
+
+func TestStructs_KVSHistory_AtIndex(t *testing.T) {
+	history := KVSHistory{
+		&KVSHistoryEntry{Key: "hello", Value: []byte("a"), RaftIndex: RaftIndex{ModifyIndex: 3}},
+		&KVSHistoryEntry{Key: "hello", Deleted: true, RaftIndex: RaftIndex{ModifyIndex: 5}},
+		&KVSHistoryEntry{Key: "hello", Value: []byte("b"), RaftIndex: RaftIndex{ModifyIndex: 8}},
+	}
+
+	cases := []struct {
+		index uint64
+		value string
+	}{
+		{2, ""},
+		{3, "a"},
+		{4, "a"},
+		{5, ""},
+		{7, ""},
+		{8, "b"},
+		{100, "b"},
+	}
+	for _, tc := range cases {
+		var value string
+		if version := history.AtIndex(tc.index); version != nil {
+			if entry := version.DirEntry(); entry != nil {
+				value = string(entry.Value)
+			}
+		}
+		if value != tc.value {
+			t.Fatalf("index %d: got %q, want %q", tc.index, value, tc.value)
+		}
+	}
+}
+
 func TestStructs_ValidateMetadata(t *testing.T) {
 	// Load a valid set of key/value pairs
 	meta := map[string]string{
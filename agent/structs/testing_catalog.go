@@ -49,6 +49,22 @@ func TestNodeServiceProxy(t testing.T) *NodeService {
 	}
 }
 
+// TestNodeServiceMeshGateway returns a *NodeService representing a valid
+// Mesh Gateway.
+func TestNodeServiceMeshGateway(t testing.T) *NodeService {
+	return &NodeService{
+		Kind:    ServiceKindMeshGateway,
+		Service: "mesh-gateway",
+		Address: "10.1.2.3",
+		Port:    8443,
+		Proxy: ConnectProxyConfig{
+			Config: map[string]interface{}{
+				"foo": "bar",
+			},
+		},
+	}
+}
+
 // TestNodeServiceSidecar returns a *NodeService representing a service
 // registration with a nested Sidecar registration.
 func TestNodeServiceSidecar(t testing.T) *NodeService {
@@ -144,10 +144,11 @@ func (s *HTTPServer) convertOps(resp http.ResponseWriter, req *http.Request) (st
 				KV: &structs.TxnKVOp{
 					Verb: verb,
 					DirEnt: structs.DirEntry{
-						Key:     in.KV.Key,
-						Value:   in.KV.Value,
-						Flags:   in.KV.Flags,
-						Session: in.KV.Session,
+						Key:           in.KV.Key,
+						Value:         in.KV.Value,
+						Flags:         in.KV.Flags,
+						Session:       in.KV.Session,
+						ExpirationTTL: in.KV.TTL,
 						RaftIndex: structs.RaftIndex{
 							ModifyIndex: in.KV.Index,
 						},
@@ -404,6 +404,48 @@ func TestTxnEndpoint_KV_Actions(t *testing.T) {
 	})
 }
 
+func TestTxnEndpoint_KV_TTL(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	start := time.Now()
+	buf := bytes.NewBuffer([]byte(`
+ [
+     {
+         "KV": {
+             "Verb": "set",
+             "Key": "key",
+             "Value": "aGVsbG8gd29ybGQ=",
+             "TTL": "1h"
+         }
+     }
+ ]
+ `))
+	req, _ := http.NewRequest("PUT", "/v1/txn", buf)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.Txn(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 200 {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+
+	txnResp, ok := obj.(structs.TxnResponse)
+	if !ok {
+		t.Fatalf("bad type: %T", obj)
+	}
+	if len(txnResp.Results) != 1 {
+		t.Fatalf("bad: %v", txnResp)
+	}
+	d := (*structs.DirEntry)(txnResp.Results[0].KV)
+	if !d.HasExpirationTime() || d.ExpirationTime.Before(start.Add(time.Hour)) || d.ExpirationTime.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("bad: %v", d)
+	}
+}
+
 func TestTxnEndpoint_UpdateCheck(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 )
@@ -25,6 +27,18 @@ func clustersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pro
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
+
+	switch cfgSnap.Kind {
+	case structs.ServiceKindMeshGateway:
+		return clustersFromSnapshotMeshGateway(cfgSnap)
+	default:
+		return clustersFromSnapshotConnectProxy(cfgSnap)
+	}
+}
+
+// clustersFromSnapshotConnectProxy returns the clusters of a connect-proxy:
+// the local application and every target its upstreams may send traffic to.
+func clustersFromSnapshotConnectProxy(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
 	// Include the "app" cluster for the public listener
 	clusters := make([]proto.Message, len(cfgSnap.Proxy.Upstreams)+1)
 
@@ -34,16 +48,154 @@ func clustersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pro
 		return nil, err
 	}
 
+	seen := make(map[string]bool)
 	for idx, upstream := range cfgSnap.Proxy.Upstreams {
 		clusters[idx+1], err = makeUpstreamCluster(upstream, cfgSnap)
 		if err != nil {
 			return nil, err
 		}
+		seen[upstream.Identifier()] = true
+	}
+
+	// Add a cluster for every other target the discovery chains of the
+	// upstreams route to, and for every failover target reached through a
+	// mesh gateway. The SNI of the latter must name their own datacenter so
+	// they can't share the cluster of the target they back up.
+	for _, upstream := range cfgSnap.Proxy.Upstreams {
+		chain := cfgSnap.UpstreamChains[upstream.Identifier()]
+		if chain == nil {
+			continue
+		}
+
+		targets := make(map[string]proxycfg.DiscoveryTarget, len(chain.Targets))
+		for id, target := range chain.Targets {
+			targets[id] = target
+		}
+		for id, target := range chain.FailoverTargets {
+			if usesMeshGateway(target, cfgSnap) {
+				targets[id] = target
+			}
+		}
+
+		ids := make([]string, 0, len(targets))
+		for id := range targets {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			target := targets[id]
+			c := makeEDSCluster(id, upstream, cfgSnap)
+			c.TlsContext = &envoyauth.UpstreamTlsContext{
+				CommonTlsContext: makeCommonTLSContext(cfgSnap),
+				Sni:              makeUpstreamSNI(target.Service, target.ServiceSubset, target.Datacenter, cfgSnap),
+			}
+			clusters = append(clusters, c)
+		}
 	}
 
 	return clusters, nil
 }
 
+// clustersFromSnapshotMeshGateway returns the clusters of a mesh gateway: one
+// for the gateways of every other datacenter and one for every service in
+// the local datacenter. They are named after the SNI they are matched with.
+func clustersFromSnapshotMeshGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	if cfgSnap.Roots == nil {
+		return nil, errors.New("no CA roots in config snapshot")
+	}
+	trustDomain := cfgSnap.Roots.TrustDomain
+
+	clusters := make([]proto.Message, 0,
+		len(cfgSnap.MeshGateway.GatewayGroups)+len(cfgSnap.MeshGateway.ServiceGroups))
+
+	for _, dc := range sortedKeys(cfgSnap.MeshGateway.GatewayGroups) {
+		clusters = append(clusters, makeMeshGatewayCluster(connect.DatacenterSNI(dc, trustDomain), cfgSnap))
+	}
+	for _, svc := range sortedKeys(cfgSnap.MeshGateway.ServiceGroups) {
+		sni := connect.ServiceSNI(svc, "", "", cfgSnap.Datacenter, trustDomain)
+		clusters = append(clusters, makeMeshGatewayCluster(sni, cfgSnap))
+
+		for _, subset := range meshGatewaySubsets(svc, cfgSnap) {
+			sni := connect.ServiceSNI(svc, subset, "", cfgSnap.Datacenter, trustDomain)
+			clusters = append(clusters, makeMeshGatewayCluster(sni, cfgSnap))
+		}
+	}
+
+	return clusters, nil
+}
+
+// meshGatewaySubsets returns the names of the subsets that the
+// service-resolver of the service defines in sorted order. Mesh gateways
+// route connections to each of them separately.
+func meshGatewaySubsets(svc string, cfgSnap *proxycfg.ConfigSnapshot) []string {
+	resolver := cfgSnap.MeshGateway.ServiceResolvers[svc]
+	if resolver == nil {
+		return nil
+	}
+	subsets := make([]string, 0, len(resolver.Subsets))
+	for name := range resolver.Subsets {
+		subsets = append(subsets, name)
+	}
+	sort.Strings(subsets)
+	return subsets
+}
+
+// makeMeshGatewayCluster returns an EDS cluster for a mesh gateway. Gateways
+// forward the TLS connections they receive unmodified so the cluster has no
+// TLS context of its own.
+func makeMeshGatewayCluster(name string, cfgSnap *proxycfg.ConfigSnapshot) *envoy.Cluster {
+	conTimeout := 5 * time.Second
+	if toRaw, ok := cfgSnap.Proxy.Config["connect_timeout_ms"]; ok {
+		if ms, err := parseTimeMillis(toRaw); err == nil {
+			conTimeout = ms
+		}
+	}
+	return &envoy.Cluster{
+		Name:           name,
+		ConnectTimeout: conTimeout,
+		Type:           envoy.Cluster_EDS,
+		EdsClusterConfig: &envoy.Cluster_EdsClusterConfig{
+			EdsConfig: &envoycore.ConfigSource{
+				ConfigSourceSpecifier: &envoycore.ConfigSource_Ads{
+					Ads: &envoycore.AggregatedConfigSource{},
+				},
+			},
+		},
+		// Having an empty config enables outlier detection with default config.
+		OutlierDetection: &envoycluster.OutlierDetection{},
+	}
+}
+
+// makeUpstreamSNI returns the SNI a proxy sets when connecting to a service,
+// or one of its subsets, in the given datacenter, which defaults to the local
+// one. Mesh gateways route the connection based on it.
+func makeUpstreamSNI(service, subset, dc string, cfgSnap *proxycfg.ConfigSnapshot) string {
+	if cfgSnap.Roots == nil {
+		return ""
+	}
+	if dc == "" {
+		dc = cfgSnap.Datacenter
+	}
+	return connect.ServiceSNI(service, subset, "", dc, cfgSnap.Roots.TrustDomain)
+}
+
+// sortedKeys returns the keys of a map of endpoint groups in sorted order so
+// that the generated config is stable.
+func sortedKeys(groups map[string]structs.CheckServiceNodes) []string {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func makeAppCluster(cfgSnap *proxycfg.ConfigSnapshot) (*envoy.Cluster, error) {
 	var c *envoy.Cluster
 	var err error
@@ -122,26 +274,7 @@ func makeUpstreamCluster(upstream structs.Upstream, cfgSnap *proxycfg.ConfigSnap
 	}
 
 	if c == nil {
-		conTimeout := 5 * time.Second
-		if toRaw, ok := upstream.Config["connect_timeout_ms"]; ok {
-			if ms, err := parseTimeMillis(toRaw); err == nil {
-				conTimeout = ms
-			}
-		}
-		c = &envoy.Cluster{
-			Name:           upstream.Identifier(),
-			ConnectTimeout: conTimeout,
-			Type:           envoy.Cluster_EDS,
-			EdsClusterConfig: &envoy.Cluster_EdsClusterConfig{
-				EdsConfig: &envoycore.ConfigSource{
-					ConfigSourceSpecifier: &envoycore.ConfigSource_Ads{
-						Ads: &envoycore.AggregatedConfigSource{},
-					},
-				},
-			},
-			// Having an empty config enables outlier detection with default config.
-			OutlierDetection: &envoycluster.OutlierDetection{},
-		}
+		c = makeEDSCluster(upstream.Identifier(), upstream, cfgSnap)
 	}
 
 	// Enable TLS upstream with the configured client certificate.
@@ -149,14 +282,71 @@ func makeUpstreamCluster(upstream structs.Upstream, cfgSnap *proxycfg.ConfigSnap
 		CommonTlsContext: makeCommonTLSContext(cfgSnap),
 	}
 
+	// Set the SNI so that mesh gateways can route connections to the
+	// service. Prepared queries may resolve to any service so they can't be
+	// routed by gateways.
+	if upstream.DestinationType != structs.UpstreamDestTypePreparedQuery {
+		c.TlsContext.Sni = makeUpstreamSNI(upstream.DestinationName, "", upstream.Datacenter, cfgSnap)
+	}
+
 	return c, nil
 }
 
+// makeEDSCluster returns a cluster with the given name whose endpoints are
+// delivered via EDS. Settings such as the connect timeout are taken from the
+// upstream the cluster belongs to unless the service-resolver of the target
+// overrides them.
+func makeEDSCluster(name string, upstream structs.Upstream, cfgSnap *proxycfg.ConfigSnapshot) *envoy.Cluster {
+	conTimeout := 5 * time.Second
+	if toRaw, ok := upstream.Config["connect_timeout_ms"]; ok {
+		if ms, err := parseTimeMillis(toRaw); err == nil {
+			conTimeout = ms
+		}
+	}
+	c := &envoy.Cluster{
+		Name:           name,
+		ConnectTimeout: conTimeout,
+		Type:           envoy.Cluster_EDS,
+		EdsClusterConfig: &envoy.Cluster_EdsClusterConfig{
+			EdsConfig: &envoycore.ConfigSource{
+				ConfigSourceSpecifier: &envoycore.ConfigSource_Ads{
+					Ads: &envoycore.AggregatedConfigSource{},
+				},
+			},
+		},
+		// Having an empty config enables outlier detection with default config.
+		OutlierDetection: &envoycluster.OutlierDetection{},
+	}
+
+	if chain := cfgSnap.UpstreamChains[upstream.Identifier()]; chain != nil {
+		// Use the protocol of the service the cluster points to, which
+		// differs from the chain's when a route or split sends requests to
+		// another service.
+		protocol := chain.Protocol
+		target, ok := chain.Targets[name]
+		if !ok {
+			target, ok = chain.FailoverTargets[name]
+		}
+		if ok {
+			protocol = target.Protocol
+			if target.ConnectTimeout > 0 {
+				c.ConnectTimeout = target.ConnectTimeout
+			}
+		}
+		switch protocol {
+		case "http2", "grpc":
+			c.Http2ProtocolOptions = &envoycore.Http2ProtocolOptions{}
+		}
+	}
+
+	return c
+}
+
 // makeClusterFromUserConfig returns the listener config decoded from an
 // arbitrary proto3 json format string or an error if it's invalid.
 //
 // For now we only support embedding in JSON strings because of the hcl parsing
-// pain (see config.go comment above call to patchSliceOfMaps). Until we
+// pain (see config.go comment above call to PatchSliceOfMaps). Until we
 // refactor config parser a _lot_ user's opaque config that contains arrays will
 // be mangled. We could actually fix that up in mapstructure which knows the
 // type of the target so could resolve the slices to singletons unambiguously
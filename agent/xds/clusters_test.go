@@ -74,3 +74,162 @@ func Test_makeUpstreamCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestClustersFromSnapshot_ServiceResolver(t *testing.T) {
+	t.Parallel()
+
+	snap := proxycfg.TestConfigSnapshotWithEntries(t,
+		&structs.ServiceResolverConfigEntry{
+			Name:          "db",
+			DefaultSubset: "v1",
+			Subsets: map[string]structs.ServiceResolverSubset{
+				"v1": {Filter: "Service.Meta.version == v1"},
+			},
+			Failover: map[string]structs.ServiceResolverFailover{
+				"*": {Datacenters: []string{"dc2"}},
+			},
+			ConnectTimeout: 33 * time.Second,
+		},
+	)
+
+	// Only the default subset gets an extra cluster, failover targets that
+	// are connected to directly are served as lower priority endpoints of
+	// that cluster.
+	clusters, err := clustersFromSnapshot(snap, "")
+	require.NoError(t, err)
+	var names []string
+	for _, c := range clusters {
+		names = append(names, c.(*envoy.Cluster).Name)
+	}
+	require.Equal(t, []string{
+		"local_app",
+		"service:db",
+		"prepared_query:geo-cache",
+		"service:db?subset=v1",
+	}, names)
+	require.Equal(t, 33*time.Second, clusters[3].(*envoy.Cluster).ConnectTimeout)
+	require.Equal(t, 1*time.Second, clusters[1].(*envoy.Cluster).ConnectTimeout)
+
+	// TCP upstreams proxy to the default subset.
+	listeners, err := listenersFromSnapshot(snap, "")
+	require.NoError(t, err)
+	l := listeners[1].(*envoy.Listener)
+	require.Equal(t, "service:db:127.0.0.1:9191", l.Name)
+	tcp, err := makeTCPProxyFilter("service:db", "service:db?subset=v1")
+	require.NoError(t, err)
+	require.Equal(t, tcp, l.FilterChains[0].Filters[0])
+}
+
+func TestClustersFromSnapshot_SubsetFailoverThroughRemoteGateway(t *testing.T) {
+	t.Parallel()
+
+	snap := proxycfg.TestConfigSnapshotWithUpstreamEntries(t,
+		func(upstreams []structs.Upstream) {
+			upstreams[0].MeshGateway.Mode = structs.MeshGatewayModeRemote
+		},
+		&structs.ServiceResolverConfigEntry{
+			Name:          "db",
+			DefaultSubset: "v1",
+			Subsets: map[string]structs.ServiceResolverSubset{
+				"v1": {Filter: "Service.Meta.version == v1"},
+			},
+			Failover: map[string]structs.ServiceResolverFailover{
+				"*": {Datacenters: []string{"dc2"}},
+			},
+		},
+	)
+	trustDomain := snap.Roots.TrustDomain
+	primary, backup := "service:db?subset=v1", "service:db?dc=dc2&subset=v1"
+	snap.UpstreamEndpoints[backup] = proxycfg.TestGatewayNodesInDC(t, "dc2")
+
+	// The failover target gets its own cluster whose SNI names its subset
+	// and datacenter so the remote gateway routes it to its own instances.
+	clusters, err := clustersFromSnapshot(snap, "")
+	require.NoError(t, err)
+	byName := make(map[string]*envoy.Cluster)
+	for _, r := range clusters {
+		c := r.(*envoy.Cluster)
+		byName[c.Name] = c
+	}
+	require.Len(t, byName, 5)
+	require.Equal(t, "v1.db.default.dc1.internal."+trustDomain, byName[primary].TlsContext.Sni)
+	require.Equal(t, "v1.db.default.dc2.internal."+trustDomain, byName[backup].TlsContext.Sni)
+
+	// Its endpoints are the remote gateways rather than lower priority
+	// endpoints of the primary cluster.
+	resources, err := endpointsFromSnapshot(snap, "")
+	require.NoError(t, err)
+	endpoints := make(map[string]*envoy.ClusterLoadAssignment)
+	for _, r := range resources {
+		la := r.(*envoy.ClusterLoadAssignment)
+		endpoints[la.ClusterName] = la
+	}
+	require.Len(t, endpoints[primary].Endpoints, 1)
+	require.Equal(t, makeLoadAssignment(backup, loadAssignmentEndpointGroup{
+		Endpoints: proxycfg.TestGatewayNodesInDC(t, "dc2"),
+		UseWAN:    true,
+	}), endpoints[backup])
+
+	requireTCPProxyTo := func(cluster string) {
+		t.Helper()
+		listeners, err := listenersFromSnapshot(snap, "")
+		require.NoError(t, err)
+		l := listeners[1].(*envoy.Listener)
+		require.Equal(t, "service:db:127.0.0.1:9191", l.Name)
+		tcp, err := makeTCPProxyFilter("service:db", cluster)
+		require.NoError(t, err)
+		require.Equal(t, tcp, l.FilterChains[0].Filters[0])
+	}
+
+	// Traffic goes to the primary while it has healthy instances, and to the
+	// failover cluster once it doesn't.
+	requireTCPProxyTo(primary)
+	snap.UpstreamEndpoints[primary] = nil
+	requireTCPProxyTo(backup)
+	snap.UpstreamEndpoints[backup] = nil
+	requireTCPProxyTo(primary)
+}
+
+func TestClustersFromSnapshot_MeshGateway(t *testing.T) {
+	t.Parallel()
+
+	snap := proxycfg.TestConfigSnapshotMeshGateway(t)
+	trustDomain := snap.Roots.TrustDomain
+
+	clusters, err := clustersFromSnapshot(snap, "")
+	require.NoError(t, err)
+
+	var names []string
+	for _, r := range clusters {
+		c := r.(*envoy.Cluster)
+		names = append(names, c.Name)
+
+		// Gateways don't terminate TLS.
+		require.Nil(t, c.TlsContext)
+		require.Equal(t, envoy.Cluster_EDS, c.Type)
+		require.Equal(t, 2*time.Second, c.ConnectTimeout)
+	}
+	require.Equal(t, []string{
+		"dc2.internal." + trustDomain,
+		"db.default.dc1.internal." + trustDomain,
+	}, names)
+}
+
+func TestClustersFromSnapshot_UpstreamSNI(t *testing.T) {
+	t.Parallel()
+
+	snap := proxycfg.TestConfigSnapshot(t)
+	snap.Proxy.Upstreams[0].Datacenter = "dc2"
+	trustDomain := snap.Roots.TrustDomain
+
+	clusters, err := clustersFromSnapshot(snap, "")
+	require.NoError(t, err)
+	require.Len(t, clusters, 3)
+
+	// Service upstreams set the SNI mesh gateways route on, prepared queries
+	// can't be routed by gateways.
+	db := clusters[1].(*envoy.Cluster)
+	require.Equal(t, "db.default.dc2.internal."+trustDomain, db.TlsContext.Sni)
+	query := clusters[2].(*envoy.Cluster)
+	require.Empty(t, query.TlsContext.Sni)
+}
@@ -2,12 +2,15 @@ package xds
 
 import (
 	"errors"
+	"fmt"
 
 	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoycore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoyendpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	"github.com/gogo/protobuf/proto"
+	bexpr "github.com/hashicorp/go-bexpr"
 
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
@@ -19,14 +22,213 @@ func endpointsFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pr
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
+
+	switch cfgSnap.Kind {
+	case structs.ServiceKindMeshGateway:
+		return endpointsFromSnapshotMeshGateway(cfgSnap)
+	default:
+		return endpointsFromSnapshotConnectProxy(cfgSnap)
+	}
+}
+
+// endpointsFromSnapshotConnectProxy returns the load assignments of every
+// upstream cluster of a connect-proxy.
+func endpointsFromSnapshotConnectProxy(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	// Failover targets that are connected to directly don't have a cluster of
+	// their own. Instead their endpoints are added to the load assignment of
+	// the targets they back up at a lower priority so that Envoy only sends
+	// them traffic once the target's own endpoints are unhealthy. Failover
+	// targets reached through mesh gateways have their own cluster, see
+	// activeCluster.
+	failover := make(map[string][]string)
+	failoverOnly := make(map[string]bool)
+	// Targets in other datacenters that are reached through the mesh gateways
+	// of that datacenter have the gateways as endpoints, which must be
+	// addressed using their WAN address.
+	remoteGateway := make(map[string]bool)
+	for _, chain := range cfgSnap.UpstreamChains {
+		for id, target := range chain.FailoverTargets {
+			if !usesMeshGateway(target, cfgSnap) {
+				failoverOnly[id] = true
+			}
+			remoteGateway[id] = usesRemoteGateway(target, cfgSnap)
+		}
+		for id, target := range chain.Targets {
+			remoteGateway[id] = usesRemoteGateway(target, cfgSnap)
+		}
+	}
+	for _, chain := range cfgSnap.UpstreamChains {
+		for id, target := range chain.Targets {
+			delete(failoverOnly, id)
+
+			var merged []string
+			for _, failoverID := range target.Failover {
+				if !usesMeshGateway(chain.FailoverTargets[failoverID], cfgSnap) {
+					merged = append(merged, failoverID)
+				}
+			}
+			if len(merged) > 0 {
+				failover[id] = merged
+			}
+		}
+	}
+	for _, u := range cfgSnap.Proxy.Upstreams {
+		delete(failoverOnly, u.Identifier())
+	}
+
 	resources := make([]proto.Message, 0, len(cfgSnap.UpstreamEndpoints))
 	for id, endpoints := range cfgSnap.UpstreamEndpoints {
-		la := makeLoadAssignment(id, endpoints)
+		if failoverOnly[id] {
+			continue
+		}
+		endpointGroups := []loadAssignmentEndpointGroup{
+			{Endpoints: endpoints, UseWAN: remoteGateway[id]},
+		}
+		for _, failoverID := range failover[id] {
+			endpointGroups = append(endpointGroups, loadAssignmentEndpointGroup{
+				Endpoints: cfgSnap.UpstreamEndpoints[failoverID],
+				UseWAN:    remoteGateway[failoverID],
+			})
+		}
+		la := makeLoadAssignment(id, endpointGroups...)
 		resources = append(resources, la)
 	}
+
+	// Targets naming an unknown subset aren't watched. They get an empty load
+	// assignment so their clusters don't wait for endpoints that never come.
+	unknown := make(map[string]bool)
+	for _, chain := range cfgSnap.UpstreamChains {
+		for id, target := range chain.Targets {
+			if target.UnknownSubset && !unknown[id] {
+				unknown[id] = true
+				resources = append(resources, makeLoadAssignment(id))
+			}
+		}
+	}
 	return resources, nil
 }
 
+// usesRemoteGateway returns true if traffic for the target is sent straight
+// to the mesh gateways of the target's datacenter.
+func usesRemoteGateway(target proxycfg.DiscoveryTarget, cfgSnap *proxycfg.ConfigSnapshot) bool {
+	return target.MeshGateway == structs.MeshGatewayModeRemote &&
+		target.Datacenter != "" &&
+		target.Datacenter != cfgSnap.Datacenter
+}
+
+// usesMeshGateway returns true if traffic for the target is sent through mesh
+// gateways, either those of the local datacenter or those of the target's.
+func usesMeshGateway(target proxycfg.DiscoveryTarget, cfgSnap *proxycfg.ConfigSnapshot) bool {
+	if target.Datacenter == "" || target.Datacenter == cfgSnap.Datacenter {
+		return false
+	}
+	switch target.MeshGateway {
+	case structs.MeshGatewayModeLocal, structs.MeshGatewayModeRemote:
+		return true
+	default:
+		return false
+	}
+}
+
+// activeCluster returns the name of the cluster that traffic for the chain
+// target with the given identifier is sent to. Envoy only fails over between
+// the endpoints of a single cluster, so once neither the target nor the
+// failover targets merged into its cluster have healthy endpoints, traffic is
+// sent to the cluster of the first failover target reached through a mesh
+// gateway that does.
+func activeCluster(chain *proxycfg.DiscoveryChain, id string, cfgSnap *proxycfg.ConfigSnapshot) string {
+	target, ok := chain.Targets[id]
+	if !ok || len(target.Failover) == 0 {
+		return id
+	}
+	// Stay on the target until its endpoints are known.
+	if endpoints, ok := cfgSnap.UpstreamEndpoints[id]; !ok || hasHealthyEndpoints(endpoints) {
+		return id
+	}
+	for _, failoverID := range target.Failover {
+		if !hasHealthyEndpoints(cfgSnap.UpstreamEndpoints[failoverID]) {
+			continue
+		}
+		if usesMeshGateway(chain.FailoverTargets[failoverID], cfgSnap) {
+			return failoverID
+		}
+		return id
+	}
+	return id
+}
+
+// hasHealthyEndpoints returns true if Envoy would consider any of the
+// endpoints healthy.
+func hasHealthyEndpoints(endpoints structs.CheckServiceNodes) bool {
+	for _, ep := range makeLbEndpoints(endpoints, false) {
+		if ep.HealthStatus == envoycore.HealthStatus_HEALTHY {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointsFromSnapshotMeshGateway returns the load assignments of the
+// clusters of a mesh gateway. The gateways of other datacenters are addressed
+// using their WAN address.
+func endpointsFromSnapshotMeshGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	if cfgSnap.Roots == nil {
+		return nil, errors.New("no CA roots in config snapshot")
+	}
+	trustDomain := cfgSnap.Roots.TrustDomain
+
+	resources := make([]proto.Message, 0,
+		len(cfgSnap.MeshGateway.GatewayGroups)+len(cfgSnap.MeshGateway.ServiceGroups))
+
+	for _, dc := range sortedKeys(cfgSnap.MeshGateway.GatewayGroups) {
+		la := makeLoadAssignment(connect.DatacenterSNI(dc, trustDomain), loadAssignmentEndpointGroup{
+			Endpoints: cfgSnap.MeshGateway.GatewayGroups[dc],
+			UseWAN:    true,
+		})
+		resources = append(resources, la)
+	}
+	for _, svc := range sortedKeys(cfgSnap.MeshGateway.ServiceGroups) {
+		endpoints := cfgSnap.MeshGateway.ServiceGroups[svc]
+		sni := connect.ServiceSNI(svc, "", "", cfgSnap.Datacenter, trustDomain)
+		la := makeLoadAssignment(sni, loadAssignmentEndpointGroup{
+			Endpoints: endpoints,
+		})
+		resources = append(resources, la)
+
+		// Subsets get the instances matching their filter.
+		for _, subset := range meshGatewaySubsets(svc, cfgSnap) {
+			filter := cfgSnap.MeshGateway.ServiceResolvers[svc].Subsets[subset].Filter
+			subsetEndpoints, err := filterEndpoints(endpoints, filter)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter for subset %q of service %q: %v", subset, svc, err)
+			}
+			sni := connect.ServiceSNI(svc, subset, "", cfgSnap.Datacenter, trustDomain)
+			la := makeLoadAssignment(sni, loadAssignmentEndpointGroup{
+				Endpoints: subsetEndpoints,
+			})
+			resources = append(resources, la)
+		}
+	}
+	return resources, nil
+}
+
+// filterEndpoints returns the endpoints matching the bexpr filter of a
+// subset. Empty filters match every endpoint.
+func filterEndpoints(endpoints structs.CheckServiceNodes, filter string) (structs.CheckServiceNodes, error) {
+	if filter == "" {
+		return endpoints, nil
+	}
+	f, err := bexpr.CreateFilter(filter, nil, endpoints)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := f.Execute(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	return raw.(structs.CheckServiceNodes), nil
+}
+
 func makeEndpoint(clusterName, host string, port int) envoyendpoint.LbEndpoint {
 	return envoyendpoint.LbEndpoint{
 		Endpoint: &envoyendpoint.Endpoint{
@@ -35,13 +237,43 @@ func makeEndpoint(clusterName, host string, port int) envoyendpoint.LbEndpoint {
 	}
 }
 
-func makeLoadAssignment(clusterName string, endpoints structs.CheckServiceNodes) *envoy.ClusterLoadAssignment {
+// loadAssignmentEndpointGroup is a group of endpoints that share the same
+// priority within a load assignment.
+type loadAssignmentEndpointGroup struct {
+	Endpoints structs.CheckServiceNodes
+
+	// UseWAN selects the WAN address of the endpoints' nodes when they have
+	// one. It is set for mesh gateways in other datacenters.
+	UseWAN bool
+}
+
+// makeLoadAssignment returns the load assignment for a cluster. Each group of
+// endpoints is assigned the next lower priority, starting with 0 for the
+// first group.
+func makeLoadAssignment(clusterName string, endpointGroups ...loadAssignmentEndpointGroup) *envoy.ClusterLoadAssignment {
+	cla := &envoy.ClusterLoadAssignment{
+		ClusterName: clusterName,
+		Endpoints:   make([]envoyendpoint.LocalityLbEndpoints, 0, len(endpointGroups)),
+	}
+	for priority, group := range endpointGroups {
+		cla.Endpoints = append(cla.Endpoints, envoyendpoint.LocalityLbEndpoints{
+			Priority:    uint32(priority),
+			LbEndpoints: makeLbEndpoints(group.Endpoints, group.UseWAN),
+		})
+	}
+	return cla
+}
+
+func makeLbEndpoints(endpoints structs.CheckServiceNodes, useWAN bool) []envoyendpoint.LbEndpoint {
 	es := make([]envoyendpoint.LbEndpoint, 0, len(endpoints))
 	for _, ep := range endpoints {
 		addr := ep.Service.Address
 		if addr == "" {
 			addr = ep.Node.Address
 		}
+		if wan := ep.Node.TaggedAddresses["wan"]; useWAN && wan != "" {
+			addr = wan
+		}
 		healthStatus := envoycore.HealthStatus_HEALTHY
 		weight := 1
 		if ep.Service.Weights != nil {
@@ -76,10 +308,5 @@ func makeLoadAssignment(clusterName string, endpoints structs.CheckServiceNodes)
 			LoadBalancingWeight: makeUint32Value(weight),
 		})
 	}
-	return &envoy.ClusterLoadAssignment{
-		ClusterName: clusterName,
-		Endpoints: []envoyendpoint.LocalityLbEndpoints{{
-			LbEndpoints: es,
-		}},
-	}
+	return es
 }
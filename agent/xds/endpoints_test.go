@@ -10,6 +10,7 @@ import (
 	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoyendpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
@@ -186,8 +187,192 @@ func Test_makeLoadAssignment(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := makeLoadAssignment(tt.clusterName, tt.endpoints)
+			got := makeLoadAssignment(tt.clusterName, loadAssignmentEndpointGroup{
+				Endpoints: tt.endpoints,
+			})
 			require.Equal(t, tt.want, got)
 		})
 	}
 }
+
+func TestEndpointsFromSnapshot_ServiceResolverFailover(t *testing.T) {
+	t.Parallel()
+
+	snap := proxycfg.TestConfigSnapshotWithEntries(t,
+		&structs.ServiceResolverConfigEntry{
+			Name:          "db",
+			DefaultSubset: "v1",
+			Subsets: map[string]structs.ServiceResolverSubset{
+				"v1": {Filter: "Service.Meta.version == v1"},
+			},
+			Failover: map[string]structs.ServiceResolverFailover{
+				"*": {Datacenters: []string{"dc2", "dc3"}},
+			},
+		},
+	)
+
+	resources, err := endpointsFromSnapshot(snap, "")
+	require.NoError(t, err)
+
+	// Failover targets don't get a load assignment of their own.
+	byName := make(map[string]*envoy.ClusterLoadAssignment)
+	for _, r := range resources {
+		la := r.(*envoy.ClusterLoadAssignment)
+		byName[la.ClusterName] = la
+	}
+	require.Len(t, byName, 2)
+	require.Contains(t, byName, "service:db")
+
+	la := byName["service:db?subset=v1"]
+	require.NotNil(t, la)
+	require.Equal(t, makeLoadAssignment("service:db?subset=v1",
+		loadAssignmentEndpointGroup{Endpoints: proxycfg.TestUpstreamNodes(t)},
+		loadAssignmentEndpointGroup{Endpoints: proxycfg.TestUpstreamNodesInDC(t, "dc2")},
+		loadAssignmentEndpointGroup{Endpoints: proxycfg.TestUpstreamNodesInDC(t, "dc3")},
+	), la)
+	require.Len(t, la.Endpoints, 3)
+	for i, group := range la.Endpoints {
+		require.Equal(t, uint32(i), group.Priority)
+	}
+}
+
+func TestEndpointsFromSnapshot_UnknownSubset(t *testing.T) {
+	t.Parallel()
+
+	snap := proxycfg.TestConfigSnapshotWithEntries(t,
+		&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+		&structs.ServiceSplitterConfigEntry{
+			Name: "db",
+			Splits: []structs.ServiceSplit{
+				{Weight: 90, ServiceSubset: "v1"},
+				{Weight: 10, ServiceSubset: "v2"},
+			},
+		},
+		&structs.ServiceResolverConfigEntry{
+			Name: "db",
+			Subsets: map[string]structs.ServiceResolverSubset{
+				"v1": {Filter: "Service.Meta.version == v1"},
+			},
+		},
+	)
+
+	resources, err := endpointsFromSnapshot(snap, "")
+	require.NoError(t, err)
+
+	byName := make(map[string]*envoy.ClusterLoadAssignment)
+	for _, r := range resources {
+		la := r.(*envoy.ClusterLoadAssignment)
+		byName[la.ClusterName] = la
+	}
+	require.Len(t, byName, 3)
+	require.NotEmpty(t, byName["service:db?subset=v1"].Endpoints)
+
+	// The subset the resolver doesn't define gets no endpoints rather than
+	// every instance of the service.
+	require.Equal(t, makeLoadAssignment("service:db?subset=v2"), byName["service:db?subset=v2"])
+}
+
+func TestEndpointsFromSnapshot_MeshGateway(t *testing.T) {
+	t.Parallel()
+
+	snap := proxycfg.TestConfigSnapshotMeshGateway(t)
+	trustDomain := snap.Roots.TrustDomain
+
+	resources, err := endpointsFromSnapshot(snap, "")
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+
+	// The gateways of other datacenters are reached via their WAN address.
+	remote := resources[0].(*envoy.ClusterLoadAssignment)
+	require.Equal(t, "dc2.internal."+trustDomain, remote.ClusterName)
+	require.Len(t, remote.Endpoints, 1)
+	require.Len(t, remote.Endpoints[0].LbEndpoints, 1)
+	require.Equal(t, makeAddressPtr("198.18.1.1", 8443),
+		remote.Endpoints[0].LbEndpoints[0].Endpoint.Address)
+
+	local := resources[1].(*envoy.ClusterLoadAssignment)
+	require.Equal(t, makeLoadAssignment("db.default.dc1.internal."+trustDomain,
+		loadAssignmentEndpointGroup{Endpoints: proxycfg.TestUpstreamNodes(t)},
+	), local)
+}
+
+func TestEndpointsFromSnapshot_MeshGatewaySubsets(t *testing.T) {
+	t.Parallel()
+
+	snap := proxycfg.TestConfigSnapshotMeshGateway(t)
+	trustDomain := snap.Roots.TrustDomain
+	nodes := snap.MeshGateway.ServiceGroups["db"]
+	nodes[0].Service.Meta = map[string]string{"version": "v1"}
+	snap.MeshGateway.ServiceResolvers["db"] = &structs.ServiceResolverConfigEntry{
+		Name: "db",
+		Subsets: map[string]structs.ServiceResolverSubset{
+			"v1": {Filter: "Service.Meta.version == v1"},
+		},
+	}
+	subsetSNI := "v1.db.default.dc1.internal." + trustDomain
+
+	// Subsets get a cluster and filter chain of their own matching the SNI
+	// proxies set for them.
+	clusters, err := clustersFromSnapshot(snap, "")
+	require.NoError(t, err)
+	require.Len(t, clusters, 3)
+	require.Equal(t, subsetSNI, clusters[2].(*envoy.Cluster).Name)
+
+	listeners, err := listenersFromSnapshot(snap, "")
+	require.NoError(t, err)
+	l := listeners[0].(*envoy.Listener)
+	subsetChain, err := makeSNIFilterChain("mesh_gateway_local.v1.db", subsetSNI, subsetSNI)
+	require.NoError(t, err)
+	require.Len(t, l.FilterChains, 3)
+	require.Equal(t, subsetChain, l.FilterChains[1])
+
+	// Their endpoints are the instances matching the subset's filter.
+	resources, err := endpointsFromSnapshot(snap, "")
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+	require.Equal(t, makeLoadAssignment(subsetSNI,
+		loadAssignmentEndpointGroup{Endpoints: nodes[:1]},
+	), resources[2])
+	require.Len(t, resources[1].(*envoy.ClusterLoadAssignment).Endpoints[0].LbEndpoints, 2)
+}
+
+func TestEndpointsFromSnapshot_MeshGatewayModes(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range []structs.MeshGatewayMode{structs.MeshGatewayModeLocal, structs.MeshGatewayModeRemote} {
+		mode := mode
+		t.Run(string(mode), func(t *testing.T) {
+			snap := proxycfg.TestConfigSnapshot(t)
+			snap.Proxy.Upstreams[0].Datacenter = "dc2"
+			snap.Proxy.Upstreams[0].MeshGateway.Mode = mode
+			id := snap.Proxy.Upstreams[0].Identifier()
+			snap.UpstreamChains = map[string]*proxycfg.DiscoveryChain{
+				id: proxycfg.TestDiscoveryChain(t, snap.Proxy.Upstreams[0]),
+			}
+			// The endpoints are the gateways of the datacenter the mode
+			// selects.
+			gatewayDC := "dc1"
+			if mode == structs.MeshGatewayModeRemote {
+				gatewayDC = "dc2"
+			}
+			snap.UpstreamEndpoints = map[string]structs.CheckServiceNodes{
+				id: proxycfg.TestGatewayNodesInDC(t, gatewayDC),
+			}
+
+			resources, err := endpointsFromSnapshot(snap, "")
+			require.NoError(t, err)
+			require.Len(t, resources, 1)
+			la := resources[0].(*envoy.ClusterLoadAssignment)
+			require.Equal(t, id, la.ClusterName)
+
+			// Only gateways in another datacenter are reached via their WAN
+			// address.
+			addr := "10.30.1.1"
+			if mode == structs.MeshGatewayModeRemote {
+				addr = "198.18.1.1"
+			}
+			require.Equal(t, makeAddressPtr(addr, 8443),
+				la.Endpoints[0].LbEndpoints[0].Endpoint.Address)
+		})
+	}
+}
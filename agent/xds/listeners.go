@@ -10,12 +10,14 @@ import (
 	envoycore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoylistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	extauthz "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/ext_authz/v2"
+	envoyhttp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 	envoytcp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/util"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 )
@@ -27,6 +29,17 @@ func listenersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pr
 		return nil, errors.New("nil config given")
 	}
 
+	switch cfgSnap.Kind {
+	case structs.ServiceKindMeshGateway:
+		return listenersFromSnapshotMeshGateway(cfgSnap)
+	default:
+		return listenersFromSnapshotConnectProxy(cfgSnap, token)
+	}
+}
+
+// listenersFromSnapshotConnectProxy returns the public listener of a
+// connect-proxy and one listener per upstream.
+func listenersFromSnapshotConnectProxy(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]proto.Message, error) {
 	// One listener for each upstream plus the public one
 	resources := make([]proto.Message, len(cfgSnap.Proxy.Upstreams)+1)
 
@@ -37,7 +50,7 @@ func listenersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pr
 		return nil, err
 	}
 	for i, u := range cfgSnap.Proxy.Upstreams {
-		resources[i+1], err = makeUpstreamListener(&u)
+		resources[i+1], err = makeUpstreamListener(&u, cfgSnap.UpstreamChains[u.Identifier()], cfgSnap)
 		if err != nil {
 			return nil, err
 		}
@@ -45,6 +58,83 @@ func listenersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pr
 	return resources, nil
 }
 
+// listenersFromSnapshotMeshGateway returns the single listener of a mesh
+// gateway.
+func listenersFromSnapshotMeshGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	l, err := makeMeshGatewayListener(cfgSnap)
+	if err != nil {
+		return nil, err
+	}
+	return []proto.Message{l}, nil
+}
+
+// makeMeshGatewayListener returns the listener of a mesh gateway. The TLS
+// connections it accepts are never terminated. Instead the TLS inspector
+// reads the SNI the client set and a filter chain matching it proxies the
+// connection as is to either the service's instances in the local datacenter
+// or the mesh gateways of the datacenter it is destined for.
+func makeMeshGatewayListener(cfgSnap *proxycfg.ConfigSnapshot) (*envoy.Listener, error) {
+	if cfgSnap.Roots == nil {
+		return nil, errors.New("no CA roots in config snapshot")
+	}
+	trustDomain := cfgSnap.Roots.TrustDomain
+
+	addr := cfgSnap.Address
+	if addr == "" {
+		addr = "0.0.0.0"
+	}
+	l := makeListener(PublicListenerName, addr, cfgSnap.Port)
+	l.ListenerFilters = []envoylistener.ListenerFilter{
+		{Name: "envoy.listener.tls_inspector"},
+	}
+
+	for _, svc := range sortedKeys(cfgSnap.MeshGateway.ServiceGroups) {
+		sni := connect.ServiceSNI(svc, "", "", cfgSnap.Datacenter, trustDomain)
+		chain, err := makeSNIFilterChain("mesh_gateway_local."+svc, sni, sni)
+		if err != nil {
+			return nil, err
+		}
+		l.FilterChains = append(l.FilterChains, chain)
+
+		for _, subset := range meshGatewaySubsets(svc, cfgSnap) {
+			sni := connect.ServiceSNI(svc, subset, "", cfgSnap.Datacenter, trustDomain)
+			chain, err := makeSNIFilterChain("mesh_gateway_local."+subset+"."+svc, sni, sni)
+			if err != nil {
+				return nil, err
+			}
+			l.FilterChains = append(l.FilterChains, chain)
+		}
+	}
+
+	for _, dc := range sortedKeys(cfgSnap.MeshGateway.GatewayGroups) {
+		dcSNI := connect.DatacenterSNI(dc, trustDomain)
+		chain, err := makeSNIFilterChain("mesh_gateway_remote."+dc, "*."+dcSNI, dcSNI)
+		if err != nil {
+			return nil, err
+		}
+		l.FilterChains = append(l.FilterChains, chain)
+	}
+
+	return l, nil
+}
+
+// makeSNIFilterChain returns a filter chain that proxies TCP connections
+// whose SNI matches serverName to the given cluster.
+func makeSNIFilterChain(statPrefix, serverName, cluster string) (envoylistener.FilterChain, error) {
+	tcpProxy, err := makeTCPProxyFilter(statPrefix, cluster)
+	if err != nil {
+		return envoylistener.FilterChain{}, err
+	}
+	return envoylistener.FilterChain{
+		FilterChainMatch: &envoylistener.FilterChainMatch{
+			ServerNames: []string{serverName},
+		},
+		Filters: []envoylistener.Filter{
+			tcpProxy,
+		},
+	}, nil
+}
+
 // makeListener returns a listener with name and bind details set. Filters must
 // be added before it's useful.
 //
@@ -67,7 +157,7 @@ func makeListener(name, addr string, port int) *envoy.Listener {
 // arbitrary proto3 json format string or an error if it's invalid.
 //
 // For now we only support embedding in JSON strings because of the hcl parsing
-// pain (see config.go comment above call to patchSliceOfMaps). Until we
+// pain (see config.go comment above call to PatchSliceOfMaps). Until we
 // refactor config parser a _lot_ user's opaque config that contains arrays will
 // be mangled. We could actually fix that up in mapstructure which knows the
 // type of the target so could resolve the slices to singletons unambiguously
@@ -176,7 +266,10 @@ func makePublicListener(cfgSnap *proxycfg.ConfigSnapshot, token string) (proto.M
 	return l, err
 }
 
-func makeUpstreamListener(u *structs.Upstream) (proto.Message, error) {
+// makeUpstreamListener returns the listener for a single upstream. Upstreams
+// whose discovery chain uses an L7 protocol get an HTTP connection manager
+// that fetches its routes via RDS, all others are proxied at the TCP level.
+func makeUpstreamListener(u *structs.Upstream, chain *proxycfg.DiscoveryChain, cfgSnap *proxycfg.ConfigSnapshot) (proto.Message, error) {
 	if listenerJSONRaw, ok := u.Config["envoy_listener_json"]; ok {
 		if listenerJSON, ok := listenerJSONRaw.(string); ok {
 			return makeListenerFromUserConfig(listenerJSON)
@@ -187,14 +280,27 @@ func makeUpstreamListener(u *structs.Upstream) (proto.Message, error) {
 		addr = "127.0.0.1"
 	}
 	l := makeListener(u.Identifier(), addr, u.LocalBindPort)
-	tcpProxy, err := makeTCPProxyFilter(u.Identifier(), u.Identifier())
+
+	var filter envoylistener.Filter
+	var err error
+	switch {
+	case chain != nil && chain.IsHTTP():
+		filter, err = makeHTTPConnectionManagerFilter(u.Identifier(), u.Identifier())
+	case chain != nil:
+		// The default target differs from the upstream's own cluster when a
+		// service-resolver sets a default subset, and traffic is sent to a
+		// failover cluster when the target has no healthy endpoints.
+		filter, err = makeTCPProxyFilter(u.Identifier(), activeCluster(chain, chain.DefaultTarget, cfgSnap))
+	default:
+		filter, err = makeTCPProxyFilter(u.Identifier(), u.Identifier())
+	}
 	if err != nil {
 		return l, err
 	}
 	l.FilterChains = []envoylistener.FilterChain{
 		{
 			Filters: []envoylistener.Filter{
-				tcpProxy,
+				filter,
 			},
 		},
 	}
@@ -209,6 +315,29 @@ func makeTCPProxyFilter(name, cluster string) (envoylistener.Filter, error) {
 	return makeFilter("envoy.tcp_proxy", cfg)
 }
 
+func makeHTTPConnectionManagerFilter(name, routeName string) (envoylistener.Filter, error) {
+	cfg := &envoyhttp.HttpConnectionManager{
+		StatPrefix: name,
+		RouteSpecifier: &envoyhttp.HttpConnectionManager_Rds{
+			Rds: &envoyhttp.Rds{
+				RouteConfigName: routeName,
+				ConfigSource: envoycore.ConfigSource{
+					ConfigSourceSpecifier: &envoycore.ConfigSource_Ads{
+						Ads: &envoycore.AggregatedConfigSource{},
+					},
+				},
+			},
+		},
+		HttpFilters: []*envoyhttp.HttpFilter{
+			&envoyhttp.HttpFilter{
+				Name: "envoy.router",
+			},
+		},
+	}
+
+	return makeFilter("envoy.http_connection_manager", cfg)
+}
+
 func makeExtAuthFilter(token string) (envoylistener.Filter, error) {
 	cfg := &extauthz.ExtAuthz{
 		StatPrefix: "connect_authz",
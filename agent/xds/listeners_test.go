@@ -0,0 +1,46 @@
+package xds
+
+import (
+	"testing"
+
+	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+)
+
+func TestListenersFromSnapshot_MeshGateway(t *testing.T) {
+	t.Parallel()
+
+	snap := proxycfg.TestConfigSnapshotMeshGateway(t)
+	trustDomain := snap.Roots.TrustDomain
+
+	listeners, err := listenersFromSnapshot(snap, "")
+	require.NoError(t, err)
+	require.Len(t, listeners, 1)
+
+	l := listeners[0].(*envoy.Listener)
+	require.Equal(t, "public_listener:1.2.3.4:8443", l.Name)
+	require.Len(t, l.ListenerFilters, 1)
+	require.Equal(t, "envoy.listener.tls_inspector", l.ListenerFilters[0].Name)
+
+	// Connections are routed on SNI without terminating TLS, to the local
+	// service or to the gateways of the remote datacenter.
+	localChain, err := makeSNIFilterChain("mesh_gateway_local.db",
+		"db.default.dc1.internal."+trustDomain, "db.default.dc1.internal."+trustDomain)
+	require.NoError(t, err)
+	remoteChain, err := makeSNIFilterChain("mesh_gateway_remote.dc2",
+		"*.dc2.internal."+trustDomain, "dc2.internal."+trustDomain)
+	require.NoError(t, err)
+	require.Len(t, l.FilterChains, 2)
+	require.Equal(t, localChain, l.FilterChains[0])
+	require.Equal(t, remoteChain, l.FilterChains[1])
+	for _, chain := range l.FilterChains {
+		require.Nil(t, chain.TlsContext)
+	}
+
+	// Gateways have no routes.
+	routes, err := routesFromSnapshot(snap, "")
+	require.NoError(t, err)
+	require.Empty(t, routes)
+}
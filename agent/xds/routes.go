@@ -2,10 +2,14 @@ package xds
 
 import (
 	"errors"
+	"strings"
 
+	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoyroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
 )
 
 // routesFromSnapshot returns the xDS API representation of the "routes"
@@ -14,6 +18,198 @@ func routesFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]proto
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
-	// We don't support routes yet but probably will later
-	return nil, nil
+
+	// One route configuration for each upstream that uses an L7 protocol. The
+	// name matches the one the upstream listener requests via RDS.
+	var resources []proto.Message
+	for _, u := range cfgSnap.Proxy.Upstreams {
+		chain := cfgSnap.UpstreamChains[u.Identifier()]
+		if chain == nil || !chain.IsHTTP() {
+			continue
+		}
+		resources = append(resources, makeRouteConfig(u.Identifier(), chain, cfgSnap))
+	}
+	return resources, nil
+}
+
+// makeRouteConfig returns the route configuration for a compiled discovery
+// chain. All requests are handled by a single virtual host.
+func makeRouteConfig(name string, chain *proxycfg.DiscoveryChain, cfgSnap *proxycfg.ConfigSnapshot) *envoy.RouteConfiguration {
+	routes := make([]envoyroute.Route, 0, len(chain.Routes))
+	for _, discoveryRoute := range chain.Routes {
+		routes = append(routes, makeRoute(discoveryRoute, chain, cfgSnap))
+	}
+
+	return &envoy.RouteConfiguration{
+		Name: name,
+		VirtualHosts: []envoyroute.VirtualHost{
+			envoyroute.VirtualHost{
+				Name:    name,
+				Domains: []string{"*"},
+				Routes:  routes,
+			},
+		},
+		// ValidateClusters defaults to true when defined statically and false
+		// when done via RDS. Re-set the sane value of true to prevent
+		// null-routing traffic.
+		ValidateClusters: makeBoolValue(true),
+	}
+}
+
+func makeRoute(discoveryRoute proxycfg.DiscoveryRoute, chain *proxycfg.DiscoveryChain, cfgSnap *proxycfg.ConfigSnapshot) envoyroute.Route {
+	action := &envoyroute.RouteAction{}
+	if len(discoveryRoute.Splits) > 0 {
+		action.ClusterSpecifier = &envoyroute.RouteAction_WeightedClusters{
+			WeightedClusters: makeWeightedClusters(discoveryRoute.Splits, chain, cfgSnap),
+		}
+	} else {
+		action.ClusterSpecifier = &envoyroute.RouteAction_Cluster{
+			Cluster: activeCluster(chain, discoveryRoute.Target, cfgSnap),
+		}
+	}
+
+	var match envoyroute.RouteMatch
+	if def := discoveryRoute.Definition; def != nil {
+		match = makeRouteMatch(def.Match)
+		if dest := def.Destination; dest != nil {
+			if dest.PrefixRewrite != "" {
+				action.PrefixRewrite = dest.PrefixRewrite
+			}
+			if dest.RequestTimeout > 0 {
+				timeout := dest.RequestTimeout
+				action.Timeout = &timeout
+			}
+			if dest.NumRetries > 0 {
+				retryOn := "5xx"
+				if len(dest.RetryOn) > 0 {
+					retryOn = strings.Join(dest.RetryOn, ",")
+				}
+				action.RetryPolicy = &envoyroute.RouteAction_RetryPolicy{
+					RetryOn:    retryOn,
+					NumRetries: makeUint32Value(int(dest.NumRetries)),
+				}
+			}
+		}
+	} else {
+		match = makeDefaultRouteMatch()
+	}
+
+	return envoyroute.Route{
+		Match: match,
+		Action: &envoyroute.Route_Route{
+			Route: action,
+		},
+	}
+}
+
+// makeWeightedClusters converts the splits of a route into Envoy weighted
+// clusters. Split weights are percentages with two decimal places so they are
+// scaled to a total weight of 10000.
+func makeWeightedClusters(splits []proxycfg.DiscoverySplit, chain *proxycfg.DiscoveryChain, cfgSnap *proxycfg.ConfigSnapshot) *envoyroute.WeightedCluster {
+	wc := &envoyroute.WeightedCluster{
+		TotalWeight: makeUint32Value(100 * 100),
+	}
+	for _, split := range splits {
+		weight := structs.ScaleServiceSplitWeight(split.Weight)
+		if weight == 0 {
+			continue
+		}
+		wc.Clusters = append(wc.Clusters, &envoyroute.WeightedCluster_ClusterWeight{
+			Name:   activeCluster(chain, split.Target, cfgSnap),
+			Weight: makeUint32Value(weight),
+		})
+	}
+	return wc
+}
+
+func makeDefaultRouteMatch() envoyroute.RouteMatch {
+	return envoyroute.RouteMatch{
+		PathSpecifier: &envoyroute.RouteMatch_Prefix{
+			Prefix: "/",
+		},
+	}
+}
+
+func makeRouteMatch(routeMatch *structs.ServiceRouteMatch) envoyroute.RouteMatch {
+	if routeMatch == nil || routeMatch.HTTP == nil {
+		return makeDefaultRouteMatch()
+	}
+	match := routeMatch.HTTP
+
+	var em envoyroute.RouteMatch
+	switch {
+	case match.PathExact != "":
+		em.PathSpecifier = &envoyroute.RouteMatch_Path{
+			Path: match.PathExact,
+		}
+	case match.PathPrefix != "":
+		em.PathSpecifier = &envoyroute.RouteMatch_Prefix{
+			Prefix: match.PathPrefix,
+		}
+	case match.PathRegex != "":
+		em.PathSpecifier = &envoyroute.RouteMatch_Regex{
+			Regex: match.PathRegex,
+		}
+	default:
+		em.PathSpecifier = &envoyroute.RouteMatch_Prefix{
+			Prefix: "/",
+		}
+	}
+
+	for _, hdr := range match.Header {
+		eh := &envoyroute.HeaderMatcher{
+			Name:        hdr.Name,
+			InvertMatch: hdr.Invert,
+		}
+		switch {
+		case hdr.Exact != "":
+			eh.HeaderMatchSpecifier = &envoyroute.HeaderMatcher_ExactMatch{
+				ExactMatch: hdr.Exact,
+			}
+		case hdr.Regex != "":
+			eh.HeaderMatchSpecifier = &envoyroute.HeaderMatcher_RegexMatch{
+				RegexMatch: hdr.Regex,
+			}
+		case hdr.Prefix != "":
+			eh.HeaderMatchSpecifier = &envoyroute.HeaderMatcher_PrefixMatch{
+				PrefixMatch: hdr.Prefix,
+			}
+		case hdr.Suffix != "":
+			eh.HeaderMatchSpecifier = &envoyroute.HeaderMatcher_SuffixMatch{
+				SuffixMatch: hdr.Suffix,
+			}
+		default:
+			eh.HeaderMatchSpecifier = &envoyroute.HeaderMatcher_PresentMatch{
+				PresentMatch: true,
+			}
+		}
+		em.Headers = append(em.Headers, eh)
+	}
+
+	if len(match.Methods) > 0 {
+		em.Headers = append(em.Headers, &envoyroute.HeaderMatcher{
+			Name: ":method",
+			HeaderMatchSpecifier: &envoyroute.HeaderMatcher_RegexMatch{
+				RegexMatch: strings.Join(match.Methods, "|"),
+			},
+		})
+	}
+
+	for _, qm := range match.QueryParam {
+		// A matcher without a value matches as long as the parameter is
+		// present.
+		eq := &envoyroute.QueryParameterMatcher{
+			Name: qm.Name,
+		}
+		switch {
+		case qm.Exact != "":
+			eq.Value = qm.Exact
+		case qm.Regex != "":
+			eq.Value = qm.Regex
+			eq.Regex = makeBoolValue(true)
+		}
+		em.QueryParameters = append(em.QueryParameters, eq)
+	}
+
+	return em
 }
@@ -0,0 +1,307 @@
+package xds
+
+import (
+	"testing"
+	"time"
+
+	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoylistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	envoyroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestRoutesFromSnapshot(t *testing.T) {
+	t.Parallel()
+
+	t.Run("tcp upstreams have no routes", func(t *testing.T) {
+		snap := proxycfg.TestConfigSnapshot(t)
+		routes, err := routesFromSnapshot(snap, "")
+		require.NoError(t, err)
+		require.Empty(t, routes)
+	})
+
+	t.Run("http upstream without router", func(t *testing.T) {
+		snap := proxycfg.TestConfigSnapshotWithEntries(t,
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+		)
+		routes, err := routesFromSnapshot(snap, "")
+		require.NoError(t, err)
+		require.Len(t, routes, 1)
+
+		rc := routes[0].(*envoy.RouteConfiguration)
+		require.Equal(t, "service:db", rc.Name)
+		require.Len(t, rc.VirtualHosts, 1)
+		require.Equal(t, []string{"*"}, rc.VirtualHosts[0].Domains)
+		require.Equal(t, []envoyroute.Route{
+			{
+				Match: makeDefaultRouteMatch(),
+				Action: &envoyroute.Route_Route{
+					Route: &envoyroute.RouteAction{
+						ClusterSpecifier: &envoyroute.RouteAction_Cluster{
+							Cluster: "service:db",
+						},
+					},
+				},
+			},
+		}, rc.VirtualHosts[0].Routes)
+	})
+
+	t.Run("router is ignored for tcp upstreams", func(t *testing.T) {
+		snap := proxycfg.TestConfigSnapshotWithEntries(t,
+			&structs.ServiceRouterConfigEntry{
+				Name: "db",
+				Routes: []structs.ServiceRoute{
+					{
+						Destination: &structs.ServiceRouteDestination{Service: "admin"},
+					},
+				},
+			},
+		)
+		routes, err := routesFromSnapshot(snap, "")
+		require.NoError(t, err)
+		require.Empty(t, routes)
+	})
+
+	t.Run("http upstream with router", func(t *testing.T) {
+		snap := proxycfg.TestConfigSnapshotWithEntries(t,
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+			&structs.ServiceRouterConfigEntry{
+				Name: "db",
+				Routes: []structs.ServiceRoute{
+					{
+						Match: &structs.ServiceRouteMatch{
+							HTTP: &structs.ServiceRouteHTTPMatch{
+								PathPrefix: "/admin",
+								Header: []structs.ServiceRouteHTTPMatchHeader{
+									{Name: "x-debug", Present: true},
+									{Name: "x-env", Exact: "prod", Invert: true},
+								},
+								QueryParam: []structs.ServiceRouteHTTPMatchQueryParam{
+									{Name: "version", Regex: "v[0-9]+"},
+								},
+								Methods: []string{"GET", "HEAD"},
+							},
+						},
+						Destination: &structs.ServiceRouteDestination{
+							Service:        "admin",
+							PrefixRewrite:  "/",
+							RequestTimeout: 10 * time.Second,
+							NumRetries:     3,
+						},
+					},
+					{
+						Match: &structs.ServiceRouteMatch{
+							HTTP: &structs.ServiceRouteHTTPMatch{
+								PathExact: "/health",
+							},
+						},
+						Destination: &structs.ServiceRouteDestination{
+							ServiceSubset: "v2",
+							NumRetries:    1,
+							RetryOn:       []string{"connect-failure", "refused-stream"},
+						},
+					},
+				},
+			},
+			&structs.ServiceResolverConfigEntry{
+				Name: "db",
+				Subsets: map[string]structs.ServiceResolverSubset{
+					"v2": {Filter: "Service.Meta.version == v2"},
+				},
+			},
+		)
+		routes, err := routesFromSnapshot(snap, "")
+		require.NoError(t, err)
+		require.Len(t, routes, 1)
+
+		rc := routes[0].(*envoy.RouteConfiguration)
+		require.Equal(t, "service:db", rc.Name)
+		envoyRoutes := rc.VirtualHosts[0].Routes
+		require.Len(t, envoyRoutes, 3)
+
+		// Route to a different service.
+		timeout := 10 * time.Second
+		require.Equal(t, envoyroute.Route{
+			Match: envoyroute.RouteMatch{
+				PathSpecifier: &envoyroute.RouteMatch_Prefix{Prefix: "/admin"},
+				Headers: []*envoyroute.HeaderMatcher{
+					{
+						Name:                 "x-debug",
+						HeaderMatchSpecifier: &envoyroute.HeaderMatcher_PresentMatch{PresentMatch: true},
+					},
+					{
+						Name:                 "x-env",
+						HeaderMatchSpecifier: &envoyroute.HeaderMatcher_ExactMatch{ExactMatch: "prod"},
+						InvertMatch:          true,
+					},
+					{
+						Name:                 ":method",
+						HeaderMatchSpecifier: &envoyroute.HeaderMatcher_RegexMatch{RegexMatch: "GET|HEAD"},
+					},
+				},
+				QueryParameters: []*envoyroute.QueryParameterMatcher{
+					{Name: "version", Value: "v[0-9]+", Regex: makeBoolValue(true)},
+				},
+			},
+			Action: &envoyroute.Route_Route{
+				Route: &envoyroute.RouteAction{
+					ClusterSpecifier: &envoyroute.RouteAction_Cluster{Cluster: "service:admin"},
+					PrefixRewrite:    "/",
+					Timeout:          &timeout,
+					RetryPolicy: &envoyroute.RouteAction_RetryPolicy{
+						RetryOn:    "5xx",
+						NumRetries: makeUint32Value(3),
+					},
+				},
+			},
+		}, envoyRoutes[0])
+
+		// Route to a subset of the same service.
+		require.Equal(t, envoyroute.Route{
+			Match: envoyroute.RouteMatch{
+				PathSpecifier: &envoyroute.RouteMatch_Path{Path: "/health"},
+			},
+			Action: &envoyroute.Route_Route{
+				Route: &envoyroute.RouteAction{
+					ClusterSpecifier: &envoyroute.RouteAction_Cluster{Cluster: "service:db?subset=v2"},
+					RetryPolicy: &envoyroute.RouteAction_RetryPolicy{
+						RetryOn:    "connect-failure,refused-stream",
+						NumRetries: makeUint32Value(1),
+					},
+				},
+			},
+		}, envoyRoutes[1])
+
+		// Catch-all route.
+		require.Equal(t, &envoyroute.RouteAction_Cluster{Cluster: "service:db"},
+			envoyRoutes[2].Action.(*envoyroute.Route_Route).Route.ClusterSpecifier)
+
+		// Each destination gets a cluster and endpoints.
+		clusters, err := clustersFromSnapshot(snap, "")
+		require.NoError(t, err)
+		var names []string
+		for _, c := range clusters {
+			names = append(names, c.(*envoy.Cluster).Name)
+		}
+		require.Equal(t, []string{
+			"local_app",
+			"service:db",
+			"prepared_query:geo-cache",
+			"service:admin",
+			"service:db?subset=v2",
+		}, names)
+
+		endpoints, err := endpointsFromSnapshot(snap, "")
+		require.NoError(t, err)
+		require.Len(t, endpoints, 3)
+
+		// The upstream listener uses the HTTP connection manager with RDS.
+		listeners, err := listenersFromSnapshot(snap, "")
+		require.NoError(t, err)
+		l := listeners[1].(*envoy.Listener)
+		require.Equal(t, "service:db:127.0.0.1:9191", l.Name)
+		hcm, err := makeHTTPConnectionManagerFilter("service:db", "service:db")
+		require.NoError(t, err)
+		require.Equal(t, "envoy.http_connection_manager", hcm.Name)
+		require.Equal(t, []envoylistener.Filter{hcm}, l.FilterChains[0].Filters)
+	})
+
+	t.Run("http upstream with splitter", func(t *testing.T) {
+		snap := proxycfg.TestConfigSnapshotWithEntries(t,
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+			&structs.ServiceSplitterConfigEntry{
+				Name: "db",
+				Splits: []structs.ServiceSplit{
+					{Weight: 89.99, ServiceSubset: "v1"},
+					{Weight: 10.01, ServiceSubset: "v2"},
+					{Weight: 0, ServiceSubset: "v3"},
+				},
+			},
+			&structs.ServiceResolverConfigEntry{
+				Name: "db",
+				Subsets: map[string]structs.ServiceResolverSubset{
+					"v1": {Filter: "Service.Meta.version == v1"},
+					"v2": {Filter: "Service.Meta.version == v2"},
+					"v3": {Filter: "Service.Meta.version == v3"},
+				},
+			},
+		)
+		routes, err := routesFromSnapshot(snap, "")
+		require.NoError(t, err)
+		require.Len(t, routes, 1)
+
+		rc := routes[0].(*envoy.RouteConfiguration)
+		require.Equal(t, []envoyroute.Route{
+			{
+				Match: makeDefaultRouteMatch(),
+				Action: &envoyroute.Route_Route{
+					Route: &envoyroute.RouteAction{
+						ClusterSpecifier: &envoyroute.RouteAction_WeightedClusters{
+							WeightedClusters: &envoyroute.WeightedCluster{
+								Clusters: []*envoyroute.WeightedCluster_ClusterWeight{
+									{Name: "service:db?subset=v1", Weight: makeUint32Value(8999)},
+									{Name: "service:db?subset=v2", Weight: makeUint32Value(1001)},
+								},
+								TotalWeight: makeUint32Value(10000),
+							},
+						},
+					},
+				},
+			},
+		}, rc.VirtualHosts[0].Routes)
+
+		// Every split gets a cluster.
+		clusters, err := clustersFromSnapshot(snap, "")
+		require.NoError(t, err)
+		var names []string
+		for _, c := range clusters {
+			names = append(names, c.(*envoy.Cluster).Name)
+		}
+		require.Equal(t, []string{
+			"local_app",
+			"service:db",
+			"prepared_query:geo-cache",
+			"service:db?subset=v1",
+			"service:db?subset=v2",
+			"service:db?subset=v3",
+		}, names)
+	})
+
+	t.Run("http2 upstream clusters", func(t *testing.T) {
+		snap := proxycfg.TestConfigSnapshotWithEntries(t,
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "grpc"},
+		)
+		clusters, err := clustersFromSnapshot(snap, "")
+		require.NoError(t, err)
+		require.NotNil(t, clusters[1].(*envoy.Cluster).Http2ProtocolOptions)
+		require.Nil(t, clusters[2].(*envoy.Cluster).Http2ProtocolOptions)
+	})
+
+	t.Run("routed clusters use the protocol of their service", func(t *testing.T) {
+		snap := proxycfg.TestConfigSnapshotWithEntries(t,
+			&structs.ServiceConfigEntry{Name: "db", Protocol: "http"},
+			&structs.ServiceConfigEntry{Name: "admin", Protocol: "grpc"},
+			&structs.ServiceRouterConfigEntry{
+				Name: "db",
+				Routes: []structs.ServiceRoute{
+					{
+						Match: &structs.ServiceRouteMatch{
+							HTTP: &structs.ServiceRouteHTTPMatch{PathPrefix: "/admin"},
+						},
+						Destination: &structs.ServiceRouteDestination{Service: "admin"},
+					},
+				},
+			},
+		)
+		clusters, err := clustersFromSnapshot(snap, "")
+		require.NoError(t, err)
+		require.Len(t, clusters, 4)
+		require.Equal(t, "service:db", clusters[1].(*envoy.Cluster).Name)
+		require.Nil(t, clusters[1].(*envoy.Cluster).Http2ProtocolOptions)
+		require.Equal(t, "service:admin", clusters[3].(*envoy.Cluster).Name)
+		require.NotNil(t, clusters[3].(*envoy.Cluster).Http2ProtocolOptions)
+	})
+}
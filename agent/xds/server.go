@@ -214,7 +214,13 @@ func (s *Server) process(stream ADSStream, reqCh <-chan *envoy.DiscoveryRequest)
 			return err
 		}
 
-		if rule != nil && !rule.ServiceWrite(cfgSnap.Proxy.DestinationServiceName, nil) {
+		// Proxies need write access to the service they represent, gateways to
+		// their own service.
+		service := cfgSnap.Proxy.DestinationServiceName
+		if cfgSnap.Kind == structs.ServiceKindMeshGateway {
+			service = cfgSnap.Service
+		}
+		if rule != nil && !rule.ServiceWrite(service, nil) {
 			return status.Errorf(codes.PermissionDenied, "permission denied")
 		}
 
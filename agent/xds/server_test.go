@@ -393,7 +393,7 @@ func expectClustersJSONResources(t *testing.T, snap *proxycfg.ConfigSnapshot, to
 
 				},
 				"connectTimeout": "1s",
-				"tlsContext": ` + expectedUpstreamTLSContextJSON(t, snap) + `
+				"tlsContext": ` + expectedUpstreamTLSContextJSON(t, snap, "db.default.dc1.internal."+snap.Roots.TrustDomain) + `
 			}`,
 		"prepared_query:geo-cache": `
 			{
@@ -411,7 +411,7 @@ func expectClustersJSONResources(t *testing.T, snap *proxycfg.ConfigSnapshot, to
 
 				},
 				"connectTimeout": "5s",
-				"tlsContext": ` + expectedUpstreamTLSContextJSON(t, snap) + `
+				"tlsContext": ` + expectedUpstreamTLSContextJSON(t, snap, "") + `
 			}`,
 	}
 }
@@ -493,15 +493,15 @@ func expectEndpointsJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, token stri
 	}`
 }
 
-func expectedUpstreamTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot) string {
-	return expectedTLSContextJSON(t, snap, false)
+func expectedUpstreamTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, sni string) string {
+	return expectedTLSContextJSON(t, snap, false, sni)
 }
 
 func expectedPublicTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot) string {
-	return expectedTLSContextJSON(t, snap, true)
+	return expectedTLSContextJSON(t, snap, true, "")
 }
 
-func expectedTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, requireClientCert bool) string {
+func expectedTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, requireClientCert bool, sni string) string {
 	// Assume just one root for now, can get fancier later if needed.
 	caPEM := snap.Roots.Roots[0].RootCert
 	reqClient := ""
@@ -509,6 +509,10 @@ func expectedTLSContextJSON(t *testing.T, snap *proxycfg.ConfigSnapshot, require
 		reqClient = `,
 		"requireClientCertificate": true`
 	}
+	if sni != "" {
+		reqClient += `,
+		"sni": "` + sni + `"`
+	}
 	return `{
 		"commonTlsContext": {
 			"tlsParams": {},
@@ -1227,7 +1231,7 @@ func TestServer_ConfigOverridesClusters(t *testing.T) {
 					customEDSClusterJSON(t, customClusterJSONOptions{
 						Name:        "myservice",
 						IncludeType: true,
-						TLSContext:  expectedUpstreamTLSContextJSON(t, snap),
+						TLSContext:  expectedUpstreamTLSContextJSON(t, snap, "db.default.dc1.internal."+snap.Roots.TrustDomain),
 					})
 				return expectClustersJSONFromResources(t, snap, "my-token", 1, 1, resources)
 			},
@@ -1248,7 +1252,7 @@ func TestServer_ConfigOverridesClusters(t *testing.T) {
 					customEDSClusterJSON(t, customClusterJSONOptions{
 						Name:        "myservice",
 						IncludeType: true,
-						TLSContext:  expectedUpstreamTLSContextJSON(t, snap),
+						TLSContext:  expectedUpstreamTLSContextJSON(t, snap, "db.default.dc1.internal."+snap.Roots.TrustDomain),
 					})
 				return expectClustersJSONFromResources(t, snap, "my-token", 1, 1, resources)
 			},
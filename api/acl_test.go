@@ -3,6 +3,7 @@ package api
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 
@@ -376,6 +377,129 @@ func prepTokenPolicies(t *testing.T, acl *ACL) (policies []*ACLPolicy) {
 	return
 }
 
+func TestAPI_ACLRole_CreateReadDelete(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	policy, _, err := acl.PolicyCreate(&ACLPolicy{
+		Name:  "node-read",
+		Rules: `node_prefix "" { policy = "read" }`,
+	}, nil)
+	require.NoError(t, err)
+
+	created, wm, err := acl.RoleCreate(&ACLRole{
+		Name:        "test-role",
+		Description: "test-role description",
+		Policies: []*ACLRolePolicyLink{
+			&ACLRolePolicyLink{
+				Name: policy.Name,
+			},
+		},
+	}, nil)
+
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	require.NotEqual(t, "", created.ID)
+	require.NotEqual(t, 0, wm.RequestTime)
+	require.Len(t, created.Policies, 1)
+	require.Equal(t, policy.ID, created.Policies[0].ID)
+
+	read, qm, err := acl.RoleRead(created.ID, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, 0, qm.LastIndex)
+	require.True(t, qm.KnownLeader)
+	require.Equal(t, created, read)
+
+	read, _, err = acl.RoleReadByName(created.Name, nil)
+	require.NoError(t, err)
+	require.Equal(t, created, read)
+
+	wm, err = acl.RoleDelete(created.ID, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, 0, wm.RequestTime)
+
+	read, _, err = acl.RoleRead(created.ID, nil)
+	require.Nil(t, read)
+	require.Error(t, err)
+}
+
+func TestAPI_ACLRole_CreateUpdate(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	policy, _, err := acl.PolicyCreate(&ACLPolicy{
+		Name:  "node-read",
+		Rules: `node_prefix "" { policy = "read" }`,
+	}, nil)
+	require.NoError(t, err)
+
+	created, _, err := acl.RoleCreate(&ACLRole{
+		Name:        "test-role",
+		Description: "test-role description",
+	}, nil)
+
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	require.NotEqual(t, "", created.ID)
+
+	read, _, err := acl.RoleRead(created.ID, nil)
+	require.NoError(t, err)
+	require.Equal(t, created, read)
+
+	read.Policies = append(read.Policies, &ACLRolePolicyLink{ID: policy.ID})
+
+	updated, wm, err := acl.RoleUpdate(read, nil)
+	require.NoError(t, err)
+	require.Equal(t, created.ID, updated.ID)
+	require.Equal(t, created.Description, updated.Description)
+	require.Len(t, updated.Policies, 1)
+	require.Equal(t, policy.Name, updated.Policies[0].Name)
+	require.Equal(t, created.CreateIndex, updated.CreateIndex)
+	require.NotEqual(t, created.ModifyIndex, updated.ModifyIndex)
+	require.NotEqual(t, 0, wm.RequestTime)
+
+	updated_read, _, err := acl.RoleRead(created.ID, nil)
+	require.NoError(t, err)
+	require.Equal(t, updated, updated_read)
+}
+
+func TestAPI_ACLRole_List(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	created1, _, err := acl.RoleCreate(&ACLRole{
+		Name: "role1",
+	}, nil)
+	require.NoError(t, err)
+
+	created2, _, err := acl.RoleCreate(&ACLRole{
+		Name: "role2",
+	}, nil)
+	require.NoError(t, err)
+
+	roles, qm, err := acl.RoleList(nil)
+	require.NoError(t, err)
+	require.Len(t, roles, 2)
+	require.NotEqual(t, 0, qm.LastIndex)
+	require.True(t, qm.KnownLeader)
+
+	roleMap := make(map[string]*ACLRole)
+	for _, role := range roles {
+		roleMap[role.ID] = role
+	}
+	require.Equal(t, created1, roleMap[created1.ID])
+	require.Equal(t, created2, roleMap[created2.ID])
+}
+
 func TestAPI_ACLToken_CreateReadDelete(t *testing.T) {
 	t.Parallel()
 	c, s := makeACLClient(t)
@@ -429,6 +553,87 @@ func TestAPI_ACLToken_CreateReadDelete(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestAPI_ACLToken_Expiration(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	created, _, err := acl.TokenCreate(&ACLToken{
+		Description:   "expiring token",
+		ExpirationTTL: time.Hour,
+		ServiceIdentities: []*ACLServiceIdentity{
+			&ACLServiceIdentity{
+				ServiceName: "web",
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, created.ExpirationTime)
+	require.Equal(t, time.Duration(0), created.ExpirationTTL)
+	require.True(t, created.CreateTime.Add(time.Hour).Equal(*created.ExpirationTime))
+
+	read, _, err := acl.TokenRead(created.AccessorID, nil)
+	require.NoError(t, err)
+	require.NotNil(t, read.ExpirationTime)
+	require.True(t, created.ExpirationTime.Equal(*read.ExpirationTime))
+
+	tokens, _, err := acl.TokenList(nil)
+	require.NoError(t, err)
+	found := false
+	for _, token := range tokens {
+		if token.AccessorID == created.AccessorID {
+			require.NotNil(t, token.ExpirationTime)
+			require.True(t, created.ExpirationTime.Equal(*token.ExpirationTime))
+			found = true
+		}
+	}
+	require.True(t, found)
+}
+
+func TestAPI_ACLToken_ServiceIdentities(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	created, _, err := acl.TokenCreate(&ACLToken{
+		Description: "token created",
+		ServiceIdentities: []*ACLServiceIdentity{
+			&ACLServiceIdentity{
+				ServiceName: "web",
+			},
+			&ACLServiceIdentity{
+				ServiceName: "db",
+				Datacenters: []string{"dc1"},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	require.Equal(t, []*ACLServiceIdentity{
+		&ACLServiceIdentity{ServiceName: "web"},
+		&ACLServiceIdentity{ServiceName: "db", Datacenters: []string{"dc1"}},
+	}, created.ServiceIdentities)
+
+	read, _, err := acl.TokenRead(created.AccessorID, nil)
+	require.NoError(t, err)
+	require.Equal(t, created, read)
+
+	tokens, _, err := acl.TokenList(nil)
+	require.NoError(t, err)
+	found := false
+	for _, token := range tokens {
+		if token.AccessorID == created.AccessorID {
+			found = true
+			require.Equal(t, created.ServiceIdentities, token.ServiceIdentities)
+		}
+	}
+	require.True(t, found)
+}
+
 func TestAPI_ACLToken_CreateUpdate(t *testing.T) {
 	t.Parallel()
 	c, s := makeACLClient(t)
@@ -725,3 +930,87 @@ query_prefix "" {
 	require.NoError(t, err)
 	require.Equal(t, expected, rules)
 }
+
+func TestAPI_ACLAuthMethod_CreateUpdateDelete(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	created, wm, err := acl.AuthMethodCreate(&ACLAuthMethod{
+		Name:        "test",
+		Type:        "testing",
+		Description: "test description",
+		Config: map[string]interface{}{
+			"SessionID": "4f64b8e1-4f86-4ec2-8fd3-4f0cd0f0a1b6",
+			"Fields":    []string{"service"},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	require.NotEqual(t, 0, wm.RequestTime)
+	require.Equal(t, "4f64b8e1-4f86-4ec2-8fd3-4f0cd0f0a1b6", created.Config["SessionID"])
+
+	read, qm, err := acl.AuthMethodRead("test", nil)
+	require.NoError(t, err)
+	require.NotEqual(t, 0, qm.LastIndex)
+	require.True(t, qm.KnownLeader)
+	require.Equal(t, created, read)
+
+	created.Description = "modified"
+	updated, _, err := acl.AuthMethodUpdate(created, nil)
+	require.NoError(t, err)
+	require.Equal(t, "modified", updated.Description)
+	require.Equal(t, created.CreateIndex, updated.CreateIndex)
+
+	methods, _, err := acl.AuthMethodList(nil)
+	require.NoError(t, err)
+	require.Len(t, methods, 1)
+	require.Equal(t, "test", methods[0].Name)
+	require.Equal(t, "testing", methods[0].Type)
+
+	rule, _, err := acl.BindingRuleCreate(&ACLBindingRule{
+		AuthMethod: "test",
+		Selector:   "value.service == web",
+		BindType:   BindingRuleBindTypeService,
+		BindName:   "${value.service}",
+	}, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, "", rule.ID)
+
+	rule.BindName = "web"
+	rule, _, err = acl.BindingRuleUpdate(rule, nil)
+	require.NoError(t, err)
+	require.Equal(t, "web", rule.BindName)
+
+	readRule, _, err := acl.BindingRuleRead(rule.ID, nil)
+	require.NoError(t, err)
+	require.Equal(t, rule, readRule)
+
+	rules, _, err := acl.BindingRuleList("test", nil)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, rule.ID, rules[0].ID)
+
+	rules, _, err = acl.BindingRuleList("not-found", nil)
+	require.NoError(t, err)
+	require.Len(t, rules, 0)
+
+	// The bearer token is unknown to the fake auth method
+	_, _, err = acl.Login(&ACLLoginParams{
+		AuthMethod:  "test",
+		BearerToken: "fake-token",
+	}, nil)
+	require.Error(t, err)
+
+	_, err = acl.BindingRuleDelete(rule.ID, nil)
+	require.NoError(t, err)
+
+	_, err = acl.AuthMethodDelete("test", nil)
+	require.NoError(t, err)
+
+	read, _, err = acl.AuthMethodRead("test", nil)
+	require.Nil(t, read)
+	require.Error(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // ServiceKind is the kind of service being registered.
@@ -23,6 +24,11 @@ const (
 	// service proxies another service within Consul and speaks the connect
 	// protocol.
 	ServiceKindConnectProxy ServiceKind = "connect-proxy"
+
+	// ServiceKindMeshGateway is a Mesh Gateway for the Connect feature. This
+	// service will proxy connections based off the SNI header set by other
+	// connect proxies so that traffic can reach services in other datacenters.
+	ServiceKindMeshGateway ServiceKind = "mesh-gateway"
 )
 
 // ProxyExecMode is the execution mode for a managed Connect proxy.
@@ -54,6 +60,33 @@ const (
 	UpstreamDestTypePreparedQuery UpstreamDestType = "prepared_query"
 )
 
+// MeshGatewayMode controls how a proxy reaches an upstream in another
+// datacenter.
+type MeshGatewayMode string
+
+const (
+	// MeshGatewayModeDefault connects directly to the upstream's instances.
+	MeshGatewayModeDefault MeshGatewayMode = ""
+
+	// MeshGatewayModeNone connects directly to the upstream's instances.
+	MeshGatewayModeNone MeshGatewayMode = "none"
+
+	// MeshGatewayModeLocal sends the traffic to a mesh gateway in the local
+	// datacenter.
+	MeshGatewayModeLocal MeshGatewayMode = "local"
+
+	// MeshGatewayModeRemote sends the traffic to a mesh gateway in the
+	// upstream's datacenter.
+	MeshGatewayModeRemote MeshGatewayMode = "remote"
+)
+
+// MeshGatewayConfig controls how Mesh Gateways are used for upstream Connect
+// services.
+type MeshGatewayConfig struct {
+	// Mode is the mode that should be used for the upstream connection.
+	Mode MeshGatewayMode `json:",omitempty"`
+}
+
 // AgentCheck represents a check known to the agent
 type AgentCheck struct {
 	Node        string
@@ -197,14 +230,28 @@ type AgentServiceCheck struct {
 	Header            map[string][]string `json:",omitempty"`
 	Method            string              `json:",omitempty"`
 	TCP               string              `json:",omitempty"`
+	UDP               string              `json:",omitempty"`
+	UDPPayload        string              `json:",omitempty"`
+	H2C               string              `json:",omitempty"`
+	OSService         string              `json:",omitempty"`
 	Status            string              `json:",omitempty"`
 	Notes             string              `json:",omitempty"`
 	TLSSkipVerify     bool                `json:",omitempty"`
+	TLSServerName     string              `json:",omitempty"`
+	CACert            string              `json:",omitempty"`
+	ClientCert        string              `json:",omitempty"`
+	ClientKey         string              `json:",omitempty"`
+	UseConnectCert    bool                `json:",omitempty"`
 	GRPC              string              `json:",omitempty"`
 	GRPCUseTLS        bool                `json:",omitempty"`
 	AliasNode         string              `json:",omitempty"`
 	AliasService      string              `json:",omitempty"`
 
+	// SuccessBeforePassing and FailuresBeforeCritical are the number of
+	// consecutive results needed to change the status of the check.
+	SuccessBeforePassing   int `json:",omitempty"`
+	FailuresBeforeCritical int `json:",omitempty"`
+
 	// In Consul 0.7 and later, checks that are associated with a service
 	// may also contain this optional DeregisterCriticalServiceAfter field,
 	// which is a timeout in the same Go time format as Interval and TTL. If
@@ -256,6 +303,42 @@ type SampledValue struct {
 	Labels map[string]string
 }
 
+// AgentCacheInfo is the response structure for the agent cache contents.
+type AgentCacheInfo struct {
+	Count      int
+	Bytes      int64
+	MaxEntries int
+	MaxBytes   int64
+	Types      []AgentCacheType
+	Entries    []AgentCacheEntry
+}
+
+// AgentCacheType holds the counters for a single agent cache type.
+type AgentCacheType struct {
+	Name        string
+	Count       int
+	Bytes       int64
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+}
+
+// AgentCacheEntry describes a single agent cache entry.
+type AgentCacheEntry struct {
+	Type       string
+	Datacenter string
+	Key        string
+	Index      uint64
+	Size       int64
+	Valid      bool
+	Fetching   bool
+	FetchedAt  time.Time
+	Age        time.Duration
+	Expires    time.Time
+	Error      string
+}
+
 // AgentAuthorizeParams are the request parameters for authorizing a request.
 type AgentAuthorizeParams struct {
 	Target           string
@@ -293,6 +376,7 @@ type Upstream struct {
 	LocalBindAddress     string                 `json:",omitempty"`
 	LocalBindPort        int                    `json:",omitempty"`
 	Config               map[string]interface{} `json:",omitempty" bexpr:"-"`
+	MeshGateway          MeshGatewayConfig
 }
 
 // Agent can be used to query the Agent endpoints
@@ -360,6 +444,23 @@ func (a *Agent) Metrics() (*MetricsInfo, error) {
 	return out, nil
 }
 
+// Cache is used to list the entries in the agent cache and the statistics of
+// each cache type, for debugging.
+func (a *Agent) Cache() (*AgentCacheInfo, error) {
+	r := a.c.newRequest("GET", "/v1/agent/cache")
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out *AgentCacheInfo
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Reload triggers a configuration reload for the agent we are connected to.
 func (a *Agent) Reload() error {
 	r := a.c.newRequest("PUT", "/v1/agent/reload")
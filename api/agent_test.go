@@ -79,6 +79,33 @@ func TestAPI_AgentHost(t *testing.T) {
 	})
 }
 
+func TestAPI_AgentCache(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+	s.WaitForSerfCheck(t)
+
+	// Populate the cache
+	_, _, err := agent.ConnectCARoots(nil)
+	require.NoError(t, err)
+
+	info, err := agent.Cache()
+	require.NoError(t, err)
+	require.NotEmpty(t, info.Types)
+
+	found := false
+	for _, entry := range info.Entries {
+		if entry.Type == "connect-ca-root" {
+			found = true
+			require.True(t, entry.Valid)
+			require.NotZero(t, entry.Index)
+		}
+	}
+	require.True(t, found, "missing connect-ca-root entry")
+}
+
 func TestAPI_AgentReload(t *testing.T) {
 	t.Parallel()
 
@@ -1550,7 +1577,7 @@ func TestAPI_AgentConnectProxyConfig(t *testing.T) {
 		ProxyServiceID:    "foo-proxy",
 		TargetServiceID:   "foo",
 		TargetServiceName: "foo",
-		ContentHash:       "acdf5eb6f5794a14",
+		ContentHash:       "b58a7e24130d3058",
 		ExecMode:          "daemon",
 		Command:           []string{"consul", "connect", "proxy"},
 		Config: map[string]interface{}{
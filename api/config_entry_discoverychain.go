@@ -0,0 +1,168 @@
+package api
+
+import (
+	"time"
+)
+
+// ServiceRouterConfigEntry defines L7 routing rules for a named service. The
+// routes are evaluated in order and the first match wins. Requests that don't
+// match any route are sent to the service named by the entry.
+type ServiceRouterConfigEntry struct {
+	Kind string
+	Name string
+
+	Routes []ServiceRoute `json:",omitempty"`
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+func (e *ServiceRouterConfigEntry) GetKind() string {
+	return e.Kind
+}
+
+func (e *ServiceRouterConfigEntry) GetName() string {
+	return e.Name
+}
+
+func (e *ServiceRouterConfigEntry) GetCreateIndex() uint64 {
+	return e.CreateIndex
+}
+
+func (e *ServiceRouterConfigEntry) GetModifyIndex() uint64 {
+	return e.ModifyIndex
+}
+
+// ServiceRoute routes traffic matching Match to Destination.
+type ServiceRoute struct {
+	Match       *ServiceRouteMatch       `json:",omitempty"`
+	Destination *ServiceRouteDestination `json:",omitempty"`
+}
+
+// ServiceRouteMatch is a set of criteria that can match incoming L7 requests.
+type ServiceRouteMatch struct {
+	HTTP *ServiceRouteHTTPMatch `json:",omitempty"`
+}
+
+// ServiceRouteHTTPMatch is a set of http-specific match criteria.
+type ServiceRouteHTTPMatch struct {
+	PathExact  string `json:",omitempty"`
+	PathPrefix string `json:",omitempty"`
+	PathRegex  string `json:",omitempty"`
+
+	Header     []ServiceRouteHTTPMatchHeader     `json:",omitempty"`
+	QueryParam []ServiceRouteHTTPMatchQueryParam `json:",omitempty"`
+	Methods    []string                          `json:",omitempty"`
+}
+
+// ServiceRouteHTTPMatchHeader matches a single request header.
+type ServiceRouteHTTPMatchHeader struct {
+	Name    string
+	Present bool   `json:",omitempty"`
+	Exact   string `json:",omitempty"`
+	Prefix  string `json:",omitempty"`
+	Suffix  string `json:",omitempty"`
+	Regex   string `json:",omitempty"`
+	Invert  bool   `json:",omitempty"`
+}
+
+// ServiceRouteHTTPMatchQueryParam matches a single query parameter.
+type ServiceRouteHTTPMatchQueryParam struct {
+	Name    string
+	Present bool   `json:",omitempty"`
+	Exact   string `json:",omitempty"`
+	Regex   string `json:",omitempty"`
+}
+
+// ServiceRouteDestination describes where and how matching requests are
+// proxied.
+type ServiceRouteDestination struct {
+	Service        string        `json:",omitempty"`
+	ServiceSubset  string        `json:",omitempty"`
+	PrefixRewrite  string        `json:",omitempty"`
+	RequestTimeout time.Duration `json:",omitempty"`
+	NumRetries     uint32        `json:",omitempty"`
+	RetryOn        []string      `json:",omitempty"`
+}
+
+// ServiceSplitterConfigEntry splits requests for a named service across
+// several subsets or services by weight. The weights of all splits must add
+// up to 100.
+type ServiceSplitterConfigEntry struct {
+	Kind string
+	Name string
+
+	Splits []ServiceSplit `json:",omitempty"`
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+func (e *ServiceSplitterConfigEntry) GetKind() string {
+	return e.Kind
+}
+
+func (e *ServiceSplitterConfigEntry) GetName() string {
+	return e.Name
+}
+
+func (e *ServiceSplitterConfigEntry) GetCreateIndex() uint64 {
+	return e.CreateIndex
+}
+
+func (e *ServiceSplitterConfigEntry) GetModifyIndex() uint64 {
+	return e.ModifyIndex
+}
+
+// ServiceSplit sends Weight percent of the requests to the given service
+// subset.
+type ServiceSplit struct {
+	Weight        float32
+	Service       string `json:",omitempty"`
+	ServiceSubset string `json:",omitempty"`
+}
+
+// ServiceResolverConfigEntry defines which instances of a service satisfy
+// discovery requests for it: named subsets selected by a filter, the default
+// subset, failover to other datacenters and the connect timeout.
+type ServiceResolverConfigEntry struct {
+	Kind string
+	Name string
+
+	DefaultSubset  string                             `json:",omitempty"`
+	Subsets        map[string]ServiceResolverSubset   `json:",omitempty"`
+	Failover       map[string]ServiceResolverFailover `json:",omitempty"`
+	ConnectTimeout time.Duration                      `json:",omitempty"`
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+func (e *ServiceResolverConfigEntry) GetKind() string {
+	return e.Kind
+}
+
+func (e *ServiceResolverConfigEntry) GetName() string {
+	return e.Name
+}
+
+func (e *ServiceResolverConfigEntry) GetCreateIndex() uint64 {
+	return e.CreateIndex
+}
+
+func (e *ServiceResolverConfigEntry) GetModifyIndex() uint64 {
+	return e.ModifyIndex
+}
+
+// ServiceResolverSubset selects the instances of a named subset using a
+// filter expression over the health results of the service, such as
+// `Service.Meta.version == v2`.
+type ServiceResolverSubset struct {
+	Filter string `json:",omitempty"`
+}
+
+// ServiceResolverFailover lists the datacenters to fail over to, in order,
+// when none of the instances of a subset are healthy.
+type ServiceResolverFailover struct {
+	Datacenters []string `json:",omitempty"`
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_ConfigEntry_ServiceRouter(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	config_entries := c.ConfigEntries()
+
+	router := &ServiceRouterConfigEntry{
+		Kind: ServiceRouter,
+		Name: "web",
+		Routes: []ServiceRoute{
+			{
+				Match: &ServiceRouteMatch{
+					HTTP: &ServiceRouteHTTPMatch{
+						PathPrefix: "/admin",
+						Header: []ServiceRouteHTTPMatchHeader{
+							{Name: "x-debug", Present: true},
+						},
+						Methods: []string{"GET"},
+					},
+				},
+				Destination: &ServiceRouteDestination{
+					Service:        "admin",
+					PrefixRewrite:  "/",
+					RequestTimeout: 5 * time.Second,
+					NumRetries:     2,
+					RetryOn:        []string{"connect-failure"},
+				},
+			},
+		},
+	}
+
+	// set it
+	_, err := config_entries.Set(router, nil)
+	require.NoError(t, err)
+
+	// get it
+	entry, _, err := config_entries.Get(ServiceRouter, "web", nil)
+	require.NoError(t, err)
+
+	// verify it
+	readRouter, ok := entry.(*ServiceRouterConfigEntry)
+	require.True(t, ok)
+	require.Equal(t, router.Routes, readRouter.Routes)
+
+	// list it
+	entries, _, err := config_entries.List(ServiceRouter, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	readRouter, ok = entries[0].(*ServiceRouterConfigEntry)
+	require.True(t, ok)
+	require.Equal(t, router.Routes, readRouter.Routes)
+
+	// invalid entries are rejected
+	_, err = config_entries.Set(&ServiceRouterConfigEntry{
+		Kind: ServiceRouter,
+		Name: "web",
+		Routes: []ServiceRoute{
+			{
+				Match: &ServiceRouteMatch{
+					HTTP: &ServiceRouteHTTPMatch{PathPrefix: "admin"},
+				},
+			},
+		},
+	}, nil)
+	require.Error(t, err)
+
+	// delete it
+	_, err = config_entries.Delete(ServiceRouter, "web", nil)
+	require.NoError(t, err)
+
+	entry, _, err = config_entries.Get(ServiceRouter, "web", nil)
+	require.NoError(t, err)
+	require.Nil(t, entry)
+}
+
+func TestAPI_DecodeConfigEntry_ServiceRouter(t *testing.T) {
+	t.Parallel()
+
+	entry, err := DecodeConfigEntryFromJSON([]byte(`{
+		"Kind": "service-router",
+		"Name": "web",
+		"Routes": [
+			{
+				"Match": {
+					"HTTP": {
+						"PathExact": "/health",
+						"QueryParam": [{"Name": "debug", "Present": true}]
+					}
+				},
+				"Destination": {
+					"ServiceSubset": "v2",
+					"RequestTimeout": "10s"
+				}
+			}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, &ServiceRouterConfigEntry{
+		Kind: ServiceRouter,
+		Name: "web",
+		Routes: []ServiceRoute{
+			{
+				Match: &ServiceRouteMatch{
+					HTTP: &ServiceRouteHTTPMatch{
+						PathExact: "/health",
+						QueryParam: []ServiceRouteHTTPMatchQueryParam{
+							{Name: "debug", Present: true},
+						},
+					},
+				},
+				Destination: &ServiceRouteDestination{
+					ServiceSubset:  "v2",
+					RequestTimeout: 10 * time.Second,
+				},
+			},
+		},
+	}, entry)
+}
+
+func TestAPI_ConfigEntry_ServiceSplitter(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	config_entries := c.ConfigEntries()
+
+	splitter := &ServiceSplitterConfigEntry{
+		Kind: ServiceSplitter,
+		Name: "web",
+		Splits: []ServiceSplit{
+			{Weight: 90, ServiceSubset: "v1"},
+			{Weight: 10, Service: "web-v2"},
+		},
+	}
+
+	// set it
+	_, err := config_entries.Set(splitter, nil)
+	require.NoError(t, err)
+
+	// get it
+	entry, _, err := config_entries.Get(ServiceSplitter, "web", nil)
+	require.NoError(t, err)
+
+	// verify it
+	readSplitter, ok := entry.(*ServiceSplitterConfigEntry)
+	require.True(t, ok)
+	require.Equal(t, splitter.Splits, readSplitter.Splits)
+
+	// weights that don't add up to 100 are rejected
+	_, err = config_entries.Set(&ServiceSplitterConfigEntry{
+		Kind: ServiceSplitter,
+		Name: "web",
+		Splits: []ServiceSplit{
+			{Weight: 90, ServiceSubset: "v1"},
+		},
+	}, nil)
+	require.Error(t, err)
+
+	// delete it
+	_, err = config_entries.Delete(ServiceSplitter, "web", nil)
+	require.NoError(t, err)
+
+	entry, _, err = config_entries.Get(ServiceSplitter, "web", nil)
+	require.NoError(t, err)
+	require.Nil(t, entry)
+}
+
+func TestAPI_ConfigEntry_ServiceResolver(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	config_entries := c.ConfigEntries()
+
+	resolver := &ServiceResolverConfigEntry{
+		Kind:          ServiceResolver,
+		Name:          "web",
+		DefaultSubset: "v1",
+		Subsets: map[string]ServiceResolverSubset{
+			"v1": {Filter: "Service.Meta.version == v1"},
+			"v2": {Filter: "Service.Meta.version == v2"},
+		},
+		Failover: map[string]ServiceResolverFailover{
+			"*": {Datacenters: []string{"dc2", "dc3"}},
+		},
+		ConnectTimeout: 15 * time.Second,
+	}
+
+	// set it
+	_, err := config_entries.Set(resolver, nil)
+	require.NoError(t, err)
+
+	// get it
+	entry, _, err := config_entries.Get(ServiceResolver, "web", nil)
+	require.NoError(t, err)
+
+	// verify it
+	readResolver, ok := entry.(*ServiceResolverConfigEntry)
+	require.True(t, ok)
+	require.Equal(t, resolver.DefaultSubset, readResolver.DefaultSubset)
+	require.Equal(t, resolver.Subsets, readResolver.Subsets)
+	require.Equal(t, resolver.Failover, readResolver.Failover)
+	require.Equal(t, resolver.ConnectTimeout, readResolver.ConnectTimeout)
+
+	// invalid filters are rejected
+	_, err = config_entries.Set(&ServiceResolverConfigEntry{
+		Kind: ServiceResolver,
+		Name: "web",
+		Subsets: map[string]ServiceResolverSubset{
+			"v1": {Filter: "Service.Nope == v1"},
+		},
+	}, nil)
+	require.Error(t, err)
+
+	// delete it
+	_, err = config_entries.Delete(ServiceResolver, "web", nil)
+	require.NoError(t, err)
+
+	entry, _, err = config_entries.Get(ServiceResolver, "web", nil)
+	require.NoError(t, err)
+	require.Nil(t, entry)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_ConfigEntries(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	config_entries := c.ConfigEntries()
+
+	t.Run("Proxy Defaults", func(t *testing.T) {
+		global_proxy := &ProxyConfigEntry{
+			Kind: ProxyDefaults,
+			Name: ProxyConfigGlobal,
+			Config: map[string]interface{}{
+				"foo": "bar",
+				"bar": 1.0,
+			},
+		}
+
+		// set it
+		wm, err := config_entries.Set(global_proxy, nil)
+		require.NoError(t, err)
+		require.NotNil(t, wm)
+		require.NotEqual(t, 0, wm.RequestTime)
+
+		// get it
+		entry, qm, err := config_entries.Get(ProxyDefaults, ProxyConfigGlobal, nil)
+		require.NoError(t, err)
+		require.NotNil(t, qm)
+		require.NotEqual(t, 0, qm.RequestTime)
+
+		// verify it
+		readProxy, ok := entry.(*ProxyConfigEntry)
+		require.True(t, ok)
+		require.Equal(t, global_proxy.Kind, readProxy.Kind)
+		require.Equal(t, global_proxy.Name, readProxy.Name)
+		require.Equal(t, global_proxy.Config, readProxy.Config)
+
+		// list it
+		entries, qm, err := config_entries.List(ProxyDefaults, nil)
+		require.NoError(t, err)
+		require.NotNil(t, qm)
+		require.NotEqual(t, 0, qm.RequestTime)
+		require.Len(t, entries, 1)
+		require.Equal(t, ProxyConfigGlobal, entries[0].GetName())
+
+		// delete it
+		wm, err = config_entries.Delete(ProxyDefaults, ProxyConfigGlobal, nil)
+		require.NoError(t, err)
+		require.NotNil(t, wm)
+		require.NotEqual(t, 0, wm.RequestTime)
+
+		entry, _, err = config_entries.Get(ProxyDefaults, ProxyConfigGlobal, nil)
+		require.NoError(t, err)
+		require.Nil(t, entry)
+	})
+
+	t.Run("Service Defaults", func(t *testing.T) {
+		service := &ServiceConfigEntry{
+			Kind:     ServiceDefaults,
+			Name:     "foo",
+			Protocol: "udp",
+		}
+
+		service2 := &ServiceConfigEntry{
+			Kind:     ServiceDefaults,
+			Name:     "bar",
+			Protocol: "tcp",
+			DNS: ServiceDNSConfiguration{
+				Ordering: DNSOrderingWeighted,
+			},
+		}
+
+		// set it
+		_, err := config_entries.Set(service, nil)
+		require.NoError(t, err)
+
+		// also set the second one
+		_, err = config_entries.Set(service2, nil)
+		require.NoError(t, err)
+
+		// get it
+		entry, _, err := config_entries.Get(ServiceDefaults, "foo", nil)
+		require.NoError(t, err)
+
+		// verify it
+		readService, ok := entry.(*ServiceConfigEntry)
+		require.True(t, ok)
+		require.Equal(t, service.Kind, readService.Kind)
+		require.Equal(t, service.Name, readService.Name)
+		require.Equal(t, service.Protocol, readService.Protocol)
+
+		// list them
+		entries, _, err := config_entries.List(ServiceDefaults, nil)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+
+		for _, entry = range entries {
+			switch entry.GetName() {
+			case "foo":
+				// this also verifies that the update value was persisted and
+				// the updated values are seen
+				readService, ok = entry.(*ServiceConfigEntry)
+				require.True(t, ok)
+				require.Equal(t, service.Protocol, readService.Protocol)
+			case "bar":
+				readService, ok = entry.(*ServiceConfigEntry)
+				require.True(t, ok)
+				require.Equal(t, service2.Protocol, readService.Protocol)
+				require.Equal(t, service2.DNS, readService.DNS)
+			}
+		}
+
+		// delete it
+		_, err = config_entries.Delete(ServiceDefaults, "foo", nil)
+		require.NoError(t, err)
+
+		// verify deletion
+		entry, _, err = config_entries.Get(ServiceDefaults, "foo", nil)
+		require.NoError(t, err)
+		require.Nil(t, entry)
+	})
+}
+
+func TestAPI_DecodeConfigEntry(t *testing.T) {
+	t.Parallel()
+
+	entry, err := DecodeConfigEntryFromJSON([]byte(`{
+		"Kind": "service-defaults",
+		"Name": "web",
+		"Protocol": "http",
+		"Connect": {
+			"SidecarProxy": true
+		}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, &ServiceConfigEntry{
+		Kind:     ServiceDefaults,
+		Name:     "web",
+		Protocol: "http",
+		Connect: ConnectConfiguration{
+			SidecarProxy: true,
+		},
+	}, entry)
+
+	_, err = DecodeConfigEntryFromJSON([]byte(`{"Name": "web"}`))
+	require.Error(t, err)
+
+	_, err = DecodeConfigEntryFromJSON([]byte(`{"Kind": "nope"}`))
+	require.Error(t, err)
+}
@@ -2,11 +2,13 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // KVPair is used to represent a single K/V entry
@@ -40,11 +42,96 @@ type KVPair struct {
 	// interactions with this key over the same session must specify the same
 	// session ID.
 	Session string
+
+	// TTL is how long the key is kept for after it is written, after which
+	// it is deleted by the servers. It is only used when writing a key and
+	// a zero TTL means the key never expires.
+	TTL time.Duration `json:",omitempty"`
+
+	// ExpirationTime is when the key expires if it was written with a TTL.
+	// This is a read-only field.
+	ExpirationTime *time.Time `json:",omitempty"`
 }
 
 // KVPairs is a list of KVPair objects
 type KVPairs []*KVPair
 
+// KVHistoryEntry is a version of a key recorded in its history.
+type KVHistoryEntry struct {
+	// Key is the name of the key.
+	Key string
+
+	// CreateIndex holds the index corresponding the creation of the key.
+	CreateIndex uint64
+
+	// ModifyIndex holds the index corresponding to the write or deletion of
+	// the key that created this version.
+	ModifyIndex uint64
+
+	// LockIndex, Flags, Value and Session hold the fields of the key as of
+	// this version.
+	LockIndex uint64
+	Flags     uint64
+	Value     []byte
+	Session   string
+
+	// Deleted is set for the version recorded when the key was deleted.
+	Deleted bool
+
+	// Time is when the version was written. It's zero for the current
+	// version of a key whose writes are not recorded.
+	Time time.Time
+}
+
+// KVEventType is the type of a change to a key.
+type KVEventType string
+
+const (
+	KVEventPut    KVEventType = "put"
+	KVEventDelete KVEventType = "delete"
+)
+
+// KVEvent is a change to a key, as streamed by KV.Stream.
+type KVEvent struct {
+	// Type is the type of the change.
+	Type KVEventType
+
+	// Key is the name of the key.
+	Key string
+
+	// ModifyIndex holds the index of the change.
+	ModifyIndex uint64
+
+	// Recurse is set for a delete of every key starting with Key that was
+	// last modified before ModifyIndex, rather than just Key. The keys
+	// under it that were kept are sent again as puts after the delete.
+	Recurse bool
+
+	// CreateIndex, LockIndex, Flags, Value and Session hold the fields of
+	// the key after a put.
+	CreateIndex uint64
+	LockIndex   uint64
+	Flags       uint64
+	Value       []byte
+	Session     string
+}
+
+// KVChanges is a batch of changes to the keys under a prefix, as streamed
+// by KV.Stream.
+type KVChanges struct {
+	// Index is the index of the latest change, which the stream can be
+	// resumed from by passing it as the WaitIndex.
+	Index uint64
+
+	// Reset is set when the changes couldn't be tracked from the index the
+	// stream started from. Events then holds a put for every key under the
+	// prefix and the keys that aren't in it should be dropped.
+	Reset bool
+
+	// Events holds the changes, oldest first.
+	Events []*KVEvent
+}
+
 // KV is used to manipulate the K/V API
 type KV struct {
 	c *Client
@@ -77,6 +164,51 @@ func (k *KV) Get(key string, q *QueryOptions) (*KVPair, *QueryMeta, error) {
 	return nil, qm, nil
 }
 
+// GetAtIndex is used to lookup the version of a single key that was current
+// at the given index. The returned pointer to the KVPair will be nil if the
+// key did not exist at that index. An error is returned if no history is kept
+// for the key, or if the index is older than the oldest version still kept.
+func (k *KV) GetAtIndex(key string, index uint64, q *QueryOptions) (*KVPair, *QueryMeta, error) {
+	params := map[string]string{"at-index": strconv.FormatUint(index, 10)}
+	resp, qm, err := k.getInternal(key, params, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp == nil {
+		return nil, qm, nil
+	}
+	defer resp.Body.Close()
+
+	var entries []*KVPair
+	if err := decodeBody(resp, &entries); err != nil {
+		return nil, nil, err
+	}
+	if len(entries) > 0 {
+		return entries[0], qm, nil
+	}
+	return nil, qm, nil
+}
+
+// History is used to lookup the versions of a single key, oldest first. Past
+// versions are only kept for the keys under the prefixes configured with
+// kv_history on the servers, otherwise only the current version is returned.
+func (k *KV) History(key string, q *QueryOptions) ([]*KVHistoryEntry, *QueryMeta, error) {
+	resp, qm, err := k.getInternal(key, map[string]string{"history": ""}, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp == nil {
+		return nil, qm, nil
+	}
+	defer resp.Body.Close()
+
+	var entries []*KVHistoryEntry
+	if err := decodeBody(resp, &entries); err != nil {
+		return nil, nil, err
+	}
+	return entries, qm, nil
+}
+
 // List is used to lookup all keys under a prefix
 func (k *KV) List(prefix string, q *QueryOptions) (KVPairs, *QueryMeta, error) {
 	resp, qm, err := k.getInternal(prefix, map[string]string{"recurse": ""}, q)
@@ -118,6 +250,53 @@ func (k *KV) Keys(prefix, separator string, q *QueryOptions) ([]string, *QueryMe
 	return entries, qm, nil
 }
 
+// Stream streams the changes to the keys under a prefix, starting after the
+// WaitIndex of the query options. Without a WaitIndex, the stream starts
+// with a reset holding every key. The returned channel is closed once the
+// stream ends, either because the given stopCh was closed or because the
+// connection to the agent was lost, and the stream can be resumed from the
+// last index received.
+func (k *KV) Stream(prefix string, stopCh <-chan struct{}, q *QueryOptions) (<-chan *KVChanges, *QueryMeta, error) {
+	resp, qm, err := k.getInternal(prefix, map[string]string{"stream": ""}, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp == nil {
+		return nil, nil, fmt.Errorf("Unexpected response code: 404")
+	}
+
+	changesCh := make(chan *KVChanges)
+	go func() {
+		defer close(changesCh)
+		defer resp.Body.Close()
+
+		// Close the body when asked to stop so a pending read returns.
+		doneCh := make(chan struct{})
+		defer close(doneCh)
+		go func() {
+			select {
+			case <-stopCh:
+				resp.Body.Close()
+			case <-doneCh:
+			}
+		}()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var changes KVChanges
+			if err := dec.Decode(&changes); err != nil {
+				return
+			}
+			select {
+			case changesCh <- &changes:
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return changesCh, qm, nil
+}
+
 func (k *KV) getInternal(key string, params map[string]string, q *QueryOptions) (*http.Response, *QueryMeta, error) {
 	r := k.c.newRequest("GET", "/v1/kv/"+strings.TrimPrefix(key, "/"))
 	r.setQueryOptions(q)
@@ -144,48 +323,60 @@ func (k *KV) getInternal(key string, params map[string]string, q *QueryOptions)
 }
 
 // Put is used to write a new value. Only the
-// Key, Flags and Value is respected.
+// Key, Flags, Value and TTL is respected.
 func (k *KV) Put(p *KVPair, q *WriteOptions) (*WriteMeta, error) {
 	params := make(map[string]string, 1)
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != 0 {
+		params["ttl"] = p.TTL.String()
+	}
 	_, wm, err := k.put(p.Key, params, p.Value, q)
 	return wm, err
 }
 
 // CAS is used for a Check-And-Set operation. The Key,
-// ModifyIndex, Flags and Value are respected. Returns true
+// ModifyIndex, Flags, Value and TTL are respected. Returns true
 // on success or false on failures.
 func (k *KV) CAS(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
 	params := make(map[string]string, 2)
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != 0 {
+		params["ttl"] = p.TTL.String()
+	}
 	params["cas"] = strconv.FormatUint(p.ModifyIndex, 10)
 	return k.put(p.Key, params, p.Value, q)
 }
 
 // Acquire is used for a lock acquisition operation. The Key,
-// Flags, Value and Session are respected. Returns true
+// Flags, Value, Session and TTL are respected. Returns true
 // on success or false on failures.
 func (k *KV) Acquire(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
 	params := make(map[string]string, 2)
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != 0 {
+		params["ttl"] = p.TTL.String()
+	}
 	params["acquire"] = p.Session
 	return k.put(p.Key, params, p.Value, q)
 }
 
 // Release is used for a lock release operation. The Key,
-// Flags, Value and Session are respected. Returns true
+// Flags, Value, Session and TTL are respected. Returns true
 // on success or false on failures.
 func (k *KV) Release(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
 	params := make(map[string]string, 2)
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != 0 {
+		params["ttl"] = p.TTL.String()
+	}
 	params["release"] = p.Session
 	return k.put(p.Key, params, p.Value, q)
 }
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/hashicorp/consul/sdk/testutil"
 )
 
 func TestAPI_ClientPutGetDelete(t *testing.T) {
@@ -74,6 +76,166 @@ func TestAPI_ClientPutGetDelete(t *testing.T) {
 	}
 }
 
+func TestAPI_ClientPutTTL(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+
+	s.WaitForSerfCheck(t)
+
+	// Put a key that expires shortly
+	key := testKey()
+	p := &KVPair{Key: key, Value: []byte("test"), TTL: 500 * time.Millisecond}
+	if _, err := kv.Put(p, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	pair, meta, err := kv.Get(key, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair == nil {
+		t.Fatalf("expected value: %#v", pair)
+	}
+	if pair.ExpirationTime == nil || pair.TTL != 0 {
+		t.Fatalf("unexpected value: %#v", pair)
+	}
+
+	// A blocking query returns once the key is deleted
+	options := &QueryOptions{WaitIndex: meta.LastIndex, WaitTime: 10 * time.Second}
+	pair, _, err = kv.Get(key, options)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair != nil {
+		t.Fatalf("unexpected value: %#v", pair)
+	}
+}
+
+func TestAPI_ClientStream(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+
+	s.WaitForSerfCheck(t)
+
+	prefix := testKey() + "/"
+	if _, err := kv.Put(&KVPair{Key: prefix + "a", Value: []byte("1")}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	stopCh := make(chan struct{})
+	changesCh, _, err := kv.Stream(prefix, stopCh, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The stream starts with every key
+	var changes *KVChanges
+	select {
+	case changes = <-changesCh:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out")
+	}
+	if !changes.Reset || len(changes.Events) != 1 {
+		t.Fatalf("unexpected value: %#v", changes)
+	}
+	if event := changes.Events[0]; event.Type != KVEventPut || event.Key != prefix+"a" || string(event.Value) != "1" {
+		t.Fatalf("unexpected value: %#v", event)
+	}
+
+	// Then the changes follow
+	if _, err := kv.Delete(prefix+"a", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	select {
+	case changes = <-changesCh:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out")
+	}
+	if changes.Reset || len(changes.Events) != 1 {
+		t.Fatalf("unexpected value: %#v", changes)
+	}
+	if event := changes.Events[0]; event.Type != KVEventDelete || event.Key != prefix+"a" || event.ModifyIndex != changes.Index {
+		t.Fatalf("unexpected value: %#v", event)
+	}
+
+	// Stopping closes the channel
+	close(stopCh)
+	select {
+	case _, ok := <-changesCh:
+		if ok {
+			t.Fatalf("expected the stream to end")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out")
+	}
+}
+
+func TestAPI_ClientHistory(t *testing.T) {
+	t.Parallel()
+	c, s := makeClientWithConfig(t, nil, func(conf *testutil.TestServerConfig) {
+		conf.Args = []string{"-hcl", `kv_history { prefix = "" max_versions = 10 }`}
+	})
+	defer s.Stop()
+
+	kv := c.KV()
+
+	s.WaitForSerfCheck(t)
+
+	// Write a couple of versions
+	key := testKey()
+	for _, value := range []string{"1", "2"} {
+		if _, err := kv.Put(&KVPair{Key: key, Flags: 42, Value: []byte(value)}, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	history, meta, err := kv.History(key, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if meta.LastIndex == 0 {
+		t.Fatalf("unexpected value: %#v", meta)
+	}
+	if len(history) != 2 {
+		t.Fatalf("unexpected value: %#v", history)
+	}
+	for i, value := range []string{"1", "2"} {
+		entry := history[i]
+		if entry.Key != key || entry.Flags != 42 || string(entry.Value) != value || entry.Deleted || entry.Time.IsZero() {
+			t.Fatalf("unexpected value: %#v", entry)
+		}
+	}
+
+	// Read back the first version
+	pair, _, err := kv.GetAtIndex(key, history[0].ModifyIndex, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair == nil || string(pair.Value) != "1" || pair.ModifyIndex != history[0].ModifyIndex {
+		t.Fatalf("unexpected value: %#v", pair)
+	}
+
+	// Nothing is retained before it
+	if _, _, err := kv.GetAtIndex(key, history[0].ModifyIndex-1, nil); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	// Missing keys have no history
+	history, _, err = kv.History(testKey(), nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("unexpected value: %#v", history)
+	}
+}
+
 func TestAPI_ClientList_DeleteRecurse(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
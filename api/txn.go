@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
 // Txn is used to manipulate the Txn API
@@ -81,6 +82,10 @@ type KVTxnOp struct {
 	Flags   uint64
 	Index   uint64
 	Session string
+
+	// TTL is how long the key is kept for after it is written by a set,
+	// CAS, lock or unlock operation. A zero TTL means it never expires.
+	TTL time.Duration `json:",omitempty"`
 }
 
 // KVTxnOps defines a set of operations to be performed inside a single
@@ -14,7 +14,13 @@ func PrintToken(token *api.ACLToken, ui cli.Ui, showMeta bool) {
 	ui.Info(fmt.Sprintf("SecretID:     %s", token.SecretID))
 	ui.Info(fmt.Sprintf("Description:  %s", token.Description))
 	ui.Info(fmt.Sprintf("Local:        %t", token.Local))
+	if token.AuthMethod != "" {
+		ui.Info(fmt.Sprintf("Auth Method:  %s", token.AuthMethod))
+	}
 	ui.Info(fmt.Sprintf("Create Time:  %v", token.CreateTime))
+	if token.ExpirationTime != nil && !token.ExpirationTime.IsZero() {
+		ui.Info(fmt.Sprintf("Expiration Time:  %v", *token.ExpirationTime))
+	}
 	if showMeta {
 		ui.Info(fmt.Sprintf("Hash:         %x", token.Hash))
 		ui.Info(fmt.Sprintf("Create Index: %d", token.CreateIndex))
@@ -24,6 +30,22 @@ func PrintToken(token *api.ACLToken, ui cli.Ui, showMeta bool) {
 	for _, policy := range token.Policies {
 		ui.Info(fmt.Sprintf("   %s - %s", policy.ID, policy.Name))
 	}
+	if len(token.Roles) > 0 {
+		ui.Info(fmt.Sprintf("Roles:"))
+		for _, role := range token.Roles {
+			ui.Info(fmt.Sprintf("   %s - %s", role.ID, role.Name))
+		}
+	}
+	if len(token.ServiceIdentities) > 0 {
+		ui.Info(fmt.Sprintf("Service Identities:"))
+		for _, svcid := range token.ServiceIdentities {
+			if len(svcid.Datacenters) > 0 {
+				ui.Info(fmt.Sprintf("   %s (Datacenters: %s)", svcid.ServiceName, strings.Join(svcid.Datacenters, ", ")))
+			} else {
+				ui.Info(fmt.Sprintf("   %s (Datacenters: all)", svcid.ServiceName))
+			}
+		}
+	}
 	if token.Rules != "" {
 		ui.Info(fmt.Sprintf("Rules:"))
 		ui.Info(token.Rules)
@@ -34,7 +56,13 @@ func PrintTokenListEntry(token *api.ACLTokenListEntry, ui cli.Ui, showMeta bool)
 	ui.Info(fmt.Sprintf("AccessorID:   %s", token.AccessorID))
 	ui.Info(fmt.Sprintf("Description:  %s", token.Description))
 	ui.Info(fmt.Sprintf("Local:        %t", token.Local))
+	if token.AuthMethod != "" {
+		ui.Info(fmt.Sprintf("Auth Method:  %s", token.AuthMethod))
+	}
 	ui.Info(fmt.Sprintf("Create Time:  %v", token.CreateTime))
+	if token.ExpirationTime != nil && !token.ExpirationTime.IsZero() {
+		ui.Info(fmt.Sprintf("Expiration Time:  %v", *token.ExpirationTime))
+	}
 	ui.Info(fmt.Sprintf("Legacy:       %t", token.Legacy))
 	if showMeta {
 		ui.Info(fmt.Sprintf("Hash:         %x", token.Hash))
@@ -45,6 +73,22 @@ func PrintTokenListEntry(token *api.ACLTokenListEntry, ui cli.Ui, showMeta bool)
 	for _, policy := range token.Policies {
 		ui.Info(fmt.Sprintf("   %s - %s", policy.ID, policy.Name))
 	}
+	if len(token.Roles) > 0 {
+		ui.Info(fmt.Sprintf("Roles:"))
+		for _, role := range token.Roles {
+			ui.Info(fmt.Sprintf("   %s - %s", role.ID, role.Name))
+		}
+	}
+	if len(token.ServiceIdentities) > 0 {
+		ui.Info(fmt.Sprintf("Service Identities:"))
+		for _, svcid := range token.ServiceIdentities {
+			if len(svcid.Datacenters) > 0 {
+				ui.Info(fmt.Sprintf("   %s (Datacenters: %s)", svcid.ServiceName, strings.Join(svcid.Datacenters, ", ")))
+			} else {
+				ui.Info(fmt.Sprintf("   %s (Datacenters: all)", svcid.ServiceName))
+			}
+		}
+	}
 }
 
 func PrintPolicy(policy *api.ACLPolicy, ui cli.Ui, showMeta bool) {
@@ -73,6 +117,36 @@ func PrintPolicyListEntry(policy *api.ACLPolicyListEntry, ui cli.Ui, showMeta bo
 	}
 }
 
+func PrintRole(role *api.ACLRole, ui cli.Ui, showMeta bool) {
+	ui.Info(fmt.Sprintf("ID:           %s", role.ID))
+	ui.Info(fmt.Sprintf("Name:         %s", role.Name))
+	ui.Info(fmt.Sprintf("Description:  %s", role.Description))
+	if showMeta {
+		ui.Info(fmt.Sprintf("Hash:         %x", role.Hash))
+		ui.Info(fmt.Sprintf("Create Index: %d", role.CreateIndex))
+		ui.Info(fmt.Sprintf("Modify Index: %d", role.ModifyIndex))
+	}
+	ui.Info(fmt.Sprintf("Policies:"))
+	for _, policy := range role.Policies {
+		ui.Info(fmt.Sprintf("   %s - %s", policy.ID, policy.Name))
+	}
+}
+
+func PrintRoleListEntry(role *api.ACLRole, ui cli.Ui, showMeta bool) {
+	ui.Info(fmt.Sprintf("%s:", role.Name))
+	ui.Info(fmt.Sprintf("   ID:           %s", role.ID))
+	ui.Info(fmt.Sprintf("   Description:  %s", role.Description))
+	if showMeta {
+		ui.Info(fmt.Sprintf("   Hash:         %x", role.Hash))
+		ui.Info(fmt.Sprintf("   Create Index: %d", role.CreateIndex))
+		ui.Info(fmt.Sprintf("   Modify Index: %d", role.ModifyIndex))
+	}
+	ui.Info(fmt.Sprintf("   Policies:"))
+	for _, policy := range role.Policies {
+		ui.Info(fmt.Sprintf("      %s - %s", policy.ID, policy.Name))
+	}
+}
+
 func GetTokenIDFromPartial(client *api.Client, partialID string) (string, error) {
 	if partialID == "anonymous" {
 		return structs.ACLTokenAnonymousID, nil
@@ -155,6 +229,70 @@ func GetPolicyIDByName(client *api.Client, name string) (string, error) {
 	return "", fmt.Errorf("No such policy with name %s", name)
 }
 
+func GetRoleIDFromPartial(client *api.Client, partialID string) (string, error) {
+	// the full UUID string was given
+	if len(partialID) == 36 {
+		return partialID, nil
+	}
+
+	roles, _, err := client.ACL().RoleList(nil)
+	if err != nil {
+		return "", err
+	}
+
+	roleID := ""
+	for _, role := range roles {
+		if strings.HasPrefix(role.ID, partialID) {
+			if roleID != "" {
+				return "", fmt.Errorf("Partial role ID is not unique")
+			}
+			roleID = role.ID
+		}
+	}
+
+	if roleID == "" {
+		return "", fmt.Errorf("No such role ID with prefix: %s", partialID)
+	}
+
+	return roleID, nil
+}
+
+func GetRoleIDByName(client *api.Client, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("No name specified")
+	}
+
+	role, _, err := client.ACL().RoleReadByName(name, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return role.ID, nil
+}
+
+// ExtractServiceIdentities parses service identities given on the command
+// line in the form <service name>[:<datacenter>[,<datacenter>...]].
+func ExtractServiceIdentities(serviceIdents []string) ([]*api.ACLServiceIdentity, error) {
+	var out []*api.ACLServiceIdentity
+	for _, svcidRaw := range serviceIdents {
+		parts := strings.Split(svcidRaw, ":")
+		switch len(parts) {
+		case 2:
+			out = append(out, &api.ACLServiceIdentity{
+				ServiceName: parts[0],
+				Datacenters: strings.Split(parts[1], ","),
+			})
+		case 1:
+			out = append(out, &api.ACLServiceIdentity{
+				ServiceName: parts[0],
+			})
+		default:
+			return nil, fmt.Errorf("Malformed -service-identity argument: %q", svcidRaw)
+		}
+	}
+	return out, nil
+}
+
 func GetRulesFromLegacyToken(client *api.Client, tokenID string, isSecret bool) (string, error) {
 	tokenID, err := GetTokenIDFromPartial(client, tokenID)
 	if err != nil {
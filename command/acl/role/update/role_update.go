@@ -0,0 +1,216 @@
+package roleupdate
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/acl"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	roleID         string
+	nameSet        bool
+	name           string
+	descriptionSet bool
+	description    string
+	policyIDs      []string
+	policyNames    []string
+	noMerge        bool
+	showMeta       bool
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.showMeta, "meta", false, "Indicates that role metadata such "+
+		"as the content hash and raft indices should be shown for each entry")
+	c.flags.StringVar(&c.roleID, "id", "", "The ID of the role to update. "+
+		"It may be specified as a unique ID prefix but will error if the prefix "+
+		"matches multiple role IDs")
+	c.flags.StringVar(&c.name, "name", "", "The role's name.")
+	c.flags.StringVar(&c.description, "description", "", "A description of the role")
+	c.flags.Var((*flags.AppendSliceValue)(&c.policyIDs), "policy-id", "ID of a "+
+		"policy to use for this role. May be specified multiple times")
+	c.flags.Var((*flags.AppendSliceValue)(&c.policyNames), "policy-name", "Name of a "+
+		"policy to use for this role. May be specified multiple times")
+	c.flags.BoolVar(&c.noMerge, "no-merge", false, "Do not merge the current role "+
+		"information with what is provided to the command. Instead overwrite all fields "+
+		"with the exception of the role ID which is immutable.")
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) checkSet(f *flag.Flag) {
+	switch f.Name {
+	case "name":
+		c.nameSet = true
+	case "description":
+		c.descriptionSet = true
+	}
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	c.flags.Visit(c.checkSet)
+
+	if c.roleID == "" && c.name == "" {
+		c.UI.Error(fmt.Sprintf("Must specify either the -id or -name parameters"))
+		return 1
+	}
+
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	var roleID string
+	if c.roleID != "" {
+		roleID, err = acl.GetRoleIDFromPartial(client, c.roleID)
+	} else {
+		roleID, err = acl.GetRoleIDByName(client, c.name)
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error determining role ID: %v", err))
+		return 1
+	}
+
+	// Resolve any partial policy IDs up front so that both the merge and
+	// no-merge paths deal with full IDs.
+	var policyIDs []string
+	for _, policyID := range c.policyIDs {
+		policyID, err := acl.GetPolicyIDFromPartial(client, policyID)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error resolving policy ID %s: %v", policyID, err))
+			return 1
+		}
+		policyIDs = append(policyIDs, policyID)
+	}
+
+	var updated *api.ACLRole
+	if c.noMerge {
+		updated = &api.ACLRole{
+			ID:          roleID,
+			Name:        c.name,
+			Description: c.description,
+		}
+
+		for _, policyName := range c.policyNames {
+			// We could resolve names to IDs here but there isn't any reason why its would be better
+			// than allowing the agent to do it.
+			updated.Policies = append(updated.Policies, &api.ACLRolePolicyLink{Name: policyName})
+		}
+
+		for _, policyID := range policyIDs {
+			updated.Policies = append(updated.Policies, &api.ACLRolePolicyLink{ID: policyID})
+		}
+	} else {
+		role, _, err := client.ACL().RoleRead(roleID, nil)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading role %q: %v", roleID, err))
+			return 1
+		}
+
+		updated = &api.ACLRole{
+			ID:          roleID,
+			Name:        role.Name,
+			Description: role.Description,
+			Policies:    role.Policies,
+		}
+
+		if c.nameSet {
+			updated.Name = c.name
+		}
+		if c.descriptionSet {
+			updated.Description = c.description
+		}
+
+		for _, policyName := range c.policyNames {
+			found := false
+			for _, link := range updated.Policies {
+				if link.Name == policyName {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				updated.Policies = append(updated.Policies, &api.ACLRolePolicyLink{Name: policyName})
+			}
+		}
+
+		for _, policyID := range policyIDs {
+			found := false
+			for _, link := range updated.Policies {
+				if link.ID == policyID {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				updated.Policies = append(updated.Policies, &api.ACLRolePolicyLink{ID: policyID})
+			}
+		}
+	}
+
+	role, _, err := client.ACL().RoleUpdate(updated, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error updating role %q: %v", roleID, err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Role updated successfully"))
+	acl.PrintRole(role, c.UI, c.showMeta)
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return flags.Usage(c.help, nil)
+}
+
+const synopsis = "Update an ACL Role"
+const help = `
+Usage: consul acl role update [options]
+
+  Updates a role. By default it will merge the role information with its
+  current state so that you do not have to provide all parameters. This
+  behavior can be disabled by passing -no-merge.
+
+  Rename the Role:
+
+          $ consul acl role update -id abcd -name "better-name"
+
+  Add a policy to the Role:
+
+          $ consul acl role update -id abcd -policy-name "node-read"
+
+  Override all role attributes:
+
+          # this will remove the description and all policies other than
+          # the ones provided
+          $ consul acl role update -id abcd -name "better-name" -no-merge \
+                                   -policy-name "node-read"
+`
@@ -3,6 +3,7 @@ package tokencreate
 import (
 	"flag"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/acl"
@@ -22,11 +23,15 @@ type cmd struct {
 	http  *flags.HTTPFlags
 	help  string
 
-	policyIDs   []string
-	policyNames []string
-	description string
-	local       bool
-	showMeta    bool
+	policyIDs     []string
+	policyNames   []string
+	roleIDs       []string
+	roleNames     []string
+	serviceIdents []string
+	expirationTTL time.Duration
+	description   string
+	local         bool
+	showMeta      bool
 }
 
 func (c *cmd) init() {
@@ -39,6 +44,15 @@ func (c *cmd) init() {
 		"policy to use for this token. May be specified multiple times")
 	c.flags.Var((*flags.AppendSliceValue)(&c.policyNames), "policy-name", "Name of a "+
 		"policy to use for this token. May be specified multiple times")
+	c.flags.Var((*flags.AppendSliceValue)(&c.roleIDs), "role-id", "ID of a "+
+		"role to use for this token. May be specified multiple times")
+	c.flags.Var((*flags.AppendSliceValue)(&c.roleNames), "role-name", "Name of a "+
+		"role to use for this token. May be specified multiple times")
+	c.flags.Var((*flags.AppendSliceValue)(&c.serviceIdents), "service-identity", "Name of a "+
+		"service identity to use for this token. May be specified multiple times. Format is "+
+		"the SERVICENAME or SERVICENAME:DATACENTER1,DATACENTER2,...")
+	c.flags.DurationVar(&c.expirationTTL, "expires-ttl", 0, "Duration of time this "+
+		"token should be valid for")
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
@@ -50,8 +64,10 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
-	if len(c.policyNames) == 0 && len(c.policyIDs) == 0 {
-		c.UI.Error(fmt.Sprintf("Cannot create a token without specifying -policy-name or -policy-id at least once"))
+	if len(c.policyNames) == 0 && len(c.policyIDs) == 0 &&
+		len(c.roleNames) == 0 && len(c.roleIDs) == 0 &&
+		len(c.serviceIdents) == 0 {
+		c.UI.Error(fmt.Sprintf("Cannot create a token without specifying -policy-name, -policy-id, -role-name, -role-id, or -service-identity at least once"))
 		return 1
 	}
 
@@ -61,9 +77,19 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	serviceIdents, err := acl.ExtractServiceIdentities(c.serviceIdents)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
 	newToken := &api.ACLToken{
-		Description: c.description,
-		Local:       c.local,
+		Description:       c.description,
+		Local:             c.local,
+		ServiceIdentities: serviceIdents,
+	}
+	if c.expirationTTL > 0 {
+		newToken.ExpirationTTL = c.expirationTTL
 	}
 
 	for _, policyName := range c.policyNames {
@@ -81,6 +107,21 @@ func (c *cmd) Run(args []string) int {
 		newToken.Policies = append(newToken.Policies, &api.ACLTokenPolicyLink{ID: policyID})
 	}
 
+	for _, roleName := range c.roleNames {
+		// We could resolve names to IDs here but there isn't any reason why its would be better
+		// than allowing the agent to do it.
+		newToken.Roles = append(newToken.Roles, &api.ACLTokenRoleLink{Name: roleName})
+	}
+
+	for _, roleID := range c.roleIDs {
+		roleID, err := acl.GetRoleIDFromPartial(client, roleID)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error resolving role ID %s: %v", roleID, err))
+			return 1
+		}
+		newToken.Roles = append(newToken.Roles, &api.ACLTokenRoleLink{ID: roleID})
+	}
+
 	token, _, err := client.ACL().TokenCreate(newToken, nil)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Failed to create new token: %v", err))
@@ -106,10 +147,23 @@ Usage: consul acl token create [options]
   When creating a new token policies may be linked using either the -policy-id
   or the -policy-name options. When specifying policies by IDs you may use a
   unique prefix of the UUID as a shortcut for specifying the entire UUID.
+  Roles are linked the same way using the -role-id or -role-name options.
+  Service identities are given with the -service-identity option as the name
+  of the service optionally followed by the datacenters it is limited to.
 
   Create a new token:
 
           $ consul acl token create -description "Replication token"
                                             -policy-id b52fc3de-5
                                             -policy-name "acl-replication"
+
+  Create a new token from an existing role:
+
+          $ consul acl token create -description "web token"
+                                            -role-name "web-server"
+
+  Create a new token for the "web" service in dc1 and dc2 only:
+
+          $ consul acl token create -description "web token"
+                                            -service-identity "web:dc1,dc2"
 `
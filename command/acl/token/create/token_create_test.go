@@ -82,4 +82,52 @@ func TestTokenCreateCommand(t *testing.T) {
 		assert.Equal(code, 0)
 		assert.Empty(ui.ErrorWriter.String())
 	}
+
+	// create with service identities
+	{
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-token=root",
+			"-service-identity=web",
+			"-service-identity=db:dc1,dc2",
+			"-description=test token",
+		}
+
+		code := cmd.Run(args)
+		assert.Equal(code, 0)
+		assert.Empty(ui.ErrorWriter.String())
+		assert.Contains(ui.OutputWriter.String(), "web (Datacenters: all)")
+		assert.Contains(ui.OutputWriter.String(), "db (Datacenters: dc1, dc2)")
+	}
+
+	// create with an expiration TTL
+	{
+		ui := cli.NewMockUi()
+		cmd := New(ui)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-token=root",
+			"-policy-name=" + policy.Name,
+			"-expires-ttl=10m",
+		}
+
+		code := cmd.Run(args)
+		assert.Equal(code, 0)
+		assert.Empty(ui.ErrorWriter.String())
+		assert.Contains(ui.OutputWriter.String(), "Expiration Time:")
+	}
+
+	// create with a malformed service identity
+	{
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-token=root",
+			"-service-identity=web:dc1:dc2",
+		}
+
+		code := cmd.Run(args)
+		assert.Equal(code, 1)
+		assert.Contains(ui.ErrorWriter.String(), "Malformed -service-identity argument")
+	}
 }
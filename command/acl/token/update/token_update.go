@@ -22,13 +22,18 @@ type cmd struct {
 	http  *flags.HTTPFlags
 	help  string
 
-	tokenID       string
-	policyIDs     []string
-	policyNames   []string
-	description   string
-	mergePolicies bool
-	showMeta      bool
-	upgradeLegacy bool
+	tokenID            string
+	policyIDs          []string
+	policyNames        []string
+	roleIDs            []string
+	roleNames          []string
+	serviceIdents      []string
+	description        string
+	mergePolicies      bool
+	mergeRoles         bool
+	mergeServiceIdents bool
+	showMeta           bool
+	upgradeLegacy      bool
 }
 
 func (c *cmd) init() {
@@ -37,6 +42,10 @@ func (c *cmd) init() {
 		"as the content hash and raft indices should be shown for each entry")
 	c.flags.BoolVar(&c.mergePolicies, "merge-policies", false, "Merge the new policies "+
 		"with the existing policies")
+	c.flags.BoolVar(&c.mergeRoles, "merge-roles", false, "Merge the new roles "+
+		"with the existing roles")
+	c.flags.BoolVar(&c.mergeServiceIdents, "merge-service-identities", false, "Merge the new service identities "+
+		"with the existing service identities")
 	c.flags.StringVar(&c.tokenID, "id", "", "The Accessor ID of the token to read. "+
 		"It may be specified as a unique ID prefix but will error if the prefix "+
 		"matches multiple token Accessor IDs")
@@ -45,6 +54,13 @@ func (c *cmd) init() {
 		"policy to use for this token. May be specified multiple times")
 	c.flags.Var((*flags.AppendSliceValue)(&c.policyNames), "policy-name", "Name of a "+
 		"policy to use for this token. May be specified multiple times")
+	c.flags.Var((*flags.AppendSliceValue)(&c.roleIDs), "role-id", "ID of a "+
+		"role to use for this token. May be specified multiple times")
+	c.flags.Var((*flags.AppendSliceValue)(&c.roleNames), "role-name", "Name of a "+
+		"role to use for this token. May be specified multiple times")
+	c.flags.Var((*flags.AppendSliceValue)(&c.serviceIdents), "service-identity", "Name of a "+
+		"service identity to use for this token. May be specified multiple times. Format is "+
+		"the SERVICENAME or SERVICENAME:DATACENTER1,DATACENTER2,...")
 	c.flags.BoolVar(&c.upgradeLegacy, "upgrade-legacy", false, "Add new polices "+
 		"to a legacy token replacing all existing rules. This will cause the legacy "+
 		"token to behave exactly like a new token but keep the same Secret.\n"+
@@ -67,6 +83,12 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	serviceIdents, err := acl.ExtractServiceIdentities(c.serviceIdents)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
 	client, err := c.http.APIClient()
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
@@ -162,6 +184,68 @@ func (c *cmd) Run(args []string) int {
 		}
 	}
 
+	if c.mergeRoles {
+		for _, roleName := range c.roleNames {
+			found := false
+			for _, link := range token.Roles {
+				if link.Name == roleName {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				// We could resolve names to IDs here but there isn't any reason why its would be better
+				// than allowing the agent to do it.
+				token.Roles = append(token.Roles, &api.ACLTokenRoleLink{Name: roleName})
+			}
+		}
+
+		for _, roleID := range c.roleIDs {
+			roleID, err := acl.GetRoleIDFromPartial(client, roleID)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error resolving role ID %s: %v", roleID, err))
+				return 1
+			}
+			found := false
+
+			for _, link := range token.Roles {
+				if link.ID == roleID {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				token.Roles = append(token.Roles, &api.ACLTokenRoleLink{ID: roleID})
+			}
+		}
+	} else {
+		token.Roles = nil
+
+		for _, roleName := range c.roleNames {
+			// We could resolve names to IDs here but there isn't any reason why its would be better
+			// than allowing the agent to do it.
+			token.Roles = append(token.Roles, &api.ACLTokenRoleLink{Name: roleName})
+		}
+
+		for _, roleID := range c.roleIDs {
+			roleID, err := acl.GetRoleIDFromPartial(client, roleID)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error resolving role ID %s: %v", roleID, err))
+				return 1
+			}
+			token.Roles = append(token.Roles, &api.ACLTokenRoleLink{ID: roleID})
+		}
+	}
+
+	if c.mergeServiceIdents {
+		// Duplicate service names are merged by the servers.
+		token.ServiceIdentities = append(token.ServiceIdentities, serviceIdents...)
+	} else {
+		token.ServiceIdentities = serviceIdents
+	}
+
 	token, _, err = client.ACL().TokenUpdate(token, nil)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Failed to update token %s: %v", tokenID, err))
@@ -192,6 +276,14 @@ Usage: consul acl token update [options]
 
         $ consul acl token update -id abcd -description "replication" -merge-policies
 
+    Add a role to a token keeping its existing roles:
+
+        $ consul acl token update -id abcd -role-name "web-server" -merge-roles
+
+    Add a service identity to a token keeping its existing service identities:
+
+        $ consul acl token update -id abcd -service-identity "web" -merge-service-identities
+
       Update all editable fields of the token:
 
           $ consul acl token update -id abcd -description "replication" -policy-name "token-replication"
@@ -148,6 +148,60 @@ func TestTokenUpdateCommand(t *testing.T) {
 		assert.Equal("test token", token.Description)
 	}
 
+	// update with service identities
+	{
+		cmd := New(ui)
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-id=" + token.AccessorID,
+			"-token=root",
+			"-policy-name=" + policy.Name,
+			"-service-identity=web",
+		}
+
+		code := cmd.Run(args)
+		assert.Equal(code, 0)
+		assert.Empty(ui.ErrorWriter.String())
+
+		token, _, err := client.ACL().TokenRead(
+			token.AccessorID,
+			&api.QueryOptions{Token: "root"},
+		)
+		assert.NoError(err)
+		assert.NotNil(token)
+		assert.Equal([]*api.ACLServiceIdentity{
+			&api.ACLServiceIdentity{ServiceName: "web"},
+		}, token.ServiceIdentities)
+	}
+
+	// update merging service identities
+	{
+		cmd := New(ui)
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-id=" + token.AccessorID,
+			"-token=root",
+			"-merge-policies",
+			"-service-identity=db:dc1",
+			"-merge-service-identities",
+		}
+
+		code := cmd.Run(args)
+		assert.Equal(code, 0)
+		assert.Empty(ui.ErrorWriter.String())
+
+		token, _, err := client.ACL().TokenRead(
+			token.AccessorID,
+			&api.QueryOptions{Token: "root"},
+		)
+		assert.NoError(err)
+		assert.NotNil(token)
+		assert.Equal([]*api.ACLServiceIdentity{
+			&api.ACLServiceIdentity{ServiceName: "web"},
+			&api.ACLServiceIdentity{ServiceName: "db", Datacenters: []string{"dc1"}},
+		}, token.ServiceIdentities)
+	}
+
 	// Need legacy token now, hopefully server had time to generate an accessor ID
 	// in the background but wait for it if not.
 	var legacyToken *api.ACLToken
@@ -10,6 +10,12 @@ import (
 	aclplist "github.com/hashicorp/consul/command/acl/policy/list"
 	aclpread "github.com/hashicorp/consul/command/acl/policy/read"
 	aclpupdate "github.com/hashicorp/consul/command/acl/policy/update"
+	aclrole "github.com/hashicorp/consul/command/acl/role"
+	aclrcreate "github.com/hashicorp/consul/command/acl/role/create"
+	aclrdelete "github.com/hashicorp/consul/command/acl/role/delete"
+	aclrlist "github.com/hashicorp/consul/command/acl/role/list"
+	aclrread "github.com/hashicorp/consul/command/acl/role/read"
+	aclrupdate "github.com/hashicorp/consul/command/acl/role/update"
 	aclrules "github.com/hashicorp/consul/command/acl/rules"
 	acltoken "github.com/hashicorp/consul/command/acl/token"
 	acltclone "github.com/hashicorp/consul/command/acl/token/clone"
@@ -23,6 +29,11 @@ import (
 	catlistdc "github.com/hashicorp/consul/command/catalog/list/dc"
 	catlistnodes "github.com/hashicorp/consul/command/catalog/list/nodes"
 	catlistsvc "github.com/hashicorp/consul/command/catalog/list/services"
+	"github.com/hashicorp/consul/command/config"
+	cfgdelete "github.com/hashicorp/consul/command/config/delete"
+	cfglist "github.com/hashicorp/consul/command/config/list"
+	cfgread "github.com/hashicorp/consul/command/config/read"
+	cfgwrite "github.com/hashicorp/consul/command/config/write"
 	"github.com/hashicorp/consul/command/connect"
 	"github.com/hashicorp/consul/command/connect/ca"
 	caget "github.com/hashicorp/consul/command/connect/ca/get"
@@ -47,10 +58,14 @@ import (
 	kvdel "github.com/hashicorp/consul/command/kv/del"
 	kvexp "github.com/hashicorp/consul/command/kv/exp"
 	kvget "github.com/hashicorp/consul/command/kv/get"
+	kvhistory "github.com/hashicorp/consul/command/kv/history"
 	kvimp "github.com/hashicorp/consul/command/kv/imp"
 	kvput "github.com/hashicorp/consul/command/kv/put"
+	kvrollback "github.com/hashicorp/consul/command/kv/rollback"
 	"github.com/hashicorp/consul/command/leave"
 	"github.com/hashicorp/consul/command/lock"
+	"github.com/hashicorp/consul/command/login"
+	"github.com/hashicorp/consul/command/logout"
 	"github.com/hashicorp/consul/command/maint"
 	"github.com/hashicorp/consul/command/members"
 	"github.com/hashicorp/consul/command/monitor"
@@ -67,6 +82,7 @@ import (
 	svcsderegister "github.com/hashicorp/consul/command/services/deregister"
 	svcsregister "github.com/hashicorp/consul/command/services/register"
 	"github.com/hashicorp/consul/command/snapshot"
+	snapextract "github.com/hashicorp/consul/command/snapshot/extract"
 	snapinspect "github.com/hashicorp/consul/command/snapshot/inspect"
 	snaprestore "github.com/hashicorp/consul/command/snapshot/restore"
 	snapsave "github.com/hashicorp/consul/command/snapshot/save"
@@ -97,6 +113,12 @@ func init() {
 	Register("acl policy read", func(ui cli.Ui) (cli.Command, error) { return aclpread.New(ui), nil })
 	Register("acl policy update", func(ui cli.Ui) (cli.Command, error) { return aclpupdate.New(ui), nil })
 	Register("acl policy delete", func(ui cli.Ui) (cli.Command, error) { return aclpdelete.New(ui), nil })
+	Register("acl role", func(cli.Ui) (cli.Command, error) { return aclrole.New(), nil })
+	Register("acl role create", func(ui cli.Ui) (cli.Command, error) { return aclrcreate.New(ui), nil })
+	Register("acl role list", func(ui cli.Ui) (cli.Command, error) { return aclrlist.New(ui), nil })
+	Register("acl role read", func(ui cli.Ui) (cli.Command, error) { return aclrread.New(ui), nil })
+	Register("acl role update", func(ui cli.Ui) (cli.Command, error) { return aclrupdate.New(ui), nil })
+	Register("acl role delete", func(ui cli.Ui) (cli.Command, error) { return aclrdelete.New(ui), nil })
 	Register("acl translate-rules", func(ui cli.Ui) (cli.Command, error) { return aclrules.New(ui), nil })
 	Register("acl set-agent-token", func(ui cli.Ui) (cli.Command, error) { return aclagent.New(ui), nil })
 	Register("acl token", func(cli.Ui) (cli.Command, error) { return acltoken.New(), nil })
@@ -113,6 +135,11 @@ func init() {
 	Register("catalog datacenters", func(ui cli.Ui) (cli.Command, error) { return catlistdc.New(ui), nil })
 	Register("catalog nodes", func(ui cli.Ui) (cli.Command, error) { return catlistnodes.New(ui), nil })
 	Register("catalog services", func(ui cli.Ui) (cli.Command, error) { return catlistsvc.New(ui), nil })
+	Register("config", func(cli.Ui) (cli.Command, error) { return config.New(), nil })
+	Register("config delete", func(ui cli.Ui) (cli.Command, error) { return cfgdelete.New(ui), nil })
+	Register("config list", func(ui cli.Ui) (cli.Command, error) { return cfglist.New(ui), nil })
+	Register("config read", func(ui cli.Ui) (cli.Command, error) { return cfgread.New(ui), nil })
+	Register("config write", func(ui cli.Ui) (cli.Command, error) { return cfgwrite.New(ui), nil })
 	Register("connect", func(ui cli.Ui) (cli.Command, error) { return connect.New(), nil })
 	Register("connect ca", func(ui cli.Ui) (cli.Command, error) { return ca.New(), nil })
 	Register("connect ca get-config", func(ui cli.Ui) (cli.Command, error) { return caget.New(ui), nil })
@@ -137,10 +164,14 @@ func init() {
 	Register("kv delete", func(ui cli.Ui) (cli.Command, error) { return kvdel.New(ui), nil })
 	Register("kv export", func(ui cli.Ui) (cli.Command, error) { return kvexp.New(ui), nil })
 	Register("kv get", func(ui cli.Ui) (cli.Command, error) { return kvget.New(ui), nil })
+	Register("kv history", func(ui cli.Ui) (cli.Command, error) { return kvhistory.New(ui), nil })
 	Register("kv import", func(ui cli.Ui) (cli.Command, error) { return kvimp.New(ui), nil })
 	Register("kv put", func(ui cli.Ui) (cli.Command, error) { return kvput.New(ui), nil })
+	Register("kv rollback", func(ui cli.Ui) (cli.Command, error) { return kvrollback.New(ui), nil })
 	Register("leave", func(ui cli.Ui) (cli.Command, error) { return leave.New(ui), nil })
 	Register("lock", func(ui cli.Ui) (cli.Command, error) { return lock.New(ui), nil })
+	Register("login", func(ui cli.Ui) (cli.Command, error) { return login.New(ui), nil })
+	Register("logout", func(ui cli.Ui) (cli.Command, error) { return logout.New(ui), nil })
 	Register("maint", func(ui cli.Ui) (cli.Command, error) { return maint.New(ui), nil })
 	Register("members", func(ui cli.Ui) (cli.Command, error) { return members.New(ui), nil })
 	Register("monitor", func(ui cli.Ui) (cli.Command, error) { return monitor.New(ui, MakeShutdownCh()), nil })
@@ -157,6 +188,7 @@ func init() {
 	Register("services register", func(ui cli.Ui) (cli.Command, error) { return svcsregister.New(ui), nil })
 	Register("services deregister", func(ui cli.Ui) (cli.Command, error) { return svcsderegister.New(ui), nil })
 	Register("snapshot", func(cli.Ui) (cli.Command, error) { return snapshot.New(), nil })
+	Register("snapshot extract", func(ui cli.Ui) (cli.Command, error) { return snapextract.New(ui), nil })
 	Register("snapshot inspect", func(ui cli.Ui) (cli.Command, error) { return snapinspect.New(ui), nil })
 	Register("snapshot restore", func(ui cli.Ui) (cli.Command, error) { return snaprestore.New(ui), nil })
 	Register("snapshot save", func(ui cli.Ui) (cli.Command, error) { return snapsave.New(ui), nil })
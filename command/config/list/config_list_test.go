@@ -0,0 +1,62 @@
+package list
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigList_noTabs(t *testing.T) {
+	t.Parallel()
+
+	require.NotContains(t, New(cli.NewMockUi()).Help(), "\t")
+}
+
+func TestConfigList(t *testing.T) {
+	t.Parallel()
+
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+	client := a.Client()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	for _, name := range []string{"web", "foo", "api"} {
+		_, err := client.ConfigEntries().Set(&api.ServiceConfigEntry{
+			Kind:     api.ServiceDefaults,
+			Name:     name,
+			Protocol: "tcp",
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-kind=" + api.ServiceDefaults,
+	}
+
+	code := c.Run(args)
+	require.Equal(t, 0, code)
+	require.Empty(t, ui.ErrorWriter.String())
+
+	services := strings.Split(strings.Trim(ui.OutputWriter.String(), "\n"), "\n")
+	sort.Strings(services)
+	require.Equal(t, []string{"api", "foo", "web"}, services)
+}
+
+func TestConfigList_InvalidArgs(t *testing.T) {
+	t.Parallel()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	require.NotEqual(t, 0, c.Run([]string{}))
+	require.Contains(t, ui.ErrorWriter.String(), "-kind")
+}
@@ -0,0 +1,72 @@
+package read
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigRead_noTabs(t *testing.T) {
+	t.Parallel()
+
+	require.NotContains(t, New(cli.NewMockUi()).Help(), "\t")
+}
+
+func TestConfigRead(t *testing.T) {
+	t.Parallel()
+
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+	client := a.Client()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	entry := &api.ServiceConfigEntry{
+		Kind:     api.ServiceDefaults,
+		Name:     "web",
+		Protocol: "tcp",
+	}
+	_, err := client.ConfigEntries().Set(entry, nil)
+	require.NoError(t, err)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-kind=" + api.ServiceDefaults,
+		"-name=web",
+	}
+
+	code := c.Run(args)
+	require.Equal(t, 0, code)
+	require.Empty(t, ui.ErrorWriter.String())
+
+	var read api.ServiceConfigEntry
+	require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &read))
+	require.Equal(t, entry.Kind, read.Kind)
+	require.Equal(t, entry.Name, read.Name)
+	require.Equal(t, entry.Protocol, read.Protocol)
+}
+
+func TestConfigRead_InvalidArgs(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]string{
+		"no kind": []string{},
+		"no name": []string{"-kind", "service-defaults"},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := New(ui)
+			require.NotEqual(t, 0, c.Run(tcase))
+			require.NotEmpty(t, ui.ErrorWriter.String())
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package write
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/consul/command/helpers"
+	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/hcl"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// testStdin is the input for testing.
+	testStdin io.Reader
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+	if len(args) != 1 {
+		c.UI.Error("Must provide exactly one positional argument to specify the config entry to write")
+		return 1
+	}
+
+	data, err := helpers.LoadDataSourceNoRaw(args[0], c.testStdin)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to load data: %v", err))
+		return 1
+	}
+
+	entry, err := parseConfigEntry(data)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to decode config entry input: %v", err))
+		return 1
+	}
+
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connect to Consul agent: %s", err))
+		return 1
+	}
+
+	if _, err := client.ConfigEntries().Set(entry, nil); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing config entry %s/%s: %v", entry.GetKind(), entry.GetName(), err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Config entry written: %s/%s", entry.GetKind(), entry.GetName()))
+	return 0
+}
+
+// repeatedBlocks lists the config entry fields that hold lists of objects and
+// so may be given as repeated HCL blocks.
+var repeatedBlocks = []string{
+	"Routes",
+	"Routes.Match.HTTP.Header",
+	"Routes.Match.HTTP.QueryParam",
+	"routes",
+	"routes.match.http.header",
+	"routes.match.http.queryparam",
+	"Splits",
+	"splits",
+}
+
+// parseConfigEntry decodes a single HCL or JSON config entry. Block style
+// HCL decodes nested objects as single element lists of maps, so those are
+// flattened before handing the result to the api decoder.
+func parseConfigEntry(data string) (entry api.ConfigEntry, err error) {
+	var raw map[string]interface{}
+	if err := hcl.Decode(&raw, data); err != nil {
+		return nil, err
+	}
+
+	// PatchSliceOfMaps panics on repeated blocks for fields that are not
+	// declared as lists, so report that as a decoding error instead.
+	defer func() {
+		if r := recover(); r != nil {
+			entry, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+	raw = lib.PatchSliceOfMaps(raw, repeatedBlocks)
+
+	return api.DecodeConfigEntry(raw)
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return flags.Usage(c.help, nil)
+}
+
+const synopsis = "Create or update a centralized config entry"
+const help = `
+Usage: consul config write [options] <configuration>
+
+  Request a config entry to be created or updated. The configuration
+  argument is either a file path or '-' to indicate that the config
+  should be read from stdin. The data should be either in HCL or
+  JSON form.
+
+  Example (from file):
+
+    $ consul config write web.service.hcl
+
+  Example (from stdin):
+
+    $ consul config write -
+`
@@ -0,0 +1,345 @@
+package write
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigWrite_noTabs(t *testing.T) {
+	t.Parallel()
+
+	require.NotContains(t, New(cli.NewMockUi()).Help(), "\t")
+}
+
+func TestConfigWrite(t *testing.T) {
+	t.Parallel()
+
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+	client := a.Client()
+
+	f := testFile(t, "hcl")
+	defer os.Remove(f.Name())
+
+	t.Run("File", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := New(ui)
+
+		_, err := f.WriteString(`
+			Kind = "service-defaults"
+			Name = "web"
+			Protocol = "udp"
+		`)
+		require.NoError(t, err)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			f.Name(),
+		}
+
+		code := c.Run(args)
+		require.Empty(t, ui.ErrorWriter.String())
+		require.Equal(t, 0, code)
+
+		entry, _, err := client.ConfigEntries().Get("service-defaults", "web", nil)
+		require.NoError(t, err)
+		svc, ok := entry.(*api.ServiceConfigEntry)
+		require.True(t, ok)
+		require.Equal(t, api.ServiceDefaults, svc.Kind)
+		require.Equal(t, "web", svc.Name)
+		require.Equal(t, "udp", svc.Protocol)
+	})
+
+	t.Run("Stdin", func(t *testing.T) {
+		stdinR, stdinW := io.Pipe()
+
+		ui := cli.NewMockUi()
+		c := New(ui)
+		c.testStdin = stdinR
+
+		go func() {
+			stdinW.Write([]byte(`{
+				"Kind": "proxy-defaults",
+				"Name": "global",
+				"Config": {
+					"foo": "bar",
+					"bar": 1.0
+				}
+			}`))
+			stdinW.Close()
+		}()
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-",
+		}
+
+		code := c.Run(args)
+		require.Empty(t, ui.ErrorWriter.String())
+		require.Equal(t, 0, code)
+
+		entry, _, err := client.ConfigEntries().Get(api.ProxyDefaults, api.ProxyConfigGlobal, nil)
+		require.NoError(t, err)
+		proxy, ok := entry.(*api.ProxyConfigEntry)
+		require.True(t, ok)
+		require.Equal(t, api.ProxyDefaults, proxy.Kind)
+		require.Equal(t, api.ProxyConfigGlobal, proxy.Name)
+		require.Equal(t, map[string]interface{}{"foo": "bar", "bar": 1.0}, proxy.Config)
+	})
+
+	t.Run("No config", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		c := New(ui)
+
+		code := c.Run([]string{})
+		require.NotEqual(t, 0, code)
+		require.NotEmpty(t, ui.ErrorWriter.String())
+	})
+}
+
+func TestParseConfigEntry(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		body     string
+		expected api.ConfigEntry
+		err      string
+	}{
+		{
+			name: "service-defaults lowercase keys",
+			body: `
+				kind = "service-defaults"
+				name = "web"
+				protocol = "http"
+			`,
+			expected: &api.ServiceConfigEntry{
+				Kind:     api.ServiceDefaults,
+				Name:     "web",
+				Protocol: "http",
+			},
+		},
+		{
+			name: "service-defaults block",
+			body: `
+				Kind = "service-defaults"
+				Name = "web"
+				Connect {
+					SidecarProxy = true
+				}
+			`,
+			expected: &api.ServiceConfigEntry{
+				Kind: api.ServiceDefaults,
+				Name: "web",
+				Connect: api.ConnectConfiguration{
+					SidecarProxy: true,
+				},
+			},
+		},
+		{
+			name: "proxy-defaults hcl",
+			body: `
+				Kind = "proxy-defaults"
+				Name = "global"
+				Config {
+					foo = "bar"
+				}
+			`,
+			expected: &api.ProxyConfigEntry{
+				Kind: api.ProxyDefaults,
+				Name: api.ProxyConfigGlobal,
+				Config: map[string]interface{}{
+					"foo": "bar",
+				},
+			},
+		},
+		{
+			name: "service-router repeated blocks",
+			body: `
+				Kind = "service-router"
+				Name = "web"
+				Routes {
+					Match {
+						HTTP {
+							PathPrefix = "/admin"
+							Header {
+								Name    = "x-debug"
+								Present = true
+							}
+							Header {
+								Name  = "x-env"
+								Exact = "prod"
+							}
+							Methods = ["GET", "POST"]
+						}
+					}
+					Destination {
+						Service        = "admin"
+						RequestTimeout = "5s"
+					}
+				}
+				Routes {
+					Match {
+						HTTP {
+							QueryParam {
+								Name  = "version"
+								Exact = "2"
+							}
+						}
+					}
+					Destination {
+						ServiceSubset = "v2"
+					}
+				}
+			`,
+			expected: &api.ServiceRouterConfigEntry{
+				Kind: api.ServiceRouter,
+				Name: "web",
+				Routes: []api.ServiceRoute{
+					{
+						Match: &api.ServiceRouteMatch{
+							HTTP: &api.ServiceRouteHTTPMatch{
+								PathPrefix: "/admin",
+								Header: []api.ServiceRouteHTTPMatchHeader{
+									{Name: "x-debug", Present: true},
+									{Name: "x-env", Exact: "prod"},
+								},
+								Methods: []string{"GET", "POST"},
+							},
+						},
+						Destination: &api.ServiceRouteDestination{
+							Service:        "admin",
+							RequestTimeout: 5 * time.Second,
+						},
+					},
+					{
+						Match: &api.ServiceRouteMatch{
+							HTTP: &api.ServiceRouteHTTPMatch{
+								QueryParam: []api.ServiceRouteHTTPMatchQueryParam{
+									{Name: "version", Exact: "2"},
+								},
+							},
+						},
+						Destination: &api.ServiceRouteDestination{
+							ServiceSubset: "v2",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "service-splitter repeated blocks",
+			body: `
+				Kind = "service-splitter"
+				Name = "web"
+				Splits {
+					Weight        = 90
+					ServiceSubset = "v1"
+				}
+				Splits {
+					Weight        = 10
+					ServiceSubset = "v2"
+				}
+			`,
+			expected: &api.ServiceSplitterConfigEntry{
+				Kind: api.ServiceSplitter,
+				Name: "web",
+				Splits: []api.ServiceSplit{
+					{Weight: 90, ServiceSubset: "v1"},
+					{Weight: 10, ServiceSubset: "v2"},
+				},
+			},
+		},
+		{
+			name: "service-resolver subsets",
+			body: `
+				Kind          = "service-resolver"
+				Name          = "web"
+				DefaultSubset = "v1"
+				Subsets {
+					v1 {
+						Filter = "Service.Meta.version == v1"
+					}
+					v2 {
+						Filter = "Service.Meta.version == v2"
+					}
+				}
+				Failover {
+					"*" {
+						Datacenters = ["dc2", "dc3"]
+					}
+				}
+				ConnectTimeout = "15s"
+			`,
+			expected: &api.ServiceResolverConfigEntry{
+				Kind:          api.ServiceResolver,
+				Name:          "web",
+				DefaultSubset: "v1",
+				Subsets: map[string]api.ServiceResolverSubset{
+					"v1": {Filter: "Service.Meta.version == v1"},
+					"v2": {Filter: "Service.Meta.version == v2"},
+				},
+				Failover: map[string]api.ServiceResolverFailover{
+					"*": {Datacenters: []string{"dc2", "dc3"}},
+				},
+				ConnectTimeout: 15 * time.Second,
+			},
+		},
+		{
+			name: "missing kind",
+			body: `Name = "web"`,
+			err:  "Kind/kind",
+		},
+		{
+			name: "repeated block",
+			body: `
+				Kind = "service-defaults"
+				Name = "web"
+				Connect {}
+				Connect {}
+			`,
+			err: "more than one element",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			entry, err := parseConfigEntry(tc.body)
+			if tc.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, entry)
+		})
+	}
+}
+
+func testFile(t *testing.T, suffix string) *os.File {
+	f := testutil.TempFile(t, "config-write-"+suffix)
+	newName := f.Name() + "." + suffix
+	if err := os.Rename(f.Name(), newName); err != nil {
+		os.Remove(f.Name())
+		t.Fatalf("err: %s", err)
+	}
+
+	f, err := os.OpenFile(newName, os.O_RDWR, 0644)
+	if err != nil {
+		os.Remove(newName)
+		t.Fatalf("err: %s", err)
+	}
+
+	return f
+}
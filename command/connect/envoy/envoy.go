@@ -9,6 +9,7 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -42,12 +43,13 @@ type cmd struct {
 	client *api.Client
 
 	// flags
-	proxyID    string
-	sidecarFor string
-	adminBind  string
-	envoyBin   string
-	bootstrap  bool
-	grpcAddr   string
+	proxyID     string
+	sidecarFor  string
+	meshGateway bool
+	adminBind   string
+	envoyBin    string
+	bootstrap   bool
+	grpcAddr    string
 }
 
 func (c *cmd) init() {
@@ -62,6 +64,10 @@ func (c *cmd) init() {
 			"with the agent as a connect-proxy with Proxy.DestinationServiceID set "+
 			"to this value. If more than one such proxy is registered it will fail.")
 
+	c.flags.BoolVar(&c.meshGateway, "mesh-gateway", false,
+		"Configure Envoy as a Mesh Gateway. Unless -proxy-id is given it requires "+
+			"that exactly one mesh-gateway service is registered with the local agent.")
+
 	c.flags.StringVar(&c.envoyBin, "envoy-binary", "",
 		"The full path to the envoy binary to run. By default will just search "+
 			"$PATH. Ignored if -bootstrap is used.")
@@ -129,9 +135,17 @@ func (c *cmd) Run(args []string) int {
 		}
 		c.proxyID = proxyID
 	}
+	if c.proxyID == "" && c.meshGateway {
+		proxyID, err := c.lookupMeshGatewayProxyID()
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		c.proxyID = proxyID
+	}
 	if c.proxyID == "" {
-		c.UI.Error("No proxy ID specified. One of -proxy-id or -sidecar-for is " +
-			"required")
+		c.UI.Error("No proxy ID specified. One of -proxy-id, -sidecar-for or " +
+			"-mesh-gateway is required")
 		return 1
 	}
 
@@ -267,6 +281,33 @@ func (c *cmd) lookupProxyIDForSidecar() (string, error) {
 	return proxyCmd.LookupProxyIDForSidecar(c.client, c.sidecarFor)
 }
 
+// lookupMeshGatewayProxyID returns the ID of the only mesh gateway registered
+// with the local agent.
+func (c *cmd) lookupMeshGatewayProxyID() (string, error) {
+	svcs, err := c.client.Agent().Services()
+	if err != nil {
+		return "", fmt.Errorf("Failed looking up mesh gateway info: %s", err)
+	}
+
+	var proxyIDs []string
+	for _, svc := range svcs {
+		if svc.Kind == api.ServiceKindMeshGateway {
+			proxyIDs = append(proxyIDs, svc.ID)
+		}
+	}
+
+	if len(proxyIDs) == 0 {
+		return "", fmt.Errorf("No mesh gateway registered")
+	}
+	if len(proxyIDs) > 1 {
+		sort.Strings(proxyIDs)
+		return "", fmt.Errorf("More than one mesh gateway registered.\n"+
+			"    Start proxy with -proxy-id and one of the following IDs: %s",
+			strings.Join(proxyIDs, ", "))
+	}
+	return proxyIDs[0], nil
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -299,4 +340,10 @@ Usage: consul connect envoy [options]
 
     $ consul connect envoy -sidecar-for web
 
+  The proxy can instead be run as a Mesh Gateway that routes Connect traffic
+  between datacenters. It assumes that a service of kind "mesh-gateway" was
+  already registered with the local agent.
+
+    $ consul connect envoy -mesh-gateway
+
 `
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/consul/agent"
 	"github.com/hashicorp/consul/agent/xds"
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/require"
@@ -165,3 +166,75 @@ func TestGenerateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestMeshGatewayProxyID(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Name    string
+		Config  string
+		WantID  string
+		WantErr string
+	}{
+		{
+			Name:    "no gateway",
+			Config:  `services { name = "web" port = 1111 }`,
+			WantErr: "No mesh gateway registered",
+		},
+		{
+			Name: "one gateway",
+			Config: `
+			services {
+				kind = "mesh-gateway"
+				name = "mesh-gateway"
+				port = 8443
+			}`,
+			WantID: "mesh-gateway",
+		},
+		{
+			Name: "two gateways",
+			Config: `
+			services {
+				kind = "mesh-gateway"
+				id = "mesh-gateway-1"
+				name = "mesh-gateway"
+				port = 8443
+			}
+			services {
+				kind = "mesh-gateway"
+				id = "mesh-gateway-2"
+				name = "mesh-gateway"
+				port = 8444
+			}`,
+			WantErr: `More than one mesh gateway registered.
+    Start proxy with -proxy-id and one of the following IDs: mesh-gateway-1, mesh-gateway-2`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			require := require.New(t)
+
+			a := agent.NewTestAgent(t, t.Name(), tc.Config)
+			defer a.Shutdown()
+
+			ui := cli.NewMockUi()
+			c := New(ui)
+
+			code := c.Run([]string{
+				"-http-addr=" + a.HTTPAddr(),
+				"-mesh-gateway",
+				"-bootstrap",
+			})
+			if tc.WantErr != "" {
+				require.Equal(1, code, ui.ErrorWriter.String())
+				require.Contains(ui.ErrorWriter.String(), tc.WantErr)
+				return
+			}
+			require.Equal(0, code, ui.ErrorWriter.String())
+			require.Equal(tc.WantID, c.proxyID)
+		})
+	}
+}
@@ -40,3 +40,18 @@ func LoadDataSource(data string, testStdin io.Reader) (string, error) {
 		return data, nil
 	}
 }
+
+// LoadDataSourceNoRaw loads data from a file path, or from stdin when the
+// argument is "-". Unlike LoadDataSource the argument is never treated as
+// the raw data itself.
+func LoadDataSourceNoRaw(data string, testStdin io.Reader) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("Failed to load data: must specify a file path or '-' for stdin")
+	}
+
+	if data == "-" {
+		return LoadDataSource(data, testStdin)
+	}
+
+	return LoadDataSource("@"+data, testStdin)
+}
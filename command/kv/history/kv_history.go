@@ -0,0 +1,151 @@
+package history
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI           cli.Ui
+	flags        *flag.FlagSet
+	http         *flags.HTTPFlags
+	help         string
+	base64encode bool
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.base64encode, "base64", false,
+		"Base64 encode the values. The default value is false.")
+
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	key := ""
+
+	// Check for arg validation
+	args = c.flags.Args()
+	switch len(args) {
+	case 0:
+		key = ""
+	case 1:
+		key = args[0]
+	default:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	// This is just a "nice" thing to do. Since pairs cannot start with a /, but
+	// users will likely put "/" or "/foo", lets go ahead and strip that for them
+	// here.
+	if len(key) > 0 && key[0] == '/' {
+		key = key[1:]
+	}
+
+	if key == "" {
+		c.UI.Error("Error! Missing KEY argument")
+		return 1
+	}
+
+	// Create and test the HTTP client
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	entries, _, err := client.KV().History(key, &api.QueryOptions{
+		AllowStale: c.http.Stale(),
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
+		return 1
+	}
+
+	if len(entries) == 0 {
+		c.UI.Error(fmt.Sprintf("Error! No history exists for: %s", key))
+		return 1
+	}
+
+	var b bytes.Buffer
+	if err := prettyHistory(&b, entries, c.base64encode); err != nil {
+		c.UI.Error(fmt.Sprintf("Error rendering KV history: %s", err))
+		return 1
+	}
+	c.UI.Info(b.String())
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+func prettyHistory(w io.Writer, entries []*api.KVHistoryEntry, base64EncodeValue bool) error {
+	tw := tabwriter.NewWriter(w, 0, 2, 6, ' ', 0)
+	fmt.Fprint(tw, "ModifyIndex\tTime\tFlags\tValue\n")
+	for _, entry := range entries {
+		written := "-"
+		if !entry.Time.IsZero() {
+			written = entry.Time.Local().Format(time.RFC3339)
+		}
+
+		var value string
+		switch {
+		case entry.Deleted:
+			value = "<deleted>"
+		case base64EncodeValue:
+			value = base64.StdEncoding.EncodeToString(entry.Value)
+		default:
+			value = string(entry.Value)
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\n", entry.ModifyIndex, written, entry.Flags, value)
+	}
+	return tw.Flush()
+}
+
+const synopsis = "Lists the past versions of a key in the KV store"
+const help = `
+Usage: consul kv history [options] KEY
+
+  Lists the versions of the given key, oldest first, with the index and time
+  they were written at. Past versions are only kept for the keys under the
+  prefixes configured with "kv_history" on the servers. For other keys only
+  the current version is listed.
+
+  To list the versions of the key named "redis/config/connections":
+
+      $ consul kv history redis/config/connections
+
+  A version can be read back with "consul kv get" using its index, or
+  restored with "consul kv rollback".
+
+  For a full list of options and examples, please see the Consul documentation.
+`
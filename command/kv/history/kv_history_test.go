@@ -0,0 +1,123 @@
+package history
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestKVHistoryCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestKVHistoryCommand_Validation(t *testing.T) {
+	t.Parallel()
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"no key": {
+			[]string{},
+			"Missing KEY argument",
+		},
+		"extra args": {
+			[]string{"foo", "bar", "baz"},
+			"Too many arguments",
+		},
+	}
+
+	for name, tc := range cases {
+		// Ensure our buffer is always clear
+		if ui.ErrorWriter != nil {
+			ui.ErrorWriter.Reset()
+		}
+		if ui.OutputWriter != nil {
+			ui.OutputWriter.Reset()
+		}
+
+		code := c.Run(tc.args)
+		if code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+
+		output := ui.ErrorWriter.String()
+		if !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestKVHistoryCommand(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), `
+		kv_history {
+			prefix = "foo"
+			max_versions = 10
+		}
+	`)
+	defer a.Shutdown()
+	client := a.Client()
+
+	for _, value := range []string{"bar", "baz"} {
+		if _, err := client.KV().Put(&api.KVPair{Key: "foo", Value: []byte(value)}, nil); err != nil {
+			t.Fatalf("err: %#v", err)
+		}
+	}
+	if _, err := client.KV().Delete("foo", nil); err != nil {
+		t.Fatalf("err: %#v", err)
+	}
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"foo",
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("bad: %#v", lines)
+	}
+	for i, value := range []string{"Value", "bar", "baz", "<deleted>"} {
+		if !strings.HasSuffix(lines[i], value) {
+			t.Errorf("line %d: expected %q to end with %q", i, lines[i], value)
+		}
+	}
+}
+
+func TestKVHistoryCommand_Missing(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"not-a-real-key",
+	}
+
+	code := c.Run(args)
+	if code == 0 {
+		t.Fatalf("expected bad code")
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "No history exists") {
+		t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
@@ -32,6 +33,7 @@ type cmd struct {
 	session       string
 	acquire       bool
 	release       bool
+	ttl           time.Duration
 
 	// testStdin is the input for testing.
 	testStdin io.Reader
@@ -64,6 +66,10 @@ func (c *cmd) init() {
 		"Forfeit the lock on the key at the given path. This requires the "+
 			"-session flag to be set. The key must be held by the session in order to "+
 			"be unlocked. The default value is false.")
+	c.flags.DurationVar(&c.ttl, "ttl", 0,
+		"Delete the key once this duration has passed since it was written, "+
+			"such as \"30s\" or \"1h\". Writing the key again without a TTL "+
+			"removes the expiration. The default value is 0 (never expires).")
 
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
@@ -117,6 +123,7 @@ func (c *cmd) Run(args []string) int {
 		Flags:       c.kvflags,
 		Value:       dataBytes,
 		Session:     c.session,
+		TTL:         c.ttl,
 	}
 
 	switch {
@@ -231,5 +238,9 @@ Usage: consul kv put [options] KEY [DATA]
 
       $ consul kv put -cas -modify-index=844 config/redis/maxconns 5
 
+  To have the key deleted automatically after a while, specify the -ttl flag:
+
+      $ consul kv put -ttl=30s deploys/web/in-progress true
+
   Additional flags and more advanced use cases are detailed below.
 `
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/agent"
 	"github.com/hashicorp/consul/api"
@@ -321,6 +322,40 @@ func TestKVPutCommand_Flags(t *testing.T) {
 	}
 }
 
+func TestKVPutCommand_TTL(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-ttl", "1h",
+		"foo",
+	}
+
+	start := time.Now()
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	data, _, err := client.KV().Get("foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.ExpirationTime == nil {
+		t.Fatalf("missing expiration time: %#v", data)
+	}
+	if data.ExpirationTime.Before(start.Add(time.Hour)) || data.ExpirationTime.After(time.Now().Add(time.Hour)) {
+		t.Errorf("bad: %v", data.ExpirationTime)
+	}
+}
+
 func TestKVPutCommand_CAS(t *testing.T) {
 	t.Parallel()
 	a := agent.NewTestAgent(t, t.Name(), ``)
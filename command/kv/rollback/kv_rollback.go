@@ -0,0 +1,155 @@
+package rollback
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// flags
+	index uint64
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.Uint64Var(&c.index, "index", 0,
+		"Index to roll the key back to. The key gets the value and flags it "+
+			"had at this index, as listed by \"consul kv history\". This is required.")
+
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	key := ""
+
+	// Check for arg validation
+	args = c.flags.Args()
+	switch len(args) {
+	case 0:
+		key = ""
+	case 1:
+		key = args[0]
+	default:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	// This is just a "nice" thing to do. Since pairs cannot start with a /, but
+	// users will likely put "/" or "/foo", lets go ahead and strip that for them
+	// here.
+	if len(key) > 0 && key[0] == '/' {
+		key = key[1:]
+	}
+
+	if key == "" {
+		c.UI.Error("Error! Missing KEY argument")
+		return 1
+	}
+	if c.index == 0 {
+		c.UI.Error("Must specify -index!")
+		return 1
+	}
+
+	// Create and test the HTTP client
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	// Look up the version to roll back to and the current one, which is used
+	// for the CAS below so a concurrent write isn't overwritten.
+	target, _, err := client.KV().GetAtIndex(key, c.index, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
+		return 1
+	}
+	if target == nil {
+		c.UI.Error(fmt.Sprintf("Error! No version of %s exists at index %d", key, c.index))
+		return 1
+	}
+
+	current, _, err := client.KV().Get(key, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
+		return 1
+	}
+
+	var modifyIndex uint64
+	if current != nil {
+		if current.ModifyIndex == target.ModifyIndex {
+			c.UI.Info(fmt.Sprintf("Success! %s already has the version from index %d", key, c.index))
+			return 0
+		}
+		modifyIndex = current.ModifyIndex
+	}
+
+	pair := &api.KVPair{
+		Key:         key,
+		ModifyIndex: modifyIndex,
+		Flags:       target.Flags,
+		Value:       target.Value,
+	}
+	ok, _, err := client.KV().CAS(pair, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error! Did not write to %s: %s", key, err))
+		return 1
+	}
+	if !ok {
+		c.UI.Error(fmt.Sprintf("Error! Did not write to %s: CAS failed", key))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Success! Rolled back %s to the version from index %d", key, c.index))
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Restores a past version of a key in the KV store"
+const help = `
+Usage: consul kv rollback [options] -index=INDEX KEY
+
+  Writes back the value and flags the given key had at the given index. The
+  write is a Check-And-Set operation against the current version of the key,
+  so it fails instead of overwriting a concurrent change.
+
+  Past versions are only kept for the keys under the prefixes configured with
+  "kv_history" on the servers. Use "consul kv history" to list them:
+
+      $ consul kv history redis/config/connections
+
+  To restore the version from index 844:
+
+      $ consul kv rollback -index=844 redis/config/connections
+
+  For a full list of options and examples, please see the Consul documentation.
+`
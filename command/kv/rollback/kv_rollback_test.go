@@ -0,0 +1,127 @@
+package rollback
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestKVRollbackCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestKVRollbackCommand_Validation(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"no key": {
+			[]string{"-index=1"},
+			"Missing KEY argument",
+		},
+		"no index": {
+			[]string{"foo"},
+			"Must specify -index",
+		},
+		"extra args": {
+			[]string{"-index=1", "foo", "bar", "baz"},
+			"Too many arguments",
+		},
+	}
+
+	for name, tc := range cases {
+		// Flag values stick between runs, so use a new command each time.
+		ui := cli.NewMockUi()
+		c := New(ui)
+
+		code := c.Run(tc.args)
+		if code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+
+		output := ui.ErrorWriter.String()
+		if !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestKVRollbackCommand(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), `
+		kv_history {
+			prefix = "foo"
+			max_versions = 10
+		}
+	`)
+	defer a.Shutdown()
+	client := a.Client()
+
+	for i, value := range []string{"bar", "baz"} {
+		if _, err := client.KV().Put(&api.KVPair{Key: "foo", Flags: uint64(i), Value: []byte(value)}, nil); err != nil {
+			t.Fatalf("err: %#v", err)
+		}
+	}
+	history, _, err := client.KV().History("foo", nil)
+	if err != nil {
+		t.Fatalf("err: %#v", err)
+	}
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		fmt.Sprintf("-index=%d", history[0].ModifyIndex),
+		"foo",
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	pair, _, err := client.KV().Get("foo", nil)
+	if err != nil {
+		t.Fatalf("err: %#v", err)
+	}
+	if string(pair.Value) != "bar" || pair.Flags != 0 || pair.ModifyIndex <= history[1].ModifyIndex {
+		t.Fatalf("bad: %#v", pair)
+	}
+}
+
+func TestKVRollbackCommand_NoVersion(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	if _, err := client.KV().Put(&api.KVPair{Key: "foo", Value: []byte("bar")}, nil); err != nil {
+		t.Fatalf("err: %#v", err)
+	}
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-index=1",
+		"foo",
+	}
+
+	code := c.Run(args)
+	if code == 0 {
+		t.Fatalf("expected bad code")
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Unexpected response code: 400") {
+		t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,214 @@
+package login
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/agent/consul/authmethod/testauth"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginCommand_noTabs(t *testing.T) {
+	t.Parallel()
+
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestLoginCommand(t *testing.T) {
+	t.Parallel()
+
+	testDir := testutil.TempDir(t, "acl")
+	defer os.RemoveAll(testDir)
+
+	a := agent.NewTestAgent(t, t.Name(), `
+	primary_datacenter = "dc1"
+	acl {
+		enabled = true
+		tokens {
+			master = "root"
+		}
+	}`)
+
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	client := a.Client()
+
+	tokenSinkFile := filepath.Join(testDir, "test.token")
+	bearerTokenFile := filepath.Join(testDir, "bearer.token")
+
+	t.Run("method is required", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd := New(ui)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-token=root",
+		}
+
+		code := cmd.Run(args)
+		require.Equal(t, code, 1, "err: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.ErrorWriter.String(), "Missing required '-method' flag")
+	})
+
+	t.Run("token-sink-file is required", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd := New(ui)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-method=test",
+		}
+
+		code := cmd.Run(args)
+		require.Equal(t, code, 1, "err: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.ErrorWriter.String(), "Missing required '-token-sink-file' flag")
+	})
+
+	t.Run("bearer-token-file is required", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		cmd := New(ui)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-method=test",
+			"-token-sink-file", tokenSinkFile,
+		}
+
+		code := cmd.Run(args)
+		require.Equal(t, code, 1, "err: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.ErrorWriter.String(), "Missing required '-bearer-token-file' flag")
+	})
+
+	t.Run("bearer-token-file is empty", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(bearerTokenFile, []byte(""), 0600))
+
+		ui := cli.NewMockUi()
+		cmd := New(ui)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-method=test",
+			"-token-sink-file", tokenSinkFile,
+			"-bearer-token-file", bearerTokenFile,
+		}
+
+		code := cmd.Run(args)
+		require.Equal(t, code, 1, "err: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.ErrorWriter.String(), "No bearer token found in")
+	})
+
+	require.NoError(t, ioutil.WriteFile(bearerTokenFile, []byte("demo-token"), 0600))
+
+	t.Run("try login with no method configured", func(t *testing.T) {
+		defer os.Remove(tokenSinkFile)
+		ui := cli.NewMockUi()
+		cmd := New(ui)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-method=test",
+			"-token-sink-file", tokenSinkFile,
+			"-bearer-token-file", bearerTokenFile,
+		}
+
+		code := cmd.Run(args)
+		require.Equal(t, code, 1, "err: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.ErrorWriter.String(), "403 (ACL not found)")
+	})
+
+	testSessionID := testauth.StartSession()
+	defer testauth.ResetSession(testSessionID)
+
+	testauth.InstallSessionToken(
+		testSessionID,
+		"demo-token",
+		map[string]string{"service": "demo"},
+	)
+
+	{
+		_, _, err := client.ACL().AuthMethodCreate(
+			&api.ACLAuthMethod{
+				Name: "test",
+				Type: "testing",
+				Config: map[string]interface{}{
+					"SessionID": testSessionID,
+					"Fields":    []string{"service"},
+				},
+			},
+			&api.WriteOptions{Token: "root"},
+		)
+		require.NoError(t, err)
+	}
+
+	t.Run("try login with method configured but no binding rules", func(t *testing.T) {
+		defer os.Remove(tokenSinkFile)
+		ui := cli.NewMockUi()
+		cmd := New(ui)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-method=test",
+			"-token-sink-file", tokenSinkFile,
+			"-bearer-token-file", bearerTokenFile,
+		}
+
+		code := cmd.Run(args)
+		require.Equal(t, code, 1, "err: %s", ui.ErrorWriter.String())
+		require.Contains(t, ui.ErrorWriter.String(), "403 (Permission denied)")
+	})
+
+	{
+		_, _, err := client.ACL().BindingRuleCreate(
+			&api.ACLBindingRule{
+				AuthMethod: "test",
+				BindType:   api.BindingRuleBindTypeService,
+				BindName:   "${value.service}",
+				Selector:   "value.service == demo",
+			},
+			&api.WriteOptions{Token: "root"},
+		)
+		require.NoError(t, err)
+	}
+
+	t.Run("try login with method configured and binding rules", func(t *testing.T) {
+		defer os.Remove(tokenSinkFile)
+		ui := cli.NewMockUi()
+		cmd := New(ui)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-method=test",
+			"-token-sink-file", tokenSinkFile,
+			"-bearer-token-file", bearerTokenFile,
+		}
+
+		code := cmd.Run(args)
+		require.Equal(t, 0, code, "err: %s", ui.ErrorWriter.String())
+		require.Empty(t, ui.ErrorWriter.String())
+		require.Empty(t, ui.OutputWriter.String())
+
+		raw, err := ioutil.ReadFile(tokenSinkFile)
+		require.NoError(t, err)
+
+		token := strings.TrimSpace(string(raw))
+		require.Len(t, token, 36, "must be a valid uid: %s", token)
+
+		self, _, err := client.ACL().TokenReadSelf(&api.QueryOptions{Token: token})
+		require.NoError(t, err)
+		require.Equal(t, "test", self.AuthMethod)
+		require.True(t, self.Local)
+		require.Len(t, self.ServiceIdentities, 1)
+		require.Equal(t, "demo", self.ServiceIdentities[0].ServiceName)
+	})
+}
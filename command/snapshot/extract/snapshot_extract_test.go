@@ -0,0 +1,188 @@
+package extract
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/kv/impexp"
+	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotExtractCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestSnapshotExtractCommand_Validation(t *testing.T) {
+	t.Parallel()
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"no file": {
+			[]string{},
+			"Missing FILE argument",
+		},
+		"extra args": {
+			[]string{"foo", "bar", "baz"},
+			"Too many arguments",
+		},
+		"bad type": {
+			[]string{"-type=nope", "foo"},
+			"Invalid type",
+		},
+		"prefix without kv": {
+			[]string{"-type=intentions", "-prefix=foo", "foo"},
+			"can only be used with -type=kv",
+		},
+	}
+
+	for name, tc := range cases {
+		// Ensure our buffer is always clear
+		if ui.ErrorWriter != nil {
+			ui.ErrorWriter.Reset()
+		}
+		if ui.OutputWriter != nil {
+			ui.OutputWriter.Reset()
+		}
+
+		code := c.Run(tc.args)
+		if code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+
+		output := ui.ErrorWriter.String()
+		if !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestSnapshotExtractCommand(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), `
+		primary_datacenter = "dc1"
+		acl {
+			enabled = true
+			tokens {
+				master = "root"
+			}
+		}`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	config := api.DefaultConfig()
+	config.Address = a.HTTPAddr()
+	config.Token = "root"
+	client, err := api.NewClient(config)
+	require.NoError(t, err)
+
+	// Write some data of each type.
+	for _, key := range []string{"foo/bar", "foo/baz", "zip"} {
+		_, err := client.KV().Put(&api.KVPair{Key: key, Flags: 42, Value: []byte(key)}, nil)
+		require.NoError(t, err)
+	}
+	_, _, err = client.ACL().PolicyCreate(&api.ACLPolicy{
+		Name:  "web",
+		Rules: `service "web" { policy = "write" }`,
+	}, nil)
+	require.NoError(t, err)
+	_, _, err = client.PreparedQuery().Create(&api.PreparedQueryDefinition{
+		Name:    "web",
+		Service: api.ServiceQuery{Service: "web"},
+	}, nil)
+	require.NoError(t, err)
+	_, _, err = client.Connect().IntentionCreate(&api.Intention{
+		SourceName:      "web",
+		DestinationName: "db",
+		Action:          api.IntentionActionAllow,
+	}, nil)
+	require.NoError(t, err)
+
+	dir := testutil.TempDir(t, "snapshot")
+	defer os.RemoveAll(dir)
+
+	file := path.Join(dir, "backup.tgz")
+
+	// Save a snapshot of the current Consul state
+	f, err := os.Create(file)
+	require.NoError(t, err)
+	snap, _, err := client.Snapshot().Save(nil)
+	if err != nil {
+		f.Close()
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := io.Copy(f, snap); err != nil {
+		f.Close()
+		t.Fatalf("err: %v", err)
+	}
+	require.NoError(t, f.Close())
+
+	// The snapshot is read without contacting the agent.
+	extract := func(t *testing.T, args ...string) []byte {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		code := c.Run(append(args, file))
+		require.Equal(t, 0, code, ui.ErrorWriter.String())
+		return ui.OutputWriter.Bytes()
+	}
+
+	t.Run("kv", func(t *testing.T) {
+		var entries []*impexp.Entry
+		require.NoError(t, json.Unmarshal(extract(t, "-prefix=/foo/"), &entries))
+		require.Equal(t, []*impexp.Entry{
+			impexp.ToEntry(&api.KVPair{Key: "foo/bar", Flags: 42, Value: []byte("foo/bar")}),
+			impexp.ToEntry(&api.KVPair{Key: "foo/baz", Flags: 42, Value: []byte("foo/baz")}),
+		}, entries)
+
+		require.NoError(t, json.Unmarshal(extract(t), &entries))
+		require.Len(t, entries, 3)
+	})
+
+	t.Run("acl-policies", func(t *testing.T) {
+		var policies []*api.ACLPolicy
+		require.NoError(t, json.Unmarshal(extract(t, "-type=acl-policies"), &policies))
+
+		var found bool
+		for _, policy := range policies {
+			if policy.Name == "web" {
+				found = true
+				require.Equal(t, `service "web" { policy = "write" }`, policy.Rules)
+			}
+		}
+		require.True(t, found, "policy not found in %v", policies)
+	})
+
+	t.Run("prepared-queries", func(t *testing.T) {
+		var queries []*api.PreparedQueryDefinition
+		require.NoError(t, json.Unmarshal(extract(t, "-type=prepared-queries"), &queries))
+		require.Len(t, queries, 1)
+		require.Equal(t, "web", queries[0].Name)
+		require.Equal(t, "web", queries[0].Service.Service)
+	})
+
+	t.Run("intentions", func(t *testing.T) {
+		var intentions []*api.Intention
+		require.NoError(t, json.Unmarshal(extract(t, "-type=intentions"), &intentions))
+		require.Len(t, intentions, 1)
+		require.Equal(t, "web", intentions[0].SourceName)
+		require.Equal(t, "db", intentions[0].DestinationName)
+		require.Equal(t, api.IntentionActionAllow, intentions[0].Action)
+		require.Equal(t, structs.IntentionDefaultNamespace, intentions[0].SourceNS)
+	})
+}
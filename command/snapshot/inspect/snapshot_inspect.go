@@ -1,17 +1,33 @@
 package inspect
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"sort"
+	"strings"
 	"text/tabwriter"
 
+	"github.com/hashicorp/consul/agent/consul/fsm"
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/hashicorp/consul/snapshot"
+	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/hashicorp/raft"
 	"github.com/mitchellh/cli"
 )
 
+const (
+	// PrettyFormat and JSONFormat are the supported output formats.
+	PrettyFormat = "pretty"
+	JSONFormat   = "json"
+)
+
 func New(ui cli.Ui) *cmd {
 	c := &cmd{UI: ui}
 	c.init()
@@ -22,10 +38,23 @@ type cmd struct {
 	UI    cli.Ui
 	flags *flag.FlagSet
 	help  string
+
+	// flags
+	format  string
+	kvDepth int
+	kvTop   int
 }
 
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.format, "format", PrettyFormat,
+		fmt.Sprintf("Output format {%s|%s}.", PrettyFormat, JSONFormat))
+	c.flags.IntVar(&c.kvDepth, "kv-depth", 2,
+		"Number of path segments of the KV keys to group the sizes by. "+
+			"Defaults to 2.")
+	c.flags.IntVar(&c.kvTop, "kv-top", 10,
+		"Number of the largest KV prefixes to display, or 0 to display all "+
+			"of them. Defaults to 10.")
 	c.help = flags.Usage(help, c.flags)
 }
 
@@ -48,6 +77,19 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	if c.format != PrettyFormat && c.format != JSONFormat {
+		c.UI.Error(fmt.Sprintf("Invalid format %q. Must be one of %q or %q", c.format, PrettyFormat, JSONFormat))
+		return 1
+	}
+	if c.kvDepth < 1 {
+		c.UI.Error("The -kv-depth flag must be at least 1")
+		return 1
+	}
+	if c.kvTop < 0 {
+		c.UI.Error("The -kv-top flag must not be negative")
+		return 1
+	}
+
 	// Open the file.
 	f, err := os.Open(file)
 	if err != nil {
@@ -56,29 +98,277 @@ func (c *cmd) Run(args []string) int {
 	}
 	defer f.Close()
 
-	meta, err := snapshot.Verify(f)
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	state, meta, err := snapshot.Read(logger, f)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error verifying snapshot: %s", err))
+		c.UI.Error(fmt.Sprintf("Error reading snapshot: %s", err))
 		return 1
 	}
+	defer func() {
+		if err := state.Close(); err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to close temp snapshot: %v", err))
+		}
+		if err := os.Remove(state.Name()); err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to clean up temp snapshot: %v", err))
+		}
+	}()
 
-	var b bytes.Buffer
-	tw := tabwriter.NewWriter(&b, 0, 2, 6, ' ', 0)
-	fmt.Fprintf(tw, "ID\t%s\n", meta.ID)
-	fmt.Fprintf(tw, "Size\t%d\n", meta.Size)
-	fmt.Fprintf(tw, "Index\t%d\n", meta.Index)
-	fmt.Fprintf(tw, "Term\t%d\n", meta.Term)
-	fmt.Fprintf(tw, "Version\t%d\n", meta.Version)
-	if err = tw.Flush(); err != nil {
-		c.UI.Error(fmt.Sprintf("Error rendering snapshot info: %s", err))
+	info, err := c.inspect(meta, state)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error decoding snapshot: %s", err))
 		return 1
 	}
 
-	c.UI.Info(b.String())
+	var out string
+	switch c.format {
+	case JSONFormat:
+		b, err := json.MarshalIndent(info, "", "    ")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error rendering snapshot info: %s", err))
+			return 1
+		}
+		out = string(b)
+	default:
+		out, err = c.formatPretty(info)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error rendering snapshot info: %s", err))
+			return 1
+		}
+	}
+
+	c.UI.Info(out)
 
 	return 0
 }
 
+// SnapshotInfo is the output of the inspect command.
+type SnapshotInfo struct {
+	Meta       *raft.SnapshotMeta
+	Stats      []TypeStats
+	TotalCount int
+	TotalSize  int
+	KVPrefixes []PrefixStats
+	Tombstones int
+}
+
+// TypeStats are the number of records and their encoded size in bytes for a
+// single message type in the FSM state.
+type TypeStats struct {
+	Name  string
+	Count int
+	Size  int
+}
+
+// PrefixStats are the number of KV entries and their encoded size in bytes
+// under a key prefix.
+type PrefixStats struct {
+	Prefix string
+	Count  int
+	Size   int
+}
+
+// inspect decodes the FSM state and collects its statistics.
+func (c *cmd) inspect(meta *raft.SnapshotMeta, state io.Reader) (*SnapshotInfo, error) {
+	cr := &countingReader{r: bufio.NewReader(state)}
+
+	types := make(map[structs.MessageType]*TypeStats)
+	prefixes := make(map[string]*PrefixStats)
+	info := &SnapshotInfo{Meta: meta}
+	handler := func(header *fsm.SnapshotHeader, msg structs.MessageType, dec *codec.Decoder) error {
+		// The message type byte was read before the handler was called.
+		start := cr.read - 1
+
+		var key string
+		switch msg {
+		case structs.KVSRequestType:
+			var entry structs.DirEntry
+			if err := dec.Decode(&entry); err != nil {
+				return err
+			}
+			key = entry.Key
+
+		case structs.TombstoneRequestType:
+			var entry structs.DirEntry
+			if err := dec.Decode(&entry); err != nil {
+				return err
+			}
+			info.Tombstones++
+
+		default:
+			var val interface{}
+			if err := dec.Decode(&val); err != nil {
+				return err
+			}
+		}
+		size := cr.read - start
+
+		s, ok := types[msg]
+		if !ok {
+			s = &TypeStats{Name: typeName(msg)}
+			types[msg] = s
+		}
+		s.Count++
+		s.Size += size
+		info.TotalCount++
+		info.TotalSize += size
+
+		if msg == structs.KVSRequestType {
+			prefix := kvPrefix(key, c.kvDepth)
+			p, ok := prefixes[prefix]
+			if !ok {
+				p = &PrefixStats{Prefix: prefix}
+				prefixes[prefix] = p
+			}
+			p.Count++
+			p.Size += size
+		}
+		return nil
+	}
+	if err := fsm.ReadSnapshot(cr, handler); err != nil {
+		return nil, err
+	}
+
+	for _, s := range types {
+		info.Stats = append(info.Stats, *s)
+	}
+	sort.Slice(info.Stats, func(i, j int) bool {
+		if info.Stats[i].Size != info.Stats[j].Size {
+			return info.Stats[i].Size > info.Stats[j].Size
+		}
+		return info.Stats[i].Name < info.Stats[j].Name
+	})
+
+	for _, p := range prefixes {
+		info.KVPrefixes = append(info.KVPrefixes, *p)
+	}
+	sort.Slice(info.KVPrefixes, func(i, j int) bool {
+		if info.KVPrefixes[i].Size != info.KVPrefixes[j].Size {
+			return info.KVPrefixes[i].Size > info.KVPrefixes[j].Size
+		}
+		return info.KVPrefixes[i].Prefix < info.KVPrefixes[j].Prefix
+	})
+	if c.kvTop > 0 && len(info.KVPrefixes) > c.kvTop {
+		info.KVPrefixes = info.KVPrefixes[:c.kvTop]
+	}
+
+	return info, nil
+}
+
+func (c *cmd) formatPretty(info *SnapshotInfo) (string, error) {
+	var b bytes.Buffer
+	tw := tabwriter.NewWriter(&b, 0, 2, 6, ' ', 0)
+	fmt.Fprintf(tw, "ID\t%s\n", info.Meta.ID)
+	fmt.Fprintf(tw, "Size\t%d\n", info.Meta.Size)
+	fmt.Fprintf(tw, "Index\t%d\n", info.Meta.Index)
+	fmt.Fprintf(tw, "Term\t%d\n", info.Meta.Term)
+	fmt.Fprintf(tw, "Version\t%d\n", info.Meta.Version)
+	fmt.Fprintf(tw, "Tombstones\t%d\n", info.Tombstones)
+	if err := tw.Flush(); err != nil {
+		return "", err
+	}
+
+	b.WriteString("\n")
+	tw = tabwriter.NewWriter(&b, 8, 8, 6, ' ', 0)
+	fmt.Fprintln(tw, "Type\tCount\tSize")
+	fmt.Fprintln(tw, "----\t----\t----")
+	for _, s := range info.Stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", s.Name, s.Count, s.Size)
+	}
+	fmt.Fprintln(tw, "----\t----\t----")
+	fmt.Fprintf(tw, "Total\t%d\t%d\n", info.TotalCount, info.TotalSize)
+	if err := tw.Flush(); err != nil {
+		return "", err
+	}
+
+	if len(info.KVPrefixes) > 0 {
+		b.WriteString("\n")
+		tw = tabwriter.NewWriter(&b, 8, 8, 6, ' ', 0)
+		fmt.Fprintln(tw, "KV Prefix\tCount\tSize")
+		fmt.Fprintln(tw, "----\t----\t----")
+		for _, p := range info.KVPrefixes {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", p.Prefix, p.Count, p.Size)
+		}
+		if err := tw.Flush(); err != nil {
+			return "", err
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// kvPrefix returns the first depth path segments of the key, including the
+// trailing slash if the key has more segments.
+func kvPrefix(key string, depth int) string {
+	idx := 0
+	for i := 0; i < depth; i++ {
+		next := strings.Index(key[idx:], "/")
+		if next == -1 {
+			return key
+		}
+		idx += next + 1
+	}
+	return key[:idx]
+}
+
+// typeNames are the display names of the message types found in snapshots.
+var typeNames = map[structs.MessageType]string{
+	structs.RegisterRequestType:          "Register",
+	structs.KVSRequestType:               "KVS",
+	structs.SessionRequestType:           "Session",
+	structs.ACLRequestType:               "ACL",
+	structs.TombstoneRequestType:         "Tombstone",
+	structs.CoordinateBatchUpdateType:    "CoordinateBatchUpdate",
+	structs.PreparedQueryRequestType:     "PreparedQuery",
+	structs.AutopilotRequestType:         "Autopilot",
+	structs.AreaRequestType:              "Area",
+	structs.ACLBootstrapRequestType:      "ACLBootstrap",
+	structs.IntentionRequestType:         "Intention",
+	structs.ConnectCARequestType:         "ConnectCA",
+	structs.ConnectCAProviderStateType:   "ConnectCAProviderState",
+	structs.ConnectCAConfigType:          "ConnectCAConfig",
+	structs.IndexRequestType:             "Index",
+	structs.ACLTokenSetRequestType:       "ACLToken",
+	structs.ACLPolicySetRequestType:      "ACLPolicy",
+	structs.ConfigEntryRequestType:       "ConfigEntry",
+	structs.ACLRoleSetRequestType:        "ACLRole",
+	structs.ACLAuthMethodSetRequestType:  "ACLAuthMethod",
+	structs.ACLBindingRuleSetRequestType: "ACLBindingRule",
+	structs.KVSHistoryRequestType:        "KVSHistory",
+	structs.DNSSECKeyRequestType:         "DNSSECKey",
+}
+
+// typeName returns the display name of a message type, stripping the flag
+// used for types that may be ignored by older versions.
+func typeName(msg structs.MessageType) string {
+	msg &^= structs.IgnoreUnknownTypeFlag
+	if name, ok := typeNames[msg]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown(%d)", msg)
+}
+
+// countingReader counts the bytes read through it. It implements
+// io.ByteReader so the decoder reads exactly the bytes of each record,
+// which makes the counts accurate.
+type countingReader struct {
+	r    *bufio.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.read++
+	}
+	return b, err
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -91,11 +381,18 @@ const synopsis = "Displays information about a Consul snapshot file"
 const help = `
 Usage: consul snapshot inspect [options] FILE
 
-  Displays information about a snapshot file on disk.
+  Displays information about a snapshot file on disk, including the number
+  and size of the records of each type in it, the largest KV prefixes and
+  the number of KV tombstones.
 
   To inspect the file "backup.snap":
 
     $ consul snapshot inspect backup.snap
 
+  To group the KV sizes by the first path segment of the keys and output
+  JSON:
+
+    $ consul snapshot inspect -kv-depth=1 -format=json backup.snap
+
   For a full list of options and examples, please see the Consul documentation.
 `
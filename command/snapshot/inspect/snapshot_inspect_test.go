@@ -1,6 +1,7 @@
 package inspect
 
 import (
+	"encoding/json"
 	"io"
 	"os"
 	"path"
@@ -8,8 +9,10 @@ import (
 	"testing"
 
 	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil"
 	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSnapshotInspectCommand_noTabs(t *testing.T) {
@@ -65,6 +68,16 @@ func TestSnapshotInspectCommand(t *testing.T) {
 	defer a.Shutdown()
 	client := a.Client()
 
+	// Write some KV data and delete a key to leave a tombstone.
+	for _, key := range []string{"foo/bar/baz", "foo/bar/zip", "foo/zap", "bar"} {
+		if _, err := client.KV().Put(&api.KVPair{Key: key, Value: []byte("hello")}, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if _, err := client.KV().Delete("bar", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
 	dir := testutil.TempDir(t, "snapshot")
 	defer os.RemoveAll(dir)
 
@@ -106,9 +119,64 @@ func TestSnapshotInspectCommand(t *testing.T) {
 		"Index",
 		"Term",
 		"Version",
+		"Tombstones      1",
+		"KVS",
+		"Register",
+		"Total",
+		"foo/bar/",
+		"foo/zap",
 	} {
 		if !strings.Contains(output, key) {
 			t.Fatalf("bad %#v, missing %q", output, key)
 		}
 	}
+
+	// Inspect it again with JSON output.
+	ui = cli.NewMockUi()
+	c = New(ui)
+	args = []string{"-format=json", "-kv-depth=1", file}
+
+	code = c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	var info SnapshotInfo
+	require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &info))
+	require.Equal(t, 1, info.Tombstones)
+	require.Len(t, info.KVPrefixes, 1)
+	require.Equal(t, "foo/", info.KVPrefixes[0].Prefix)
+	require.Equal(t, 3, info.KVPrefixes[0].Count)
+
+	var total int
+	var kvs *TypeStats
+	for i, s := range info.Stats {
+		total += s.Size
+		if s.Name == "KVS" {
+			kvs = &info.Stats[i]
+		}
+	}
+	require.NotNil(t, kvs)
+	require.Equal(t, 3, kvs.Count)
+	require.Equal(t, kvs.Size, info.KVPrefixes[0].Size)
+	require.Equal(t, info.TotalSize, total)
+}
+
+func TestSnapshotInspectCommand_kvPrefix(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		key    string
+		depth  int
+		prefix string
+	}{
+		{"foo", 1, "foo"},
+		{"foo/bar", 1, "foo/"},
+		{"foo/bar", 2, "foo/bar"},
+		{"foo/bar/baz", 2, "foo/bar/"},
+		{"foo/", 2, "foo/"},
+		{"/foo", 1, "/"},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.prefix, kvPrefix(tc.key, tc.depth), "%s at depth %d", tc.key, tc.depth)
+	}
 }
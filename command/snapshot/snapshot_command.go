@@ -23,14 +23,14 @@ func (c *cmd) Help() string {
 	return flags.Usage(help, nil)
 }
 
-const synopsis = "Saves, restores and inspects snapshots of Consul server state"
+const synopsis = "Saves, restores, inspects and extracts snapshots of Consul server state"
 const help = `
 Usage: consul snapshot <subcommand> [options] [args]
 
-  This command has subcommands for saving, restoring, and inspecting the state
-  of the Consul servers for disaster recovery. These are atomic, point-in-time
-  snapshots which include key/value entries, service catalog, prepared queries,
-  sessions, and ACLs.
+  This command has subcommands for saving, restoring, inspecting and extracting
+  the state of the Consul servers for disaster recovery. These are atomic,
+  point-in-time snapshots which include key/value entries, service catalog,
+  prepared queries, sessions, and ACLs.
 
   If ACLs are enabled, a management token must be supplied in order to perform
   snapshot operations.
@@ -47,6 +47,10 @@ Usage: consul snapshot <subcommand> [options] [args]
 
       $ consul snapshot inspect backup.snap
 
+  Extract the KV entries under a prefix from a snapshot:
+
+      $ consul snapshot extract -prefix=vault/ backup.snap
+
   Run a daemon process that locally saves a snapshot every hour (available only in
   Consul Enterprise) :
 
@@ -46,12 +46,13 @@ type cmd struct {
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.StringVar(&c.watchType, "type", "",
-		"Specifies the watch type. One of key, keyprefix, services, nodes, "+
-			"service, checks, or event.")
+		"Specifies the watch type. One of key, keyprefix, keyprefix_changes, "+
+			"services, nodes, service, checks, or event.")
 	c.flags.StringVar(&c.key, "key", "",
 		"Specifies the key to watch. Only for 'key' type.")
 	c.flags.StringVar(&c.prefix, "prefix", "",
-		"Specifies the key prefix to watch. Only for 'keyprefix' type.")
+		"Specifies the key prefix to watch. Only for 'keyprefix' and "+
+			"'keyprefix_changes' types.")
 	c.flags.StringVar(&c.service, "service", "",
 		"Specifies the service to watch. Required for 'service' type, "+
 			"optional for 'checks' type.")
@@ -31,6 +31,7 @@ require (
 	github.com/containerd/continuity v0.0.0-20181203112020-004b46473808 // indirect
 	github.com/coredns/coredns v1.1.2
 	github.com/denisenkom/go-mssqldb v0.0.0-20180620032804-94c9c97e8c9f // indirect
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/digitalocean/godo v1.10.0 // indirect
 	github.com/docker/go-connections v0.3.0
 	github.com/docker/go-units v0.3.3 // indirect
@@ -43,6 +44,7 @@ require (
 	github.com/go-sql-driver/mysql v0.0.0-20180618115901-749ddf1598b4 // indirect
 	github.com/go-test/deep v1.0.1 // indirect
 	github.com/gocql/gocql v0.0.0-20180617115710-e06f8c1bcd78 // indirect
+	github.com/godbus/dbus v4.1.0+incompatible
 	github.com/gogo/googleapis v1.1.0
 	github.com/gogo/protobuf v1.1.1
 	github.com/golang/protobuf v1.2.0
@@ -1,10 +1,13 @@
-package config
+package lib
 
 import (
 	"fmt"
 )
 
-func patchSliceOfMaps(m map[string]interface{}, skip []string) map[string]interface{} {
+// PatchSliceOfMaps converts the []map[string]interface{} values that the HCL
+// decoder produces for blocks into plain maps so they can be decoded into
+// structs. Any keys listed in skip, using dotted paths, are left as slices.
+func PatchSliceOfMaps(m map[string]interface{}, skip []string) map[string]interface{} {
 	return patchValue("", m, skip).(map[string]interface{})
 }
 
@@ -29,7 +32,7 @@ func patchValue(name string, v interface{}, skip []string) interface{} {
 		if len(x) == 0 {
 			return nil
 		}
-		if strSliceContains(name, skip) {
+		if StrContains(skip, name) {
 			for i, y := range x {
 				x[i] = patchValue(name, y, skip)
 			}
@@ -47,7 +50,7 @@ func patchValue(name string, v interface{}, skip []string) interface{} {
 		if len(x) == 0 {
 			return nil
 		}
-		if strSliceContains(name, skip) {
+		if StrContains(skip, name) {
 			for i, y := range x {
 				x[i] = patchValue(name, y, skip).(map[string]interface{})
 			}
@@ -62,12 +65,3 @@ func patchValue(name string, v interface{}, skip []string) interface{} {
 		return v
 	}
 }
-
-func strSliceContains(s string, v []string) bool {
-	for _, vv := range v {
-		if s == vv {
-			return true
-		}
-	}
-	return false
-}
@@ -140,13 +140,15 @@ func Verify(in io.Reader) (*raft.SnapshotMeta, error) {
 	return &metadata, nil
 }
 
-// Restore takes the snapshot from the reader and attempts to apply it to the
-// given Raft instance.
-func Restore(logger *log.Logger, in io.Reader, r *raft.Raft) error {
+// Read decompresses and verifies the snapshot archive from the reader and
+// writes the FSM state in it to a temporary file, which is returned rewound
+// and ready to be read along with the snapshot metadata. This avoids buffering
+// the state in memory. The caller must close and remove the file.
+func Read(logger *log.Logger, in io.Reader) (*os.File, *raft.SnapshotMeta, error) {
 	// Wrap the reader in a gzip decompressor.
 	decomp, err := gzip.NewReader(in)
 	if err != nil {
-		return fmt.Errorf("failed to decompress snapshot: %v", err)
+		return nil, nil, fmt.Errorf("failed to decompress snapshot: %v", err)
 	}
 	defer func() {
 		if err := decomp.Close(); err != nil {
@@ -158,33 +160,54 @@ func Restore(logger *log.Logger, in io.Reader, r *raft.Raft) error {
 	// we can avoid buffering in memory.
 	snap, err := ioutil.TempFile("", "snapshot")
 	if err != nil {
-		return fmt.Errorf("failed to create temp snapshot file: %v", err)
+		return nil, nil, fmt.Errorf("failed to create temp snapshot file: %v", err)
 	}
-	defer func() {
+	cleanup := func() {
 		if err := snap.Close(); err != nil {
 			logger.Printf("[ERR] snapshot: Failed to close temp snapshot: %v", err)
 		}
 		if err := os.Remove(snap.Name()); err != nil {
 			logger.Printf("[ERR] snapshot: Failed to clean up temp snapshot: %v", err)
 		}
-	}()
+	}
 
 	// Read the archive.
 	var metadata raft.SnapshotMeta
 	if err := read(decomp, &metadata, snap); err != nil {
-		return fmt.Errorf("failed to read snapshot file: %v", err)
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to read snapshot file: %v", err)
 	}
 
 	// Sync and rewind the file so it's ready to be read again.
 	if err := snap.Sync(); err != nil {
-		return fmt.Errorf("failed to sync temp snapshot: %v", err)
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to sync temp snapshot: %v", err)
 	}
 	if _, err := snap.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to rewind temp snapshot: %v", err)
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to rewind temp snapshot: %v", err)
+	}
+	return snap, &metadata, nil
+}
+
+// Restore takes the snapshot from the reader and attempts to apply it to the
+// given Raft instance.
+func Restore(logger *log.Logger, in io.Reader, r *raft.Raft) error {
+	snap, metadata, err := Read(logger, in)
+	if err != nil {
+		return err
 	}
+	defer func() {
+		if err := snap.Close(); err != nil {
+			logger.Printf("[ERR] snapshot: Failed to close temp snapshot: %v", err)
+		}
+		if err := os.Remove(snap.Name()); err != nil {
+			logger.Printf("[ERR] snapshot: Failed to clean up temp snapshot: %v", err)
+		}
+	}()
 
 	// Feed the snapshot into Raft.
-	if err := r.Restore(&metadata, snap, 0); err != nil {
+	if err := r.Restore(metadata, snap, 0); err != nil {
 		return fmt.Errorf("Raft error when restoring snapshot: %v", err)
 	}
 
@@ -175,6 +175,28 @@ func TestSnapshot(t *testing.T) {
 		t.Fatalf("bad: %d", metadata.Version)
 	}
 
+	// Read the state out of the snapshot. We have to rewind it after for
+	// the restore.
+	state, readMeta, err := Read(logger, snap)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.Remove(state.Name())
+	defer state.Close()
+	if _, err := snap.file.Seek(0, 0); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if readMeta.Index != metadata.Index {
+		t.Fatalf("bad: %d", readMeta.Index)
+	}
+	var logs [][]byte
+	if err := codec.NewDecoder(state, &codec.MsgpackHandle{}).Decode(&logs); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(logs) != len(expected) {
+		t.Fatalf("bad: %d vs. %d", len(logs), len(expected))
+	}
+
 	// Make a new, independent Raft.
 	after, fsm := makeRaft(t, path.Join(dir, "after"))
 	defer after.Shutdown()
@@ -234,6 +256,15 @@ func TestSnapshot_BadVerify(t *testing.T) {
 	}
 }
 
+func TestSnapshot_BadRead(t *testing.T) {
+	buf := bytes.NewBuffer([]byte("nope"))
+	logger := log.New(os.Stdout, "", 0)
+	_, _, err := Read(logger, buf)
+	if err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestSnapshot_BadRestore(t *testing.T) {
 	dir := testutil.TempDir(t, "snapshot")
 	defer os.RemoveAll(dir)
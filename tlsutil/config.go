@@ -339,6 +339,19 @@ func (c *Configurator) IncomingHTTPSConfig() *tls.Config {
 	return config
 }
 
+// IncomingDNSConfig generates a *tls.Config for incoming DNS-over-TLS
+// connections. Client certificates are only required if VerifyIncoming is
+// set, since DNS clients generally don't have one.
+func (c *Configurator) IncomingDNSConfig() *tls.Config {
+	c.log("IncomingDNSConfig")
+	config := c.commonTLSConfig(false)
+	config.NextProtos = []string{"dot"}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		return c.IncomingDNSConfig(), nil
+	}
+	return config
+}
+
 // IncomingTLSConfig generates a *tls.Config for outgoing TLS connections for
 // checks. This function is separated because there is an extra flag to
 // consider for checks. EnableAgentTLSForChecks and InsecureSkipVerify has to
@@ -671,6 +671,16 @@ func TestConfigurator_IncomingHTTPSConfig(t *testing.T) {
 	require.Equal(t, []string{"h2", "http/1.1"}, c.IncomingHTTPSConfig().NextProtos)
 }
 
+func TestConfigurator_IncomingDNSConfig(t *testing.T) {
+	c := Configurator{base: &Config{VerifyIncomingHTTPS: true, VerifyIncomingRPC: true}}
+	tlsConf := c.IncomingDNSConfig()
+	require.Equal(t, []string{"dot"}, tlsConf.NextProtos)
+	require.Equal(t, tls.NoClientCert, tlsConf.ClientAuth)
+
+	c.base.VerifyIncoming = true
+	require.Equal(t, tls.RequireAndVerifyClientCert, c.IncomingDNSConfig().ClientAuth)
+}
+
 func TestConfigurator_OutgoingTLSConfigForChecks(t *testing.T) {
 	c := Configurator{base: &Config{
 		TLSMinVersion:           "tls12",
@@ -18,6 +18,7 @@ func init() {
 	watchFuncFactory = map[string]watchFactory{
 		"key":                  keyWatch,
 		"keyprefix":            keyPrefixWatch,
+		"keyprefix_changes":    keyPrefixChangesWatch,
 		"services":             servicesWatch,
 		"nodes":                nodesWatch,
 		"service":              serviceWatch,
@@ -87,6 +88,46 @@ func keyPrefixWatch(params map[string]interface{}) (WatcherFunc, error) {
 	return fn, nil
 }
 
+// keyPrefixChangesWatch is used to return a function streaming the changes
+// to the keys under a prefix, rather than listing them all on every change
+func keyPrefixChangesWatch(params map[string]interface{}) (WatcherFunc, error) {
+	stale := false
+	if err := assignValueBool(params, "stale", &stale); err != nil {
+		return nil, err
+	}
+
+	var prefix string
+	if err := assignValue(params, "prefix", &prefix); err != nil {
+		return nil, err
+	}
+	if prefix == "" {
+		return nil, fmt.Errorf("Must specify a single prefix to watch")
+	}
+
+	var changesCh <-chan *consulapi.KVChanges
+	fn := func(p *Plan) (BlockingParamVal, interface{}, error) {
+		// Open the stream on the first call and again after it ended,
+		// resuming it from the last index handled.
+		if changesCh == nil {
+			opts := makeQueryOptionsWithContext(p, stale)
+			ch, _, err := p.client.KV().Stream(prefix, p.stopCh, &opts)
+			if err != nil {
+				p.cancelFunc()
+				return p.lastParamVal, nil, err
+			}
+			changesCh = ch
+		}
+
+		changes, ok := <-changesCh
+		if !ok {
+			changesCh = nil
+			return p.lastParamVal, nil, fmt.Errorf("Stream of prefix %q ended", prefix)
+		}
+		return WaitIndexVal(changes.Index), changes, nil
+	}
+	return fn, nil
+}
+
 // servicesWatch is used to watch the list of available services
 func servicesWatch(params map[string]interface{}) (WatcherFunc, error) {
 	stale := false
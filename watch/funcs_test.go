@@ -193,6 +193,60 @@ func TestKeyPrefixWatch(t *testing.T) {
 	wg.Wait()
 }
 
+func TestKeyPrefixChangesWatch(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	invoke := makeInvokeCh()
+	plan := mustParse(t, `{"type":"keyprefix_changes", "prefix":"foo/"}`)
+	plan.Handler = func(idx uint64, raw interface{}) {
+		if raw == nil {
+			return // ignore
+		}
+		v, ok := raw.(*consulapi.KVChanges)
+		if !ok || len(v.Events) == 0 {
+			return
+		}
+		if v.Events[0].Type != consulapi.KVEventPut || v.Events[0].Key != "foo/bar" || v.Index != idx {
+			invoke <- errBadContent
+			return
+		}
+		invoke <- nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		kv := a.Client().KV()
+
+		time.Sleep(20 * time.Millisecond)
+		pair := &consulapi.KVPair{
+			Key: "foo/bar",
+		}
+		if _, err := kv.Put(pair, nil); err != nil {
+			t.Errorf("err: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := plan.Run(a.HTTPAddr()); err != nil {
+			t.Errorf("err: %v", err)
+		}
+	}()
+
+	if err := <-invoke; err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	plan.Stop()
+	wg.Wait()
+}
+
 func TestServicesWatch(t *testing.T) {
 	t.Parallel()
 	a := agent.NewTestAgent(t, t.Name(), ``)